@@ -0,0 +1,84 @@
+// Command keystore manages the keystore file of the file secret store, whose secrets
+// integrations and linked accounts reference as "file:<name>".
+//
+//	keystore set NAME        # reads the secret from standard input
+//	keystore delete NAME
+//	keystore list
+//
+// The keystore is the file SECRETS_FILE_PATH names, encrypted with ENCRYPTION_KEY. Secrets
+// of linked accounts must be named linked-accounts/<owner ID>/...
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/AkashKesav/API2SDK/configs"
+	"github.com/AkashKesav/API2SDK/internal/crypto"
+	"github.com/AkashKesav/API2SDK/internal/secrets"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: keystore set NAME | delete NAME | list")
+	}
+	flag.Parse()
+
+	appConfigs, err := configs.LoadConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if appConfigs.SecretsFilePath == "" {
+		log.Fatal("SECRETS_FILE_PATH is not set")
+	}
+	keyring, err := crypto.KeyringFromConfig(appConfigs)
+	if err != nil {
+		log.Fatalf("invalid encryption keys: %v", err)
+	}
+	store := secrets.NewFileStore(appConfigs.SecretsFilePath, keyring)
+
+	switch {
+	case flag.NArg() == 2 && flag.Arg(0) == "set":
+		value, err := readSecret(os.Stdin)
+		if err != nil {
+			log.Fatalf("failed to read the secret: %v", err)
+		}
+		if err := store.Set(flag.Arg(1), value); err != nil {
+			log.Fatalf("failed to store %s: %v", flag.Arg(1), err)
+		}
+		fmt.Printf("stored %s:%s\n", secrets.BackendFile, flag.Arg(1))
+	case flag.NArg() == 2 && flag.Arg(0) == "delete":
+		if err := store.Delete(flag.Arg(1)); err != nil {
+			log.Fatalf("failed to delete %s: %v", flag.Arg(1), err)
+		}
+	case flag.NArg() == 1 && flag.Arg(0) == "list":
+		names, err := store.Names()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// readSecret reads a secret from r, without the line break that ends it.
+func readSecret(r io.Reader) (string, error) {
+	value, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	value = strings.TrimRight(value, "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("the secret is empty")
+	}
+	return value, nil
+}
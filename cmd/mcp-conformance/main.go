@@ -0,0 +1,122 @@
+// Command mcp-conformance checks an MCP server against the protocol.
+//
+//	mcp-conformance [flags] command [args...]        # stdio server run as a subprocess
+//	mcp-conformance -transport sse -url URL           # HTTP+SSE server
+//	mcp-conformance -transport streamable-http -url URL
+//
+// It exits with status 1 when a check fails.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/mcp/conformance"
+	"go.uber.org/zap"
+)
+
+func main() {
+	transport := flag.String("transport", "stdio", "transport of the server: stdio, sse or streamable-http")
+	url := flag.String("url", "", "URL of the event stream (sse) or endpoint (streamable-http)")
+	timeout := flag.Duration("timeout", conformance.DefaultTimeout, "time limit of each check")
+	concurrency := flag.Int("concurrency", conformance.DefaultConcurrency, "simultaneous requests of the concurrency check")
+	payloadBytes := flag.Int("payload-bytes", conformance.DefaultLargePayloadBytes, "size of the request of the large payload check")
+	checks := flag.String("checks", "", "comma separated checks to run; all when empty")
+	list := flag.Bool("list", false, "list the checks and exit")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	verbose := flag.Bool("v", false, "log the server's stderr and check details")
+
+	headers := http.Header{}
+	flag.Func("header", "HTTP header sent to the server, as 'Name: value'; repeatable", func(value string) error {
+		name, headerValue, ok := strings.Cut(value, ":")
+		if !ok {
+			return fmt.Errorf("header %q is not 'Name: value'", value)
+		}
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(headerValue))
+		return nil
+	})
+	var toolCalls []conformance.ToolCall
+	flag.Func("tool-call", "tool to call and check, as 'name' or 'name={json arguments}'; repeatable", func(value string) error {
+		name, args, hasArgs := strings.Cut(value, "=")
+		call := conformance.ToolCall{Name: name}
+		if hasArgs {
+			if err := json.Unmarshal([]byte(args), &call.Arguments); err != nil {
+				return fmt.Errorf("invalid arguments of %s: %w", name, err)
+			}
+		}
+		toolCalls = append(toolCalls, call)
+		return nil
+	})
+	flag.Parse()
+
+	if *list {
+		for _, name := range conformance.CheckNames() {
+			fmt.Println(name)
+		}
+		return
+	}
+
+	logger := zap.NewNop()
+	if *verbose {
+		var err error
+		if logger, err = zap.NewDevelopment(); err != nil {
+			log.Fatalf("can't initialize zap logger: %v", err)
+		}
+		defer logger.Sync()
+	}
+
+	var dial conformance.Dialer
+	switch *transport {
+	case "stdio":
+		if flag.NArg() == 0 {
+			log.Fatal("the command of the stdio server is required")
+		}
+		dial = conformance.Command(logger, flag.Arg(0), flag.Args()[1:]...)
+	case "sse", "streamable-http":
+		if *url == "" {
+			log.Fatalf("-url is required for the %s transport", *transport)
+		}
+		if *transport == "sse" {
+			dial = conformance.SSE(*url, headers, nil)
+		} else {
+			dial = conformance.StreamableHTTP(*url, headers, nil)
+		}
+	default:
+		log.Fatalf("unsupported transport %q: use stdio, sse or streamable-http", *transport)
+	}
+
+	options := conformance.Options{
+		Timeout:           *timeout,
+		Concurrency:       *concurrency,
+		LargePayloadBytes: *payloadBytes,
+		ToolCalls:         toolCalls,
+		Logger:            logger,
+	}
+	if *checks != "" {
+		options.Checks = strings.Split(*checks, ",")
+	}
+
+	started := time.Now()
+	report, err := conformance.Run(context.Background(), dial, options)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		fmt.Print(report)
+		fmt.Printf("finished in %s\n", time.Since(started).Round(time.Millisecond))
+	}
+	if report.Failed() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,89 @@
+// Command rotate-keys re-encrypts the stored secrets under the active master key, and encrypts
+// those still stored in plaintext.
+//
+//	ENCRYPTION_KEY_ID=k2 ENCRYPTION_KEY=... ENCRYPTION_PREVIOUS_KEYS=k1:... rotate-keys [-dry-run]
+//
+// Every key stored values are encrypted with must be configured. The keystore file of the file
+// secret store (SECRETS_FILE_PATH) is rotated too. Once a rotation reports no failures and no
+// conflicts, the previous keys can be removed from the configuration. It exits with status 1
+// when a value could not be rotated.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/AkashKesav/API2SDK/configs"
+	"github.com/AkashKesav/API2SDK/internal/crypto"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"github.com/AkashKesav/API2SDK/internal/secrets"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"go.uber.org/zap"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "count the values to rotate without changing them")
+	verbose := flag.Bool("v", false, "log progress and the values that fail to decrypt")
+	flag.Parse()
+
+	logger := zap.NewNop()
+	if *verbose {
+		var err error
+		if logger, err = zap.NewDevelopment(); err != nil {
+			log.Fatalf("can't initialize zap logger: %v", err)
+		}
+		defer logger.Sync()
+	}
+
+	appConfigs, err := configs.LoadConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	configs.InitConfig(appConfigs)
+	keyring, err := crypto.KeyringFromConfig(appConfigs)
+	if err != nil {
+		log.Fatalf("invalid encryption keys: %v", err)
+	}
+	if err := configs.InitDatabase(appConfigs); err != nil {
+		log.Fatalf("failed to initialize database: %v", err)
+	}
+	defer configs.CloseDatabase()
+
+	rotation := services.NewKeyRotationService(repositories.NewRawDocumentRepository(configs.GetDatabase()), keyring, logger)
+	reports, err := rotation.Rotate(context.Background(), *dryRun)
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(out, "COLLECTION\tDOCUMENTS\tROTATED\tENCRYPTED\tCURRENT\tFAILED\tCONFLICTS")
+	failed := false
+	for _, report := range reports {
+		fmt.Fprintf(out, "%s\t%d\t%d\t%d\t%d\t%d\t%d\n", report.Collection, report.Documents, report.Rotated, report.Encrypted, report.Current, report.Failed, report.Conflicts)
+		failed = failed || report.Failed > 0
+	}
+	out.Flush()
+	if *dryRun {
+		fmt.Printf("dry run: nothing was changed; the active key is %q\n", keyring.ActiveKeyID())
+	}
+
+	if err != nil {
+		configs.CloseDatabase()
+		log.Fatalf("rotation failed: %v", err)
+	}
+
+	// The keystore file of the file secret store is encrypted with the same keys
+	if appConfigs.SecretsFilePath != "" && !*dryRun {
+		rotated, err := secrets.NewFileStore(appConfigs.SecretsFilePath, keyring).Rotate()
+		if err != nil {
+			configs.CloseDatabase()
+			log.Fatalf("keystore rotation failed: %v", err)
+		}
+		fmt.Printf("keystore %s: %d secrets rotated\n", appConfigs.SecretsFilePath, rotated)
+	}
+	if failed {
+		configs.CloseDatabase()
+		os.Exit(1)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
@@ -15,11 +16,17 @@ import (
 
 	"github.com/AkashKesav/API2SDK/configs"
 	"github.com/AkashKesav/API2SDK/internal/controllers"
+	"github.com/AkashKesav/API2SDK/internal/crypto"
+	"github.com/AkashKesav/API2SDK/internal/hosting"
 	"github.com/AkashKesav/API2SDK/internal/mcp"
 	"github.com/AkashKesav/API2SDK/internal/middleware"
+	"github.com/AkashKesav/API2SDK/internal/outbound"
 	"github.com/AkashKesav/API2SDK/internal/repositories"
 	"github.com/AkashKesav/API2SDK/internal/routes"
+	"github.com/AkashKesav/API2SDK/internal/secrets"
 	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/AkashKesav/API2SDK/internal/utils"
+	"github.com/go-redis/redis/v8"
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/cors"
 	"github.com/gofiber/fiber/v3/middleware/logger"
@@ -74,6 +81,12 @@ func main() {
 	}
 	configs.InitConfig(appConfigs)
 
+	keyring, err := crypto.KeyringFromConfig(appConfigs)
+	if err != nil {
+		zapLogger.Fatal("Invalid encryption keys", zap.Error(err))
+	}
+	crypto.SetDefaultKeyring(keyring)
+
 	zapLogger.Info("Starting API2SDK server with MongoDB functionality")
 
 	// Initialize Database Connection - required for operation
@@ -88,18 +101,96 @@ func main() {
 	collectionRepo := repositories.NewCollectionRepository(db)
 	platformSettingsRepo := repositories.NewMongoPlatformSettingsRepository(db)
 	sdkRepo := repositories.NewSDKRepository(db, zapLogger)
+	organizationRepo := repositories.NewOrganizationRepository(db)
+	roleRepo := repositories.NewRoleRepository(db)
 	integrationRepo := repositories.NewIntegrationRepository(db)
+	linkedAccountRepo := repositories.NewLinkedAccountRepository(db)
+	toolExecutionRepo := repositories.NewToolExecutionRepository(db)
+	oauthStateRepo := repositories.NewOAuthStateRepository(db)
+	oidcStateRepo := repositories.NewOIDCStateRepository(db)
 	mcpInstanceRepo := repositories.NewMCPInstanceRepository(db)
+	mcpServerRepo := repositories.NewMCPServerRepository(db)
+	promptRepo := repositories.NewPromptRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	accessTokenRepo := repositories.NewAccessTokenRepository(db)
+	auditRepo := repositories.NewAuditRepository(db)
 	zapLogger.Info("All repositories initialized with database")
 
 	// Initialize Services
-	authService := services.NewAuthService(userRepo, zapLogger, appConfigs)
-	var platformSettingsService services.PlatformSettingsService = services.NewPlatformSettingsService(platformSettingsRepo)
+	// Failed logins lock accounts and access tokens are rate limited; without Redis both are
+	// tracked per process
+	var redisClient *redis.Client
+	var accountLockoutService services.AccountLockoutService
+	if appConfigs.RedisAddr != "" {
+		redisClient = redis.NewClient(&redis.Options{
+			Addr:     appConfigs.RedisAddr,
+			Password: appConfigs.RedisPassword,
+			DB:       appConfigs.RedisDB,
+		})
+		pingCtx, cancelPing := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := redisClient.Ping(pingCtx).Err(); err != nil {
+			zapLogger.Warn("Redis is not reachable; login lockouts fail open until it is", zap.Error(err))
+		}
+		cancelPing()
+		accountLockoutService = services.NewAccountLockoutService(redisClient, zapLogger, services.DefaultAccountLockoutConfig)
+	} else {
+		accountLockoutService = services.NewMemoryAccountLockoutService(zapLogger, services.DefaultAccountLockoutConfig)
+	}
+	// Logins, tokens, settings, credentials, SDKs and tool executions are recorded in the audit log
+	auditService := services.NewAuditService(auditRepo, time.Duration(appConfigs.AuditRetentionDays)*24*time.Hour, zapLogger)
+	authService := services.NewAuthService(userRepo, sessionRepo, accountLockoutService, auditService, zapLogger, appConfigs)
+	accessTokenService := services.NewAccessTokenService(accessTokenRepo, userRepo, auditService, zapLogger)
+	var platformSettingsService services.PlatformSettingsService = services.NewPlatformSettingsService(platformSettingsRepo, auditService)
 	userService := services.NewUserService(userRepo, platformSettingsService, zapLogger)
-	integrationService := services.NewIntegrationService(integrationRepo)
-	toolProvider := services.NewToolProviderService(integrationService)
-	mcpInstanceService := services.NewMCPInstanceService(mcpInstanceRepo, integrationService, toolProvider)
-	mcpManager := mcp.NewMCPManager(zapLogger, integrationService, toolProvider)
+	integrationService := services.NewIntegrationService(integrationRepo, auditService)
+	// All traffic to integration APIs goes through one SSRF-hardened client
+	outboundClient := outbound.NewClient(outbound.Config{
+		AllowedHosts:         appConfigs.OutboundAllowedHosts,
+		AllowPrivateNetworks: appConfigs.OutboundAllowPrivateNetworks,
+		Timeout:              time.Duration(appConfigs.OutboundTimeout) * time.Second,
+		MaxRedirects:         appConfigs.OutboundMaxRedirects,
+		MaxResponseBytes:     int64(appConfigs.OutboundMaxResponseMB) << 20,
+	}, utils.GetGlobalMetricsCollector(zapLogger), zapLogger)
+	oauth2Service := services.NewOAuth2Service(integrationService, linkedAccountRepo, oauthStateRepo, appConfigs.PublicBaseURL, outboundClient, zapLogger)
+	secretService := services.NewSecretService(secrets.ResolverFromConfig(appConfigs, keyring), auditService, zapLogger)
+	linkedAccountService := services.NewLinkedAccountService(linkedAccountRepo, toolExecutionRepo, oauth2Service, secretService, auditService, zapLogger)
+	toolProvider := services.NewToolProviderService(integrationService, linkedAccountService, auditService, outboundClient, zapLogger)
+	toolSearchService := services.NewToolSearchService(integrationService, toolProvider, zapLogger)
+	integrationService.OnChange(toolSearchService.InvalidateIntegration)
+	promptService := services.NewPromptService(promptRepo)
+	mcpInstanceService := services.NewMCPInstanceService(mcpInstanceRepo, integrationService, toolProvider, linkedAccountService, outboundClient)
+	// Platform and organization roles grant permissions; organizations share ownership of
+	// collections, SDKs and integrations
+	policyService := services.NewPolicyService(organizationRepo, roleRepo, zapLogger)
+	organizationService := services.NewOrganizationService(organizationRepo, policyService, userRepo, collectionRepo, sdkRepo, zapLogger)
+	// Hosted and bridged MCP servers run as child processes under the same limits
+	processLimits := hosting.Limits{
+		MemoryBytes:  int64(appConfigs.MCPHostingMemoryMB) << 20,
+		CPUPercent:   appConfigs.MCPHostingCPUPercent,
+		MaxProcesses: appConfigs.MCPHostingMaxProcesses,
+		MaxOpenFiles: appConfigs.MCPHostingMaxOpenFiles,
+		CgroupRoot:   appConfigs.MCPHostingCgroupRoot,
+	}
+	// Gateway upstreams are user-supplied URLs, so they get the same address checks as
+	// integration traffic. Event streams stay open; single requests are bounded by their context,
+	// and a stream reaching the response size limit ends and its upstream reconnects.
+	gatewayClient := outbound.NewClient(outbound.Config{
+		AllowedHosts:         appConfigs.OutboundAllowedHosts,
+		AllowPrivateNetworks: appConfigs.OutboundAllowPrivateNetworks,
+		Timeout:              5 * time.Minute,
+		MaxRedirects:         appConfigs.OutboundMaxRedirects,
+		MaxResponseBytes:     int64(appConfigs.OutboundMaxResponseMB) << 20,
+	}, utils.GetGlobalMetricsCollector(zapLogger), zapLogger)
+	gatewayClient.Timeout = 0
+	mcpHostingService, err := services.NewMCPHostingService(mcpInstanceRepo, sdkRepo, integrationService, linkedAccountService, organizationService, services.MCPHostingConfig{
+		Dir:     appConfigs.MCPHostingDir,
+		PortMin: appConfigs.MCPHostingPortMin,
+		PortMax: appConfigs.MCPHostingPortMax,
+		Limits:  processLimits,
+	}, zapLogger)
+	if err != nil {
+		zapLogger.Fatal("Failed to initialize MCP hosting service", zap.Error(err))
+	}
 	zapLogger.Info("All services initialized with database")
 
 	// Create PostmanClient for SDKService
@@ -111,6 +202,11 @@ func main() {
 		openAPIGenPath = "openapi-generator-cli.jar"
 	}
 
+	// The identity provider is configured by administrators and is often on the internal network,
+	// so it is reached without the integrations' outbound restrictions
+	oidcService := services.NewOIDCService(platformSettingsService, oidcStateRepo, userRepo, authService, organizationService, policyService, auditService,
+		appConfigs.PublicBaseURL, &http.Client{Timeout: time.Duration(appConfigs.OutboundTimeout) * time.Second}, zapLogger)
+
 	// Initialize SDK service
 	sdkService, err := services.NewSDKService(
 		sdkRepo,
@@ -120,13 +216,21 @@ func main() {
 		services.PyGenScript,
 		services.PhpGenScript,
 		services.PhpVendorZip,
+		organizationService,
 	)
 	if err != nil {
 		zapLogger.Fatal("Failed to initialize SDK service", zap.Error(err))
 	}
 
 	// Initialize collection service
-	collectionService := services.NewCollectionService(collectionRepo, zapLogger, sdkService)
+	collectionService := services.NewCollectionService(collectionRepo, zapLogger, sdkService, organizationService)
+
+	// MCP servers expose integrations, collections and SDKs as resources
+	resourceService := services.NewResourceService(integrationService, toolProvider, collectionService, sdkRepo)
+	mcpManager := mcp.NewMCPManager(zapLogger, integrationService, toolProvider, toolSearchService, promptService, resourceService, linkedAccountService, mcpServerRepo, mcp.BridgeOptions{
+		AllowedCommands: appConfigs.MCPBridgeAllowedCommands,
+		Limits:          processLimits,
+	}, mcp.GatewayOptions{HTTPClient: gatewayClient})
 
 	// Use configs.GetPostmanAPIKey() to get the key from the initialized global config
 	postmanAPIKey := configs.GetPostmanAPIKey()
@@ -134,6 +238,8 @@ func main() {
 		zapLogger.Warn("POSTMAN_API_KEY is not set in environment. Postman API features will be limited.")
 	}
 	postmanAPIService := services.NewPostmanAPIService(zapLogger, postmanAPIKey)
+	// Linked collections are polled for changes, and their SDKs optionally regenerated
+	postmanSyncService := services.NewPostmanSyncService(repositories.NewPostmanSyncRepository(db), postmanAPIService, sdkRepo, sdkService, auditService, zapLogger)
 
 	// Initialize public API service
 	publicApiService := services.NewPublicAPIService(zapLogger, postmanAPIService, collectionService, db)
@@ -142,13 +248,21 @@ func main() {
 	healthController := controllers.NewHealthController(zapLogger)
 	authController := controllers.NewAuthController(authService, userService, zapLogger)
 	userController := controllers.NewUserController(userService, zapLogger)
-	collectionController := controllers.NewCollectionController(collectionService, zapLogger)
-	adminController := controllers.NewAdminController(userService, services.NewPlatformSettingsService(platformSettingsRepo), sdkService, collectionService, zapLogger)
-	sdkController := controllers.NewSDKController(sdkService, collectionService, services.NewPlatformSettingsService(platformSettingsRepo), zapLogger)
+	collectionController := controllers.NewCollectionController(collectionService, postmanSyncService, zapLogger)
+	adminController := controllers.NewAdminController(userService, platformSettingsService, sdkService, collectionService, zapLogger)
+	sdkController := controllers.NewSDKController(sdkService, collectionService, platformSettingsService, auditService, zapLogger)
 	htmxController := controllers.NewHTMXController(zapLogger, collectionService, postmanAPIService, publicApiService)
 	publicApiController := controllers.NewPublicAPIController(publicApiService, zapLogger)
-	mcpController := controllers.NewMCPController(mcpInstanceService, integrationService, mcpManager, zapLogger)
-	userMCPController := controllers.NewUserMCPController(mcpInstanceService, integrationService)
+	mcpController := controllers.NewMCPController(mcpInstanceService, integrationService, linkedAccountService, mcpHostingService, mcpManager, outboundClient, zapLogger)
+	userMCPController := controllers.NewUserMCPController(mcpInstanceService, integrationService, mcpHostingService, organizationService)
+	linkedAccountController := controllers.NewLinkedAccountController(linkedAccountService, integrationService, zapLogger)
+	oauthController := controllers.NewOAuthController(oauth2Service, linkedAccountService, integrationService, zapLogger)
+	integrationController := controllers.NewIntegrationController(integrationService, promptService, secretService, zapLogger)
+	accessTokenController := controllers.NewAccessTokenController(accessTokenService, zapLogger)
+	organizationController := controllers.NewOrganizationController(organizationService, zapLogger)
+	policyController := controllers.NewPolicyController(policyService, zapLogger)
+	ssoController := controllers.NewSSOController(oidcService, zapLogger)
+	auditController := controllers.NewAuditController(auditService, zapLogger)
 
 	if *transport == "stdio" {
 		zapLogger.Info("Starting server in stdio mode")
@@ -262,7 +376,7 @@ func main() {
 
 		// Enhanced Middleware
 		app.Use(recover.New())
-		app.Use(middleware.AuthSkipMiddleware())
+		app.Use(middleware.RequestContextMiddleware())
 		app.Use(middleware.DefaultSecurityHeadersMiddleware())
 		// Disable input validation in development
 		if !appConfigs.IsDevelopment() {
@@ -285,7 +399,7 @@ func main() {
 		app.Use(cors.New(cors.Config{
 			AllowOrigins:     []string{"http://localhost:3000", "http://localhost:3001", "http://localhost:8080"},
 			AllowMethods:     []string{fiber.MethodGet, fiber.MethodPost, fiber.MethodHead, fiber.MethodPut, fiber.MethodDelete, fiber.MethodPatch, fiber.MethodOptions},
-			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key"},
 			AllowCredentials: true,
 		}))
 
@@ -316,11 +430,43 @@ func main() {
 			publicApiController,
 			mcpController,
 			userMCPController,
+			linkedAccountController,
+			oauthController,
+			integrationController,
+			accessTokenController,
+			organizationController,
+			policyController,
+			ssoController,
+			auditController,
 			authService,
+			policyService,
+			accessTokenService,
+			redisClient,
 			zapLogger,
 			appConfigs,
 		)
 
+		// Restore persisted MCP servers and keep them reconciled
+		managerCtx, stopManager := context.WithCancel(context.Background())
+		go func() {
+			if err := mcpManager.Run(managerCtx); err != nil {
+				zapLogger.Error("MCP server reconciler stopped", zap.Error(err))
+			}
+		}()
+
+		// Expired audit events are pruned hourly
+		go auditService.RunRetention(managerCtx, time.Hour)
+
+		// Linked Postman collections are checked for changes every POSTMAN_SYNC_INTERVAL_MINUTES
+		go postmanSyncService.RunScheduler(managerCtx, time.Duration(appConfigs.PostmanSyncInterval)*time.Minute)
+
+		// Bring hosted MCP servers back up in the background; builds can take a while
+		go func() {
+			if err := mcpHostingService.Restore(managerCtx); err != nil {
+				zapLogger.Error("Failed to restore hosted MCP servers", zap.Error(err))
+			}
+		}()
+
 		// Graceful Shutdown
 		quit := make(chan os.Signal, 1)
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -328,6 +474,12 @@ func main() {
 		go func() {
 			<-quit
 			zapLogger.Info("Shutting down server...")
+			stopManager()
+			// Servers keep their desired state, so they are restored on the next start
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			mcpManager.Shutdown(shutdownCtx)
+			mcpHostingService.Shutdown(shutdownCtx)
+			cancel()
 			if err := app.Shutdown(); err != nil {
 				zapLogger.Fatal("Server forced to shutdown:", zap.Error(err))
 			}
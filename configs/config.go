@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -13,6 +14,8 @@ import (
 type Config struct {
 	// Server Configuration
 	Port string `json:"port"`
+	// PublicBaseURL is the externally reachable origin of the server, used to build OAuth2 callback URLs
+	PublicBaseURL string `json:"public_base_url"`
 
 	// MongoDB Configuration
 	MongoDBURI      string `json:"mongodb_uri"`
@@ -21,16 +24,72 @@ type Config struct {
 	MongoDBPassword string `json:"mongodb_password"`
 
 	// External API Configuration
-	PostmanAPIKey string `json:"postman_api_key"`
+	PostmanAPIKey       string `json:"postman_api_key"`
+	PostmanSyncInterval int    `json:"postman_sync_interval"` // minutes between checks of linked collections; 0 disables the scheduler
 
 	// HTTP Client Configuration
 	HTTPClientTimeout int `json:"http_client_timeout"`
 
+	// Outbound Integration Traffic Configuration
+	OutboundAllowedHosts         []string `json:"outbound_allowed_hosts"` // Hosts, IPs or CIDRs exempt from SSRF filtering
+	OutboundAllowPrivateNetworks bool     `json:"outbound_allow_private_networks"`
+	OutboundTimeout              int      `json:"outbound_timeout"` // seconds
+	OutboundMaxRedirects         int      `json:"outbound_max_redirects"`
+	OutboundMaxResponseMB        int      `json:"outbound_max_response_mb"`
+
+	// Hosted MCP Server Configuration
+	MCPHostingDir          string `json:"mcp_hosting_dir"` // Where deployed artifacts are unpacked and built
+	MCPHostingPortMin      int    `json:"mcp_hosting_port_min"`
+	MCPHostingPortMax      int    `json:"mcp_hosting_port_max"`
+	MCPHostingMemoryMB     int    `json:"mcp_hosting_memory_mb"`
+	MCPHostingCPUPercent   int    `json:"mcp_hosting_cpu_percent"`   // Of one core; applied only with cgroups
+	MCPHostingMaxProcesses int    `json:"mcp_hosting_max_processes"` // Per server; applied only with cgroups
+	MCPHostingMaxOpenFiles int    `json:"mcp_hosting_max_open_files"`
+	MCPHostingCgroupRoot   string `json:"mcp_hosting_cgroup_root"` // Writable cgroup v2 directory; empty disables cgroups
+
+	// Stdio Bridge Configuration
+	MCPBridgeAllowedCommands []string `json:"mcp_bridge_allowed_commands"` // Executables bridge servers may run; empty disables bridge servers
+
 	// Environment
 	Environment string `json:"environment"`
 
-	// Encryption Key
-	EncryptionKey string `json:"encryption_key"`
+	// Encryption Keys; secrets are encrypted under EncryptionKey, and values encrypted under the
+	// retired keys of EncryptionPreviousKeys ("key-id:key" entries) are still decrypted
+	EncryptionKey          string   `json:"encryption_key"`
+	EncryptionKeyID        string   `json:"encryption_key_id"`
+	EncryptionPreviousKeys []string `json:"encryption_previous_keys"`
+	EncryptionStrict       bool     `json:"encryption_strict"` // Refuse stored secrets that are not encrypted
+
+	// Authentication Configuration
+	JWTSecret       string `json:"jwt_secret"`        // Signs access tokens; at least 32 bytes
+	AccessTokenTTL  int    `json:"access_token_ttl"`  // minutes
+	RefreshTokenTTL int    `json:"refresh_token_ttl"` // hours; a session ends this long after login
+
+	// Redis Configuration; empty RedisAddr keeps login lockouts in memory
+	RedisAddr     string `json:"redis_addr"`
+	RedisPassword string `json:"redis_password"`
+	RedisDB       int    `json:"redis_db"`
+
+	// Audit Log Configuration
+	AuditRetentionDays int `json:"audit_retention_days"` // 0 keeps audit events forever
+
+	// Secret Store Configuration; credentials may reference secrets kept in Vault KV (when
+	// VaultAddr is set), a keystore file (when SecretsFilePath is set) or environment variables
+	// (when SecretsEnvPrefix is set, only those starting with it)
+	VaultAddr        string `json:"vault_addr"`
+	VaultToken       string `json:"vault_token"`
+	VaultNamespace   string `json:"vault_namespace"`
+	VaultKVMount     string `json:"vault_kv_mount"` // Mount of the KV version 2 secrets engine
+	SecretsFilePath  string `json:"secrets_file_path"`
+	SecretsEnvPrefix string `json:"secrets_env_prefix"`
+	SecretsCacheTTL  int    `json:"secrets_cache_ttl"` // seconds; 0 reads secrets on every use
+}
+
+// publishedSecrets are secrets that have been published, like the one the repository's .env
+// used to ship with. Anyone can forge access tokens signed with them or decrypt what they
+// encrypt, so they are refused as JWT_SECRET and ENCRYPTION_KEY.
+var publishedSecrets = map[string]bool{
+	"43840c487f1eac1711bb2fd613f1ecf21cfc64e7be5cc1182a291c97b343dc80": true,
 }
 
 // GlobalConfig holds the global configuration instance
@@ -76,7 +135,8 @@ func LoadConfig() (*Config, error) {
 
 	config := &Config{
 		// Server Configuration
-		Port: getEnvOrDefault("API_PORT", "8080"),
+		Port:          getEnvOrDefault("API_PORT", "8080"),
+		PublicBaseURL: getEnvOrDefault("PUBLIC_BASE_URL", ""),
 
 		// MongoDB Configuration
 		MongoDBURI:      getEnvOrDefault("MONGODB_URI", "mongodb://localhost:27017"),
@@ -85,16 +145,62 @@ func LoadConfig() (*Config, error) {
 		MongoDBPassword: getEnvOrDefault("MONGODB_PASSWORD", ""),
 
 		// External API Configuration
-		PostmanAPIKey: getEnvOrDefault("POSTMAN_API_KEY", ""),
+		PostmanAPIKey:       getEnvOrDefault("POSTMAN_API_KEY", ""),
+		PostmanSyncInterval: getEnvAsIntOrDefault("POSTMAN_SYNC_INTERVAL_MINUTES", 15),
 
 		// HTTP Client Configuration
 		HTTPClientTimeout: getEnvAsIntOrDefault("HTTP_CLIENT_TIMEOUT", 150),
 
+		// Outbound Integration Traffic Configuration
+		OutboundAllowedHosts:         getEnvAsListOrDefault("OUTBOUND_ALLOWED_HOSTS", nil),
+		OutboundAllowPrivateNetworks: getEnvAsBoolOrDefault("OUTBOUND_ALLOW_PRIVATE_NETWORKS", false),
+		OutboundTimeout:              getEnvAsIntOrDefault("OUTBOUND_TIMEOUT", 30),
+		OutboundMaxRedirects:         getEnvAsIntOrDefault("OUTBOUND_MAX_REDIRECTS", 5),
+		OutboundMaxResponseMB:        getEnvAsIntOrDefault("OUTBOUND_MAX_RESPONSE_MB", 10),
+
+		// Hosted MCP Server Configuration
+		MCPHostingDir:          getEnvOrDefault("MCP_HOSTING_DIR", "hosted_mcps"),
+		MCPHostingPortMin:      getEnvAsIntOrDefault("MCP_HOSTING_PORT_MIN", 20000),
+		MCPHostingPortMax:      getEnvAsIntOrDefault("MCP_HOSTING_PORT_MAX", 20999),
+		MCPHostingMemoryMB:     getEnvAsIntOrDefault("MCP_HOSTING_MEMORY_MB", 512),
+		MCPHostingCPUPercent:   getEnvAsIntOrDefault("MCP_HOSTING_CPU_PERCENT", 100),
+		MCPHostingMaxProcesses: getEnvAsIntOrDefault("MCP_HOSTING_MAX_PROCESSES", 256),
+		MCPHostingMaxOpenFiles: getEnvAsIntOrDefault("MCP_HOSTING_MAX_OPEN_FILES", 1024),
+		MCPHostingCgroupRoot:   getEnvOrDefault("MCP_HOSTING_CGROUP_ROOT", ""),
+
+		// Stdio Bridge Configuration
+		MCPBridgeAllowedCommands: getEnvAsListOrDefault("MCP_BRIDGE_ALLOWED_COMMANDS", nil),
+
 		// Environment
 		Environment: getEnvOrDefault("ENVIRONMENT", "development"),
 
-		// Encryption Key
-		EncryptionKey: getEnvOrDefault("ENCRYPTION_KEY", ""),
+		// Encryption Keys
+		EncryptionKey:          getEnvOrDefault("ENCRYPTION_KEY", ""),
+		EncryptionKeyID:        getEnvOrDefault("ENCRYPTION_KEY_ID", "primary"),
+		EncryptionPreviousKeys: getEnvAsListOrDefault("ENCRYPTION_PREVIOUS_KEYS", nil),
+		EncryptionStrict:       getEnvAsBoolOrDefault("ENCRYPTION_STRICT", false),
+
+		// Authentication Configuration
+		JWTSecret:       getEnvOrDefault("JWT_SECRET", ""),
+		AccessTokenTTL:  getEnvAsIntOrDefault("ACCESS_TOKEN_TTL_MINUTES", 15),
+		RefreshTokenTTL: getEnvAsIntOrDefault("REFRESH_TOKEN_TTL_HOURS", 720),
+
+		// Redis Configuration
+		RedisAddr:     getEnvOrDefault("REDIS_ADDR", ""),
+		RedisPassword: getEnvOrDefault("REDIS_PASSWORD", ""),
+		RedisDB:       getEnvAsIntOrDefault("REDIS_DB", 0),
+
+		// Audit Log Configuration
+		AuditRetentionDays: getEnvAsIntOrDefault("AUDIT_RETENTION_DAYS", 365),
+
+		// Secret Store Configuration
+		VaultAddr:        getEnvOrDefault("VAULT_ADDR", ""),
+		VaultToken:       getEnvOrDefault("VAULT_TOKEN", ""),
+		VaultNamespace:   getEnvOrDefault("VAULT_NAMESPACE", ""),
+		VaultKVMount:     getEnvOrDefault("VAULT_KV_MOUNT", "secret"),
+		SecretsFilePath:  getEnvOrDefault("SECRETS_FILE_PATH", ""),
+		SecretsEnvPrefix: getEnvOrDefault("SECRETS_ENV_PREFIX", "API2SDK_SECRET_"),
+		SecretsCacheTTL:  getEnvAsIntOrDefault("SECRETS_CACHE_TTL_SECONDS", 300),
 	}
 
 	// Validate required configuration
@@ -102,6 +208,10 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
 	}
 
+	if config.PublicBaseURL == "" {
+		config.PublicBaseURL = "http://localhost:" + config.Port
+	}
+
 	// Set global config
 	GlobalConfig = config
 
@@ -136,8 +246,49 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("API_PORT must be a valid number: %w", err)
 	}
 
-	if config.EncryptionKey == "" {
-		return fmt.Errorf("ENCRYPTION_KEY is required")
+	// A published key may stay in ENCRYPTION_PREVIOUS_KEYS until rotate-keys has re-encrypted
+	// the secrets stored under it.
+	switch {
+	case config.EncryptionKey == "":
+		return fmt.Errorf("ENCRYPTION_KEY is required; generate one with: openssl rand -hex 32")
+	case publishedSecrets[config.EncryptionKey]:
+		return fmt.Errorf("ENCRYPTION_KEY is a published default; generate a new one with: openssl rand -hex 32, " +
+			"move the old key to ENCRYPTION_PREVIOUS_KEYS and run rotate-keys")
+	}
+
+	switch {
+	case config.JWTSecret == "":
+		return fmt.Errorf("JWT_SECRET is required; generate one with: openssl rand -hex 32")
+	case len(config.JWTSecret) < 32:
+		return fmt.Errorf("JWT_SECRET must be at least 32 characters")
+	case publishedSecrets[config.JWTSecret]:
+		return fmt.Errorf("JWT_SECRET is a published default; generate a new one with: openssl rand -hex 32")
+	case config.JWTSecret == config.EncryptionKey:
+		return fmt.Errorf("JWT_SECRET must differ from ENCRYPTION_KEY")
+	}
+
+	if config.AccessTokenTTL <= 0 || config.RefreshTokenTTL <= 0 {
+		return fmt.Errorf("ACCESS_TOKEN_TTL_MINUTES and REFRESH_TOKEN_TTL_HOURS must be positive")
+	}
+
+	if config.PostmanSyncInterval < 0 {
+		return fmt.Errorf("POSTMAN_SYNC_INTERVAL_MINUTES must not be negative")
+	}
+
+	if config.AuditRetentionDays < 0 {
+		return fmt.Errorf("AUDIT_RETENTION_DAYS must not be negative")
+	}
+
+	if config.VaultAddr != "" && config.VaultToken == "" {
+		return fmt.Errorf("VAULT_TOKEN is required when VAULT_ADDR is set")
+	}
+
+	if config.SecretsCacheTTL < 0 {
+		return fmt.Errorf("SECRETS_CACHE_TTL_SECONDS must not be negative")
+	}
+
+	if config.MCPHostingPortMin <= 0 || config.MCPHostingPortMax < config.MCPHostingPortMin || config.MCPHostingPortMax > 65535 {
+		return fmt.Errorf("MCP_HOSTING_PORT_MIN and MCP_HOSTING_PORT_MAX must form a valid port range")
 	}
 
 	return nil
@@ -162,6 +313,32 @@ func getEnvAsIntOrDefault(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsBoolOrDefault returns the value of the environment variable as a boolean or a default value
+func getEnvAsBoolOrDefault(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+		log.Printf("Warning: Could not convert environment variable %s to boolean, using default value", key)
+	}
+	return defaultValue
+}
+
+// getEnvAsListOrDefault returns the comma-separated values of the environment variable or a default value
+func getEnvAsListOrDefault(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 // IsDevelopment returns true if the application is running in development mode
 func (c *Config) IsDevelopment() bool {
 	return c.Environment == "development"
@@ -187,10 +364,26 @@ func (c *Config) LogConfig() {
 	log.Printf("Configuration:")
 	log.Printf("  Environment: %s", c.Environment)
 	log.Printf("  Port: %s", c.Port)
+	log.Printf("  Public Base URL: %s", c.PublicBaseURL)
 	log.Printf("  MongoDB Database: %s", c.MongoDBName)
 	log.Printf("  MongoDB URI: %s", maskSensitiveData(c.MongoDBURI))
 	log.Printf("  Postman API Key: %s", maskSensitiveData(c.PostmanAPIKey))
+	log.Printf("  Postman Sync Interval: %d minutes", c.PostmanSyncInterval)
 	log.Printf("  HTTP Client Timeout: %d seconds", c.HTTPClientTimeout)
+	log.Printf("  Outbound Allowed Hosts: %v", c.OutboundAllowedHosts)
+	log.Printf("  Outbound Allow Private Networks: %t", c.OutboundAllowPrivateNetworks)
+	log.Printf("  Outbound Timeout: %d seconds", c.OutboundTimeout)
+	log.Printf("  MCP Hosting Ports: %d-%d", c.MCPHostingPortMin, c.MCPHostingPortMax)
+	log.Printf("  MCP Bridge Allowed Commands: %v", c.MCPBridgeAllowedCommands)
+	log.Printf("  Access Token TTL: %d minutes", c.AccessTokenTTL)
+	log.Printf("  Refresh Token TTL: %d hours", c.RefreshTokenTTL)
+	log.Printf("  Redis Address: %s", c.RedisAddr)
+	log.Printf("  Audit Retention: %d days", c.AuditRetentionDays)
+	log.Printf("  Encryption Key ID: %s (%d previous keys, strict: %t)", c.EncryptionKeyID, len(c.EncryptionPreviousKeys), c.EncryptionStrict)
+	log.Printf("  Vault Address: %s (KV mount: %s)", c.VaultAddr, c.VaultKVMount)
+	log.Printf("  Secrets File: %s", c.SecretsFilePath)
+	log.Printf("  Secrets Env Prefix: %s", c.SecretsEnvPrefix)
+	log.Printf("  Secrets Cache TTL: %d seconds", c.SecretsCacheTTL)
 }
 
 // maskSensitiveData masks sensitive configuration data for logging
@@ -0,0 +1,67 @@
+package configs
+
+import (
+	"strings"
+	"testing"
+)
+
+func validConfig() *Config {
+	return &Config{
+		MongoDBURI:        "mongodb://localhost:27017",
+		MongoDBName:       "api2sdk",
+		Port:              "3000",
+		EncryptionKey:     strings.Repeat("e", 64),
+		JWTSecret:         strings.Repeat("j", 64),
+		AccessTokenTTL:    15,
+		RefreshTokenTTL:   720,
+		MCPHostingPortMin: 20000,
+		MCPHostingPortMax: 20999,
+	}
+}
+
+func TestValidateConfigRefusesWeakJWTSecrets(t *testing.T) {
+	if err := validateConfig(validConfig()); err != nil {
+		t.Fatalf("validateConfig() error = %v", err)
+	}
+
+	tests := map[string]func(*Config){
+		"empty":     func(c *Config) { c.JWTSecret = "" },
+		"short":     func(c *Config) { c.JWTSecret = "secret" },
+		"published": func(c *Config) { c.JWTSecret = "43840c487f1eac1711bb2fd613f1ecf21cfc64e7be5cc1182a291c97b343dc80" },
+		"reused":    func(c *Config) { c.JWTSecret = c.EncryptionKey },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := validConfig()
+			mutate(config)
+			err := validateConfig(config)
+			if err == nil || !strings.Contains(err.Error(), "JWT_SECRET") {
+				t.Errorf("validateConfig() error = %v, want a JWT_SECRET error", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigRefusesPublishedEncryptionKeys(t *testing.T) {
+	tests := map[string]func(*Config){
+		"empty":     func(c *Config) { c.EncryptionKey = "" },
+		"published": func(c *Config) { c.EncryptionKey = "43840c487f1eac1711bb2fd613f1ecf21cfc64e7be5cc1182a291c97b343dc80" },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := validConfig()
+			mutate(config)
+			err := validateConfig(config)
+			if err == nil || !strings.Contains(err.Error(), "ENCRYPTION_KEY") {
+				t.Errorf("validateConfig() error = %v, want an ENCRYPTION_KEY error", err)
+			}
+		})
+	}
+
+	// The published key may still decrypt until the secrets stored under it are rotated.
+	config := validConfig()
+	config.EncryptionPreviousKeys = []string{"old:43840c487f1eac1711bb2fd613f1ecf21cfc64e7be5cc1182a291c97b343dc80"}
+	if err := validateConfig(config); err != nil {
+		t.Errorf("validateConfig() with the published key retired error = %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"strings"
+)
+
+// ErrUnknownKey is returned for tokens signed with a key that is not in the key set; the
+// issuer may have rotated its keys.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// JSONWebKey is a public key of a JSON Web Key Set (RFC 7517). Only RSA and EC signing keys are used.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC keys
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is the document an issuer publishes at its jwks_uri.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// signedHeader is the header of a token signed by someone else.
+type signedHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+}
+
+// VerifySignature checks the signature of a compact JWT against the key set and returns its
+// decoded payload. Expiry and the other claims are left to the caller.
+func VerifySignature(token string, keys *JSONWebKeySet) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+	rawHeader, err := decodeSegment(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var h signedHeader
+	if err := json.Unmarshal(rawHeader, &h); err != nil {
+		return nil, ErrInvalidToken
+	}
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	key, err := keys.find(h.KeyID, h.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := key.PublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if err := verify(h.Algorithm, publicKey, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return payload, nil
+}
+
+// find returns the signing key with the ID, or the only signing key of the set when the token names none.
+func (s *JSONWebKeySet) find(keyID, algorithm string) (*JSONWebKey, error) {
+	var candidates []*JSONWebKey
+	for i := range s.Keys {
+		key := &s.Keys[i]
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if key.Algorithm != "" && key.Algorithm != algorithm {
+			continue
+		}
+		if keyID == "" || key.KeyID == keyID {
+			candidates = append(candidates, key)
+		}
+	}
+	if len(candidates) != 1 {
+		return nil, ErrUnknownKey
+	}
+	return candidates[0], nil
+}
+
+// PublicKey decodes the key into an *rsa.PublicKey or *ecdsa.PublicKey.
+func (k *JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeSegment(k.N)
+		if err != nil || len(n) == 0 {
+			return nil, errors.New("invalid RSA modulus")
+		}
+		e, err := decodeSegment(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, errX := decodeSegment(k.X)
+		y, errY := decodeSegment(k.Y)
+		if errX != nil || errY != nil {
+			return nil, errors.New("invalid EC point")
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+}
+
+// verify checks a signature made with one of the asymmetric JWS algorithms. Symmetric and
+// "none" algorithms are refused, so a token cannot pick a weaker scheme than the issuer's keys.
+func verify(algorithm string, publicKey crypto.PublicKey, signingInput string, signature []byte) error {
+	if len(algorithm) != 5 {
+		return ErrInvalidToken
+	}
+	var h hash.Hash
+	var hashFunc crypto.Hash
+	switch algorithm[2:] {
+	case "256":
+		h, hashFunc = sha256.New(), crypto.SHA256
+	case "384":
+		h, hashFunc = sha512.New384(), crypto.SHA384
+	case "512":
+		h, hashFunc = sha512.New(), crypto.SHA512
+	default:
+		return ErrInvalidToken
+	}
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		switch {
+		case strings.HasPrefix(algorithm, "RS"):
+			if rsa.VerifyPKCS1v15(key, hashFunc, digest, signature) == nil {
+				return nil
+			}
+		case strings.HasPrefix(algorithm, "PS"):
+			if rsa.VerifyPSS(key, hashFunc, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil {
+				return nil
+			}
+		}
+	case *ecdsa.PublicKey:
+		// JWS encodes ECDSA signatures as the fixed-size concatenation of r and s
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if strings.HasPrefix(algorithm, "ES") && len(signature) == 2*size {
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			if ecdsa.Verify(key, digest, r, s) {
+				return nil
+			}
+		}
+	}
+	return ErrInvalidToken
+}
@@ -0,0 +1,24 @@
+// Package backoff computes the delays between retries of crashed processes and lost connections.
+package backoff
+
+import "time"
+
+const (
+	// Base is the delay before the first retry.
+	Base = time.Second
+	// Max bounds the delay before any retry.
+	Max = 5 * time.Minute
+)
+
+// Delay returns the delay before the given consecutive retry, counted from 1. It starts at
+// Base and doubles with every further retry, up to Max.
+func Delay(retries int) time.Duration {
+	delay := Base
+	for i := 1; i < retries && delay < Max; i++ {
+		delay *= 2
+	}
+	if delay > Max {
+		delay = Max
+	}
+	return delay
+}
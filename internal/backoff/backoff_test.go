@@ -0,0 +1,21 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelay(t *testing.T) {
+	tests := map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		4:  8 * time.Second,
+		5:  16 * time.Second,
+		20: Max,
+	}
+	for retries, want := range tests {
+		if got := Delay(retries); got != want {
+			t.Errorf("Delay(%d) = %s, want %s", retries, got, want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// AccessTokenController handles the requests for a user's personal access tokens.
+type AccessTokenController struct {
+	accessTokenService services.AccessTokenService
+	logger             *zap.Logger
+}
+
+// NewAccessTokenController creates a new AccessTokenController.
+func NewAccessTokenController(accessTokenService services.AccessTokenService, logger *zap.Logger) *AccessTokenController {
+	return &AccessTokenController{
+		accessTokenService: accessTokenService,
+		logger:             logger,
+	}
+}
+
+// CreateToken creates a token for the caller. The response is the only time the token is shown.
+func (c *AccessTokenController) CreateToken(ctx fiber.Ctx) error {
+	userID, ok := ctx.Locals("userID").(primitive.ObjectID)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req models.CreateAccessTokenRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	role, _ := ctx.Locals("user_role").(string)
+	token, err := c.accessTokenService.CreateToken(ctx.Context(), userID, models.UserRole(role), &req)
+	if errors.Is(err, services.ErrInvalidAccessTokenRequest) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		c.logger.Error("Failed to create access token", zap.String("userID", userID.Hex()), zap.Error(err))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create access token"})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(token)
+}
+
+// ListTokens lists the caller's unrevoked tokens.
+func (c *AccessTokenController) ListTokens(ctx fiber.Ctx) error {
+	userID, ok := ctx.Locals("userID").(primitive.ObjectID)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	tokens, err := c.accessTokenService.ListTokens(ctx.Context(), userID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list access tokens"})
+	}
+
+	return ctx.JSON(tokens)
+}
+
+// RevokeToken revokes one of the caller's tokens.
+func (c *AccessTokenController) RevokeToken(ctx fiber.Ctx) error {
+	userID, ok := ctx.Locals("userID").(primitive.ObjectID)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	tokenID, err := primitive.ObjectIDFromHex(ctx.Params("tokenID"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid token ID"})
+	}
+
+	err = c.accessTokenService.RevokeToken(ctx.Context(), userID, tokenID)
+	if errors.Is(err, services.ErrAccessTokenNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		c.logger.Error("Failed to revoke access token", zap.String("userID", userID.Hex()), zap.Error(err))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to revoke access token"})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
@@ -197,7 +197,7 @@ func (ac *AdminController) UpdateUserRole(c fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body", err.Error())
 	}
 
-	if req.Role != models.RoleAdmin && req.Role != models.RoleUser && req.Role != models.RoleModerator {
+	if !req.Role.IsValid() {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid user role specified", "role: must be admin, user, or moderator")
 	}
 
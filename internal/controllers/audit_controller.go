@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/AkashKesav/API2SDK/internal/utils"
+	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// AuditController serves the audit log to administrators.
+type AuditController struct {
+	auditService services.AuditService
+	logger       *zap.Logger
+}
+
+// NewAuditController creates a new AuditController.
+func NewAuditController(auditService services.AuditService, logger *zap.Logger) *AuditController {
+	return &AuditController{
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// ListEvents returns a page of audit events, newest first, filtered by the query parameters
+// actor_id, organization_id, action ("auth.*" matches a group), outcome, target_type,
+// target_id, request_id, from and to (RFC 3339).
+func (ac *AuditController) ListEvents(c fiber.Ctx) error {
+	query, err := parseAuditQuery(c)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid audit query", err.Error())
+	}
+	query.Page, _ = strconv.Atoi(c.Query("page", "1"))
+	query.Limit, _ = strconv.Atoi(c.Query("limit", "50"))
+
+	events, total, err := ac.auditService.Query(c.Context(), query)
+	if errors.Is(err, services.ErrInvalidAuditQuery) {
+		return utils.BadRequestResponse(c, "Invalid audit query", err.Error())
+	}
+	if err != nil {
+		ac.logger.Error("Failed to query audit events", zap.Error(err))
+		return utils.InternalServerErrorResponse(c, "Failed to retrieve audit events", "")
+	}
+
+	return utils.SuccessResponse(c, "Successfully retrieved audit events", fiber.Map{
+		"events": events,
+		"pagination": models.Pagination{
+			CurrentPage: query.Page,
+			Limit:       query.Limit,
+			TotalItems:  total,
+			TotalPages:  int(math.Ceil(float64(total) / float64(query.Limit))),
+		},
+	})
+}
+
+// ExportEvents downloads every matching audit event, oldest first, as JSON lines or CSV
+// according to the format query parameter. It takes the filters of ListEvents.
+func (ac *AuditController) ExportEvents(c fiber.Ctx) error {
+	query, err := parseAuditQuery(c)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid audit query", err.Error())
+	}
+
+	format := c.Query("format", services.AuditExportJSONL)
+	contentType := "application/x-ndjson"
+	switch format {
+	case services.AuditExportJSONL:
+	case services.AuditExportCSV:
+		contentType = "text/csv; charset=utf-8"
+	default:
+		return utils.BadRequestResponse(c, "Invalid export format", "format must be jsonl or csv")
+	}
+
+	// The log can be large, so it is streamed; the query was validated above, so failures past
+	// this point can only end the download early.
+	ctx := c.Context()
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Attachment(fmt.Sprintf("audit-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format))
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		if err := ac.auditService.Export(ctx, query, format, w); err != nil {
+			ac.logger.Error("Failed to export audit events", zap.Error(err))
+		}
+		if err := w.Flush(); err != nil {
+			ac.logger.Warn("Audit export was not fully sent", zap.Error(err))
+		}
+	})
+}
+
+// parseAuditQuery reads the filters of an audit query from the query parameters.
+func parseAuditQuery(c fiber.Ctx) (*models.AuditQuery, error) {
+	query := &models.AuditQuery{
+		OrganizationID: c.Query("organization_id"),
+		Action:         c.Query("action"),
+		Outcome:        models.AuditOutcome(c.Query("outcome")),
+		TargetType:     c.Query("target_type"),
+		TargetID:       c.Query("target_id"),
+		RequestID:      c.Query("request_id"),
+	}
+	if actorID := c.Query("actor_id"); actorID != "" {
+		id, err := primitive.ObjectIDFromHex(actorID)
+		if err != nil {
+			return nil, errors.New("actor_id must be a user ID")
+		}
+		query.ActorID = id
+	}
+	for name, target := range map[string]*time.Time{"from": &query.From, "to": &query.To} {
+		if value := c.Query(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be an RFC 3339 time", name)
+			}
+			*target = parsed
+		}
+	}
+	switch query.Outcome {
+	case "", models.AuditOutcomeSuccess, models.AuditOutcomeFailure:
+	default:
+		return nil, errors.New("outcome must be success or failure")
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return nil, errors.New("from must be before to")
+	}
+	return query, nil
+}
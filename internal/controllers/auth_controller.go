@@ -2,12 +2,16 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
+	"strconv"
 
+	"github.com/AkashKesav/API2SDK/internal/middleware"
 	"github.com/AkashKesav/API2SDK/internal/models"
 	"github.com/AkashKesav/API2SDK/internal/services"
 	"github.com/AkashKesav/API2SDK/internal/utils"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 )
 
@@ -32,7 +36,6 @@ type RegisterRequest struct {
 	Name     string `json:"name" validate:"required"`
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8"`
-	Role     string `json:"role"` // Optional: specify role, defaults to "user"
 }
 
 var validateAuth = validator.New()
@@ -54,22 +57,19 @@ func (ac *AuthController) Register(c fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Validation failed", err.Error())
 	}
 
-	userRole := models.RoleUser // Default role
-	if req.Role != "" {
-		userRole = models.UserRole(req.Role)
-	}
-
 	user := models.User{
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: req.Password, // Password will be hashed by the service
-		Role:     userRole,
 	}
 
 	createdUser, err := ac.authService.Register(c.Context(), user)
+	if errors.Is(err, services.ErrUserExists) {
+		return utils.ErrorResponse(c, fiber.StatusConflict, "Failed to register user", err.Error())
+	}
 	if err != nil {
 		ac.logger.Error("User registration failed", zap.String("email", req.Email), zap.Error(err))
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to register user", err.Error())
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to register user", "")
 	}
 
 	return utils.SuccessResponse(c, "User registered successfully", fiber.Map{
@@ -83,7 +83,7 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
-// Login handles user login (no authentication required)
+// Login verifies the credentials and returns the tokens of a new session
 func (ac *AuthController) Login(c fiber.Ctx) error {
 	var req LoginRequest
 	if err := c.Bind().Body(&req); err != nil {
@@ -95,32 +95,111 @@ func (ac *AuthController) Login(c fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Validation failed", err.Error())
 	}
 
-	ac.logger.Info("Login attempt", zap.String("email", req.Email))
+	tokens, user, err := ac.authService.Login(c.Context(), req.Email, req.Password, clientInfo(c))
+	var lockedErr *services.AccountLockedError
+	switch {
+	case errors.As(err, &lockedErr):
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(lockedErr.RetryAfter.Seconds())+1))
+		return utils.ErrorResponse(c, fiber.StatusTooManyRequests, "Account temporarily locked", lockedErr.Error())
+	case errors.Is(err, services.ErrInvalidCredentials):
+		return utils.UnauthorizedResponse(c, err.Error())
+	case err != nil:
+		ac.logger.Error("Login failed", zap.String("email", req.Email), zap.Error(err))
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Login failed", "")
+	}
 
-	return utils.SuccessResponse(c, "Login successful", fiber.Map{
-		"user": fiber.Map{
-			"id":    "default-user",
-			"email": req.Email,
-			"name":  "User",
-			"role":  "user",
-		},
-	})
+	return utils.SuccessResponse(c, "Login successful", tokenResponse(tokens, user))
+}
+
+// RefreshRequest defines the structure for token refresh requests
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// Refresh exchanges a refresh token for new tokens; the presented refresh token stops working
+func (ac *AuthController) Refresh(c fiber.Ctx) error {
+	var req RefreshRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request payload", err.Error())
+	}
+
+	if err := validateAuth.Struct(req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Validation failed", err.Error())
+	}
+
+	tokens, err := ac.authService.Refresh(c.Context(), req.RefreshToken, clientInfo(c))
+	if errors.Is(err, services.ErrInvalidRefreshToken) {
+		return utils.UnauthorizedResponse(c, err.Error())
+	}
+	if err != nil {
+		ac.logger.Error("Token refresh failed", zap.Error(err))
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Token refresh failed", "")
+	}
+
+	return utils.SuccessResponse(c, "Token refreshed successfully", tokenResponse(tokens, nil))
 }
 
-// GetUserProfile returns a default user profile (no authentication required)
+// GetUserProfile returns the profile of the authenticated user
 func (ac *AuthController) GetUserProfile(c fiber.Ctx) error {
-	ac.logger.Info("Get user profile")
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return utils.UnauthorizedResponse(c, "Authentication required")
+	}
 
-	return utils.SuccessResponse(c, "User profile retrieved successfully", fiber.Map{
-		"id":    "default-user",
-		"email": "user@example.com",
-		"name":  "Default User",
-		"role":  "user",
-	})
+	user, err := ac.userService.GetUserProfile(c.Context(), userID)
+	if err != nil {
+		ac.logger.Warn("Failed to get user profile", zap.String("userID", userID), zap.Error(err))
+		return utils.NotFoundResponse(c, "User not found")
+	}
+	return utils.SuccessResponse(c, "User profile retrieved successfully", user)
+}
+
+// LogoutRequest defines the structure for logout requests
+type LogoutRequest struct {
+	// All revokes every session of the user instead of only the current one
+	All bool `json:"all"`
 }
 
-// Logout handles user logout (no-op since no authentication)
+// Logout revokes the session of the request, or all sessions of the user
 func (ac *AuthController) Logout(c fiber.Ctx) error {
-	ac.logger.Info("Logout")
+	var req LogoutRequest
+	if len(c.Body()) > 0 {
+		if err := c.Bind().Body(&req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request payload", err.Error())
+		}
+	}
+
+	var err error
+	if req.All {
+		userID, _ := c.Locals("userID").(primitive.ObjectID)
+		err = ac.authService.LogoutAll(c.Context(), userID)
+	} else {
+		sessionID, _ := middleware.GetSessionID(c)
+		err = ac.authService.Logout(c.Context(), sessionID)
+	}
+	if err != nil {
+		ac.logger.Error("Logout failed", zap.Error(err))
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Logout failed", "")
+	}
 	return utils.SuccessResponse(c, "Logout successful", nil)
 }
+
+// clientInfo describes the client of a login or refresh request
+func clientInfo(c fiber.Ctx) services.ClientInfo {
+	return services.ClientInfo{UserAgent: c.Get(fiber.HeaderUserAgent), IPAddress: c.IP()}
+}
+
+// tokenResponse is the body of login and refresh responses; the web client reads the tokens at the top level
+func tokenResponse(tokens *services.AuthTokens, user *models.User) fiber.Map {
+	response := fiber.Map{
+		"access_token":       tokens.AccessToken,
+		"refresh_token":      tokens.RefreshToken,
+		"token_type":         tokens.TokenType,
+		"expires_in":         tokens.ExpiresIn,
+		"refresh_expires_at": tokens.RefreshExpiresAt,
+	}
+	if user != nil {
+		response["user"] = user
+	}
+	return response
+}
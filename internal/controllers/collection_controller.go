@@ -2,24 +2,28 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 
 	"github.com/AkashKesav/API2SDK/internal/models"
 	"github.com/AkashKesav/API2SDK/internal/services"
 	"github.com/AkashKesav/API2SDK/internal/utils"
 	"github.com/gofiber/fiber/v3"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 )
 
 type CollectionController struct {
-	service *services.CollectionService
-	logger  *zap.Logger
+	service     *services.CollectionService
+	syncService services.PostmanSyncService
+	logger      *zap.Logger
 }
 
-func NewCollectionController(service *services.CollectionService, logger *zap.Logger) *CollectionController {
+func NewCollectionController(service *services.CollectionService, syncService services.PostmanSyncService, logger *zap.Logger) *CollectionController {
 	return &CollectionController{
-		service: service,
-		logger:  logger,
+		service:     service,
+		syncService: syncService,
+		logger:      logger,
 	}
 }
 
@@ -31,7 +35,7 @@ func (cc *CollectionController) GetUserCollections(c fiber.Ctx) error {
 		return utils.UnauthorizedResponse(c, "Unauthorized or invalid user ID type")
 	}
 
-	collections, err := cc.service.GetCollectionsByUserID(userID.Hex())
+	collections, err := cc.service.GetAccessibleCollections(c.Context(), userID.Hex())
 	if err != nil {
 		cc.logger.Error("Failed to retrieve collections for user", zap.String("userID", userID.Hex()), zap.Error(err))
 		return utils.InternalServerErrorResponse(c, "Failed to retrieve collections", err.Error())
@@ -56,16 +60,10 @@ func (cc *CollectionController) GetCollectionByID(c fiber.Ctx) error {
 		return utils.UnauthorizedResponse(c, "Unauthorized or invalid user ID type")
 	}
 
-	// UserID is checked for authorization but not passed to the service's GetCollection method
-	collection, err := cc.service.GetCollection(id)
+	collection, err := cc.service.GetCollectionForUser(c.Context(), id, userID.Hex(), models.ActionRead)
 	if err != nil {
-		cc.logger.Error("Failed to retrieve collection by ID", zap.String("collectionID", id), zap.String("userID", userID.Hex()), zap.Error(err)) // Log userID for context
-		return utils.InternalServerErrorResponse(c, "Failed to retrieve collection", err.Error())
+		return cc.collectionAccessError(c, id, userID, err)
 	}
-	if collection == nil { // Handle case where collection is not found
-		return utils.NotFoundResponse(c, "Collection not found")
-	}
-	// Optional: Add an explicit check: if collection.UserID != userID.Hex() { return unauthorized }
 
 	return utils.SuccessResponse(c, "Collection retrieved successfully", collection)
 }
@@ -81,6 +79,7 @@ func (cc *CollectionController) CreateCollection(c fiber.Ctx) error {
 
 		req.Name = c.FormValue("name")
 		req.Description = c.FormValue("description")
+		req.OrganizationID = c.FormValue("organization_id")
 
 		// Check if file was uploaded
 		file, err := c.FormFile("file")
@@ -133,6 +132,9 @@ func (cc *CollectionController) CreateCollection(c fiber.Ctx) error {
 	}
 
 	collection, err := cc.service.CreateCollection(&req, userID.Hex())
+	if errors.Is(err, services.ErrPermissionDenied) {
+		return utils.ForbiddenResponse(c, "Forbidden: You may not create collections in this organization")
+	}
 	if err != nil {
 		cc.logger.Error("Failed to create collection", zap.Error(err))
 		return utils.InternalServerErrorResponse(c, "Failed to create collection", err.Error())
@@ -165,19 +167,9 @@ func (cc *CollectionController) UpdateCollection(c fiber.Ctx) error {
 		return utils.UnauthorizedResponse(c, "Unauthorized or invalid user ID type")
 	}
 
-	// UserID is checked for authorization, but not passed to the service's UpdateCollection method.
-	// First, get the existing collection to verify ownership if needed.
-	existingCollection, err := cc.service.GetCollection(id)
-	if err != nil {
-		cc.logger.Error("Failed to retrieve collection for update check", zap.String("collectionID", id), zap.String("userID", userID.Hex()), zap.Error(err))
-		return utils.InternalServerErrorResponse(c, "Failed to retrieve collection for update", err.Error())
-	}
-	if existingCollection == nil {
-		return utils.NotFoundResponse(c, "Collection not found")
-	}
-	if existingCollection.UserID != userID.Hex() {
-		cc.logger.Warn("User attempted to update collection they do not own", zap.String("collectionID", id), zap.String("ownerUserID", existingCollection.UserID), zap.String("requestingUserID", userID.Hex()))
-		return utils.ForbiddenResponse(c, "Forbidden: You do not own this collection")
+	// First, verify the user may edit the collection.
+	if _, err := cc.service.GetCollectionForUser(c.Context(), id, userID.Hex(), models.ActionWrite); err != nil {
+		return cc.collectionAccessError(c, id, userID, err)
 	}
 
 	collection, err := cc.service.UpdateCollection(id, &req)
@@ -202,22 +194,12 @@ func (cc *CollectionController) DeleteCollection(c fiber.Ctx) error {
 		return utils.UnauthorizedResponse(c, "Unauthorized or invalid user ID type")
 	}
 
-	// UserID is checked for authorization, but not passed to the service's DeleteCollection method.
-	// First, get the existing collection to verify ownership.
-	existingCollection, err := cc.service.GetCollection(id)
-	if err != nil {
-		cc.logger.Error("Failed to retrieve collection for delete check", zap.String("collectionID", id), zap.String("userID", userID.Hex()), zap.Error(err))
-		return utils.InternalServerErrorResponse(c, "Failed to retrieve collection for delete", err.Error())
-	}
-	if existingCollection == nil {
-		return utils.NotFoundResponse(c, "Collection not found")
-	}
-	if existingCollection.UserID != userID.Hex() {
-		cc.logger.Warn("User attempted to delete collection they do not own", zap.String("collectionID", id), zap.String("ownerUserID", existingCollection.UserID), zap.String("requestingUserID", userID.Hex()))
-		return utils.ForbiddenResponse(c, "Forbidden: You do not own this collection")
+	// First, verify the user may delete the collection.
+	if _, err := cc.service.GetCollectionForUser(c.Context(), id, userID.Hex(), models.ActionDelete); err != nil {
+		return cc.collectionAccessError(c, id, userID, err)
 	}
 
-	err = cc.service.DeleteCollection(id)
+	err := cc.service.DeleteCollection(id)
 	if err != nil {
 		cc.logger.Error("Failed to delete collection", zap.String("collectionID", id), zap.String("userID", userID.Hex()), zap.Error(err))
 		return utils.InternalServerErrorResponse(c, "Failed to delete collection", err.Error())
@@ -239,18 +221,9 @@ func (cc *CollectionController) GenerateOpenAPISpec(c fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
 	}
 
-	// Check if user owns the collection before generating spec
-	existingCollection, err := cc.service.GetCollection(collectionID)
-	if err != nil {
-		cc.logger.Error("Failed to retrieve collection for OpenAPI spec generation check", zap.String("collectionID", collectionID), zap.String("userID", userID.Hex()), zap.Error(err))
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve collection", "details": err.Error()})
-	}
-	if existingCollection == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Collection not found"})
-	}
-	if existingCollection.UserID != userID.Hex() {
-		cc.logger.Warn("User attempted to generate spec for collection they do not own", zap.String("collectionID", collectionID), zap.String("ownerUserID", existingCollection.UserID), zap.String("requestingUserID", userID.Hex()))
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden: You do not own this collection"})
+	// Check if user may read the collection before generating spec
+	if _, err := cc.service.GetCollectionForUser(c.Context(), collectionID, userID.Hex(), models.ActionRead); err != nil {
+		return cc.collectionAccessError(c, collectionID, userID, err)
 	}
 
 	_, specContent, err := cc.service.GenerateOpenAPISpec(collectionID) // Service returns filePath, specContent, error
@@ -266,6 +239,174 @@ func (cc *CollectionController) GenerateOpenAPISpec(c fiber.Ctx) error {
 	})
 }
 
+// TransferCollection handles PUT /collections/:id/organization, moving a collection and its SDKs
+// into an organization or, with an empty organization_id, back to the user who created it
+func (cc *CollectionController) TransferCollection(c fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return utils.BadRequestResponse(c, "Collection ID is required", "")
+	}
+
+	var req models.TransferRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return utils.BadRequestResponse(c, "Invalid request body", err.Error())
+	}
+
+	userID, ok := c.Locals("userID").(primitive.ObjectID)
+	if !ok {
+		cc.logger.Error("Failed to get userID from context or userID is not of type primitive.ObjectID for TransferCollection")
+		return utils.UnauthorizedResponse(c, "Unauthorized or invalid user ID type")
+	}
+
+	collection, err := cc.service.TransferCollection(c.Context(), id, userID.Hex(), req.OrganizationID)
+	if err != nil {
+		return cc.collectionAccessError(c, id, userID, err)
+	}
+
+	return utils.SuccessResponse(c, "Collection transferred successfully", collection)
+}
+
+// LinkPostmanCollection handles PUT /collections/:id/postman, linking a collection to the Postman
+// collection it tracks and syncing it
+func (cc *CollectionController) LinkPostmanCollection(c fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return utils.BadRequestResponse(c, "Collection ID is required", "")
+	}
+
+	var req models.LinkPostmanCollectionRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return utils.BadRequestResponse(c, "Invalid request body", err.Error())
+	}
+	if req.PostmanUID == "" {
+		return utils.BadRequestResponse(c, "postman_uid is required", "")
+	}
+
+	userID, ok := c.Locals("userID").(primitive.ObjectID)
+	if !ok {
+		cc.logger.Error("Failed to get userID from context or userID is not of type primitive.ObjectID for LinkPostmanCollection")
+		return utils.UnauthorizedResponse(c, "Unauthorized or invalid user ID type")
+	}
+
+	collection, err := cc.service.GetCollectionForUser(c.Context(), id, userID.Hex(), models.ActionWrite)
+	if err != nil {
+		return cc.collectionAccessError(c, id, userID, err)
+	}
+
+	result, err := cc.syncService.Link(c.Context(), collection, &req)
+	if err != nil {
+		return cc.postmanSyncError(c, id, err)
+	}
+	return utils.SuccessResponse(c, "Collection linked to Postman successfully", result)
+}
+
+// UnlinkPostmanCollection handles DELETE /collections/:id/postman; the stored revisions are kept
+func (cc *CollectionController) UnlinkPostmanCollection(c fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return utils.BadRequestResponse(c, "Collection ID is required", "")
+	}
+
+	userID, ok := c.Locals("userID").(primitive.ObjectID)
+	if !ok {
+		cc.logger.Error("Failed to get userID from context or userID is not of type primitive.ObjectID for UnlinkPostmanCollection")
+		return utils.UnauthorizedResponse(c, "Unauthorized or invalid user ID type")
+	}
+
+	collection, err := cc.service.GetCollectionForUser(c.Context(), id, userID.Hex(), models.ActionWrite)
+	if err != nil {
+		return cc.collectionAccessError(c, id, userID, err)
+	}
+
+	if err := cc.syncService.Unlink(c.Context(), collection); err != nil {
+		return cc.postmanSyncError(c, id, err)
+	}
+	return utils.SuccessResponse(c, "Collection unlinked from Postman successfully", collection)
+}
+
+// SyncPostmanCollection handles POST /collections/:id/postman/sync, checking a linked collection
+// for changes now instead of waiting for the scheduler
+func (cc *CollectionController) SyncPostmanCollection(c fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return utils.BadRequestResponse(c, "Collection ID is required", "")
+	}
+
+	userID, ok := c.Locals("userID").(primitive.ObjectID)
+	if !ok {
+		cc.logger.Error("Failed to get userID from context or userID is not of type primitive.ObjectID for SyncPostmanCollection")
+		return utils.UnauthorizedResponse(c, "Unauthorized or invalid user ID type")
+	}
+
+	collection, err := cc.service.GetCollectionForUser(c.Context(), id, userID.Hex(), models.ActionWrite)
+	if err != nil {
+		return cc.collectionAccessError(c, id, userID, err)
+	}
+
+	result, err := cc.syncService.Sync(c.Context(), collection, models.SyncTriggerManual)
+	if err != nil {
+		return cc.postmanSyncError(c, id, err)
+	}
+	return utils.SuccessResponse(c, "Collection synced successfully", result)
+}
+
+// GetCollectionRevisions handles GET /collections/:id/revisions, listing the revisions the
+// Postman sync stored, newest first
+func (cc *CollectionController) GetCollectionRevisions(c fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return utils.BadRequestResponse(c, "Collection ID is required", "")
+	}
+
+	userID, ok := c.Locals("userID").(primitive.ObjectID)
+	if !ok {
+		cc.logger.Error("Failed to get userID from context or userID is not of type primitive.ObjectID for GetCollectionRevisions")
+		return utils.UnauthorizedResponse(c, "Unauthorized or invalid user ID type")
+	}
+
+	collection, err := cc.service.GetCollectionForUser(c.Context(), id, userID.Hex(), models.ActionRead)
+	if err != nil {
+		return cc.collectionAccessError(c, id, userID, err)
+	}
+
+	revisions, err := cc.syncService.Revisions(c.Context(), collection)
+	if err != nil {
+		cc.logger.Error("Failed to retrieve collection revisions", zap.String("collectionID", id), zap.Error(err))
+		return utils.InternalServerErrorResponse(c, "Failed to retrieve collection revisions", err.Error())
+	}
+	return utils.SuccessResponse(c, "Collection revisions retrieved successfully", fiber.Map{
+		"revisions": revisions,
+		"count":     len(revisions),
+	})
+}
+
+// postmanSyncError responds to a failed link, unlink or sync of a collection
+func (cc *CollectionController) postmanSyncError(c fiber.Ctx, collectionID string, err error) error {
+	switch {
+	case errors.Is(err, services.ErrNotPostmanCollection), errors.Is(err, services.ErrInvalidPostmanUID), errors.Is(err, services.ErrCollectionNotLinked):
+		return utils.BadRequestResponse(c, err.Error(), "")
+	case errors.Is(err, services.ErrPostmanSyncConflict):
+		return utils.ErrorResponse(c, fiber.StatusConflict, err.Error(), "")
+	case errors.Is(err, services.ErrPostmanFetchFailed):
+		cc.logger.Warn("Failed to fetch linked Postman collection", zap.String("collectionID", collectionID), zap.Error(err))
+		return utils.ErrorResponse(c, fiber.StatusBadGateway, "Failed to fetch the Postman collection", err.Error())
+	}
+	cc.logger.Error("Failed to sync collection with Postman", zap.String("collectionID", collectionID), zap.Error(err))
+	return utils.InternalServerErrorResponse(c, "Failed to sync collection with Postman", err.Error())
+}
+
+// collectionAccessError responds to a collection that could not be loaded for the user
+func (cc *CollectionController) collectionAccessError(c fiber.Ctx, collectionID string, userID primitive.ObjectID, err error) error {
+	if errors.Is(err, services.ErrPermissionDenied) {
+		return utils.ForbiddenResponse(c, "Forbidden: You are not allowed to do this with this collection")
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, primitive.ErrInvalidHex) {
+		return utils.NotFoundResponse(c, "Collection not found")
+	}
+	cc.logger.Error("Failed to retrieve collection", zap.String("collectionID", collectionID), zap.String("userID", userID.Hex()), zap.Error(err))
+	return utils.InternalServerErrorResponse(c, "Failed to retrieve collection", err.Error())
+}
+
 // CreateCollectionFromPublicAPI handles POST /collections/from-public-api
 // This functionality is not currently implemented in the CollectionService.
 // Commenting out for now to resolve compiler errors.
@@ -0,0 +1,347 @@
+package controllers
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/openapi"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/AkashKesav/API2SDK/internal/signing"
+	"github.com/AkashKesav/API2SDK/internal/types"
+	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// IntegrationController handles admin configuration of integrations.
+type IntegrationController struct {
+	integrationService services.IntegrationService
+	promptService      services.PromptService
+	secretService      services.SecretService
+	logger             *zap.Logger
+}
+
+// NewIntegrationController creates a new IntegrationController.
+func NewIntegrationController(integrationService services.IntegrationService, promptService services.PromptService, secretService services.SecretService, logger *zap.Logger) *IntegrationController {
+	return &IntegrationController{
+		integrationService: integrationService,
+		promptService:      promptService,
+		secretService:      secretService,
+		logger:             logger,
+	}
+}
+
+// GetAuth returns the configured and the effective auth scheme of an integration,
+// along with the schemes its spec declares and the available signer types.
+func (c *IntegrationController) GetAuth(ctx fiber.Ctx) error {
+	integration, doc, ferr := c.integrationWithSpec(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	response := fiber.Map{
+		"configured":  integration.Auth,
+		"specSchemes": services.SpecAuthSchemes(doc),
+		"signers":     signing.Types(),
+	}
+	if resolved, err := services.ResolveAuthScheme(integration, doc, nil); err == nil {
+		response["effective"] = resolved
+	} else {
+		response["effectiveError"] = err.Error()
+	}
+	return ctx.JSON(response)
+}
+
+// UpdateAuth chooses and configures the auth scheme of an integration.
+// An empty body clears the configuration so the scheme is derived from the spec again.
+func (c *IntegrationController) UpdateAuth(ctx fiber.Ctx) error {
+	integration, doc, ferr := c.integrationWithSpec(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	var auth models.IntegrationAuth
+	if err := ctx.Bind().Body(&auth); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	integration.Auth = nil
+	if auth.SchemeName != "" || auth.Type != "" {
+		integration.Auth = &auth
+		// Validate by building the signer the executor would use.
+		if _, err := services.NewRequestSigner(integration, doc, nil); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	updated, err := c.integrationService.UpdateIntegration(ctx.Context(), integration.ID, integration)
+	if err != nil {
+		c.logger.Error("Failed to update integration auth", zap.String("integrationID", integration.ID.Hex()), zap.Error(err))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update integration"})
+	}
+
+	resolved, _ := services.ResolveAuthScheme(updated, doc, nil)
+	return ctx.JSON(fiber.Map{"configured": updated.Auth, "effective": resolved})
+}
+
+// GetAPIKey tells whether an integration has a shared API key, without revealing a key stored
+// as a value, and returns the reference of a key kept in a secret store.
+func (c *IntegrationController) GetAPIKey(ctx fiber.Ctx) error {
+	integration, _, ferr := c.integrationWithSpec(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	return ctx.JSON(fiber.Map{"apiKeySet": integration.HasAPIKey(), "apiKeyRef": integration.APIKeyRef})
+}
+
+// UpdateAPIKey sets the shared API key of an integration, as a value stored encrypted or as a
+// reference to a secret store. An empty body clears the key.
+func (c *IntegrationController) UpdateAPIKey(ctx fiber.Ctx) error {
+	integration, _, ferr := c.integrationWithSpec(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	var req models.IntegrationAPIKeyRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.APIKey != "" && req.APIKeyRef != "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "set either apiKey or apiKeyRef"})
+	}
+	if req.APIKeyRef != "" {
+		if err := c.secretService.ValidateReference(req.APIKeyRef, ""); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	integration.APIKey = types.EncryptedString(req.APIKey)
+	integration.APIKeyRef = req.APIKeyRef
+	updated, err := c.integrationService.UpdateIntegration(ctx.Context(), integration.ID, integration)
+	if err != nil {
+		c.logger.Error("Failed to update integration API key", zap.String("integrationID", integration.ID.Hex()), zap.Error(err))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update integration"})
+	}
+	return ctx.JSON(fiber.Map{"apiKeySet": updated.HasAPIKey(), "apiKeyRef": updated.APIKeyRef})
+}
+
+// GetResponseShaping returns how the responses of an integration's tools are shaped.
+func (c *IntegrationController) GetResponseShaping(ctx fiber.Ctx) error {
+	integration, _, ferr := c.integrationWithSpec(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	return ctx.JSON(fiber.Map{
+		"responseShaping": integration.ResponseShaping,
+		"defaultMaxBytes": services.DefaultMaxResultBytes,
+	})
+}
+
+// UpdateResponseShaping replaces the response shaping of an integration. The body maps
+// tool names, or "*" for every other tool, to their shaping configuration.
+func (c *IntegrationController) UpdateResponseShaping(ctx fiber.Ctx) error {
+	integration, doc, ferr := c.integrationWithSpec(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	var shaping map[string]models.ResponseShaping
+	if err := ctx.Bind().Body(&shaping); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	for toolName, config := range shaping {
+		if toolName != "*" && (doc == nil || doc.Operation(toolName) == nil) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "unknown tool: " + toolName})
+		}
+		if err := services.ValidateResponseShaping(config); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": toolName + ": " + err.Error()})
+		}
+	}
+
+	integration.ResponseShaping = shaping
+	updated, err := c.integrationService.UpdateIntegration(ctx.Context(), integration.ID, integration)
+	if err != nil {
+		c.logger.Error("Failed to update integration response shaping", zap.String("integrationID", integration.ID.Hex()), zap.Error(err))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update integration"})
+	}
+	return ctx.JSON(fiber.Map{"responseShaping": updated.ResponseShaping})
+}
+
+// UpdateOrganization restricts an integration to the members of an organization, or makes it
+// available to every user again when organization_id is empty.
+func (c *IntegrationController) UpdateOrganization(ctx fiber.Ctx) error {
+	integration, _, ferr := c.integrationWithSpec(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	var req models.TransferRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.OrganizationID != "" && !primitive.IsValidObjectID(req.OrganizationID) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid organization ID"})
+	}
+
+	integration.OrganizationID = req.OrganizationID
+	updated, err := c.integrationService.UpdateIntegration(ctx.Context(), integration.ID, integration)
+	if err != nil {
+		c.logger.Error("Failed to update integration organization", zap.String("integrationID", integration.ID.Hex()), zap.Error(err))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update integration"})
+	}
+	return ctx.JSON(fiber.Map{"organizationId": updated.OrganizationID})
+}
+
+// ListPrompts returns the MCP prompts of an integration, generated and custom, by their
+// names relative to the integration, along with the prefix that qualifies them in MCP servers.
+func (c *IntegrationController) ListPrompts(ctx fiber.Ctx) error {
+	integration, _, ferr := c.integrationWithSpec(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	prompts, err := c.promptService.Prompts(ctx.Context(), integration)
+	if err != nil {
+		c.logger.Error("Failed to list integration prompts", zap.String("integrationID", integration.ID.Hex()), zap.Error(err))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list prompts"})
+	}
+	return ctx.JSON(fiber.Map{"prompts": prompts, "prefix": services.PromptPrefix(integration)})
+}
+
+// SavePrompt creates or replaces the custom prompt named by the :name parameter.
+// A custom prompt with the name of a generated prompt replaces it.
+func (c *IntegrationController) SavePrompt(ctx fiber.Ctx) error {
+	integration, _, ferr := c.integrationWithSpec(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	var prompt models.Prompt
+	if err := ctx.Bind().Body(&prompt); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	prompt.IntegrationID = integration.ID
+	prompt.Name = ctx.Params("name")
+
+	saved, err := c.promptService.SaveCustomPrompt(ctx.Context(), &prompt)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPrompt) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.logger.Error("Failed to save integration prompt", zap.String("integrationID", integration.ID.Hex()), zap.Error(err))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save prompt"})
+	}
+	return ctx.JSON(saved)
+}
+
+// DeletePrompt removes the custom prompt named by the :name parameter. A generated prompt
+// it replaced is served again.
+func (c *IntegrationController) DeletePrompt(ctx fiber.Ctx) error {
+	integrationID, err := primitive.ObjectIDFromHex(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid integration ID"})
+	}
+
+	if err := c.promptService.DeleteCustomPrompt(ctx.Context(), integrationID, ctx.Params("name")); err != nil {
+		if errors.Is(err, services.ErrPromptNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "prompt not found"})
+		}
+		c.logger.Error("Failed to delete integration prompt", zap.String("integrationID", integrationID.Hex()), zap.Error(err))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to delete prompt"})
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// GetToolOverrides returns the annotation overrides of an integration's tools together with
+// the annotations each tool ends up with.
+func (c *IntegrationController) GetToolOverrides(ctx fiber.Ctx) error {
+	integration, doc, ferr := c.integrationWithSpec(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	return ctx.JSON(fiber.Map{
+		"configured": integration.ToolOverrides,
+		"effective":  effectiveToolAnnotations(doc, integration.ToolOverrides),
+	})
+}
+
+// UpdateToolOverrides replaces the annotation overrides of an integration's tools.
+// Every key must name a tool of the integration's spec; an empty object clears the overrides.
+func (c *IntegrationController) UpdateToolOverrides(ctx fiber.Ctx) error {
+	integration, doc, ferr := c.integrationWithSpec(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	var overrides map[string]models.ToolAnnotations
+	if err := ctx.Bind().Body(&overrides); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if len(overrides) > 0 && doc == nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "integration has no OpenAPI spec to override tools of"})
+	}
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if op := doc.Operation(name); op == nil || op.ToolName != name {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "unknown tool: " + name})
+		}
+	}
+
+	integration.ToolOverrides = overrides
+	if len(overrides) == 0 {
+		integration.ToolOverrides = nil
+	}
+	updated, err := c.integrationService.UpdateIntegration(ctx.Context(), integration.ID, integration)
+	if err != nil {
+		c.logger.Error("Failed to update integration tool overrides", zap.String("integrationID", integration.ID.Hex()), zap.Error(err))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update integration"})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"configured": updated.ToolOverrides,
+		"effective":  effectiveToolAnnotations(doc, updated.ToolOverrides),
+	})
+}
+
+// effectiveToolAnnotations returns the annotations of every tool in doc after overrides apply,
+// keyed by tool name. It mirrors what the tool provider serves.
+func effectiveToolAnnotations(doc *openapi.Document, overrides map[string]models.ToolAnnotations) map[string]models.ToolAnnotations {
+	effective := map[string]models.ToolAnnotations{}
+	if doc == nil {
+		return effective
+	}
+	for _, op := range doc.Operations {
+		annotations := op.Annotations()
+		if override, ok := overrides[op.ToolName]; ok {
+			annotations.Apply(override)
+		}
+		effective[op.ToolName] = annotations
+	}
+	return effective
+}
+
+// integrationWithSpec loads the integration named by the :id parameter and parses its spec.
+// The document is nil when the integration has no spec.
+func (c *IntegrationController) integrationWithSpec(ctx fiber.Ctx) (*models.Integration, *openapi.Document, *fiber.Error) {
+	integrationID, err := primitive.ObjectIDFromHex(ctx.Params("id"))
+	if err != nil {
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, "invalid integration ID")
+	}
+	integration, err := c.integrationService.GetIntegration(ctx.Context(), integrationID)
+	if err != nil {
+		return nil, nil, fiber.NewError(fiber.StatusNotFound, "integration not found")
+	}
+	if integration.OpenAPISpec == "" {
+		return integration, nil, nil
+	}
+	doc, err := openapi.Parse([]byte(integration.OpenAPISpec))
+	if err != nil {
+		return nil, nil, fiber.NewError(fiber.StatusUnprocessableEntity, "failed to parse integration spec: "+err.Error())
+	}
+	return integration, doc, nil
+}
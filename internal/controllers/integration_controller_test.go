@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const itemsSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Items", "version": "1.0.0"},
+  "paths": {
+    "/items": {"get": {"operationId": "list_items"}},
+    "/items/{id}": {"delete": {"operationId": "delete_item"}}
+  }
+}`
+
+// memoryIntegrations keeps integrations in memory; unused methods panic.
+type memoryIntegrations struct {
+	services.IntegrationService
+	integrations map[primitive.ObjectID]*models.Integration
+}
+
+func (s *memoryIntegrations) GetIntegration(ctx context.Context, id primitive.ObjectID) (*models.Integration, error) {
+	integration, ok := s.integrations[id]
+	if !ok {
+		return nil, errors.New("integration not found")
+	}
+	copied := *integration
+	return &copied, nil
+}
+
+func (s *memoryIntegrations) UpdateIntegration(ctx context.Context, id primitive.ObjectID, integration *models.Integration) (*models.Integration, error) {
+	s.integrations[id] = integration
+	return integration, nil
+}
+
+func TestUpdateToolOverrides(t *testing.T) {
+	id := primitive.NewObjectID()
+	store := &memoryIntegrations{integrations: map[primitive.ObjectID]*models.Integration{
+		id: {ID: id, Name: "items", OpenAPISpec: itemsSpec},
+	}}
+	controller := NewIntegrationController(store, nil, nil, zap.NewNop())
+	app := fiber.New()
+	app.Get("/integrations/:id/tool-overrides", controller.GetToolOverrides)
+	app.Put("/integrations/:id/tool-overrides", controller.UpdateToolOverrides)
+
+	put := func(body string) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPut, "/integrations/"+id.Hex()+"/tool-overrides", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := put(`{"drop_items": {"destructiveHint": false}}`); status != fiber.StatusBadRequest {
+		t.Fatalf("override of an unknown tool: status = %d, want %d", status, fiber.StatusBadRequest)
+	}
+	if store.integrations[id].ToolOverrides != nil {
+		t.Fatal("override of an unknown tool was stored")
+	}
+
+	if status := put(`{"delete_item": {"destructiveHint": false, "title": "Archive item"}}`); status != fiber.StatusOK {
+		t.Fatalf("override of delete_item: status = %d, want %d", status, fiber.StatusOK)
+	}
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/integrations/"+id.Hex()+"/tool-overrides", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		Effective map[string]models.ToolAnnotations `json:"effective"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	deleteItem := body.Effective["delete_item"]
+	if deleteItem.Title != "Archive item" || deleteItem.IsDestructive() {
+		t.Fatalf("effective delete_item annotations = %+v, want the non-destructive override", deleteItem)
+	}
+
+	if status := put(`{}`); status != fiber.StatusOK {
+		t.Fatalf("clearing overrides: status = %d, want %d", status, fiber.StatusOK)
+	}
+	if store.integrations[id].ToolOverrides != nil {
+		t.Fatalf("overrides after clearing = %v, want none", store.integrations[id].ToolOverrides)
+	}
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// LinkedAccountController handles the requests for a user's linked integration accounts.
+type LinkedAccountController struct {
+	linkedAccountService services.LinkedAccountService
+	integrationService   services.IntegrationService
+	logger               *zap.Logger
+}
+
+// NewLinkedAccountController creates a new LinkedAccountController.
+func NewLinkedAccountController(linkedAccountService services.LinkedAccountService, integrationService services.IntegrationService, logger *zap.Logger) *LinkedAccountController {
+	return &LinkedAccountController{
+		linkedAccountService: linkedAccountService,
+		integrationService:   integrationService,
+		logger:               logger,
+	}
+}
+
+// CreateLinkedAccount links the caller's credentials to an integration.
+func (c *LinkedAccountController) CreateLinkedAccount(ctx fiber.Ctx) error {
+	userID, ok := ctx.Locals("user_id").(string)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req models.CreateLinkedAccountRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	integrationID, err := primitive.ObjectIDFromHex(req.IntegrationID)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid integration ID"})
+	}
+	if _, err := c.integrationService.GetIntegration(ctx.Context(), integrationID); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "integration not found"})
+	}
+
+	account, err := c.linkedAccountService.CreateLinkedAccount(ctx.Context(), userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrLinkedAccountExists) {
+			return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, services.ErrOwnerNotManaged) {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.logger.Warn("Failed to create linked account", zap.String("userID", userID), zap.Error(err))
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(account)
+}
+
+// ListLinkedAccounts lists the linked accounts managed by the caller.
+func (c *LinkedAccountController) ListLinkedAccounts(ctx fiber.Ctx) error {
+	userID, ok := ctx.Locals("user_id").(string)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	accounts, err := c.linkedAccountService.ListLinkedAccounts(ctx.Context(), userID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list linked accounts"})
+	}
+	if accounts == nil {
+		accounts = []*models.LinkedAccount{}
+	}
+
+	return ctx.JSON(accounts)
+}
+
+// UpdateLinkedAccount enables or disables a linked account.
+func (c *LinkedAccountController) UpdateLinkedAccount(ctx fiber.Ctx) error {
+	account, ferr := c.ownedAccount(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := ctx.Bind().Body(&req); err != nil || req.Enabled == nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "enabled is required"})
+	}
+
+	if err := c.linkedAccountService.SetEnabled(ctx.Context(), account.ID, *req.Enabled); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update linked account"})
+	}
+	account.Enabled = *req.Enabled
+
+	return ctx.JSON(account)
+}
+
+// DeleteLinkedAccount unlinks an account.
+func (c *LinkedAccountController) DeleteLinkedAccount(ctx fiber.Ctx) error {
+	account, ferr := c.ownedAccount(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	if err := c.linkedAccountService.DeleteLinkedAccount(ctx.Context(), account.ID); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to delete linked account"})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// ListExecutions returns the audit trail of tools executed with a linked account.
+func (c *LinkedAccountController) ListExecutions(ctx fiber.Ctx) error {
+	account, ferr := c.ownedAccount(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	limit, _ := strconv.ParseInt(ctx.Query("limit", "100"), 10, 64)
+	executions, err := c.linkedAccountService.ListExecutions(ctx.Context(), account.ID, limit)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list executions"})
+	}
+	if executions == nil {
+		executions = []*models.ToolExecution{}
+	}
+
+	return ctx.JSON(executions)
+}
+
+// ownedAccount loads the account named by the :accountID parameter and checks that the caller manages it.
+func (c *LinkedAccountController) ownedAccount(ctx fiber.Ctx) (*models.LinkedAccount, *fiber.Error) {
+	userID, ok := ctx.Locals("user_id").(string)
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	accountID, err := primitive.ObjectIDFromHex(ctx.Params("accountID"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid linked account ID")
+	}
+
+	account, err := c.linkedAccountService.GetLinkedAccount(ctx.Context(), accountID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "linked account not found")
+	}
+
+	if account.UserID != userID {
+		return nil, fiber.NewError(fiber.StatusForbidden, "forbidden")
+	}
+
+	return account, nil
+}
@@ -2,40 +2,59 @@ package controllers
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 
-	"github.com/AkashKesav/API2SDK/internal/crypto"
 	"github.com/AkashKesav/API2SDK/internal/mcp"
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/openapi"
+	"github.com/AkashKesav/API2SDK/internal/outbound"
 	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/AkashKesav/API2SDK/internal/signing"
+	"github.com/AkashKesav/API2SDK/internal/utils"
 	"github.com/gofiber/fiber/v3"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 )
 
 // MCPController handles the requests for the unified MCP system.
 type MCPController struct {
-	mcpInstanceService services.MCPInstanceService
-	integrationService services.IntegrationService
-	mcpManager         *mcp.MCPManager
-	logger             *zap.Logger
+	mcpInstanceService   services.MCPInstanceService
+	integrationService   services.IntegrationService
+	linkedAccountService services.LinkedAccountService
+	hostingService       services.MCPHostingService
+	mcpManager           *mcp.MCPManager
+	httpClient           *http.Client
+	logger               *zap.Logger
 }
 
 // NewMCPController creates a new MCPController.
 func NewMCPController(
 	mcpInstanceService services.MCPInstanceService,
 	integrationService services.IntegrationService,
+	linkedAccountService services.LinkedAccountService,
+	hostingService services.MCPHostingService,
 	mcpManager *mcp.MCPManager,
+	httpClient *http.Client,
 	logger *zap.Logger,
 ) *MCPController {
 	return &MCPController{
-		mcpInstanceService: mcpInstanceService,
-		integrationService: integrationService,
-		mcpManager:         mcpManager,
-		logger:             logger,
+		mcpInstanceService:   mcpInstanceService,
+		integrationService:   integrationService,
+		linkedAccountService: linkedAccountService,
+		hostingService:       hostingService,
+		mcpManager:           mcpManager,
+		httpClient:           httpClient,
+		logger:               logger,
 	}
 }
 
@@ -60,9 +79,21 @@ func (c *MCPController) StartServer(ctx fiber.Ctx) error {
 		config.TransportType = "sse" // Default to SSE
 	}
 
-	if config.TransportType == "sse" && config.Port == 0 {
+	if config.TransportType != "stdio" && config.Port == 0 {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "port is required for SSE transport",
+			"error": fmt.Sprintf("port is required for %s transport", config.TransportType),
+		})
+	}
+
+	if config.Type == mcp.ServerTypeBridge && len(config.Command) == 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "command is required for bridge server type",
+		})
+	}
+
+	if config.Type == mcp.ServerTypeGateway && len(config.Upstreams) == 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "upstreams are required for gateway server type",
 		})
 	}
 
@@ -77,7 +108,26 @@ func (c *MCPController) StartServer(ctx fiber.Ctx) error {
 		zap.String("transport", config.TransportType),
 		zap.Int("port", config.Port))
 
-	server, err := c.mcpManager.StartServer(&config)
+	// Servers belong to the signed-in user and act for a linked account owner it manages
+	ownerID, ok := ctx.Locals("user_id").(string)
+	if !ok || ownerID == "" {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	server, err := c.mcpManager.StartServer(ownerID, &config)
+	if errors.Is(err, mcp.ErrCommandNotAllowed) || errors.Is(err, services.ErrOwnerNotManaged) {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, mcp.ErrServerNotFound) {
+		// A gateway upstream refers to a server the caller does not have
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 	if err != nil {
 		c.logger.Error("Failed to start MCP server", zap.Error(err))
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -150,7 +200,8 @@ func (c *MCPController) ListServers(ctx fiber.Ctx) error {
 func (c *MCPController) GetMCPMetrics(ctx fiber.Ctx) error {
 	metrics := c.mcpManager.GetMetrics()
 	return ctx.JSON(fiber.Map{
-		"metrics": metrics,
+		"metrics":  metrics,
+		"outbound": utils.GetGlobalMetricsCollector(c.logger).GetMetrics(),
 	})
 }
 
@@ -181,8 +232,34 @@ func (c *MCPController) CleanupServers(ctx fiber.Ctx) error {
 
 // Legacy methods for backward compatibility
 
+// AuthorizeInstance lets requests for an instance of the authenticated user through. Instances
+// of other users are reported as not found.
+func (c *MCPController) AuthorizeInstance(ctx fiber.Ctx) error {
+	userID, ok := ctx.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return ctx.Status(fiber.StatusUnauthorized).SendString("Authentication required")
+	}
+	objId, err := primitive.ObjectIDFromHex(ctx.Params("instanceId"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString("Invalid instance ID")
+	}
+
+	mcpInstance, err := c.mcpInstanceService.GetMCPInstance(ctx.Context(), objId)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && mcpInstance.UserID != userID) {
+		return ctx.Status(fiber.StatusNotFound).SendString("MCP instance not found")
+	}
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).SendString("Failed to get MCP instance")
+	}
+	return ctx.Next()
+}
+
 // HandleRequest handles all incoming requests to the MCP (legacy method).
 func (c *MCPController) HandleRequest(ctx fiber.Ctx) error {
+	if handled, err := c.proxyHosted(ctx); handled {
+		return err
+	}
+
 	path := ctx.Params("*")
 	if strings.HasSuffix(path, "/list_tools") {
 		return c.ListTools(ctx)
@@ -214,14 +291,27 @@ func (c *MCPController) HandleRequest(ctx fiber.Ctx) error {
 		return ctx.Status(fiber.StatusInternalServerError).SendString("Failed to get integration")
 	}
 
-	// Decrypt the API key
-	decryptedAPIKey, err := crypto.Decrypt(string(integration.APIKey))
+	// Resolve the instance owner's credentials, refreshing OAuth2 tokens as needed
+	credentials, err := c.linkedAccountService.ResolveCredentials(ctx.Context(), integration, mcpInstance.UserID)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).SendString("Failed to decrypt API key")
+		c.logger.Warn("Failed to resolve credentials", zap.String("instanceId", instanceId), zap.Error(err))
+		return ctx.Status(fiber.StatusUnauthorized).SendString("Failed to resolve credentials: " + err.Error())
+	}
+
+	// Sign the request the way the integration's security scheme expects
+	var doc *openapi.Document
+	if integration.OpenAPISpec != "" {
+		if doc, err = openapi.Parse([]byte(integration.OpenAPISpec)); err != nil {
+			c.logger.Warn("Failed to parse integration spec", zap.String("integration", integration.Name), zap.Error(err))
+		}
+	}
+	signer, err := services.NewRequestSigner(integration, doc, nil)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
 	// Forward the request to the integration's API
-	return forwardRequest(ctx, integration.BaseURL, decryptedAPIKey)
+	return forwardRequest(ctx, c.httpClient, integration, credentials, signer)
 }
 
 // ListTools returns a list of all tools provided by the integration (legacy method).
@@ -258,6 +348,9 @@ func (c *MCPController) CallTool(ctx fiber.Ctx) error {
 	}
 
 	result, err := c.mcpInstanceService.ExecuteToolCall(ctx.Context(), objId, body.ToolName, body.Arguments)
+	if errors.Is(err, services.ErrOwnerNotManaged) {
+		return ctx.Status(fiber.StatusForbidden).SendString(err.Error())
+	}
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
@@ -266,13 +359,22 @@ func (c *MCPController) CallTool(ctx fiber.Ctx) error {
 }
 
 // forwardRequest handles the logic of forwarding the request to the target API.
-func forwardRequest(c fiber.Ctx, baseURL string, apiKey string) error {
+// The path is normalized so it cannot escape the integration's base URL, and the request is sent
+// with the shared outbound client, which enforces the SSRF, redirect, timeout and size limits.
+func forwardRequest(c fiber.Ctx, client *http.Client, integration *models.Integration, credentials *services.Credentials, signer signing.Signer) error {
 	// Construct the target URL
-	path := c.Params("*")
-	targetURL := fmt.Sprintf("%s/%s", strings.TrimSuffix(baseURL, "/"), path)
+	target, err := outbound.JoinPath(integration.BaseURL, c.Params("*"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid target path: " + err.Error())
+	}
+	target.RawQuery = string(c.Request().URI().QueryString())
 
 	// Create a new request
-	req, err := http.NewRequestWithContext(c.Context(), string(c.Method()), targetURL, strings.NewReader(string(c.Body())))
+	reqCtx := outbound.WithIntegration(c.Context(), integration.Name)
+	if credentials != nil {
+		reqCtx = outbound.WithCredentials(reqCtx)
+	}
+	req, err := http.NewRequestWithContext(reqCtx, string(c.Method()), target.String(), bytes.NewReader(c.Body()))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to create request")
 	}
@@ -291,21 +393,29 @@ func forwardRequest(c fiber.Ctx, baseURL string, apiKey string) error {
 		}
 	})
 
-	// Add the API key to the request if it exists
-	if apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+apiKey)
+	// Add the credentials to the request if there are any
+	if err := credentials.Sign(req, signer); err != nil {
+		return c.Status(fiber.StatusUnauthorized).SendString("Failed to sign request: " + err.Error())
 	}
 
 	// Send the request
-	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
+		if errors.Is(err, outbound.ErrBlockedDestination) || errors.Is(err, outbound.ErrUnsupportedScheme) {
+			return c.Status(fiber.StatusForbidden).SendString("Destination is not allowed")
+		}
+		if errors.Is(err, outbound.ErrCredentialedRedirect) {
+			return c.Status(fiber.StatusBadGateway).SendString("Upstream redirected to another host")
+		}
 		return c.Status(fiber.StatusBadGateway).SendString("Failed to forward request")
 	}
 	defer resp.Body.Close()
 
-	// Copy headers from the response
+	// Copy headers from the response, except those that describe the upstream connection
 	for key, values := range resp.Header {
+		if hopHeaders[http.CanonicalHeaderKey(key)] {
+			continue
+		}
 		for _, value := range values {
 			c.Set(key, value)
 		}
@@ -316,14 +426,31 @@ func forwardRequest(c fiber.Ctx, baseURL string, apiKey string) error {
 
 	// Copy the body
 	if _, err := io.Copy(c.Response().BodyWriter(), resp.Body); err != nil {
+		c.Response().ResetBody()
+		if errors.Is(err, outbound.ErrResponseTooLarge) {
+			return c.Status(fiber.StatusBadGateway).SendString("Upstream response is too large")
+		}
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to copy response body")
 	}
 
 	return nil
 }
 
+// hopHeaders are upstream response headers that must not be copied to the client.
+var hopHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Keep-Alive":        true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+}
+
 // StreamTool handles SSE connections for streaming tool results (legacy method).
 func (c *MCPController) StreamTool(ctx fiber.Ctx) error {
+	if handled, err := c.proxyHosted(ctx); handled {
+		return err
+	}
+
 	ctx.Set("Content-Type", "text/event-stream")
 	ctx.Set("Cache-Control", "no-cache")
 	ctx.Set("Connection", "keep-alive")
@@ -379,3 +506,131 @@ func (c *MCPController) StreamTool(ctx fiber.Ctx) error {
 
 	return nil
 }
+
+const (
+	// hostedProxyTimeout bounds a request to a hosted server, up to the end of its response
+	// unless that is an event stream.
+	hostedProxyTimeout = 60 * time.Second
+	// hostedStreamIdleTimeout closes event streams the hosted server sent nothing on for so long.
+	hostedStreamIdleTimeout = 5 * time.Minute
+)
+
+// hostedProxyClient forwards requests to hosted servers on the loopback interface. It is not the
+// outbound client, which rejects local destinations. Its own timeouts cover connecting and waiting
+// for the response headers; proxyHosted bounds the rest, so that active streams stay open.
+var hostedProxyClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext:           (&net.Dialer{Timeout: 5 * time.Second}).DialContext,
+		ResponseHeaderTimeout: 30 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// hostedProxyDroppedHeaders are request headers that are not forwarded to hosted servers: the
+// caller's credentials for this platform and the request's own host.
+var hostedProxyDroppedHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"Host":          true,
+	"X-Api-Key":     true,
+}
+
+// proxyHosted forwards a request for an instance with a hosted server to that server. The routes
+// only call it for the instance's owner. It reports false for instances without a deployment,
+// which are served by the platform itself.
+func (c *MCPController) proxyHosted(ctx fiber.Ctx) (bool, error) {
+	instanceId := ctx.Params("instanceId")
+	origin, err := c.hostingService.Endpoint(instanceId)
+	if errors.Is(err, services.ErrNotHosted) {
+		return false, nil
+	}
+	if err != nil {
+		return true, ctx.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// The matched route's remainder after the instance ID is the path on the hosted server, so
+	// both the /instances and the old-format routes forward the same path.
+	_, path, _ := strings.Cut(ctx.Route().Path, ":instanceId/")
+	if path == "*" {
+		path = ctx.Params("*")
+	}
+	target, err := outbound.JoinPath(origin, path)
+	if err != nil {
+		return true, ctx.Status(fiber.StatusBadRequest).SendString("Invalid target path: " + err.Error())
+	}
+	target.RawQuery = string(ctx.Request().URI().QueryString())
+
+	// The deadline cancels requests that take too long and, once reset, streams that are idle.
+	reqCtx, cancel := context.WithCancel(context.Background())
+	deadline := time.AfterFunc(hostedProxyTimeout, cancel)
+	stop := func() {
+		deadline.Stop()
+		cancel()
+	}
+	req, err := http.NewRequestWithContext(reqCtx, string(ctx.Method()), target.String(), bytes.NewReader(ctx.Body()))
+	if err != nil {
+		stop()
+		return true, ctx.Status(fiber.StatusInternalServerError).SendString("Failed to create request")
+	}
+	ctx.Request().Header.VisitAll(func(key, value []byte) {
+		name := http.CanonicalHeaderKey(string(key))
+		if !hopHeaders[name] && !hostedProxyDroppedHeaders[name] {
+			req.Header.Add(name, string(value))
+		}
+	})
+
+	resp, err := hostedProxyClient.Do(req)
+	if err != nil {
+		stop()
+		c.logger.Warn("Failed to reach hosted MCP server", zap.String("instanceId", instanceId), zap.Error(err))
+		return true, ctx.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "hosted server is not reachable"})
+	}
+
+	for key, values := range resp.Header {
+		if hopHeaders[http.CanonicalHeaderKey(key)] {
+			continue
+		}
+		for _, value := range values {
+			ctx.Set(key, value)
+		}
+	}
+	ctx.Status(resp.StatusCode)
+
+	// Event streams are relayed as they arrive; everything else is buffered like forwardRequest.
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		deadline.Reset(hostedStreamIdleTimeout)
+		ctx.Response().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer stop()
+			defer resp.Body.Close()
+			buf := make([]byte, 32*1024)
+			for {
+				n, readErr := resp.Body.Read(buf)
+				if n > 0 {
+					deadline.Reset(hostedStreamIdleTimeout)
+					if _, err := w.Write(buf[:n]); err != nil {
+						return
+					}
+					// A failed flush means the client went away; cancelling stops the upstream stream.
+					if err := w.Flush(); err != nil {
+						return
+					}
+				}
+				if readErr != nil {
+					return
+				}
+			}
+		})
+		return true, nil
+	}
+
+	defer stop()
+	defer resp.Body.Close()
+	if _, err := io.Copy(ctx.Response().BodyWriter(), resp.Body); err != nil {
+		ctx.Response().ResetBody()
+		return true, ctx.Status(fiber.StatusBadGateway).SendString("Failed to read hosted server response")
+	}
+	return true, nil
+}
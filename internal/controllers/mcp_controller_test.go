@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// fixedHosting serves every instance from one origin; unused methods panic.
+type fixedHosting struct {
+	services.MCPHostingService
+	origin string
+}
+
+func (h *fixedHosting) Endpoint(instanceID string) (string, error) {
+	return h.origin, nil
+}
+
+func TestProxyHostedForwardsRoutePath(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.URL.Path+"?"+r.URL.RawQuery)
+	}))
+	defer upstream.Close()
+
+	controller := NewMCPController(nil, nil, nil, &fixedHosting{origin: upstream.URL}, nil, nil, zap.NewNop())
+	app := fiber.New()
+	// The same routes as the MCP router, which also runs AuthorizeInstance first.
+	mcp := app.Group("/mcp")
+	mcp.Get("/instances/:instanceId/sse", controller.StreamTool)
+	mcp.All("/instances/:instanceId/*", controller.HandleRequest)
+	mcp.Get("/:instanceId/sse", controller.StreamTool)
+	mcp.All("/:instanceId/*", controller.HandleRequest)
+
+	const instanceID = "64b7f0c2e4b0a1a2b3c4d5e6"
+	tests := map[string]string{
+		"/mcp/instances/" + instanceID + "/sse":           "/sse?",
+		"/mcp/instances/" + instanceID + "/messages?s=1": "/messages?s=1",
+		"/mcp/" + instanceID + "/sse":                     "/sse?",
+		"/mcp/" + instanceID + "/messages?s=1":            "/messages?s=1",
+	}
+	for path, want := range tests {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(body) != want {
+			t.Errorf("GET %s reached %d %q, want %q", path, resp.StatusCode, body, want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// OAuthController handles the OAuth2 authorization-code flow that links accounts to integrations.
+type OAuthController struct {
+	oauth2Service        services.OAuth2Service
+	linkedAccountService services.LinkedAccountService
+	integrationService   services.IntegrationService
+	logger               *zap.Logger
+}
+
+// NewOAuthController creates a new OAuthController.
+func NewOAuthController(oauth2Service services.OAuth2Service, linkedAccountService services.LinkedAccountService, integrationService services.IntegrationService, logger *zap.Logger) *OAuthController {
+	return &OAuthController{
+		oauth2Service:        oauth2Service,
+		linkedAccountService: linkedAccountService,
+		integrationService:   integrationService,
+		logger:               logger,
+	}
+}
+
+// Authorize starts the flow for an integration and redirects to the provider's consent screen.
+// Clients that accept JSON get the URL back instead of a redirect.
+func (c *OAuthController) Authorize(ctx fiber.Ctx) error {
+	userID, ok := ctx.Locals("user_id").(string)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	integrationID, err := primitive.ObjectIDFromHex(ctx.Params("integrationID"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid integration ID"})
+	}
+
+	returnTo := ctx.Query("return_to")
+	if !isLocalPath(returnTo) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "return_to must be a path on this server"})
+	}
+
+	// Accounts can only be linked for owners the caller manages or claims
+	ownerID := ctx.Query("owner_id")
+	if ownerID != "" {
+		err := c.linkedAccountService.AuthorizeNewOwner(ctx.Context(), userID, ownerID)
+		switch {
+		case errors.Is(err, services.ErrInvalidOwnerID):
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, services.ErrOwnerNotManaged):
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		case err != nil:
+			c.logger.Error("Failed to check linked account owner", zap.String("ownerID", ownerID), zap.Error(err))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to start authorization"})
+		}
+	}
+
+	authURL, err := c.oauth2Service.AuthorizationURL(ctx.Context(), integrationID, userID, ownerID, returnTo)
+	if err != nil {
+		if errors.Is(err, services.ErrOAuth2NotConfigured) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.logger.Error("Failed to start OAuth2 flow", zap.String("integrationID", integrationID.Hex()), zap.Error(err))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to start authorization"})
+	}
+
+	if strings.Contains(ctx.Get(fiber.HeaderAccept), fiber.MIMEApplicationJSON) {
+		return ctx.JSON(fiber.Map{"authorizationUrl": authURL})
+	}
+	return ctx.Redirect().Status(fiber.StatusFound).To(authURL)
+}
+
+// Callback completes the flow when the provider redirects back with a code.
+func (c *OAuthController) Callback(ctx fiber.Ctx) error {
+	if providerErr := ctx.Query("error"); providerErr != "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":       "authorization was not granted",
+			"reason":      providerErr,
+			"description": ctx.Query("error_description"),
+		})
+	}
+
+	state, code := ctx.Query("state"), ctx.Query("code")
+	if state == "" || code == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "state and code are required"})
+	}
+
+	account, returnTo, err := c.oauth2Service.HandleCallback(ctx.Context(), state, code)
+	if err != nil {
+		if errors.Is(err, services.ErrOAuth2InvalidState) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.logger.Warn("OAuth2 callback failed", zap.Error(err))
+		return ctx.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "failed to complete authorization"})
+	}
+
+	if returnTo != "" {
+		return ctx.Redirect().Status(fiber.StatusFound).To(returnTo)
+	}
+	return ctx.JSON(account)
+}
+
+// Revoke revokes an OAuth2 linked account's tokens with the provider and unlinks it.
+func (c *OAuthController) Revoke(ctx fiber.Ctx) error {
+	userID, ok := ctx.Locals("user_id").(string)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	accountID, err := primitive.ObjectIDFromHex(ctx.Params("accountID"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid linked account ID"})
+	}
+	account, err := c.linkedAccountService.GetLinkedAccount(ctx.Context(), accountID)
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "linked account not found"})
+	}
+	if account.UserID != userID {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
+	}
+	if account.AuthType != models.LinkedAccountAuthOAuth2 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "linked account does not use OAuth2"})
+	}
+
+	if err := c.oauth2Service.Revoke(ctx.Context(), account); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to revoke linked account"})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// GetConfig returns the OAuth2 config of an integration, or the one derived from its spec.
+func (c *OAuthController) GetConfig(ctx fiber.Ctx) error {
+	integrationID, err := primitive.ObjectIDFromHex(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid integration ID"})
+	}
+	integration, err := c.integrationService.GetIntegration(ctx.Context(), integrationID)
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "integration not found"})
+	}
+	if integration.OAuth2 != nil {
+		return ctx.JSON(fiber.Map{"configured": true, "config": integration.OAuth2})
+	}
+
+	derived, err := c.oauth2Service.DeriveConfig(integration, ctx.Query("scheme"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(fiber.Map{"configured": false, "config": derived})
+}
+
+// UpdateConfig sets the OAuth2 client credentials of an integration.
+func (c *OAuthController) UpdateConfig(ctx fiber.Ctx) error {
+	integrationID, err := primitive.ObjectIDFromHex(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid integration ID"})
+	}
+
+	var req models.UpdateOAuth2ConfigRequest
+	if err := ctx.Bind().Body(&req); err != nil || req.ClientID == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "clientId is required"})
+	}
+
+	integration, err := c.oauth2Service.UpdateConfig(ctx.Context(), integrationID, &req)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.JSON(integration.OAuth2)
+}
+
+// isLocalPath reports whether target is empty or a path on this server, so the
+// callback cannot be used as an open redirect.
+func isLocalPath(target string) bool {
+	if target == "" {
+		return true
+	}
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.Contains(target, "\\") {
+		return false
+	}
+	u, err := url.Parse(target)
+	return err == nil && u.Scheme == "" && u.Host == ""
+}
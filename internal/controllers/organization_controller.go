@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// OrganizationController handles the requests for organizations, their members and invitations.
+type OrganizationController struct {
+	organizationService services.OrganizationService
+	logger              *zap.Logger
+}
+
+// NewOrganizationController creates a new OrganizationController.
+func NewOrganizationController(organizationService services.OrganizationService, logger *zap.Logger) *OrganizationController {
+	return &OrganizationController{
+		organizationService: organizationService,
+		logger:              logger,
+	}
+}
+
+// CreateOrganization creates an organization owned by the caller.
+func (c *OrganizationController) CreateOrganization(ctx fiber.Ctx) error {
+	userID, ok := ctx.Locals("userID").(primitive.ObjectID)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req models.CreateOrganizationRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	organization, err := c.organizationService.CreateOrganization(ctx.Context(), userID, &req)
+	if err != nil {
+		return c.errorResponse(ctx, "create organization", err)
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(organization)
+}
+
+// ListOrganizations lists the caller's organizations with the caller's role in each.
+func (c *OrganizationController) ListOrganizations(ctx fiber.Ctx) error {
+	userID, ok := ctx.Locals("userID").(primitive.ObjectID)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	organizations, err := c.organizationService.ListOrganizations(ctx.Context(), userID)
+	if err != nil {
+		return c.errorResponse(ctx, "list organizations", err)
+	}
+	return ctx.JSON(organizations)
+}
+
+// GetOrganization returns one of the caller's organizations.
+func (c *OrganizationController) GetOrganization(ctx fiber.Ctx) error {
+	userID, organizationID, ferr := organizationParams(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	organization, err := c.organizationService.GetOrganization(ctx.Context(), userID, organizationID)
+	if err != nil {
+		return c.errorResponse(ctx, "get organization", err)
+	}
+	return ctx.JSON(organization)
+}
+
+// RenameOrganization renames an organization; only owners may.
+func (c *OrganizationController) RenameOrganization(ctx fiber.Ctx) error {
+	userID, organizationID, ferr := organizationParams(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	var req models.CreateOrganizationRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	organization, err := c.organizationService.RenameOrganization(ctx.Context(), userID, organizationID, &req)
+	if err != nil {
+		return c.errorResponse(ctx, "rename organization", err)
+	}
+	return ctx.JSON(organization)
+}
+
+// DeleteOrganization deletes an organization; only owners may.
+func (c *OrganizationController) DeleteOrganization(ctx fiber.Ctx) error {
+	userID, organizationID, ferr := organizationParams(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	if err := c.organizationService.DeleteOrganization(ctx.Context(), userID, organizationID); err != nil {
+		return c.errorResponse(ctx, "delete organization", err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// ListMembers lists the members of an organization.
+func (c *OrganizationController) ListMembers(ctx fiber.Ctx) error {
+	userID, organizationID, ferr := organizationParams(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	members, err := c.organizationService.ListMembers(ctx.Context(), userID, organizationID)
+	if err != nil {
+		return c.errorResponse(ctx, "list members", err)
+	}
+	return ctx.JSON(members)
+}
+
+// UpdateMember changes the role of a member.
+func (c *OrganizationController) UpdateMember(ctx fiber.Ctx) error {
+	userID, organizationID, ferr := organizationParams(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	memberID, err := primitive.ObjectIDFromHex(ctx.Params("userID"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+
+	var req models.UpdateMemberRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	if err := c.organizationService.UpdateMemberRole(ctx.Context(), userID, organizationID, memberID, req.Role); err != nil {
+		return c.errorResponse(ctx, "update member", err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// RemoveMember removes a member; members may remove themselves to leave the organization.
+func (c *OrganizationController) RemoveMember(ctx fiber.Ctx) error {
+	userID, organizationID, ferr := organizationParams(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	memberID, err := primitive.ObjectIDFromHex(ctx.Params("userID"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+
+	if err := c.organizationService.RemoveMember(ctx.Context(), userID, organizationID, memberID); err != nil {
+		return c.errorResponse(ctx, "remove member", err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// CreateInvitation invites an email address to an organization. The response is the only
+// time the invitation token is shown.
+func (c *OrganizationController) CreateInvitation(ctx fiber.Ctx) error {
+	userID, organizationID, ferr := organizationParams(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	var req models.CreateInvitationRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	invitation, err := c.organizationService.CreateInvitation(ctx.Context(), userID, organizationID, &req)
+	if err != nil {
+		return c.errorResponse(ctx, "create invitation", err)
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(invitation)
+}
+
+// ListInvitations lists the pending invitations of an organization.
+func (c *OrganizationController) ListInvitations(ctx fiber.Ctx) error {
+	userID, organizationID, ferr := organizationParams(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	invitations, err := c.organizationService.ListInvitations(ctx.Context(), userID, organizationID)
+	if err != nil {
+		return c.errorResponse(ctx, "list invitations", err)
+	}
+	return ctx.JSON(invitations)
+}
+
+// RevokeInvitation deletes a pending invitation.
+func (c *OrganizationController) RevokeInvitation(ctx fiber.Ctx) error {
+	userID, organizationID, ferr := organizationParams(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	invitationID, err := primitive.ObjectIDFromHex(ctx.Params("invitationID"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid invitation ID"})
+	}
+
+	if err := c.organizationService.RevokeInvitation(ctx.Context(), userID, organizationID, invitationID); err != nil {
+		return c.errorResponse(ctx, "revoke invitation", err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// AcceptInvitation adds the caller to the organization of an invitation sent to their email address.
+func (c *OrganizationController) AcceptInvitation(ctx fiber.Ctx) error {
+	userID, ok := ctx.Locals("userID").(primitive.ObjectID)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req models.AcceptInvitationRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	organization, err := c.organizationService.AcceptInvitation(ctx.Context(), userID, req.Token)
+	if err != nil {
+		return c.errorResponse(ctx, "accept invitation", err)
+	}
+	return ctx.JSON(organization)
+}
+
+// errorResponse maps organization service errors to responses.
+func (c *OrganizationController) errorResponse(ctx fiber.Ctx, action string, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidOrganizationRequest):
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrOrganizationNotFound),
+		errors.Is(err, services.ErrMemberNotFound),
+		errors.Is(err, services.ErrInvitationNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrPermissionDenied):
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrLastOwner):
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	c.logger.Error("Failed to "+action, zap.Error(err))
+	return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to " + action})
+}
+
+// organizationParams returns the caller and the organization of the :orgID parameter.
+func organizationParams(ctx fiber.Ctx) (primitive.ObjectID, primitive.ObjectID, *fiber.Error) {
+	userID, ok := ctx.Locals("userID").(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	organizationID, err := primitive.ObjectIDFromHex(ctx.Params("orgID"))
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, fiber.NewError(fiber.StatusBadRequest, "invalid organization ID")
+	}
+	return userID, organizationID, nil
+}
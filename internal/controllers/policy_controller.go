@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/AkashKesav/API2SDK/internal/middleware"
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// PolicyController explains permission decisions and manages the custom roles of organizations.
+type PolicyController struct {
+	policyService services.PolicyService
+	logger        *zap.Logger
+}
+
+// NewPolicyController creates a new PolicyController.
+func NewPolicyController(policyService services.PolicyService, logger *zap.Logger) *PolicyController {
+	return &PolicyController{
+		policyService: policyService,
+		logger:        logger,
+	}
+}
+
+// ExplainPermission reports whether the caller has the permission of the "permission" query
+// parameter, in the organization of "organization_id" if given, and why.
+func (c *PolicyController) ExplainPermission(ctx fiber.Ctx) error {
+	userID, ok := ctx.Locals("userID").(primitive.ObjectID)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	permission := models.Permission(ctx.Query("permission"))
+	role := models.UserRole(middleware.GetUserRole(ctx))
+	decision, err := c.policyService.Explain(ctx.Context(), userID, role, permission, ctx.Query("organization_id"))
+	if err != nil {
+		return c.errorResponse(ctx, "explain permission", err)
+	}
+	return ctx.JSON(decision)
+}
+
+// ListRoles lists the built-in and custom roles of an organization.
+func (c *PolicyController) ListRoles(ctx fiber.Ctx) error {
+	userID, organizationID, ferr := organizationParams(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	roles, err := c.policyService.ListRoles(ctx.Context(), userID, organizationID)
+	if err != nil {
+		return c.errorResponse(ctx, "list roles", err)
+	}
+	return ctx.JSON(roles)
+}
+
+// CreateRole defines a custom role of an organization.
+func (c *PolicyController) CreateRole(ctx fiber.Ctx) error {
+	userID, organizationID, ferr := organizationParams(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	var req models.RoleRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	role, err := c.policyService.CreateRole(ctx.Context(), userID, organizationID, &req)
+	if err != nil {
+		return c.errorResponse(ctx, "create role", err)
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(role)
+}
+
+// UpdateRole changes the description and permissions of a custom role.
+func (c *PolicyController) UpdateRole(ctx fiber.Ctx) error {
+	userID, organizationID, ferr := organizationParams(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	roleID, err := primitive.ObjectIDFromHex(ctx.Params("roleID"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid role ID"})
+	}
+
+	var req models.RoleRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	role, err := c.policyService.UpdateRole(ctx.Context(), userID, organizationID, roleID, &req)
+	if err != nil {
+		return c.errorResponse(ctx, "update role", err)
+	}
+	return ctx.JSON(role)
+}
+
+// DeleteRole deletes a custom role no member has.
+func (c *PolicyController) DeleteRole(ctx fiber.Ctx) error {
+	userID, organizationID, ferr := organizationParams(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	roleID, err := primitive.ObjectIDFromHex(ctx.Params("roleID"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid role ID"})
+	}
+
+	if err := c.policyService.DeleteRole(ctx.Context(), userID, organizationID, roleID); err != nil {
+		return c.errorResponse(ctx, "delete role", err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// errorResponse maps policy service errors to responses.
+func (c *PolicyController) errorResponse(ctx fiber.Ctx, action string, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidPermission), errors.Is(err, services.ErrInvalidRoleRequest):
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrOrganizationNotFound), errors.Is(err, services.ErrRoleNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrPermissionDenied):
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrRoleInUse):
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	c.logger.Error("Failed to "+action, zap.Error(err))
+	return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to " + action})
+}
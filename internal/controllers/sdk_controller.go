@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"math"
 	"strconv"
 	"strings"
@@ -22,16 +23,18 @@ type SDKController struct {
 	sdkService              services.SDKServiceInterface
 	collectionService       *services.CollectionService // Added CollectionService
 	platformSettingsService services.PlatformSettingsService
+	auditService            services.AuditService
 	logger                  *zap.Logger
 	validate                *validator.Validate // Added validator instance
 }
 
 // NewSDKController creates a new SDKController.
-func NewSDKController(sdkService services.SDKServiceInterface, collectionService *services.CollectionService, platformSettingsService services.PlatformSettingsService, logger *zap.Logger) *SDKController {
+func NewSDKController(sdkService services.SDKServiceInterface, collectionService *services.CollectionService, platformSettingsService services.PlatformSettingsService, auditService services.AuditService, logger *zap.Logger) *SDKController {
 	return &SDKController{
 		sdkService:              sdkService,
 		collectionService:       collectionService, // Initialize CollectionService
 		platformSettingsService: platformSettingsService,
+		auditService:            auditService,
 		logger:                  logger,
 		validate:                validator.New(), // Initialize validator
 	}
@@ -63,18 +66,19 @@ func (ctrl *SDKController) GenerateSDK(c fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Validation failed", err.Error())
 	}
 
-	_, err := ctrl.collectionService.GetCollectionByIDAndUser(context.Background(), req.CollectionID, userIDStr)
+	collection, err := ctrl.collectionService.GetCollectionForUser(context.Background(), req.CollectionID, userIDStr, models.ActionWrite)
 	if err != nil {
-		ctrl.logger.Error("Failed to verify collection ownership or collection not found", zap.String("collectionID", req.CollectionID), zap.String("userID", userIDStr), zap.Error(err))
+		ctrl.logger.Error("Failed to verify collection access or collection not found", zap.String("collectionID", req.CollectionID), zap.String("userID", userIDStr), zap.Error(err))
 		return utils.ErrorResponse(c, fiber.StatusForbidden, "Access to collection denied or collection not found", err.Error())
 	}
 
 	initialSDKRecord := &models.SDK{
-		UserID:       userIDStr,
-		CollectionID: req.CollectionID,
-		Language:     req.Language,
-		PackageName:  req.PackageName,
-		Status:       models.SDKStatusPending,
+		UserID:         userIDStr,
+		OrganizationID: collection.OrganizationID,
+		CollectionID:   req.CollectionID,
+		Language:       req.Language,
+		PackageName:    req.PackageName,
+		Status:         models.SDKStatusPending,
 	}
 
 	createdRecord, err := ctrl.sdkService.CreateSDKRecord(context.Background(), initialSDKRecord)
@@ -83,10 +87,13 @@ func (ctrl *SDKController) GenerateSDK(c fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to initialize SDK generation", err.Error())
 	}
 	recordID := createdRecord.ID
+	// Generation outlives the request; its outcome is audited with the request's metadata
+	auditCtx := context.WithoutCancel(c.Context())
 
 	go func() {
 		bgCtx := context.Background()
 		generatedSDK, genErr := ctrl.sdkService.GenerateSDK(bgCtx, &req, recordID)
+		ctrl.auditGeneration(auditCtx, createdRecord, genErr)
 
 		if genErr != nil {
 			ctrl.logger.Error("SDK generation failed in goroutine", zap.Error(genErr), zap.String("recordID", recordID.Hex()))
@@ -126,19 +133,21 @@ func (ctrl *SDKController) GenerateMCP(c fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Validation failed", err.Error())
 	}
 
-	_, err := ctrl.collectionService.GetCollectionByIDAndUser(context.Background(), req.CollectionID, userIDStr)
+	collection, err := ctrl.collectionService.GetCollectionForUser(context.Background(), req.CollectionID, userIDStr, models.ActionWrite)
 	if err != nil {
-		ctrl.logger.Error("Failed to verify collection ownership or collection not found for MCP gen", zap.String("collectionID", req.CollectionID), zap.String("userID", userIDStr), zap.Error(err))
+		ctrl.logger.Error("Failed to verify collection access or collection not found for MCP gen", zap.String("collectionID", req.CollectionID), zap.String("userID", userIDStr), zap.Error(err))
 		return utils.ErrorResponse(c, fiber.StatusForbidden, "Access to collection denied or collection not found", err.Error())
 	}
 
 	initialSDKRecord := &models.SDK{
 		UserID:         userIDStr,
+		OrganizationID: collection.OrganizationID,
 		CollectionID:   req.CollectionID,
 		GenerationType: models.GenerationTypeMCP,
 		Status:         models.SDKStatusPending,
 		MCPTransport:   req.Transport,
 		MCPPort:        req.Port,
+		MCPLanguage:    req.Language,
 	}
 
 	createdRecord, err := ctrl.sdkService.CreateSDKRecord(context.Background(), initialSDKRecord)
@@ -147,10 +156,12 @@ func (ctrl *SDKController) GenerateMCP(c fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to initialize MCP generation", err.Error())
 	}
 	recordID := createdRecord.ID
+	auditCtx := context.WithoutCancel(c.Context())
 
 	go func() {
 		bgCtx := context.Background()
 		generatedMCP, genErr := ctrl.sdkService.GenerateMCP(bgCtx, &req, recordID)
+		ctrl.auditGeneration(auditCtx, createdRecord, genErr)
 
 		if genErr != nil {
 			ctrl.logger.Error("MCP generation failed in goroutine", zap.Error(genErr), zap.String("recordID", recordID.Hex()))
@@ -307,9 +318,44 @@ func (ctrl *SDKController) DownloadSDK(c fiber.Ctx) error {
 	}
 
 	ctrl.logger.Info("Attempting to download SDK file", zap.String("filePath", sdk.FilePath), zap.String("downloadAs", downloadFilename))
+	ctrl.auditService.Record(c.Context(), &models.AuditEvent{
+		Action:         models.AuditActionSDKDownload,
+		OrganizationID: sdk.OrganizationID,
+		TargetType:     models.AuditTargetSDK,
+		TargetID:       sdk.ID.Hex(),
+		Metadata:       sdkAuditMetadata(sdk),
+	})
 	return c.Download(sdk.FilePath, downloadFilename)
 }
 
+// auditGeneration records the outcome of an SDK or MCP server generation.
+func (ctrl *SDKController) auditGeneration(ctx context.Context, sdk *models.SDK, genErr error) {
+	event := &models.AuditEvent{
+		Action:         models.AuditActionSDKGenerate,
+		OrganizationID: sdk.OrganizationID,
+		TargetType:     models.AuditTargetSDK,
+		TargetID:       sdk.ID.Hex(),
+		Metadata:       sdkAuditMetadata(sdk),
+	}
+	if genErr != nil {
+		event.Outcome = models.AuditOutcomeFailure
+		event.Metadata["error"] = genErr.Error()
+	}
+	ctrl.auditService.Record(ctx, event)
+}
+
+// sdkAuditMetadata describes an SDK in audit events.
+func sdkAuditMetadata(sdk *models.SDK) map[string]string {
+	metadata := map[string]string{"collectionId": sdk.CollectionID}
+	if sdk.GenerationType == models.GenerationTypeMCP {
+		metadata["generationType"] = string(models.GenerationTypeMCP)
+		metadata["language"] = sdk.MCPLanguage
+	} else {
+		metadata["language"] = sdk.Language
+	}
+	return metadata
+}
+
 // DeleteSDK handles the request to delete an SDK.
 func (ctrl *SDKController) DeleteSDK(c fiber.Ctx) error {
 	sdkID := c.Params("id")
@@ -331,6 +377,9 @@ func (ctrl *SDKController) DeleteSDK(c fiber.Ctx) error {
 	serviceErr := ctrl.sdkService.DeleteSDK(context.Background(), objectSdkID, userIDStr)
 	if serviceErr != nil {
 		ctrl.logger.Error("Failed to delete SDK", zap.Error(serviceErr), zap.String("sdkID", sdkID), zap.String("userID", userIDStr))
+		if errors.Is(serviceErr, services.ErrPermissionDenied) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Not allowed to delete this SDK", serviceErr.Error())
+		}
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to delete SDK", serviceErr.Error())
 	}
 
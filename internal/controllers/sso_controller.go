@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/AkashKesav/API2SDK/internal/utils"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// SSOController handles single sign-on with an OpenID Connect provider and its settings.
+type SSOController struct {
+	oidcService services.OIDCService
+	logger      *zap.Logger
+}
+
+// NewSSOController creates a new SSOController.
+func NewSSOController(oidcService services.OIDCService, logger *zap.Logger) *SSOController {
+	return &SSOController{
+		oidcService: oidcService,
+		logger:      logger,
+	}
+}
+
+// Login starts a sign-on and redirects to the provider. Clients that accept JSON get the URL
+// back instead of a redirect.
+func (sc *SSOController) Login(c fiber.Ctx) error {
+	returnTo := c.Query("return_to")
+	if !isLocalPath(returnTo) {
+		return utils.BadRequestResponse(c, "Invalid return_to", "return_to must be a path on this server")
+	}
+
+	authURL, err := sc.oidcService.AuthorizationURL(c.Context(), returnTo)
+	if errors.Is(err, services.ErrOIDCNotConfigured) {
+		return utils.NotFoundResponse(c, err.Error())
+	}
+	if err != nil {
+		sc.logger.Error("Failed to start single sign-on", zap.Error(err))
+		return utils.ErrorResponse(c, fiber.StatusBadGateway, "Failed to start single sign-on", "")
+	}
+
+	if strings.Contains(c.Get(fiber.HeaderAccept), fiber.MIMEApplicationJSON) {
+		return utils.SuccessResponse(c, "Single sign-on started", fiber.Map{"authorizationUrl": authURL})
+	}
+	return c.Redirect().Status(fiber.StatusFound).To(authURL)
+}
+
+// Callback completes a sign-on when the provider redirects back with a code. Sign-ons started
+// with a return_to redirect there with the tokens in the URL fragment, which browsers do not send
+// to servers; others get the tokens like a password login.
+func (sc *SSOController) Callback(c fiber.Ctx) error {
+	if providerErr := c.Query("error"); providerErr != "" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Sign-in was not completed", providerErr+": "+c.Query("error_description"))
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		return utils.BadRequestResponse(c, "Invalid callback", "state and code are required")
+	}
+
+	tokens, user, returnTo, err := sc.oidcService.HandleCallback(c.Context(), state, code, clientInfo(c))
+	switch {
+	case errors.Is(err, services.ErrOIDCInvalidState):
+		return utils.BadRequestResponse(c, "Invalid callback", err.Error())
+	case errors.Is(err, services.ErrOIDCNotConfigured):
+		return utils.NotFoundResponse(c, err.Error())
+	case errors.Is(err, services.ErrOIDCInvalidIDToken):
+		sc.logger.Warn("Rejected OIDC ID token", zap.Error(err))
+		return utils.UnauthorizedResponse(c, err.Error())
+	case errors.Is(err, services.ErrOIDCAccessDenied):
+		return utils.ForbiddenResponse(c, err.Error())
+	case err != nil:
+		sc.logger.Error("Single sign-on failed", zap.Error(err))
+		return utils.ErrorResponse(c, fiber.StatusBadGateway, "Single sign-on failed", "")
+	}
+
+	if returnTo != "" {
+		fragment := url.Values{
+			"access_token":  {tokens.AccessToken},
+			"refresh_token": {tokens.RefreshToken},
+			"token_type":    {tokens.TokenType},
+			"expires_in":    {strconv.Itoa(tokens.ExpiresIn)},
+		}
+		return c.Redirect().Status(fiber.StatusFound).To(returnTo + "#" + fragment.Encode())
+	}
+	return utils.SuccessResponse(c, "Login successful", tokenResponse(tokens, user))
+}
+
+// GetSettings returns the OIDC settings; the client secret is never returned.
+func (sc *SSOController) GetSettings(c fiber.Ctx) error {
+	settings, err := sc.oidcService.GetSettings(c.Context())
+	if err != nil {
+		sc.logger.Error("Failed to get OIDC settings", zap.Error(err))
+		return utils.InternalServerErrorResponse(c, "Failed to retrieve OIDC settings", "")
+	}
+	return utils.SuccessResponse(c, "Successfully retrieved OIDC settings", fiber.Map{
+		"settings":        settings,
+		"clientSecretSet": settings.ClientSecret != "",
+		"redirectUrlPath": services.OIDCCallbackPath,
+	})
+}
+
+// UpdateSettings replaces the OIDC settings.
+func (sc *SSOController) UpdateSettings(c fiber.Ctx) error {
+	var req models.UpdateOIDCSettingsRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return utils.BadRequestResponse(c, "Invalid request payload", err.Error())
+	}
+
+	settings, err := sc.oidcService.UpdateSettings(c.Context(), &req)
+	if errors.Is(err, services.ErrInvalidOIDCSettings) {
+		return utils.BadRequestResponse(c, "Invalid OIDC settings", err.Error())
+	}
+	if err != nil {
+		sc.logger.Error("Failed to update OIDC settings", zap.Error(err))
+		return utils.InternalServerErrorResponse(c, "Failed to update OIDC settings", "")
+	}
+	return utils.SuccessResponse(c, "OIDC settings updated successfully", settings)
+}
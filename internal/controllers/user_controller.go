@@ -82,7 +82,7 @@ func (uc *UserController) UpdateMe(c fiber.Ctx) error {
 
 // GetAllUsers handles GET /api/v1/admin/users - retrieves all users (admin only)
 func (uc *UserController) GetAllUsers(c fiber.Ctx) error {
-	// This route is protected by AdminRequired middleware
+	// This route requires the users:read permission
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "10"))
 
@@ -1,6 +1,10 @@
 package controllers
 
 import (
+	"context"
+	"errors"
+	"strconv"
+
 	"github.com/AkashKesav/API2SDK/internal/models"
 	"github.com/AkashKesav/API2SDK/internal/services"
 	"github.com/gofiber/fiber/v3"
@@ -11,13 +15,17 @@ import (
 type UserMCPController struct {
 	mcpInstanceService services.MCPInstanceService
 	integrationService services.IntegrationService
+	hostingService     services.MCPHostingService
+	authorizer         services.ResourceAuthorizer
 }
 
 // NewUserMCPController creates a new UserMCPController.
-func NewUserMCPController(mcpInstanceService services.MCPInstanceService, integrationService services.IntegrationService) *UserMCPController {
+func NewUserMCPController(mcpInstanceService services.MCPInstanceService, integrationService services.IntegrationService, hostingService services.MCPHostingService, authorizer services.ResourceAuthorizer) *UserMCPController {
 	return &UserMCPController{
 		mcpInstanceService: mcpInstanceService,
 		integrationService: integrationService,
+		hostingService:     hostingService,
+		authorizer:         authorizer,
 	}
 }
 
@@ -36,6 +44,21 @@ func (c *UserMCPController) CreateMCPInstance(ctx fiber.Ctx) error {
 		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
 	}
 
+	// Integrations of an organization are only available to its members
+	integrationID, err := primitive.ObjectIDFromHex(req.IntegrationID)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid integration ID"})
+	}
+	integration, err := c.integrationService.GetIntegration(ctx.Context(), integrationID)
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "integration not found"})
+	}
+	if integration.OrganizationID != "" {
+		if err := c.authorizer.Authorize(ctx.Context(), userID, "", integration.OrganizationID, models.ResourceIntegrations, models.ActionRead); err != nil {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "integration not found"})
+		}
+	}
+
 	mcpInstance := &models.MCPInstance{
 		UserID:        userID,
 		IntegrationID: req.IntegrationID,
@@ -86,6 +109,12 @@ func (c *UserMCPController) DeleteMCPInstance(ctx fiber.Ctx) error {
 		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
 	}
 
+	if instance.Hosting != nil {
+		if err := c.hostingService.Undeploy(ctx.Context(), objID); err != nil && !errors.Is(err, services.ErrNotHosted) {
+			return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "failed to remove hosted server: " + err.Error()})
+		}
+	}
+
 	if err := c.mcpInstanceService.DeleteMCPInstance(ctx.Context(), objID); err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to delete MCP instance"})
 	}
@@ -122,3 +151,126 @@ func (c *UserMCPController) ListResources(ctx fiber.Ctx) error {
 
 	return ctx.JSON(resources)
 }
+
+// DeployHostedServer deploys a generated MCP server for an instance and runs it on the platform.
+func (c *UserMCPController) DeployHostedServer(ctx fiber.Ctx) error {
+	objID, ferr := c.ownedInstance(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	var req models.DeployMCPHostingRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	sdkID, err := primitive.ObjectIDFromHex(req.SDKID)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid SDK ID"})
+	}
+
+	record, err := c.hostingService.Deploy(ctx.Context(), objID, sdkID)
+	if err != nil {
+		return hostingError(ctx, err)
+	}
+	return ctx.Status(fiber.StatusAccepted).JSON(record)
+}
+
+// GetHostedServer returns the hosting state of an instance.
+func (c *UserMCPController) GetHostedServer(ctx fiber.Ctx) error {
+	objID, ferr := c.ownedInstance(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	record, err := c.hostingService.Status(ctx.Context(), objID)
+	if err != nil {
+		return hostingError(ctx, err)
+	}
+	return ctx.JSON(record)
+}
+
+// DeleteHostedServer stops the hosted server of an instance and removes its deployment.
+func (c *UserMCPController) DeleteHostedServer(ctx fiber.Ctx) error {
+	objID, ferr := c.ownedInstance(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	if err := c.hostingService.Undeploy(ctx.Context(), objID); err != nil {
+		return hostingError(ctx, err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// StartHostedServer starts the hosted server of an instance.
+func (c *UserMCPController) StartHostedServer(ctx fiber.Ctx) error {
+	return c.controlHostedServer(ctx, c.hostingService.Start)
+}
+
+// StopHostedServer stops the hosted server of an instance.
+func (c *UserMCPController) StopHostedServer(ctx fiber.Ctx) error {
+	return c.controlHostedServer(ctx, c.hostingService.Stop)
+}
+
+// RestartHostedServer restarts the hosted server of an instance.
+func (c *UserMCPController) RestartHostedServer(ctx fiber.Ctx) error {
+	return c.controlHostedServer(ctx, c.hostingService.Restart)
+}
+
+// GetHostedServerLogs returns the most recent build and process output of the hosted server.
+// The tail query parameter limits the number of lines (default 200).
+func (c *UserMCPController) GetHostedServerLogs(ctx fiber.Ctx) error {
+	objID, ferr := c.ownedInstance(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	tail, err := strconv.Atoi(ctx.Query("tail", "200"))
+	if err != nil || tail < 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "tail must be a non-negative number"})
+	}
+	return ctx.JSON(fiber.Map{"lines": c.hostingService.Logs(objID, tail)})
+}
+
+func (c *UserMCPController) controlHostedServer(ctx fiber.Ctx, action func(ctx context.Context, instanceID primitive.ObjectID) (*models.MCPHosting, error)) error {
+	objID, ferr := c.ownedInstance(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	record, err := action(ctx.Context(), objID)
+	if err != nil {
+		return hostingError(ctx, err)
+	}
+	return ctx.JSON(record)
+}
+
+// ownedInstance returns the ID of the instance in the route if it belongs to the authenticated user.
+func (c *UserMCPController) ownedInstance(ctx fiber.Ctx) (primitive.ObjectID, *fiber.Error) {
+	objID, err := primitive.ObjectIDFromHex(ctx.Params("instanceID"))
+	if err != nil {
+		return primitive.NilObjectID, fiber.NewError(fiber.StatusBadRequest, "invalid instance ID")
+	}
+	userID, ok := ctx.Locals("user_id").(string)
+	if !ok {
+		return primitive.NilObjectID, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	instance, err := c.mcpInstanceService.GetMCPInstance(ctx.Context(), objID)
+	if err != nil {
+		return primitive.NilObjectID, fiber.NewError(fiber.StatusNotFound, "instance not found")
+	}
+	if instance.UserID != userID {
+		return primitive.NilObjectID, fiber.NewError(fiber.StatusForbidden, "forbidden")
+	}
+	return objID, nil
+}
+
+// hostingError maps hosting service errors onto responses.
+func hostingError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrNotHosted):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrHostingBusy):
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidArtifact):
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
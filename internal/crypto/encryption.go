@@ -5,61 +5,302 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"regexp"
+	"strings"
+	"sync"
 
 	"github.com/AkashKesav/API2SDK/configs"
 )
 
-// Encrypt encrypts a string using AES-GCM.
-func Encrypt(stringToEncrypt string) (string, error) {
-	key := []byte(configs.GetEncryptionKey())
-	plaintext := []byte(stringToEncrypt)
+// ciphertextPrefix starts every value encrypted with envelope encryption. The rest of the value is
+// "<key ID>:<wrapped data key>:<payload>", both base64url-encoded. Values without the prefix were
+// encrypted directly with a master key before key IDs were introduced.
+const ciphertextPrefix = "enc:v1:"
 
-	block, err := aes.NewCipher(key)
+// dataKeySize is the size of the AES-256 key generated for each encrypted value.
+const dataKeySize = 32
+
+var (
+	// ErrNotCiphertext is returned when decrypting a value that is not encrypted with any known
+	// key, such as a plaintext value stored before encryption was introduced.
+	ErrNotCiphertext = errors.New("value is not encrypted with a known key")
+	// ErrUnknownKeyID is returned when decrypting a value whose master key is not configured.
+	ErrUnknownKeyID = errors.New("unknown encryption key ID")
+	// ErrDecryptionFailed is returned for encrypted values that are corrupt or were tampered with.
+	ErrDecryptionFailed = errors.New("decryption failed")
+)
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Keyring holds the versioned master keys. Values are encrypted with a fresh data key, which is
+// wrapped by the active master key and stored with the value along with the master key's ID;
+// retired master keys only decrypt.
+type Keyring struct {
+	activeID string
+	keys     map[string]cipher.AEAD
+	// legacy are the master keys in the order they are tried for values without a key ID.
+	legacy []cipher.AEAD
+	strict bool
+}
+
+// NewKeyring creates a keyring whose active master key is keys[activeID]. Keys are 16, 24 or 32
+// bytes long, or 64 hex characters for 32 bytes. In strict mode values that are not encrypted
+// fail to decode instead of being read as plaintext.
+func NewKeyring(activeID string, keys map[string]string, strict bool) (*Keyring, error) {
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("no key with the active key ID %q", activeID)
+	}
+	keyring := &Keyring{activeID: activeID, keys: map[string]cipher.AEAD{}, strict: strict}
+	ids := []string{activeID}
+	for id := range keys {
+		if id != activeID {
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range ids {
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid key ID %q: use up to 32 letters, digits, '-' and '_'", id)
+		}
+		aead, err := newAEAD(parseKey(keys[id]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", id, err)
+		}
+		keyring.keys[id] = aead
+		keyring.legacy = append(keyring.legacy, aead)
+	}
+	return keyring, nil
+}
+
+// KeyringFromConfig creates the keyring of the configured master keys: ENCRYPTION_KEY under
+// ENCRYPTION_KEY_ID, and the retired keys of ENCRYPTION_PREVIOUS_KEYS.
+func KeyringFromConfig(config *configs.Config) (*Keyring, error) {
+	keys := map[string]string{config.EncryptionKeyID: config.EncryptionKey}
+	for _, entry := range config.EncryptionPreviousKeys {
+		id, key, ok := strings.Cut(entry, ":")
+		if !ok || id == "" || key == "" {
+			return nil, fmt.Errorf("ENCRYPTION_PREVIOUS_KEYS entries must be key-id:key")
+		}
+		if _, exists := keys[id]; exists {
+			return nil, fmt.Errorf("key ID %q is configured more than once", id)
+		}
+		keys[id] = key
+	}
+	return NewKeyring(config.EncryptionKeyID, keys, config.EncryptionStrict)
+}
+
+// ActiveKeyID returns the ID of the master key new values are encrypted with.
+func (k *Keyring) ActiveKeyID() string {
+	return k.activeID
+}
+
+// Strict reports whether values that are not encrypted are refused.
+func (k *Keyring) Strict() bool {
+	return k.strict
+}
+
+// Encrypt encrypts plaintext with a new data key wrapped by the active master key.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	payload, err := seal(dataAEAD, []byte(plaintext), nil)
 	if err != nil {
 		return "", err
 	}
+	return k.wrap(dataKey, payload)
+}
 
-	aesGCM, err := cipher.NewGCM(block)
+// Decrypt decrypts a value encrypted by Encrypt, or by a master key directly before key IDs
+// were introduced.
+func (k *Keyring) Decrypt(ciphertext string) (string, error) {
+	if !strings.HasPrefix(ciphertext, ciphertextPrefix) {
+		return k.decryptLegacy(ciphertext)
+	}
+	_, dataKey, payload, err := k.unwrap(ciphertext)
 	if err != nil {
 		return "", err
 	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataAEAD, payload, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Rotate returns ciphertext re-encrypted under the active master key and whether it changed.
+// For values already using envelope encryption only the data key is rewrapped. It returns
+// ErrNotCiphertext for values that are not encrypted.
+func (k *Keyring) Rotate(ciphertext string) (string, bool, error) {
+	if !strings.HasPrefix(ciphertext, ciphertextPrefix) {
+		plaintext, err := k.decryptLegacy(ciphertext)
+		if err != nil {
+			return "", false, err
+		}
+		rotated, err := k.Encrypt(plaintext)
+		return rotated, err == nil, err
+	}
+
+	keyID, dataKey, payload, err := k.unwrap(ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+	if keyID == k.activeID {
+		return ciphertext, false, nil
+	}
+	rotated, err := k.wrap(dataKey, payload)
+	return rotated, err == nil, err
+}
 
-	nonce := make([]byte, aesGCM.NonceSize())
-	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+// wrap encrypts a data key with the active master key and joins it with the payload.
+func (k *Keyring) wrap(dataKey, payload []byte) (string, error) {
+	wrapped, err := seal(k.keys[k.activeID], dataKey, []byte(ciphertextPrefix+k.activeID))
+	if err != nil {
 		return "", err
 	}
+	return ciphertextPrefix + k.activeID + ":" +
+		base64.RawURLEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawURLEncoding.EncodeToString(payload), nil
+}
 
-	ciphertext := aesGCM.Seal(nonce, nonce, plaintext, nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+// unwrap splits an envelope-encrypted value and decrypts its data key. The key ID is
+// authenticated with the data key, so a value cannot be relabelled with another key's ID.
+func (k *Keyring) unwrap(ciphertext string) (keyID string, dataKey, payload []byte, err error) {
+	parts := strings.Split(strings.TrimPrefix(ciphertext, ciphertextPrefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, fmt.Errorf("%w: malformed ciphertext", ErrDecryptionFailed)
+	}
+	keyID = parts[0]
+	masterAEAD, ok := k.keys[keyID]
+	if !ok {
+		return "", nil, nil, fmt.Errorf("%w %q", ErrUnknownKeyID, keyID)
+	}
+	wrapped, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("%w: malformed data key", ErrDecryptionFailed)
+	}
+	payload, err = base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("%w: malformed payload", ErrDecryptionFailed)
+	}
+	dataKey, err = open(masterAEAD, wrapped, []byte(ciphertextPrefix+keyID))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return keyID, dataKey, payload, nil
 }
 
-// Decrypt decrypts a string using AES-GCM.
-func Decrypt(encryptedString string) (string, error) {
-	key := []byte(configs.GetEncryptionKey())
-	enc, _ := base64.StdEncoding.DecodeString(encryptedString)
+// decryptLegacy decrypts a value encrypted directly with one of the master keys.
+func (k *Keyring) decryptLegacy(ciphertext string) (string, error) {
+	enc, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", ErrNotCiphertext
+	}
+	for _, aead := range k.legacy {
+		if plaintext, err := open(aead, enc, nil); err == nil {
+			return string(plaintext), nil
+		}
+	}
+	return "", ErrNotCiphertext
+}
+
+// parseKey returns the bytes of a configured key: 64 hex characters encode 32 bytes, and
+// anything else is used as is, as it was before keys could be given in hex.
+func parseKey(key string) []byte {
+	if len(key) == 64 {
+		if decoded, err := hex.DecodeString(key); err == nil {
+			return decoded
+		}
+	}
+	return []byte(key)
+}
 
+func newAEAD(key []byte) (cipher.AEAD, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	return cipher.NewGCM(block)
+}
 
-	aesGCM, err := cipher.NewGCM(block)
+// seal encrypts with a random nonce, which is prepended to the ciphertext.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts the output of seal.
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	nonceSize := aead.NonceSize()
+	if len(sealed) < nonceSize+aead.Overhead() {
+		return nil, fmt.Errorf("%w: ciphertext too short", ErrDecryptionFailed)
+	}
+	plaintext, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], additionalData)
 	if err != nil {
-		return "", err
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+var (
+	defaultMu      sync.RWMutex
+	defaultKeyring *Keyring
+)
+
+// SetDefaultKeyring sets the keyring Encrypt and Decrypt use.
+func SetDefaultKeyring(keyring *Keyring) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultKeyring = keyring
+}
+
+// DefaultKeyring returns the keyring set by SetDefaultKeyring, or else the keyring of the global
+// configuration.
+func DefaultKeyring() (*Keyring, error) {
+	defaultMu.RLock()
+	keyring := defaultKeyring
+	defaultMu.RUnlock()
+	if keyring != nil {
+		return keyring, nil
 	}
 
-	nonceSize := aesGCM.NonceSize()
-	if len(enc) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
+	keyring, err := KeyringFromConfig(configs.GetConfig())
+	if err != nil {
+		return nil, err
 	}
+	SetDefaultKeyring(keyring)
+	return keyring, nil
+}
 
-	nonce, ciphertext := enc[:nonceSize], enc[nonceSize:]
-	plaintext, err := aesGCM.Open(nil, nonce, ciphertext, nil)
+// Encrypt encrypts a string with the default keyring.
+func Encrypt(stringToEncrypt string) (string, error) {
+	keyring, err := DefaultKeyring()
 	if err != nil {
 		return "", err
 	}
+	return keyring.Encrypt(stringToEncrypt)
+}
 
-	return string(plaintext), nil
+// Decrypt decrypts a string with the default keyring.
+func Decrypt(encryptedString string) (string, error) {
+	keyring, err := DefaultKeyring()
+	if err != nil {
+		return "", err
+	}
+	return keyring.Decrypt(encryptedString)
 }
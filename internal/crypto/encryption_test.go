@@ -0,0 +1,157 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/configs"
+)
+
+const (
+	oldKey = "0123456789abcdef0123456789abcdef"
+	newKey = "570c77d71926aff4c71cf019179516c56912ff8fcdc9a373dd56b6ea5ef92168"
+)
+
+func mustKeyring(t *testing.T, activeID string, keys map[string]string, strict bool) *Keyring {
+	t.Helper()
+	keyring, err := NewKeyring(activeID, keys, strict)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	return keyring
+}
+
+// legacyEncrypt encrypts like Encrypt did before key IDs were introduced.
+func legacyEncrypt(t *testing.T, key, plaintext string) string {
+	t.Helper()
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := seal(aead, []byte(plaintext), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(sealed)
+}
+
+func TestKeyringRoundTrip(t *testing.T) {
+	keyring := mustKeyring(t, "k1", map[string]string{"k1": newKey}, false)
+
+	first, err := keyring.Encrypt("s3cret")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	second, _ := keyring.Encrypt("s3cret")
+	if !strings.HasPrefix(first, "enc:v1:k1:") || first == second {
+		t.Errorf("Encrypt() = %q, %q, want distinct values prefixed with the key ID", first, second)
+	}
+	if plaintext, err := keyring.Decrypt(first); err != nil || plaintext != "s3cret" {
+		t.Errorf("Decrypt() = %q, %v, want the plaintext", plaintext, err)
+	}
+	if empty, _ := keyring.Encrypt(""); empty == "" {
+		t.Error("Encrypt(\"\") returned an empty value")
+	}
+}
+
+func TestKeyringDecryptErrors(t *testing.T) {
+	keyring := mustKeyring(t, "k1", map[string]string{"k1": newKey}, false)
+	ciphertext, _ := keyring.Encrypt("s3cret")
+	parts := strings.Split(ciphertext, ":")
+
+	other := mustKeyring(t, "k2", map[string]string{"k2": oldKey}, false)
+	if _, err := other.Decrypt(ciphertext); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("Decrypt() with a missing key error = %v, want ErrUnknownKeyID", err)
+	}
+
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[4])
+	payload[len(payload)-1] ^= 1
+	parts[4] = base64.RawURLEncoding.EncodeToString(payload)
+	if _, err := keyring.Decrypt(strings.Join(parts, ":")); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("Decrypt() of a tampered value error = %v, want ErrDecryptionFailed", err)
+	}
+
+	// The key ID is authenticated, so relabelling a value with another key's ID fails even when
+	// that ID names the same key
+	relabelled := mustKeyring(t, "k1", map[string]string{"k1": newKey, "k9": newKey}, false)
+	if _, err := relabelled.Decrypt(strings.Replace(ciphertext, "enc:v1:k1:", "enc:v1:k9:", 1)); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("Decrypt() of a relabelled value error = %v, want ErrDecryptionFailed", err)
+	}
+
+	for _, value := range []string{"plain text", "aGVsbG8gd29ybGQ="} {
+		if _, err := keyring.Decrypt(value); !errors.Is(err, ErrNotCiphertext) {
+			t.Errorf("Decrypt(%q) error = %v, want ErrNotCiphertext", value, err)
+		}
+	}
+}
+
+func TestKeyringDecryptsLegacyValues(t *testing.T) {
+	keyring := mustKeyring(t, "k2", map[string]string{"k2": newKey, "k1": oldKey}, false)
+	legacy := legacyEncrypt(t, oldKey, "s3cret")
+
+	if plaintext, err := keyring.Decrypt(legacy); err != nil || plaintext != "s3cret" {
+		t.Errorf("Decrypt() of a legacy value = %q, %v, want the plaintext", plaintext, err)
+	}
+}
+
+func TestKeyringRotate(t *testing.T) {
+	old := mustKeyring(t, "k1", map[string]string{"k1": oldKey}, false)
+	keyring := mustKeyring(t, "k2", map[string]string{"k2": newKey, "k1": oldKey}, false)
+	ciphertext, _ := old.Encrypt("s3cret")
+
+	rotated, changed, err := keyring.Rotate(ciphertext)
+	if err != nil || !changed || !strings.HasPrefix(rotated, "enc:v1:k2:") {
+		t.Fatalf("Rotate() = %q, %t, %v, want a value under k2", rotated, changed, err)
+	}
+	// Only the data key is rewrapped
+	if payload := func(value string) string { return value[strings.LastIndex(value, ":"):] }; payload(rotated) != payload(ciphertext) {
+		t.Error("Rotate() re-encrypted the payload, want only the data key rewrapped")
+	}
+	current := mustKeyring(t, "k2", map[string]string{"k2": newKey}, false)
+	if plaintext, err := current.Decrypt(rotated); err != nil || plaintext != "s3cret" {
+		t.Errorf("Decrypt() of the rotated value without k1 = %q, %v", plaintext, err)
+	}
+
+	if again, changed, err := keyring.Rotate(rotated); err != nil || changed || again != rotated {
+		t.Errorf("Rotate() of a current value = %t, %v, want it unchanged", changed, err)
+	}
+	fromLegacy, changed, err := keyring.Rotate(legacyEncrypt(t, oldKey, "s3cret"))
+	if err != nil || !changed || !strings.HasPrefix(fromLegacy, "enc:v1:k2:") {
+		t.Errorf("Rotate() of a legacy value = %q, %t, %v, want it encrypted under k2", fromLegacy, changed, err)
+	}
+	if _, _, err := keyring.Rotate("plain text"); !errors.Is(err, ErrNotCiphertext) {
+		t.Errorf("Rotate() of plaintext error = %v, want ErrNotCiphertext", err)
+	}
+}
+
+func TestKeyringFromConfig(t *testing.T) {
+	keyring, err := KeyringFromConfig(&configs.Config{
+		EncryptionKeyID:        "k2",
+		EncryptionKey:          newKey,
+		EncryptionPreviousKeys: []string{"k1:" + oldKey},
+		EncryptionStrict:       true,
+	})
+	if err != nil || keyring.ActiveKeyID() != "k2" || !keyring.Strict() || len(keyring.keys) != 2 {
+		t.Fatalf("KeyringFromConfig() = %+v, %v", keyring, err)
+	}
+
+	invalid := map[string]*configs.Config{
+		"short key":        {EncryptionKeyID: "k1", EncryptionKey: "short"},
+		"key ID":           {EncryptionKeyID: "k:1", EncryptionKey: oldKey},
+		"previous entry":   {EncryptionKeyID: "k1", EncryptionKey: oldKey, EncryptionPreviousKeys: []string{oldKey}},
+		"duplicate key ID": {EncryptionKeyID: "k1", EncryptionKey: oldKey, EncryptionPreviousKeys: []string{"k1:" + newKey}},
+	}
+	for name, config := range invalid {
+		if _, err := KeyringFromConfig(config); err == nil {
+			t.Errorf("KeyringFromConfig() with an invalid %s succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package hosting
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Languages of the artifacts that can be built.
+const (
+	LanguageGo         = "go"
+	LanguageTypeScript = "typescript"
+)
+
+const (
+	// maxArtifactBytes caps the unpacked size of an artifact.
+	maxArtifactBytes = 200 << 20
+	// maxArtifactFiles caps the number of entries in an artifact.
+	maxArtifactFiles = 10000
+)
+
+var (
+	// ErrUnsafeArtifact is returned for archives with entries outside the target directory or over the size limits.
+	ErrUnsafeArtifact = errors.New("unsafe artifact")
+	// ErrUnknownArtifact is returned when an unpacked artifact is neither a Go nor a Node.js project.
+	ErrUnknownArtifact = errors.New("artifact is not a Go or TypeScript MCP server project")
+)
+
+// Unpack extracts the zip archive at zipPath into dir, which must not exist yet.
+// Entries that would escape dir, links and archives over the size limits are rejected.
+func Unpack(zipPath, dir string) error {
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to open artifact: %w", err)
+	}
+	defer archive.Close()
+
+	if len(archive.File) > maxArtifactFiles {
+		return fmt.Errorf("%w: more than %d entries", ErrUnsafeArtifact, maxArtifactFiles)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var total int64
+	for _, file := range archive.File {
+		name := path.Clean(strings.ReplaceAll(file.Name, "\\", "/"))
+		if name == "." || name == "/" {
+			continue
+		}
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("%w: entry %q leaves the target directory", ErrUnsafeArtifact, file.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		mode := file.Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		case !mode.IsRegular():
+			return fmt.Errorf("%w: entry %q is not a regular file", ErrUnsafeArtifact, file.Name)
+		}
+
+		total += int64(file.UncompressedSize64)
+		if total > maxArtifactBytes {
+			return fmt.Errorf("%w: larger than %d bytes", ErrUnsafeArtifact, maxArtifactBytes)
+		}
+		if err := extractFile(file, target, mode.Perm()|0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractFile(file *zip.File, target string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	src, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_EXCL, perm&0755)
+	if err != nil {
+		return err
+	}
+	// The declared size is checked by the caller; never copy more than it.
+	_, copyErr := io.Copy(dst, io.LimitReader(src, int64(file.UncompressedSize64)))
+	if closeErr := dst.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	return copyErr
+}
+
+// DetectLanguage reports the language of an unpacked MCP server project.
+func DetectLanguage(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+		return LanguageGo, nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, "package.json")); err == nil {
+		return LanguageTypeScript, nil
+	}
+	return "", ErrUnknownArtifact
+}
+
+// Build compiles the project in dir and returns the command line that runs the server.
+// Build output is written to logs. env is the complete environment of the build tools.
+func Build(ctx context.Context, dir, language string, env []string, logs io.Writer) ([]string, error) {
+	var steps [][]string
+	var command []string
+	switch language {
+	case LanguageGo:
+		binary := filepath.Join(dir, "bin", "server")
+		steps = [][]string{{"go", "mod", "tidy"}, {"go", "build", "-o", binary, "."}}
+		command = []string{binary}
+	case LanguageTypeScript:
+		steps = [][]string{{"npm", "install", "--no-audit", "--no-fund"}, {"npm", "run", "build"}}
+		command = []string{"node", filepath.Join(dir, "dist", "index.js")}
+	default:
+		return nil, ErrUnknownArtifact
+	}
+
+	for _, step := range steps {
+		fmt.Fprintf(logs, "$ %s\n", strings.Join(step, " "))
+		cmd := exec.CommandContext(ctx, step[0], step[1:]...)
+		cmd.Dir = dir
+		cmd.Env = env
+		cmd.Stdout = logs
+		cmd.Stderr = logs
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("%s failed: %w", strings.Join(step[:2], " "), err)
+		}
+	}
+	return command, nil
+}
@@ -0,0 +1,158 @@
+package hosting
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// writeArchive creates a zip archive with the given entries and returns its path.
+func writeArchive(t *testing.T, entries map[string]string) string {
+	t.Helper()
+	zipPath := filepath.Join(t.TempDir(), "artifact.zip")
+	file, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := zip.NewWriter(file)
+	for name, content := range entries {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return zipPath
+}
+
+func TestUnpack(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "server")
+	if err := Unpack(writeArchive(t, map[string]string{"go.mod": "module server\n", "cmd/main.go": "package main\n"}), dir); err != nil {
+		t.Fatalf("Unpack() error = %v", err)
+	}
+	if language, err := DetectLanguage(dir); err != nil || language != LanguageGo {
+		t.Fatalf("DetectLanguage() = %q, %v, want %q", language, err, LanguageGo)
+	}
+
+	for _, name := range []string{"../escape.go", "/etc/cron.d/job", "a/../../escape.go", "..\\escape.go"} {
+		err := Unpack(writeArchive(t, map[string]string{name: "x"}), filepath.Join(t.TempDir(), "server"))
+		if !errors.Is(err, ErrUnsafeArtifact) {
+			t.Errorf("Unpack() of entry %q: error = %v, want %v", name, err, ErrUnsafeArtifact)
+		}
+	}
+
+	empty := t.TempDir()
+	if _, err := DetectLanguage(empty); !errors.Is(err, ErrUnknownArtifact) {
+		t.Errorf("DetectLanguage() of an empty directory: error = %v, want %v", err, ErrUnknownArtifact)
+	}
+}
+
+func TestLogBufferKeepsRecentLines(t *testing.T) {
+	logs := NewLogBuffer(2)
+	_, _ = logs.Write([]byte("one\ntwo\nthr"))
+	_, _ = logs.Write([]byte("ee\r\nfour"))
+
+	lines := logs.Tail(0)
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], " two") || !strings.HasSuffix(lines[1], " three") {
+		t.Fatalf("Tail() = %q, want two and three", lines)
+	}
+	if lines := logs.Tail(1); len(lines) != 1 || !strings.HasSuffix(lines[0], " three") {
+		t.Fatalf("Tail(1) = %q, want three", lines)
+	}
+}
+
+func TestSupervisorRestartsAndStops(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("no /bin/sh")
+	}
+
+	var mu sync.Mutex
+	var statuses []string
+	failed := make(chan State, 1)
+	supervisor := NewSupervisor(Limits{}, func(id string, state State) {
+		mu.Lock()
+		statuses = append(statuses, state.Status)
+		mu.Unlock()
+		if state.Status == StatusFailed {
+			select {
+			case failed <- state:
+			default:
+			}
+		}
+	}, zap.NewNop())
+
+	if err := supervisor.Start("crashing", Spec{Command: []string{"/bin/sh", "-c", "echo $SECRET started; exit 3"}, Env: Environment()}); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := supervisor.Start("crashing", Spec{Command: []string{"/bin/sh"}}); err == nil {
+		t.Fatal("Start() of a supervised ID succeeded")
+	}
+
+	select {
+	case state := <-failed:
+		if state.Restarts != 1 || state.NextRestartAt.IsZero() || state.LastError == "" {
+			t.Fatalf("failed state = %+v, want a scheduled restart", state)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the process did not exit")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := supervisor.Stop(ctx, "crashing"); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if _, ok := supervisor.State("crashing"); ok {
+		t.Fatal("a stopped process is still supervised")
+	}
+	if err := supervisor.Stop(ctx, "crashing"); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("Stop() of a stopped process: error = %v, want %v", err, ErrNotRunning)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if last := statuses[len(statuses)-1]; last != StatusStopped {
+		t.Fatalf("statuses = %v, want the last to be %s", statuses, StatusStopped)
+	}
+	if lines := supervisor.Logs("crashing").Tail(0); !containsLine(lines, " started") {
+		t.Fatalf("logs = %q, want the process output", lines)
+	}
+}
+
+func TestEnvironmentWithholdsPlatformSecrets(t *testing.T) {
+	t.Setenv("JWT_SECRET", "platform-secret")
+	t.Setenv("PATH", "/usr/bin")
+	env := Environment("PORT=8080")
+	joined := strings.Join(env, "\n")
+	if strings.Contains(joined, "platform-secret") {
+		t.Fatal("Environment() passes the platform's secrets through")
+	}
+	if !strings.Contains(joined, "PATH=/usr/bin") || env[len(env)-1] != "PORT=8080" {
+		t.Fatalf("Environment() = %v, want PATH and then PORT", env)
+	}
+}
+
+func containsLine(lines []string, suffix string) bool {
+	for _, line := range lines {
+		if strings.HasSuffix(line, suffix) {
+			return true
+		}
+	}
+	return false
+}
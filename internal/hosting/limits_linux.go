@@ -0,0 +1,77 @@
+//go:build linux
+
+package hosting
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// cpuPeriod is the cgroup cpu.max period in microseconds.
+const cpuPeriod = 100000
+
+// applyLimits runs cmd in its own process group, so stopping it also stops its children,
+// and wraps it in a shell that sets the ulimits before exec'ing the real command. The process
+// limit is left to the cgroup: RLIMIT_NPROC counts every process of the user, including the
+// other hosted servers and the API itself.
+func applyLimits(cmd *exec.Cmd, limits Limits) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	}
+
+	var ulimits []string
+	if limits.MemoryBytes > 0 {
+		// The data limit rather than the address space limit: runtimes such as V8 reserve
+		// far more address space than they ever use.
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -d %d", limits.MemoryBytes/1024))
+	}
+	if limits.MaxOpenFiles > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -n %d", limits.MaxOpenFiles))
+	}
+	if len(ulimits) == 0 {
+		return
+	}
+	script := strings.Join(ulimits, " && ") + ` && exec "$0" "$@"`
+	cmd.Args = append([]string{"sh", "-c", script}, cmd.Args...)
+	cmd.Path = "/bin/sh"
+}
+
+// joinCgroup moves pid into a new cgroup v2 group under limits.CgroupRoot with the
+// configured memory, CPU and process limits; pids.max is the only process limit applied. The returned cleanup removes the group.
+func joinCgroup(limits Limits, id string, pid int) (func(), error) {
+	noop := func() {}
+	if limits.CgroupRoot == "" {
+		return noop, nil
+	}
+	dir := filepath.Join(limits.CgroupRoot, "mcp-"+id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return noop, err
+	}
+	cleanup := func() { _ = os.Remove(dir) }
+
+	settings := map[string]string{}
+	if limits.MemoryBytes > 0 {
+		settings["memory.max"] = strconv.FormatInt(limits.MemoryBytes, 10)
+	}
+	if limits.CPUPercent > 0 {
+		settings["cpu.max"] = fmt.Sprintf("%d %d", cpuPeriod*limits.CPUPercent/100, cpuPeriod)
+	}
+	if limits.MaxProcesses > 0 {
+		settings["pids.max"] = strconv.Itoa(limits.MaxProcesses)
+	}
+	for file, value := range settings {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0644); err != nil {
+			return cleanup, fmt.Errorf("failed to set %s: %w", file, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0644); err != nil {
+		return cleanup, fmt.Errorf("failed to join cgroup: %w", err)
+	}
+	return cleanup, nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package hosting
+
+import "os/exec"
+
+// applyLimits is a no-op on platforms without process groups and ulimits support here.
+func applyLimits(cmd *exec.Cmd, limits Limits) {}
+
+// joinCgroup is a no-op on platforms without cgroups.
+func joinCgroup(limits Limits, id string, pid int) (func(), error) {
+	return func() {}, nil
+}
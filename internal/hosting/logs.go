@@ -0,0 +1,80 @@
+package hosting
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// maxLineBytes caps a single captured log line; longer lines are split.
+const maxLineBytes = 4096
+
+// LogBuffer keeps the most recent output lines of a process. It is an io.Writer,
+// so it can be used as the stdout and stderr of a command.
+type LogBuffer struct {
+	mu       sync.Mutex
+	lines    []string
+	next     int
+	full     bool
+	partial  []byte
+	maxLines int
+}
+
+// NewLogBuffer creates a buffer that keeps the last maxLines lines.
+func NewLogBuffer(maxLines int) *LogBuffer {
+	return &LogBuffer{lines: make([]string, maxLines), maxLines: maxLines}
+}
+
+// Write splits p into lines and stores the complete ones, prefixed with a timestamp.
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	data := append(b.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		b.add(string(bytes.TrimRight(data[:i], "\r")))
+		data = data[i+1:]
+	}
+	for len(data) > maxLineBytes {
+		b.add(string(data[:maxLineBytes]))
+		data = data[maxLineBytes:]
+	}
+	b.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// Note records a message from the supervisor itself, marked so it stands out from process output.
+func (b *LogBuffer) Note(message string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.add("[supervisor] " + message)
+}
+
+// add stores a line. Callers hold b.mu.
+func (b *LogBuffer) add(line string) {
+	b.lines[b.next] = time.Now().UTC().Format(time.RFC3339) + " " + line
+	b.next = (b.next + 1) % b.maxLines
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Tail returns up to n of the most recent lines, oldest first. n <= 0 returns all lines.
+func (b *LogBuffer) Tail(n int) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var ordered []string
+	if b.full {
+		ordered = append(ordered, b.lines[b.next:]...)
+	}
+	ordered = append(ordered, b.lines[:b.next]...)
+	if n > 0 && len(ordered) > n {
+		ordered = ordered[len(ordered)-n:]
+	}
+	return ordered
+}
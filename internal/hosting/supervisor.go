@@ -0,0 +1,324 @@
+// Package hosting runs generated MCP servers as supervised child processes.
+//
+// A Supervisor starts each process with resource limits and a minimal environment,
+// captures its output, and restarts it with exponential backoff when it exits unexpectedly.
+// Persisting what runs where is left to the caller, which is notified of every state change.
+package hosting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/backoff"
+	"go.uber.org/zap"
+)
+
+// Process statuses reported in State.
+const (
+	StatusStarting = "starting"
+	StatusRunning  = "running"
+	StatusStopped  = "stopped"
+	// StatusFailed is a process that exited unexpectedly and waits for its restart.
+	StatusFailed = "failed"
+)
+
+const (
+	// stableUptime is how long a process must stay up for its restart backoff to reset.
+	stableUptime = time.Minute
+	// stopGracePeriod is how long a process may take to exit after SIGTERM before it is killed.
+	stopGracePeriod = 10 * time.Second
+	// logLines is the number of output lines kept per process.
+	logLines = 1000
+)
+
+// ErrNotRunning is returned when stopping a process that is not supervised.
+var ErrNotRunning = errors.New("process is not running")
+
+// Limits bound the resources of a child process. Zero values leave a resource unlimited.
+type Limits struct {
+	MemoryBytes  int64
+	CPUPercent   int
+	MaxProcesses int // Enforced only through the cgroup's pids.max
+	MaxOpenFiles int
+	// CgroupRoot is a writable cgroup v2 directory under which each process gets its own
+	// cgroup. When empty, or on systems without cgroups, only ulimits are applied, so CPU and
+	// process limits are not enforced.
+	CgroupRoot string
+}
+
+// Spec describes a process to run.
+type Spec struct {
+	Command []string
+	Dir     string
+	// Env is the complete environment of the process; nothing is inherited.
+	Env []string
+}
+
+// State is the observed state of a supervised process.
+type State struct {
+	Status        string
+	PID           int
+	Restarts      int
+	LastError     string
+	StartedAt     time.Time
+	StoppedAt     time.Time
+	NextRestartAt time.Time
+}
+
+type process struct {
+	spec   Spec
+	state  State
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Supervisor runs and restarts child processes by ID.
+type Supervisor struct {
+	limits  Limits
+	onState func(id string, state State)
+	logger  *zap.Logger
+
+	mu    sync.Mutex
+	procs map[string]*process
+	logs  map[string]*LogBuffer
+}
+
+// NewSupervisor creates a Supervisor. onState is called, without locks held, after every state change.
+func NewSupervisor(limits Limits, onState func(id string, state State), logger *zap.Logger) *Supervisor {
+	if limits.MaxProcesses > 0 && limits.CgroupRoot == "" {
+		logger.Warn("The process limit of hosted processes is not enforced without a cgroup root", zap.Int("maxProcesses", limits.MaxProcesses))
+	}
+	return &Supervisor{
+		limits:  limits,
+		onState: onState,
+		logger:  logger,
+		procs:   make(map[string]*process),
+		logs:    make(map[string]*LogBuffer),
+	}
+}
+
+// Logs returns the output buffer of id, which outlives restarts of the process.
+func (s *Supervisor) Logs(id string) *LogBuffer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buffer, ok := s.logs[id]
+	if !ok {
+		buffer = NewLogBuffer(logLines)
+		s.logs[id] = buffer
+	}
+	return buffer
+}
+
+// Start runs spec under id until Stop is called, restarting it when it exits.
+// Starting an ID that is already supervised is an error.
+func (s *Supervisor) Start(id string, spec Spec) error {
+	if len(spec.Command) == 0 {
+		return fmt.Errorf("no command to run")
+	}
+	logs := s.Logs(id)
+
+	s.mu.Lock()
+	if p, ok := s.procs[id]; ok {
+		status := p.state.Status
+		s.mu.Unlock()
+		return fmt.Errorf("process %s is already supervised (status: %s)", id, status)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &process{spec: spec, cancel: cancel, done: make(chan struct{})}
+	s.procs[id] = p
+	s.mu.Unlock()
+
+	go s.supervise(ctx, id, p, logs)
+	return nil
+}
+
+// Stop terminates the process of id and waits for it to exit, or for ctx to end.
+func (s *Supervisor) Stop(ctx context.Context, id string) error {
+	s.mu.Lock()
+	p, ok := s.procs[id]
+	s.mu.Unlock()
+	if !ok {
+		return ErrNotRunning
+	}
+	p.cancel()
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// StopAll terminates every process, waiting until they exit or ctx ends.
+func (s *Supervisor) StopAll(ctx context.Context) {
+	s.mu.Lock()
+	ids := make([]string, 0, len(s.procs))
+	for id := range s.procs {
+		ids = append(ids, id)
+	}
+	s.mu.Unlock()
+	for _, id := range ids {
+		_ = s.Stop(ctx, id)
+	}
+}
+
+// State returns the state of id, and false when it is not supervised.
+func (s *Supervisor) State(id string) (State, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.procs[id]
+	if !ok {
+		return State{}, false
+	}
+	return p.state, true
+}
+
+// supervise runs the process until ctx is cancelled, restarting it with backoff.
+func (s *Supervisor) supervise(ctx context.Context, id string, p *process, logs *LogBuffer) {
+	defer close(p.done)
+	defer func() {
+		s.mu.Lock()
+		delete(s.procs, id)
+		s.mu.Unlock()
+	}()
+
+	for {
+		started := time.Now()
+		err := s.run(ctx, id, p, logs)
+		stopped := time.Now()
+
+		if ctx.Err() != nil {
+			logs.Note("stopped")
+			s.update(id, p, func(state *State) {
+				state.Status = StatusStopped
+				state.PID = 0
+				state.StoppedAt = stopped
+				state.NextRestartAt = time.Time{}
+			})
+			return
+		}
+
+		if err == nil {
+			err = errors.New("process exited")
+		}
+		var next time.Time
+		s.update(id, p, func(state *State) {
+			if stopped.Sub(started) >= stableUptime {
+				state.Restarts = 0
+			}
+			state.Restarts++
+			state.Status = StatusFailed
+			state.PID = 0
+			state.LastError = err.Error()
+			state.StoppedAt = stopped
+			state.NextRestartAt = stopped.Add(backoff.Delay(state.Restarts))
+			next = state.NextRestartAt
+		})
+		logs.Note(fmt.Sprintf("exited: %v; restarting at %s", err, next.UTC().Format(time.RFC3339)))
+		s.logger.Warn("Hosted process exited", zap.String("id", id), zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			s.update(id, p, func(state *State) {
+				state.Status = StatusStopped
+				state.NextRestartAt = time.Time{}
+			})
+			return
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
+
+// run starts the process once and waits for it to exit.
+func (s *Supervisor) run(ctx context.Context, id string, p *process, logs *LogBuffer) error {
+	s.update(id, p, func(state *State) {
+		state.Status = StatusStarting
+		state.StartedAt = time.Now()
+		state.NextRestartAt = time.Time{}
+	})
+
+	cmd := Command(ctx, p.spec, s.limits)
+	cmd.Stdout = logs
+	cmd.Stderr = logs
+
+	logs.Note("starting " + p.spec.Command[0])
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start: %w", err)
+	}
+	cleanup, err := joinCgroup(s.limits, id, cmd.Process.Pid)
+	if err != nil {
+		logs.Note("cgroup limits not applied: " + err.Error())
+	}
+	defer cleanup()
+
+	s.update(id, p, func(state *State) {
+		state.Status = StatusRunning
+		state.PID = cmd.Process.Pid
+	})
+	return cmd.Wait()
+}
+
+// Command builds the command for spec with the ulimits of limits applied. It runs in its own
+// process group, which is terminated when ctx ends, and is killed if it has not exited
+// within a grace period. Cgroup limits are applied by the Supervisor only.
+func Command(ctx context.Context, spec Spec, limits Limits) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, spec.Command[0], spec.Command[1:]...)
+	cmd.Dir = spec.Dir
+	cmd.Env = spec.Env
+	cmd.WaitDelay = stopGracePeriod
+	applyLimits(cmd, limits)
+	return cmd
+}
+
+// update applies change to the state of p and reports the new state.
+func (s *Supervisor) update(id string, p *process, change func(state *State)) {
+	s.mu.Lock()
+	change(&p.state)
+	state := p.state
+	s.mu.Unlock()
+	if s.onState != nil {
+		s.onState(id, state)
+	}
+}
+
+// FreePort returns a port in [min, max] that is not in use and not in taken.
+func FreePort(min, max int, taken map[int]bool) (int, error) {
+	for port := min; port <= max; port++ {
+		if taken[port] {
+			continue
+		}
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			continue
+		}
+		listener.Close()
+		return port, nil
+	}
+	return 0, fmt.Errorf("no free port between %d and %d", min, max)
+}
+
+// passThroughEnv are the variables of the platform's environment that hosted servers
+// and their build tools may see. Everything else, including the platform's own secrets, is withheld.
+var passThroughEnv = []string{
+	"PATH", "HOME", "LANG", "TMPDIR", "TZ", "SSL_CERT_FILE", "SSL_CERT_DIR",
+	"GOPATH", "GOCACHE", "GOMODCACHE", "GOPROXY", "GOSUMDB", "GOFLAGS", "GOTOOLCHAIN",
+	"NODE_PATH", "npm_config_cache", "npm_config_registry",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY",
+}
+
+// Environment returns the pass-through variables of the current environment followed by extra ("KEY=value").
+func Environment(extra ...string) []string {
+	env := []string{}
+	for _, key := range passThroughEnv {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	return append(env, extra...)
+}
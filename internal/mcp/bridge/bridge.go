@@ -0,0 +1,550 @@
+// Package bridge exposes a stdio MCP server over HTTP.
+//
+// A Bridge runs the server as a subprocess and multiplexes any number of remote client
+// sessions onto its stdin and stdout. The request IDs and progress tokens of each session
+// are rewritten to values unique on the subprocess's stream and mapped back on the way out,
+// so clients cannot see or collide with each other. The subprocess is initialized once and
+// later sessions are answered from the first initialize result.
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/hosting"
+	"github.com/AkashKesav/API2SDK/internal/mcp/client"
+	"github.com/AkashKesav/API2SDK/internal/mcp/transport/httpserver"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// Transports a bridge can expose the stdio server over.
+const (
+	TransportSSE            = httpserver.TransportSSE
+	TransportStreamableHTTP = httpserver.TransportStreamableHTTP
+)
+
+// codeRequestTimeout is the JSON-RPC error code returned when the stdio server does not
+// answer in time.
+const codeRequestTimeout = -32001
+
+const (
+	// maxMessageBytes bounds a single line read from the stdio server and a message from a client.
+	maxMessageBytes = 16 << 20
+	// requestTimeout bounds how long a request waits for its response.
+	requestTimeout = 5 * time.Minute
+	// shutdownTimeout bounds closing the HTTP listener.
+	shutdownTimeout = 5 * time.Second
+)
+
+// Config describes the stdio server to run and how to expose it.
+type Config struct {
+	// Command is the stdio server's command line, e.g. ["npx", "-y", "@modelcontextprotocol/server-everything"].
+	Command []string
+	// Env are extra "KEY=value" variables; the rest of the environment is the hosting pass-through set.
+	Env       []string
+	Dir       string
+	Transport string
+	Port      int
+	Limits    hosting.Limits
+}
+
+// Validate checks that the configuration can be served.
+func (c *Config) Validate() error {
+	if len(c.Command) == 0 || c.Command[0] == "" {
+		return errors.New("a command is required")
+	}
+	if c.Transport != TransportSSE && c.Transport != TransportStreamableHTTP {
+		return fmt.Errorf("unsupported bridge transport: %s. Supported: %s, %s", c.Transport, TransportSSE, TransportStreamableHTTP)
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("a valid port is required for the %s transport", c.Transport)
+	}
+	return nil
+}
+
+// pendingRequest routes the response to a forwarded request back to its session.
+type pendingRequest struct {
+	session *httpserver.Session
+	id      json.RawMessage
+	// progressToken is the upstream progress token of the request, if it asked for progress.
+	progressToken string
+	initialize    bool
+}
+
+// progressRoute routes progress notifications back to the session that asked for them.
+type progressRoute struct {
+	session *httpserver.Session
+	token   json.RawMessage
+}
+
+// Bridge runs one stdio MCP server and serves it to remote sessions.
+type Bridge struct {
+	config Config
+	logger *zap.Logger
+
+	writeMu sync.Mutex
+	stdin   io.Writer
+
+	server *httpserver.Server
+
+	mu     sync.Mutex
+	nextID int64
+	// waiters are the requests of sessions waiting for their response.
+	waiters map[waiterKey]chan *client.Message
+	// pending are the forwarded requests, keyed by their upstream ID.
+	pending map[string]*pendingRequest
+	// progress are the rewritten progress tokens, keyed by their upstream value.
+	progress map[string]progressRoute
+	// serverRequests are requests from the stdio server, keyed by ID, and the session asked to answer.
+	serverRequests map[string]*httpserver.Session
+	// initResult is the stdio server's answer to the first initialize, with the ID removed.
+	initResult *client.Message
+	// initWaiters are initialize requests that arrived while the first one was in flight.
+	initWaiters       []*pendingRequest
+	initForwarded     bool
+	initializedSent   bool
+	lastActiveSession *httpserver.Session
+}
+
+// New creates a Bridge for config. Call Serve to run it.
+func New(config Config, logger *zap.Logger) (*Bridge, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	b := &Bridge{
+		config:         config,
+		logger:         logger.With(zap.String("command", config.Command[0]), zap.Int("port", config.Port)),
+		waiters:        make(map[waiterKey]chan *client.Message),
+		pending:        make(map[string]*pendingRequest),
+		progress:       make(map[string]progressRoute),
+		serverRequests: make(map[string]*httpserver.Session),
+	}
+	b.server = httpserver.New(httpserver.Config{
+		Transport:      config.Transport,
+		BodyLimit:      maxMessageBytes,
+		RequestTimeout: requestTimeout,
+	}, b, b.logger)
+	return b, nil
+}
+
+// Serve starts the stdio server and the HTTP listener and blocks until ctx is cancelled,
+// the server exits or the listener fails. All sessions end with it.
+func (b *Bridge) Serve(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := hosting.Command(ctx, hosting.Spec{
+		Command: b.config.Command,
+		Dir:     b.config.Dir,
+		Env:     hosting.Environment(b.config.Env...),
+	}, b.config.Limits)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	cmd.Stderr = &lineLogger{logger: b.logger}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start stdio server: %w", err)
+	}
+	b.stdin = stdin
+
+	exited := make(chan error, 1)
+	go func() {
+		// Wait must only be called once stdout has been read to the end.
+		b.readLoop(stdout)
+		exited <- cmd.Wait()
+	}()
+
+	app := b.server.App()
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(fmt.Sprintf(":%d", b.config.Port), fiber.ListenConfig{DisableStartupMessage: true})
+	}()
+	b.logger.Info("Started stdio bridge", zap.String("transport", b.config.Transport), zap.Int("pid", cmd.Process.Pid))
+
+	reaper := time.NewTicker(time.Minute)
+	defer reaper.Stop()
+
+	var result error
+	processDone := false
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case err := <-exited:
+			processDone = true
+			result = errors.New("stdio server exited")
+			if err != nil {
+				result = fmt.Errorf("stdio server exited: %w", err)
+			}
+			break loop
+		case err := <-listenErr:
+			if err == nil {
+				err = fmt.Errorf("listener on port %d closed", b.config.Port)
+			}
+			result = fmt.Errorf("bridge on port %d failed: %w", b.config.Port, err)
+			break loop
+		case <-reaper.C:
+			b.server.ReapIdleSessions()
+		}
+	}
+
+	b.server.CloseAllSessions()
+	if err := app.ShutdownWithTimeout(shutdownTimeout); err != nil {
+		b.logger.Warn("Failed to shut down bridge listener", zap.Error(err))
+	}
+	cancel()
+	if !processDone {
+		<-exited
+	}
+	return result
+}
+
+// readLoop dispatches the messages the stdio server writes, one JSON value per line.
+func (b *Bridge) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageBytes)
+	for scanner.Scan() {
+		messages, _, err := client.ParseMessages(scanner.Bytes())
+		if err != nil {
+			// Servers sometimes print banners to stdout; they are not protocol traffic.
+			b.logger.Debug("Ignoring non-JSON-RPC output", zap.ByteString("line", scanner.Bytes()))
+			continue
+		}
+		for _, msg := range messages {
+			b.fromServer(msg)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		b.logger.Warn("Failed to read from stdio server", zap.Error(err))
+	}
+}
+
+// send writes a message to the stdio server.
+func (b *Bridge) send(msg *client.Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	if _, err := b.stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write to stdio server: %w", err)
+	}
+	return nil
+}
+
+// fromClient handles a message a session sent. Errors for requests are delivered to the session.
+func (b *Bridge) fromClient(s *httpserver.Session, msg *client.Message) {
+	switch {
+	case msg.IsRequest():
+		b.forwardRequest(s, msg)
+	case msg.IsNotification():
+		b.forwardNotification(s, msg)
+	default:
+		b.forwardResponse(s, msg)
+	}
+}
+
+// forwardRequest sends a client request upstream under a new ID.
+func (b *Bridge) forwardRequest(s *httpserver.Session, msg *client.Message) {
+	b.mu.Lock()
+	b.lastActiveSession = s
+	route := &pendingRequest{session: s, id: msg.ID, initialize: msg.Method == "initialize"}
+	if route.initialize {
+		switch {
+		case b.initResult != nil:
+			reply := *b.initResult
+			reply.ID = msg.ID
+			b.mu.Unlock()
+			b.deliver(s, &reply)
+			return
+		case b.initForwarded:
+			b.initWaiters = append(b.initWaiters, route)
+			b.mu.Unlock()
+			return
+		}
+		b.initForwarded = true
+	}
+
+	b.nextID++
+	upstreamID := strconv.FormatInt(b.nextID, 10)
+	forwarded := *msg
+	forwarded.ID = json.RawMessage(upstreamID)
+	if token, ok := field(msg.Params, "_meta", "progressToken"); ok {
+		route.progressToken = "bridge-" + upstreamID
+		upstreamToken, _ := json.Marshal(route.progressToken)
+		forwarded.Params = setField(msg.Params, upstreamToken, "_meta", "progressToken")
+		b.progress[route.progressToken] = progressRoute{session: s, token: token}
+	}
+	b.pending[upstreamID] = route
+	b.mu.Unlock()
+
+	if err := b.send(&forwarded); err != nil {
+		b.mu.Lock()
+		b.dropPending(upstreamID)
+		if route.initialize {
+			b.initForwarded = false
+		}
+		b.mu.Unlock()
+		b.deliver(s, client.ErrorResponse(msg.ID, err))
+	}
+}
+
+// forwardNotification sends a client notification upstream, rewriting references to request IDs.
+func (b *Bridge) forwardNotification(s *httpserver.Session, msg *client.Message) {
+	switch msg.Method {
+	case "notifications/initialized":
+		// The stdio server is initialized once, by the first session.
+		b.mu.Lock()
+		sent := b.initializedSent
+		b.initializedSent = true
+		b.mu.Unlock()
+		if sent {
+			return
+		}
+	case "notifications/cancelled":
+		requestID, ok := field(msg.Params, "requestId")
+		if !ok {
+			return
+		}
+		upstreamID, ok := b.upstreamID(s, requestID)
+		if !ok {
+			return
+		}
+		forwarded := *msg
+		forwarded.Params = setField(msg.Params, json.RawMessage(upstreamID), "requestId")
+		msg = &forwarded
+	}
+	if err := b.send(msg); err != nil {
+		b.logger.Warn("Failed to forward notification", zap.String("method", msg.Method), zap.Error(err))
+	}
+}
+
+// forwardResponse sends a session's answer to a request of the stdio server upstream.
+func (b *Bridge) forwardResponse(s *httpserver.Session, msg *client.Message) {
+	key := string(msg.ID)
+	b.mu.Lock()
+	asked := b.serverRequests[key]
+	if asked == s {
+		delete(b.serverRequests, key)
+	}
+	b.mu.Unlock()
+	if asked != s {
+		return
+	}
+	if err := b.send(msg); err != nil {
+		b.logger.Warn("Failed to forward response", zap.Error(err))
+	}
+}
+
+// upstreamID returns the upstream ID of a request the session has in flight.
+func (b *Bridge) upstreamID(s *httpserver.Session, id json.RawMessage) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for upstreamID, route := range b.pending {
+		if route.session == s && string(route.id) == string(id) {
+			return upstreamID, true
+		}
+	}
+	return "", false
+}
+
+// fromServer routes a message from the stdio server to the sessions it concerns.
+func (b *Bridge) fromServer(msg *client.Message) {
+	switch {
+	case msg.IsResponse():
+		b.routeResponse(msg)
+	case msg.IsRequest():
+		b.routeServerRequest(msg)
+	case msg.IsNotification():
+		b.routeNotification(msg)
+	}
+}
+
+func (b *Bridge) routeResponse(msg *client.Message) {
+	key := string(msg.ID)
+	b.mu.Lock()
+	route, ok := b.pending[key]
+	if !ok {
+		b.mu.Unlock()
+		b.logger.Debug("Dropping response to unknown request", zap.String("id", key))
+		return
+	}
+	b.dropPending(key)
+
+	var waiters []*pendingRequest
+	if route.initialize {
+		waiters = b.initWaiters
+		b.initWaiters = nil
+		if msg.Error == nil {
+			cached := *msg
+			cached.ID = nil
+			b.initResult = &cached
+		} else {
+			// A failed initialize may be retried by the next session.
+			b.initForwarded = false
+		}
+	}
+	b.mu.Unlock()
+
+	for _, target := range append([]*pendingRequest{route}, waiters...) {
+		reply := *msg
+		reply.ID = target.id
+		b.deliver(target.session, &reply)
+	}
+}
+
+func (b *Bridge) routeServerRequest(msg *client.Message) {
+	// Pings are answered by the bridge; they only check that the stdio stream is alive.
+	if msg.Method == "ping" {
+		_ = b.send(&client.Message{JSONRPC: "2.0", ID: msg.ID, Result: json.RawMessage("{}")})
+		return
+	}
+
+	sessions := b.server.Sessions()
+	b.mu.Lock()
+	// Requests such as sampling or roots are asked of the session that was active last.
+	target := b.lastActiveSession
+	if target == nil || target.Closed() {
+		target = nil
+		if len(sessions) > 0 {
+			target = sessions[0]
+		}
+	}
+	if target != nil {
+		b.serverRequests[string(msg.ID)] = target
+	}
+	b.mu.Unlock()
+
+	if target == nil {
+		_ = b.send(client.ErrorResponse(msg.ID, client.NewError(client.CodeInternalError, "no client is connected")))
+		return
+	}
+	b.deliver(target, msg)
+}
+
+func (b *Bridge) routeNotification(msg *client.Message) {
+	switch msg.Method {
+	case "notifications/progress":
+		token, ok := field(msg.Params, "progressToken")
+		if !ok {
+			return
+		}
+		var upstreamToken string
+		_ = json.Unmarshal(token, &upstreamToken)
+		b.mu.Lock()
+		route, ok := b.progress[upstreamToken]
+		b.mu.Unlock()
+		if !ok {
+			return
+		}
+		forwarded := *msg
+		forwarded.Params = setField(msg.Params, route.token, "progressToken")
+		b.deliver(route.session, &forwarded)
+	case "notifications/cancelled":
+		// The stdio server withdrew one of its own requests.
+		requestID, ok := field(msg.Params, "requestId")
+		if !ok {
+			return
+		}
+		b.mu.Lock()
+		target := b.serverRequests[string(requestID)]
+		delete(b.serverRequests, string(requestID))
+		b.mu.Unlock()
+		if target != nil {
+			b.deliver(target, msg)
+		}
+	default:
+		// List changes, resource updates and log messages concern every session.
+		for _, s := range b.server.Sessions() {
+			b.deliver(s, msg)
+		}
+	}
+}
+
+// dropPending forgets a forwarded request and its progress token. Callers hold b.mu.
+func (b *Bridge) dropPending(upstreamID string) {
+	if route, ok := b.pending[upstreamID]; ok && route.progressToken != "" {
+		delete(b.progress, route.progressToken)
+	}
+	delete(b.pending, upstreamID)
+}
+
+// field returns the raw value at path in a JSON object.
+func field(object json.RawMessage, path ...string) (json.RawMessage, bool) {
+	current := object
+	for _, key := range path {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(current, &fields); err != nil {
+			return nil, false
+		}
+		value, ok := fields[key]
+		if !ok {
+			return nil, false
+		}
+		current = value
+	}
+	return current, true
+}
+
+// setField returns object with the value at path replaced. The path must exist.
+func setField(object json.RawMessage, value json.RawMessage, path ...string) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(object, &fields); err != nil {
+		return object
+	}
+	if len(path) == 1 {
+		fields[path[0]] = value
+	} else {
+		fields[path[0]] = setField(fields[path[0]], value, path[1:]...)
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return object
+	}
+	return data
+}
+
+// lineLogger logs the stderr output of the stdio server line by line.
+type lineLogger struct {
+	logger  *zap.Logger
+	partial []byte
+}
+
+func (l *lineLogger) Write(p []byte) (int, error) {
+	data := append(l.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		if line := bytes.TrimSpace(data[:i]); len(line) > 0 {
+			l.logger.Info("stdio server", zap.ByteString("stderr", line))
+		}
+		data = data[i+1:]
+	}
+	if len(data) > maxLineLog {
+		l.logger.Info("stdio server", zap.ByteString("stderr", data[:maxLineLog]))
+		data = nil
+	}
+	l.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// maxLineLog bounds a single logged stderr line.
+const maxLineLog = 4096
@@ -0,0 +1,214 @@
+package bridge
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/mcp/client"
+	"github.com/AkashKesav/API2SDK/internal/mcp/transport/httpserver"
+	"go.uber.org/zap"
+)
+
+// fakeServer stands in for the stdio server: it answers every request with the ID it was
+// sent, preceded by a progress notification when the request asked for progress.
+type fakeServer struct {
+	mu       sync.Mutex
+	requests []*client.Message
+}
+
+// attach connects the fake to b's stdin and routes its output back through b.
+func (f *fakeServer) attach(t *testing.T, b *Bridge) {
+	reader, writer := io.Pipe()
+	b.stdin = writer
+	t.Cleanup(func() { writer.Close() })
+	go func() {
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			var msg client.Message
+			if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || !msg.IsRequest() {
+				continue
+			}
+			f.mu.Lock()
+			f.requests = append(f.requests, &msg)
+			f.mu.Unlock()
+			if token, ok := field(msg.Params, "_meta", "progressToken"); ok {
+				params, _ := json.Marshal(map[string]interface{}{"progressToken": token, "progress": 1})
+				b.fromServer(&client.Message{JSONRPC: "2.0", Method: "notifications/progress", Params: params})
+			}
+			result, _ := json.Marshal(map[string]interface{}{"method": msg.Method, "upstreamId": msg.ID})
+			b.fromServer(&client.Message{JSONRPC: "2.0", ID: msg.ID, Result: result})
+		}
+	}()
+}
+
+func (f *fakeServer) methods() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var methods []string
+	for _, msg := range f.requests {
+		methods = append(methods, msg.Method)
+	}
+	return methods
+}
+
+func newTestBridge(t *testing.T, transport string) (*Bridge, *fakeServer) {
+	t.Helper()
+	b, err := New(Config{Command: []string{"server"}, Transport: transport, Port: 8080}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeServer{}
+	server.attach(t, b)
+	return b, server
+}
+
+// next returns the next message queued for s.
+func next(t *testing.T, s *httpserver.Session) *client.Message {
+	t.Helper()
+	select {
+	case msg := <-s.Messages():
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message for the session")
+		return nil
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	invalid := []Config{
+		{Transport: TransportSSE, Port: 8080},
+		{Command: []string{"server"}, Transport: "stdio", Port: 8080},
+		{Command: []string{"server"}, Transport: TransportStreamableHTTP},
+	}
+	for _, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded", config)
+		}
+	}
+}
+
+func TestSessionsKeepTheirRequestIDs(t *testing.T) {
+	b, server := newTestBridge(t, TransportSSE)
+	alice, bob := b.server.NewSession(nil, false), b.server.NewSession(nil, false)
+
+	request := &client.Message{JSONRPC: "2.0", ID: json.RawMessage("1"), Method: "tools/call", Params: json.RawMessage(`{"name":"x","_meta":{"progressToken":"p"}}`)}
+	b.fromClient(alice, request)
+	progress, response := next(t, alice), next(t, alice)
+	b.fromClient(bob, request)
+	next(t, bob)
+	bobResponse := next(t, bob)
+
+	if token, _ := field(progress.Params, "progressToken"); string(token) != `"p"` {
+		t.Errorf("progress token = %s, want the session's own token", token)
+	}
+	if string(response.ID) != "1" || string(bobResponse.ID) != "1" {
+		t.Fatalf("response IDs = %s and %s, want the sessions' own IDs", response.ID, bobResponse.ID)
+	}
+	aliceUpstream, _ := field(response.Result, "upstreamId")
+	bobUpstream, _ := field(bobResponse.Result, "upstreamId")
+	if string(aliceUpstream) == string(bobUpstream) {
+		t.Fatalf("both sessions used upstream ID %s", aliceUpstream)
+	}
+	if len(b.pending) != 0 || len(b.progress) != 0 {
+		t.Fatalf("pending = %v, progress = %v, want both empty", b.pending, b.progress)
+	}
+	if methods := server.methods(); len(methods) != 2 {
+		t.Fatalf("upstream requests = %v", methods)
+	}
+}
+
+func TestInitializeIsForwardedOnce(t *testing.T) {
+	b, server := newTestBridge(t, TransportSSE)
+	alice, bob := b.server.NewSession(nil, false), b.server.NewSession(nil, false)
+	initialize := func(s *httpserver.Session, id string) *client.Message {
+		b.fromClient(s, &client.Message{JSONRPC: "2.0", ID: json.RawMessage(id), Method: "initialize", Params: json.RawMessage(`{}`)})
+		return next(t, s)
+	}
+	initialized := &client.Message{JSONRPC: "2.0", Method: "notifications/initialized"}
+
+	if reply := initialize(alice, `"a"`); string(reply.ID) != `"a"` {
+		t.Fatalf("initialize reply ID = %s", reply.ID)
+	}
+	b.fromClient(alice, initialized)
+	if reply := initialize(bob, `"b"`); string(reply.ID) != `"b"` || len(reply.Result) == 0 {
+		t.Fatalf("cached initialize reply = %+v", reply)
+	}
+	b.fromClient(bob, initialized)
+
+	b.fromClient(bob, &client.Message{JSONRPC: "2.0", ID: json.RawMessage("2"), Method: "ping"})
+	next(t, bob)
+	if methods := server.methods(); strings.Join(methods, ",") != "initialize,ping" {
+		t.Fatalf("upstream requests = %v, want one initialize", methods)
+	}
+}
+
+func TestServerRequestsAreAnsweredByOneSession(t *testing.T) {
+	b, _ := newTestBridge(t, TransportSSE)
+	alice, bob := b.server.NewSession(nil, false), b.server.NewSession(nil, false)
+	b.fromClient(alice, &client.Message{JSONRPC: "2.0", ID: json.RawMessage("1"), Method: "tools/list"})
+	next(t, alice)
+
+	b.fromServer(&client.Message{JSONRPC: "2.0", ID: json.RawMessage(`"s1"`), Method: "sampling/createMessage"})
+	if msg := next(t, alice); msg.Method != "sampling/createMessage" {
+		t.Fatalf("server request went to %+v, want the last active session", msg)
+	}
+
+	// Only the asked session may answer.
+	b.fromClient(bob, &client.Message{JSONRPC: "2.0", ID: json.RawMessage(`"s1"`), Result: json.RawMessage(`{}`)})
+	if b.serverRequests[`"s1"`] != alice {
+		t.Fatal("another session answered the server's request")
+	}
+	b.fromClient(alice, &client.Message{JSONRPC: "2.0", ID: json.RawMessage(`"s1"`), Result: json.RawMessage(`{}`)})
+	if _, ok := b.serverRequests[`"s1"`]; ok {
+		t.Fatal("the server's request is still open after the answer")
+	}
+}
+
+func TestStreamableHTTPSessions(t *testing.T) {
+	b, _ := newTestBridge(t, TransportStreamableHTTP)
+	app := b.server.App()
+	post := func(sessionID, body string) (*http.Response, *client.Message) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if sessionID != "" {
+			req.Header.Set(httpserver.SessionHeader, sessionID)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var reply client.Message
+		_ = json.NewDecoder(resp.Body).Decode(&reply)
+		return resp, &reply
+	}
+
+	resp, reply := post("", `{"jsonrpc":"2.0","id":7,"method":"initialize","params":{}}`)
+	sessionID := resp.Header.Get(httpserver.SessionHeader)
+	if sessionID == "" || string(reply.ID) != "7" {
+		t.Fatalf("initialize = %d %+v, session %q", resp.StatusCode, reply, sessionID)
+	}
+
+	if resp, reply = post(sessionID, `{"jsonrpc":"2.0","id":8,"method":"tools/list"}`); resp.StatusCode != http.StatusOK || string(reply.ID) != "8" {
+		t.Fatalf("tools/list = %d %+v", resp.StatusCode, reply)
+	}
+	if resp, _ = post("", `{"jsonrpc":"2.0","id":9,"method":"tools/list"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("request without a session = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/mcp", nil)
+	req.Header.Set(httpserver.SessionHeader, sessionID)
+	if resp, err := app.Test(req); err != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE = %v, %v", resp, err)
+	}
+	if resp, _ = post(sessionID, `{"jsonrpc":"2.0","id":10,"method":"tools/list"}`); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("request on a closed session = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,122 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/AkashKesav/API2SDK/internal/mcp/client"
+	"github.com/AkashKesav/API2SDK/internal/mcp/transport/httpserver"
+	"go.uber.org/zap"
+)
+
+// waiterKey identifies a request of a session that waits for its response.
+type waiterKey struct {
+	session *httpserver.Session
+	id      string
+}
+
+// Handle forwards a message of a session to the stdio server. Requests wait for their
+// response; when they give up, the request is cancelled upstream.
+func (b *Bridge) Handle(ctx context.Context, s *httpserver.Session, msg *client.Message) *client.Message {
+	if !msg.IsRequest() {
+		b.fromClient(s, msg)
+		return nil
+	}
+
+	key := waiterKey{session: s, id: string(msg.ID)}
+	waiter := make(chan *client.Message, 1)
+	b.mu.Lock()
+	b.waiters[key] = waiter
+	b.mu.Unlock()
+	b.fromClient(s, msg)
+
+	select {
+	case response := <-waiter:
+		return response
+	case <-ctx.Done():
+	}
+	b.mu.Lock()
+	delete(b.waiters, key)
+	b.mu.Unlock()
+	if s.Closed() {
+		// SessionClosed cancels the session's requests upstream.
+		return client.ErrorResponse(msg.ID, client.NewError(client.CodeInternalError, "session closed"))
+	}
+	b.cancelRequest(s, msg.ID, "request timed out")
+	return client.ErrorResponse(msg.ID, client.NewError(codeRequestTimeout, "request timed out"))
+}
+
+// deliver hands a message to a session: responses go to the request waiting for them if
+// there is one, everything else is queued for the session's event stream.
+func (b *Bridge) deliver(s *httpserver.Session, msg *client.Message) {
+	if msg.IsResponse() {
+		key := waiterKey{session: s, id: string(msg.ID)}
+		b.mu.Lock()
+		waiter, ok := b.waiters[key]
+		delete(b.waiters, key)
+		b.mu.Unlock()
+		if ok {
+			waiter <- msg
+			return
+		}
+	}
+	b.server.Deliver(s, msg)
+}
+
+// cancelRequest tells the stdio server that a session no longer waits for a request.
+func (b *Bridge) cancelRequest(s *httpserver.Session, id json.RawMessage, reason string) {
+	upstreamID, ok := b.upstreamID(s, id)
+	if !ok {
+		return
+	}
+	b.mu.Lock()
+	b.dropPending(upstreamID)
+	b.mu.Unlock()
+	b.sendCancelled(upstreamID, reason)
+}
+
+func (b *Bridge) sendCancelled(upstreamID, reason string) {
+	params, _ := json.Marshal(map[string]interface{}{"requestId": json.RawMessage(upstreamID), "reason": reason})
+	if err := b.send(&client.Message{JSONRPC: "2.0", Method: "notifications/cancelled", Params: params}); err != nil {
+		b.logger.Debug("Failed to send cancellation", zap.Error(err))
+	}
+}
+
+// SessionClosed cancels the requests of a session that ended upstream and answers the
+// requests the stdio server asked of it with an error, so neither side waits forever.
+func (b *Bridge) SessionClosed(s *httpserver.Session) {
+	b.mu.Lock()
+	if b.lastActiveSession == s {
+		b.lastActiveSession = nil
+	}
+	var cancelled []string
+	for upstreamID, route := range b.pending {
+		// The initialize in flight is still answered, for the sessions waiting on it.
+		if route.session == s && !route.initialize {
+			cancelled = append(cancelled, upstreamID)
+			b.dropPending(upstreamID)
+		}
+	}
+	waiters := b.initWaiters[:0]
+	for _, waiter := range b.initWaiters {
+		if waiter.session != s {
+			waiters = append(waiters, waiter)
+		}
+	}
+	b.initWaiters = waiters
+	var unanswered []json.RawMessage
+	for id, asked := range b.serverRequests {
+		if asked == s {
+			unanswered = append(unanswered, json.RawMessage(id))
+			delete(b.serverRequests, id)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, upstreamID := range cancelled {
+		b.sendCancelled(upstreamID, "client disconnected")
+	}
+	for _, id := range unanswered {
+		_ = b.send(client.ErrorResponse(id, client.NewError(client.CodeInternalError, "client disconnected")))
+	}
+}
@@ -0,0 +1,410 @@
+// Package client is an MCP client for the stdio, SSE and Streamable HTTP transports.
+//
+// A Client owns one connection to a server. Requests may be issued concurrently and are
+// matched to their responses by ID; a request whose context ends is cancelled on the server.
+// Notifications from the server are delivered in order on a separate goroutine, so a
+// handler may itself make requests, and requests from the server are answered concurrently.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ProtocolVersion is the MCP revision the client asks for.
+const ProtocolVersion = "2025-03-26"
+
+const (
+	// maxMessageBytes bounds a single message received from a server.
+	maxMessageBytes = 16 << 20
+	// notificationQueueSize is the number of undelivered notifications kept before new ones are dropped.
+	notificationQueueSize = 256
+	// cancelTimeout bounds sending the cancellation of an abandoned request.
+	cancelTimeout = 5 * time.Second
+)
+
+var (
+	// ErrClosed is returned for requests on a connection that has ended.
+	ErrClosed = errors.New("connection closed")
+	// ErrSessionExpired is returned when a Streamable HTTP server no longer knows the session.
+	ErrSessionExpired = errors.New("session expired")
+)
+
+// Transport carries JSON-RPC messages between a Client and a server.
+type Transport interface {
+	// Start connects to the server. Every message or batch received until the
+	// connection ends is passed to receive.
+	Start(ctx context.Context, receive func(data []byte)) error
+	// Send delivers one message or batch to the server.
+	Send(ctx context.Context, data []byte) error
+	// Done is closed when the connection has ended.
+	Done() <-chan struct{}
+	// Err reports why the connection ended, once Done is closed.
+	Err() error
+	// Close ends the connection.
+	Close() error
+}
+
+// Implementation names an MCP client or server.
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// InitializeResult is the server's answer to initialize.
+type InitializeResult struct {
+	ProtocolVersion string                     `json:"protocolVersion"`
+	Capabilities    map[string]json.RawMessage `json:"capabilities"`
+	ServerInfo      Implementation             `json:"serverInfo"`
+	Instructions    string                     `json:"instructions,omitempty"`
+}
+
+// HasCapability reports whether the server declared capability, e.g. "tools".
+func (r *InitializeResult) HasCapability(capability string) bool {
+	_, ok := r.Capabilities[capability]
+	return ok
+}
+
+// Options configure a Client.
+type Options struct {
+	ClientInfo   Implementation
+	Capabilities map[string]interface{}
+	// OnNotification is called, in order and one at a time, for every notification from the server.
+	OnNotification func(method string, params json.RawMessage)
+	// OnRequest answers requests from the server other than ping. When nil, they are
+	// answered with method not found.
+	OnRequest func(ctx context.Context, method string, params json.RawMessage) (interface{}, error)
+	Logger    *zap.Logger
+}
+
+type notification struct {
+	method string
+	params json.RawMessage
+}
+
+// Client is a connection to an MCP server.
+type Client struct {
+	transport Transport
+	options   Options
+	logger    *zap.Logger
+
+	nextID        atomic.Int64
+	mu            sync.Mutex
+	pending       map[string]chan *Message
+	notifications chan notification
+	closed        chan struct{}
+	closeOnce     sync.Once
+	// requestCtx ends the handling of server requests when the client closes.
+	requestCtx    context.Context
+	cancelRequest context.CancelFunc
+
+	initialized InitializeResult
+}
+
+// Connect starts transport and initializes the session. The returned Client owns the transport.
+func Connect(ctx context.Context, transport Transport, options Options) (*Client, error) {
+	if options.Logger == nil {
+		options.Logger = zap.NewNop()
+	}
+	if options.ClientInfo.Name == "" {
+		options.ClientInfo = Implementation{Name: "api2sdk-mcp-client", Version: "1.0.0"}
+	}
+	if options.Capabilities == nil {
+		options.Capabilities = map[string]interface{}{}
+	}
+	requestCtx, cancelRequest := context.WithCancel(context.Background())
+	c := &Client{
+		transport:     transport,
+		options:       options,
+		logger:        options.Logger,
+		pending:       make(map[string]chan *Message),
+		notifications: make(chan notification, notificationQueueSize),
+		closed:        make(chan struct{}),
+		requestCtx:    requestCtx,
+		cancelRequest: cancelRequest,
+	}
+	if err := transport.Start(ctx, c.receive); err != nil {
+		cancelRequest()
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	go c.dispatchNotifications()
+	go func() {
+		<-transport.Done()
+		c.shutdown()
+	}()
+
+	if err := c.initialize(ctx); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) initialize(ctx context.Context) error {
+	params := map[string]interface{}{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    c.options.Capabilities,
+		"clientInfo":      c.options.ClientInfo,
+	}
+	if err := c.Call(ctx, "initialize", params, &c.initialized); err != nil {
+		return fmt.Errorf("initialize failed: %w", err)
+	}
+	if versioned, ok := c.transport.(interface{ setProtocolVersion(string) }); ok {
+		versioned.setProtocolVersion(c.initialized.ProtocolVersion)
+	}
+	if err := c.Notify(ctx, "notifications/initialized", nil); err != nil {
+		return fmt.Errorf("failed to confirm initialization: %w", err)
+	}
+	return nil
+}
+
+// InitializeResult returns the server's answer to initialize.
+func (c *Client) InitializeResult() InitializeResult {
+	return c.initialized
+}
+
+// Call sends a request and decodes its result into result, unless result is nil.
+// When ctx ends first the request is cancelled on the server and ctx's error returned.
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	raw, err := c.CallRaw(ctx, method, params)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(raw, result); err != nil {
+		return fmt.Errorf("invalid %s result: %w", method, err)
+	}
+	return nil
+}
+
+// CallRaw sends a request and returns its undecoded result.
+func (c *Client) CallRaw(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	id := strconv.FormatInt(c.nextID.Add(1), 10)
+	request, err := encode(json.RawMessage(id), method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make(chan *Message, 1)
+	c.mu.Lock()
+	select {
+	case <-c.closed:
+		c.mu.Unlock()
+		return nil, c.closedError()
+	default:
+	}
+	c.pending[id] = response
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.transport.Send(ctx, request); err != nil {
+		if ctx.Err() != nil {
+			c.cancel(id, ctx.Err())
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to send %s: %w", method, err)
+	}
+
+	select {
+	case msg := <-response:
+		if msg.Error != nil {
+			return nil, msg.Error
+		}
+		return msg.Result, nil
+	case <-ctx.Done():
+		c.cancel(id, ctx.Err())
+		return nil, ctx.Err()
+	case <-c.closed:
+		return nil, c.closedError()
+	}
+}
+
+// Notify sends a notification.
+func (c *Client) Notify(ctx context.Context, method string, params interface{}) error {
+	data, err := encode(nil, method, params)
+	if err != nil {
+		return err
+	}
+	return c.transport.Send(ctx, data)
+}
+
+// Ping checks that the server is responsive.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.Call(ctx, "ping", nil, nil)
+}
+
+// cancel tells the server that the request id is no longer awaited.
+func (c *Client) cancel(id string, reason error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+	defer cancel()
+	params := map[string]interface{}{"requestId": json.RawMessage(id), "reason": reason.Error()}
+	if err := c.Notify(ctx, "notifications/cancelled", params); err != nil {
+		c.logger.Debug("Failed to send cancellation", zap.String("requestID", id), zap.Error(err))
+	}
+}
+
+// Done is closed when the connection has ended.
+func (c *Client) Done() <-chan struct{} {
+	return c.closed
+}
+
+// Err reports why the connection ended.
+func (c *Client) Err() error {
+	select {
+	case <-c.closed:
+		return c.closedError()
+	default:
+		return nil
+	}
+}
+
+func (c *Client) closedError() error {
+	if err := c.transport.Err(); err != nil && !errors.Is(err, ErrClosed) {
+		return fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	return ErrClosed
+}
+
+// Close ends the connection.
+func (c *Client) Close() error {
+	err := c.transport.Close()
+	c.shutdown()
+	return err
+}
+
+func (c *Client) shutdown() {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		close(c.closed)
+		c.mu.Unlock()
+		c.cancelRequest()
+	})
+}
+
+// receive handles a message or batch from the transport.
+func (c *Client) receive(data []byte) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return
+	}
+	if data[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(data, &batch); err != nil {
+			c.logger.Warn("Ignoring malformed batch from server", zap.Error(err))
+			return
+		}
+		for _, item := range batch {
+			c.receiveOne(item)
+		}
+		return
+	}
+	c.receiveOne(data)
+}
+
+func (c *Client) receiveOne(data []byte) {
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.logger.Warn("Ignoring malformed message from server", zap.Error(err))
+		return
+	}
+	switch {
+	case msg.IsResponse():
+		c.mu.Lock()
+		response, ok := c.pending[string(msg.ID)]
+		c.mu.Unlock()
+		if !ok {
+			c.logger.Debug("Ignoring response to unknown request", zap.ByteString("id", msg.ID))
+			return
+		}
+		select {
+		case response <- &msg:
+		default:
+		}
+	case msg.IsRequest():
+		go c.answer(&msg)
+	case msg.IsNotification():
+		select {
+		case c.notifications <- notification{method: msg.Method, params: msg.Params}:
+		default:
+			c.logger.Warn("Dropping notification from server", zap.String("method", msg.Method))
+		}
+	}
+}
+
+// answer responds to a request from the server.
+func (c *Client) answer(msg *Message) {
+	response := &Message{JSONRPC: "2.0", ID: msg.ID}
+	switch {
+	case msg.Method == "ping":
+		response.Result = json.RawMessage("{}")
+	case c.options.OnRequest != nil:
+		result, err := c.options.OnRequest(c.requestCtx, msg.Method, msg.Params)
+		if err != nil {
+			response = ErrorResponse(msg.ID, err)
+			break
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			response.Error = &RPCError{Code: CodeInternalError, Message: err.Error()}
+			break
+		}
+		response.Result = data
+	default:
+		response.Error = &RPCError{Code: CodeMethodNotFound, Message: "Method not found", Data: mustMarshal(msg.Method)}
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	if err := c.transport.Send(c.requestCtx, data); err != nil {
+		c.logger.Debug("Failed to answer server request", zap.String("method", msg.Method), zap.Error(err))
+	}
+}
+
+// dispatchNotifications delivers queued notifications until the client closes.
+func (c *Client) dispatchNotifications() {
+	for {
+		select {
+		case n := <-c.notifications:
+			if c.options.OnNotification != nil {
+				c.options.OnNotification(n.method, n.params)
+			}
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+// encode builds a request, or a notification when id is nil.
+func encode(id json.RawMessage, method string, params interface{}) ([]byte, error) {
+	msg := Message{JSONRPC: "2.0", ID: id, Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s params: %w", method, err)
+		}
+		msg.Params = data
+	}
+	return json.Marshal(msg)
+}
+
+func mustMarshal(value interface{}) json.RawMessage {
+	data, _ := json.Marshal(value)
+	return data
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// JSON-RPC error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// RPCError is the error of a JSON-RPC response.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	if len(e.Data) > 0 {
+		return fmt.Sprintf("%s (code %d): %s", e.Message, e.Code, e.Data)
+	}
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// NewError returns an *RPCError with a formatted message.
+func NewError(code int, format string, args ...interface{}) error {
+	return &RPCError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Message is a JSON-RPC message. Fields are kept raw so that they pass through unchanged.
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+func (m *Message) IsRequest() bool      { return m.Method != "" && len(m.ID) > 0 }
+func (m *Message) IsNotification() bool { return m.Method != "" && len(m.ID) == 0 }
+func (m *Message) IsResponse() bool     { return m.Method == "" && len(m.ID) > 0 }
+
+// ErrorResponse builds an error response to id. A nil id is sent as null, for messages whose
+// ID could not be determined, and errors other than *RPCError are sent as internal errors.
+func ErrorResponse(id json.RawMessage, err error) *Message {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		rpcErr = &RPCError{Code: CodeInternalError, Message: err.Error()}
+	}
+	return &Message{JSONRPC: "2.0", ID: id, Error: rpcErr}
+}
+
+// ParseMessages decodes a single message or a batch. It reports whether the input was a batch.
+func ParseMessages(data []byte) ([]*Message, bool, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, false, errors.New("empty message")
+	}
+	if trimmed[0] == '[' {
+		var batch []*Message
+		if err := json.Unmarshal(trimmed, &batch); err != nil {
+			return nil, true, err
+		}
+		if len(batch) == 0 {
+			return nil, true, errors.New("empty batch")
+		}
+		for _, msg := range batch {
+			if msg == nil {
+				return nil, true, errors.New("invalid message in batch")
+			}
+		}
+		return batch, true, nil
+	}
+	var msg Message
+	if err := json.Unmarshal(trimmed, &msg); err != nil {
+		return nil, false, err
+	}
+	return []*Message{&msg}, false, nil
+}
@@ -0,0 +1,292 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// maxListPages bounds the pages followed by the List helpers, so a server that keeps
+// returning cursors cannot stall a caller.
+const maxListPages = 100
+
+// Tool is a tool offered by the server.
+type Tool struct {
+	Name         string          `json:"name"`
+	Title        string          `json:"title,omitempty"`
+	Description  string          `json:"description,omitempty"`
+	InputSchema  json.RawMessage `json:"inputSchema"`
+	OutputSchema json.RawMessage `json:"outputSchema,omitempty"`
+	Annotations  json.RawMessage `json:"annotations,omitempty"`
+}
+
+// Content is a content block of a tool result or prompt message. Fields other than
+// Type are set according to the type.
+type Content struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	URI      string          `json:"uri,omitempty"`
+	Name     string          `json:"name,omitempty"`
+	Data     string          `json:"data,omitempty"`
+	MimeType string          `json:"mimeType,omitempty"`
+	Resource json.RawMessage `json:"resource,omitempty"`
+}
+
+// ToolResult is the result of calling a tool.
+type ToolResult struct {
+	Content           []Content       `json:"content"`
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+	IsError           bool            `json:"isError,omitempty"`
+}
+
+// Resource is a resource offered by the server.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceTemplate describes resources by a URI template.
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents is the content of a resource, as text or base64 encoded blob.
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// Prompt is a prompt template offered by the server.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Title       string           `json:"title,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument is an argument of a prompt template.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptMessage is a message of a rendered prompt.
+type PromptMessage struct {
+	Role    string  `json:"role"`
+	Content Content `json:"content"`
+}
+
+// PromptResult is a rendered prompt.
+type PromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// ListTools returns every tool of the server, following pagination.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	return listAll[Tool](ctx, c, "tools/list", "tools")
+}
+
+// CallTool calls a tool. A tool that fails reports it in the result, with IsError set.
+func (c *Client) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+	var result ToolResult
+	if err := c.Call(ctx, "tools/call", map[string]interface{}{"name": name, "arguments": arguments}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListResources returns every resource of the server, following pagination.
+func (c *Client) ListResources(ctx context.Context) ([]Resource, error) {
+	return listAll[Resource](ctx, c, "resources/list", "resources")
+}
+
+// ListResourceTemplates returns every resource template of the server, following pagination.
+func (c *Client) ListResourceTemplates(ctx context.Context) ([]ResourceTemplate, error) {
+	return listAll[ResourceTemplate](ctx, c, "resources/templates/list", "resourceTemplates")
+}
+
+// ReadResource returns the contents of the resource at uri.
+func (c *Client) ReadResource(ctx context.Context, uri string) ([]ResourceContents, error) {
+	var result struct {
+		Contents []ResourceContents `json:"contents"`
+	}
+	if err := c.Call(ctx, "resources/read", map[string]string{"uri": uri}, &result); err != nil {
+		return nil, err
+	}
+	return result.Contents, nil
+}
+
+// Subscribe asks for notifications/resources/updated when the resource at uri changes.
+func (c *Client) Subscribe(ctx context.Context, uri string) error {
+	return c.Call(ctx, "resources/subscribe", map[string]string{"uri": uri}, nil)
+}
+
+// Unsubscribe ends a subscription made with Subscribe.
+func (c *Client) Unsubscribe(ctx context.Context, uri string) error {
+	return c.Call(ctx, "resources/unsubscribe", map[string]string{"uri": uri}, nil)
+}
+
+// ListPrompts returns every prompt of the server, following pagination.
+func (c *Client) ListPrompts(ctx context.Context) ([]Prompt, error) {
+	return listAll[Prompt](ctx, c, "prompts/list", "prompts")
+}
+
+// GetPrompt renders a prompt with arguments.
+func (c *Client) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*PromptResult, error) {
+	params := map[string]interface{}{"name": name}
+	if len(arguments) > 0 {
+		params["arguments"] = arguments
+	}
+	var result PromptResult
+	if err := c.Call(ctx, "prompts/get", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SetLoggingLevel sets the minimum level of the log messages the server sends.
+func (c *Client) SetLoggingLevel(ctx context.Context, level string) error {
+	return c.Call(ctx, "logging/setLevel", map[string]string{"level": level}, nil)
+}
+
+// listAll requests every page of a list method and collects the items under key.
+func listAll[T any](ctx context.Context, c *Client, method, key string) ([]T, error) {
+	items := []T{}
+	cursor := ""
+	for page := 0; page < maxListPages; page++ {
+		var params interface{}
+		if cursor != "" {
+			params = map[string]string{"cursor": cursor}
+		}
+		raw, err := c.CallRaw(ctx, method, params)
+		if err != nil {
+			return nil, err
+		}
+		var result map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &result); err != nil {
+			return nil, fmt.Errorf("invalid %s result: %w", method, err)
+		}
+		var pageItems []T
+		if list, ok := result[key]; ok {
+			if err := json.Unmarshal(list, &pageItems); err != nil {
+				return nil, fmt.Errorf("invalid %s result: %w", method, err)
+			}
+		}
+		items = append(items, pageItems...)
+
+		cursor = ""
+		if next, ok := result["nextCursor"]; ok {
+			json.Unmarshal(next, &cursor)
+		}
+		if cursor == "" {
+			return items, nil
+		}
+	}
+	return nil, fmt.Errorf("%s returned more than %d pages", method, maxListPages)
+}
+
+// Request is one request of a batch.
+type Request struct {
+	Method string
+	Params interface{}
+}
+
+// Response is the answer to one request of a batch: its raw result or its error.
+type Response struct {
+	Result json.RawMessage
+	Err    error
+}
+
+// Batch sends requests as one JSON-RPC batch and returns their responses in the same order.
+// The error is set when the batch could not be sent or ctx ended first; an error of a single
+// request is reported in its Response.
+func (c *Client) Batch(ctx context.Context, requests []Request) ([]Response, error) {
+	if len(requests) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, len(requests))
+	channels := make([]chan *Message, len(requests))
+	encoded := make([]json.RawMessage, len(requests))
+	for i, request := range requests {
+		ids[i] = strconv.FormatInt(c.nextID.Add(1), 10)
+		data, err := encode(json.RawMessage(ids[i]), request.Method, request.Params)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = data
+		channels[i] = make(chan *Message, 1)
+	}
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	select {
+	case <-c.closed:
+		c.mu.Unlock()
+		return nil, c.closedError()
+	default:
+	}
+	for i, id := range ids {
+		c.pending[id] = channels[i]
+	}
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		for _, id := range ids {
+			delete(c.pending, id)
+		}
+		c.mu.Unlock()
+	}()
+
+	if err := c.transport.Send(ctx, data); err != nil {
+		if ctx.Err() != nil {
+			c.cancelAll(ids, channels, ctx.Err())
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to send batch: %w", err)
+	}
+
+	responses := make([]Response, len(requests))
+	for i := range requests {
+		select {
+		case msg := <-channels[i]:
+			if msg.Error != nil {
+				responses[i].Err = msg.Error
+			} else {
+				responses[i].Result = msg.Result
+			}
+		case <-ctx.Done():
+			c.cancelAll(ids[i:], channels[i:], ctx.Err())
+			return nil, ctx.Err()
+		case <-c.closed:
+			return nil, c.closedError()
+		}
+	}
+	return responses, nil
+}
+
+// cancelAll cancels the requests of a batch that have not been answered yet.
+func (c *Client) cancelAll(ids []string, channels []chan *Message, reason error) {
+	for i, id := range ids {
+		if len(channels[i]) == 0 {
+			c.cancel(id, reason)
+		}
+	}
+}
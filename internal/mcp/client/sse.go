@@ -0,0 +1,247 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// SSETransport speaks the HTTP+SSE transport of MCP 2024-11-05: messages from the server
+// arrive on an event stream, whose first event names the URL messages are posted to.
+type SSETransport struct {
+	url     string
+	headers http.Header
+	client  *http.Client
+
+	endpoint string
+	receive  func(data []byte)
+	cancel   context.CancelFunc
+
+	done     chan struct{}
+	doneOnce sync.Once
+	err      error
+}
+
+// NewSSETransport creates a transport for the event stream at rawURL. headers are sent
+// with every request, e.g. for authorization. client must not time out whole requests,
+// as the event stream stays open.
+func NewSSETransport(rawURL string, headers http.Header, client *http.Client) *SSETransport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &SSETransport{url: rawURL, headers: headers, client: client, done: make(chan struct{})}
+}
+
+// Start opens the event stream and waits for the endpoint event.
+func (t *SSETransport) Start(ctx context.Context, receive func(data []byte)) error {
+	base, err := url.Parse(t.url)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	t.receive = receive
+
+	// The stream outlives ctx, which only bounds connecting.
+	streamCtx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, t.url, nil)
+	if err != nil {
+		cancel()
+		return err
+	}
+	setHeaders(req, t.headers)
+	req.Header.Set("Accept", "text/event-stream")
+
+	connected := make(chan error, 1)
+	var reportOnce sync.Once
+	report := func(err error) { reportOnce.Do(func() { connected <- err }) }
+	go func() {
+		resp, err := t.client.Do(req)
+		if err != nil {
+			report(err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			report(statusError(resp))
+			return
+		}
+
+		gotEndpoint := false
+		err = readEvents(resp.Body, func(event, data string) bool {
+			if !gotEndpoint {
+				if event != "endpoint" {
+					report(fmt.Errorf("expected an endpoint event, got %q", event))
+					return false
+				}
+				endpoint, err := base.Parse(strings.TrimSpace(data))
+				if err != nil || endpoint.Scheme != base.Scheme || endpoint.Host != base.Host {
+					report(fmt.Errorf("invalid endpoint %q: it must be on the same origin as the stream", data))
+					return false
+				}
+				t.endpoint = endpoint.String()
+				gotEndpoint = true
+				report(nil)
+				return true
+			}
+			if event == "" || event == "message" {
+				receive([]byte(data))
+			}
+			return true
+		})
+		if !gotEndpoint {
+			if err == nil {
+				err = errors.New("stream ended before the endpoint event")
+			}
+			report(err)
+			return
+		}
+		if err == nil {
+			err = errors.New("event stream closed")
+		}
+		t.finish(err)
+	}()
+
+	select {
+	case err := <-connected:
+		if err != nil {
+			cancel()
+			return fmt.Errorf("failed to open event stream: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		cancel()
+		return ctx.Err()
+	}
+}
+
+// Send posts a message to the endpoint. Servers answering in the response body instead of
+// on the stream are supported as well.
+func (t *SSETransport) Send(ctx context.Context, data []byte) error {
+	select {
+	case <-t.done:
+		return ErrClosed
+	default:
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	setHeaders(req, t.headers)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return statusError(resp)
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxMessageBytes))
+		if err != nil {
+			return err
+		}
+		t.receive(body)
+	}
+	return nil
+}
+
+// Done is closed when the event stream has ended.
+func (t *SSETransport) Done() <-chan struct{} {
+	return t.done
+}
+
+// Err reports why the event stream ended.
+func (t *SSETransport) Err() error {
+	select {
+	case <-t.done:
+		return t.err
+	default:
+		return nil
+	}
+}
+
+// Close ends the event stream, which ends the session.
+func (t *SSETransport) Close() error {
+	if t.cancel != nil {
+		t.cancel()
+	}
+	t.finish(ErrClosed)
+	return nil
+}
+
+func (t *SSETransport) finish(err error) {
+	t.doneOnce.Do(func() {
+		t.err = err
+		close(t.done)
+	})
+}
+
+// readEvents parses a server-sent event stream and calls handle for every event until
+// handle returns false or the stream ends.
+func readEvents(r io.Reader, handle func(event, data string) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageBytes)
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 {
+				if !handle(event, strings.Join(data, "\n")) {
+					return nil
+				}
+			}
+			event, data = "", nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		name, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch name {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	return scanner.Err()
+}
+
+func setHeaders(req *http.Request, headers http.Header) {
+	for key, values := range headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+}
+
+// StatusError is returned when an HTTP server answers with an unexpected status. Body
+// holds the start of the response body.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body != "" {
+		return fmt.Sprintf("server responded with %s: %s", e.Status, e.Body)
+	}
+	return fmt.Sprintf("server responded with %s", e.Status)
+}
+
+// statusError describes an unexpected HTTP response.
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: strings.TrimSpace(string(body))}
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// stdioCloseTimeout is how long a server may take to exit after its stdin is closed.
+const stdioCloseTimeout = 5 * time.Second
+
+// StdioTransport runs a server as a subprocess and talks to it over stdin and stdout.
+// The server's stderr is logged.
+type StdioTransport struct {
+	cmd    *exec.Cmd
+	logger *zap.Logger
+
+	writeMu sync.Mutex
+	stdin   io.WriteCloser
+
+	done     chan struct{}
+	doneOnce sync.Once
+	err      error
+}
+
+// NewStdioTransport creates a transport for cmd, which must not have been started.
+// Resource limits and the lifetime of the process are up to the caller building cmd.
+func NewStdioTransport(cmd *exec.Cmd, logger *zap.Logger) *StdioTransport {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &StdioTransport{cmd: cmd, logger: logger, done: make(chan struct{})}
+}
+
+// Start runs the process.
+func (t *StdioTransport) Start(ctx context.Context, receive func(data []byte)) error {
+	stdin, err := t.cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := t.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := t.cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := t.cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", t.cmd.Path, err)
+	}
+	t.stdin = stdin
+
+	go t.logStderr(stderr)
+	go func() {
+		readErr := readLines(stdout, receive)
+		if readErr != nil {
+			// The stream cannot be resynchronized after an oversized or broken line.
+			t.cmd.Process.Kill()
+		}
+		err := t.cmd.Wait()
+		switch {
+		case readErr != nil:
+			t.finish(fmt.Errorf("failed to read from server: %w", readErr))
+		case err != nil:
+			t.finish(fmt.Errorf("server exited: %w", err))
+		default:
+			t.finish(errors.New("server exited"))
+		}
+	}()
+	return nil
+}
+
+// readLines passes every line of r to receive until r ends.
+func readLines(r io.Reader, receive func(data []byte)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageBytes)
+	for scanner.Scan() {
+		receive(scanner.Bytes())
+	}
+	return scanner.Err()
+}
+
+func (t *StdioTransport) logStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	scanner.Buffer(make([]byte, 4096), 64*1024)
+	for scanner.Scan() {
+		if line := scanner.Bytes(); len(line) > 0 {
+			t.logger.Info("stdio server", zap.ByteString("stderr", line))
+		}
+	}
+}
+
+// Send writes a message as one line.
+func (t *StdioTransport) Send(ctx context.Context, data []byte) error {
+	select {
+	case <-t.done:
+		return ErrClosed
+	default:
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write to server: %w", err)
+	}
+	return nil
+}
+
+// Done is closed when the process has exited.
+func (t *StdioTransport) Done() <-chan struct{} {
+	return t.done
+}
+
+// Err reports why the process exited.
+func (t *StdioTransport) Err() error {
+	select {
+	case <-t.done:
+		return t.err
+	default:
+		return nil
+	}
+}
+
+// Close closes the server's stdin and kills the process if it does not exit in time.
+func (t *StdioTransport) Close() error {
+	if t.stdin == nil {
+		return nil
+	}
+	t.writeMu.Lock()
+	t.stdin.Close()
+	t.writeMu.Unlock()
+	select {
+	case <-t.done:
+	case <-time.After(stdioCloseTimeout):
+		if t.cmd.Process != nil {
+			t.cmd.Process.Kill()
+		}
+		<-t.done
+	}
+	return nil
+}
+
+func (t *StdioTransport) finish(err error) {
+	t.doneOnce.Do(func() {
+		t.err = err
+		close(t.done)
+	})
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// StreamTransport exchanges newline-delimited messages over an already connected reader
+// and writer, such as pipes to a server running in the same process.
+type StreamTransport struct {
+	r io.Reader
+
+	writeMu sync.Mutex
+	w       io.WriteCloser
+
+	done     chan struct{}
+	doneOnce sync.Once
+	err      error
+}
+
+// NewStreamTransport creates a transport reading messages from r and writing them to w.
+func NewStreamTransport(r io.Reader, w io.WriteCloser) *StreamTransport {
+	return &StreamTransport{r: r, w: w, done: make(chan struct{})}
+}
+
+// Start reads messages until r ends.
+func (t *StreamTransport) Start(ctx context.Context, receive func(data []byte)) error {
+	go func() {
+		if err := readLines(t.r, receive); err != nil {
+			t.finish(fmt.Errorf("failed to read from server: %w", err))
+			return
+		}
+		t.finish(errors.New("server closed the stream"))
+	}()
+	return nil
+}
+
+// Send writes a message as one line.
+func (t *StreamTransport) Send(ctx context.Context, data []byte) error {
+	select {
+	case <-t.done:
+		return ErrClosed
+	default:
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write to server: %w", err)
+	}
+	return nil
+}
+
+// Done is closed when r has ended.
+func (t *StreamTransport) Done() <-chan struct{} {
+	return t.done
+}
+
+// Err reports why r ended.
+func (t *StreamTransport) Err() error {
+	select {
+	case <-t.done:
+		return t.err
+	default:
+		return nil
+	}
+}
+
+// Close closes w, which tells the server that the client is gone.
+func (t *StreamTransport) Close() error {
+	t.writeMu.Lock()
+	err := t.w.Close()
+	t.writeMu.Unlock()
+	t.finish(ErrClosed)
+	return err
+}
+
+func (t *StreamTransport) finish(err error) {
+	t.doneOnce.Do(func() {
+		t.err = err
+		close(t.done)
+	})
+}
@@ -0,0 +1,249 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sessionHeader         = "Mcp-Session-Id"
+	protocolVersionHeader = "MCP-Protocol-Version"
+	// listenRetryDelay is the pause before the event stream for server messages is reopened.
+	listenRetryDelay = time.Second
+	// closeSessionTimeout bounds ending the session when the transport closes.
+	closeSessionTimeout = 5 * time.Second
+)
+
+// StreamableHTTPTransport speaks the Streamable HTTP transport of MCP 2025-03-26: every
+// message is POSTed to one URL and answered with JSON or an event stream, and once a
+// session exists a GET stream carries messages the server sends on its own.
+type StreamableHTTPTransport struct {
+	url     string
+	headers http.Header
+	client  *http.Client
+
+	receive func(data []byte)
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	mu              sync.Mutex
+	sessionID       string
+	protocolVersion string
+	listening       bool
+
+	done     chan struct{}
+	doneOnce sync.Once
+	err      error
+}
+
+// NewStreamableHTTPTransport creates a transport for the MCP endpoint at rawURL. headers
+// are sent with every request, e.g. for authorization. client must not time out whole
+// requests, as responses may be streamed.
+func NewStreamableHTTPTransport(rawURL string, headers http.Header, client *http.Client) *StreamableHTTPTransport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &StreamableHTTPTransport{url: rawURL, headers: headers, client: client, done: make(chan struct{})}
+}
+
+// Start prepares the transport; the connection is made by the first message.
+func (t *StreamableHTTPTransport) Start(ctx context.Context, receive func(data []byte)) error {
+	t.receive = receive
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+	return nil
+}
+
+func (t *StreamableHTTPTransport) setProtocolVersion(version string) {
+	t.mu.Lock()
+	t.protocolVersion = version
+	t.mu.Unlock()
+}
+
+// Send posts a message and delivers the messages of the response before returning.
+func (t *StreamableHTTPTransport) Send(ctx context.Context, data []byte) error {
+	select {
+	case <-t.done:
+		return ErrClosed
+	default:
+	}
+	// The request ends with the transport or when ctx ends, whichever is first.
+	reqCtx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	req, err := t.newRequest(reqCtx, http.MethodPost, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && t.session() != "" {
+		t.finish(ErrSessionExpired)
+		return ErrSessionExpired
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return statusError(resp)
+	}
+	if id := resp.Header.Get(sessionHeader); id != "" {
+		t.mu.Lock()
+		if t.sessionID == "" {
+			t.sessionID = id
+		}
+		t.mu.Unlock()
+	}
+	t.listen()
+
+	contentType := resp.Header.Get("Content-Type")
+	switch {
+	case strings.HasPrefix(contentType, "text/event-stream"):
+		return readEvents(resp.Body, func(event, data string) bool {
+			if event == "" || event == "message" {
+				t.receive([]byte(data))
+			}
+			return true
+		})
+	case strings.HasPrefix(contentType, "application/json"):
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxMessageBytes))
+		if err != nil {
+			return err
+		}
+		t.receive(body)
+	}
+	return nil
+}
+
+// listen opens the GET stream for messages from the server, once.
+func (t *StreamableHTTPTransport) listen() {
+	t.mu.Lock()
+	if t.listening {
+		t.mu.Unlock()
+		return
+	}
+	t.listening = true
+	t.mu.Unlock()
+
+	go func() {
+		for {
+			supported, err := t.listenOnce()
+			if !supported || t.ctx.Err() != nil {
+				return
+			}
+			if errors.Is(err, ErrSessionExpired) {
+				t.finish(err)
+				return
+			}
+			select {
+			case <-t.ctx.Done():
+				return
+			case <-time.After(listenRetryDelay):
+			}
+		}
+	}()
+}
+
+// listenOnce reads the GET stream until it ends. It reports false when the server does not offer one.
+func (t *StreamableHTTPTransport) listenOnce() (bool, error) {
+	req, err := t.newRequest(t.ctx, http.MethodGet, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusMethodNotAllowed:
+		return false, nil
+	case resp.StatusCode == http.StatusNotFound && t.session() != "":
+		return true, ErrSessionExpired
+	case resp.StatusCode != http.StatusOK:
+		return true, statusError(resp)
+	}
+	return true, readEvents(resp.Body, func(event, data string) bool {
+		if event == "" || event == "message" {
+			t.receive([]byte(data))
+		}
+		return true
+	})
+}
+
+func (t *StreamableHTTPTransport) newRequest(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.url, body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	setHeaders(req, t.headers)
+	t.mu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set(sessionHeader, t.sessionID)
+	}
+	if t.protocolVersion != "" {
+		req.Header.Set(protocolVersionHeader, t.protocolVersion)
+	}
+	t.mu.Unlock()
+	return req, nil
+}
+
+func (t *StreamableHTTPTransport) session() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+// Done is closed when the transport is closed or the session has expired.
+func (t *StreamableHTTPTransport) Done() <-chan struct{} {
+	return t.done
+}
+
+// Err reports why the transport ended.
+func (t *StreamableHTTPTransport) Err() error {
+	select {
+	case <-t.done:
+		return t.err
+	default:
+		return nil
+	}
+}
+
+// Close ends the session on the server and stops the transport.
+func (t *StreamableHTTPTransport) Close() error {
+	if t.cancel == nil {
+		return nil
+	}
+	t.cancel()
+	if t.session() != "" && t.Err() == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), closeSessionTimeout)
+		defer cancel()
+		if req, err := t.newRequest(ctx, http.MethodDelete, nil); err == nil {
+			if resp, err := t.client.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+	}
+	t.finish(ErrClosed)
+	return nil
+}
+
+func (t *StreamableHTTPTransport) finish(err error) {
+	t.doneOnce.Do(func() {
+		t.err = err
+		close(t.done)
+		t.cancel()
+	})
+}
@@ -0,0 +1,754 @@
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/mcp/client"
+)
+
+// supportedVersions are the MCP revisions a server may answer initialize with.
+var supportedVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// maxPromptsRendered bounds the prompts rendered by the prompts check.
+const maxPromptsRendered = 10
+
+type check struct {
+	name string
+	run  func(ctx context.Context, r *runner) error
+}
+
+var checks = []check{
+	{"initialize", checkInitialize},
+	{"ping", checkPing},
+	{"unknown-method", checkUnknownMethod},
+	{"id-types", checkIDTypes},
+	{"parse-error", checkParseError},
+	{"invalid-request", checkInvalidRequest},
+	{"notifications", checkNotifications},
+	{"batch", checkBatch},
+	{"empty-batch", checkEmptyBatch},
+	{"concurrency", checkConcurrency},
+	{"cancellation", checkCancellation},
+	{"large-payload", checkLargePayload},
+	{"tools", checkTools},
+	{"unknown-tool", checkUnknownTool},
+	{"tool-calls", checkToolCalls},
+	{"resources", checkResources},
+	{"prompts", checkPrompts},
+	{"logging", checkLogging},
+}
+
+// findings collects the problems of a check, so that one run reports all of them.
+type findings []string
+
+func (f *findings) addf(format string, args ...interface{}) {
+	*f = append(*f, fmt.Sprintf(format, args...))
+}
+
+func (f findings) err() error {
+	if len(f) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(f, "; "))
+}
+
+// checkInitialize checks the handshake and the server's answer to it.
+func checkInitialize(ctx context.Context, r *runner) error {
+	c, err := dialConn(ctx, r.dial)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+	result, err := c.handshake(ctx)
+	if err != nil {
+		return err
+	}
+
+	var problems findings
+	if !contains(supportedVersions, result.ProtocolVersion) {
+		problems.addf("unknown protocol version %q", result.ProtocolVersion)
+	}
+	if result.ServerInfo.Name == "" {
+		problems.addf("serverInfo.name is missing")
+	}
+	if result.Capabilities == nil {
+		problems.addf("capabilities are missing")
+	}
+	for name, capability := range result.Capabilities {
+		if !isObject(capability) {
+			problems.addf("capability %q is not an object", name)
+		}
+	}
+	// Until initialize is answered a server may send pings and log messages, nothing else.
+	for _, request := range c.serverRequests() {
+		if request.Method != "ping" {
+			problems.addf("the server sent a %s request before the session was initialized", request.Method)
+		}
+	}
+	if err := c.problems(); err != nil {
+		problems.addf("%v", err)
+	}
+	return problems.err()
+}
+
+// checkPing checks that ping is answered with an empty result.
+func checkPing(ctx context.Context, r *runner) error {
+	c, _, err := openConn(ctx, r.dial)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+	response, err := c.request(ctx, `"ping-1"`, "ping", nil)
+	if err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return fmt.Errorf("ping failed: %v", response.Error)
+	}
+	if !isObject(response.Result) {
+		return fmt.Errorf("ping result is not an object: %s", truncate(response.Result, 200))
+	}
+	return nil
+}
+
+// checkUnknownMethod checks that an unknown method is answered with method not found.
+func checkUnknownMethod(ctx context.Context, r *runner) error {
+	c, _, err := openConn(ctx, r.dial)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+	response, err := c.request(ctx, `"unknown-1"`, "conformance/unknown", map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+	return expectError(response, client.CodeMethodNotFound)
+}
+
+// checkIDTypes checks that string and number IDs come back exactly as sent.
+func checkIDTypes(ctx context.Context, r *runner) error {
+	c, _, err := openConn(ctx, r.dial)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+	for _, id := range []string{`"conformance-id"`, `42`, `0`, `"7"`} {
+		// Whether the method succeeds does not matter; the response must carry the ID.
+		if _, err := c.request(ctx, id, "ping", nil); err != nil {
+			return fmt.Errorf("request with ID %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// checkParseError checks that malformed JSON is rejected and does not break the connection.
+func checkParseError(ctx context.Context, r *runner) error {
+	c, _, err := openConn(ctx, r.dial)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+	if err := expectRejected(ctx, c, []byte(`{"jsonrpc":"2.0","id":"parse-1","method":"ping"`), client.CodeParseError, "null"); err != nil {
+		return err
+	}
+	return stillResponsive(ctx, c)
+}
+
+// checkInvalidRequest checks that JSON that is not a valid request is rejected.
+func checkInvalidRequest(ctx context.Context, r *runner) error {
+	c, _, err := openConn(ctx, r.dial)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+	cases := []struct {
+		name string
+		data string
+		id   string
+	}{
+		{"wrong jsonrpc version", `{"jsonrpc":"1.0","id":"invalid-1","method":"ping"}`, `"invalid-1"`},
+		{"method not a string", `{"jsonrpc":"2.0","id":"invalid-2","method":42}`, `"invalid-2"`},
+		{"not an object", `42`, "null"},
+	}
+	var problems findings
+	for _, tc := range cases {
+		// Every case gets its share of the time, so one unanswered case does not fail the others.
+		caseCtx, cancel := context.WithTimeout(ctx, r.options.Timeout/time.Duration(len(cases)+1))
+		// A server that cannot tell the ID of an invalid request answers with a null ID.
+		err := expectRejected(caseCtx, c, []byte(tc.data), client.CodeInvalidRequest, tc.id, "null")
+		cancel()
+		if err != nil {
+			problems.addf("%s: %v", tc.name, err)
+		}
+	}
+	if err := stillResponsive(ctx, c); err != nil {
+		problems.addf("%v", err)
+	}
+	return problems.err()
+}
+
+// checkNotifications checks that notifications, known or not, are never answered.
+func checkNotifications(ctx context.Context, r *runner) error {
+	c, _, err := openConn(ctx, r.dial)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+	notifications := []struct {
+		method string
+		params interface{}
+	}{
+		{"notifications/roots/list_changed", nil},
+		{"notifications/conformance/unknown", map[string]interface{}{"value": 1}},
+		{"notifications/cancelled", map[string]interface{}{"requestId": "never-sent", "reason": "conformance check"}},
+	}
+	for _, n := range notifications {
+		if err := c.notify(ctx, n.method, n.params); err != nil {
+			return fmt.Errorf("failed to send %s: %w", n.method, err)
+		}
+	}
+	// The next response must be the one to this request, not to a notification.
+	if _, err := c.request(ctx, `"after-notifications"`, "ping", nil); err != nil {
+		return err
+	}
+	return c.problems()
+}
+
+// checkBatch checks that a batch is answered with one array holding a response to
+// each request and none to the notification.
+func checkBatch(ctx context.Context, r *runner) error {
+	c, initialized, err := openConn(ctx, r.dial)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+	if initialized.ProtocolVersion == "2025-06-18" {
+		return skipf("batches were removed in protocol version 2025-06-18")
+	}
+	batch := `[` +
+		`{"jsonrpc":"2.0","id":"batch-1","method":"conformance/unknown"},` +
+		`{"jsonrpc":"2.0","method":"notifications/conformance/unknown"},` +
+		`{"jsonrpc":"2.0","id":"batch-2","method":"conformance/other"}` +
+		`]`
+	if err := c.send(ctx, []byte(batch)); err != nil {
+		return fmt.Errorf("the batch was rejected: %w", err)
+	}
+
+	pending := map[string]bool{`"batch-1"`: true, `"batch-2"`: true}
+	var problems findings
+	separate := false
+	for len(pending) > 0 {
+		p, err := c.next(ctx)
+		if err != nil {
+			return fmt.Errorf("%d of 2 batched requests were not answered: %w", len(pending), err)
+		}
+		for _, msg := range p.messages {
+			if msg == nil || !msg.IsResponse() {
+				continue
+			}
+			id := compactID(msg.ID)
+			if !pending[id] {
+				problems.addf("unexpected response with ID %s", id)
+				continue
+			}
+			delete(pending, id)
+			if err := expectError(msg, client.CodeMethodNotFound); err != nil {
+				problems.addf("request %s: %v", id, err)
+			}
+			if !p.batch {
+				separate = true
+			}
+		}
+	}
+	if separate {
+		problems.addf("the batch was answered with separate messages instead of an array")
+	}
+	if err := stillResponsive(ctx, c); err != nil {
+		problems.addf("%v", err)
+	}
+	return problems.err()
+}
+
+// checkEmptyBatch checks that an empty batch is rejected as an invalid request.
+func checkEmptyBatch(ctx context.Context, r *runner) error {
+	c, initialized, err := openConn(ctx, r.dial)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+	if initialized.ProtocolVersion == "2025-06-18" {
+		return skipf("batches were removed in protocol version 2025-06-18")
+	}
+	if err := expectRejected(ctx, c, []byte(`[]`), client.CodeInvalidRequest, "null"); err != nil {
+		return err
+	}
+	return stillResponsive(ctx, c)
+}
+
+// checkConcurrency checks that many simultaneous requests on one connection are all
+// answered, each with its own response.
+func checkConcurrency(ctx context.Context, r *runner) error {
+	c, err := r.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	method := probeMethod(c.InitializeResult())
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var failed []error
+	for i := 0; i < r.options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Call(ctx, method, nil, nil); err != nil {
+				mu.Lock()
+				failed = append(failed, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d concurrent %s requests failed, e.g. %v", len(failed), r.options.Concurrency, method, failed[0])
+	}
+	return nil
+}
+
+// checkCancellation checks that cancelling a request leaves the connection usable. The
+// cancelled request may or may not be answered.
+func checkCancellation(ctx context.Context, r *runner) error {
+	c, initialized, err := openConn(ctx, r.dial)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+	method := probeMethod(*initialized)
+	request := map[string]interface{}{"jsonrpc": "2.0", "id": "cancel-1", "method": method}
+	if err := c.send(ctx, request); err != nil {
+		return fmt.Errorf("failed to send %s: %w", method, err)
+	}
+	if err := c.notify(ctx, "notifications/cancelled", map[string]interface{}{"requestId": "cancel-1", "reason": "conformance check"}); err != nil {
+		return fmt.Errorf("failed to send notifications/cancelled: %w", err)
+	}
+	if err := c.send(ctx, map[string]interface{}{"jsonrpc": "2.0", "id": "after-cancel", "method": "ping"}); err != nil {
+		return fmt.Errorf("failed to send ping: %w", err)
+	}
+	for {
+		response, err := c.response(ctx, `"cancel-1"`, `"after-cancel"`)
+		if err != nil {
+			return fmt.Errorf("after cancelling a request: %w", err)
+		}
+		if sameID(response.ID, `"after-cancel"`) {
+			return c.problems()
+		}
+	}
+}
+
+// checkLargePayload checks that a request far larger than typical line or buffer
+// limits is handled.
+func checkLargePayload(ctx context.Context, r *runner) error {
+	c, initialized, err := openConn(ctx, r.dial)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+	method := probeMethod(*initialized)
+	// Unknown fields of _meta are ignored by the server, whatever their size.
+	params := map[string]interface{}{
+		"_meta": map[string]interface{}{"conformancePadding": strings.Repeat("x", r.options.LargePayloadBytes)},
+	}
+	response, err := c.request(ctx, `"large-1"`, method, params)
+	if err != nil {
+		return fmt.Errorf("%d byte %s request: %w", r.options.LargePayloadBytes, method, err)
+	}
+	if response.Error != nil {
+		return fmt.Errorf("%d byte %s request failed: %v", r.options.LargePayloadBytes, method, response.Error)
+	}
+	return stillResponsive(ctx, c)
+}
+
+// checkTools checks the tool list.
+func checkTools(ctx context.Context, r *runner) error {
+	c, err := r.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	initialized := c.InitializeResult()
+	if !initialized.HasCapability("tools") {
+		return skipf("the server does not declare tools")
+	}
+	if err := hasList(ctx, c, "tools/list", "tools"); err != nil {
+		return err
+	}
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		return fmt.Errorf("tools/list failed: %w", err)
+	}
+
+	var problems findings
+	seen := map[string]bool{}
+	for i, tool := range tools {
+		if tool.Name == "" {
+			problems.addf("tool %d has no name", i)
+			continue
+		}
+		if seen[tool.Name] {
+			problems.addf("tool %s is listed twice", tool.Name)
+		}
+		seen[tool.Name] = true
+		if err := checkSchema(tool.InputSchema); err != nil {
+			problems.addf("tool %s: inputSchema %v", tool.Name, err)
+		}
+		if len(tool.OutputSchema) > 0 {
+			if err := checkSchema(tool.OutputSchema); err != nil {
+				problems.addf("tool %s: outputSchema %v", tool.Name, err)
+			}
+		}
+	}
+	return problems.err()
+}
+
+// checkUnknownTool checks that calling a tool that does not exist fails.
+func checkUnknownTool(ctx context.Context, r *runner) error {
+	c, err := r.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	initialized := c.InitializeResult()
+	if !initialized.HasCapability("tools") {
+		return skipf("the server does not declare tools")
+	}
+	result, err := c.CallTool(ctx, "conformance_unknown_tool", nil)
+	var rpcErr *client.RPCError
+	switch {
+	case errors.As(err, &rpcErr):
+		return nil
+	case err != nil:
+		return fmt.Errorf("tools/call failed: %w", err)
+	case !result.IsError:
+		return errors.New("calling an unknown tool succeeded")
+	}
+	return nil
+}
+
+// checkToolCalls calls the configured tools and checks their results.
+func checkToolCalls(ctx context.Context, r *runner) error {
+	if len(r.options.ToolCalls) == 0 {
+		return skipf("no tool calls configured")
+	}
+	c, err := r.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var problems findings
+	for _, call := range r.options.ToolCalls {
+		raw, err := c.CallRaw(ctx, "tools/call", map[string]interface{}{"name": call.Name, "arguments": arguments(call.Arguments)})
+		if err != nil {
+			problems.addf("%s: %v", call.Name, err)
+			continue
+		}
+		var fields map[string]json.RawMessage
+		var result client.ToolResult
+		if json.Unmarshal(raw, &fields) != nil || json.Unmarshal(raw, &result) != nil {
+			problems.addf("%s: invalid result %s", call.Name, truncate(raw, 200))
+			continue
+		}
+		if _, ok := fields["content"]; !ok {
+			problems.addf("%s: the result has no content", call.Name)
+		}
+		for i, content := range result.Content {
+			if err := checkContent(content); err != nil {
+				problems.addf("%s: content %d: %v", call.Name, i, err)
+			}
+		}
+		if len(result.StructuredContent) > 0 && !isObject(result.StructuredContent) {
+			problems.addf("%s: structuredContent is not an object", call.Name)
+		}
+		if result.IsError {
+			problems.addf("%s: the tool reported an error: %s", call.Name, firstText(result.Content))
+		}
+	}
+	return problems.err()
+}
+
+// checkResources checks the resource list and reads the first resource.
+func checkResources(ctx context.Context, r *runner) error {
+	c, err := r.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	initialized := c.InitializeResult()
+	if !initialized.HasCapability("resources") {
+		return skipf("the server does not declare resources")
+	}
+	if err := hasList(ctx, c, "resources/list", "resources"); err != nil {
+		return err
+	}
+	resources, err := c.ListResources(ctx)
+	if err != nil {
+		return fmt.Errorf("resources/list failed: %w", err)
+	}
+
+	var problems findings
+	for i, resource := range resources {
+		if resource.Name == "" {
+			problems.addf("resource %d has no name", i)
+		}
+		if u, err := url.Parse(resource.URI); err != nil || u.Scheme == "" {
+			problems.addf("resource %d has an invalid URI %q", i, resource.URI)
+		}
+	}
+
+	templates, err := c.ListResourceTemplates(ctx)
+	var rpcErr *client.RPCError
+	switch {
+	case errors.As(err, &rpcErr) && rpcErr.Code == client.CodeMethodNotFound:
+	case err != nil:
+		problems.addf("resources/templates/list failed: %v", err)
+	default:
+		for i, template := range templates {
+			if template.URITemplate == "" || template.Name == "" {
+				problems.addf("resource template %d lacks a uriTemplate or name", i)
+			}
+		}
+	}
+
+	if len(resources) > 0 {
+		contents, err := c.ReadResource(ctx, resources[0].URI)
+		if err != nil {
+			problems.addf("reading %s failed: %v", resources[0].URI, err)
+		} else {
+			if len(contents) == 0 {
+				problems.addf("reading %s returned no contents", resources[0].URI)
+			}
+			for i, content := range contents {
+				if content.URI == "" {
+					problems.addf("contents %d of %s have no uri", i, resources[0].URI)
+				}
+				if content.Text == "" && content.Blob == "" {
+					problems.addf("contents %d of %s have neither text nor blob", i, resources[0].URI)
+				}
+			}
+		}
+	}
+
+	if _, err := c.ReadResource(ctx, "conformance://missing"); !errors.As(err, &rpcErr) {
+		problems.addf("reading a resource that does not exist did not fail")
+	}
+	return problems.err()
+}
+
+// checkPrompts checks the prompt list and renders the first prompts.
+func checkPrompts(ctx context.Context, r *runner) error {
+	c, err := r.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	initialized := c.InitializeResult()
+	if !initialized.HasCapability("prompts") {
+		return skipf("the server does not declare prompts")
+	}
+	if err := hasList(ctx, c, "prompts/list", "prompts"); err != nil {
+		return err
+	}
+	prompts, err := c.ListPrompts(ctx)
+	if err != nil {
+		return fmt.Errorf("prompts/list failed: %w", err)
+	}
+
+	var problems findings
+	seen := map[string]bool{}
+	for i, prompt := range prompts {
+		if prompt.Name == "" {
+			problems.addf("prompt %d has no name", i)
+			continue
+		}
+		if seen[prompt.Name] {
+			problems.addf("prompt %s is listed twice", prompt.Name)
+		}
+		seen[prompt.Name] = true
+		if i >= maxPromptsRendered {
+			continue
+		}
+
+		args := map[string]string{}
+		for key, value := range r.options.PromptArguments[prompt.Name] {
+			args[key] = value
+		}
+		for _, argument := range prompt.Arguments {
+			if _, ok := args[argument.Name]; argument.Required && !ok {
+				args[argument.Name] = "conformance"
+			}
+		}
+		result, err := c.GetPrompt(ctx, prompt.Name, args)
+		if err != nil {
+			problems.addf("rendering %s failed: %v", prompt.Name, err)
+			continue
+		}
+		if len(result.Messages) == 0 {
+			problems.addf("prompt %s rendered no messages", prompt.Name)
+		}
+		for j, msg := range result.Messages {
+			if msg.Role != "user" && msg.Role != "assistant" {
+				problems.addf("prompt %s: message %d has role %q", prompt.Name, j, msg.Role)
+			}
+			if err := checkContent(msg.Content); err != nil {
+				problems.addf("prompt %s: message %d: %v", prompt.Name, j, err)
+			}
+		}
+	}
+
+	var rpcErr *client.RPCError
+	if _, err := c.GetPrompt(ctx, "conformance_unknown_prompt", nil); !errors.As(err, &rpcErr) {
+		problems.addf("rendering a prompt that does not exist did not fail")
+	}
+	return problems.err()
+}
+
+// checkLogging checks that the log level can be set when the server declares logging.
+func checkLogging(ctx context.Context, r *runner) error {
+	c, err := r.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	initialized := c.InitializeResult()
+	if !initialized.HasCapability("logging") {
+		return skipf("the server does not declare logging")
+	}
+	if err := c.SetLoggingLevel(ctx, "debug"); err != nil {
+		return fmt.Errorf("logging/setLevel failed: %w", err)
+	}
+	return nil
+}
+
+// expectError checks that response is an error with code.
+func expectError(response *client.Message, code int) error {
+	if response.Error == nil {
+		return fmt.Errorf("answered with a result instead of error %d", code)
+	}
+	if response.Error.Code != code {
+		return fmt.Errorf("answered with error %d (%s) instead of %d", response.Error.Code, response.Error.Message, code)
+	}
+	return nil
+}
+
+// expectRejected sends data and expects an error response with code and one of ids. HTTP
+// transports may instead reject the message with a 4xx status.
+func expectRejected(ctx context.Context, c *conn, data []byte, code int, ids ...string) error {
+	if err := c.send(ctx, data); err != nil {
+		var status *client.StatusError
+		if errors.As(err, &status) && status.StatusCode >= 400 && status.StatusCode < 500 {
+			return nil
+		}
+		return fmt.Errorf("failed to send: %w", err)
+	}
+	response, err := c.response(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	return expectError(response, code)
+}
+
+// stillResponsive checks that the server answers a request after an earlier check.
+func stillResponsive(ctx context.Context, c *conn) error {
+	if _, err := c.request(ctx, `"still-responsive"`, "ping", nil); err != nil {
+		return fmt.Errorf("the server stopped responding: %w", err)
+	}
+	return nil
+}
+
+// probeMethod is a method without side effects the server is expected to answer.
+func probeMethod(initialized client.InitializeResult) string {
+	if initialized.HasCapability("tools") {
+		return "tools/list"
+	}
+	return "ping"
+}
+
+// hasList checks that the first page of a list method holds its array under key.
+func hasList(ctx context.Context, c *client.Client, method, key string) error {
+	raw, err := c.CallRaw(ctx, method, nil)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", method, err)
+	}
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return fmt.Errorf("%s result is not an object: %s", method, truncate(raw, 200))
+	}
+	if list, ok := result[key]; !ok || len(list) == 0 || list[0] != '[' {
+		return fmt.Errorf("%s result has no %s array", method, key)
+	}
+	return nil
+}
+
+// checkSchema checks that a tool schema is an object schema.
+func checkSchema(schema json.RawMessage) error {
+	var fields map[string]interface{}
+	if len(schema) == 0 || json.Unmarshal(schema, &fields) != nil || fields == nil {
+		return errors.New("is missing or not an object")
+	}
+	if fields["type"] != "object" {
+		return fmt.Errorf("has type %v instead of object", fields["type"])
+	}
+	return nil
+}
+
+// checkContent checks a content block by its type.
+func checkContent(content client.Content) error {
+	switch content.Type {
+	case "text":
+		return nil
+	case "image", "audio":
+		if content.Data == "" || content.MimeType == "" {
+			return fmt.Errorf("%s content lacks data or mimeType", content.Type)
+		}
+	case "resource":
+		if !isObject(content.Resource) {
+			return errors.New("resource content lacks the resource")
+		}
+	case "resource_link":
+		if content.URI == "" || content.Name == "" {
+			return errors.New("resource_link content lacks uri or name")
+		}
+	default:
+		return fmt.Errorf("unknown content type %q", content.Type)
+	}
+	return nil
+}
+
+func arguments(args map[string]interface{}) map[string]interface{} {
+	if args == nil {
+		return map[string]interface{}{}
+	}
+	return args
+}
+
+func firstText(contents []client.Content) string {
+	for _, content := range contents {
+		if content.Type == "text" {
+			return content.Text
+		}
+	}
+	return ""
+}
+
+func isObject(data json.RawMessage) bool {
+	var fields map[string]json.RawMessage
+	return json.Unmarshal(data, &fields) == nil && fields != nil
+}
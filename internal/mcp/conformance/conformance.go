@@ -0,0 +1,262 @@
+// Package conformance checks MCP servers against the protocol, end to end over a transport.
+//
+// Run drives a server through the initialize handshake, the JSON-RPC error cases (malformed
+// JSON, invalid requests, unknown methods), batches, notifications, concurrent and cancelled
+// requests, large messages, and the tools, resources and prompts it declares. Every check opens
+// its own connection, so a server broken by one check does not fail the others.
+//
+// Any transport.MCPServer can be checked in-process with InProcess; servers running elsewhere
+// are reached with Command, SSE or StreamableHTTP.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/mcp/client"
+	"github.com/AkashKesav/API2SDK/internal/mcp/transport"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultTimeout bounds a check when Options.Timeout is not set.
+	DefaultTimeout = 10 * time.Second
+	// DefaultConcurrency is the number of simultaneous requests of the concurrency check.
+	DefaultConcurrency = 32
+	// DefaultLargePayloadBytes is the size of the message sent by the large payload check.
+	DefaultLargePayloadBytes = 1 << 20
+)
+
+// Dialer opens a new, unstarted transport to the server under test.
+type Dialer func(ctx context.Context) (client.Transport, error)
+
+// InProcess serves server over the stdio transport of the transport package, through pipes
+// within this process. Every connection gets its own transport; server is shared.
+func InProcess(server transport.MCPServer, logger *zap.Logger) Dialer {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return func(ctx context.Context) (client.Transport, error) {
+		serverIn, clientOut := io.Pipe()
+		clientIn, serverOut := io.Pipe()
+		stdio := transport.NewStdioTransportWithIO(serverIn, serverOut, logger)
+		go func() {
+			// The transport serves until the client closes its end of the pipe.
+			err := stdio.Start(context.Background(), server)
+			logger.Debug("In-process MCP transport stopped", zap.Error(err))
+			serverOut.Close()
+			serverIn.Close()
+		}()
+		return client.NewStreamTransport(clientIn, clientOut), nil
+	}
+}
+
+// Command runs the server as a subprocess for every connection and talks to it over stdio.
+func Command(logger *zap.Logger, name string, args ...string) Dialer {
+	return func(ctx context.Context) (client.Transport, error) {
+		return client.NewStdioTransport(exec.Command(name, args...), logger), nil
+	}
+}
+
+// SSE connects to the event stream of an HTTP+SSE server at url.
+func SSE(url string, headers http.Header, httpClient *http.Client) Dialer {
+	return func(ctx context.Context) (client.Transport, error) {
+		return client.NewSSETransport(url, headers, httpClient), nil
+	}
+}
+
+// StreamableHTTP connects to the endpoint of a Streamable HTTP server at url.
+func StreamableHTTP(url string, headers http.Header, httpClient *http.Client) Dialer {
+	return func(ctx context.Context) (client.Transport, error) {
+		return client.NewStreamableHTTPTransport(url, headers, httpClient), nil
+	}
+}
+
+// ToolCall is a tool call whose result is checked.
+type ToolCall struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+}
+
+// Options configure a run.
+type Options struct {
+	// Timeout bounds every check.
+	Timeout time.Duration
+	// Concurrency is the number of simultaneous requests of the concurrency check.
+	Concurrency int
+	// LargePayloadBytes is the size of the message sent by the large payload check.
+	LargePayloadBytes int
+	// ToolCalls are called and their results checked. Tools are not called otherwise, as
+	// they may have side effects.
+	ToolCalls []ToolCall
+	// PromptArguments are the arguments prompts are rendered with, by prompt name. Required
+	// arguments without a value are filled with a placeholder.
+	PromptArguments map[string]map[string]string
+	// Checks restricts the run to the named checks. All checks run when it is empty.
+	Checks []string
+	Logger *zap.Logger
+}
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// Result is the outcome of one check.
+type Result struct {
+	Name     string        `json:"name"`
+	Status   Status        `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Failed reports whether any check failed.
+func (r *Report) Failed() bool {
+	for _, result := range r.Results {
+		if result.Status == StatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders the report with one line per check.
+func (r *Report) String() string {
+	var b strings.Builder
+	counts := map[Status]int{}
+	for _, result := range r.Results {
+		counts[result.Status]++
+		fmt.Fprintf(&b, "%-8s %-18s %6dms", strings.ToUpper(string(result.Status)), result.Name, result.Duration.Milliseconds())
+		if result.Detail != "" {
+			fmt.Fprintf(&b, "  %s", result.Detail)
+		}
+		b.WriteByte('\n')
+	}
+	fmt.Fprintf(&b, "%d passed, %d failed, %d skipped\n", counts[StatusPassed], counts[StatusFailed], counts[StatusSkipped])
+	return b.String()
+}
+
+// CheckNames returns the names of all checks, in the order they run.
+func CheckNames() []string {
+	names := make([]string, len(checks))
+	for i, check := range checks {
+		names[i] = check.name
+	}
+	return names
+}
+
+// Run checks the server reached by dial. It returns an error only for invalid options;
+// problems of the server are reported in the Report.
+func Run(ctx context.Context, dial Dialer, options Options) (*Report, error) {
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultTimeout
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = DefaultConcurrency
+	}
+	if options.LargePayloadBytes <= 0 {
+		options.LargePayloadBytes = DefaultLargePayloadBytes
+	}
+	if options.Logger == nil {
+		options.Logger = zap.NewNop()
+	}
+	selected := map[string]bool{}
+	for _, name := range options.Checks {
+		if !contains(CheckNames(), name) {
+			return nil, fmt.Errorf("unknown check %q", name)
+		}
+		selected[name] = true
+	}
+
+	r := &runner{dial: dial, options: options}
+	report := &Report{}
+	for _, check := range checks {
+		if len(selected) > 0 && !selected[check.name] {
+			continue
+		}
+		if ctx.Err() != nil {
+			report.Results = append(report.Results, Result{Name: check.name, Status: StatusSkipped, Detail: "run cancelled"})
+			continue
+		}
+		report.Results = append(report.Results, r.run(ctx, check))
+	}
+	return report, nil
+}
+
+// runner carries what checks need.
+type runner struct {
+	dial    Dialer
+	options Options
+}
+
+func (r *runner) run(ctx context.Context, check check) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.options.Timeout)
+	defer cancel()
+	started := time.Now()
+	err := check.run(ctx, r)
+	result := Result{Name: check.name, Status: StatusPassed, Duration: time.Since(started)}
+
+	var skipped *skipError
+	switch {
+	case errors.As(err, &skipped):
+		result.Status = StatusSkipped
+		result.Detail = skipped.reason
+	case err != nil:
+		result.Status = StatusFailed
+		result.Detail = err.Error()
+	}
+	r.options.Logger.Debug("Conformance check finished",
+		zap.String("check", check.name),
+		zap.String("status", string(result.Status)),
+		zap.String("detail", result.Detail))
+	return result
+}
+
+// connect opens a Client to the server.
+func (r *runner) connect(ctx context.Context) (*client.Client, error) {
+	transport, err := r.dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial: %w", err)
+	}
+	return client.Connect(ctx, transport, client.Options{
+		ClientInfo: client.Implementation{Name: "api2sdk-mcp-conformance", Version: "1.0.0"},
+		Logger:     r.options.Logger,
+	})
+}
+
+// skipError marks a check that does not apply to the server.
+type skipError struct {
+	reason string
+}
+
+func (e *skipError) Error() string {
+	return "skipped: " + e.reason
+}
+
+func skipf(format string, args ...interface{}) error {
+	return &skipError{reason: fmt.Sprintf(format, args...)}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package conformance
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/mcp/client"
+	"github.com/AkashKesav/API2SDK/internal/mcp/servers"
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// memoryIntegrations lists its integrations; unused methods panic.
+type memoryIntegrations struct {
+	services.IntegrationService
+	integrations []*models.Integration
+}
+
+func (s *memoryIntegrations) ListIntegrations(ctx context.Context) ([]*models.Integration, error) {
+	return s.integrations, nil
+}
+
+func (s *memoryIntegrations) GetIntegration(ctx context.Context, id primitive.ObjectID) (*models.Integration, error) {
+	for _, integration := range s.integrations {
+		if integration.ID == id {
+			return integration, nil
+		}
+	}
+	return nil, errors.New("integration not found")
+}
+
+// noTools is a tool provider without tools; unused methods panic.
+type noTools struct {
+	services.ToolProvider
+}
+
+func (noTools) GetTools(ctx context.Context, integrationID primitive.ObjectID) ([]models.Tool, error) {
+	return nil, nil
+}
+
+// memoryPrompts has no custom prompts; unused methods panic.
+type memoryPrompts struct {
+	repositories.PromptRepository
+}
+
+func (r *memoryPrompts) ListByIntegration(ctx context.Context, integrationID primitive.ObjectID) ([]*models.Prompt, error) {
+	return nil, nil
+}
+
+func testOptions(checks ...string) Options {
+	return Options{Timeout: 5 * time.Second, Concurrency: 8, LargePayloadBytes: 256 << 10, Checks: checks}
+}
+
+func statuses(report *Report) map[string]Status {
+	byName := map[string]Status{}
+	for _, result := range report.Results {
+		byName[result.Name] = result.Status
+	}
+	return byName
+}
+
+func TestRepositoryServersConform(t *testing.T) {
+	integrations := &memoryIntegrations{integrations: []*models.Integration{{ID: primitive.NewObjectID(), Name: "crm"}}}
+	prompts := services.NewPromptService(&memoryPrompts{})
+	// Without a linked account owner the servers expose integrations only, which need no database.
+	resources := services.NewResourceService(integrations, noTools{}, nil, nil)
+	tests := map[string]func() Dialer{
+		"unified": func() Dialer {
+			return InProcess(servers.NewUnifiedMCPServer(zap.NewNop(), integrations, noTools{}, nil, prompts, resources, nil, "user-1", "", false), nil)
+		},
+		"apps": func() Dialer {
+			return InProcess(servers.NewAppsMCPServer(zap.NewNop(), integrations, noTools{}, prompts, resources, nil, "user-1", "", []string{"crm"}), nil)
+		},
+	}
+	for name, dial := range tests {
+		t.Run(name, func(t *testing.T) {
+			report, err := Run(context.Background(), dial(), testOptions())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Failed() {
+				t.Fatalf("conformance checks failed:\n%s", report)
+			}
+		})
+	}
+}
+
+// scriptedServer answers every request on a stream with answer, which returns the result.
+func scriptedServer(answer func(method string) interface{}) Dialer {
+	return func(ctx context.Context) (client.Transport, error) {
+		serverIn, clientOut := io.Pipe()
+		clientIn, serverOut := io.Pipe()
+		go func() {
+			defer serverOut.Close()
+			scanner := bufio.NewScanner(serverIn)
+			for scanner.Scan() {
+				var msg client.Message
+				if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || len(msg.ID) == 0 || msg.Method == "" {
+					continue
+				}
+				data, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": msg.ID, "result": answer(msg.Method)})
+				if _, err := serverOut.Write(append(data, '\n')); err != nil {
+					return
+				}
+			}
+		}()
+		return client.NewStreamTransport(clientIn, clientOut), nil
+	}
+}
+
+func TestRunReportsViolations(t *testing.T) {
+	// The server succeeds at everything, including methods it does not have, and lists a
+	// tool without a name and one without an input schema.
+	dial := scriptedServer(func(method string) interface{} {
+		switch method {
+		case "initialize":
+			return map[string]interface{}{
+				"protocolVersion": "2025-03-26",
+				"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+				"serverInfo":      map[string]string{"name": "scripted", "version": "1.0.0"},
+			}
+		case "tools/list":
+			return map[string]interface{}{"tools": []map[string]interface{}{{"description": "nameless"}, {"name": "schemaless"}}}
+		default:
+			return map[string]interface{}{}
+		}
+	})
+
+	report, err := Run(context.Background(), dial, testOptions("initialize", "ping", "unknown-method", "tools", "prompts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Status{
+		"initialize":     StatusPassed,
+		"ping":           StatusPassed,
+		"unknown-method": StatusFailed,
+		"tools":          StatusFailed,
+		"prompts":        StatusSkipped,
+	}
+	got := statuses(report)
+	for name, status := range want {
+		if got[name] != status {
+			t.Errorf("check %s = %s, want %s\n%s", name, got[name], status, report)
+		}
+	}
+	if !report.Failed() {
+		t.Error("Failed() = false for a non-compliant server")
+	}
+
+	if _, err := Run(context.Background(), dial, testOptions("no-such-check")); err == nil {
+		t.Error("Run() accepted an unknown check")
+	}
+}
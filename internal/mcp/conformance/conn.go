@@ -0,0 +1,260 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/AkashKesav/API2SDK/internal/mcp/client"
+)
+
+// incomingQueueSize is the number of received payloads kept until a check reads them.
+const incomingQueueSize = 1024
+
+// payload is one message or batch as the server sent it.
+type payload struct {
+	messages []*client.Message
+	batch    bool
+}
+
+// conn is a connection below the client, so that checks control every byte sent and see
+// every message received, including those a Client would reject.
+type conn struct {
+	transport client.Transport
+	incoming  chan payload
+
+	mu        sync.Mutex
+	dropped   bool
+	malformed [][]byte
+	// requests are the requests the server sent, in order.
+	requests []*client.Message
+}
+
+// dialConn opens a connection without initializing it.
+func dialConn(ctx context.Context, dial Dialer) (*conn, error) {
+	transport, err := dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial: %w", err)
+	}
+	c := &conn{transport: transport, incoming: make(chan payload, incomingQueueSize)}
+	if err := transport.Start(ctx, c.receive); err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	return c, nil
+}
+
+// openConn opens a connection and completes the initialize handshake.
+func openConn(ctx context.Context, dial Dialer) (*conn, *client.InitializeResult, error) {
+	c, err := dialConn(ctx, dial)
+	if err != nil {
+		return nil, nil, err
+	}
+	initialized, err := c.handshake(ctx)
+	if err != nil {
+		c.close()
+		return nil, nil, err
+	}
+	return c, initialized, nil
+}
+
+func (c *conn) receive(data []byte) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return
+	}
+	var p payload
+	if data[0] == '[' {
+		p.batch = true
+		if err := json.Unmarshal(data, &p.messages); err != nil {
+			c.recordMalformed(data)
+			return
+		}
+	} else {
+		var msg client.Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.recordMalformed(data)
+			return
+		}
+		p.messages = []*client.Message{&msg}
+	}
+
+	for _, msg := range p.messages {
+		if msg != nil && msg.IsRequest() {
+			c.mu.Lock()
+			c.requests = append(c.requests, msg)
+			c.mu.Unlock()
+			if msg.Method == "ping" {
+				go c.send(context.Background(), map[string]interface{}{"jsonrpc": "2.0", "id": msg.ID, "result": map[string]interface{}{}})
+			}
+		}
+	}
+	select {
+	case c.incoming <- p:
+	default:
+		c.mu.Lock()
+		c.dropped = true
+		c.mu.Unlock()
+	}
+}
+
+func (c *conn) recordMalformed(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// The transport may reuse data once receive returns.
+	c.malformed = append(c.malformed, append([]byte(nil), data...))
+}
+
+// send marshals v and sends it, or sends it as it is when it is a []byte.
+func (c *conn) send(ctx context.Context, v interface{}) error {
+	data, ok := v.([]byte)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+	return c.transport.Send(ctx, data)
+}
+
+// request sends a request with the given raw ID and waits for its response.
+func (c *conn) request(ctx context.Context, id, method string, params interface{}) (*client.Message, error) {
+	request := map[string]interface{}{"jsonrpc": "2.0", "id": json.RawMessage(id), "method": method}
+	if params != nil {
+		request["params"] = params
+	}
+	if err := c.send(ctx, request); err != nil {
+		return nil, fmt.Errorf("failed to send %s: %w", method, err)
+	}
+	return c.response(ctx, id)
+}
+
+// notify sends a notification.
+func (c *conn) notify(ctx context.Context, method string, params interface{}) error {
+	notification := map[string]interface{}{"jsonrpc": "2.0", "method": method}
+	if params != nil {
+		notification["params"] = params
+	}
+	return c.send(ctx, notification)
+}
+
+// response waits for the response with one of the given raw IDs. Other responses are errors,
+// as checks only have one request outstanding; notifications and server requests are skipped.
+func (c *conn) response(ctx context.Context, ids ...string) (*client.Message, error) {
+	for {
+		p, err := c.next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range p.messages {
+			if msg == nil || !msg.IsResponse() {
+				continue
+			}
+			for _, id := range ids {
+				if sameID(msg.ID, id) {
+					return msg, nil
+				}
+			}
+			return nil, fmt.Errorf("got a response with ID %s while waiting for %s", msg.ID, strings.Join(ids, " or "))
+		}
+	}
+}
+
+// next returns the next payload from the server.
+func (c *conn) next(ctx context.Context) (payload, error) {
+	select {
+	case p := <-c.incoming:
+		return p, nil
+	default:
+	}
+	select {
+	case p := <-c.incoming:
+		return p, nil
+	case <-c.transport.Done():
+		// Payloads received before the connection ended are still delivered.
+		select {
+		case p := <-c.incoming:
+			return p, nil
+		default:
+		}
+		if err := c.transport.Err(); err != nil {
+			return payload{}, fmt.Errorf("connection ended: %w", err)
+		}
+		return payload{}, client.ErrClosed
+	case <-ctx.Done():
+		return payload{}, fmt.Errorf("no answer from the server: %w", ctx.Err())
+	}
+}
+
+// handshake initializes the session.
+func (c *conn) handshake(ctx context.Context) (*client.InitializeResult, error) {
+	params := map[string]interface{}{
+		"protocolVersion": client.ProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      client.Implementation{Name: "api2sdk-mcp-conformance", Version: "1.0.0"},
+	}
+	response, err := c.request(ctx, `"initialize"`, "initialize", params)
+	if err != nil {
+		return nil, fmt.Errorf("initialize failed: %w", err)
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("initialize failed: %w", response.Error)
+	}
+	var result client.InitializeResult
+	if err := json.Unmarshal(response.Result, &result); err != nil {
+		return nil, fmt.Errorf("invalid initialize result: %w", err)
+	}
+	if err := c.notify(ctx, "notifications/initialized", nil); err != nil {
+		return nil, fmt.Errorf("failed to send notifications/initialized: %w", err)
+	}
+	return &result, nil
+}
+
+// serverRequests returns the requests the server has sent so far.
+func (c *conn) serverRequests() []*client.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*client.Message(nil), c.requests...)
+}
+
+// problems reports messages that could not be parsed or were dropped.
+func (c *conn) problems() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var errs []error
+	for _, data := range c.malformed {
+		errs = append(errs, fmt.Errorf("the server sent invalid JSON: %s", truncate(data, 200)))
+	}
+	if c.dropped {
+		errs = append(errs, errors.New("the server sent more messages than were read"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *conn) close() {
+	c.transport.Close()
+}
+
+// compactID returns a raw ID in the form it was sent in.
+func compactID(id json.RawMessage) string {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, id); err != nil {
+		return string(id)
+	}
+	return compacted.String()
+}
+
+// sameID reports whether the raw ID received matches the raw ID sent.
+func sameID(received json.RawMessage, sent string) bool {
+	return compactID(received) == sent
+}
+
+func truncate(data []byte, limit int) string {
+	if len(data) <= limit {
+		return string(data)
+	}
+	return string(data[:limit]) + "..."
+}
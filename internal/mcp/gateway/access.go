@@ -0,0 +1,63 @@
+package gateway
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// access is a compiled Access.
+type access struct {
+	allow []*regexp.Regexp
+	deny  []*regexp.Regexp
+}
+
+func compileAccess(a Access) (access, error) {
+	var compiled access
+	var err error
+	if compiled.allow, err = compilePatterns(a.Allow); err != nil {
+		return access{}, err
+	}
+	if compiled.deny, err = compilePatterns(a.Deny); err != nil {
+		return access{}, err
+	}
+	return compiled, nil
+}
+
+// compilePatterns turns patterns where "*" matches any run of characters into anchored expressions.
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return nil, fmt.Errorf("empty access pattern")
+		}
+		parts := strings.Split(pattern, "*")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		expression, err := regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid access pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, expression)
+	}
+	return compiled, nil
+}
+
+// permits reports whether key is allowed and not denied.
+func (a access) permits(key string) bool {
+	for _, pattern := range a.deny {
+		if pattern.MatchString(key) {
+			return false
+		}
+	}
+	if len(a.allow) == 0 {
+		return true
+	}
+	for _, pattern := range a.allow {
+		if pattern.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,639 @@
+// Package gateway aggregates several MCP servers behind one MCP endpoint.
+//
+// A Gateway connects to each upstream server as a client and serves the union of their
+// tools, prompts and resources. Tool and prompt names are prefixed with the upstream's
+// name and "__", resource URIs with the upstream's name and "+", so entries of different
+// upstreams cannot collide and every request is routed to the upstream that owns it.
+// Notifications are forwarded to the sessions they concern. An upstream that disconnects
+// drops out of the catalog and is reconnected with backoff while the others keep serving.
+// Access lists, for the whole gateway and per user, hide entries and refuse requests for them.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/hosting"
+	"github.com/AkashKesav/API2SDK/internal/mcp/client"
+	"github.com/AkashKesav/API2SDK/internal/mcp/transport/httpserver"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// Transports of upstream servers. The gateway itself serves over SSE or Streamable HTTP.
+const (
+	TransportStdio          = "stdio"
+	TransportSSE            = "sse"
+	TransportStreamableHTTP = "streamable-http"
+)
+
+const (
+	// NameSeparator joins an upstream's name and the name of one of its tools or prompts.
+	NameSeparator = "__"
+	// URISeparator joins an upstream's name and the URI of one of its resources. The
+	// upstream's name becomes part of the URI scheme, so the result is still a valid URI.
+	URISeparator = "+"
+)
+
+const (
+	// maxMessageBytes bounds a single message from a client.
+	maxMessageBytes = 16 << 20
+	// requestTimeout bounds the handling of a single request.
+	requestTimeout = 5 * time.Minute
+	// shutdownTimeout bounds closing the HTTP listener.
+	shutdownTimeout = 5 * time.Second
+)
+
+// supportedVersions are the MCP revisions the gateway serves, newest first.
+var supportedVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+var upstreamNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{0,31}$`)
+
+// Upstream is an MCP server the gateway connects to.
+type Upstream struct {
+	// Name prefixes the upstream's entries: a lowercase letter followed by lowercase letters, digits and hyphens.
+	Name      string
+	Transport string
+	// URL is the event stream of an SSE upstream or the endpoint of a Streamable HTTP upstream.
+	URL     string
+	Headers http.Header
+	// HTTPClient reaches URL. It must not time out whole requests, as event streams stay open.
+	HTTPClient *http.Client
+	// Command is the command line of a stdio upstream, which is run like a bridge server's.
+	Command []string
+	// Env are extra "KEY=value" variables of Command.
+	Env []string
+}
+
+// Access lists decide which entries are exposed. Patterns match namespaced tool and prompt
+// names and resource URIs; "*" matches any run of characters.
+type Access struct {
+	// Allow exposes only matching entries; when empty, everything not denied is exposed.
+	Allow []string
+	// Deny hides matching entries, even if they are allowed.
+	Deny []string
+}
+
+// User may open sessions with a bearer token.
+type User struct {
+	ID     string
+	Token  string
+	Access Access
+}
+
+// Config describes the upstreams and how to expose them.
+type Config struct {
+	Transport string
+	Port      int
+	Upstreams []Upstream
+	// Access applies to every session.
+	Access Access
+	// Users, when set, are the only ones who may open sessions, each also limited by their own Access.
+	Users []User
+	// Limits apply to the processes of stdio upstreams.
+	Limits hosting.Limits
+}
+
+// Validate checks that the configuration can be served.
+func (c *Config) Validate() error {
+	if c.Transport != TransportSSE && c.Transport != TransportStreamableHTTP {
+		return fmt.Errorf("unsupported gateway transport: %s. Supported: %s, %s", c.Transport, TransportSSE, TransportStreamableHTTP)
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("a valid port is required for the %s transport", c.Transport)
+	}
+	if len(c.Upstreams) == 0 {
+		return errors.New("at least one upstream is required")
+	}
+	names := make(map[string]bool, len(c.Upstreams))
+	for _, upstream := range c.Upstreams {
+		if !upstreamNamePattern.MatchString(upstream.Name) {
+			return fmt.Errorf("invalid upstream name %q: use up to 32 lowercase letters, digits and hyphens, starting with a letter", upstream.Name)
+		}
+		if names[upstream.Name] {
+			return fmt.Errorf("duplicate upstream name %q", upstream.Name)
+		}
+		names[upstream.Name] = true
+		switch upstream.Transport {
+		case TransportStdio:
+			if len(upstream.Command) == 0 || upstream.Command[0] == "" {
+				return fmt.Errorf("upstream %s: a command is required for the stdio transport", upstream.Name)
+			}
+		case TransportSSE, TransportStreamableHTTP:
+			if !strings.HasPrefix(upstream.URL, "http://") && !strings.HasPrefix(upstream.URL, "https://") {
+				return fmt.Errorf("upstream %s: an http or https URL is required for the %s transport", upstream.Name, upstream.Transport)
+			}
+		default:
+			return fmt.Errorf("upstream %s: unsupported transport %q", upstream.Name, upstream.Transport)
+		}
+	}
+	if _, err := compileAccess(c.Access); err != nil {
+		return err
+	}
+	tokens := make(map[string]bool, len(c.Users))
+	for _, user := range c.Users {
+		if user.ID == "" || user.Token == "" {
+			return errors.New("gateway users need an ID and a token")
+		}
+		if tokens[user.Token] {
+			return fmt.Errorf("user %s: tokens must be unique", user.ID)
+		}
+		tokens[user.Token] = true
+		if _, err := compileAccess(user.Access); err != nil {
+			return fmt.Errorf("user %s: %w", user.ID, err)
+		}
+	}
+	return nil
+}
+
+// gatewayUser is a User with compiled access lists.
+type gatewayUser struct {
+	User
+	access access
+}
+
+// progressRoute routes progress notifications back to the session that asked for them.
+type progressRoute struct {
+	session *httpserver.Session
+	token   json.RawMessage
+}
+
+// Gateway serves the aggregated catalog of its upstreams to remote sessions.
+type Gateway struct {
+	config    Config
+	logger    *zap.Logger
+	access    access
+	users     []*gatewayUser
+	upstreams []*upstream
+	byName    map[string]*upstream
+
+	server *httpserver.Server
+
+	mu sync.Mutex
+	// progress are the rewritten progress tokens of forwarded requests, keyed by the gateway's token.
+	progress  map[string]progressRoute
+	nextToken int64
+	// subscriptions count the sessions subscribed to each namespaced resource URI.
+	subscriptions map[string]int
+	// subscribed are the namespaced URIs of the resources each session is subscribed to.
+	subscribed map[*httpserver.Session]map[string]bool
+}
+
+// New creates a Gateway for config. Call Serve to run it.
+func New(config Config, logger *zap.Logger) (*Gateway, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	g := &Gateway{
+		config:        config,
+		logger:        logger.With(zap.Int("port", config.Port)),
+		byName:        make(map[string]*upstream, len(config.Upstreams)),
+		progress:      make(map[string]progressRoute),
+		subscriptions: make(map[string]int),
+		subscribed:    make(map[*httpserver.Session]map[string]bool),
+	}
+	g.access, _ = compileAccess(config.Access)
+	for _, user := range config.Users {
+		compiled, _ := compileAccess(user.Access)
+		g.users = append(g.users, &gatewayUser{User: user, access: compiled})
+	}
+	for _, upstreamConfig := range config.Upstreams {
+		u := newUpstream(g, upstreamConfig)
+		g.upstreams = append(g.upstreams, u)
+		g.byName[u.config.Name] = u
+	}
+	g.server = httpserver.New(httpserver.Config{
+		Transport:      config.Transport,
+		BodyLimit:      maxMessageBytes,
+		RequestTimeout: requestTimeout,
+		Authenticate:   g.authenticate,
+		Realm:          "mcp-gateway",
+		Health:         g.health,
+	}, g, g.logger)
+	return g, nil
+}
+
+// Serve connects to the upstreams and serves sessions until ctx is cancelled or the
+// listener fails. Upstreams that fail are reconnected; they do not end Serve.
+func (g *Gateway) Serve(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var upstreams sync.WaitGroup
+	for _, u := range g.upstreams {
+		upstreams.Add(1)
+		go func(u *upstream) {
+			defer upstreams.Done()
+			u.run(ctx)
+		}(u)
+	}
+
+	app := g.server.App()
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(fmt.Sprintf(":%d", g.config.Port), fiber.ListenConfig{DisableStartupMessage: true})
+	}()
+	g.logger.Info("Started MCP gateway", zap.String("transport", g.config.Transport), zap.Int("upstreams", len(g.upstreams)))
+
+	reaper := time.NewTicker(time.Minute)
+	defer reaper.Stop()
+
+	var result error
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case err := <-listenErr:
+			if err == nil {
+				err = fmt.Errorf("listener on port %d closed", g.config.Port)
+			}
+			result = fmt.Errorf("gateway on port %d failed: %w", g.config.Port, err)
+			break loop
+		case <-reaper.C:
+			g.server.ReapIdleSessions()
+		}
+	}
+
+	g.server.CloseAllSessions()
+	if err := app.ShutdownWithTimeout(shutdownTimeout); err != nil {
+		g.logger.Warn("Failed to shut down gateway listener", zap.Error(err))
+	}
+	cancel()
+	upstreams.Wait()
+	return result
+}
+
+// health reports the state of every upstream.
+func (g *Gateway) health() fiber.Map {
+	upstreams := make([]map[string]interface{}, 0, len(g.upstreams))
+	for _, u := range g.upstreams {
+		upstreams = append(upstreams, u.health())
+	}
+	return fiber.Map{"upstreams": upstreams}
+}
+
+// handle answers a request from a session.
+func (g *Gateway) handle(ctx context.Context, s *httpserver.Session, method string, params json.RawMessage) (json.RawMessage, error) {
+	switch method {
+	case "initialize":
+		return g.initialize(params)
+	case "ping":
+		return json.RawMessage("{}"), nil
+	case "tools/list":
+		return g.list(s, "tools", func(c *catalog) []entry { return c.tools })
+	case "prompts/list":
+		return g.list(s, "prompts", func(c *catalog) []entry { return c.prompts })
+	case "resources/list":
+		return g.list(s, "resources", func(c *catalog) []entry { return c.resources })
+	case "resources/templates/list":
+		return g.list(s, "resourceTemplates", func(c *catalog) []entry { return c.templates })
+	case "tools/call":
+		return g.forward(ctx, s, method, params, "name", NameSeparator, "tool")
+	case "prompts/get":
+		return g.forward(ctx, s, method, params, "name", NameSeparator, "prompt")
+	case "resources/read":
+		result, err := g.forward(ctx, s, method, params, "uri", URISeparator, "resource")
+		if err != nil {
+			return nil, err
+		}
+		name, _, _ := strings.Cut(stringField(params, "uri"), URISeparator)
+		return namespaceContents(result, name), nil
+	case "resources/subscribe":
+		return g.subscribe(ctx, s, params)
+	case "resources/unsubscribe":
+		return g.unsubscribe(ctx, s, params)
+	case "completion/complete":
+		return g.complete(ctx, s, params)
+	case "logging/setLevel":
+		for _, u := range g.upstreams {
+			if u.hasCapability("logging") {
+				if _, err := u.call(ctx, method, params); err != nil {
+					u.logger.Debug("Failed to set log level", zap.Error(err))
+				}
+			}
+		}
+		return json.RawMessage("{}"), nil
+	default:
+		return nil, &client.RPCError{Code: client.CodeMethodNotFound, Message: "Method not found", Data: mustMarshal(method)}
+	}
+}
+
+// initialize answers with the gateway's own capabilities; upstreams are initialized separately.
+func (g *Gateway) initialize(params json.RawMessage) (json.RawMessage, error) {
+	version := supportedVersions[0]
+	requested := stringField(params, "protocolVersion")
+	for _, supported := range supportedVersions {
+		if requested == supported {
+			version = requested
+		}
+	}
+	return json.Marshal(map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"tools":       map[string]interface{}{"listChanged": true},
+			"prompts":     map[string]interface{}{"listChanged": true},
+			"resources":   map[string]interface{}{"subscribe": true, "listChanged": true},
+			"logging":     map[string]interface{}{},
+			"completions": map[string]interface{}{},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    "api2sdk-mcp-gateway",
+			"version": "1.0.0",
+		},
+		"instructions": "Tools and prompts are named <server>" + NameSeparator + "<name> and resource URIs <server>" + URISeparator + "<uri>, where <server> is the MCP server providing them.",
+	})
+}
+
+// list returns the entries of the connected upstreams that the session may see.
+func (g *Gateway) list(s *httpserver.Session, key string, entries func(c *catalog) []entry) (json.RawMessage, error) {
+	definitions := []json.RawMessage{}
+	for _, u := range g.upstreams {
+		u.mu.RLock()
+		for _, e := range entries(&u.catalog) {
+			if g.permits(s, e.key) {
+				definitions = append(definitions, e.definition)
+			}
+		}
+		u.mu.RUnlock()
+	}
+	return json.Marshal(map[string]interface{}{key: definitions})
+}
+
+// forward sends a request naming one entry, in params[keyField], to the upstream owning it.
+func (g *Gateway) forward(ctx context.Context, s *httpserver.Session, method string, params json.RawMessage, keyField, separator, kind string) (json.RawMessage, error) {
+	key := stringField(params, keyField)
+	if key == "" {
+		return nil, client.NewError(client.CodeInvalidParams, "%s is required", keyField)
+	}
+	u, original, ok := g.route(key, separator)
+	if !ok || !g.permits(s, key) {
+		return nil, client.NewError(client.CodeInvalidParams, "unknown %s: %s", kind, key)
+	}
+	originalValue, _ := json.Marshal(original)
+	params = setField(params, originalValue, keyField)
+
+	if token, ok := field(params, "_meta", "progressToken"); ok {
+		gatewayToken := g.addProgress(s, token)
+		defer g.dropProgress(gatewayToken)
+		value, _ := json.Marshal(gatewayToken)
+		params = setField(params, value, "_meta", "progressToken")
+	}
+	return u.call(ctx, method, params)
+}
+
+// complete routes a completion request by the prompt or resource template it refers to.
+func (g *Gateway) complete(ctx context.Context, s *httpserver.Session, params json.RawMessage) (json.RawMessage, error) {
+	ref, _ := field(params, "ref")
+	keyField, separator := "name", NameSeparator
+	if stringField(ref, "type") == "ref/resource" {
+		keyField, separator = "uri", URISeparator
+	}
+	key := stringField(ref, keyField)
+	u, original, ok := g.route(key, separator)
+	if !ok || !g.permits(s, key) {
+		return nil, client.NewError(client.CodeInvalidParams, "unknown reference: %s", key)
+	}
+	originalValue, _ := json.Marshal(original)
+	return u.call(ctx, "completion/complete", setField(params, originalValue, "ref", keyField))
+}
+
+// route finds the upstream owning a namespaced key and returns the key as the upstream knows it.
+func (g *Gateway) route(key, separator string) (*upstream, string, bool) {
+	name, original, ok := strings.Cut(key, separator)
+	if !ok || original == "" {
+		return nil, "", false
+	}
+	u, ok := g.byName[name]
+	return u, original, ok
+}
+
+// permits reports whether the session may see and use the entry with the namespaced key.
+func (g *Gateway) permits(s *httpserver.Session, key string) bool {
+	if !g.access.permits(key) {
+		return false
+	}
+	user := sessionUser(s)
+	return user == nil || user.access.permits(key)
+}
+
+func (g *Gateway) addProgress(s *httpserver.Session, token json.RawMessage) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.nextToken++
+	gatewayToken := fmt.Sprintf("gateway-%d", g.nextToken)
+	g.progress[gatewayToken] = progressRoute{session: s, token: token}
+	return gatewayToken
+}
+
+func (g *Gateway) dropProgress(token string) {
+	g.mu.Lock()
+	delete(g.progress, token)
+	g.mu.Unlock()
+}
+
+// routeProgress delivers a progress notification of an upstream to the session that asked for it.
+func (g *Gateway) routeProgress(params json.RawMessage) {
+	token := stringField(params, "progressToken")
+	g.mu.Lock()
+	route, ok := g.progress[token]
+	g.mu.Unlock()
+	if !ok {
+		return
+	}
+	g.server.Deliver(route.session, &client.Message{
+		JSONRPC: "2.0",
+		Method:  "notifications/progress",
+		Params:  setField(params, route.token, "progressToken"),
+	})
+}
+
+// subscribe subscribes a session to updates of a resource. The upstream is subscribed once
+// for all sessions.
+func (g *Gateway) subscribe(ctx context.Context, s *httpserver.Session, params json.RawMessage) (json.RawMessage, error) {
+	key := stringField(params, "uri")
+	u, original, ok := g.route(key, URISeparator)
+	if !ok || !g.permits(s, key) {
+		return nil, client.NewError(client.CodeInvalidParams, "unknown resource: %s", key)
+	}
+	g.mu.Lock()
+	if s.Closed() {
+		// SessionClosed has already released the session's subscriptions.
+		g.mu.Unlock()
+		return nil, client.NewError(client.CodeInternalError, "session closed")
+	}
+	if g.subscribed[s][key] {
+		g.mu.Unlock()
+		return json.RawMessage("{}"), nil
+	}
+	if g.subscribed[s] == nil {
+		g.subscribed[s] = make(map[string]bool)
+	}
+	g.subscribed[s][key] = true
+	g.subscriptions[key]++
+	first := g.subscriptions[key] == 1
+	g.mu.Unlock()
+
+	if first {
+		if _, err := u.call(ctx, "resources/subscribe", map[string]string{"uri": original}); err != nil {
+			g.mu.Lock()
+			delete(g.subscribed[s], key)
+			g.releaseSubscription(key)
+			g.mu.Unlock()
+			return nil, err
+		}
+	}
+	return json.RawMessage("{}"), nil
+}
+
+func (g *Gateway) unsubscribe(ctx context.Context, s *httpserver.Session, params json.RawMessage) (json.RawMessage, error) {
+	key := stringField(params, "uri")
+	u, original, ok := g.route(key, URISeparator)
+	if !ok {
+		return nil, client.NewError(client.CodeInvalidParams, "unknown resource: %s", key)
+	}
+	g.mu.Lock()
+	last := false
+	if g.subscribed[s][key] {
+		delete(g.subscribed[s], key)
+		last = g.releaseSubscription(key)
+	}
+	g.mu.Unlock()
+	if last {
+		if _, err := u.call(ctx, "resources/unsubscribe", map[string]string{"uri": original}); err != nil {
+			u.logger.Debug("Failed to unsubscribe", zap.String("uri", original), zap.Error(err))
+		}
+	}
+	return json.RawMessage("{}"), nil
+}
+
+// releaseSubscription drops one session's subscription to key and reports whether it was
+// the last. Callers hold g.mu.
+func (g *Gateway) releaseSubscription(key string) bool {
+	g.subscriptions[key]--
+	if g.subscriptions[key] > 0 {
+		return false
+	}
+	delete(g.subscriptions, key)
+	return true
+}
+
+// subscribedURIs returns the resource URIs of upstream that sessions are subscribed to, as the upstream knows them.
+func (g *Gateway) subscribedURIs(u *upstream) []string {
+	prefix := u.config.Name + URISeparator
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var uris []string
+	for key := range g.subscriptions {
+		if strings.HasPrefix(key, prefix) {
+			uris = append(uris, strings.TrimPrefix(key, prefix))
+		}
+	}
+	return uris
+}
+
+// resourceUpdated tells the sessions subscribed to key that the resource changed.
+func (g *Gateway) resourceUpdated(key string, params json.RawMessage) {
+	g.mu.Lock()
+	var targets []*httpserver.Session
+	for s, keys := range g.subscribed {
+		if keys[key] {
+			targets = append(targets, s)
+		}
+	}
+	g.mu.Unlock()
+	for _, s := range targets {
+		g.server.Deliver(s, &client.Message{JSONRPC: "2.0", Method: "notifications/resources/updated", Params: params})
+	}
+}
+
+// broadcast sends a notification to every session.
+func (g *Gateway) broadcast(method string, params json.RawMessage) {
+	for _, s := range g.server.Sessions() {
+		g.server.Deliver(s, &client.Message{JSONRPC: "2.0", Method: method, Params: params})
+	}
+}
+
+// namespaceContents prefixes the URIs of the contents of a resources/read result.
+func namespaceContents(result json.RawMessage, name string) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(result, &fields); err != nil {
+		return result
+	}
+	var contents []map[string]json.RawMessage
+	if err := json.Unmarshal(fields["contents"], &contents); err != nil {
+		return result
+	}
+	for _, content := range contents {
+		var uri string
+		if err := json.Unmarshal(content["uri"], &uri); err == nil && uri != "" {
+			content["uri"], _ = json.Marshal(name + URISeparator + uri)
+		}
+	}
+	fields["contents"], _ = json.Marshal(contents)
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return result
+	}
+	return data
+}
+
+// field returns the raw value at path in a JSON object.
+func field(object json.RawMessage, path ...string) (json.RawMessage, bool) {
+	current := object
+	for _, key := range path {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(current, &fields); err != nil {
+			return nil, false
+		}
+		value, ok := fields[key]
+		if !ok {
+			return nil, false
+		}
+		current = value
+	}
+	return current, true
+}
+
+// stringField returns the string at path in a JSON object, or "".
+func stringField(object json.RawMessage, path ...string) string {
+	value, ok := field(object, path...)
+	if !ok {
+		return ""
+	}
+	var s string
+	_ = json.Unmarshal(value, &s)
+	return s
+}
+
+// setField returns object with the value at path replaced. Missing objects along the path are created.
+func setField(object json.RawMessage, value json.RawMessage, path ...string) json.RawMessage {
+	fields := map[string]json.RawMessage{}
+	if len(object) > 0 {
+		if err := json.Unmarshal(object, &fields); err != nil {
+			return object
+		}
+	}
+	if len(path) == 1 {
+		fields[path[0]] = value
+	} else {
+		fields[path[0]] = setField(fields[path[0]], value, path[1:]...)
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return object
+	}
+	return data
+}
+
+func mustMarshal(value interface{}) json.RawMessage {
+	data, _ := json.Marshal(value)
+	return data
+}
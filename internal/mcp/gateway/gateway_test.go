@@ -0,0 +1,219 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/mcp/client"
+	"github.com/AkashKesav/API2SDK/internal/mcp/transport/httpserver"
+	"go.uber.org/zap"
+)
+
+// fakeUpstream is a Streamable HTTP MCP server with the tools search and delete, whose calls
+// echo the name they were called with.
+func fakeUpstream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var msg client.Message
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if len(msg.ID) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	var result interface{}
+	switch msg.Method {
+	case "initialize":
+		result = map[string]interface{}{
+			"protocolVersion": "2025-03-26",
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": "fake", "version": "1.0.0"},
+		}
+	case "tools/list":
+		result = map[string]interface{}{"tools": []map[string]string{{"name": "search"}, {"name": "delete"}}}
+	case "tools/call":
+		result = map[string]interface{}{"content": []map[string]string{{"type": "text", "text": "called " + stringField(msg.Params, "name")}}}
+	default:
+		result = map[string]interface{}{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(&client.Message{JSONRPC: "2.0", ID: msg.ID, Result: mustMarshal(result)})
+}
+
+// newTestGateway connects a gateway to two upstreams named crm and docs. Deleting is denied to
+// everyone; alice may only use crm, bob may use everything else.
+func newTestGateway(t *testing.T) *Gateway {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(fakeUpstream))
+	t.Cleanup(server.Close)
+
+	g, err := New(Config{
+		Transport: TransportStreamableHTTP,
+		Port:      8080,
+		Upstreams: []Upstream{
+			{Name: "crm", Transport: TransportStreamableHTTP, URL: server.URL, HTTPClient: server.Client()},
+			{Name: "docs", Transport: TransportStreamableHTTP, URL: server.URL, HTTPClient: server.Client()},
+		},
+		Access: Access{Deny: []string{"*__delete"}},
+		Users: []User{
+			{ID: "alice", Token: "alice-token", Access: Access{Allow: []string{"crm__*"}}},
+			{ID: "bob", Token: "bob-token"},
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{}, len(g.upstreams))
+	for _, u := range g.upstreams {
+		go func(u *upstream) {
+			u.run(ctx)
+			done <- struct{}{}
+		}(u)
+	}
+	t.Cleanup(func() {
+		cancel()
+		for range g.upstreams {
+			<-done
+		}
+	})
+
+	deadline := time.Now().Add(10 * time.Second)
+	for _, u := range g.upstreams {
+		for u.health()["status"] != statusConnected {
+			if time.Now().After(deadline) {
+				t.Fatalf("upstream %s did not connect: %v", u.config.Name, u.health())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	return g
+}
+
+func TestGatewayNamespacesAndFiltersUpstreams(t *testing.T) {
+	g := newTestGateway(t)
+	app := g.server.App()
+	post := func(token, sessionID, body string) (*http.Response, *client.Message) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if sessionID != "" {
+			req.Header.Set(httpserver.SessionHeader, sessionID)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var reply client.Message
+		_ = json.NewDecoder(resp.Body).Decode(&reply)
+		return resp, &reply
+	}
+	open := func(token string) string {
+		t.Helper()
+		resp, reply := post(token, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`)
+		if resp.StatusCode != http.StatusOK || reply.Error != nil {
+			t.Fatalf("initialize = %d %+v", resp.StatusCode, reply)
+		}
+		return resp.Header.Get(httpserver.SessionHeader)
+	}
+	toolNames := func(token, sessionID string) string {
+		t.Helper()
+		_, reply := post(token, sessionID, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+		var result struct {
+			Tools []struct{ Name string } `json:"tools"`
+		}
+		_ = json.Unmarshal(reply.Result, &result)
+		var names []string
+		for _, tool := range result.Tools {
+			names = append(names, tool.Name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	if resp, _ := post("", "", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("initialize without a token = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	alice, bob := open("alice-token"), open("bob-token")
+	if got := toolNames("alice-token", alice); got != "crm__search" {
+		t.Errorf("tools of alice = %s, want crm__search", got)
+	}
+	if got := toolNames("bob-token", bob); got != "crm__search,docs__search" {
+		t.Errorf("tools of bob = %s, want both searches", got)
+	}
+	if resp, _ := post("bob-token", alice, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("bob using the session of alice = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	_, reply := post("bob-token", bob, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"docs__search","arguments":{}}}`)
+	if reply.Error != nil || !strings.Contains(string(reply.Result), `"called search"`) {
+		t.Fatalf("tools/call = %+v %s, want the upstream's own tool name", reply.Error, reply.Result)
+	}
+	for _, call := range []struct{ token, session, name string }{
+		{"alice-token", alice, "docs__search"},
+		{"bob-token", bob, "crm__delete"},
+		{"bob-token", bob, "mail__search"},
+	} {
+		_, reply := post(call.token, call.session, `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"`+call.name+`"}}`)
+		if reply.Error == nil {
+			t.Errorf("call of %s succeeded, want an unknown tool", call.name)
+		}
+	}
+}
+
+func TestAccessPatterns(t *testing.T) {
+	compiled, err := compileAccess(Access{Allow: []string{"crm__*", "docs+file:///*"}, Deny: []string{"*delete*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]bool{
+		"crm__search":           true,
+		"crm__delete_contact":   false,
+		"docs__search":          false,
+		"docs+file:///a.md":     true,
+		"docs+file:///a.delete": false,
+		"crm.x__search":         false,
+	}
+	for key, want := range tests {
+		if got := compiled.permits(key); got != want {
+			t.Errorf("permits(%q) = %v, want %v", key, got, want)
+		}
+	}
+	if _, err := compileAccess(Access{Deny: []string{""}}); err == nil {
+		t.Error("compileAccess() accepted an empty pattern")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	upstream := Upstream{Name: "crm", Transport: TransportStreamableHTTP, URL: "https://crm.example.com/mcp"}
+	invalid := map[string]Config{
+		"no upstreams":      {Transport: TransportSSE, Port: 8080},
+		"invalid name":      {Transport: TransportSSE, Port: 8080, Upstreams: []Upstream{{Name: "CRM", Transport: TransportSSE, URL: "https://x"}}},
+		"duplicate names":   {Transport: TransportSSE, Port: 8080, Upstreams: []Upstream{upstream, upstream}},
+		"non-HTTP URL":      {Transport: TransportSSE, Port: 8080, Upstreams: []Upstream{{Name: "crm", Transport: TransportSSE, URL: "file:///etc"}}},
+		"stdio without cmd": {Transport: TransportSSE, Port: 8080, Upstreams: []Upstream{{Name: "crm", Transport: TransportStdio}}},
+		"shared tokens":     {Transport: TransportSSE, Port: 8080, Upstreams: []Upstream{upstream}, Users: []User{{ID: "a", Token: "t"}, {ID: "b", Token: "t"}}},
+	}
+	for name, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Validate() of config with %s succeeded", name)
+		}
+	}
+	valid := Config{Transport: TransportSSE, Port: 8080, Upstreams: []Upstream{upstream}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
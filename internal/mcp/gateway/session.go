@@ -0,0 +1,107 @@
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"github.com/AkashKesav/API2SDK/internal/mcp/client"
+	"github.com/AkashKesav/API2SDK/internal/mcp/transport/httpserver"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// authenticate returns the user whose bearer token the request carries. It reports false
+// when the gateway has users and the request names none of them.
+func (g *Gateway) authenticate(c fiber.Ctx) (interface{}, bool) {
+	if len(g.users) == 0 {
+		return (*gatewayUser)(nil), true
+	}
+	token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !ok || token == "" {
+		return nil, false
+	}
+	var found *gatewayUser
+	for _, user := range g.users {
+		// Every token is compared, so timing does not reveal which user matched.
+		if subtle.ConstantTimeCompare([]byte(token), []byte(user.Token)) == 1 {
+			found = user
+		}
+	}
+	return found, found != nil
+}
+
+// sessionUser returns the authenticated user of a session, or nil when the gateway has no users.
+func sessionUser(s *httpserver.Session) *gatewayUser {
+	user, _ := s.User.(*gatewayUser)
+	return user
+}
+
+// Handle handles a message from a session and returns the response to send, if any.
+func (g *Gateway) Handle(ctx context.Context, s *httpserver.Session, msg *client.Message) *client.Message {
+	if msg.Method == "" {
+		// The gateway sends no requests, so there is nothing to match responses to.
+		return nil
+	}
+	if len(msg.ID) == 0 {
+		g.notification(s, msg)
+		return nil
+	}
+
+	result, err := g.handle(ctx, s, msg.Method, msg.Params)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = client.NewError(client.CodeInternalError, "request timed out")
+		}
+		return client.ErrorResponse(msg.ID, err)
+	}
+	return &client.Message{JSONRPC: "2.0", ID: msg.ID, Result: result}
+}
+
+// notification handles a notification from a session.
+func (g *Gateway) notification(s *httpserver.Session, msg *client.Message) {
+	switch msg.Method {
+	case "notifications/cancelled":
+		requestID, ok := field(msg.Params, "requestId")
+		if !ok {
+			return
+		}
+		// Cancelling the context cancels the request upstream as well.
+		g.server.Cancel(s, requestID)
+	default:
+		// initialized and roots/list_changed need no action: the gateway asks nothing of clients.
+	}
+}
+
+// SessionClosed releases the subscriptions of a session that ended.
+func (g *Gateway) SessionClosed(s *httpserver.Session) {
+	g.mu.Lock()
+	var released []string
+	for key := range g.subscribed[s] {
+		if g.releaseSubscription(key) {
+			released = append(released, key)
+		}
+	}
+	delete(g.subscribed, s)
+	g.mu.Unlock()
+
+	if len(released) > 0 {
+		go g.unsubscribeUpstream(released)
+	}
+}
+
+// unsubscribeUpstream ends the upstream subscriptions no session needs any more.
+func (g *Gateway) unsubscribeUpstream(keys []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	for _, key := range keys {
+		u, original, ok := g.route(key, URISeparator)
+		if !ok {
+			continue
+		}
+		if _, err := u.call(ctx, "resources/unsubscribe", map[string]string{"uri": original}); err != nil {
+			u.logger.Debug("Failed to unsubscribe", zap.String("uri", original), zap.Error(err))
+		}
+	}
+}
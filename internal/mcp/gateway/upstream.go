@@ -0,0 +1,384 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/backoff"
+	"github.com/AkashKesav/API2SDK/internal/hosting"
+	"github.com/AkashKesav/API2SDK/internal/mcp/client"
+	"go.uber.org/zap"
+)
+
+const (
+	// connectTimeout bounds connecting to and initializing an upstream, and refreshing its catalog.
+	connectTimeout = 30 * time.Second
+	// pingInterval is how often connected upstreams are checked for responsiveness.
+	pingInterval = 30 * time.Second
+	pingTimeout  = 10 * time.Second
+	// stableUptime is how long an upstream must stay connected for its backoff to reset.
+	stableUptime = time.Minute
+	// maxListPages bounds the pages read from a paginated list.
+	maxListPages = 100
+)
+
+// Upstream statuses reported by the health endpoint.
+const (
+	statusConnecting   = "connecting"
+	statusConnected    = "connected"
+	statusDisconnected = "disconnected"
+)
+
+// entry is a tool, prompt, resource or resource template of an upstream, as the gateway presents it.
+type entry struct {
+	// key is the namespaced name or URI.
+	key        string
+	definition json.RawMessage
+}
+
+// catalog is everything an upstream offers.
+type catalog struct {
+	tools     []entry
+	prompts   []entry
+	resources []entry
+	templates []entry
+}
+
+// listKind describes one list of an upstream's catalog.
+type listKind struct {
+	capability string
+	method     string
+	// result is the field of the list result holding the entries.
+	result string
+	// keyField is the field of an entry that is namespaced.
+	keyField  string
+	separator string
+	// notification announces changes of the list.
+	notification string
+	slot         func(c *catalog) *[]entry
+}
+
+var (
+	toolList = listKind{"tools", "tools/list", "tools", "name", NameSeparator,
+		"notifications/tools/list_changed", func(c *catalog) *[]entry { return &c.tools }}
+	promptList = listKind{"prompts", "prompts/list", "prompts", "name", NameSeparator,
+		"notifications/prompts/list_changed", func(c *catalog) *[]entry { return &c.prompts }}
+	resourceList = listKind{"resources", "resources/list", "resources", "uri", URISeparator,
+		"notifications/resources/list_changed", func(c *catalog) *[]entry { return &c.resources }}
+	templateList = listKind{"resources", "resources/templates/list", "resourceTemplates", "uriTemplate", URISeparator,
+		"notifications/resources/list_changed", func(c *catalog) *[]entry { return &c.templates }}
+	allLists = []listKind{toolList, promptList, resourceList, templateList}
+)
+
+// upstream is the gateway's connection to one upstream server.
+type upstream struct {
+	gateway *Gateway
+	config  Upstream
+	logger  *zap.Logger
+
+	mu        sync.RWMutex
+	client    *client.Client
+	catalog   catalog
+	status    string
+	lastError string
+}
+
+func newUpstream(g *Gateway, config Upstream) *upstream {
+	return &upstream{
+		gateway: g,
+		config:  config,
+		logger:  g.logger.With(zap.String("upstream", config.Name)),
+		status:  statusConnecting,
+	}
+}
+
+// run keeps the upstream connected until ctx is cancelled, reconnecting with backoff.
+func (u *upstream) run(ctx context.Context) {
+	failures := 0
+	for {
+		started := time.Now()
+		err := u.connect(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = errors.New("connection closed")
+		}
+		if time.Since(started) >= stableUptime {
+			failures = 0
+		}
+		failures++
+		delay := backoff.Delay(failures)
+		u.mu.Lock()
+		u.status = statusDisconnected
+		u.lastError = err.Error()
+		u.mu.Unlock()
+		u.logger.Warn("Upstream MCP server disconnected", zap.Duration("retryIn", delay), zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		u.mu.Lock()
+		u.status = statusConnecting
+		u.mu.Unlock()
+	}
+}
+
+// connect connects to the upstream, loads its catalog and then watches the connection
+// until it fails or ctx is cancelled. The catalog is dropped when it returns.
+func (u *upstream) connect(ctx context.Context) error {
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	initCtx, cancelInit := context.WithTimeout(connCtx, connectTimeout)
+	c, err := client.Connect(initCtx, u.transport(connCtx), client.Options{
+		ClientInfo:     client.Implementation{Name: "api2sdk-mcp-gateway", Version: "1.0.0"},
+		OnNotification: u.notification,
+		Logger:         u.logger,
+	})
+	cancelInit()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	u.mu.Lock()
+	u.client = c
+	u.mu.Unlock()
+	defer u.disconnected()
+
+	refreshCtx, cancelRefresh := context.WithTimeout(connCtx, connectTimeout)
+	err = u.refresh(refreshCtx, c, allLists...)
+	cancelRefresh()
+	if err != nil {
+		return err
+	}
+	for _, uri := range u.gateway.subscribedURIs(u) {
+		if err := c.Call(connCtx, "resources/subscribe", map[string]string{"uri": uri}, nil); err != nil {
+			u.logger.Warn("Failed to restore resource subscription", zap.String("uri", uri), zap.Error(err))
+		}
+	}
+
+	u.mu.Lock()
+	u.status = statusConnected
+	u.lastError = ""
+	u.mu.Unlock()
+	info := c.InitializeResult().ServerInfo
+	u.logger.Info("Connected to upstream MCP server", zap.String("server", info.Name), zap.String("version", info.Version))
+	u.gateway.announce(allLists...)
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-connCtx.Done():
+			return nil
+		case <-c.Done():
+			return c.Err()
+		case <-ticker.C:
+			pingCtx, cancelPing := context.WithTimeout(connCtx, pingTimeout)
+			err := c.Ping(pingCtx)
+			cancelPing()
+			if err != nil && connCtx.Err() == nil {
+				return fmt.Errorf("ping failed: %w", err)
+			}
+		}
+	}
+}
+
+// disconnected drops the client and catalog and tells sessions that the lists changed.
+func (u *upstream) disconnected() {
+	u.mu.Lock()
+	u.client = nil
+	u.catalog = catalog{}
+	u.mu.Unlock()
+	u.gateway.announce(allLists...)
+}
+
+// transport creates the transport of a new connection. Stdio processes end with ctx.
+func (u *upstream) transport(ctx context.Context) client.Transport {
+	switch u.config.Transport {
+	case TransportStdio:
+		cmd := hosting.Command(ctx, hosting.Spec{
+			Command: u.config.Command,
+			Env:     hosting.Environment(u.config.Env...),
+		}, u.gateway.config.Limits)
+		return client.NewStdioTransport(cmd, u.logger)
+	case TransportSSE:
+		return client.NewSSETransport(u.config.URL, u.config.Headers, u.config.HTTPClient)
+	default:
+		return client.NewStreamableHTTPTransport(u.config.URL, u.config.Headers, u.config.HTTPClient)
+	}
+}
+
+// refresh reloads the given lists of the catalog. Lists the upstream does not offer stay empty.
+func (u *upstream) refresh(ctx context.Context, c *client.Client, kinds ...listKind) error {
+	initialized := c.InitializeResult()
+	for _, kind := range kinds {
+		var entries []entry
+		if initialized.HasCapability(kind.capability) {
+			definitions, err := u.list(ctx, c, kind)
+			var rpcErr *client.RPCError
+			switch {
+			case errors.As(err, &rpcErr):
+				// A server may declare a capability without implementing every list of it.
+				u.logger.Debug("Upstream failed to list", zap.String("method", kind.method), zap.Error(err))
+			case err != nil:
+				return fmt.Errorf("failed to load %s: %w", kind.method, err)
+			}
+			for _, definition := range definitions {
+				if e, ok := u.namespace(definition, kind); ok {
+					entries = append(entries, e)
+				}
+			}
+		}
+		u.mu.Lock()
+		*kind.slot(&u.catalog) = entries
+		u.mu.Unlock()
+	}
+	return nil
+}
+
+// list reads all pages of a list.
+func (u *upstream) list(ctx context.Context, c *client.Client, kind listKind) ([]json.RawMessage, error) {
+	var all []json.RawMessage
+	cursor := ""
+	for page := 0; page < maxListPages; page++ {
+		var params map[string]string
+		if cursor != "" {
+			params = map[string]string{"cursor": cursor}
+		}
+		result, err := c.CallRaw(ctx, kind.method, params)
+		if err != nil {
+			return nil, err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(result, &fields); err != nil {
+			return nil, fmt.Errorf("invalid %s result: %w", kind.method, err)
+		}
+		var definitions []json.RawMessage
+		if raw, ok := fields[kind.result]; ok {
+			if err := json.Unmarshal(raw, &definitions); err != nil {
+				return nil, fmt.Errorf("invalid %s result: %w", kind.method, err)
+			}
+		}
+		all = append(all, definitions...)
+		cursor = stringField(result, "nextCursor")
+		if cursor == "" {
+			return all, nil
+		}
+	}
+	u.logger.Warn("Upstream list has too many pages; the rest is ignored", zap.String("method", kind.method))
+	return all, nil
+}
+
+// namespace prefixes the key field of a definition with the upstream's name.
+func (u *upstream) namespace(definition json.RawMessage, kind listKind) (entry, bool) {
+	original := stringField(definition, kind.keyField)
+	if original == "" {
+		return entry{}, false
+	}
+	key := u.config.Name + kind.separator + original
+	value, _ := json.Marshal(key)
+	return entry{key: key, definition: setField(definition, value, kind.keyField)}, true
+}
+
+// notification handles a notification from the upstream. Notifications are handled one at
+// a time on the client's dispatch goroutine, so catalog refreshes happen in order.
+func (u *upstream) notification(method string, params json.RawMessage) {
+	switch method {
+	case toolList.notification, promptList.notification, resourceList.notification:
+		kinds := []listKind{toolList}
+		switch method {
+		case promptList.notification:
+			kinds = []listKind{promptList}
+		case resourceList.notification:
+			kinds = []listKind{resourceList, templateList}
+		}
+		u.mu.RLock()
+		c := u.client
+		u.mu.RUnlock()
+		if c == nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+		defer cancel()
+		if err := u.refresh(ctx, c, kinds...); err != nil {
+			u.logger.Warn("Failed to refresh upstream catalog", zap.String("method", method), zap.Error(err))
+		}
+		u.gateway.announce(kinds...)
+	case "notifications/resources/updated":
+		key := u.config.Name + URISeparator + stringField(params, "uri")
+		value, _ := json.Marshal(key)
+		u.gateway.resourceUpdated(key, setField(params, value, "uri"))
+	case "notifications/progress":
+		u.gateway.routeProgress(params)
+	case "notifications/message":
+		// Log messages name the upstream they come from.
+		logger := u.config.Name
+		if name := stringField(params, "logger"); name != "" {
+			logger += "/" + name
+		}
+		value, _ := json.Marshal(logger)
+		u.gateway.broadcast(method, setField(params, value, "logger"))
+	default:
+		u.logger.Debug("Ignoring upstream notification", zap.String("method", method))
+	}
+}
+
+// call sends a request to the upstream.
+func (u *upstream) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	u.mu.RLock()
+	c := u.client
+	u.mu.RUnlock()
+	if c == nil {
+		return nil, client.NewError(client.CodeInternalError, "upstream %s is not connected", u.config.Name)
+	}
+	return c.CallRaw(ctx, method, params)
+}
+
+func (u *upstream) hasCapability(capability string) bool {
+	u.mu.RLock()
+	c := u.client
+	u.mu.RUnlock()
+	if c == nil {
+		return false
+	}
+	initialized := c.InitializeResult()
+	return initialized.HasCapability(capability)
+}
+
+// health describes the upstream for the health endpoint.
+func (u *upstream) health() map[string]interface{} {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	health := map[string]interface{}{
+		"name":      u.config.Name,
+		"transport": u.config.Transport,
+		"status":    u.status,
+		"tools":     len(u.catalog.tools),
+		"prompts":   len(u.catalog.prompts),
+		"resources": len(u.catalog.resources),
+	}
+	if u.lastError != "" {
+		health["last_error"] = u.lastError
+	}
+	return health
+}
+
+// announce tells every session that the given lists changed.
+func (g *Gateway) announce(kinds ...listKind) {
+	sent := make(map[string]bool, len(kinds))
+	for _, kind := range kinds {
+		if !sent[kind.notification] {
+			sent[kind.notification] = true
+			g.broadcast(kind.notification, nil)
+		}
+	}
+}
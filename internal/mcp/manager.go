@@ -2,293 +2,807 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/AkashKesav/API2SDK/internal/backoff"
+	"github.com/AkashKesav/API2SDK/internal/hosting"
+	"github.com/AkashKesav/API2SDK/internal/mcp/bridge"
+	"github.com/AkashKesav/API2SDK/internal/mcp/gateway"
 	"github.com/AkashKesav/API2SDK/internal/mcp/servers"
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
 	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/AkashKesav/API2SDK/internal/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 )
 
 // MCPServerType represents the type of MCP server
-type MCPServerType string
+type MCPServerType = models.MCPServerType
 
 const (
-	ServerTypeUnified MCPServerType = "unified"
-	ServerTypeApps    MCPServerType = "apps"
+	ServerTypeUnified = models.MCPServerTypeUnified
+	ServerTypeApps    = models.MCPServerTypeApps
+	ServerTypeBridge  = models.MCPServerTypeBridge
+	ServerTypeGateway = models.MCPServerTypeGateway
 )
 
 // MCPServerConfig holds configuration for starting an MCP server
-type MCPServerConfig struct {
-	Type                 MCPServerType `json:"type"`
-	TransportType        string        `json:"transport_type"` // "stdio" or "sse"
-	Port                 int           `json:"port,omitempty"`
-	LinkedAccountOwnerID string        `json:"linked_account_owner_id"`
-	AllowedApps          []string      `json:"allowed_apps,omitempty"` // For apps server
-	AllowedAppsOnly      bool          `json:"allowed_apps_only"`      // For unified server
+type MCPServerConfig = models.MCPServerConfig
+
+const (
+	// reconcileInterval is how often desired state, crashes and health are checked.
+	reconcileInterval = 5 * time.Second
+	// stableUptime is how long a server must stay up for its restart backoff to reset.
+	stableUptime = time.Minute
+	// healthCheckTimeout bounds a single health probe of an SSE server.
+	healthCheckTimeout = 2 * time.Second
+	// storeTimeout bounds a single write of server state.
+	storeTimeout = 5 * time.Second
+)
+
+// ErrServerNotFound is returned for unknown server IDs.
+var ErrServerNotFound = errors.New("server not found")
+
+// ErrCommandNotAllowed is returned for bridge servers whose command is not in the allowlist.
+var ErrCommandNotAllowed = errors.New("command is not allowed for bridge servers")
+
+// BridgeOptions configures the stdio servers run by bridge servers and by gateway upstreams.
+type BridgeOptions struct {
+	// AllowedCommands are the executables bridge servers may run, by name or absolute path.
+	// When empty, bridge servers and stdio upstreams are disabled.
+	AllowedCommands []string
+	Limits          hosting.Limits
+}
+
+// GatewayOptions configures how gateway servers reach remote upstreams.
+type GatewayOptions struct {
+	// HTTPClient connects to upstream URLs. It should refuse internal addresses and must
+	// not time out whole requests, as event streams stay open. Upstreams that are managed
+	// servers are reached on localhost without it.
+	HTTPClient *http.Client
 }
 
-// RunningServer represents a running MCP server instance
+// RunningServer represents a managed MCP server instance
 type RunningServer struct {
-	ID          string             `json:"id"`
-	Type        MCPServerType      `json:"type"`
-	Transport   string             `json:"transport"`
-	Port        int                `json:"port,omitempty"`
-	Status      string             `json:"status"`
-	AllowedApps []string           `json:"allowed_apps,omitempty"`
-	Cancel      context.CancelFunc `json:"-"`
-	StartedAt   int64              `json:"started_at"`
+	ID            string        `json:"id"`
+	OwnerID       string        `json:"owner_id,omitempty"`
+	Type          MCPServerType `json:"type"`
+	Transport     string        `json:"transport"`
+	Port          int           `json:"port,omitempty"`
+	Status        string        `json:"status"`
+	DesiredState  string        `json:"desired_state"`
+	Health        string        `json:"health"`
+	Restarts      int           `json:"restarts"`
+	LastError     string        `json:"last_error,omitempty"`
+	AllowedApps   []string      `json:"allowed_apps,omitempty"`
+	StartedAt     int64         `json:"started_at"`
+	NextRestartAt int64         `json:"next_restart_at,omitempty"`
+	CreatedAt     int64         `json:"created_at"`
+}
+
+// managedServer is a persisted server and, while it runs, its serve goroutine.
+type managedServer struct {
+	record *models.MCPServer
+	cancel context.CancelFunc
+	// done is closed when the serve goroutine exits; nil when the server is not active.
+	done chan struct{}
 }
 
-// MCPManager manages multiple MCP server instances
+func (s *managedServer) active() bool {
+	if s.done == nil {
+		return false
+	}
+	select {
+	case <-s.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// MCPManager manages multiple MCP server instances. Servers are persisted with their
+// desired state, and a reconciler restarts desired servers on boot and after crashes.
 type MCPManager struct {
 	logger             *zap.Logger
 	integrationService services.IntegrationService
 	toolProvider       services.ToolProvider
-	runningServers     map[string]*RunningServer
-	mu                 sync.RWMutex
+	toolSearch         services.ToolSearchService
+	promptService      services.PromptService
+	resourceService    services.ResourceService
+	owners             services.OwnerAuthorizer
+	repo               repositories.MCPServerRepository
+	bridgeOptions      BridgeOptions
+	gatewayOptions     GatewayOptions
+	healthClient       *http.Client
+	// localClient reaches managed servers that are gateway upstreams.
+	localClient *http.Client
+	servers     map[string]*managedServer
+	mu          sync.RWMutex
 }
 
-// NewMCPManager creates a new MCP manager
+// NewMCPManager creates a new MCP manager. Call Run to restore persisted servers.
 func NewMCPManager(
 	logger *zap.Logger,
 	integrationService services.IntegrationService,
 	toolProvider services.ToolProvider,
+	toolSearch services.ToolSearchService,
+	promptService services.PromptService,
+	resourceService services.ResourceService,
+	owners services.OwnerAuthorizer,
+	repo repositories.MCPServerRepository,
+	bridgeOptions BridgeOptions,
+	gatewayOptions GatewayOptions,
 ) *MCPManager {
 	return &MCPManager{
 		logger:             logger,
 		integrationService: integrationService,
 		toolProvider:       toolProvider,
-		runningServers:     make(map[string]*RunningServer),
+		toolSearch:         toolSearch,
+		promptService:      promptService,
+		resourceService:    resourceService,
+		owners:             owners,
+		repo:               repo,
+		bridgeOptions:      bridgeOptions,
+		gatewayOptions:     gatewayOptions,
+		// Health checks only reach the manager's own servers on localhost.
+		healthClient: &http.Client{Timeout: healthCheckTimeout},
+		localClient:  &http.Client{},
+		servers:      make(map[string]*managedServer),
 	}
 }
 
-// StartServer starts a new MCP server with the given configuration
-func (m *MCPManager) StartServer(config *MCPServerConfig) (*RunningServer, error) {
+// Run loads the persisted servers, starts those that should be running and then
+// reconciles until ctx is cancelled. Servers are left running when it returns;
+// use Shutdown to stop them without changing their desired state.
+func (m *MCPManager) Run(ctx context.Context) error {
+	records, err := m.repo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load MCP servers: %w", err)
+	}
+
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	for _, record := range records {
+		if _, exists := m.servers[record.ID]; exists {
+			continue
+		}
+		server := &managedServer{record: record}
+		m.servers[record.ID] = server
+		if record.DesiredState == models.MCPServerDesiredRunning {
+			m.logger.Info("Restoring MCP server", zap.String("serverID", record.ID))
+			record.NextRestartAt = time.Time{}
+			m.launch(server)
+		} else if record.Status != models.MCPServerStatusStopped && record.Status != models.MCPServerStatusFailed {
+			record.Status = models.MCPServerStatusStopped
+			m.save(record)
+		}
+	}
+	m.mu.Unlock()
+
+	ticker := time.NewTicker(reconcileInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			m.reconcile(ctx)
+		}
+	}
+}
 
-	// Generate unique server ID
-	serverID := fmt.Sprintf("%s_%s_%d", config.Type, config.TransportType, len(m.runningServers)+1)
+// StartServer persists and starts a new MCP server owned by ownerID
+func (m *MCPManager) StartServer(ownerID string, config *MCPServerConfig) (*RunningServer, error) {
+	if _, err := servers.ParseDestructiveToolPolicy(config.DestructiveTools); err != nil {
+		return nil, err
+	}
+	switch config.Type {
+	case ServerTypeUnified, ServerTypeApps:
+	case ServerTypeBridge:
+		if err := m.validateBridge(config); err != nil {
+			return nil, err
+		}
+	case ServerTypeGateway:
+		if err := m.validateGateway(ownerID, config); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported server type: %s", config.Type)
+	}
+	if config.LinkedAccountOwnerID == "" {
+		config.LinkedAccountOwnerID = ownerID
+	}
+	if err := m.authorizeLinkedAccountOwner(context.Background(), ownerID, config); err != nil {
+		return nil, err
+	}
 
-	// Check if port is already in use (for SSE servers)
-	if config.TransportType == "sse" {
-		for _, server := range m.runningServers {
-			if server.Port == config.Port && server.Status == "running" {
-				return nil, fmt.Errorf("port %d already in use by server %s", config.Port, server.ID)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Check if port is already claimed (for servers listening on HTTP)
+	if listens(config) {
+		for _, server := range m.servers {
+			if listens(&server.record.Config) && server.record.Config.Port == config.Port &&
+				(server.record.DesiredState == models.MCPServerDesiredRunning || server.active()) {
+				return nil, fmt.Errorf("port %d already in use by server %s", config.Port, server.record.ID)
 			}
 		}
 	}
 
+	record := &models.MCPServer{
+		ID:           fmt.Sprintf("%s_%s_%s", config.Type, config.TransportType, primitive.NewObjectID().Hex()),
+		OwnerID:      ownerID,
+		Config:       *config,
+		DesiredState: models.MCPServerDesiredRunning,
+		Status:       models.MCPServerStatusStarting,
+		Health:       models.MCPServerHealthUnknown,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := m.repo.Create(ctx, record); err != nil {
+		return nil, fmt.Errorf("failed to save MCP server: %w", err)
+	}
+
 	m.logger.Info("Starting MCP server",
-		zap.String("serverID", serverID),
+		zap.String("serverID", record.ID),
+		zap.String("ownerID", ownerID),
 		zap.String("type", string(config.Type)),
 		zap.String("transport", config.TransportType),
 		zap.Int("port", config.Port))
 
-	// Create context for this server
+	server := &managedServer{record: record}
+	m.servers[record.ID] = server
+	m.launch(server)
+	return view(record), nil
+}
+
+// launch starts the serve goroutine of server. Callers hold m.mu.
+func (m *MCPManager) launch(server *managedServer) {
+	record := server.record
+	config := record.Config
 	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	server.cancel = cancel
+	server.done = done
+
+	record.Status = models.MCPServerStatusStarting
+	record.Health = models.MCPServerHealthUnknown
+	record.StartedAt = time.Now()
+	record.StoppedAt = time.Time{}
+	if !listens(&config) {
+		// Stdio servers have no health endpoint; they are up once started.
+		record.Status = models.MCPServerStatusRunning
+	}
+	m.save(record)
 
-	// Create running server entry
-	runningServer := &RunningServer{
-		ID:          serverID,
-		Type:        config.Type,
-		Transport:   config.TransportType,
-		Port:        config.Port,
-		Status:      "starting",
-		AllowedApps: config.AllowedApps,
-		Cancel:      cancel,
-		StartedAt:   int64(1234567890), // This should be time.Now().Unix() in production
+	go func() {
+		defer close(done)
+		err := m.serve(ctx, record.OwnerID, &config)
+		m.exited(server, ctx.Err() != nil, err)
+	}()
+}
+
+// serve runs an MCP server of ownerID until ctx is cancelled or the server fails
+func (m *MCPManager) serve(ctx context.Context, ownerID string, config *MCPServerConfig) error {
+	policy, err := servers.ParseDestructiveToolPolicy(config.DestructiveTools)
+	if err != nil {
+		return err
+	}
+	switch config.Type {
+	case ServerTypeUnified:
+		return m.startUnifiedServer(ctx, ownerID, config, policy)
+	case ServerTypeApps:
+		return m.startAppsServer(ctx, ownerID, config, policy)
+	case ServerTypeBridge:
+		return m.startBridgeServer(ctx, config)
+	case ServerTypeGateway:
+		return m.startGatewayServer(ctx, config)
+	default:
+		return fmt.Errorf("unsupported server type: %s", config.Type)
 	}
+}
 
-	m.runningServers[serverID] = runningServer
+// exited records the end of a serve goroutine. A server that exits without being
+// stopped has crashed and is scheduled for a restart with exponential backoff.
+func (m *MCPManager) exited(server *managedServer, stopped bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	// Start the appropriate server type
-	go func() {
-		defer func() {
-			m.mu.Lock()
-			if server, exists := m.runningServers[serverID]; exists {
-				server.Status = "stopped"
-			}
-			m.mu.Unlock()
-		}()
-
-		var err error
-		switch config.Type {
-		case ServerTypeUnified:
-			err = m.startUnifiedServer(ctx, config)
-		case ServerTypeApps:
-			err = m.startAppsServer(ctx, config)
-		default:
-			err = fmt.Errorf("unsupported server type: %s", config.Type)
-		}
+	record := server.record
+	record.StoppedAt = time.Now()
+	record.Health = models.MCPServerHealthUnknown
+	if stopped || record.DesiredState != models.MCPServerDesiredRunning {
+		record.Status = models.MCPServerStatusStopped
+		m.logger.Info("MCP server stopped", zap.String("serverID", record.ID))
+		m.save(record)
+		return
+	}
 
-		if err != nil {
-			m.logger.Error("Failed to start MCP server",
-				zap.String("serverID", serverID),
-				zap.Error(err))
-			m.mu.Lock()
-			if server, exists := m.runningServers[serverID]; exists {
-				server.Status = "failed"
+	if err == nil {
+		err = errors.New("server exited unexpectedly")
+	}
+	if record.StoppedAt.Sub(record.StartedAt) >= stableUptime {
+		record.Restarts = 0
+	}
+	record.Restarts++
+	record.Status = models.MCPServerStatusFailed
+	record.LastError = err.Error()
+	record.NextRestartAt = record.StoppedAt.Add(backoff.Delay(record.Restarts))
+	m.logger.Error("MCP server crashed",
+		zap.String("serverID", record.ID),
+		zap.Int("restarts", record.Restarts),
+		zap.Time("nextRestartAt", record.NextRestartAt),
+		zap.Error(err))
+	m.save(record)
+}
+
+// reconcile restarts crashed servers whose backoff has elapsed and checks the health of HTTP servers.
+func (m *MCPManager) reconcile(ctx context.Context) {
+	m.mu.Lock()
+	now := time.Now()
+	var probes []*managedServer
+	for _, server := range m.servers {
+		record := server.record
+		switch {
+		case record.DesiredState == models.MCPServerDesiredRunning && !server.active():
+			if now.Before(record.NextRestartAt) {
+				continue
 			}
-			m.mu.Unlock()
-			return
+			m.logger.Info("Restarting MCP server",
+				zap.String("serverID", record.ID),
+				zap.Int("restarts", record.Restarts))
+			m.launch(server)
+		case server.active() && listens(&record.Config):
+			probes = append(probes, server)
 		}
+	}
+	m.mu.Unlock()
+
+	for _, server := range probes {
+		m.mu.RLock()
+		port := server.record.Config.Port
+		m.mu.RUnlock()
+
+		health := m.probe(ctx, port)
 
-		// Mark as running
 		m.mu.Lock()
-		if server, exists := m.runningServers[serverID]; exists {
-			server.Status = "running"
+		record := server.record
+		changed := record.Health != health
+		record.Health = health
+		record.LastHealthAt = time.Now()
+		if health == models.MCPServerHealthHealthy && record.Status == models.MCPServerStatusStarting {
+			record.Status = models.MCPServerStatusRunning
+			changed = true
+		}
+		if changed && server.active() {
+			m.save(record)
 		}
 		m.mu.Unlock()
+	}
+}
 
-		m.logger.Info("MCP server started successfully", zap.String("serverID", serverID))
+// probe calls the health endpoint of the HTTP server on port.
+func (m *MCPManager) probe(ctx context.Context, port int) string {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d/health", port), nil)
+	if err != nil {
+		return models.MCPServerHealthUnhealthy
+	}
+	resp, err := m.healthClient.Do(req)
+	if err != nil {
+		return models.MCPServerHealthUnhealthy
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return models.MCPServerHealthUnhealthy
+	}
+	return models.MCPServerHealthHealthy
+}
 
-		// Wait for context cancellation
-		<-ctx.Done()
-		m.logger.Info("MCP server stopped", zap.String("serverID", serverID))
-	}()
+// save persists record. Failures are logged: the in-memory state stays authoritative
+// and the next change is saved again. Callers hold m.mu.
+func (m *MCPManager) save(record *models.MCPServer) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := m.repo.Save(ctx, record); err != nil {
+		m.logger.Error("Failed to save MCP server state", zap.String("serverID", record.ID), zap.Error(err))
+	}
+}
 
-	return runningServer, nil
+// authorizeLinkedAccountOwner checks that ownerID manages the linked account owner whose
+// credentials a unified or apps server executes tools with.
+func (m *MCPManager) authorizeLinkedAccountOwner(ctx context.Context, ownerID string, config *MCPServerConfig) error {
+	if config.LinkedAccountOwnerID == ownerID {
+		return nil
+	}
+	if m.owners == nil {
+		return services.ErrOwnerNotManaged
+	}
+	return m.owners.AuthorizeOwner(ctx, ownerID, config.LinkedAccountOwnerID)
 }
 
 // startUnifiedServer starts a unified MCP server
-func (m *MCPManager) startUnifiedServer(ctx context.Context, config *MCPServerConfig) error {
+func (m *MCPManager) startUnifiedServer(ctx context.Context, ownerID string, config *MCPServerConfig, policy servers.DestructiveToolPolicy) error {
+	// Restored servers are checked again, as the owner may no longer be managed.
+	if err := m.authorizeLinkedAccountOwner(ctx, ownerID, config); err != nil {
+		return err
+	}
 	server := servers.NewUnifiedMCPServer(
 		m.logger,
 		m.integrationService,
 		m.toolProvider,
+		m.toolSearch,
+		m.promptService,
+		m.resourceService,
+		m.owners,
+		ownerID,
 		config.LinkedAccountOwnerID,
 		config.AllowedAppsOnly,
 	)
+	server.SetDestructiveToolPolicy(policy)
 
 	return server.StartWithTransport(ctx, config.TransportType, config.Port)
 }
 
 // startAppsServer starts an apps-specific MCP server
-func (m *MCPManager) startAppsServer(ctx context.Context, config *MCPServerConfig) error {
+func (m *MCPManager) startAppsServer(ctx context.Context, ownerID string, config *MCPServerConfig, policy servers.DestructiveToolPolicy) error {
 	if len(config.AllowedApps) == 0 {
 		return fmt.Errorf("allowed_apps must be specified for apps server type")
 	}
+	if err := m.authorizeLinkedAccountOwner(ctx, ownerID, config); err != nil {
+		return err
+	}
 
 	server := servers.NewAppsMCPServer(
 		m.logger,
 		m.integrationService,
 		m.toolProvider,
+		m.promptService,
+		m.resourceService,
+		m.owners,
+		ownerID,
 		config.LinkedAccountOwnerID,
 		config.AllowedApps,
 	)
+	server.SetDestructiveToolPolicy(policy)
 
 	return server.StartWithTransport(ctx, config.TransportType, config.Port)
 }
 
-// StopServer stops a running MCP server
+// startBridgeServer runs the configured stdio server behind an HTTP bridge
+func (m *MCPManager) startBridgeServer(ctx context.Context, config *MCPServerConfig) error {
+	server, err := bridge.New(bridge.Config{
+		Command:   config.Command,
+		Env:       environment(config.Env),
+		Transport: config.TransportType,
+		Port:      config.Port,
+		Limits:    m.bridgeOptions.Limits,
+	}, m.logger)
+	if err != nil {
+		return err
+	}
+	return server.Serve(ctx)
+}
+
+// validateBridge checks the transport and command of a bridge server.
+func (m *MCPManager) validateBridge(config *MCPServerConfig) error {
+	if len(config.Command) == 0 {
+		return fmt.Errorf("command must be specified for bridge server type")
+	}
+	if config.TransportType != bridge.TransportSSE && config.TransportType != bridge.TransportStreamableHTTP {
+		return fmt.Errorf("unsupported bridge transport: %s. Supported: %s, %s", config.TransportType, bridge.TransportSSE, bridge.TransportStreamableHTTP)
+	}
+	return m.commandAllowed(config.Command)
+}
+
+// commandAllowed checks the executable of a stdio server command against the allowlist.
+func (m *MCPManager) commandAllowed(command []string) error {
+	executable := command[0]
+	// Entries match exactly: a bare name is looked up in PATH, so it never allows "./name" or another directory.
+	for _, allowed := range m.bridgeOptions.AllowedCommands {
+		if executable == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrCommandNotAllowed, executable)
+}
+
+// startGatewayServer aggregates the configured upstreams behind one endpoint
+func (m *MCPManager) startGatewayServer(ctx context.Context, config *MCPServerConfig) error {
+	gatewayConfig, err := m.gatewayConfig(config)
+	if err != nil {
+		return err
+	}
+	server, err := gateway.New(gatewayConfig, m.logger)
+	if err != nil {
+		return err
+	}
+	return server.Serve(ctx)
+}
+
+// gatewayConfig builds the gateway configuration of a gateway server.
+func (m *MCPManager) gatewayConfig(config *MCPServerConfig) (gateway.Config, error) {
+	gatewayConfig := gateway.Config{
+		Transport: config.TransportType,
+		Port:      config.Port,
+		Access:    gateway.Access{Allow: config.Access.Allow, Deny: config.Access.Deny},
+		Limits:    m.bridgeOptions.Limits,
+	}
+	for _, upstream := range config.Upstreams {
+		resolved, err := m.resolveUpstream(upstream)
+		if err != nil {
+			return gateway.Config{}, err
+		}
+		gatewayConfig.Upstreams = append(gatewayConfig.Upstreams, resolved)
+	}
+	for _, user := range config.Users {
+		gatewayConfig.Users = append(gatewayConfig.Users, gateway.User{
+			ID:     user.UserID,
+			Token:  string(user.Token),
+			Access: gateway.Access{Allow: user.Access.Allow, Deny: user.Access.Deny},
+		})
+	}
+	return gatewayConfig, nil
+}
+
+// resolveUpstream turns a configured upstream into the gateway's form. Upstreams that are
+// managed servers are reached on localhost at the server's port.
+func (m *MCPManager) resolveUpstream(upstream models.MCPGatewayUpstream) (gateway.Upstream, error) {
+	resolved := gateway.Upstream{
+		Name:       upstream.Name,
+		Transport:  upstream.Transport,
+		URL:        upstream.URL,
+		HTTPClient: m.gatewayOptions.HTTPClient,
+		Command:    upstream.Command,
+		Env:        environment(upstream.Env),
+	}
+	if len(upstream.Headers) > 0 {
+		resolved.Headers = make(http.Header, len(upstream.Headers))
+		for key, value := range upstream.Headers {
+			resolved.Headers.Set(key, string(value))
+		}
+	}
+	if upstream.ServerID == "" {
+		return resolved, nil
+	}
+
+	m.mu.RLock()
+	server, exists := m.servers[upstream.ServerID]
+	var target MCPServerConfig
+	if exists {
+		target = server.record.Config
+	}
+	m.mu.RUnlock()
+	if !exists {
+		return gateway.Upstream{}, fmt.Errorf("upstream %s: %w: %s", upstream.Name, ErrServerNotFound, upstream.ServerID)
+	}
+	resolved.Transport = target.TransportType
+	resolved.HTTPClient = m.localClient
+	resolved.Headers = nil
+	switch target.TransportType {
+	case gateway.TransportSSE:
+		resolved.URL = fmt.Sprintf("http://127.0.0.1:%d/sse", target.Port)
+	case gateway.TransportStreamableHTTP:
+		resolved.URL = fmt.Sprintf("http://127.0.0.1:%d/mcp", target.Port)
+	default:
+		return gateway.Upstream{}, fmt.Errorf("upstream %s: server %s does not listen on HTTP", upstream.Name, upstream.ServerID)
+	}
+	return resolved, nil
+}
+
+// validateGateway checks the transport, upstreams and users of a gateway server.
+func (m *MCPManager) validateGateway(ownerID string, config *MCPServerConfig) error {
+	if len(config.Upstreams) == 0 {
+		return fmt.Errorf("upstreams must be specified for gateway server type")
+	}
+	for _, upstream := range config.Upstreams {
+		if upstream.ServerID != "" {
+			m.mu.RLock()
+			server, exists := m.servers[upstream.ServerID]
+			var target models.MCPServer
+			if exists {
+				target = *server.record
+			}
+			m.mu.RUnlock()
+			if !exists || target.OwnerID != ownerID {
+				return fmt.Errorf("upstream %s: %w: %s", upstream.Name, ErrServerNotFound, upstream.ServerID)
+			}
+			if target.Config.Type == ServerTypeGateway {
+				return fmt.Errorf("upstream %s: gateway servers cannot be upstreams of other gateways", upstream.Name)
+			}
+			if !listens(&target.Config) {
+				return fmt.Errorf("upstream %s: server %s does not listen on HTTP", upstream.Name, upstream.ServerID)
+			}
+			continue
+		}
+		if upstream.Transport == gateway.TransportStdio && len(upstream.Command) > 0 {
+			if err := m.commandAllowed(upstream.Command); err != nil {
+				return fmt.Errorf("upstream %s: %w", upstream.Name, err)
+			}
+		}
+	}
+
+	gatewayConfig, err := m.gatewayConfig(config)
+	if err != nil {
+		return err
+	}
+	return gatewayConfig.Validate()
+}
+
+// environment renders environment variables as sorted "KEY=value" pairs.
+func environment(vars map[string]types.EncryptedString) []string {
+	env := make([]string, 0, len(vars))
+	for key, value := range vars {
+		env = append(env, key+"="+string(value))
+	}
+	sort.Strings(env)
+	return env
+}
+
+// listens reports whether a server serves HTTP on its port.
+func listens(config *MCPServerConfig) bool {
+	return config.TransportType != "stdio"
+}
+
+// StopServer stops an MCP server and keeps it stopped across restarts
 func (m *MCPManager) StopServer(serverID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	server, exists := m.runningServers[serverID]
+	server, exists := m.servers[serverID]
 	if !exists {
-		return fmt.Errorf("server %s not found", serverID)
+		return fmt.Errorf("%w: %s", ErrServerNotFound, serverID)
 	}
 
-	if server.Status != "running" {
-		return fmt.Errorf("server %s is not running (status: %s)", serverID, server.Status)
+	record := server.record
+	if record.DesiredState != models.MCPServerDesiredRunning && !server.active() {
+		return fmt.Errorf("server %s is not running (status: %s)", serverID, record.Status)
 	}
 
 	m.logger.Info("Stopping MCP server", zap.String("serverID", serverID))
-
-	// Cancel the server context
-	server.Cancel()
-	server.Status = "stopping"
-
+	m.stop(server)
 	return nil
 }
 
+// stop marks server as desired stopped and cancels it. Callers hold m.mu.
+func (m *MCPManager) stop(server *managedServer) {
+	record := server.record
+	record.DesiredState = models.MCPServerDesiredStopped
+	record.NextRestartAt = time.Time{}
+	if server.active() {
+		server.cancel()
+		record.Status = models.MCPServerStatusStopping
+	} else {
+		record.Status = models.MCPServerStatusStopped
+	}
+	m.save(record)
+}
+
 // GetServer returns information about a specific server
 func (m *MCPManager) GetServer(serverID string) (*RunningServer, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	server, exists := m.runningServers[serverID]
+	server, exists := m.servers[serverID]
 	if !exists {
-		return nil, fmt.Errorf("server %s not found", serverID)
+		return nil, fmt.Errorf("%w: %s", ErrServerNotFound, serverID)
 	}
-
-	// Return a copy to avoid external modification
-	return &RunningServer{
-		ID:          server.ID,
-		Type:        server.Type,
-		Transport:   server.Transport,
-		Port:        server.Port,
-		Status:      server.Status,
-		AllowedApps: server.AllowedApps,
-		StartedAt:   server.StartedAt,
-	}, nil
+	return view(server.record), nil
 }
 
-// ListServers returns all running servers
+// ListServers returns all managed servers
 func (m *MCPManager) ListServers() map[string]*RunningServer {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	result := make(map[string]*RunningServer)
-	for id, server := range m.runningServers {
-		result[id] = &RunningServer{
-			ID:          server.ID,
-			Type:        server.Type,
-			Transport:   server.Transport,
-			Port:        server.Port,
-			Status:      server.Status,
-			AllowedApps: server.AllowedApps,
-			StartedAt:   server.StartedAt,
-		}
+	result := make(map[string]*RunningServer, len(m.servers))
+	for id, server := range m.servers {
+		result[id] = view(server.record)
 	}
-
 	return result
 }
 
+// view copies a record into the API representation, so callers cannot modify it
+func view(record *models.MCPServer) *RunningServer {
+	server := &RunningServer{
+		ID:           record.ID,
+		OwnerID:      record.OwnerID,
+		Type:         record.Config.Type,
+		Transport:    record.Config.TransportType,
+		Port:         record.Config.Port,
+		Status:       record.Status,
+		DesiredState: record.DesiredState,
+		Health:       record.Health,
+		Restarts:     record.Restarts,
+		LastError:    record.LastError,
+		AllowedApps:  append([]string(nil), record.Config.AllowedApps...),
+		CreatedAt:    record.CreatedAt.Unix(),
+	}
+	if !record.StartedAt.IsZero() {
+		server.StartedAt = record.StartedAt.Unix()
+	}
+	if !record.NextRestartAt.IsZero() && record.Status == models.MCPServerStatusFailed {
+		server.NextRestartAt = record.NextRestartAt.Unix()
+	}
+	return server
+}
+
 // GetServerStatus returns the status of all servers
 func (m *MCPManager) GetServerStatus() map[string]string {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	status := make(map[string]string)
-	for id, server := range m.runningServers {
-		status[id] = server.Status
+	for id, server := range m.servers {
+		status[id] = server.record.Status
 	}
 
 	return status
 }
 
-// StopAllServers stops all running servers
+// StopAllServers stops all servers and keeps them stopped across restarts
 func (m *MCPManager) StopAllServers() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	m.logger.Info("Stopping all MCP servers")
 
-	for serverID, server := range m.runningServers {
-		if server.Status == "running" {
+	for serverID, server := range m.servers {
+		if server.record.DesiredState == models.MCPServerDesiredRunning || server.active() {
 			m.logger.Info("Stopping server", zap.String("serverID", serverID))
-			server.Cancel()
-			server.Status = "stopping"
+			m.stop(server)
 		}
 	}
 
 	return nil
 }
 
-// CleanupStoppedServers removes stopped servers from the manager
+// Shutdown stops the running servers for a process exit without changing their
+// desired state, so they are started again by the next Run.
+func (m *MCPManager) Shutdown(ctx context.Context) {
+	m.mu.RLock()
+	var pending []chan struct{}
+	for _, server := range m.servers {
+		if server.active() {
+			server.cancel()
+			pending = append(pending, server.done)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, done := range pending {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// CleanupStoppedServers removes stopped and failed servers from the manager and the store.
+// Failed servers are no longer restarted once removed.
 func (m *MCPManager) CleanupStoppedServers() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	for serverID, server := range m.runningServers {
-		if server.Status == "stopped" || server.Status == "failed" {
-			delete(m.runningServers, serverID)
-			m.logger.Debug("Cleaned up stopped server", zap.String("serverID", serverID))
+	for serverID, server := range m.servers {
+		status := server.record.Status
+		if server.active() || (status != models.MCPServerStatusStopped && status != models.MCPServerStatusFailed) {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		err := m.repo.Delete(ctx, serverID)
+		cancel()
+		if err != nil {
+			m.logger.Error("Failed to delete MCP server", zap.String("serverID", serverID), zap.Error(err))
+			continue
 		}
+		delete(m.servers, serverID)
+		m.logger.Debug("Cleaned up stopped server", zap.String("serverID", serverID))
 	}
 }
 
@@ -300,17 +814,24 @@ func (m *MCPManager) GetMetrics() map[string]interface{} {
 	statusCounts := make(map[string]int)
 	typeCounts := make(map[string]int)
 	transportCounts := make(map[string]int)
-
-	for _, server := range m.runningServers {
-		statusCounts[server.Status]++
-		typeCounts[string(server.Type)]++
-		transportCounts[server.Transport]++
+	healthCounts := make(map[string]int)
+	restarts := 0
+
+	for _, server := range m.servers {
+		record := server.record
+		statusCounts[record.Status]++
+		typeCounts[string(record.Config.Type)]++
+		transportCounts[record.Config.TransportType]++
+		healthCounts[record.Health]++
+		restarts += record.Restarts
 	}
 
 	return map[string]interface{}{
-		"total_servers":    len(m.runningServers),
+		"total_servers":    len(m.servers),
 		"status_counts":    statusCounts,
 		"type_counts":      typeCounts,
 		"transport_counts": transportCounts,
+		"health_counts":    healthCounts,
+		"restarts":         restarts,
 	}
 }
@@ -0,0 +1,140 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"go.uber.org/zap"
+)
+
+// ownOnly lets users act for themselves only.
+type ownOnly struct{}
+
+func (ownOnly) AuthorizeOwner(ctx context.Context, userID, ownerID string) error {
+	if userID != ownerID {
+		return services.ErrOwnerNotManaged
+	}
+	return nil
+}
+
+// memoryMCPServers keeps copies of the saved server records in memory.
+type memoryMCPServers struct {
+	repositories.MCPServerRepository
+	mu      sync.Mutex
+	records map[string]models.MCPServer
+}
+
+func (r *memoryMCPServers) Create(ctx context.Context, server *models.MCPServer) error {
+	return r.Save(ctx, server)
+}
+
+func (r *memoryMCPServers) Save(ctx context.Context, server *models.MCPServer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[server.ID] = *server
+	return nil
+}
+
+func (r *memoryMCPServers) List(ctx context.Context) ([]*models.MCPServer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var records []*models.MCPServer
+	for _, record := range r.records {
+		copied := record
+		records = append(records, &copied)
+	}
+	return records, nil
+}
+
+func (r *memoryMCPServers) get(id string) models.MCPServer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.records[id]
+}
+
+func TestStartServerRefusesUnmanagedLinkedAccountOwner(t *testing.T) {
+	manager := NewMCPManager(zap.NewNop(), nil, nil, nil, nil, nil, ownOnly{}, nil, BridgeOptions{}, GatewayOptions{})
+
+	for _, serverType := range []MCPServerType{ServerTypeUnified, ServerTypeApps} {
+		config := &MCPServerConfig{
+			Type:                 serverType,
+			TransportType:        "sse",
+			Port:                 9100,
+			LinkedAccountOwnerID: "someone-else",
+			AllowedApps:          []string{"items"},
+		}
+		if _, err := manager.StartServer("user-1", config); !errors.Is(err, services.ErrOwnerNotManaged) {
+			t.Fatalf("%s server: error = %v, want %v", serverType, err, services.ErrOwnerNotManaged)
+		}
+	}
+}
+
+func TestRunRestoresPersistedServers(t *testing.T) {
+	repo := &memoryMCPServers{records: map[string]models.MCPServer{
+		// Apps servers without apps fail as soon as they start.
+		"crashing": {ID: "crashing", OwnerID: "user-1", DesiredState: models.MCPServerDesiredRunning, Status: models.MCPServerStatusRunning,
+			Config: MCPServerConfig{Type: ServerTypeApps, TransportType: "stdio", LinkedAccountOwnerID: "user-1"}},
+		// The owner no longer manages the linked account owner the server was started for.
+		"unmanaged": {ID: "unmanaged", OwnerID: "user-1", DesiredState: models.MCPServerDesiredRunning, Status: models.MCPServerStatusRunning,
+			Config: MCPServerConfig{Type: ServerTypeUnified, TransportType: "stdio", LinkedAccountOwnerID: "someone-else"}},
+		"idle": {ID: "idle", OwnerID: "user-1", DesiredState: models.MCPServerDesiredStopped, Status: models.MCPServerStatusRunning,
+			Config: MCPServerConfig{Type: ServerTypeUnified, TransportType: "stdio"}},
+	}}
+	manager := NewMCPManager(zap.NewNop(), nil, nil, nil, nil, nil, ownOnly{}, repo, BridgeOptions{}, GatewayOptions{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go manager.Run(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for _, id := range []string{"crashing", "unmanaged"} {
+		var record models.MCPServer
+		for record = repo.get(id); record.Restarts == 0 && time.Now().Before(deadline); record = repo.get(id) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if record.Status != models.MCPServerStatusFailed || record.Restarts != 1 || record.NextRestartAt.IsZero() {
+			t.Fatalf("%s server after its crash = %+v, want a failed server waiting for its first restart", id, record)
+		}
+	}
+	if record := repo.get("unmanaged"); record.LastError != services.ErrOwnerNotManaged.Error() {
+		t.Errorf("unmanaged server error = %q, want %q", record.LastError, services.ErrOwnerNotManaged)
+	}
+	if record := repo.get("idle"); record.Status != models.MCPServerStatusStopped {
+		t.Errorf("idle server status = %q, want %q", record.Status, models.MCPServerStatusStopped)
+	}
+}
+
+func TestBridgeCommandAllowlist(t *testing.T) {
+	manager := NewMCPManager(zap.NewNop(), nil, nil, nil, nil, nil, ownOnly{}, nil, BridgeOptions{AllowedCommands: []string{"npx", "/usr/bin/uvx"}}, GatewayOptions{})
+
+	tests := []struct {
+		command []string
+		allowed bool
+	}{
+		{[]string{"npx", "-y", "server"}, true},
+		{[]string{"/usr/bin/uvx", "server"}, true},
+		{[]string{"./npx"}, false},
+		{[]string{"/tmp/npx"}, false},
+		{[]string{"sh", "-c", "npx"}, false},
+	}
+	for _, tt := range tests {
+		err := manager.validateBridge(&MCPServerConfig{Type: ServerTypeBridge, TransportType: "sse", Command: tt.command})
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("validateBridge(%v) = %v, want allowed %v", tt.command, err, tt.allowed)
+		}
+		if !tt.allowed && !errors.Is(err, ErrCommandNotAllowed) {
+			t.Errorf("validateBridge(%v) error = %v, want %v", tt.command, err, ErrCommandNotAllowed)
+		}
+	}
+
+	disabled := NewMCPManager(zap.NewNop(), nil, nil, nil, nil, nil, ownOnly{}, nil, BridgeOptions{}, GatewayOptions{})
+	if err := disabled.validateBridge(&MCPServerConfig{Type: ServerTypeBridge, TransportType: "sse", Command: []string{"npx"}}); !errors.Is(err, ErrCommandNotAllowed) {
+		t.Errorf("validateBridge() without an allowlist = %v, want %v", err, ErrCommandNotAllowed)
+	}
+}
@@ -17,17 +17,27 @@ type AppsMCPServer struct {
 	logger               *zap.Logger
 	integrationService   services.IntegrationService
 	toolProvider         services.ToolProvider
+	promptService        services.PromptService
+	resourceService      services.ResourceService
+	owners               services.OwnerAuthorizer
+	userID               string // Platform user the server runs for
 	linkedAccountOwnerID string
 	allowedApps          []string
+	toolPolicy           DestructiveToolPolicy
 	toolsCache           []models.Tool
 	initialized          bool
 }
 
-// NewAppsMCPServer creates a new apps-specific MCP server instance
+// NewAppsMCPServer creates a new apps-specific MCP server instance. It runs for userID, and
+// owners decides which linked account owners that user's clients may override the default with.
 func NewAppsMCPServer(
 	logger *zap.Logger,
 	integrationService services.IntegrationService,
 	toolProvider services.ToolProvider,
+	promptService services.PromptService,
+	resourceService services.ResourceService,
+	owners services.OwnerAuthorizer,
+	userID string,
 	linkedAccountOwnerID string,
 	allowedApps []string,
 ) *AppsMCPServer {
@@ -35,13 +45,23 @@ func NewAppsMCPServer(
 		logger:               logger,
 		integrationService:   integrationService,
 		toolProvider:         toolProvider,
+		promptService:        promptService,
+		resourceService:      resourceService,
+		owners:               owners,
+		userID:               userID,
 		linkedAccountOwnerID: linkedAccountOwnerID,
 		allowedApps:          allowedApps,
+		toolPolicy:           DestructiveToolsAllow,
 		toolsCache:           []models.Tool{},
 		initialized:          false,
 	}
 }
 
+// SetDestructiveToolPolicy sets how destructive tools are exposed to clients
+func (s *AppsMCPServer) SetDestructiveToolPolicy(policy DestructiveToolPolicy) {
+	s.toolPolicy = policy
+}
+
 // Initialize implements the MCP initialize method
 func (s *AppsMCPServer) Initialize(params map[string]interface{}) (map[string]interface{}, error) {
 	s.logger.Info("Initializing apps MCP server",
@@ -65,6 +85,9 @@ func (s *AppsMCPServer) Initialize(params map[string]interface{}) (map[string]in
 				"subscribe":   true,
 				"listChanged": true,
 			},
+			"prompts": map[string]interface{}{
+				"listChanged": true,
+			},
 		},
 		"serverInfo": map[string]interface{}{
 			"name":        "api2sdk-apps-mcp",
@@ -145,13 +168,12 @@ func (s *AppsMCPServer) ListTools() ([]interface{}, error) {
 	// Convert models.Tool to interface{} for MCP compatibility
 	var tools []interface{}
 	for _, tool := range s.toolsCache {
-		mcpTool := map[string]interface{}{
-			"name":        tool.Name,
-			"description": tool.Description,
-			"inputSchema": tool.InputSchema,
+		if !s.toolPolicy.visible(tool) {
+			continue
 		}
-		tools = append(tools, mcpTool)
+		tools = append(tools, s.toolPolicy.present(tool))
 	}
+	tools = append(tools, services.ContinueResultTool, services.NextPageTool)
 
 	s.logger.Debug("Returning apps MCP tools", zap.Int("count", len(tools)))
 	return tools, nil
@@ -167,9 +189,21 @@ func (s *AppsMCPServer) CallTool(name string, arguments map[string]interface{})
 		zap.String("toolName", name),
 		zap.Any("arguments", arguments))
 
+	switch name {
+	case services.ContinueResultToolName:
+		token, _ := arguments["continuation_token"].(string)
+		return s.toolProvider.ContinueResult(context.Background(), token)
+	case services.NextPageToolName:
+		token, _ := arguments["page_token"].(string)
+		return s.toolProvider.NextPage(context.Background(), token)
+	}
+
 	// Handle optional linked account owner ID override
 	linkedOwnerID := s.linkedAccountOwnerID
 	if overrideID, ok := arguments["override_linked_account_owner_id"].(string); ok && overrideID != "" {
+		if err := authorizeOwnerOverride(s.owners, s.userID, overrideID); err != nil {
+			return nil, err
+		}
 		linkedOwnerID = overrideID
 		s.logger.Debug("Using overridden linked account owner ID", zap.String("overrideID", overrideID))
 		// Remove the override parameter from arguments before passing to the tool
@@ -193,6 +227,10 @@ func (s *AppsMCPServer) CallTool(name string, arguments map[string]interface{})
 		return nil, fmt.Errorf("tool '%s' not found. Available tools: %v", name, availableTools)
 	}
 
+	if err := s.toolPolicy.authorize(*targetTool, arguments); err != nil {
+		return nil, err
+	}
+
 	// Find the integration that owns this tool
 	integrations, err := s.integrationService.ListIntegrations(context.Background())
 	if err != nil {
@@ -228,18 +266,14 @@ func (s *AppsMCPServer) CallTool(name string, arguments map[string]interface{})
 					zap.String("integrationName", integration.Name),
 					zap.String("linkedOwnerID", linkedOwnerID))
 
-				result, err := s.toolProvider.ExecuteTool(context.Background(), integration.ID, name, arguments)
+				result, err := s.toolProvider.ExecuteTool(context.Background(), integration.ID, linkedOwnerID, name, arguments)
 				if err != nil {
 					return nil, fmt.Errorf("tool execution failed: %w", err)
 				}
 
-				return map[string]interface{}{
-					"success":     true,
-					"tool_name":   name,
-					"result":      result,
-					"integration": integration.Name,
-					"app":         integration.Name,
-				}, nil
+				result.Meta["tool_name"] = name
+				result.Meta["integration"] = integration.Name
+				return result, nil
 			}
 		}
 	}
@@ -247,7 +281,8 @@ func (s *AppsMCPServer) CallTool(name string, arguments map[string]interface{})
 	return nil, fmt.Errorf("tool '%s' execution failed - integration not found", name)
 }
 
-// ListResources returns available resources from the specified apps
+// ListResources returns the allowed apps and the collections, OpenAPI documents and SDKs
+// of the linked account owner
 func (s *AppsMCPServer) ListResources() ([]interface{}, error) {
 	if !s.initialized {
 		return nil, fmt.Errorf("server not initialized")
@@ -255,84 +290,108 @@ func (s *AppsMCPServer) ListResources() ([]interface{}, error) {
 
 	s.logger.Debug("Listing apps MCP resources")
 
-	// Get integrations for allowed apps only
-	integrations, err := s.integrationService.ListIntegrations(context.Background())
+	resources, err := listResources(context.Background(), s.resourceService, s.resourceScope())
 	if err != nil {
-		return nil, fmt.Errorf("failed to list integrations: %w", err)
+		return nil, fmt.Errorf("failed to list resources: %w", err)
 	}
 
-	var resources []interface{}
-	for _, integration := range integrations {
-		// Check if this integration is in our allowed apps
-		appAllowed := false
-		for _, allowedApp := range s.allowedApps {
-			if strings.EqualFold(integration.Name, allowedApp) {
-				appAllowed = true
-				break
-			}
-		}
+	s.logger.Debug("Returning apps MCP resources", zap.Int("count", len(resources)))
+	return resources, nil
+}
 
-		if !appAllowed {
-			continue
-		}
+// ListResourceTemplates returns the URI templates for browsing collections and their documents
+func (s *AppsMCPServer) ListResourceTemplates() ([]interface{}, error) {
+	if !s.initialized {
+		return nil, fmt.Errorf("server not initialized")
+	}
+	return listResourceTemplates(s.resourceService), nil
+}
+
+// ReadResource reads a specific resource. app://<name> URIs remain supported as aliases
+// of the allowed apps' integration resources.
+func (s *AppsMCPServer) ReadResource(uri string) (interface{}, error) {
+	if !s.initialized {
+		return nil, fmt.Errorf("server not initialized")
+	}
+
+	s.logger.Debug("Reading apps MCP resource", zap.String("uri", uri))
 
-		resource := map[string]interface{}{
-			"uri":         fmt.Sprintf("app://%s", integration.Name),
-			"name":        integration.Name,
-			"description": fmt.Sprintf("%s integration resources", integration.Name),
-			"mimeType":    "application/json",
+	if appName, ok := strings.CutPrefix(uri, "app://"); ok {
+		integrations, err := s.allowedIntegrations()
+		if err != nil {
+			return nil, err
 		}
-		resources = append(resources, resource)
+		for _, integration := range integrations {
+			if strings.EqualFold(integration.Name, appName) {
+				return readResource(context.Background(), s.toolProvider, s.resourceService, s.resourceScope(), services.IntegrationResourceScheme+integration.ID.Hex())
+			}
+		}
+		return nil, transport.ResourceNotFound(fmt.Errorf("app '%s' not allowed. Allowed apps: %v", appName, s.allowedApps))
 	}
 
-	s.logger.Debug("Returning apps MCP resources", zap.Int("count", len(resources)))
-	return resources, nil
+	return readResource(context.Background(), s.toolProvider, s.resourceService, s.resourceScope(), uri)
 }
 
-// ReadResource reads a specific resource from the allowed apps
-func (s *AppsMCPServer) ReadResource(uri string) (interface{}, error) {
+// resourceScope exposes the allowed apps and the linked account owner's own data
+func (s *AppsMCPServer) resourceScope() services.ResourceScope {
+	return services.ResourceScope{OwnerID: s.linkedAccountOwnerID, Integration: s.allows}
+}
+
+// ListPrompts returns the prompts of the allowed apps
+func (s *AppsMCPServer) ListPrompts() ([]interface{}, error) {
 	if !s.initialized {
 		return nil, fmt.Errorf("server not initialized")
 	}
 
-	s.logger.Debug("Reading apps MCP resource", zap.String("uri", uri))
+	integrations, err := s.allowedIntegrations()
+	if err != nil {
+		return nil, err
+	}
 
-	// Parse URI to extract app name
-	if !strings.HasPrefix(uri, "app://") {
-		return nil, fmt.Errorf("invalid URI format. Expected app://appname")
+	prompts := listPrompts(context.Background(), s.logger, s.promptService, integrations)
+	s.logger.Debug("Returning apps MCP prompts", zap.Int("count", len(prompts)))
+	return prompts, nil
+}
+
+// GetPrompt renders a prompt of one of the allowed apps
+func (s *AppsMCPServer) GetPrompt(name string, arguments map[string]string) (interface{}, error) {
+	if !s.initialized {
+		return nil, fmt.Errorf("server not initialized")
 	}
 
-	appName := strings.TrimPrefix(uri, "app://")
+	s.logger.Debug("Getting apps MCP prompt", zap.String("name", name))
 
-	// Check if the app is in our allowed list
-	appAllowed := false
-	for _, allowedApp := range s.allowedApps {
-		if strings.EqualFold(appName, allowedApp) {
-			appAllowed = true
-			break
-		}
+	integrations, err := s.allowedIntegrations()
+	if err != nil {
+		return nil, err
 	}
+	return getPrompt(context.Background(), s.promptService, integrations, name, arguments)
+}
 
-	if !appAllowed {
-		return nil, fmt.Errorf("app '%s' not allowed. Allowed apps: %v", appName, s.allowedApps)
+// allowedIntegrations returns the integrations of the allowed apps
+func (s *AppsMCPServer) allowedIntegrations() ([]*models.Integration, error) {
+	integrations, err := s.integrationService.ListIntegrations(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list integrations: %w", err)
 	}
 
-	// Find tools for this specific app
-	var appTools []models.Tool
-	for _, tool := range s.toolsCache {
-		// This is a simplified approach - in a real implementation,
-		// you might want to track which tools belong to which apps
-		appTools = append(appTools, tool)
+	var allowed []*models.Integration
+	for _, integration := range integrations {
+		if s.allows(integration) {
+			allowed = append(allowed, integration)
+		}
 	}
+	return allowed, nil
+}
 
-	return map[string]interface{}{
-		"uri":         uri,
-		"app":         appName,
-		"tools":       appTools,
-		"toolCount":   len(appTools),
-		"mimeType":    "application/json",
-		"allowedApps": s.allowedApps,
-	}, nil
+// allows reports whether the integration is one of the allowed apps
+func (s *AppsMCPServer) allows(integration *models.Integration) bool {
+	for _, allowedApp := range s.allowedApps {
+		if strings.EqualFold(integration.Name, allowedApp) {
+			return true
+		}
+	}
+	return false
 }
 
 // Shutdown gracefully shuts down the server
@@ -0,0 +1,51 @@
+package servers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/AkashKesav/API2SDK/internal/mcp/transport"
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"go.uber.org/zap"
+)
+
+// listPrompts returns the prompts of the integrations. An integration whose prompts cannot
+// be built, for example because of an invalid spec, is logged and left out.
+func listPrompts(ctx context.Context, logger *zap.Logger, promptService services.PromptService, integrations []*models.Integration) []interface{} {
+	prompts := []interface{}{}
+	for _, integration := range integrations {
+		descriptors, err := promptService.ListPrompts(ctx, integration)
+		if err != nil {
+			logger.Warn("Failed to list prompts for integration",
+				zap.String("integrationID", integration.ID.Hex()),
+				zap.String("integrationName", integration.Name),
+				zap.Error(err))
+			continue
+		}
+		for _, descriptor := range descriptors {
+			prompts = append(prompts, descriptor)
+		}
+	}
+	return prompts
+}
+
+// getPrompt renders the prompt with the qualified name from the first integration that has it.
+// Unknown prompts and missing arguments are errors of the request.
+func getPrompt(ctx context.Context, promptService services.PromptService, integrations []*models.Integration, name string, arguments map[string]string) (interface{}, error) {
+	for _, integration := range integrations {
+		result, err := promptService.GetPrompt(ctx, integration, name, arguments)
+		switch {
+		case err == nil:
+			return result, nil
+		case errors.Is(err, services.ErrPromptNotFound):
+			continue
+		case errors.Is(err, services.ErrInvalidPromptArguments):
+			return nil, transport.InvalidParams(err)
+		default:
+			return nil, err
+		}
+	}
+	return nil, transport.InvalidParams(fmt.Errorf("unknown prompt: %s", name))
+}
@@ -0,0 +1,53 @@
+package servers
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/AkashKesav/API2SDK/internal/mcp/transport"
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/services"
+)
+
+// listResources returns the platform resources in scope.
+func listResources(ctx context.Context, resourceService services.ResourceService, scope services.ResourceScope) ([]interface{}, error) {
+	listed, err := resourceService.ListResources(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]interface{}, 0, len(listed))
+	for _, resource := range listed {
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+// listResourceTemplates returns the templates of the platform resources.
+func listResourceTemplates(resourceService services.ResourceService) []interface{} {
+	listed := resourceService.ListResourceTemplates()
+	templates := make([]interface{}, 0, len(listed))
+	for _, template := range listed {
+		templates = append(templates, template)
+	}
+	return templates
+}
+
+// readResource reads a stored tool result or a platform resource in scope. URIs that
+// name no resource are errors of the request.
+func readResource(ctx context.Context, toolProvider services.ToolProvider, resourceService services.ResourceService, scope services.ResourceScope, uri string) (interface{}, error) {
+	var contents *models.ResourceContents
+	var err error
+	if strings.HasPrefix(uri, services.ResultResourceScheme) {
+		contents, err = toolProvider.ReadResultResource(ctx, uri)
+	} else {
+		contents, err = resourceService.ReadResource(ctx, scope, uri)
+	}
+	if errors.Is(err, services.ErrResourceNotFound) {
+		return nil, transport.ResourceNotFound(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"contents": []*models.ResourceContents{contents}}, nil
+}
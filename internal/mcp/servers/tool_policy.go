@@ -0,0 +1,108 @@
+package servers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+)
+
+// DestructiveToolPolicy controls how a server exposes tools whose annotations
+// mark them as destructive.
+type DestructiveToolPolicy string
+
+const (
+	// DestructiveToolsAllow exposes destructive tools like any other tool.
+	DestructiveToolsAllow DestructiveToolPolicy = "allow"
+	// DestructiveToolsConfirm exposes destructive tools but refuses to run them
+	// unless the caller passes confirm_destructive=true.
+	DestructiveToolsConfirm DestructiveToolPolicy = "confirm"
+	// DestructiveToolsHide removes destructive tools from listings and refuses to run them.
+	DestructiveToolsHide DestructiveToolPolicy = "hide"
+)
+
+// ConfirmArgument is the tool argument that acknowledges a destructive call
+// under DestructiveToolsConfirm.
+const ConfirmArgument = "confirm_destructive"
+
+// ParseDestructiveToolPolicy validates a policy name. An empty name means allow.
+func ParseDestructiveToolPolicy(value string) (DestructiveToolPolicy, error) {
+	switch DestructiveToolPolicy(strings.ToLower(strings.TrimSpace(value))) {
+	case "", DestructiveToolsAllow:
+		return DestructiveToolsAllow, nil
+	case DestructiveToolsConfirm:
+		return DestructiveToolsConfirm, nil
+	case DestructiveToolsHide:
+		return DestructiveToolsHide, nil
+	default:
+		return "", fmt.Errorf("unsupported destructive tool policy: %s. Supported: allow, confirm, hide", value)
+	}
+}
+
+// visible reports whether the tool should appear in listings and search results.
+func (p DestructiveToolPolicy) visible(tool models.Tool) bool {
+	return p != DestructiveToolsHide || !tool.IsDestructive()
+}
+
+// present returns the tool as clients should see it. Under the confirm policy,
+// destructive tools gain a required confirm_destructive argument.
+func (p DestructiveToolPolicy) present(tool models.Tool) models.Tool {
+	if p != DestructiveToolsConfirm || !tool.IsDestructive() {
+		return tool
+	}
+
+	schema := make(map[string]interface{}, len(tool.InputSchema)+1)
+	for k, v := range tool.InputSchema {
+		schema[k] = v
+	}
+	properties := map[string]interface{}{}
+	if existing, ok := schema["properties"].(map[string]interface{}); ok {
+		for k, v := range existing {
+			properties[k] = v
+		}
+	}
+	properties[ConfirmArgument] = map[string]interface{}{
+		"type":        "boolean",
+		"description": "This tool may modify or delete data. Set to true to confirm the call.",
+	}
+	schema["properties"] = properties
+
+	var required []string
+	switch r := schema["required"].(type) {
+	case []string:
+		required = append(required, r...)
+	case []interface{}:
+		for _, v := range r {
+			if name, ok := v.(string); ok {
+				required = append(required, name)
+			}
+		}
+	}
+	schema["required"] = append(required, ConfirmArgument)
+	if schema["type"] == nil {
+		schema["type"] = "object"
+	}
+
+	tool.InputSchema = schema
+	return tool
+}
+
+// authorize checks whether a call to tool may proceed and strips the
+// confirmation argument so that it is not forwarded upstream.
+func (p DestructiveToolPolicy) authorize(tool models.Tool, arguments map[string]interface{}) error {
+	confirmed, _ := arguments[ConfirmArgument].(bool)
+	delete(arguments, ConfirmArgument)
+
+	if !tool.IsDestructive() {
+		return nil
+	}
+	switch p {
+	case DestructiveToolsHide:
+		return fmt.Errorf("tool '%s' is destructive and disabled on this server", tool.Name)
+	case DestructiveToolsConfirm:
+		if !confirmed {
+			return fmt.Errorf("tool '%s' may modify or delete data. Call it again with %s=true to confirm", tool.Name, ConfirmArgument)
+		}
+	}
+	return nil
+}
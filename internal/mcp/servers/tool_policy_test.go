@@ -0,0 +1,57 @@
+package servers
+
+import (
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+)
+
+func TestDestructiveToolPolicy(t *testing.T) {
+	readOnly, destructive := true, true
+	reader := models.Tool{Name: "list_items", Annotations: &models.ToolAnnotations{ReadOnlyHint: &readOnly}}
+	deleter := models.Tool{
+		Name:        "delete_item",
+		InputSchema: map[string]interface{}{"type": "object", "required": []interface{}{"id"}},
+		Annotations: &models.ToolAnnotations{DestructiveHint: &destructive},
+	}
+
+	tests := []struct {
+		policy           DestructiveToolPolicy
+		visible          bool
+		unconfirmedError bool
+		confirmedError   bool
+	}{
+		{DestructiveToolsAllow, true, false, false},
+		{DestructiveToolsConfirm, true, true, false},
+		{DestructiveToolsHide, false, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			if !tt.policy.visible(reader) || tt.policy.visible(deleter) != tt.visible {
+				t.Errorf("visible(delete_item) = %v, want %v", tt.policy.visible(deleter), tt.visible)
+			}
+			if err := tt.policy.authorize(reader, map[string]interface{}{}); err != nil {
+				t.Errorf("authorize(list_items) = %v", err)
+			}
+			if err := tt.policy.authorize(deleter, map[string]interface{}{}); (err != nil) != tt.unconfirmedError {
+				t.Errorf("authorize(delete_item) = %v, want error %v", err, tt.unconfirmedError)
+			}
+			arguments := map[string]interface{}{ConfirmArgument: true}
+			if err := tt.policy.authorize(deleter, arguments); (err != nil) != tt.confirmedError {
+				t.Errorf("authorize(delete_item, confirmed) = %v, want error %v", err, tt.confirmedError)
+			}
+			if _, ok := arguments[ConfirmArgument]; ok {
+				t.Error("confirmation argument would be forwarded upstream")
+			}
+		})
+	}
+
+	presented := DestructiveToolsConfirm.present(deleter)
+	required, _ := presented.InputSchema["required"].([]string)
+	if len(required) != 2 || required[1] != ConfirmArgument {
+		t.Errorf("confirm policy required = %v, want [id %s]", presented.InputSchema["required"], ConfirmArgument)
+	}
+	if _, ok := deleter.InputSchema["properties"]; ok {
+		t.Error("present modified the original tool schema")
+	}
+}
@@ -2,6 +2,7 @@ package servers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/AkashKesav/API2SDK/internal/mcp/transport"
@@ -17,8 +18,14 @@ type UnifiedMCPServer struct {
 	logger               *zap.Logger
 	integrationService   services.IntegrationService
 	toolProvider         services.ToolProvider
+	toolSearch           services.ToolSearchService
+	promptService        services.PromptService
+	resourceService      services.ResourceService
+	owners               services.OwnerAuthorizer
+	userID               string // Platform user the server runs for
 	linkedAccountOwnerID string
 	allowedAppsOnly      bool
+	toolPolicy           DestructiveToolPolicy
 	functionCache        map[string][]models.Tool
 	initialized          bool
 }
@@ -30,19 +37,17 @@ type Tool struct {
 	InputSchema map[string]interface{} `json:"inputSchema"`
 }
 
-// Resource represents a unified MCP resource
-type Resource struct {
-	URI         string `json:"uri"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	MimeType    string `json:"mimeType,omitempty"`
-}
-
-// NewUnifiedMCPServer creates a new unified MCP server instance
+// NewUnifiedMCPServer creates a new unified MCP server instance. It runs for userID, and owners
+// decides which linked account owners that user's clients may override the default with.
 func NewUnifiedMCPServer(
 	logger *zap.Logger,
 	integrationService services.IntegrationService,
 	toolProvider services.ToolProvider,
+	toolSearch services.ToolSearchService,
+	promptService services.PromptService,
+	resourceService services.ResourceService,
+	owners services.OwnerAuthorizer,
+	userID string,
 	linkedAccountOwnerID string,
 	allowedAppsOnly bool,
 ) *UnifiedMCPServer {
@@ -50,13 +55,24 @@ func NewUnifiedMCPServer(
 		logger:               logger,
 		integrationService:   integrationService,
 		toolProvider:         toolProvider,
+		toolSearch:           toolSearch,
+		promptService:        promptService,
+		resourceService:      resourceService,
+		owners:               owners,
+		userID:               userID,
 		linkedAccountOwnerID: linkedAccountOwnerID,
 		allowedAppsOnly:      allowedAppsOnly,
+		toolPolicy:           DestructiveToolsAllow,
 		functionCache:        make(map[string][]models.Tool),
 		initialized:          false,
 	}
 }
 
+// SetDestructiveToolPolicy sets how destructive functions are exposed to clients
+func (s *UnifiedMCPServer) SetDestructiveToolPolicy(policy DestructiveToolPolicy) {
+	s.toolPolicy = policy
+}
+
 // Initialize implements the MCP initialize method
 func (s *UnifiedMCPServer) Initialize(params map[string]interface{}) (map[string]interface{}, error) {
 	s.logger.Info("Initializing unified MCP server",
@@ -75,6 +91,9 @@ func (s *UnifiedMCPServer) Initialize(params map[string]interface{}) (map[string
 				"subscribe":   true,
 				"listChanged": true,
 			},
+			"prompts": map[string]interface{}{
+				"listChanged": true,
+			},
 		},
 		"serverInfo": map[string]interface{}{
 			"name":    "api2sdk-unified-mcp",
@@ -105,12 +124,25 @@ func (s *UnifiedMCPServer) ListTools() ([]interface{}, error) {
 				},
 				"query": map[string]interface{}{
 					"type":        "string",
-					"description": "Optional search query to filter functions by name or description",
+					"description": "Optional natural language query. Results are ranked by relevance to the function name, description, tags and parameters.",
 				},
 				"category": map[string]interface{}{
 					"type":        "string",
 					"description": "Optional category filter (e.g., 'communication', 'productivity', 'data')",
 				},
+				"tags": map[string]interface{}{
+					"type":        "array",
+					"description": "Optional list of tags; functions with any of these tags are returned",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Maximum number of functions to return (default %d, max %d)", services.DefaultToolSearchLimit, services.MaxToolSearchLimit),
+				},
+				"offset": map[string]interface{}{
+					"type":        "integer",
+					"description": "Number of ranked results to skip, for pagination",
+				},
 			},
 		},
 	}
@@ -129,16 +161,24 @@ func (s *UnifiedMCPServer) ListTools() ([]interface{}, error) {
 					"type":        "object",
 					"description": "The arguments to pass to the function, structured according to the function's input schema",
 				},
+				"integration_id": map[string]interface{}{
+					"type":        "string",
+					"description": "The integration_id of the function (obtained from ACI_SEARCH_FUNCTIONS). Required when several apps have a function with this name",
+				},
+				"app": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: The app of the function, as an alternative to integration_id",
+				},
 				"override_linked_account_owner_id": map[string]interface{}{
 					"type":        "string",
-					"description": "Optional: Override the default linked account owner ID for multi-user scenarios",
+					"description": "Optional: Execute as another linked account owner. The server's user must manage that owner's linked accounts",
 				},
 			},
 			"required": []string{"function_name", "function_arguments"},
 		},
 	}
 
-	tools := []interface{}{aciSearchFunctions, aciExecuteFunction}
+	tools := []interface{}{aciSearchFunctions, aciExecuteFunction, services.ContinueResultTool, services.NextPageTool}
 
 	s.logger.Debug("Returning unified MCP tools", zap.Int("count", len(tools)))
 	return tools, nil
@@ -161,8 +201,16 @@ func (s *UnifiedMCPServer) CallTool(name string, arguments map[string]interface{
 	case "ACI_EXECUTE_FUNCTION":
 		return s.handleExecuteFunction(arguments)
 
+	case services.ContinueResultToolName:
+		token, _ := arguments["continuation_token"].(string)
+		return s.toolProvider.ContinueResult(context.Background(), token)
+
+	case services.NextPageToolName:
+		token, _ := arguments["page_token"].(string)
+		return s.toolProvider.NextPage(context.Background(), token)
+
 	default:
-		return nil, fmt.Errorf("unknown tool: %s. Available tools: ACI_SEARCH_FUNCTIONS, ACI_EXECUTE_FUNCTION", name)
+		return nil, fmt.Errorf("unknown tool: %s. Available tools: ACI_SEARCH_FUNCTIONS, ACI_EXECUTE_FUNCTION, %s, %s", name, services.ContinueResultToolName, services.NextPageToolName)
 	}
 }
 
@@ -170,83 +218,46 @@ func (s *UnifiedMCPServer) CallTool(name string, arguments map[string]interface{
 func (s *UnifiedMCPServer) handleSearchFunctions(arguments map[string]interface{}) (interface{}, error) {
 	s.logger.Debug("Handling ACI_SEARCH_FUNCTIONS", zap.Any("arguments", arguments))
 
-	// Extract search parameters
-	appNames := []string{}
-	if appNamesInterface, ok := arguments["app_names"]; ok {
-		if appList, ok := appNamesInterface.([]interface{}); ok {
-			for _, app := range appList {
-				if appStr, ok := app.(string); ok {
-					appNames = append(appNames, appStr)
-				}
-			}
-		}
+	query := services.ToolSearchQuery{
+		AppNames: stringList(arguments["app_names"]),
+		Tags:     stringList(arguments["tags"]),
+		Filter:   s.toolPolicy.visible,
+	}
+	query.Query, _ = arguments["query"].(string)
+	query.Category, _ = arguments["category"].(string)
+	// JSON numbers decode as float64
+	if limit, ok := arguments["limit"].(float64); ok {
+		query.Limit = int(limit)
+	}
+	if offset, ok := arguments["offset"].(float64); ok {
+		query.Offset = int(offset)
 	}
 
-	query, _ := arguments["query"].(string)
-	category, _ := arguments["category"].(string)
-
-	// Get all available integrations
-	integrations, err := s.integrationService.ListIntegrations(context.Background())
+	results, err := s.toolSearch.Search(context.Background(), query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list integrations: %w", err)
+		return nil, fmt.Errorf("failed to search functions: %w", err)
 	}
 
-	var allTools []models.Tool
-	for _, integration := range integrations {
-		// Filter by app names if specified
-		if len(appNames) > 0 {
-			found := false
-			for _, appName := range appNames {
-				if integration.Name == appName {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
-		}
-
-		// Get tools for this integration
-		tools, err := s.toolProvider.GetTools(context.Background(), integration.ID)
-		if err != nil {
-			s.logger.Warn("Failed to get tools for integration",
-				zap.String("integrationID", integration.ID.Hex()),
-				zap.Error(err))
-			continue
-		}
-
-		// Apply filters
-		for _, tool := range tools {
-			// Apply query filter
-			if query != "" {
-				if !contains(tool.Name, query) && !contains(tool.Description, query) {
-					continue
-				}
-			}
-
-			// Apply category filter (if your tools have categories)
-			if category != "" {
-				// This would require extending your Tool model to include categories
-				// For now, skip this filter
-			}
-
-			allTools = append(allTools, tool)
-		}
+	for i := range results.Hits {
+		results.Hits[i].Tool = s.toolPolicy.present(results.Hits[i].Tool)
 	}
 
 	// Format response similar to ACI-MCP
 	response := map[string]interface{}{
-		"functions": allTools,
-		"total":     len(allTools),
-		"query":     query,
-		"app_names": appNames,
-		"category":  category,
+		"functions": results.Hits,
+		"total":     results.Total,
+		"limit":     results.Limit,
+		"offset":    results.Offset,
+		"query":     query.Query,
+		"app_names": query.AppNames,
+		"category":  query.Category,
+		"tags":      query.Tags,
 	}
 
 	s.logger.Info("Search functions completed",
-		zap.Int("totalFound", len(allTools)),
-		zap.Strings("appNames", appNames))
+		zap.Int("totalFound", results.Total),
+		zap.Int("returned", len(results.Hits)),
+		zap.Strings("appNames", query.AppNames))
 
 	return response, nil
 }
@@ -269,49 +280,59 @@ func (s *UnifiedMCPServer) handleExecuteFunction(arguments map[string]interface{
 	// Handle optional linked account owner ID override
 	linkedOwnerID := s.linkedAccountOwnerID
 	if overrideID, ok := arguments["override_linked_account_owner_id"].(string); ok && overrideID != "" {
+		if err := authorizeOwnerOverride(s.owners, s.userID, overrideID); err != nil {
+			return nil, err
+		}
 		linkedOwnerID = overrideID
 		s.logger.Debug("Using overridden linked account owner ID", zap.String("overrideID", overrideID))
 	}
 
 	// Find the integration that has this function
-	integrations, err := s.integrationService.ListIntegrations(context.Background())
+	app, _ := arguments["integration_id"].(string)
+	if app == "" {
+		app, _ = arguments["app"].(string)
+	}
+	hit, err := s.toolSearch.FindTool(context.Background(), functionName, app)
+	if errors.Is(err, services.ErrAmbiguousTool) {
+		return nil, fmt.Errorf("%w. Pass the integration_id from ACI_SEARCH_FUNCTIONS to choose one", err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to list integrations: %w", err)
+		return nil, fmt.Errorf("function '%s' not found. Use ACI_SEARCH_FUNCTIONS to discover available functions", functionName)
 	}
 
-	for _, integration := range integrations {
-		tools, err := s.toolProvider.GetTools(context.Background(), integration.ID)
-		if err != nil {
-			continue
-		}
+	if err := s.toolPolicy.authorize(hit.Tool, functionArguments); err != nil {
+		return nil, err
+	}
 
-		for _, tool := range tools {
-			if tool.Name == functionName {
-				// Found the function, execute it
-				s.logger.Info("Executing function",
-					zap.String("functionName", functionName),
-					zap.String("integrationID", integration.ID.Hex()),
-					zap.String("linkedOwnerID", linkedOwnerID))
-
-				result, err := s.toolProvider.ExecuteTool(context.Background(), integration.ID, functionName, functionArguments)
-				if err != nil {
-					return nil, fmt.Errorf("function execution failed: %w", err)
-				}
-
-				return map[string]interface{}{
-					"success":       true,
-					"function_name": functionName,
-					"result":        result,
-					"integration":   integration.Name,
-				}, nil
-			}
-		}
+	s.logger.Info("Executing function",
+		zap.String("functionName", functionName),
+		zap.String("integrationID", hit.IntegrationID.Hex()),
+		zap.String("linkedOwnerID", linkedOwnerID))
+
+	result, err := s.toolProvider.ExecuteTool(context.Background(), hit.IntegrationID, linkedOwnerID, functionName, functionArguments)
+	if err != nil {
+		return nil, fmt.Errorf("function execution failed: %w", err)
+	}
+	if result.Meta == nil {
+		result.Meta = map[string]interface{}{}
 	}
+	result.Meta["function_name"] = functionName
+	result.Meta["integration"] = hit.App
+
+	return result, nil
+}
 
-	return nil, fmt.Errorf("function '%s' not found. Use ACI_SEARCH_FUNCTIONS to discover available functions", functionName)
+// authorizeOwnerOverride checks that the user a server runs for manages ownerID, the linked
+// account owner a client named with override_linked_account_owner_id.
+func authorizeOwnerOverride(owners services.OwnerAuthorizer, userID, ownerID string) error {
+	if owners == nil {
+		return services.ErrOwnerNotManaged
+	}
+	return owners.AuthorizeOwner(context.Background(), userID, ownerID)
 }
 
-// ListResources returns available resources (could be collections, APIs, etc.)
+// ListResources returns the integrations and the collections, OpenAPI documents and SDKs
+// of the linked account owner
 func (s *UnifiedMCPServer) ListResources() ([]interface{}, error) {
 	if !s.initialized {
 		return nil, fmt.Errorf("server not initialized")
@@ -319,27 +340,23 @@ func (s *UnifiedMCPServer) ListResources() ([]interface{}, error) {
 
 	s.logger.Debug("Listing unified MCP resources")
 
-	// Get available integrations as resources
-	integrations, err := s.integrationService.ListIntegrations(context.Background())
+	resources, err := listResources(context.Background(), s.resourceService, s.resourceScope())
 	if err != nil {
-		return nil, fmt.Errorf("failed to list integrations: %w", err)
-	}
-
-	var resources []interface{}
-	for _, integration := range integrations {
-		resource := Resource{
-			URI:         fmt.Sprintf("integration://%s", integration.ID.Hex()),
-			Name:        integration.Name,
-			Description: integration.Description,
-			MimeType:    "application/json",
-		}
-		resources = append(resources, resource)
+		return nil, fmt.Errorf("failed to list resources: %w", err)
 	}
 
 	s.logger.Debug("Returning unified MCP resources", zap.Int("count", len(resources)))
 	return resources, nil
 }
 
+// ListResourceTemplates returns the URI templates for browsing collections and their documents
+func (s *UnifiedMCPServer) ListResourceTemplates() ([]interface{}, error) {
+	if !s.initialized {
+		return nil, fmt.Errorf("server not initialized")
+	}
+	return listResourceTemplates(s.resourceService), nil
+}
+
 // ReadResource reads a specific resource
 func (s *UnifiedMCPServer) ReadResource(uri string) (interface{}, error) {
 	if !s.initialized {
@@ -348,14 +365,43 @@ func (s *UnifiedMCPServer) ReadResource(uri string) (interface{}, error) {
 
 	s.logger.Debug("Reading unified MCP resource", zap.String("uri", uri))
 
-	// Parse URI and return resource content
-	// For now, return a simple response
-	return map[string]interface{}{
-		"uri":       uri,
-		"content":   "Resource content would be provided here",
-		"mimeType":  "application/json",
-		"timestamp": fmt.Sprintf("%d", 1234567890),
-	}, nil
+	return readResource(context.Background(), s.toolProvider, s.resourceService, s.resourceScope(), uri)
+}
+
+// resourceScope exposes every integration and the linked account owner's own data
+func (s *UnifiedMCPServer) resourceScope() services.ResourceScope {
+	return services.ResourceScope{OwnerID: s.linkedAccountOwnerID}
+}
+
+// ListPrompts returns the prompts of all integrations
+func (s *UnifiedMCPServer) ListPrompts() ([]interface{}, error) {
+	if !s.initialized {
+		return nil, fmt.Errorf("server not initialized")
+	}
+
+	integrations, err := s.integrationService.ListIntegrations(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list integrations: %w", err)
+	}
+
+	prompts := listPrompts(context.Background(), s.logger, s.promptService, integrations)
+	s.logger.Debug("Returning unified MCP prompts", zap.Int("count", len(prompts)))
+	return prompts, nil
+}
+
+// GetPrompt renders a prompt of any integration
+func (s *UnifiedMCPServer) GetPrompt(name string, arguments map[string]string) (interface{}, error) {
+	if !s.initialized {
+		return nil, fmt.Errorf("server not initialized")
+	}
+
+	s.logger.Debug("Getting unified MCP prompt", zap.String("name", name))
+
+	integrations, err := s.integrationService.ListIntegrations(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list integrations: %w", err)
+	}
+	return getPrompt(context.Background(), s.promptService, integrations, name, arguments)
 }
 
 // Shutdown gracefully shuts down the server
@@ -386,11 +432,17 @@ func (s *UnifiedMCPServer) StartWithTransport(ctx context.Context, transportType
 	return mcpTransport.Start(ctx, s)
 }
 
-// Helper function to check if a string contains a substring (case-insensitive)
-func contains(text, substr string) bool {
-	return len(text) >= len(substr) &&
-		(text == substr ||
-			len(substr) == 0 ||
-			(len(text) > 0 && len(substr) > 0 &&
-				fmt.Sprintf("%s", text) != fmt.Sprintf("%s", substr)))
+// stringList converts a JSON array argument into a string slice, skipping non-string items
+func stringList(value interface{}) []string {
+	list := []string{}
+	items, ok := value.([]interface{})
+	if !ok {
+		return list
+	}
+	for _, item := range items {
+		if str, ok := item.(string); ok {
+			list = append(list, str)
+		}
+	}
+	return list
 }
@@ -0,0 +1,110 @@
+package servers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// sharedNameSearch indexes one get_user tool in each of its apps; unused methods panic.
+type sharedNameSearch struct {
+	services.ToolSearchService
+	apps map[string]primitive.ObjectID
+}
+
+func (s *sharedNameSearch) FindTool(ctx context.Context, name, app string) (*services.ToolSearchHit, error) {
+	if name != "get_user" {
+		return nil, services.ErrToolNotFound
+	}
+	if app == "" {
+		return nil, fmt.Errorf("%w: get_user", services.ErrAmbiguousTool)
+	}
+	for appName, id := range s.apps {
+		if app == appName || app == id.Hex() {
+			return &services.ToolSearchHit{Tool: models.Tool{Name: name}, IntegrationID: id, App: appName}, nil
+		}
+	}
+	return nil, services.ErrToolNotFound
+}
+
+// recordingToolProvider records the integration of each executed tool; unused methods panic.
+type recordingToolProvider struct {
+	services.ToolProvider
+	executed []primitive.ObjectID
+}
+
+func (p *recordingToolProvider) ExecuteTool(ctx context.Context, integrationID primitive.ObjectID, linkedAccountOwnerID string, toolName string, arguments map[string]interface{}) (*models.ToolResult, error) {
+	p.executed = append(p.executed, integrationID)
+	return models.TextResult("ok"), nil
+}
+
+func TestExecuteFunctionWithSharedName(t *testing.T) {
+	github, jira := primitive.NewObjectID(), primitive.NewObjectID()
+	search := &sharedNameSearch{apps: map[string]primitive.ObjectID{"github": github, "jira": jira}}
+	provider := &recordingToolProvider{}
+	server := NewUnifiedMCPServer(zap.NewNop(), nil, provider, search, nil, nil, nil, "user-1", "user-1", false)
+
+	call := func(extra map[string]interface{}) error {
+		arguments := map[string]interface{}{"function_name": "get_user", "function_arguments": map[string]interface{}{}}
+		for k, v := range extra {
+			arguments[k] = v
+		}
+		_, err := server.handleExecuteFunction(arguments)
+		return err
+	}
+
+	if err := call(nil); !errors.Is(err, services.ErrAmbiguousTool) {
+		t.Fatalf("call without app: error = %v, want %v", err, services.ErrAmbiguousTool)
+	}
+	if err := call(map[string]interface{}{"integration_id": jira.Hex()}); err != nil {
+		t.Fatalf("call with integration_id: %v", err)
+	}
+	if err := call(map[string]interface{}{"app": "github"}); err != nil {
+		t.Fatalf("call with app: %v", err)
+	}
+	if fmt.Sprint(provider.executed) != fmt.Sprint([]primitive.ObjectID{jira, github}) {
+		t.Fatalf("executed integrations = %v, want [%s %s]", provider.executed, jira.Hex(), github.Hex())
+	}
+}
+
+// ownOnly lets users act for themselves only.
+type ownOnly struct{}
+
+func (ownOnly) AuthorizeOwner(ctx context.Context, userID, ownerID string) error {
+	if userID != ownerID {
+		return services.ErrOwnerNotManaged
+	}
+	return nil
+}
+
+func TestOwnerOverrideRefusesForeignOwner(t *testing.T) {
+	override := func() map[string]interface{} {
+		return map[string]interface{}{
+			"function_name":                    "get_user",
+			"function_arguments":               map[string]interface{}{},
+			"override_linked_account_owner_id": "user-2",
+		}
+	}
+
+	unified := NewUnifiedMCPServer(zap.NewNop(), nil, nil, nil, nil, nil, ownOnly{}, "user-1", "user-1", false)
+	if _, err := unified.handleExecuteFunction(override()); !errors.Is(err, services.ErrOwnerNotManaged) {
+		t.Errorf("unified server: error = %v, want %v", err, services.ErrOwnerNotManaged)
+	}
+
+	apps := NewAppsMCPServer(zap.NewNop(), nil, nil, nil, nil, ownOnly{}, "user-1", "user-1", []string{"items"})
+	apps.initialized = true
+	if _, err := apps.CallTool("get_user", override()); !errors.Is(err, services.ErrOwnerNotManaged) {
+		t.Errorf("apps server: error = %v, want %v", err, services.ErrOwnerNotManaged)
+	}
+
+	unauthorized := NewUnifiedMCPServer(zap.NewNop(), nil, nil, nil, nil, nil, nil, "user-1", "user-1", false)
+	if _, err := unauthorized.handleExecuteFunction(override()); !errors.Is(err, services.ErrOwnerNotManaged) {
+		t.Errorf("server without authorizer: error = %v, want %v", err, services.ErrOwnerNotManaged)
+	}
+}
@@ -0,0 +1,275 @@
+package httpserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/mcp/client"
+	"github.com/gofiber/fiber/v3"
+)
+
+// App creates the HTTP server of the configured transport.
+func (srv *Server) App() *fiber.App {
+	app := fiber.New(fiber.Config{
+		ReadTimeout:  0, // Event streams stay open
+		WriteTimeout: 0,
+		BodyLimit:    srv.config.BodyLimit,
+	})
+
+	allowHeaders := "Content-Type, Accept, " + SessionHeader + ", Mcp-Protocol-Version"
+	if srv.config.Authenticate != nil {
+		allowHeaders = "Authorization, " + allowHeaders
+	}
+	app.Use(func(c fiber.Ctx) error {
+		c.Set("Access-Control-Allow-Origin", "*")
+		c.Set("Access-Control-Allow-Headers", allowHeaders)
+		c.Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		c.Set("Access-Control-Expose-Headers", SessionHeader)
+		if c.Method() == fiber.MethodOptions {
+			return c.SendStatus(fiber.StatusOK)
+		}
+		return c.Next()
+	})
+
+	app.Get("/health", func(c fiber.Ctx) error {
+		srv.mu.Lock()
+		sessions := len(srv.sessions)
+		srv.mu.Unlock()
+		health := fiber.Map{
+			"status":    "healthy",
+			"transport": srv.config.Transport,
+			"sessions":  sessions,
+		}
+		if srv.config.Health != nil {
+			for key, value := range srv.config.Health() {
+				health[key] = value
+			}
+		}
+		return c.JSON(health)
+	})
+
+	switch srv.config.Transport {
+	case TransportSSE:
+		app.Get("/sse", srv.handleSSE)
+		app.Post("/message", srv.handleSSEMessage)
+	case TransportStreamableHTTP:
+		app.Post("/mcp", srv.handleStreamablePost)
+		app.Get("/mcp", srv.handleStreamableGet)
+		app.Delete("/mcp", srv.handleStreamableDelete)
+	}
+	return app
+}
+
+// authenticate returns the user of the request. It reports false when Config.Authenticate
+// refuses the request.
+func (srv *Server) authenticate(c fiber.Ctx) (interface{}, bool) {
+	if srv.config.Authenticate == nil {
+		return nil, true
+	}
+	return srv.config.Authenticate(c)
+}
+
+func (srv *Server) unauthorized(c fiber.Ctx) error {
+	c.Set(fiber.HeaderWWWAuthenticate, fmt.Sprintf("Bearer realm=%q", srv.config.Realm))
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "a valid bearer token is required"})
+}
+
+// handleSSE opens a session of the SSE transport. The first event names the endpoint
+// the client posts its messages to; the session ends when the stream closes.
+func (srv *Server) handleSSE(c fiber.Ctx) error {
+	user, ok := srv.authenticate(c)
+	if !ok {
+		return srv.unauthorized(c)
+	}
+	s := srv.NewSession(user, false)
+	srv.mu.Lock()
+	s.streaming = true
+	srv.mu.Unlock()
+	// The endpoint is relative, so it resolves correctly behind a path-prefixing proxy.
+	endpoint := fmt.Sprintf("event: endpoint\ndata: message?sessionId=%s\n\n", s.ID)
+	srv.stream(c, s, endpoint, true)
+	return nil
+}
+
+// handleSSEMessage accepts a message or batch for an SSE session. Responses are sent on the stream.
+func (srv *Server) handleSSEMessage(c fiber.Ctx) error {
+	user, ok := srv.authenticate(c)
+	if !ok {
+		return srv.unauthorized(c)
+	}
+	s := srv.session(c.Query("sessionId"), user)
+	if s == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "session not found"})
+	}
+	messages, _, err := client.ParseMessages(c.Body())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(client.ErrorResponse(nil, client.NewError(client.CodeParseError, "Parse error")))
+	}
+	for _, msg := range messages {
+		go func(msg *client.Message) {
+			if response := srv.handle(s, msg); response != nil {
+				srv.Deliver(s, response)
+			}
+		}(msg)
+	}
+	return c.Status(fiber.StatusAccepted).SendString("Accepted")
+}
+
+// handleStreamablePost handles a message or batch of the Streamable HTTP transport. An initialize
+// request opens a session; other messages must name theirs. Requests are answered in the response body.
+func (srv *Server) handleStreamablePost(c fiber.Ctx) error {
+	user, ok := srv.authenticate(c)
+	if !ok {
+		return srv.unauthorized(c)
+	}
+	messages, batch, err := client.ParseMessages(c.Body())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(client.ErrorResponse(nil, client.NewError(client.CodeParseError, "Parse error")))
+	}
+
+	initializing := false
+	for _, msg := range messages {
+		if msg.IsRequest() && msg.Method == "initialize" {
+			initializing = true
+		}
+	}
+
+	var s *Session
+	if initializing {
+		if len(messages) > 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(client.ErrorResponse(nil, client.NewError(client.CodeInvalidRequest, "initialize must be sent on its own")))
+		}
+		s = srv.NewSession(user, true)
+	} else {
+		id := c.Get(SessionHeader)
+		if id == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(client.ErrorResponse(nil, client.NewError(client.CodeInvalidRequest, "missing %s header", SessionHeader)))
+		}
+		if s = srv.session(id, user); s == nil {
+			return c.Status(fiber.StatusNotFound).JSON(client.ErrorResponse(nil, client.NewError(client.CodeInvalidRequest, "session not found")))
+		}
+	}
+
+	// Requests of a batch are handled concurrently; responses keep the order of the requests.
+	responses := make([]*client.Message, len(messages))
+	var wg sync.WaitGroup
+	for i, msg := range messages {
+		wg.Add(1)
+		go func(i int, msg *client.Message) {
+			defer wg.Done()
+			responses[i] = srv.handle(s, msg)
+		}(i, msg)
+	}
+	wg.Wait()
+
+	answered := make([]*client.Message, 0, len(responses))
+	for _, response := range responses {
+		if response != nil {
+			answered = append(answered, response)
+		}
+	}
+	if initializing {
+		if len(answered) == 1 && answered[0].Error == nil {
+			c.Set(SessionHeader, s.ID)
+		} else {
+			srv.CloseSession(s)
+		}
+	}
+	if len(answered) == 0 {
+		return c.SendStatus(fiber.StatusAccepted)
+	}
+	if batch {
+		return c.JSON(answered)
+	}
+	return c.JSON(answered[0])
+}
+
+// handleStreamableGet opens the event stream of a Streamable HTTP session, which carries
+// notifications and requests from the server. A session has at most one such stream.
+func (srv *Server) handleStreamableGet(c fiber.Ctx) error {
+	user, ok := srv.authenticate(c)
+	if !ok {
+		return srv.unauthorized(c)
+	}
+	s := srv.session(c.Get(SessionHeader), user)
+	if s == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "session not found"})
+	}
+	srv.mu.Lock()
+	if s.streaming {
+		srv.mu.Unlock()
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "the session already has an open stream"})
+	}
+	s.streaming = true
+	srv.mu.Unlock()
+	srv.stream(c, s, "", false)
+	return nil
+}
+
+// handleStreamableDelete ends a Streamable HTTP session.
+func (srv *Server) handleStreamableDelete(c fiber.Ctx) error {
+	user, ok := srv.authenticate(c)
+	if !ok {
+		return srv.unauthorized(c)
+	}
+	s := srv.session(c.Get(SessionHeader), user)
+	if s == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "session not found"})
+	}
+	srv.CloseSession(s)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// stream relays the queued messages of s as server-sent events until the session closes or
+// the client goes away. first is written before any message. With closeOnEnd the session
+// ends with the stream; otherwise it is only marked as no longer streaming.
+func (srv *Server) stream(c fiber.Ctx, s *Session, first string, closeOnEnd bool) {
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // Disable nginx buffering
+
+	c.Response().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer func() {
+			srv.mu.Lock()
+			s.streaming = false
+			s.lastSeen = time.Now()
+			srv.mu.Unlock()
+			if closeOnEnd {
+				srv.CloseSession(s)
+			}
+		}()
+
+		if first != "" {
+			if _, err := w.WriteString(first); err != nil || w.Flush() != nil {
+				return
+			}
+		}
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case msg := <-s.out:
+				data, err := json.Marshal(msg)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+				if err := w.Flush(); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				// A comment keeps proxies from timing out and detects clients that went away.
+				if _, err := w.WriteString(": ping\n\n"); err != nil || w.Flush() != nil {
+					return
+				}
+			case <-s.closed:
+				return
+			}
+		}
+	})
+}
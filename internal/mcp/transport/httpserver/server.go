@@ -0,0 +1,270 @@
+// Package httpserver serves MCP sessions over HTTP, with the HTTP+SSE transport of MCP
+// 2024-11-05 or the Streamable HTTP transport of MCP 2025-03-26.
+//
+// A Server keeps the sessions of remote clients, queues the messages sent to them for their
+// event streams and ends Streamable HTTP sessions that stay unused. What the messages of a
+// session mean is up to the Server's Handler.
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/mcp/client"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Transports a Server can serve.
+const (
+	// TransportSSE is the HTTP+SSE transport of MCP 2024-11-05: GET /sse and POST /message.
+	TransportSSE = "sse"
+	// TransportStreamableHTTP is the Streamable HTTP transport of MCP 2025-03-26 at /mcp.
+	TransportStreamableHTTP = "streamable-http"
+)
+
+// SessionHeader carries the session ID of the Streamable HTTP transport.
+const SessionHeader = "Mcp-Session-Id"
+
+const (
+	// sessionQueueSize is the number of undelivered messages kept per session.
+	sessionQueueSize = 1024
+	// sessionIdleTimeout is how long a Streamable HTTP session without an open stream may stay unused.
+	sessionIdleTimeout = 30 * time.Minute
+	// heartbeatInterval is how often idle event streams get a comment, which also detects gone clients.
+	heartbeatInterval = 15 * time.Second
+)
+
+// Handler handles the messages of a Server's sessions.
+type Handler interface {
+	// Handle handles a message of s and returns the response to send, if any. Messages that
+	// are not valid JSON-RPC are answered by the Server and never reach Handle. The context
+	// of a request ends after the RequestTimeout, when the session closes or when the request
+	// is cancelled with Cancel.
+	Handle(ctx context.Context, s *Session, msg *client.Message) *client.Message
+	// SessionClosed is called once after s has ended.
+	SessionClosed(s *Session)
+}
+
+// Config describes how a Server is exposed.
+type Config struct {
+	Transport string
+	// BodyLimit bounds a message or batch posted by a client.
+	BodyLimit int
+	// RequestTimeout bounds the handling of a single request.
+	RequestTimeout time.Duration
+	// Authenticate, when set, returns the user a request acts for, who alone may use the
+	// sessions they open. Requests it refuses are answered with a bearer challenge for Realm.
+	Authenticate func(c fiber.Ctx) (interface{}, bool)
+	Realm        string
+	// Health adds fields to the /health response.
+	Health func() fiber.Map
+}
+
+// Session is one remote client. Fields other than ID and User are guarded by Server.mu.
+type Session struct {
+	ID string
+	// User is the user Config.Authenticate returned for the request that opened the session.
+	User interface{}
+
+	// out queues the messages for the session's event stream.
+	out       chan *client.Message
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	// inflight cancels the requests being handled, keyed by their ID.
+	inflight map[string]context.CancelFunc
+	// streaming is set while an event stream delivers the session's messages.
+	streaming bool
+	lastSeen  time.Time
+	// reapable sessions end after sessionIdleTimeout without use; SSE sessions end with their stream instead.
+	reapable bool
+}
+
+// Done is closed when the session ends.
+func (s *Session) Done() <-chan struct{} {
+	return s.closed
+}
+
+// Closed reports whether the session has ended.
+func (s *Session) Closed() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Messages returns the queue of messages for the session's event stream.
+func (s *Session) Messages() <-chan *client.Message {
+	return s.out
+}
+
+// Server serves the sessions of remote clients to a Handler.
+type Server struct {
+	config  Config
+	handler Handler
+	logger  *zap.Logger
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+// New creates a Server for config whose messages are handled by handler. Serve App to run it.
+func New(config Config, handler Handler, logger *zap.Logger) *Server {
+	return &Server{
+		config:   config,
+		handler:  handler,
+		logger:   logger,
+		sessions: make(map[string]*Session),
+	}
+}
+
+// NewSession registers a new session of user. Reapable sessions end after they stay unused.
+func (srv *Server) NewSession(user interface{}, reapable bool) *Session {
+	s := &Session{
+		ID:       uuid.NewString(),
+		User:     user,
+		out:      make(chan *client.Message, sessionQueueSize),
+		closed:   make(chan struct{}),
+		inflight: make(map[string]context.CancelFunc),
+		lastSeen: time.Now(),
+		reapable: reapable,
+	}
+	srv.mu.Lock()
+	srv.sessions[s.ID] = s
+	srv.mu.Unlock()
+	return s
+}
+
+// session returns the session with id if it belongs to user, and marks it as used.
+func (srv *Server) session(id string, user interface{}) *Session {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	s, ok := srv.sessions[id]
+	if !ok || s.User != user {
+		return nil
+	}
+	s.lastSeen = time.Now()
+	return s
+}
+
+// Sessions returns the open sessions.
+func (srv *Server) Sessions() []*Session {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	sessions := make([]*Session, 0, len(srv.sessions))
+	for _, s := range srv.sessions {
+		sessions = append(sessions, s)
+	}
+	return sessions
+}
+
+// Deliver queues a message for the session's event stream.
+func (srv *Server) Deliver(s *Session, msg *client.Message) {
+	if s.Closed() {
+		return
+	}
+	select {
+	case s.out <- msg:
+	default:
+		srv.logger.Warn("Dropping message for slow session", zap.String("sessionID", s.ID), zap.String("method", msg.Method))
+	}
+}
+
+// handle validates a message of s and hands it to the Handler, tracking requests while they
+// are handled.
+func (srv *Server) handle(s *Session, msg *client.Message) *client.Message {
+	if msg.JSONRPC != "2.0" || (msg.Method == "" && len(msg.ID) == 0) {
+		return client.ErrorResponse(msg.ID, client.NewError(client.CodeInvalidRequest, "Invalid Request"))
+	}
+	if !msg.IsRequest() {
+		return srv.handler.Handle(context.Background(), s, msg)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), srv.config.RequestTimeout)
+	defer cancel()
+	if !srv.track(s, msg.ID, cancel) {
+		return client.ErrorResponse(msg.ID, client.NewError(client.CodeInvalidRequest, "a request with this ID is already in flight"))
+	}
+	defer srv.untrack(s, msg.ID)
+	return srv.handler.Handle(ctx, s, msg)
+}
+
+// track registers a request being handled. It reports false when the session already
+// has a request with that ID in flight.
+func (srv *Server) track(s *Session, id json.RawMessage, cancel context.CancelFunc) bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if _, exists := s.inflight[string(id)]; exists {
+		return false
+	}
+	s.inflight[string(id)] = cancel
+	// Requests of a session that already ended are cancelled right away.
+	if srv.sessions[s.ID] != s {
+		cancel()
+	}
+	return true
+}
+
+func (srv *Server) untrack(s *Session, id json.RawMessage) {
+	srv.mu.Lock()
+	delete(s.inflight, string(id))
+	s.lastSeen = time.Now()
+	srv.mu.Unlock()
+}
+
+// Cancel cancels the context of a request the session has in flight.
+func (srv *Server) Cancel(s *Session, id json.RawMessage) {
+	srv.mu.Lock()
+	cancel := s.inflight[string(id)]
+	srv.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// CloseSession ends a session and cancels its requests.
+func (srv *Server) CloseSession(s *Session) {
+	srv.mu.Lock()
+	if srv.sessions[s.ID] != s {
+		srv.mu.Unlock()
+		return
+	}
+	delete(srv.sessions, s.ID)
+	for _, cancel := range s.inflight {
+		cancel()
+	}
+	srv.mu.Unlock()
+
+	s.closeOnce.Do(func() { close(s.closed) })
+	srv.handler.SessionClosed(s)
+}
+
+// CloseAllSessions ends every session.
+func (srv *Server) CloseAllSessions() {
+	for _, s := range srv.Sessions() {
+		srv.CloseSession(s)
+	}
+}
+
+// ReapIdleSessions ends Streamable HTTP sessions that were not used for sessionIdleTimeout.
+func (srv *Server) ReapIdleSessions() {
+	cutoff := time.Now().Add(-sessionIdleTimeout)
+	srv.mu.Lock()
+	var idle []*Session
+	for _, s := range srv.sessions {
+		if s.reapable && !s.streaming && len(s.inflight) == 0 && s.lastSeen.Before(cutoff) {
+			idle = append(idle, s)
+		}
+	}
+	srv.mu.Unlock()
+	for _, s := range idle {
+		srv.logger.Debug("Closing idle session", zap.String("sessionID", s.ID))
+		srv.CloseSession(s)
+	}
+}
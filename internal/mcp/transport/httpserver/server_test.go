@@ -0,0 +1,143 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/mcp/client"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// echoHandler answers every request with its method. Requests for "block" wait until their
+// context ends.
+type echoHandler struct {
+	mu     sync.Mutex
+	closed []*Session
+}
+
+func (h *echoHandler) Handle(ctx context.Context, s *Session, msg *client.Message) *client.Message {
+	if !msg.IsRequest() {
+		return nil
+	}
+	if msg.Method == "block" {
+		<-ctx.Done()
+		return client.ErrorResponse(msg.ID, ctx.Err())
+	}
+	result, _ := json.Marshal(msg.Method)
+	return &client.Message{JSONRPC: "2.0", ID: msg.ID, Result: result}
+}
+
+func (h *echoHandler) SessionClosed(s *Session) {
+	h.mu.Lock()
+	h.closed = append(h.closed, s)
+	h.mu.Unlock()
+}
+
+func TestStreamableHTTPSessions(t *testing.T) {
+	handler := &echoHandler{}
+	srv := New(Config{
+		Transport:      TransportStreamableHTTP,
+		RequestTimeout: time.Minute,
+		Authenticate: func(c fiber.Ctx) (interface{}, bool) {
+			user := strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+			return user, user == "alice" || user == "bob"
+		},
+		Realm: "test",
+	}, handler, zap.NewNop())
+	app := srv.App()
+	send := func(method, user, sessionID, body string) *http.Response {
+		t.Helper()
+		req := httptest.NewRequest(method, "/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+user)
+		if sessionID != "" {
+			req.Header.Set(SessionHeader, sessionID)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize"}`
+	if resp := send(http.MethodPost, "mallory", "", initialize); resp.StatusCode != http.StatusUnauthorized ||
+		resp.Header.Get(fiber.HeaderWWWAuthenticate) != `Bearer realm="test"` {
+		t.Fatalf("initialize with an unknown token = %d %q", resp.StatusCode, resp.Header.Get(fiber.HeaderWWWAuthenticate))
+	}
+	resp := send(http.MethodPost, "alice", "", initialize)
+	sessionID := resp.Header.Get(SessionHeader)
+	if resp.StatusCode != http.StatusOK || sessionID == "" {
+		t.Fatalf("initialize = %d, session %q", resp.StatusCode, sessionID)
+	}
+
+	// Notifications are not answered and responses keep the order of the requests.
+	resp = send(http.MethodPost, "alice", sessionID, `[{"jsonrpc":"2.0","id":2,"method":"tools/list"},`+
+		`{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","id":3,"method":"ping"},{"id":4}]`)
+	var replies []client.Message
+	if err := json.NewDecoder(resp.Body).Decode(&replies); err != nil {
+		t.Fatal(err)
+	}
+	if len(replies) != 3 || string(replies[0].Result) != `"tools/list"` || string(replies[1].Result) != `"ping"` ||
+		replies[2].Error == nil || replies[2].Error.Code != client.CodeInvalidRequest {
+		t.Fatalf("batch replies = %+v", replies)
+	}
+
+	if resp := send(http.MethodPost, "bob", sessionID, `{"jsonrpc":"2.0","id":5,"method":"ping"}`); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("bob using the session of alice = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if resp := send(http.MethodDelete, "alice", sessionID, ""); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+	if resp := send(http.MethodPost, "alice", sessionID, `{"jsonrpc":"2.0","id":6,"method":"ping"}`); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("request on a closed session = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if len(handler.closed) != 1 || handler.closed[0].ID != sessionID {
+		t.Fatalf("closed sessions = %v, want the deleted one", handler.closed)
+	}
+}
+
+func TestCloseSessionCancelsRequests(t *testing.T) {
+	srv := New(Config{Transport: TransportSSE, RequestTimeout: time.Minute}, &echoHandler{}, zap.NewNop())
+	s := srv.NewSession(nil, false)
+	block := &client.Message{JSONRPC: "2.0", ID: json.RawMessage("1"), Method: "block"}
+	done := make(chan *client.Message, 1)
+	go func() { done <- srv.handle(s, block) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		srv.mu.Lock()
+		tracked := len(s.inflight)
+		srv.mu.Unlock()
+		if tracked == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the request was not tracked")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if reply := srv.handle(s, block); reply.Error == nil || reply.Error.Code != client.CodeInvalidRequest {
+		t.Fatalf("second request with the same ID = %+v, want an invalid request", reply)
+	}
+
+	srv.CloseSession(s)
+	select {
+	case reply := <-done:
+		if reply.Error == nil {
+			t.Fatalf("request of a closed session = %+v, want an error", reply)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("closing the session did not cancel its request")
+	}
+	if !s.Closed() {
+		t.Fatal("the session is not closed")
+	}
+}
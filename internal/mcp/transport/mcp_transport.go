@@ -1,14 +1,13 @@
 package transport
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"os"
 	"sync"
 
+	"github.com/AkashKesav/API2SDK/internal/models"
 	"github.com/gofiber/fiber/v3"
 	"go.uber.org/zap"
 )
@@ -30,6 +29,37 @@ type MCPError struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+func (e *MCPError) Error() string {
+	if e.Data != nil {
+		return fmt.Sprintf("%s (code %d): %v", e.Message, e.Code, e.Data)
+	}
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// InvalidParams marks err as caused by the request's parameters, such as an unknown
+// prompt name, so that it is answered with an invalid params error.
+func InvalidParams(err error) error {
+	return &MCPError{Code: codeInvalidParams, Message: err.Error()}
+}
+
+// ResourceNotFound marks err as caused by a resource URI that names no resource.
+func ResourceNotFound(err error) error {
+	return &MCPError{Code: codeResourceNotFound, Message: err.Error()}
+}
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+	// codeResourceNotFound is the MCP error for resources/read of an unknown URI.
+	codeResourceNotFound = -32002
+	// codeServerBusy refuses requests the server has no room to queue.
+	codeServerBusy = -32000
+)
+
 // MCPServer interface that MCP servers must implement
 type MCPServer interface {
 	Initialize(params map[string]interface{}) (map[string]interface{}, error)
@@ -37,6 +67,9 @@ type MCPServer interface {
 	CallTool(name string, arguments map[string]interface{}) (interface{}, error)
 	ListResources() ([]interface{}, error)
 	ReadResource(uri string) (interface{}, error)
+	ListResourceTemplates() ([]interface{}, error)
+	ListPrompts() ([]interface{}, error)
+	GetPrompt(name string, arguments map[string]string) (interface{}, error)
 	Shutdown() error
 }
 
@@ -47,231 +80,6 @@ type MCPTransport interface {
 	SendMessage(message MCPMessage) error
 }
 
-// StdioTransport implements MCP over stdio
-type StdioTransport struct {
-	logger *zap.Logger
-	reader *bufio.Scanner
-	writer io.Writer
-	server MCPServer
-	ctx    context.Context
-	cancel context.CancelFunc
-	mu     sync.Mutex
-}
-
-// NewStdioTransport creates a new stdio transport
-func NewStdioTransport(logger *zap.Logger) *StdioTransport {
-	return &StdioTransport{
-		logger: logger,
-		reader: bufio.NewScanner(os.Stdin),
-		writer: os.Stdout,
-	}
-}
-
-// Start begins the stdio transport loop
-func (t *StdioTransport) Start(ctx context.Context, server MCPServer) error {
-	t.ctx, t.cancel = context.WithCancel(ctx)
-	t.server = server
-
-	t.logger.Info("Starting MCP stdio transport")
-
-	// Send initialization
-	initMessage := MCPMessage{
-		JSONRPC: "2.0",
-		Method:  "initialize",
-		ID:      1,
-		Params: map[string]interface{}{
-			"protocolVersion": "2024-11-05",
-			"capabilities": map[string]interface{}{
-				"tools":     map[string]interface{}{},
-				"resources": map[string]interface{}{},
-			},
-		},
-	}
-
-	if err := t.SendMessage(initMessage); err != nil {
-		return fmt.Errorf("failed to send initialization: %w", err)
-	}
-
-	// Start message processing loop
-	go t.messageLoop()
-
-	return nil
-}
-
-// messageLoop processes incoming messages
-func (t *StdioTransport) messageLoop() {
-	for t.reader.Scan() {
-		select {
-		case <-t.ctx.Done():
-			return
-		default:
-		}
-
-		line := t.reader.Text()
-		if line == "" {
-			continue
-		}
-
-		var message MCPMessage
-		if err := json.Unmarshal([]byte(line), &message); err != nil {
-			t.logger.Error("Failed to parse MCP message", zap.Error(err), zap.String("line", line))
-			continue
-		}
-
-		t.handleMessage(message)
-	}
-
-	if err := t.reader.Err(); err != nil {
-		t.logger.Error("Stdio scanner error", zap.Error(err))
-	}
-}
-
-// handleMessage processes individual MCP messages
-func (t *StdioTransport) handleMessage(message MCPMessage) {
-	response := MCPMessage{
-		JSONRPC: "2.0",
-		ID:      message.ID,
-	}
-
-	switch message.Method {
-	case "initialize":
-		params := make(map[string]interface{})
-		if message.Params != nil {
-			if p, ok := message.Params.(map[string]interface{}); ok {
-				params = p
-			}
-		}
-
-		result, err := t.server.Initialize(params)
-		if err != nil {
-			response.Error = &MCPError{
-				Code:    -32603,
-				Message: "Internal error during initialization",
-				Data:    err.Error(),
-			}
-		} else {
-			response.Result = result
-		}
-
-	case "tools/list":
-		tools, err := t.server.ListTools()
-		if err != nil {
-			response.Error = &MCPError{
-				Code:    -32603,
-				Message: "Failed to list tools",
-				Data:    err.Error(),
-			}
-		} else {
-			response.Result = map[string]interface{}{
-				"tools": tools,
-			}
-		}
-
-	case "tools/call":
-		params := make(map[string]interface{})
-		if message.Params != nil {
-			if p, ok := message.Params.(map[string]interface{}); ok {
-				params = p
-			}
-		}
-
-		name, _ := params["name"].(string)
-		arguments := make(map[string]interface{})
-		if args, ok := params["arguments"].(map[string]interface{}); ok {
-			arguments = args
-		}
-
-		result, err := t.server.CallTool(name, arguments)
-		if err != nil {
-			response.Error = &MCPError{
-				Code:    -32603,
-				Message: "Tool execution failed",
-				Data:    err.Error(),
-			}
-		} else {
-			response.Result = map[string]interface{}{
-				"content": []map[string]interface{}{
-					{
-						"type": "text",
-						"text": fmt.Sprintf("%v", result),
-					},
-				},
-			}
-		}
-
-	case "resources/list":
-		resources, err := t.server.ListResources()
-		if err != nil {
-			response.Error = &MCPError{
-				Code:    -32603,
-				Message: "Failed to list resources",
-				Data:    err.Error(),
-			}
-		} else {
-			response.Result = map[string]interface{}{
-				"resources": resources,
-			}
-		}
-
-	case "resources/read":
-		params := make(map[string]interface{})
-		if message.Params != nil {
-			if p, ok := message.Params.(map[string]interface{}); ok {
-				params = p
-			}
-		}
-
-		uri, _ := params["uri"].(string)
-		result, err := t.server.ReadResource(uri)
-		if err != nil {
-			response.Error = &MCPError{
-				Code:    -32603,
-				Message: "Failed to read resource",
-				Data:    err.Error(),
-			}
-		} else {
-			response.Result = result
-		}
-
-	default:
-		response.Error = &MCPError{
-			Code:    -32601,
-			Message: "Method not found",
-			Data:    message.Method,
-		}
-	}
-
-	if err := t.SendMessage(response); err != nil {
-		t.logger.Error("Failed to send response", zap.Error(err))
-	}
-}
-
-// SendMessage sends an MCP message via stdio
-func (t *StdioTransport) SendMessage(message MCPMessage) error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	data, err := json.Marshal(message)
-	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
-	}
-
-	_, err = fmt.Fprintln(t.writer, string(data))
-	if err != nil {
-		return fmt.Errorf("failed to write message: %w", err)
-	}
-
-	return nil
-}
-
-// Stop stops the stdio transport
-func (t *StdioTransport) Stop() error {
-	if t.cancel != nil {
-		t.cancel()
-	}
-	return nil
-}
-
 // SSETransport implements MCP over Server-Sent Events using Fiber v3
 type SSETransport struct {
 	logger *zap.Logger
@@ -291,7 +99,8 @@ func NewSSETransport(port int, logger *zap.Logger) *SSETransport {
 	}
 }
 
-// Start begins the SSE transport server using Fiber v3
+// Start begins the SSE transport server using Fiber v3. It blocks until ctx is
+// cancelled or the server fails.
 func (t *SSETransport) Start(ctx context.Context, server MCPServer) error {
 	t.ctx, t.cancel = context.WithCancel(ctx)
 	t.server = server
@@ -333,15 +142,22 @@ func (t *SSETransport) Start(ctx context.Context, server MCPServer) error {
 	t.logger.Info("Starting MCP SSE transport with Fiber v3", zap.Int("port", t.port))
 
 	// Start server in goroutine
+	listenErr := make(chan error, 1)
 	go func() {
-		if err := t.app.Listen(fmt.Sprintf(":%d", t.port)); err != nil {
-			t.logger.Error("SSE transport server error", zap.Error(err))
-		}
+		listenErr <- t.app.Listen(fmt.Sprintf(":%d", t.port), fiber.ListenConfig{DisableStartupMessage: true})
 	}()
 
-	// Wait for context cancellation
-	<-t.ctx.Done()
-	return t.app.Shutdown()
+	// Serve until cancelled or the listener fails
+	select {
+	case <-t.ctx.Done():
+		return t.app.Shutdown()
+	case err := <-listenErr:
+		if err == nil {
+			err = fmt.Errorf("listener on port %d closed", t.port)
+		}
+		t.logger.Error("SSE transport server error", zap.Error(err))
+		return fmt.Errorf("SSE transport on port %d failed: %w", t.port, err)
+	}
 }
 
 // handleSSE handles SSE connections using Fiber v3
@@ -404,115 +220,177 @@ func (t *SSETransport) processMessage(message MCPMessage) MCPMessage {
 		ID:      message.ID,
 	}
 
-	switch message.Method {
-	case "initialize":
-		params := make(map[string]interface{})
-		if message.Params != nil {
-			if p, ok := message.Params.(map[string]interface{}); ok {
-				params = p
-			}
-		}
+	params := make(map[string]interface{})
+	if p, ok := message.Params.(map[string]interface{}); ok {
+		params = p
+	}
+	result, mcpErr := dispatch(t.server, message.Method, params)
+	if mcpErr != nil {
+		response.Error = mcpErr
+	} else {
+		response.Result = result
+	}
+	return response
+}
 
-		result, err := t.server.Initialize(params)
+// dispatch calls the server method of an MCP request and returns its result or error.
+func dispatch(server MCPServer, method string, params map[string]interface{}) (interface{}, *MCPError) {
+	switch method {
+	case "initialize":
+		result, err := server.Initialize(params)
 		if err != nil {
-			response.Error = &MCPError{
-				Code:    -32603,
+			return nil, &MCPError{
+				Code:    codeInternalError,
 				Message: "Internal error during initialization",
 				Data:    err.Error(),
 			}
-		} else {
-			response.Result = result
 		}
+		return result, nil
+
+	case "ping":
+		return map[string]interface{}{}, nil
 
 	case "tools/list":
-		tools, err := t.server.ListTools()
+		tools, err := server.ListTools()
 		if err != nil {
-			response.Error = &MCPError{
-				Code:    -32603,
+			return nil, &MCPError{
+				Code:    codeInternalError,
 				Message: "Failed to list tools",
 				Data:    err.Error(),
 			}
-		} else {
-			response.Result = map[string]interface{}{
-				"tools": tools,
-			}
 		}
+		return map[string]interface{}{
+			"tools": tools,
+		}, nil
 
 	case "tools/call":
-		params := make(map[string]interface{})
-		if message.Params != nil {
-			if p, ok := message.Params.(map[string]interface{}); ok {
-				params = p
+		name, _ := params["name"].(string)
+		if name == "" {
+			return nil, &MCPError{
+				Code:    codeInvalidParams,
+				Message: "Tool name is required",
 			}
 		}
-
-		name, _ := params["name"].(string)
 		arguments := make(map[string]interface{})
 		if args, ok := params["arguments"].(map[string]interface{}); ok {
 			arguments = args
 		}
 
-		result, err := t.server.CallTool(name, arguments)
+		result, err := server.CallTool(name, arguments)
 		if err != nil {
-			response.Error = &MCPError{
-				Code:    -32603,
+			return nil, &MCPError{
+				Code:    codeInternalError,
 				Message: "Tool execution failed",
 				Data:    err.Error(),
 			}
-		} else {
-			response.Result = map[string]interface{}{
-				"content": []map[string]interface{}{
-					{
-						"type": "text",
-						"text": fmt.Sprintf("%v", result),
-					},
-				},
-			}
 		}
+		return toolResult(result), nil
 
 	case "resources/list":
-		resources, err := t.server.ListResources()
+		resources, err := server.ListResources()
 		if err != nil {
-			response.Error = &MCPError{
-				Code:    -32603,
+			return nil, &MCPError{
+				Code:    codeInternalError,
 				Message: "Failed to list resources",
 				Data:    err.Error(),
 			}
-		} else {
-			response.Result = map[string]interface{}{
-				"resources": resources,
-			}
 		}
+		return map[string]interface{}{
+			"resources": resources,
+		}, nil
 
-	case "resources/read":
-		params := make(map[string]interface{})
-		if message.Params != nil {
-			if p, ok := message.Params.(map[string]interface{}); ok {
-				params = p
+	case "resources/templates/list":
+		templates, err := server.ListResourceTemplates()
+		if err != nil {
+			return nil, &MCPError{
+				Code:    codeInternalError,
+				Message: "Failed to list resource templates",
+				Data:    err.Error(),
 			}
 		}
+		return map[string]interface{}{
+			"resourceTemplates": templates,
+		}, nil
 
+	case "resources/read":
 		uri, _ := params["uri"].(string)
-		result, err := t.server.ReadResource(uri)
+		if uri == "" {
+			return nil, &MCPError{
+				Code:    codeInvalidParams,
+				Message: "Resource URI is required",
+			}
+		}
+		result, err := server.ReadResource(uri)
 		if err != nil {
-			response.Error = &MCPError{
-				Code:    -32603,
+			var mcpErr *MCPError
+			if errors.As(err, &mcpErr) {
+				return nil, mcpErr
+			}
+			return nil, &MCPError{
+				Code:    codeInternalError,
 				Message: "Failed to read resource",
 				Data:    err.Error(),
 			}
-		} else {
-			response.Result = result
+		}
+		return result, nil
+
+	case "prompts/list":
+		prompts, err := server.ListPrompts()
+		if err != nil {
+			return nil, &MCPError{
+				Code:    codeInternalError,
+				Message: "Failed to list prompts",
+				Data:    err.Error(),
+			}
+		}
+		return map[string]interface{}{
+			"prompts": prompts,
+		}, nil
+
+	case "prompts/get":
+		name, _ := params["name"].(string)
+		if name == "" {
+			return nil, &MCPError{
+				Code:    codeInvalidParams,
+				Message: "Prompt name is required",
+			}
+		}
+		arguments := make(map[string]string)
+		if args, ok := params["arguments"].(map[string]interface{}); ok {
+			for key, value := range args {
+				str, ok := value.(string)
+				if !ok {
+					return nil, &MCPError{
+						Code:    codeInvalidParams,
+						Message: "Prompt arguments must be strings",
+						Data:    key,
+					}
+				}
+				arguments[key] = str
+			}
 		}
 
+		result, err := server.GetPrompt(name, arguments)
+		if err != nil {
+			var mcpErr *MCPError
+			if errors.As(err, &mcpErr) {
+				return nil, mcpErr
+			}
+			return nil, &MCPError{
+				Code:    codeInternalError,
+				Message: "Failed to get prompt",
+				Data:    err.Error(),
+			}
+		}
+		return result, nil
+
 	default:
-		response.Error = &MCPError{
-			Code:    -32601,
+		return nil, &MCPError{
+			Code:    codeMethodNotFound,
 			Message: "Method not found",
-			Data:    message.Method,
+			Data:    method,
 		}
 	}
-
-	return response
 }
 
 // SendMessage sends an MCP message via SSE (typically not used in SSE pattern)
@@ -536,3 +414,16 @@ func (t *SSETransport) Stop() error {
 
 	return nil
 }
+
+// toolResult renders the result of MCPServer.CallTool as an MCP tool result. Results the
+// server already shaped are sent as they are, and anything else becomes a JSON text block.
+func toolResult(result interface{}) interface{} {
+	switch v := result.(type) {
+	case *models.ToolResult:
+		return v
+	case string:
+		return models.TextResult(v)
+	default:
+		return models.JSONResult(v)
+	}
+}
@@ -0,0 +1,542 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxMessageBytes bounds a single line of input. Longer lines are skipped and answered
+	// with an error, so the messages after them are still read intact.
+	maxMessageBytes = 16 << 20
+	// maxConcurrentRequests bounds the requests handled at the same time.
+	maxConcurrentRequests = 64
+	// maxQueuedRequests bounds the requests waiting for a handler. Reading never waits for one,
+	// so that responses to the server's own requests still arrive while every handler waits
+	// for them; requests beyond the queue are refused.
+	maxQueuedRequests = 1024
+)
+
+var (
+	errMessageTooLarge = errors.New("message too large")
+	// ErrTransportClosed is returned for requests to the client once the transport has stopped.
+	ErrTransportClosed = errors.New("transport closed")
+)
+
+// StdioTransport implements MCP over stdio. Every line of input is a message or a batch.
+// Requests are handled concurrently, except initialize, which completes before anything
+// read after it is handled; responses are written whole, one at a time.
+type StdioTransport struct {
+	logger *zap.Logger
+	reader *bufio.Reader
+	writer io.Writer
+	server MCPServer
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+
+	slots    chan struct{}
+	queue    chan struct{}
+	inflight sync.WaitGroup
+
+	requestsMu sync.Mutex
+	// active holds the requests being handled, by ID, and whether the client cancelled them.
+	active map[string]bool
+	// pending holds the requests sent to the client that await a response, by ID.
+	pending map[string]chan *wireMessage
+	nextID  int64
+}
+
+// wireMessage is an incoming message with its fields kept raw, so that invalid messages
+// can be told apart from valid ones.
+type wireMessage struct {
+	JSONRPC json.RawMessage `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  json.RawMessage `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	Result  json.RawMessage `json:"result"`
+	Error   json.RawMessage `json:"error"`
+}
+
+// request is a valid request from the client.
+type request struct {
+	id     json.RawMessage
+	key    string
+	method string
+	params json.RawMessage
+}
+
+// NewStdioTransport creates a new stdio transport
+func NewStdioTransport(logger *zap.Logger) *StdioTransport {
+	return NewStdioTransportWithIO(os.Stdin, os.Stdout, logger)
+}
+
+// NewStdioTransportWithIO creates a stdio transport that reads messages from r and writes
+// them to w instead of the process's stdin and stdout, e.g. to serve over pipes in-process.
+func NewStdioTransportWithIO(r io.Reader, w io.Writer, logger *zap.Logger) *StdioTransport {
+	return &StdioTransport{
+		logger:  logger,
+		reader:  bufio.NewReaderSize(r, 64*1024),
+		writer:  w,
+		slots:   make(chan struct{}, maxConcurrentRequests),
+		queue:   make(chan struct{}, maxConcurrentRequests+maxQueuedRequests),
+		active:  make(map[string]bool),
+		pending: make(map[string]chan *wireMessage),
+	}
+}
+
+// Start begins the stdio transport loop. It blocks until ctx is cancelled or the input is closed.
+func (t *StdioTransport) Start(ctx context.Context, server MCPServer) error {
+	t.requestsMu.Lock()
+	t.ctx, t.cancel = context.WithCancel(ctx)
+	t.requestsMu.Unlock()
+	t.server = server
+
+	t.logger.Info("Starting MCP stdio transport")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- t.messageLoop()
+	}()
+
+	// Serve until cancelled or the input is closed
+	select {
+	case <-t.ctx.Done():
+		return nil
+	case err := <-done:
+		// Requests already read are still answered.
+		t.inflight.Wait()
+		t.cancel()
+		if err != nil {
+			return fmt.Errorf("stdio input failed: %w", err)
+		}
+		return fmt.Errorf("stdio input closed")
+	}
+}
+
+// messageLoop reads and handles messages until the input ends or the transport stops.
+func (t *StdioTransport) messageLoop() error {
+	for {
+		line, err := readLine(t.reader, maxMessageBytes)
+		if errors.Is(err, errMessageTooLarge) {
+			t.logger.Warn("Skipping oversized MCP message", zap.Int("limit", maxMessageBytes))
+			t.write(errorResponse(nil, codeInvalidRequest, "Message too large", fmt.Sprintf("messages are limited to %d bytes", maxMessageBytes)))
+			continue
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			t.logger.Error("Stdio read error", zap.Error(err))
+			return err
+		}
+		if t.ctx.Err() != nil {
+			return nil
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		t.handleLine(line)
+	}
+}
+
+// readLine reads one line of at most limit bytes. A longer line is consumed and reported
+// as errMessageTooLarge.
+func readLine(r *bufio.Reader, limit int) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if len(line)+len(chunk) > limit {
+			for errors.Is(err, bufio.ErrBufferFull) {
+				_, err = r.ReadSlice('\n')
+			}
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			return nil, errMessageTooLarge
+		}
+		line = append(line, chunk...)
+		switch {
+		case err == nil:
+			return line, nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case err == io.EOF && len(line) > 0:
+			return line, nil
+		default:
+			return nil, err
+		}
+	}
+}
+
+// handleLine handles a message or batch. Responses and notifications take effect before
+// anything else is read; requests are handed to handlers.
+func (t *StdioTransport) handleLine(line []byte) {
+	if !json.Valid(line) {
+		t.logger.Warn("Failed to parse MCP message", zap.Int("bytes", len(line)))
+		t.write(errorResponse(nil, codeParseError, "Parse error", nil))
+		return
+	}
+
+	if line[0] == '[' {
+		var batch []json.RawMessage
+		json.Unmarshal(line, &batch)
+		if len(batch) == 0 {
+			t.write(errorResponse(nil, codeInvalidRequest, "Invalid Request", "empty batch"))
+			return
+		}
+		t.handleBatch(batch)
+		return
+	}
+
+	req, invalid := t.classify(line)
+	switch {
+	case invalid != nil:
+		t.write(invalid)
+	case req == nil:
+	case req.method == "initialize":
+		// The server is set up by initialize; nothing else runs until it has completed.
+		if response := t.handleRequest(req); response != nil {
+			t.write(response)
+		}
+	default:
+		queued := t.spawn(func() {
+			if response := t.handleRequest(req); response != nil {
+				t.write(response)
+			}
+		})
+		if !queued {
+			t.write(t.busy(req))
+		}
+	}
+}
+
+// handleBatch handles the requests of a batch concurrently and answers them in one array,
+// in the order of the requests.
+func (t *StdioTransport) handleBatch(batch []json.RawMessage) {
+	responses := make([]*MCPMessage, len(batch))
+	requests := make([]*request, len(batch))
+	queue := false
+	for i, data := range batch {
+		req, invalid := t.classify(data)
+		switch {
+		case invalid != nil:
+			responses[i] = invalid
+		case req == nil:
+		case req.method == "initialize":
+			t.untrack(req)
+			responses[i] = errorResponse(req.id, codeInvalidRequest, "Invalid Request", "initialize must not be part of a batch")
+		default:
+			requests[i] = req
+			queue = true
+		}
+	}
+	if !queue {
+		t.writeBatch(responses)
+		return
+	}
+
+	queued := t.spawn(func() {
+		var wg sync.WaitGroup
+		for i, req := range requests {
+			if req == nil {
+				continue
+			}
+			wg.Add(1)
+			go func(i int, req *request) {
+				defer wg.Done()
+				responses[i] = t.handleRequest(req)
+			}(i, req)
+		}
+		wg.Wait()
+		t.writeBatch(responses)
+	})
+	if !queued {
+		for i, req := range requests {
+			if req != nil {
+				responses[i] = t.busy(req)
+			}
+		}
+		t.writeBatch(responses)
+	}
+}
+
+// writeBatch writes the responses of a batch, if it has any.
+func (t *StdioTransport) writeBatch(responses []*MCPMessage) {
+	answered := make([]*MCPMessage, 0, len(responses))
+	for _, response := range responses {
+		if response != nil {
+			answered = append(answered, response)
+		}
+	}
+	if len(answered) > 0 {
+		t.write(answered)
+	}
+}
+
+// classify checks a single message. It returns the request to handle, or the error
+// response to an invalid message. Notifications and responses are handled here and
+// yield neither.
+func (t *StdioTransport) classify(data json.RawMessage) (*request, *MCPMessage) {
+	var msg wireMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, errorResponse(nil, codeInvalidRequest, "Invalid Request", "a message must be an object")
+	}
+
+	var id json.RawMessage
+	if len(msg.ID) > 0 {
+		if !validID(msg.ID) {
+			return nil, errorResponse(nil, codeInvalidRequest, "Invalid Request", "id must be a string or number")
+		}
+		id = msg.ID
+	}
+	if string(msg.JSONRPC) != `"2.0"` {
+		return nil, errorResponse(id, codeInvalidRequest, "Invalid Request", `jsonrpc must be "2.0"`)
+	}
+
+	if len(msg.Method) == 0 {
+		if id != nil && (len(msg.Result) > 0 || len(msg.Error) > 0) {
+			t.handleResponse(id, &msg)
+			return nil, nil
+		}
+		return nil, errorResponse(id, codeInvalidRequest, "Invalid Request", "method is required")
+	}
+	var method string
+	if err := json.Unmarshal(msg.Method, &method); err != nil || method == "" {
+		return nil, errorResponse(id, codeInvalidRequest, "Invalid Request", "method must be a non-empty string")
+	}
+
+	if id == nil {
+		t.handleNotification(method, msg.Params)
+		return nil, nil
+	}
+	req := &request{id: id, key: idKey(id), method: method, params: msg.Params}
+	t.track(req)
+	return req, nil
+}
+
+// handleRequest calls the server and returns the response, or nil when the client has
+// cancelled the request.
+func (t *StdioTransport) handleRequest(req *request) *MCPMessage {
+	response := &MCPMessage{JSONRPC: "2.0", ID: req.id}
+	params := make(map[string]interface{})
+	if len(req.params) > 0 && string(req.params) != "null" {
+		if err := json.Unmarshal(req.params, &params); err != nil {
+			response.Error = &MCPError{Code: codeInvalidParams, Message: "Invalid params", Data: "params must be an object"}
+		}
+	}
+	if response.Error == nil {
+		result, mcpErr := dispatch(t.server, req.method, params)
+		if mcpErr != nil {
+			response.Error = mcpErr
+		} else {
+			response.Result = result
+		}
+	}
+
+	if t.untrack(req) {
+		t.logger.Debug("Dropping response to cancelled request", zap.String("method", req.method))
+		return nil
+	}
+	return response
+}
+
+// handleNotification handles a notification from the client.
+func (t *StdioTransport) handleNotification(method string, params json.RawMessage) {
+	switch method {
+	case "notifications/cancelled":
+		var cancelled struct {
+			RequestID json.RawMessage `json:"requestId"`
+		}
+		if json.Unmarshal(params, &cancelled) != nil || len(cancelled.RequestID) == 0 {
+			return
+		}
+		key := idKey(cancelled.RequestID)
+		t.requestsMu.Lock()
+		if _, ok := t.active[key]; ok {
+			t.active[key] = true
+		}
+		t.requestsMu.Unlock()
+	default:
+		t.logger.Debug("Received MCP notification", zap.String("method", method))
+	}
+}
+
+// handleResponse delivers the client's response to a request sent with Request.
+func (t *StdioTransport) handleResponse(id json.RawMessage, msg *wireMessage) {
+	t.requestsMu.Lock()
+	response, ok := t.pending[idKey(id)]
+	t.requestsMu.Unlock()
+	if !ok {
+		t.logger.Debug("Ignoring response to unknown request", zap.ByteString("id", id))
+		return
+	}
+	select {
+	case response <- msg:
+	default:
+	}
+}
+
+// Request sends a request to the client and waits for its result. When ctx ends first,
+// the client is told that the request was cancelled. An error response is returned as *MCPError.
+func (t *StdioTransport) Request(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	t.requestsMu.Lock()
+	if t.ctx == nil || t.ctx.Err() != nil {
+		t.requestsMu.Unlock()
+		return nil, ErrTransportClosed
+	}
+	done := t.ctx.Done()
+	t.nextID++
+	id := strconv.FormatInt(t.nextID, 10)
+	response := make(chan *wireMessage, 1)
+	t.pending[id] = response
+	t.requestsMu.Unlock()
+	defer func() {
+		t.requestsMu.Lock()
+		delete(t.pending, id)
+		t.requestsMu.Unlock()
+	}()
+
+	if err := t.SendMessage(MCPMessage{JSONRPC: "2.0", ID: json.RawMessage(id), Method: method, Params: params}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg := <-response:
+		if len(msg.Error) > 0 {
+			var mcpErr MCPError
+			if err := json.Unmarshal(msg.Error, &mcpErr); err != nil {
+				return nil, fmt.Errorf("invalid error response to %s: %w", method, err)
+			}
+			return nil, &mcpErr
+		}
+		return msg.Result, nil
+	case <-ctx.Done():
+		t.SendMessage(MCPMessage{
+			JSONRPC: "2.0",
+			Method:  "notifications/cancelled",
+			Params:  map[string]interface{}{"requestId": json.RawMessage(id), "reason": ctx.Err().Error()},
+		})
+		return nil, ctx.Err()
+	case <-done:
+		return nil, ErrTransportClosed
+	}
+}
+
+// SendMessage sends an MCP message via stdio
+func (t *StdioTransport) SendMessage(message MCPMessage) error {
+	return t.write(message)
+}
+
+// write sends a message or batch as one line.
+func (t *StdioTransport) write(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := t.writer.Write(append(data, '\n')); err != nil {
+		t.logger.Error("Failed to send MCP message", zap.Error(err))
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
+
+// spawn runs handle once a handler slot is free, without waiting for one. It reports false
+// when the queue is full, and handle is then not run.
+func (t *StdioTransport) spawn(handle func()) bool {
+	select {
+	case t.queue <- struct{}{}:
+	default:
+		return false
+	}
+	t.inflight.Add(1)
+	go func() {
+		defer t.inflight.Done()
+		defer func() { <-t.queue }()
+		select {
+		case t.slots <- struct{}{}:
+		case <-t.ctx.Done():
+			return
+		}
+		defer func() { <-t.slots }()
+		handle()
+	}()
+	return true
+}
+
+// busy refuses a request that could not be queued.
+func (t *StdioTransport) busy(req *request) *MCPMessage {
+	t.untrack(req)
+	t.logger.Warn("Refusing MCP request, the queue is full", zap.String("method", req.method))
+	return errorResponse(req.id, codeServerBusy, "Server busy",
+		fmt.Sprintf("at most %d requests are handled or queued at a time", maxConcurrentRequests+maxQueuedRequests))
+}
+
+func (t *StdioTransport) track(req *request) {
+	t.requestsMu.Lock()
+	t.active[req.key] = false
+	t.requestsMu.Unlock()
+}
+
+// untrack forgets a request and reports whether the client cancelled it.
+func (t *StdioTransport) untrack(req *request) bool {
+	t.requestsMu.Lock()
+	defer t.requestsMu.Unlock()
+	cancelled := t.active[req.key]
+	delete(t.active, req.key)
+	return cancelled
+}
+
+// Stop stops the stdio transport
+func (t *StdioTransport) Stop() error {
+	if t.cancel != nil {
+		t.cancel()
+	}
+	return nil
+}
+
+// errorResponse builds an error response. A nil id is sent as null, for messages whose
+// ID could not be determined.
+func errorResponse(id json.RawMessage, code int, message string, data interface{}) *MCPMessage {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &MCPMessage{JSONRPC: "2.0", ID: id, Error: &MCPError{Code: code, Message: message, Data: data}}
+}
+
+// validID reports whether a raw ID is a string or number.
+func validID(id json.RawMessage) bool {
+	var value interface{}
+	if err := json.Unmarshal(id, &value); err != nil {
+		return false
+	}
+	switch value.(type) {
+	case string, float64:
+		return true
+	}
+	return false
+}
+
+// idKey returns a raw ID in a canonical form, for matching.
+func idKey(id json.RawMessage) string {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, id); err != nil {
+		return string(id)
+	}
+	return compacted.String()
+}
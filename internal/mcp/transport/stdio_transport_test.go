@@ -0,0 +1,126 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// askingServer asks the client for something on every tool call, as sampling does.
+type askingServer struct {
+	transport *StdioTransport
+}
+
+func (s *askingServer) Initialize(params map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+func (s *askingServer) ListTools() ([]interface{}, error)             { return nil, nil }
+func (s *askingServer) ListResources() ([]interface{}, error)         { return nil, nil }
+func (s *askingServer) ReadResource(uri string) (interface{}, error)  { return nil, nil }
+func (s *askingServer) ListResourceTemplates() ([]interface{}, error) { return nil, nil }
+func (s *askingServer) ListPrompts() ([]interface{}, error)           { return nil, nil }
+func (s *askingServer) GetPrompt(name string, arguments map[string]string) (interface{}, error) {
+	return nil, nil
+}
+func (s *askingServer) Shutdown() error { return nil }
+
+func (s *askingServer) CallTool(name string, arguments map[string]interface{}) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	answer, err := s.transport.Request(ctx, "sampling/createMessage", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"answer": answer}, nil
+}
+
+// TestServerRequestsWhileHandlersAreBusy answers the server's requests only once every handler
+// waits for one, singly or in a batch; the answers must still be read.
+func TestServerRequestsWhileHandlersAreBusy(t *testing.T) {
+	tests := []struct {
+		name     string
+		requests int
+		batch    bool
+	}{
+		{"more requests than handlers", maxConcurrentRequests + 8, false},
+		{"answers in a batch", maxConcurrentRequests, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inR, inW := io.Pipe()
+			outR, outW := io.Pipe()
+			transport := NewStdioTransportWithIO(inR, outW, zap.NewNop())
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			defer inW.Close()
+			go transport.Start(ctx, &askingServer{transport: transport})
+
+			go func() {
+				for id := 1; id <= tt.requests; id++ {
+					fmt.Fprintf(inW, `{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"ask"}}`+"\n", id)
+				}
+			}()
+
+			done := make(chan error, 1)
+			go func() {
+				done <- answerUntilDone(outR, inW, tt.requests, tt.batch)
+			}()
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("transport stopped reading while its handlers waited for the client")
+			}
+		})
+	}
+}
+
+// answerUntilDone answers the server's requests on in until it has responded to the first
+// count requests. In batch mode answers are held until every handler asks, then sent together.
+func answerUntilDone(out io.Reader, in io.Writer, count int, batch bool) error {
+	scanner := bufio.NewScanner(out)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageBytes)
+	var held []string
+	answered := 0
+	for answered < count && scanner.Scan() {
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Error  *MCPError       `json:"error"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return fmt.Errorf("invalid message %s: %w", scanner.Bytes(), err)
+		}
+		switch {
+		case msg.Method != "":
+			answer := fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{"content":"ok"}}`, msg.ID)
+			if !batch {
+				fmt.Fprintln(in, answer)
+				continue
+			}
+			held = append(held, answer)
+			if len(held) == maxConcurrentRequests {
+				raw := make([]json.RawMessage, len(held))
+				for i, answer := range held {
+					raw[i] = json.RawMessage(answer)
+				}
+				data, _ := json.Marshal(raw)
+				fmt.Fprintf(in, "%s\n", data)
+				held = nil
+			}
+		case msg.Error != nil:
+			return fmt.Errorf("request %s failed: %v", msg.ID, msg.Error)
+		default:
+			answered++
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,390 @@
+// Package codegen renders standalone MCP server projects from an OpenAPI document.
+// Every operation in the document becomes one MCP tool that proxies to the upstream API.
+package codegen
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/AkashKesav/API2SDK/internal/openapi"
+)
+
+//go:embed all:templates
+var templateFS embed.FS
+
+// Language selects the implementation language of the generated server.
+type Language string
+
+const (
+	LanguageGo         Language = "go"
+	LanguageTypeScript Language = "typescript"
+)
+
+// Transport selects how the generated server talks to MCP clients.
+type Transport string
+
+const (
+	TransportStdio          Transport = "stdio"
+	TransportSSE            Transport = "sse"
+	TransportStreamableHTTP Transport = "streamable-http"
+)
+
+// DefaultPort is used for HTTP transports when no port is given.
+const DefaultPort = 8080
+
+var slugChars = regexp.MustCompile(`[^a-z0-9]+`)
+var envChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// Options controls what the generator emits.
+type Options struct {
+	Name      string    // Project and server name; derived from the spec title when empty
+	Language  Language  // Defaults to Go
+	Transport Transport // Defaults to stdio
+	Port      int       // Listen port for sse and streamable-http
+	BaseURL   string    // Overrides the first server URL from the spec
+}
+
+// Result describes a generated project.
+type Result struct {
+	Dir       string   `json:"dir"`
+	Language  Language `json:"language"`
+	Transport string   `json:"transport"`
+	Files     []string `json:"files"`
+	ToolCount int      `json:"toolCount"`
+}
+
+// NormalizeTransport maps user supplied transport names onto the supported set.
+// "web" and "http" are accepted as aliases of SSE for backward compatibility.
+func NormalizeTransport(value string) (Transport, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "stdio":
+		return TransportStdio, nil
+	case "sse", "web", "http":
+		return TransportSSE, nil
+	case "streamable-http", "streamable_http", "streamablehttp":
+		return TransportStreamableHTTP, nil
+	default:
+		return "", fmt.Errorf("unsupported MCP transport %q. Supported: stdio, sse, streamable-http", value)
+	}
+}
+
+// NormalizeLanguage maps user supplied language names onto the supported set.
+func NormalizeLanguage(value string) (Language, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "go", "golang":
+		return LanguageGo, nil
+	case "typescript", "ts", "node":
+		return LanguageTypeScript, nil
+	default:
+		return "", fmt.Errorf("unsupported MCP server language %q. Supported: go, typescript", value)
+	}
+}
+
+// Generate writes an MCP server project for spec into outputDir.
+func Generate(spec []byte, outputDir string, opts Options) (*Result, error) {
+	doc, err := openapi.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	if len(doc.Operations) == 0 {
+		return nil, fmt.Errorf("OpenAPI document contains no operations to expose as tools")
+	}
+
+	language, err := NormalizeLanguage(string(opts.Language))
+	if err != nil {
+		return nil, err
+	}
+	transport, err := NormalizeTransport(string(opts.Transport))
+	if err != nil {
+		return nil, err
+	}
+
+	data := newTemplateData(doc, opts, transport)
+
+	root := path.Join("templates", string(language))
+	result := &Result{
+		Dir:       outputDir,
+		Language:  language,
+		Transport: string(transport),
+		ToolCount: len(data.Tools),
+	}
+
+	err = fs.WalkDir(templateFS, root, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() || !strings.HasSuffix(p, ".tmpl") {
+			return nil
+		}
+
+		rel := strings.TrimSuffix(strings.TrimPrefix(p, root+"/"), ".tmpl")
+
+		content, err := render(p, data)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(outputDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", rel, err)
+		}
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", rel, err)
+		}
+		result.Files = append(result.Files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s MCP server: %w", language, err)
+	}
+
+	sort.Strings(result.Files)
+	return result, nil
+}
+
+func render(name string, data *templateData) ([]byte, error) {
+	raw, err := templateFS.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(path.Base(name)).Funcs(templateFuncs).Parse(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute template %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+var templateFuncs = template.FuncMap{
+	// quote renders a Go string literal.
+	"quote": strconv.Quote,
+	// jsString renders a JavaScript/JSON string literal.
+	"jsString": func(s string) string {
+		b, _ := json.Marshal(s)
+		return string(b)
+	},
+	"jsStrings": func(values []string) string {
+		if values == nil {
+			values = []string{}
+		}
+		b, _ := json.Marshal(values)
+		return string(b)
+	},
+	"goStrings": func(values []string) string {
+		quoted := make([]string, len(values))
+		for i, v := range values {
+			quoted[i] = strconv.Quote(v)
+		}
+		return "[]string{" + strings.Join(quoted, ", ") + "}"
+	},
+	"isHTTP": func(t string) bool {
+		return t != string(TransportStdio)
+	},
+	// line renders spec text inside a comment or heading; it must not end the line early.
+	"line": singleLine,
+}
+
+// singleLine collapses line breaks, other control characters and runs of spaces into single spaces.
+func singleLine(s string) string {
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	}), " ")
+}
+
+type templateData struct {
+	Name        string
+	Title       string
+	Description string
+	Version     string
+	Transport   string
+	Port        int
+	BaseURL     string
+	Tools       []toolData
+	Auth        []authData
+}
+
+type toolData struct {
+	Name            string
+	Description     string
+	Method          string
+	Path            string
+	InputSchema     string
+	Annotations     string
+	PathParams      []string
+	QueryParams     []string
+	HeaderParams    []string
+	CookieParams    []string
+	HasBody         bool
+	BodyContentType string
+}
+
+// authData describes how one security scheme is fed from environment variables.
+type authData struct {
+	Name      string
+	Kind      string // apiKey, basic or bearer
+	In        string // header, query or cookie for apiKey
+	ParamName string
+	EnvValue  string
+	EnvUser   string
+	EnvPass   string
+}
+
+func newTemplateData(doc *openapi.Document, opts Options, transport Transport) *templateData {
+	title := doc.Title
+	if title == "" {
+		title = "API"
+	}
+	name := opts.Name
+	if name == "" {
+		name = title + " mcp"
+	}
+	name = strings.Trim(slugChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if name == "" {
+		name = "generated-mcp"
+	}
+
+	version := doc.Version
+	if version == "" {
+		version = "1.0.0"
+	}
+
+	port := opts.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+
+	baseURL := opts.BaseURL
+	if baseURL == "" {
+		baseURL = doc.BaseURL()
+	}
+
+	data := &templateData{
+		Name:        name,
+		Title:       title,
+		Description: doc.Description,
+		Version:     version,
+		Transport:   string(transport),
+		Port:        port,
+		BaseURL:     strings.TrimSuffix(baseURL, "/"),
+		Auth:        authFromSchemes(doc),
+	}
+
+	for _, op := range doc.Operations {
+		schema, _ := json.Marshal(op.InputSchema)
+		annotations, _ := json.Marshal(op.Annotations())
+		tool := toolData{
+			Name:        op.ToolName,
+			Description: op.DisplayDescription(),
+			Method:      op.Method,
+			Path:        op.Path,
+			InputSchema: string(schema),
+			Annotations: string(annotations),
+		}
+		for _, p := range op.Parameters {
+			switch p.In {
+			case "path":
+				tool.PathParams = append(tool.PathParams, p.Name)
+			case "query":
+				tool.QueryParams = append(tool.QueryParams, p.Name)
+			case "header":
+				tool.HeaderParams = append(tool.HeaderParams, p.Name)
+			case "cookie":
+				tool.CookieParams = append(tool.CookieParams, p.Name)
+			}
+		}
+		if op.RequestBody != nil {
+			tool.HasBody = true
+			tool.BodyContentType = op.RequestBody.ContentType
+		}
+		data.Tools = append(data.Tools, tool)
+	}
+
+	return data
+}
+
+// authFromSchemes maps the document's security schemes to environment variable based credentials.
+// Documents without schemes get a generic bearer token so that the server is still usable.
+func authFromSchemes(doc *openapi.Document) []authData {
+	names := make([]string, 0, len(doc.SecuritySchemes))
+	for name := range doc.SecuritySchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []authData
+	for _, name := range names {
+		scheme := doc.SecuritySchemes[name]
+		prefix := strings.Trim(envChars.ReplaceAllString(strings.ToUpper(name), "_"), "_")
+		if prefix == "" {
+			prefix = "API"
+		}
+		a := authData{Name: name}
+		switch strings.ToLower(scheme.Type) {
+		case "apikey":
+			a.Kind = "apiKey"
+			a.In = strings.ToLower(scheme.In)
+			if a.In == "" {
+				a.In = "header"
+			}
+			a.ParamName = scheme.Name
+			a.EnvValue = prefix + "_API_KEY"
+		case "http":
+			if strings.EqualFold(scheme.Scheme, "basic") {
+				a.Kind = "basic"
+				a.EnvUser = prefix + "_USERNAME"
+				a.EnvPass = prefix + "_PASSWORD"
+			} else {
+				a.Kind = "bearer"
+				a.EnvValue = prefix + "_TOKEN"
+			}
+		case "oauth2", "openidconnect":
+			a.Kind = "bearer"
+			a.EnvValue = prefix + "_ACCESS_TOKEN"
+		default:
+			continue
+		}
+		out = append(out, a)
+	}
+
+	if len(out) == 0 {
+		out = append(out, authData{Name: "default", Kind: "bearer", EnvValue: "API_TOKEN"})
+	}
+	return out
+}
+
+// CredentialEnv names the environment variables a generated server reads one credential from.
+type CredentialEnv struct {
+	Kind     string // apiKey, basic or bearer
+	Value    string // API key or token variable, for apiKey and bearer
+	Username string // for basic
+	Password string // for basic
+}
+
+// CredentialEnvVars returns the credential variables of the server generated from doc,
+// so that a host can provide them. A nil doc yields the generic bearer token.
+func CredentialEnvVars(doc *openapi.Document) []CredentialEnv {
+	if doc == nil {
+		doc = &openapi.Document{}
+	}
+	auth := authFromSchemes(doc)
+	out := make([]CredentialEnv, 0, len(auth))
+	for _, a := range auth {
+		out = append(out, CredentialEnv{Kind: a.Kind, Value: a.EnvValue, Username: a.EnvUser, Password: a.EnvPass})
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package codegen
+
+import (
+	"bufio"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const petstore = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Pet\nstore */ \u0007API", "version": "2.1.0"},
+  "servers": [{"url": "https://pets.example.com/v1/"}],
+  "components": {"securitySchemes": {"key": {"type": "apiKey", "in": "header", "name": "X-Key"}}},
+  "paths": {
+    "/pets": {
+      "get": {"operationId": "listPets", "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}]},
+      "post": {"operationId": "createPet", "requestBody": {"content": {"application/json": {"schema": {"type": "object"}}}}}
+    },
+    "/pets/{id}": {
+      "get": {"operationId": "getPet", "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}]}
+    }
+  }
+}`
+
+func TestGenerateGoProject(t *testing.T) {
+	dir := t.TempDir()
+	result, err := Generate([]byte(petstore), dir, Options{Transport: "web"})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if result.Language != LanguageGo || result.Transport != string(TransportSSE) || result.ToolCount != 3 {
+		t.Fatalf("Generate() = %+v, want a Go SSE project with 3 tools", result)
+	}
+
+	fset := token.NewFileSet()
+	for _, file := range result.Files {
+		if filepath.Ext(file) != ".go" {
+			continue
+		}
+		if _, err := parser.ParseFile(fset, filepath.Join(dir, file), nil, parser.AllErrors); err != nil {
+			t.Errorf("generated %s does not parse: %v", file, err)
+		}
+	}
+
+	operations, err := os.ReadFile(filepath.Join(dir, "operations.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"listPets"`, `"/pets/{id}"`} {
+		if !strings.Contains(string(operations), want) {
+			t.Errorf("operations.go does not contain %s", want)
+		}
+	}
+}
+
+func TestGenerateKeepsTitleOnOneLine(t *testing.T) {
+	for _, language := range []Language{LanguageGo, LanguageTypeScript} {
+		t.Run(string(language), func(t *testing.T) {
+			dir := t.TempDir()
+			if _, err := Generate([]byte(petstore), dir, Options{Language: language}); err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if got, want := firstLine(t, filepath.Join(dir, "README.md")), "# Pet store */ API MCP server"; got != want {
+				t.Errorf("README.md heading = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestNormalizeTransport(t *testing.T) {
+	tests := map[string]Transport{
+		"":                TransportStdio,
+		"STDIO":           TransportStdio,
+		"http":            TransportSSE,
+		"streamable_http": TransportStreamableHTTP,
+	}
+	for value, want := range tests {
+		if got, err := NormalizeTransport(value); err != nil || got != want {
+			t.Errorf("NormalizeTransport(%q) = %q, %v, want %q", value, got, err, want)
+		}
+	}
+	if _, err := NormalizeTransport("websocket"); err == nil {
+		t.Error("NormalizeTransport(websocket) succeeded, want an error")
+	}
+}
+
+func firstLine(t *testing.T, name string) string {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Scan()
+	return scanner.Text()
+}
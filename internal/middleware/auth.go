@@ -1,21 +1,137 @@
 package middleware
 
 import (
+	"errors"
+	"strings"
+
+	"github.com/AkashKesav/API2SDK/internal/services"
 	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// GetUserID returns a default user ID since authentication is disabled
+// GetUserID returns the hex ID of the authenticated user
 func GetUserID(c fiber.Ctx) (string, bool) {
-	// Return a default user ID for all requests
-	return "default-user", true
+	userID, ok := c.Locals("user_id").(string)
+	return userID, ok && userID != ""
+}
+
+// GetUserRole returns the role of the authenticated user
+func GetUserRole(c fiber.Ctx) string {
+	role, _ := c.Locals("user_role").(string)
+	return role
+}
+
+// GetSessionID returns the session the request was authenticated with
+func GetSessionID(c fiber.Ctx) (primitive.ObjectID, bool) {
+	sessionID, ok := c.Locals("session_id").(primitive.ObjectID)
+	return sessionID, ok
+}
+
+// GetPrincipal returns the authenticated principal of the request
+func GetPrincipal(c fiber.Ctx) (*services.Principal, bool) {
+	principal, ok := c.Locals("principal").(*services.Principal)
+	return principal, ok
+}
+
+// AuthMiddleware requires an access JWT or a personal access token, sent as a bearer token or
+// in the X-API-Key header, and sets the user context: "user_id" (hex string), "userID"
+// (ObjectID), "user_role", "session_id" for sessions and "principal"
+func AuthMiddleware(authService services.AuthService, accessTokenService services.AccessTokenService) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		credential := c.Get("X-API-Key")
+		fromAPIKeyHeader := credential != ""
+		if !fromAPIKeyHeader {
+			credential = bearerToken(c)
+		}
+		if credential == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Authentication required",
+			})
+		}
+
+		var principal *services.Principal
+		var err error
+		switch {
+		case services.IsAccessToken(credential):
+			principal, err = accessTokenService.Authenticate(c.Context(), credential, c.IP())
+		case fromAPIKeyHeader:
+			err = services.ErrUnauthenticated
+		default:
+			principal, err = authService.Authenticate(c.Context(), credential)
+		}
+		if errors.Is(err, services.ErrUnauthenticated) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid or expired credentials",
+			})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to authenticate request",
+			})
+		}
+
+		c.Locals("principal", principal)
+		c.Locals("user_id", principal.UserID.Hex())
+		c.Locals("userID", principal.UserID)
+		c.Locals("user_role", string(principal.Role))
+		if !principal.IsAccessToken() {
+			c.Locals("session_id", principal.SessionID)
+		}
+		if meta := services.RequestMetadataFrom(c.Context()); meta != nil {
+			meta.ActorID = principal.UserID
+			meta.AccessTokenID = principal.AccessTokenID
+		}
+		return c.Next()
+	}
 }
 
-// NoAuthMiddleware is a pass-through middleware that sets a default user context
-func NoAuthMiddleware() fiber.Handler {
+// RequireScope allows requests whose principal has the scope; sessions have every scope
+func RequireScope(scope string) fiber.Handler {
 	return func(c fiber.Ctx) error {
-		// Set a default user ID in the context
-		c.Locals("user_id", "default-user")
-		c.Locals("user_role", "user")
+		return requireScope(c, scope)
+	}
+}
+
+// RequireMethodScope requires readScope for safe methods and writeScope for all others
+func RequireMethodScope(readScope, writeScope string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return requireScope(c, readScope)
+		default:
+			return requireScope(c, writeScope)
+		}
+	}
+}
+
+// RequireSession refuses personal access tokens, for routes only an interactive user may use
+// such as managing the tokens themselves
+func RequireSession() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if principal, ok := GetPrincipal(c); !ok || principal.IsAccessToken() {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "This route requires a signed-in session",
+			})
+		}
 		return c.Next()
 	}
 }
+
+func requireScope(c fiber.Ctx, scope string) error {
+	principal, ok := GetPrincipal(c)
+	if !ok || !principal.HasScope(scope) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access token lacks the " + scope + " scope",
+		})
+	}
+	return c.Next()
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(c fiber.Ctx) string {
+	scheme, token, ok := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// stubSessions authenticates the access JWT "session"; unused methods panic.
+type stubSessions struct {
+	services.AuthService
+}
+
+func (s stubSessions) Authenticate(ctx context.Context, accessToken string) (*services.Principal, error) {
+	if accessToken != "session" {
+		return nil, services.ErrUnauthenticated
+	}
+	return &services.Principal{UserID: primitive.NewObjectID(), Role: models.RoleUser, SessionID: primitive.NewObjectID()}, nil
+}
+
+// stubAccessTokens authenticates the personal access tokens it holds; unused methods panic.
+type stubAccessTokens struct {
+	services.AccessTokenService
+	tokens map[string]*services.Principal
+}
+
+func (s stubAccessTokens) Authenticate(ctx context.Context, token, clientIP string) (*services.Principal, error) {
+	principal, ok := s.tokens[token]
+	if !ok {
+		return nil, services.ErrUnauthenticated
+	}
+	return principal, nil
+}
+
+func newTestAuthApp() *fiber.App {
+	tokens := stubAccessTokens{tokens: map[string]*services.Principal{
+		services.AccessTokenPrefix + "reader": {UserID: primitive.NewObjectID(), AccessTokenID: primitive.NewObjectID(), Scopes: []string{models.ScopeCollectionsRead}},
+		services.AccessTokenPrefix + "admin":  {UserID: primitive.NewObjectID(), AccessTokenID: primitive.NewObjectID(), Scopes: []string{models.ScopeAdmin}},
+	}}
+	app := fiber.New()
+	app.Use(AuthMiddleware(stubSessions{}, tokens))
+	ok := func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) }
+	collections := RequireMethodScope(models.ScopeCollectionsRead, models.ScopeCollectionsWrite)
+	app.Get("/collections", ok, collections)
+	app.Post("/collections", ok, collections)
+	app.Post("/sdks", ok, RequireScope(models.ScopeSDKGenerate))
+	app.Get("/tokens", ok, RequireSession())
+	return app
+}
+
+func TestAuthMiddlewareScopes(t *testing.T) {
+	app := newTestAuthApp()
+	for _, tc := range []struct {
+		name, method, path string
+		header, credential string
+		want               int
+	}{
+		{"no credential", http.MethodGet, "/collections", "", "", fiber.StatusUnauthorized},
+		{"unknown token", http.MethodGet, "/collections", "X-API-Key", services.AccessTokenPrefix + "unknown", fiber.StatusUnauthorized},
+		{"JWT in the API key header", http.MethodGet, "/collections", "X-API-Key", "session", fiber.StatusUnauthorized},
+		{"session read", http.MethodGet, "/collections", "Authorization", "Bearer session", fiber.StatusNoContent},
+		{"session write", http.MethodPost, "/collections", "Authorization", "Bearer session", fiber.StatusNoContent},
+		{"token read", http.MethodGet, "/collections", "X-API-Key", services.AccessTokenPrefix + "reader", fiber.StatusNoContent},
+		{"token as bearer", http.MethodGet, "/collections", "Authorization", "Bearer " + services.AccessTokenPrefix + "reader", fiber.StatusNoContent},
+		{"token write without scope", http.MethodPost, "/collections", "X-API-Key", services.AccessTokenPrefix + "reader", fiber.StatusForbidden},
+		{"token without scope", http.MethodPost, "/sdks", "X-API-Key", services.AccessTokenPrefix + "reader", fiber.StatusForbidden},
+		{"admin token", http.MethodPost, "/sdks", "X-API-Key", services.AccessTokenPrefix + "admin", fiber.StatusNoContent},
+		{"session-only route with a session", http.MethodGet, "/tokens", "Authorization", "Bearer session", fiber.StatusNoContent},
+		{"session-only route with a token", http.MethodGet, "/tokens", "X-API-Key", services.AccessTokenPrefix + "admin", fiber.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.header != "" {
+				req.Header.Set(tc.header, tc.credential)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tc.want {
+				t.Errorf("%s %s = %d, want %d", tc.method, tc.path, resp.StatusCode, tc.want)
+			}
+		})
+	}
+}
+
+func TestAccessTokenRateLimiter(t *testing.T) {
+	limited := &services.Principal{UserID: primitive.NewObjectID(), AccessTokenID: primitive.NewObjectID(), RateLimitPerMinute: 2}
+	other := &services.Principal{UserID: primitive.NewObjectID(), AccessTokenID: primitive.NewObjectID(), RateLimitPerMinute: 2}
+	session := &services.Principal{UserID: primitive.NewObjectID(), SessionID: primitive.NewObjectID()}
+	principals := map[string]*services.Principal{"limited": limited, "other": other, "session": session}
+
+	app := fiber.New()
+	app.Use(func(c fiber.Ctx) error {
+		c.Locals("principal", principals[c.Get("X-Principal")])
+		return c.Next()
+	})
+	app.Use(AccessTokenRateLimiterMiddleware(RedisRateLimiterConfig{RequestsPerMin: 1}))
+	app.Get("/", func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+
+	request := func(principal string) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Principal", principal)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+	// The token's own limit replaces the default of one request per minute.
+	for i, want := range []int{fiber.StatusNoContent, fiber.StatusNoContent, fiber.StatusTooManyRequests} {
+		if got := request("limited"); got != want {
+			t.Fatalf("request %d = %d, want %d", i+1, got, want)
+		}
+	}
+	// Tokens are counted separately and sessions are not limited here.
+	if got := request("other"); got != fiber.StatusNoContent {
+		t.Errorf("request with another token = %d, want %d", got, fiber.StatusNoContent)
+	}
+	for i := 0; i < 3; i++ {
+		if got := request("session"); got != fiber.StatusNoContent {
+			t.Fatalf("session request %d = %d, want %d", i+1, got, fiber.StatusNoContent)
+		}
+	}
+}
@@ -229,32 +229,3 @@ func CompressionMiddleware() fiber.Handler {
 		return c.Next()
 	}
 }
-
-// AuthSkipMiddleware skips authentication for certain paths
-func AuthSkipMiddleware() fiber.Handler {
-	return func(c fiber.Ctx) error {
-		path := c.Path()
-
-		// Skip auth for MCP routes, health checks, and public endpoints
-		skipPaths := []string{
-			"/mcp/",
-			"/api/v1/health",
-			"/api/v1/auth/",
-			"/api/v1/public-apis",
-			"/",
-			"/login",
-			"/register",
-		}
-
-		for _, skipPath := range skipPaths {
-			if strings.HasPrefix(path, skipPath) {
-				// Set mock user context for these routes if needed
-				c.Locals("user_id", "mock-user-123")
-				c.Locals("user_role", "admin")
-				return c.Next()
-			}
-		}
-
-		return c.Next()
-	}
-}
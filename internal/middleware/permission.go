@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/gofiber/fiber/v3"
+)
+
+// RequirePermission allows only users whose platform role grants the permission; it must run
+// after AuthMiddleware
+func RequirePermission(policy services.PolicyService, permission models.Permission) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if !HasPermission(c, policy, permission) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Missing permission " + string(permission),
+			})
+		}
+		return c.Next()
+	}
+}
+
+// HasPermission reports whether the platform role of the authenticated user grants the
+// permission, for handlers whose behaviour depends on it
+func HasPermission(c fiber.Ctx, policy services.PolicyService, permission models.Permission) bool {
+	return policy.CheckPlatform(models.UserRole(GetUserRole(c)), permission).Allowed
+}
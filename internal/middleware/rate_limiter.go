@@ -132,27 +132,30 @@ var (
 func RateLimitMiddleware(limiter *RateLimiter) fiber.Handler {
 	return func(c fiber.Ctx) error {
 		// Get client IP
-		ip := c.IP()
-
-		// Check if request is allowed
-		if !limiter.Allow(ip) {
-			remaining := limiter.GetRemaining(ip)
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":       true,
-				"message":     "Rate limit exceeded",
-				"remaining":   remaining,
-				"retry_after": int(limiter.window.Seconds()),
-			})
-		}
-
-		// Add rate limit headers
-		remaining := limiter.GetRemaining(ip)
-		c.Set("X-RateLimit-Limit", fmt.Sprintf("%d", limiter.limit))
-		c.Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
-		c.Set("X-RateLimit-Reset", fmt.Sprintf("%d", time.Now().Add(limiter.window).Unix()))
+		return limitRequest(c, limiter, c.IP())
+	}
+}
 
-		return c.Next()
+// limitRequest counts the request against the key and answers it once the limit is exceeded
+func limitRequest(c fiber.Ctx, limiter *RateLimiter, key string) error {
+	// Check if request is allowed
+	if !limiter.Allow(key) {
+		remaining := limiter.GetRemaining(key)
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error":       true,
+			"message":     "Rate limit exceeded",
+			"remaining":   remaining,
+			"retry_after": int(limiter.window.Seconds()),
+		})
 	}
+
+	// Add rate limit headers
+	remaining := limiter.GetRemaining(key)
+	c.Set("X-RateLimit-Limit", fmt.Sprintf("%d", limiter.limit))
+	c.Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
+	c.Set("X-RateLimit-Reset", fmt.Sprintf("%d", time.Now().Add(limiter.window).Unix()))
+
+	return c.Next()
 }
 
 // APIRateLimitMiddleware applies general API rate limiting
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -18,6 +19,8 @@ type RedisRateLimiterConfig struct {
 	RequestsPerMin int
 	WindowSize     time.Duration
 	Logger         *zap.Logger
+	// KeyGenerator returns what requests are counted by; the client IP by default
+	KeyGenerator func(c fiber.Ctx) string
 }
 
 // DefaultRedisRateLimiterConfig is the default Redis rate limiter configuration
@@ -41,8 +44,11 @@ func RedisRateLimiterMiddleware(config RedisRateLimiterConfig) fiber.Handler {
 	}
 
 	return func(c fiber.Ctx) error {
-		// Get client IP
+		// Get client IP, or the key requests are counted by
 		clientIP := c.IP()
+		if config.KeyGenerator != nil {
+			clientIP = config.KeyGenerator(c)
+		}
 		
 		// Create rate limit key
 		key := fmt.Sprintf("%s%s", config.KeyPrefix, clientIP)
@@ -218,4 +224,50 @@ func AdaptiveRedisRateLimiterMiddleware(config RedisRateLimiterConfig, getSystem
 		
 		return RedisRateLimiterMiddleware(adaptiveConfig)(c)
 	}
-}
\ No newline at end of file
+}
+
+// AccessTokenRateLimiterMiddleware limits the requests made with each personal access token to
+// the token's own limit, or config.RequestsPerMin. Without a Redis client the counts are kept in
+// memory. Requests authenticated otherwise are not limited here.
+func AccessTokenRateLimiterMiddleware(config RedisRateLimiterConfig) fiber.Handler {
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = "token_rate_limit:"
+	}
+	if config.RequestsPerMin == 0 {
+		config.RequestsPerMin = DefaultRedisRateLimiterConfig.RequestsPerMin
+	}
+	if config.WindowSize == 0 {
+		config.WindowSize = DefaultRedisRateLimiterConfig.WindowSize
+	}
+
+	// In-memory limiters, one per distinct limit
+	var mu sync.Mutex
+	memoryLimiters := make(map[int]*RateLimiter)
+
+	return func(c fiber.Ctx) error {
+		principal, ok := GetPrincipal(c)
+		if !ok || !principal.IsAccessToken() {
+			return c.Next()
+		}
+
+		tokenConfig := config
+		if principal.RateLimitPerMinute > 0 {
+			tokenConfig.RequestsPerMin = principal.RateLimitPerMinute
+		}
+		tokenID := principal.AccessTokenID.Hex()
+
+		if config.RedisClient == nil {
+			mu.Lock()
+			limiter, ok := memoryLimiters[tokenConfig.RequestsPerMin]
+			if !ok {
+				limiter = NewRateLimiter(tokenConfig.RequestsPerMin, config.WindowSize)
+				memoryLimiters[tokenConfig.RequestsPerMin] = limiter
+			}
+			mu.Unlock()
+			return limitRequest(c, limiter, tokenID)
+		}
+
+		tokenConfig.KeyGenerator = func(fiber.Ctx) string { return tokenID }
+		return RedisRateLimiterMiddleware(tokenConfig)(c)
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// maxRequestIDLength bounds client-supplied request IDs, which end up in logs and the audit log
+const maxRequestIDLength = 128
+
+// RequestContextMiddleware gives every request an ID, kept from the X-Request-ID header when the
+// client sent a usable one and echoed in the response, and attaches the request metadata that
+// audit events record to the request context. AuthMiddleware adds the actor to it.
+func RequestContextMiddleware() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		requestID := c.Get(fiber.HeaderXRequestID)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+			c.Request().Header.Set(fiber.HeaderXRequestID, requestID)
+		}
+		c.Set(fiber.HeaderXRequestID, requestID)
+		c.Locals("request_id", requestID)
+
+		c.SetContext(services.WithRequestMetadata(c.Context(), &services.RequestMetadata{
+			IPAddress: c.IP(),
+			UserAgent: c.Get(fiber.HeaderUserAgent),
+			RequestID: requestID,
+		}))
+		return c.Next()
+	}
+}
+
+// validRequestID accepts short IDs of printable ASCII without spaces
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Access token scopes. A token may only be used on the routes of its scopes; ScopeAdmin
+// grants every scope but, like the admin routes themselves, only to users with platform permissions.
+const (
+	ScopeCollectionsRead  = "collections:read"
+	ScopeCollectionsWrite = "collections:write"
+	ScopeSDKGenerate      = "sdk:generate"
+	ScopeMCPManage        = "mcp:manage"
+	ScopeAdmin            = "admin"
+)
+
+// AccessTokenScopes lists the valid scopes.
+var AccessTokenScopes = []string{ScopeCollectionsRead, ScopeCollectionsWrite, ScopeSDKGenerate, ScopeMCPManage, ScopeAdmin}
+
+// AccessToken is a personal access token for non-interactive clients such as CI pipelines.
+// Only the SHA-256 of the token is stored; the token itself is shown once, on creation.
+type AccessToken struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID primitive.ObjectID `bson:"userId" json:"userId"`
+	Name   string             `bson:"name" json:"name"`
+	// Prefix is the start of the token, to tell tokens apart without revealing them.
+	Prefix    string   `bson:"prefix" json:"prefix"`
+	TokenHash string   `bson:"tokenHash" json:"-"`
+	Scopes    []string `bson:"scopes" json:"scopes"`
+	// AllowedIPs restricts the token to these IPs or CIDR ranges; empty allows any address.
+	AllowedIPs []string `bson:"allowedIps,omitempty" json:"allowedIps,omitempty"`
+	// RateLimitPerMinute overrides the default request limit of the token.
+	RateLimitPerMinute int        `bson:"rateLimitPerMinute,omitempty" json:"rateLimitPerMinute,omitempty"`
+	ExpiresAt          *time.Time `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+	LastUsedAt         *time.Time `bson:"lastUsedAt,omitempty" json:"lastUsedAt,omitempty"`
+	LastUsedIP         string     `bson:"lastUsedIp,omitempty" json:"lastUsedIp,omitempty"`
+	RevokedAt          *time.Time `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+	CreatedAt          time.Time  `bson:"createdAt" json:"createdAt"`
+}
+
+// CreateAccessTokenRequest is the body of a request to create a personal access token.
+type CreateAccessTokenRequest struct {
+	Name               string     `json:"name"`
+	Scopes             []string   `json:"scopes"`
+	ExpiresAt          *time.Time `json:"expiresAt,omitempty"`
+	AllowedIPs         []string   `json:"allowedIps,omitempty"`
+	RateLimitPerMinute int        `json:"rateLimitPerMinute,omitempty"`
+}
+
+// CreatedAccessToken is the response to creating a token; it is the only time Token is returned.
+type CreatedAccessToken struct {
+	*AccessToken
+	Token string `json:"token"`
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditAction names what an audit event records.
+type AuditAction string
+
+const (
+	AuditActionLogin                    AuditAction = "auth.login"
+	AuditActionSSOLogin                 AuditAction = "auth.sso_login"
+	AuditActionAccessTokenCreate        AuditAction = "access_token.create"
+	AuditActionAccessTokenRevoke        AuditAction = "access_token.revoke"
+	AuditActionSettingsUpdate           AuditAction = "settings.update"
+	AuditActionOIDCSettingsUpdate       AuditAction = "settings.oidc_update"
+	AuditActionIntegrationCredentialSet AuditAction = "integration.credentials_update"
+	AuditActionLinkedAccountCreate      AuditAction = "linked_account.create"
+	AuditActionLinkedAccountUpdate      AuditAction = "linked_account.update"
+	AuditActionLinkedAccountDelete      AuditAction = "linked_account.delete"
+	AuditActionSDKGenerate              AuditAction = "sdk.generate"
+	AuditActionSDKDownload              AuditAction = "sdk.download"
+	AuditActionToolExecute              AuditAction = "mcp.tool_execute"
+	AuditActionSecretRead               AuditAction = "secret.read"
+)
+
+// AuditOutcome tells whether the audited action succeeded.
+type AuditOutcome string
+
+const (
+	AuditOutcomeSuccess AuditOutcome = "success"
+	AuditOutcomeFailure AuditOutcome = "failure"
+)
+
+// Audit target types.
+const (
+	AuditTargetUser          = "user"
+	AuditTargetAccessToken   = "access_token"
+	AuditTargetSettings      = "settings"
+	AuditTargetIntegration   = "integration"
+	AuditTargetLinkedAccount = "linked_account"
+	AuditTargetSDK           = "sdk"
+	AuditTargetSecret        = "secret"
+)
+
+// AuditChange is the value of a field before and after a change. Secret values are redacted.
+type AuditChange struct {
+	Field  string      `bson:"field" json:"field"`
+	Before interface{} `bson:"before,omitempty" json:"before,omitempty"`
+	After  interface{} `bson:"after,omitempty" json:"after,omitempty"`
+}
+
+// AuditEvent is an entry of the append-only audit log.
+type AuditEvent struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Timestamp time.Time          `bson:"timestamp" json:"timestamp"`
+	// ActorID is the user who acted; it is empty for failed logins of unknown users.
+	ActorID primitive.ObjectID `bson:"actorId,omitempty" json:"actorId,omitempty"`
+	// AccessTokenID is set when the actor used a personal access token.
+	AccessTokenID  primitive.ObjectID `bson:"accessTokenId,omitempty" json:"accessTokenId,omitempty"`
+	OrganizationID string             `bson:"organizationId,omitempty" json:"organizationId,omitempty"`
+	Action         AuditAction        `bson:"action" json:"action"`
+	Outcome        AuditOutcome       `bson:"outcome" json:"outcome"`
+	TargetType     string             `bson:"targetType,omitempty" json:"targetType,omitempty"`
+	TargetID       string             `bson:"targetId,omitempty" json:"targetId,omitempty"`
+	Changes        []AuditChange      `bson:"changes,omitempty" json:"changes,omitempty"`
+	Metadata       map[string]string  `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	IPAddress      string             `bson:"ipAddress,omitempty" json:"ipAddress,omitempty"`
+	UserAgent      string             `bson:"userAgent,omitempty" json:"userAgent,omitempty"`
+	RequestID      string             `bson:"requestId,omitempty" json:"requestId,omitempty"`
+}
+
+// AuditQuery filters the audit log. Zero fields match every event; From is inclusive and To exclusive.
+type AuditQuery struct {
+	ActorID        primitive.ObjectID
+	OrganizationID string
+	// Action matches an action exactly, or every action of a group when it ends in ".*", e.g. "auth.*".
+	Action     string
+	Outcome    AuditOutcome
+	TargetType string
+	TargetID   string
+	RequestID  string
+	From       time.Time
+	To         time.Time
+	Page       int
+	Limit      int
+}
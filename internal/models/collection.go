@@ -22,16 +22,31 @@ type Collection struct {
 	Name           string             `json:"name" bson:"name" validate:"required"`
 	Description    string             `json:"description" bson:"description"`
 	UserID         string             `json:"user_id" bson:"user_id"`
+	OrganizationID string             `json:"organization_id,omitempty" bson:"organization_id,omitempty"`   // Owning organization; empty for personal collections
 	PostmanData    interface{}        `json:"postman_data,omitempty" bson:"postman_data,omitempty"`         // Keep if direct Postman JSON is stored
 	RawPostmanJSON string             `json:"raw_postman_json,omitempty" bson:"raw_postman_json,omitempty"` // For collections imported from Postman API
 	OpenAPISpec    string             `json:"openapi_spec,omitempty" bson:"openapi_spec,omitempty"`         // For OpenAPI specs
 	Source         CollectionSource   `json:"source" bson:"source"`                                         // To distinguish between Postman, OpenAPI, etc.
 	SourceDetail   string             `json:"source_detail,omitempty" bson:"source_detail,omitempty"`       // E.g., Postman Collection UID, Konfig ID
+	PostmanLink    *PostmanLink       `json:"postman_link,omitempty" bson:"postman_link,omitempty"`         // Set while the collection tracks a Postman collection
 	Endpoints      []Endpoint         `json:"endpoints" bson:"endpoints"`
 	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
 }
 
+// PostmanLink links a collection to the Postman collection it tracks. The sync scheduler polls
+// Postman and stores a new revision whenever the content of the collection changes.
+type PostmanLink struct {
+	UID            string    `json:"uid" bson:"uid"`
+	AutoRegenerate bool      `json:"auto_regenerate" bson:"auto_regenerate"` // Regenerate the collection's SDKs when its content changes
+	Revision       int       `json:"revision" bson:"revision"`               // Latest stored revision; 0 before the first sync
+	ContentHash    string    `json:"content_hash,omitempty" bson:"content_hash,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"` // Postman's updatedAt of the latest check
+	LinkedAt       time.Time `json:"linked_at" bson:"linked_at"`
+	LastCheckedAt  time.Time `json:"last_checked_at,omitempty" bson:"last_checked_at,omitempty"`
+	LastError      string    `json:"last_error,omitempty" bson:"last_error,omitempty"` // Why the latest check failed
+}
+
 // Endpoint represents an API endpoint
 type Endpoint struct {
 	ID          string            `json:"id" bson:"id"`
@@ -53,6 +68,7 @@ type CreateCollectionRequest struct {
 	OpenAPISpec    string           `json:"openapi_spec,omitempty"`     // Added for importing
 	Source         CollectionSource `json:"source" validate:"required"`
 	SourceDetail   string           `json:"source_detail"`
+	OrganizationID string           `json:"organization_id,omitempty"` // Create the collection in this organization
 }
 
 // UpdateCollectionRequest represents the request to update a collection
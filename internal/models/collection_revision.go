@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SyncTrigger tells what started a Postman sync.
+type SyncTrigger string
+
+const (
+	SyncTriggerLink     SyncTrigger = "link"     // The collection was linked
+	SyncTriggerSchedule SyncTrigger = "schedule" // The sync scheduler polled Postman
+	SyncTriggerManual   SyncTrigger = "manual"   // A user asked to sync now
+)
+
+// CollectionRevision is the content of a linked collection as one sync found it in Postman.
+type CollectionRevision struct {
+	ID               primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	CollectionID     primitive.ObjectID `json:"collection_id" bson:"collection_id"`
+	Revision         int                `json:"revision" bson:"revision"`
+	PostmanUID       string             `json:"postman_uid" bson:"postman_uid"`
+	PostmanUpdatedAt time.Time          `json:"postman_updated_at,omitempty" bson:"postman_updated_at,omitempty"`
+	ContentHash      string             `json:"content_hash" bson:"content_hash"`
+	RawPostmanJSON   string             `json:"raw_postman_json,omitempty" bson:"raw_postman_json"` // Left out of revision listings
+	Trigger          SyncTrigger        `json:"trigger" bson:"trigger"`
+	CreatedAt        time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// LinkPostmanCollectionRequest links a collection to a Postman collection.
+type LinkPostmanCollectionRequest struct {
+	PostmanUID     string `json:"postman_uid" validate:"required"`
+	AutoRegenerate bool   `json:"auto_regenerate"`
+}
+
+// PostmanSyncResult reports a sync of a linked collection.
+type PostmanSyncResult struct {
+	Collection  *Collection         `json:"collection"`
+	Changed     bool                `json:"changed"`               // A new revision was stored
+	Revision    *CollectionRevision `json:"revision,omitempty"`    // The new revision, without its content
+	Regenerated []*SDK              `json:"regenerated,omitempty"` // SDK generations started for the new revision
+}
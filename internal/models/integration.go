@@ -15,6 +15,71 @@ type Integration struct {
 	BaseURL     string                `bson:"baseURL" json:"baseURL"`
 	APIKey      types.EncryptedString `bson:"apiKey,omitempty" json:"-"`
 	OpenAPISpec string                `bson:"openapiSpec" json:"openapiSpec"`
-	CreatedAt   time.Time             `bson:"createdAt" json:"createdAt"`
-	UpdatedAt   time.Time             `bson:"updatedAt" json:"updatedAt"`
+	// APIKeyRef references the API key in a secret store, such as "vault:integrations/github#key",
+	// and is used instead of APIKey.
+	APIKeyRef string `bson:"apiKeyRef,omitempty" json:"apiKeyRef,omitempty"`
+	// OrganizationID restricts the integration to the members of an organization; when empty
+	// every user may use it.
+	OrganizationID string `bson:"organizationId,omitempty" json:"organizationId,omitempty"`
+	// Auth selects how requests to the integration are signed. When nil the scheme is derived from the spec.
+	Auth *IntegrationAuth `bson:"auth,omitempty" json:"auth,omitempty"`
+	// OAuth2 is the client configuration used to link accounts via the authorization-code flow.
+	OAuth2 *IntegrationOAuth2Config `bson:"oauth2,omitempty" json:"oauth2,omitempty"`
+	// ToolOverrides replaces derived tool annotations, keyed by tool name.
+	ToolOverrides map[string]ToolAnnotations `bson:"toolOverrides,omitempty" json:"toolOverrides,omitempty"`
+	// ResponseShaping controls how tool responses are projected, truncated and paginated,
+	// keyed by tool name. The "*" entry applies to tools without their own entry.
+	ResponseShaping map[string]ResponseShaping `bson:"responseShaping,omitempty" json:"responseShaping,omitempty"`
+	CreatedAt       time.Time                  `bson:"createdAt" json:"createdAt"`
+	UpdatedAt       time.Time                  `bson:"updatedAt" json:"updatedAt"`
+}
+
+// HasAPIKey reports whether the integration has a shared API key, as a value or a reference.
+func (i *Integration) HasAPIKey() bool {
+	return i.APIKey != "" || i.APIKeyRef != ""
+}
+
+// IntegrationAPIKeyRequest sets the shared API key of an integration as a value or a reference
+// to a secret store. Setting neither clears the key.
+type IntegrationAPIKeyRequest struct {
+	APIKey    string `json:"apiKey,omitempty"`
+	APIKeyRef string `json:"apiKeyRef,omitempty"`
+}
+
+// ResponseShaping configures how the JSON response of a tool is turned into a tool result.
+type ResponseShaping struct {
+	// JSONPath projects the response with a JSONPath expression, e.g. "$.items[*].name".
+	JSONPath string `bson:"jsonPath,omitempty" json:"jsonPath,omitempty"`
+	// JMESPath projects the response with a JMESPath expression, e.g. "items[].{id: id, name: name}".
+	// At most one of JSONPath and JMESPath may be set.
+	JMESPath string `bson:"jmesPath,omitempty" json:"jmesPath,omitempty"`
+	// MaxBytes is the size above which results are truncated; 0 uses the default.
+	MaxBytes int `bson:"maxBytes,omitempty" json:"maxBytes,omitempty"`
+	// Pagination describes how to request the next page. Without it, a Link header
+	// with rel="next" is followed when it maps onto the operation's query parameters.
+	Pagination *PaginationConfig `bson:"pagination,omitempty" json:"pagination,omitempty"`
+}
+
+// PaginationConfig describes the pagination scheme of an operation.
+type PaginationConfig struct {
+	// CursorPath is a JMESPath expression selecting the next-page cursor in the unprojected response.
+	CursorPath string `bson:"cursorPath,omitempty" json:"cursorPath,omitempty"`
+	// CursorParam is the argument the cursor is passed back in.
+	CursorParam string `bson:"cursorParam,omitempty" json:"cursorParam,omitempty"`
+	// PageParam is a page-number argument incremented for each page, for APIs without cursors.
+	// Paging stops at the first page whose projected result is empty.
+	PageParam string `bson:"pageParam,omitempty" json:"pageParam,omitempty"`
+}
+
+// IntegrationAuth selects and configures the security scheme used to sign requests to an integration.
+// Options are not secret: credentials come from linked accounts or the integration API key.
+type IntegrationAuth struct {
+	// SchemeName picks an entry of the spec's securitySchemes.
+	SchemeName string `bson:"schemeName,omitempty" json:"schemeName,omitempty"`
+	// Type is a signer type such as api_key, basic, bearer, oauth2, hmac or aws_sigv4.
+	// It overrides the type derived from SchemeName.
+	Type string `bson:"type,omitempty" json:"type,omitempty"`
+	// Options configure the signer, e.g. in and name for api_key, region and service for aws_sigv4.
+	// They are merged over the options derived from SchemeName.
+	Options map[string]string `bson:"options,omitempty" json:"options,omitempty"`
 }
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LinkedAccountAuthType is the kind of credential a linked account holds.
+type LinkedAccountAuthType string
+
+const (
+	LinkedAccountAuthAPIKey LinkedAccountAuthType = "api_key"
+	LinkedAccountAuthBasic  LinkedAccountAuthType = "basic"
+	LinkedAccountAuthBearer LinkedAccountAuthType = "bearer"
+	LinkedAccountAuthOAuth2 LinkedAccountAuthType = "oauth2"
+	// LinkedAccountAuthSignature holds a key ID and secret for signature schemes such as HMAC or AWS SigV4.
+	LinkedAccountAuthSignature LinkedAccountAuthType = "signature"
+)
+
+// Credential fields whose secrets may be kept in a secret store, the keys of SecretRefs.
+const (
+	CredentialAPIKey   = "apiKey"
+	CredentialPassword = "password"
+	CredentialToken    = "token"
+	CredentialSecret   = "secret"
+)
+
+// LinkedAccount connects one owner's own credentials to an integration.
+// MCP servers resolve the account by linked account owner ID when executing tools,
+// so that calls run as the end user instead of with the integration-level API key.
+type LinkedAccount struct {
+	ID            primitive.ObjectID       `bson:"_id,omitempty" json:"id,omitempty"`
+	IntegrationID primitive.ObjectID       `bson:"integrationId" json:"integrationId"`
+	OwnerID       string                   `bson:"ownerId" json:"ownerId"` // Linked account owner ID passed by MCP clients
+	UserID        string                   `bson:"userId" json:"userId"`   // Platform user that manages the account
+	AuthType      LinkedAccountAuthType    `bson:"authType" json:"authType"`
+	Enabled       bool                     `bson:"enabled" json:"enabled"`
+	Credentials   LinkedAccountCredentials `bson:"credentials" json:"-"`
+	LastUsedAt    *time.Time               `bson:"lastUsedAt,omitempty" json:"lastUsedAt,omitempty"`
+	CreatedAt     time.Time                `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time                `bson:"updatedAt" json:"updatedAt"`
+}
+
+// LinkedAccountCredentials holds the secrets of a linked account. Secret fields are
+// encrypted at rest and never serialised to JSON.
+type LinkedAccountCredentials struct {
+	APIKey       types.EncryptedString `bson:"apiKey,omitempty"`
+	Username     string                `bson:"username,omitempty"`
+	Password     types.EncryptedString `bson:"password,omitempty"`
+	Token        types.EncryptedString `bson:"token,omitempty"`
+	AccessToken  types.EncryptedString `bson:"accessToken,omitempty"`
+	RefreshToken types.EncryptedString `bson:"refreshToken,omitempty"`
+	TokenType    string                `bson:"tokenType,omitempty"`
+	ExpiresAt    *time.Time            `bson:"expiresAt,omitempty"`
+	KeyID        string                `bson:"keyId,omitempty"`
+	Secret       types.EncryptedString `bson:"secret,omitempty"`
+	// SecretRefs reference secrets in a secret store by credential field, used instead of the
+	// values of those fields. They lie in the namespace of the account's owner.
+	SecretRefs map[string]string `bson:"secretRefs,omitempty"`
+}
+
+// CreateLinkedAccountRequest is the payload for linking credentials to an integration.
+type CreateLinkedAccountRequest struct {
+	IntegrationID string                `json:"integrationId" validate:"required"`
+	OwnerID       string                `json:"ownerId,omitempty"` // Defaults to the calling user; others must be managed by the caller or not linked yet
+	AuthType      LinkedAccountAuthType `json:"authType" validate:"required,oneof=api_key basic bearer oauth2 signature"`
+	APIKey        string                `json:"apiKey,omitempty"`
+	Username      string                `json:"username,omitempty"`
+	Password      string                `json:"password,omitempty"`
+	Token         string                `json:"token,omitempty"`
+	AccessToken   string                `json:"accessToken,omitempty"`
+	RefreshToken  string                `json:"refreshToken,omitempty"`
+	TokenType     string                `json:"tokenType,omitempty"`
+	ExpiresAt     *time.Time            `json:"expiresAt,omitempty"`
+	KeyID         string                `json:"keyId,omitempty"`
+	Secret        string                `json:"secret,omitempty"`
+	// SecretRefs reference secrets in a secret store, keyed by apiKey, password, token or secret,
+	// instead of passing their values. Their paths must start with linked-accounts/<owner ID>/.
+	SecretRefs map[string]string `json:"secretRefs,omitempty"`
+}
+
+// ToolExecution records which linked account executed which tool.
+type ToolExecution struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	IntegrationID   primitive.ObjectID `bson:"integrationId" json:"integrationId"`
+	LinkedAccountID primitive.ObjectID `bson:"linkedAccountId,omitempty" json:"linkedAccountId,omitempty"`
+	OwnerID         string             `bson:"ownerId,omitempty" json:"ownerId,omitempty"`
+	ToolName        string             `bson:"toolName" json:"toolName"`
+	StatusCode      int                `bson:"statusCode,omitempty" json:"statusCode,omitempty"`
+	Success         bool               `bson:"success" json:"success"`
+	Error           string             `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMs      int64              `bson:"durationMs" json:"durationMs"`
+	CreatedAt       time.Time          `bson:"createdAt" json:"createdAt"`
+}
@@ -2,14 +2,86 @@ package models
 
 // Tool represents a single capability or function that can be executed by the MCP.
 type Tool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"inputSchema"`
+	Name         string                 `json:"name"`
+	Title        string                 `json:"title,omitempty"`
+	Description  string                 `json:"description"`
+	Category     string                 `json:"category,omitempty"`
+	Tags         []string               `json:"tags,omitempty"`
+	InputSchema  map[string]interface{} `json:"inputSchema"`
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+	Annotations  *ToolAnnotations       `json:"annotations,omitempty"`
+}
+
+// ToolAnnotations are the MCP behavioural hints attached to a tool.
+// Hints are pointers so that "unset" can be told apart from an explicit false.
+type ToolAnnotations struct {
+	Title           string `bson:"title,omitempty" json:"title,omitempty"`
+	ReadOnlyHint    *bool  `bson:"readOnlyHint,omitempty" json:"readOnlyHint,omitempty"`
+	DestructiveHint *bool  `bson:"destructiveHint,omitempty" json:"destructiveHint,omitempty"`
+	IdempotentHint  *bool  `bson:"idempotentHint,omitempty" json:"idempotentHint,omitempty"`
+	OpenWorldHint   *bool  `bson:"openWorldHint,omitempty" json:"openWorldHint,omitempty"`
+}
+
+// Apply copies every field that is set on override onto a.
+func (a *ToolAnnotations) Apply(override ToolAnnotations) {
+	if override.Title != "" {
+		a.Title = override.Title
+	}
+	if override.ReadOnlyHint != nil {
+		a.ReadOnlyHint = override.ReadOnlyHint
+	}
+	if override.DestructiveHint != nil {
+		a.DestructiveHint = override.DestructiveHint
+	}
+	if override.IdempotentHint != nil {
+		a.IdempotentHint = override.IdempotentHint
+	}
+	if override.OpenWorldHint != nil {
+		a.OpenWorldHint = override.OpenWorldHint
+	}
+}
+
+// IsDestructive reports whether a tool with these annotations may destroy data.
+// Following the MCP specification, a tool that is not read-only is assumed to be
+// destructive unless destructiveHint is explicitly false.
+func (a *ToolAnnotations) IsDestructive() bool {
+	if a == nil {
+		return true
+	}
+	if a.ReadOnlyHint != nil && *a.ReadOnlyHint {
+		return false
+	}
+	if a.DestructiveHint != nil {
+		return *a.DestructiveHint
+	}
+	return true
+}
+
+// IsDestructive reports whether the tool may destroy data, see ToolAnnotations.IsDestructive.
+func (t Tool) IsDestructive() bool {
+	return t.Annotations.IsDestructive()
 }
 
 // Resource represents a data source that can be accessed through the MCP.
 type Resource struct {
 	URI         string `json:"uri"`
 	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
 	Description string `json:"description"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceTemplate describes a family of resources by an RFC 6570 URI template.
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
 }
+
+// MIME types of MCP resources.
+const (
+	MimeTypeJSON = "application/json"
+	MimeTypeYAML = "application/yaml"
+)
@@ -16,6 +16,37 @@ type MCPInstance struct {
 	ServerType    string             `bson:"serverType" json:"serverType"`
 	ToolsURL      string             `bson:"toolsUrl,omitempty" json:"toolsUrl,omitempty"`
 	Resources     []Resource         `bson:"resources,omitempty" json:"resources"`
-	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt     time.Time          `bson:"updatedAt" json:"updatedAt"`
+	// Hosting is set when a generated MCP server is deployed for the instance as a managed process.
+	Hosting   *MCPHosting `bson:"hosting,omitempty" json:"hosting,omitempty"`
+	CreatedAt time.Time   `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time   `bson:"updatedAt" json:"updatedAt"`
+}
+
+// MCPHostingStatusBuilding is the status of a hosted server while its artifact is unpacked and built.
+// The other statuses are shared with managed MCP servers, see MCPServerStatusRunning.
+const MCPHostingStatusBuilding = "building"
+
+// MCPHosting is the deployment of a generated MCP server artifact as a supervised child process.
+type MCPHosting struct {
+	// SDKID is the MCP generation record whose artifact is deployed.
+	SDKID    primitive.ObjectID `bson:"sdkId" json:"sdkId"`
+	Language string             `bson:"language,omitempty" json:"language,omitempty"`
+	// Command is the built server's command line; empty until the build succeeds.
+	Command []string `bson:"command,omitempty" json:"command,omitempty"`
+	// Dir is the directory the artifact was unpacked and built in on the host.
+	Dir          string    `bson:"dir,omitempty" json:"-"`
+	Port         int       `bson:"port,omitempty" json:"port,omitempty"`
+	DesiredState string    `bson:"desiredState" json:"desiredState"`
+	Status       string    `bson:"status" json:"status"`
+	PID          int       `bson:"pid,omitempty" json:"pid,omitempty"`
+	Restarts     int       `bson:"restarts" json:"restarts"`
+	LastError    string    `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	DeployedAt   time.Time `bson:"deployedAt" json:"deployedAt"`
+	StartedAt    time.Time `bson:"startedAt,omitempty" json:"startedAt,omitempty"`
+	StoppedAt    time.Time `bson:"stoppedAt,omitempty" json:"stoppedAt,omitempty"`
+}
+
+// DeployMCPHostingRequest is the request body for deploying a generated MCP server for an instance.
+type DeployMCPHostingRequest struct {
+	SDKID string `json:"sdkId" validate:"required"`
 }
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/types"
+)
+
+// MCPServerType represents the type of a managed MCP server.
+type MCPServerType string
+
+const (
+	MCPServerTypeUnified MCPServerType = "unified"
+	MCPServerTypeApps    MCPServerType = "apps"
+	// MCPServerTypeBridge runs a stdio MCP server command and exposes it over HTTP.
+	MCPServerTypeBridge MCPServerType = "bridge"
+	// MCPServerTypeGateway aggregates several upstream MCP servers behind one endpoint.
+	MCPServerTypeGateway MCPServerType = "gateway"
+)
+
+// Desired states of a managed MCP server.
+const (
+	MCPServerDesiredRunning = "running"
+	MCPServerDesiredStopped = "stopped"
+)
+
+// Observed statuses of a managed MCP server.
+const (
+	MCPServerStatusStarting = "starting"
+	MCPServerStatusRunning  = "running"
+	MCPServerStatusStopping = "stopping"
+	MCPServerStatusStopped  = "stopped"
+	// MCPServerStatusFailed is a server that exited unexpectedly and waits for its restart.
+	MCPServerStatusFailed = "failed"
+)
+
+// Health of a managed MCP server, as reported by its health check.
+const (
+	MCPServerHealthUnknown   = "unknown"
+	MCPServerHealthHealthy   = "healthy"
+	MCPServerHealthUnhealthy = "unhealthy"
+)
+
+// MCPServerConfig holds configuration for starting an MCP server.
+type MCPServerConfig struct {
+	Type                 MCPServerType `bson:"type" json:"type"`
+	TransportType        string        `bson:"transportType" json:"transport_type"` // "stdio", "sse" or, for bridge and gateway servers, "streamable-http"
+	Port                 int           `bson:"port,omitempty" json:"port,omitempty"`
+	LinkedAccountOwnerID string        `bson:"linkedAccountOwnerId" json:"linked_account_owner_id"`
+	AllowedApps          []string      `bson:"allowedApps,omitempty" json:"allowed_apps,omitempty"`           // For apps server
+	AllowedAppsOnly      bool          `bson:"allowedAppsOnly" json:"allowed_apps_only"`                      // For unified server
+	DestructiveTools     string        `bson:"destructiveTools,omitempty" json:"destructive_tools,omitempty"` // "allow" (default), "confirm" or "hide"
+	// Command is the stdio server command line of a bridge server.
+	Command []string `bson:"command,omitempty" json:"command,omitempty"`
+	// Env are extra environment variables of a bridge server's command, stored encrypted.
+	Env map[string]types.EncryptedString `bson:"env,omitempty" json:"env,omitempty"`
+	// Upstreams are the servers a gateway server aggregates.
+	Upstreams []MCPGatewayUpstream `bson:"upstreams,omitempty" json:"upstreams,omitempty"`
+	// Access limits what a gateway server exposes to every client.
+	Access MCPGatewayAccess `bson:"access,omitempty" json:"access,omitempty"`
+	// Users, when set, are the only ones who may use a gateway server, each with their own access lists.
+	Users []MCPGatewayUser `bson:"users,omitempty" json:"users,omitempty"`
+}
+
+// MCPGatewayUpstream is an MCP server a gateway server connects to as a client: another
+// managed server, a remote server reached over SSE or Streamable HTTP, or a stdio command.
+type MCPGatewayUpstream struct {
+	// Name prefixes the upstream's tools and prompts ("<name>__<tool>") and resource URIs ("<name>+<uri>").
+	Name string `bson:"name" json:"name"`
+	// ServerID refers to another managed server of the same owner; transport and URL are then derived from it.
+	ServerID  string `bson:"serverId,omitempty" json:"server_id,omitempty"`
+	Transport string `bson:"transport,omitempty" json:"transport,omitempty"` // "stdio", "sse" or "streamable-http"
+	URL       string `bson:"url,omitempty" json:"url,omitempty"`
+	// Headers are sent to remote upstreams, e.g. for authorization, and stored encrypted.
+	Headers map[string]types.EncryptedString `bson:"headers,omitempty" json:"headers,omitempty"`
+	Command []string                         `bson:"command,omitempty" json:"command,omitempty"`
+	Env     map[string]types.EncryptedString `bson:"env,omitempty" json:"env,omitempty"`
+}
+
+// MCPGatewayAccess lists the entries a gateway server exposes. Patterns match namespaced
+// tool and prompt names and resource URIs, with "*" matching any run of characters;
+// deny wins over allow, and an empty allow list allows everything not denied.
+type MCPGatewayAccess struct {
+	Allow []string `bson:"allow,omitempty" json:"allow,omitempty"`
+	Deny  []string `bson:"deny,omitempty" json:"deny,omitempty"`
+}
+
+// MCPGatewayUser may use a gateway server by presenting Token as a bearer token.
+type MCPGatewayUser struct {
+	UserID string                `bson:"userId" json:"user_id"`
+	Token  types.EncryptedString `bson:"token" json:"token"`
+	Access MCPGatewayAccess      `bson:"access,omitempty" json:"access,omitempty"`
+}
+
+// MCPServer is the persisted record of a managed MCP server. The manager reconciles
+// the observed Status with DesiredState, so servers survive restarts of the process.
+type MCPServer struct {
+	ID           string          `bson:"_id" json:"id"`
+	OwnerID      string          `bson:"ownerId,omitempty" json:"owner_id,omitempty"`
+	Config       MCPServerConfig `bson:"config" json:"config"`
+	DesiredState string          `bson:"desiredState" json:"desired_state"`
+	Status       string          `bson:"status" json:"status"`
+	Health       string          `bson:"health" json:"health"`
+	// Restarts counts consecutive unexpected exits; it is reset once the server stays up.
+	Restarts      int       `bson:"restarts" json:"restarts"`
+	LastError     string    `bson:"lastError,omitempty" json:"last_error,omitempty"`
+	StartedAt     time.Time `bson:"startedAt,omitempty" json:"started_at,omitempty"`
+	StoppedAt     time.Time `bson:"stoppedAt,omitempty" json:"stopped_at,omitempty"`
+	NextRestartAt time.Time `bson:"nextRestartAt,omitempty" json:"next_restart_at,omitempty"`
+	LastHealthAt  time.Time `bson:"lastHealthAt,omitempty" json:"last_health_at,omitempty"`
+	CreatedAt     time.Time `bson:"createdAt" json:"created_at"`
+	UpdatedAt     time.Time `bson:"updatedAt" json:"updated_at"`
+}
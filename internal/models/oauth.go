@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IntegrationOAuth2Config is the OAuth2 client configuration of an integration.
+// Endpoints and scopes are derived from the spec's securitySchemes; the client
+// credentials are registered with the provider and set by an administrator.
+type IntegrationOAuth2Config struct {
+	SchemeName       string                `bson:"schemeName" json:"schemeName"`
+	ClientID         string                `bson:"clientId" json:"clientId"`
+	ClientSecret     types.EncryptedString `bson:"clientSecret,omitempty" json:"-"`
+	AuthorizationURL string                `bson:"authorizationUrl" json:"authorizationUrl"`
+	TokenURL         string                `bson:"tokenUrl" json:"tokenUrl"`
+	RevocationURL    string                `bson:"revocationUrl,omitempty" json:"revocationUrl,omitempty"`
+	Scopes           []string              `bson:"scopes,omitempty" json:"scopes,omitempty"`
+}
+
+// UpdateOAuth2ConfigRequest sets the client credentials of an integration's OAuth2 config.
+// Empty endpoint fields keep the values derived from the spec.
+type UpdateOAuth2ConfigRequest struct {
+	SchemeName       string   `json:"schemeName,omitempty"`
+	ClientID         string   `json:"clientId" validate:"required"`
+	ClientSecret     string   `json:"clientSecret,omitempty"`
+	AuthorizationURL string   `json:"authorizationUrl,omitempty"`
+	TokenURL         string   `json:"tokenUrl,omitempty"`
+	RevocationURL    string   `json:"revocationUrl,omitempty"`
+	Scopes           []string `json:"scopes,omitempty"`
+}
+
+// OAuthState is a pending authorization-code flow, keyed by the state parameter.
+type OAuthState struct {
+	ID            primitive.ObjectID    `bson:"_id,omitempty" json:"-"`
+	State         string                `bson:"state" json:"-"`
+	IntegrationID primitive.ObjectID    `bson:"integrationId" json:"-"`
+	OwnerID       string                `bson:"ownerId" json:"-"`
+	UserID        string                `bson:"userId" json:"-"`
+	CodeVerifier  types.EncryptedString `bson:"codeVerifier" json:"-"`
+	ReturnTo      string                `bson:"returnTo,omitempty" json:"-"`
+	ExpiresAt     time.Time             `bson:"expiresAt" json:"-"`
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrganizationRole is the role of a member in an organization: a built-in role or the name
+// of a custom role of the organization.
+type OrganizationRole string
+
+const (
+	OrganizationRoleOwner  OrganizationRole = "owner"
+	OrganizationRoleAdmin  OrganizationRole = "admin"
+	OrganizationRoleMember OrganizationRole = "member"
+	OrganizationRoleViewer OrganizationRole = "viewer"
+)
+
+// IsValid reports whether the role is one of the built-in organization roles.
+func (r OrganizationRole) IsValid() bool {
+	_, ok := OrganizationRoles[r]
+	return ok
+}
+
+// Organization groups users that share collections, SDKs and integrations.
+type Organization struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name      string             `bson:"name" json:"name"`
+	CreatedBy primitive.ObjectID `bson:"createdBy" json:"createdBy"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// OrganizationMember is the membership of a user in an organization.
+type OrganizationMember struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	OrganizationID primitive.ObjectID `bson:"organizationId" json:"organizationId"`
+	UserID         primitive.ObjectID `bson:"userId" json:"userId"`
+	Role           OrganizationRole   `bson:"role" json:"role"`
+	// Source is MembershipSourceSSO for memberships that follow the user's OIDC groups.
+	Source string `bson:"source,omitempty" json:"source,omitempty"`
+	// Name and Email are filled in from the user when members are listed.
+	Name      string    `bson:"-" json:"name,omitempty"`
+	Email     string    `bson:"-" json:"email,omitempty"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+// OrganizationInvitation invites an email address to join an organization. Only the
+// SHA-256 of the invitation token is stored; the token is shown once, on creation.
+type OrganizationInvitation struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	OrganizationID primitive.ObjectID `bson:"organizationId" json:"organizationId"`
+	Email          string             `bson:"email" json:"email"`
+	Role           OrganizationRole   `bson:"role" json:"role"`
+	TokenHash      string             `bson:"tokenHash" json:"-"`
+	InvitedBy      primitive.ObjectID `bson:"invitedBy" json:"invitedBy"`
+	ExpiresAt      time.Time          `bson:"expiresAt" json:"expiresAt"`
+	AcceptedAt     *time.Time         `bson:"acceptedAt,omitempty" json:"acceptedAt,omitempty"`
+	AcceptedBy     primitive.ObjectID `bson:"acceptedBy,omitempty" json:"acceptedBy,omitempty"`
+	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// OrganizationMembership is an organization together with the role of the user listing it.
+type OrganizationMembership struct {
+	*Organization
+	Role OrganizationRole `json:"role"`
+}
+
+// CreateOrganizationRequest is the body of a request to create or rename an organization.
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+// CreateInvitationRequest is the body of a request to invite someone to an organization.
+type CreateInvitationRequest struct {
+	Email string           `json:"email"`
+	Role  OrganizationRole `json:"role"`
+}
+
+// CreatedInvitation is the response to creating an invitation; it is the only time Token is
+// returned, for the inviter to send to the invited address.
+type CreatedInvitation struct {
+	*OrganizationInvitation
+	Token string `json:"token"`
+}
+
+// AcceptInvitationRequest is the body of a request to accept an invitation.
+type AcceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+// UpdateMemberRequest is the body of a request to change the role of a member.
+type UpdateMemberRequest struct {
+	Role OrganizationRole `json:"role"`
+}
+
+// TransferRequest moves a collection into an organization, or back to its creator when
+// OrganizationID is empty.
+type TransferRequest struct {
+	OrganizationID string `json:"organization_id"`
+}
@@ -0,0 +1,221 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ResourceKind is a kind of thing permissions apply to.
+type ResourceKind string
+
+// Platform resources, governed by the platform role of the user.
+const (
+	ResourceUsers                  ResourceKind = "users"
+	ResourceSettings               ResourceKind = "settings"
+	ResourceIntegrations           ResourceKind = "integrations"
+	ResourceIntegrationCredentials ResourceKind = "integration_credentials"
+	ResourceMCPServers             ResourceKind = "mcp_servers"
+	ResourceLogs                   ResourceKind = "logs"
+	ResourceAuditLog               ResourceKind = "audit_log"
+)
+
+// Organization resources, governed by the role of the user in the organization that owns them.
+const (
+	ResourceCollections  ResourceKind = "collections"
+	ResourceSDKs         ResourceKind = "sdks"
+	ResourceOrganization ResourceKind = "organization"
+)
+
+// Action is something a user may be permitted to do to a resource.
+type Action string
+
+const (
+	ActionRead   Action = "read"
+	ActionWrite  Action = "write"
+	ActionDelete Action = "delete"
+	// ActionManage renames and deletes an organization and appoints its owners and roles, or
+	// starts and stops MCP servers.
+	ActionManage Action = "manage"
+	// ActionManageMembers invites the members of an organization, changes their roles and removes them.
+	ActionManageMembers Action = "manage_members"
+	// ActionManageRoles changes the platform roles of users.
+	ActionManageRoles Action = "manage_roles"
+)
+
+// Permission is an action on a resource, written "resource:action". In roles a permission may
+// be a pattern: "resource:*" grants every action on the resource and "*" grants everything.
+type Permission string
+
+// NewPermission returns the permission to perform the action on the resource.
+func NewPermission(resource ResourceKind, action Action) Permission {
+	return Permission(string(resource) + ":" + string(action))
+}
+
+// Platform permissions.
+const (
+	PermissionUsersRead                  Permission = "users:read"
+	PermissionUsersWrite                 Permission = "users:write"
+	PermissionUsersDelete                Permission = "users:delete"
+	PermissionUsersManageRoles           Permission = "users:manage_roles"
+	PermissionSettingsRead               Permission = "settings:read"
+	PermissionSettingsWrite              Permission = "settings:write"
+	PermissionIntegrationsRead           Permission = "integrations:read"
+	PermissionIntegrationsWrite          Permission = "integrations:write"
+	PermissionIntegrationCredentialsRead Permission = "integration_credentials:read"
+	// PermissionIntegrationCredentialsWrite changes the auth and OAuth2 configuration of integrations.
+	PermissionIntegrationCredentialsWrite Permission = "integration_credentials:write"
+	PermissionMCPServersRead              Permission = "mcp_servers:read"
+	// PermissionMCPServersManage starts, stops and cleans up the MCP servers of every user.
+	PermissionMCPServersManage Permission = "mcp_servers:manage"
+	PermissionLogsRead         Permission = "logs:read"
+	// PermissionAuditLogRead queries and exports the audit log.
+	PermissionAuditLogRead Permission = "audit_log:read"
+)
+
+// Organization permissions.
+const (
+	PermissionCollectionsRead           Permission = "collections:read"
+	PermissionCollectionsWrite          Permission = "collections:write"
+	PermissionCollectionsDelete         Permission = "collections:delete"
+	PermissionSDKsRead                  Permission = "sdks:read"
+	PermissionSDKsWrite                 Permission = "sdks:write"
+	PermissionSDKsDelete                Permission = "sdks:delete"
+	PermissionOrganizationManageMembers Permission = "organization:manage_members"
+	PermissionOrganizationManage        Permission = "organization:manage"
+)
+
+// permissionActions lists the actions of each resource.
+var permissionActions = map[ResourceKind][]Action{
+	ResourceUsers:                  {ActionRead, ActionWrite, ActionDelete, ActionManageRoles},
+	ResourceSettings:               {ActionRead, ActionWrite},
+	ResourceIntegrations:           {ActionRead, ActionWrite},
+	ResourceIntegrationCredentials: {ActionRead, ActionWrite},
+	ResourceMCPServers:             {ActionRead, ActionManage},
+	ResourceLogs:                   {ActionRead},
+	ResourceAuditLog:               {ActionRead},
+	ResourceCollections:            {ActionRead, ActionWrite, ActionDelete},
+	ResourceSDKs:                   {ActionRead, ActionWrite, ActionDelete},
+	ResourceOrganization:           {ActionManageMembers, ActionManage},
+}
+
+// organizationResources are the resources an organization role may grant. Integrations are
+// platform resources, but reading one owned by an organization is up to the organization.
+var organizationResources = map[ResourceKind]bool{
+	ResourceCollections:  true,
+	ResourceSDKs:         true,
+	ResourceIntegrations: true,
+	ResourceOrganization: true,
+}
+
+// Split returns the resource and action of the permission.
+func (p Permission) Split() (ResourceKind, Action) {
+	resource, action, _ := strings.Cut(string(p), ":")
+	return ResourceKind(resource), Action(action)
+}
+
+// IsValid reports whether the permission is a known action on a known resource.
+func (p Permission) IsValid() bool {
+	resource, action := p.Split()
+	for _, known := range permissionActions[resource] {
+		if known == action {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidPattern reports whether the permission is valid or a pattern of valid permissions.
+func (p Permission) IsValidPattern() bool {
+	if p == "*" {
+		return true
+	}
+	if resource, action := p.Split(); action == "*" {
+		_, ok := permissionActions[resource]
+		return ok
+	}
+	return p.IsValid()
+}
+
+// IsOrganizationPermission reports whether an organization role may grant the permission pattern.
+func (p Permission) IsOrganizationPermission() bool {
+	resource, _ := p.Split()
+	return p.IsValidPattern() && organizationResources[resource]
+}
+
+// Matches reports whether the permission pattern grants the permission.
+func (p Permission) Matches(permission Permission) bool {
+	if p == "*" || p == permission {
+		return true
+	}
+	resource, action := p.Split()
+	wanted, _ := permission.Split()
+	return action == "*" && resource == wanted
+}
+
+// Role is a named set of permissions: a built-in platform or organization role, or a custom
+// role defined by an organization.
+type Role struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	OrganizationID primitive.ObjectID `bson:"organizationId,omitempty" json:"organizationId,omitempty"`
+	Name           string             `bson:"name" json:"name"`
+	Description    string             `bson:"description,omitempty" json:"description,omitempty"`
+	Permissions    []Permission       `bson:"permissions" json:"permissions"`
+	BuiltIn        bool               `bson:"-" json:"builtIn"`
+	CreatedAt      time.Time          `bson:"createdAt,omitempty" json:"createdAt,omitempty"`
+	UpdatedAt      time.Time          `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
+}
+
+// Grants returns the permission pattern of the role that grants the permission, if any.
+func (r *Role) Grants(permission Permission) (Permission, bool) {
+	for _, granted := range r.Permissions {
+		if granted.Matches(permission) {
+			return granted, true
+		}
+	}
+	return "", false
+}
+
+// PlatformRoles are the permissions of each platform role. Users act on their own and their
+// organizations' resources through ownership and organization roles, so they have no platform permissions.
+var PlatformRoles = map[UserRole]*Role{
+	RoleAdmin: {Name: string(RoleAdmin), Description: "Full access to the platform", Permissions: []Permission{"*"}, BuiltIn: true},
+	RoleModerator: {Name: string(RoleModerator), Description: "Reviews users, integrations and running MCP servers", Permissions: []Permission{
+		PermissionUsersRead, PermissionIntegrationsRead, PermissionMCPServersRead, PermissionMCPServersManage, PermissionLogsRead,
+	}, BuiltIn: true},
+	RoleUser: {Name: string(RoleUser), Description: "Signed-in user", Permissions: []Permission{}, BuiltIn: true},
+}
+
+// OrganizationRoles are the built-in organization roles; every role has the permissions of the roles below it.
+var OrganizationRoles = map[OrganizationRole]*Role{
+	OrganizationRoleViewer: {Name: string(OrganizationRoleViewer), Description: "Reads the organization's collections, SDKs and integrations", Permissions: []Permission{
+		PermissionCollectionsRead, PermissionSDKsRead, PermissionIntegrationsRead,
+	}, BuiltIn: true},
+	OrganizationRoleMember: {Name: string(OrganizationRoleMember), Description: "Creates and edits collections and generates SDKs", Permissions: []Permission{
+		PermissionCollectionsRead, PermissionCollectionsWrite, PermissionSDKsRead, PermissionSDKsWrite, PermissionIntegrationsRead,
+	}, BuiltIn: true},
+	OrganizationRoleAdmin: {Name: string(OrganizationRoleAdmin), Description: "Deletes anything in the organization and manages its members", Permissions: []Permission{
+		"collections:*", "sdks:*", PermissionIntegrationsRead, PermissionOrganizationManageMembers,
+	}, BuiltIn: true},
+	OrganizationRoleOwner: {Name: string(OrganizationRoleOwner), Description: "Manages the organization, its owners and its roles", Permissions: []Permission{"*"}, BuiltIn: true},
+}
+
+// PolicyDecision explains whether a permission was granted and why.
+type PolicyDecision struct {
+	Permission     Permission `json:"permission"`
+	OrganizationID string     `json:"organizationId,omitempty"`
+	Allowed        bool       `json:"allowed"`
+	// Role is the role that was evaluated, if the user has one.
+	Role string `json:"role,omitempty"`
+	// GrantedBy is the permission pattern of the role that granted the permission.
+	GrantedBy Permission `json:"grantedBy,omitempty"`
+	Reason    string     `json:"reason"`
+}
+
+// RoleRequest is the body of a request to create or update a custom organization role.
+type RoleRequest struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+}
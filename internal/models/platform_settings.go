@@ -8,13 +8,15 @@ import (
 
 // PlatformSettings holds the general platform settings as a single document.
 type PlatformSettings struct {
-	ID                    primitive.ObjectID     `bson:"_id,omitempty" json:"id,omitempty"`
-	PostmanAPIKey         string                 `bson:"postmanApiKey,omitempty" json:"postmanApiKey,omitempty"`
-	MaintenanceMode       bool                   `bson:"maintenanceMode,omitempty" json:"maintenanceMode,omitempty"`             // Added Maintenance Mode
-	LogConfig             LogSettings            `bson:"logConfig,omitempty" json:"logConfig,omitempty"`                         // Added for log management settings
-	SupportedSDKLanguages []string               `bson:"supportedSdkLanguages,omitempty" json:"supportedSdkLanguages,omitempty"` // Added for supported SDK languages
-	Settings              map[string]interface{} `bson:"settings" json:"settings"`
-	UpdatedAt             time.Time              `bson:"updatedAt" json:"updatedAt"`
+	ID                    primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	PostmanAPIKey         string             `bson:"postmanApiKey,omitempty" json:"postmanApiKey,omitempty"`
+	MaintenanceMode       bool               `bson:"maintenanceMode,omitempty" json:"maintenanceMode,omitempty"`             // Added Maintenance Mode
+	LogConfig             LogSettings        `bson:"logConfig,omitempty" json:"logConfig,omitempty"`                         // Added for log management settings
+	SupportedSDKLanguages []string           `bson:"supportedSdkLanguages,omitempty" json:"supportedSdkLanguages,omitempty"` // Added for supported SDK languages
+	// OIDC configures single sign-on; it is changed through its own endpoint, not the settings map.
+	OIDC      *OIDCSettings          `bson:"oidc,omitempty" json:"oidc,omitempty"`
+	Settings  map[string]interface{} `bson:"settings" json:"settings"`
+	UpdatedAt time.Time              `bson:"updatedAt" json:"updatedAt"`
 }
 
 // LogSettings defines configuration for system logging.
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Prompt sources.
+const (
+	// PromptSourceGenerated marks prompts derived from an integration's spec.
+	PromptSourceGenerated = "generated"
+	// PromptSourceCustom marks prompts authored by an admin.
+	PromptSourceCustom = "custom"
+)
+
+// Prompt is an MCP prompt template of an integration. Message texts may reference the
+// arguments as {{name}}; they are substituted when the prompt is rendered.
+type Prompt struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	IntegrationID primitive.ObjectID `bson:"integrationId" json:"integrationId"`
+	Name          string             `bson:"name" json:"name"`
+	Title         string             `bson:"title,omitempty" json:"title,omitempty"`
+	Description   string             `bson:"description,omitempty" json:"description,omitempty"`
+	Arguments     []PromptArgument   `bson:"arguments,omitempty" json:"arguments,omitempty"`
+	Messages      []PromptMessage    `bson:"messages" json:"messages"`
+	// Source is PromptSourceGenerated or PromptSourceCustom. It is not stored, as only custom prompts are.
+	Source    string    `bson:"-" json:"source,omitempty"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt,omitempty"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt,omitempty"`
+}
+
+// PromptArgument is an argument of a prompt template.
+type PromptArgument struct {
+	Name        string `bson:"name" json:"name"`
+	Description string `bson:"description,omitempty" json:"description,omitempty"`
+	Required    bool   `bson:"required,omitempty" json:"required,omitempty"`
+}
+
+// PromptMessage is a message of a prompt template.
+type PromptMessage struct {
+	// Role is "user" or "assistant".
+	Role string `bson:"role" json:"role"`
+	Text string `bson:"text" json:"text"`
+}
+
+// Prompt message roles.
+const (
+	PromptRoleUser      = "user"
+	PromptRoleAssistant = "assistant"
+)
+
+// PromptDescriptor is a prompt as listed by an MCP prompts/list request.
+type PromptDescriptor struct {
+	Name        string           `json:"name"`
+	Title       string           `json:"title,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptResult is the result of an MCP prompts/get request.
+type PromptResult struct {
+	Description string                `json:"description,omitempty"`
+	Messages    []PromptResultMessage `json:"messages"`
+}
+
+// PromptResultMessage is a rendered prompt message.
+type PromptResultMessage struct {
+	Role    string       `json:"role"`
+	Content ContentBlock `json:"content"`
+}
+
+// Descriptor returns the prompt as listed to MCP clients, under name.
+func (p *Prompt) Descriptor(name string) PromptDescriptor {
+	return PromptDescriptor{Name: name, Title: p.Title, Description: p.Description, Arguments: p.Arguments}
+}
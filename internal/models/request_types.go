@@ -30,7 +30,8 @@ type MaintenanceModeRequest struct {
 // MCPGenerationRequest defines the parameters for generating an MCP server.
 type MCPGenerationRequest struct {
 	CollectionID string `json:"collectionId" validate:"required"`
-	Transport    string `json:"transport" validate:"required"` // e.g., "http", "grpc"
-	Port         int    `json:"port" validate:"required,gte=1,lte=65535"`
-	// Add any other MCP-specific options here
+	Transport    string `json:"transport" validate:"required,oneof=stdio sse streamable-http web"` // "web" is an alias of "sse"
+	Port         int    `json:"port,omitempty" validate:"omitempty,gte=1,lte=65535"`               // Only used by sse and streamable-http
+	Language     string `json:"language,omitempty" validate:"omitempty,oneof=go typescript"`       // Defaults to go
+	ServerName   string `json:"serverName,omitempty" validate:"omitempty,max=100"`                 // Defaults to the API title
 }
@@ -28,7 +28,8 @@ const (
 // SDK represents an SDK or MCP generation record.
 type SDK struct {
 	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	UserID         string             `bson:"userId" json:"userId"` // User who generated the SDK
+	UserID         string             `bson:"userId" json:"userId"`                                     // User who generated the SDK
+	OrganizationID string             `bson:"organizationId,omitempty" json:"organizationId,omitempty"` // Organization of the collection, if any
 	CollectionID   string             `bson:"collectionId,omitempty" json:"collectionId,omitempty"`
 	GenerationType GenerationType     `bson:"generationType" json:"generationType"` // New: "sdk" or "mcp"
 
@@ -39,6 +40,7 @@ type SDK struct {
 	// MCP-specific fields (optional if GenerationType is sdk)
 	MCPTransport string `bson:"mcpTransport,omitempty" json:"mcpTransport,omitempty"`
 	MCPPort      int    `bson:"mcpPort,omitempty" json:"mcpPort,omitempty"`
+	MCPLanguage  string `bson:"mcpLanguage,omitempty" json:"mcpLanguage,omitempty"`
 
 	Status         SDKGenerationStatus `bson:"status" json:"status"`
 	FilePath       string              `bson:"filePath,omitempty" json:"filePath,omitempty"`                 // Path to the generated SDK archive/folder
@@ -7,4 +7,5 @@ type SDKGenerationRequest struct {
 	CollectionID string `json:"collectionId" validate:"required,hexadecimal,len=24"` // Made required, assuming SDK is always from an existing collection
 	Language     string `json:"language" validate:"required"`                        // Specific language for this SDK generation (not a list)
 	PackageName  string `json:"packageName,omitempty"`                               // Optional: Package name for the SDK
+	PostmanJSON  string `json:"-"`                                                   // Content to generate from instead of fetching the collection; set by the Postman sync
 }
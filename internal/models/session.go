@@ -6,8 +6,25 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Session is a login of a user. Access tokens name the session they were issued for, and the
+// session's refresh token, which changes on every refresh, issues new ones until it expires
+// or is revoked.
 type Session struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
-	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID primitive.ObjectID `bson:"userId" json:"userId"`
+	// RefreshTokenHash is the SHA-256 of the current refresh token; PreviousRefreshTokenHash is
+	// the one it replaced, kept to detect the reuse of a rotated token.
+	RefreshTokenHash         string     `bson:"refreshTokenHash,omitempty" json:"-"`
+	PreviousRefreshTokenHash string     `bson:"previousRefreshTokenHash,omitempty" json:"-"`
+	UserAgent                string     `bson:"userAgent,omitempty" json:"userAgent,omitempty"`
+	IPAddress                string     `bson:"ipAddress,omitempty" json:"ipAddress,omitempty"`
+	CreatedAt                time.Time  `bson:"createdAt" json:"createdAt"`
+	LastUsedAt               time.Time  `bson:"lastUsedAt,omitempty" json:"lastUsedAt,omitempty"`
+	ExpiresAt                time.Time  `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+	RevokedAt                *time.Time `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+}
+
+// Active reports whether the session is neither revoked nor expired at now.
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
 }
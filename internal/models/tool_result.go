@@ -0,0 +1,67 @@
+package models
+
+import "encoding/json"
+
+// Content block types of an MCP tool result.
+const (
+	ContentTypeText         = "text"
+	ContentTypeImage        = "image"
+	ContentTypeAudio        = "audio"
+	ContentTypeResource     = "resource"
+	ContentTypeResourceLink = "resource_link"
+)
+
+// ContentBlock is one item of a tool result's content, following the MCP content types.
+// Which fields are set depends on Type.
+type ContentBlock struct {
+	Type string `json:"type"`
+	// Text is set on text blocks.
+	Text string `json:"text,omitempty"`
+	// Data is the base64-encoded payload of image and audio blocks.
+	Data     string `json:"data,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	// URI, Name and Size describe the target of a resource_link block.
+	URI  string `json:"uri,omitempty"`
+	Name string `json:"name,omitempty"`
+	Size int64  `json:"size,omitempty"`
+	// Resource is the embedded content of a resource block.
+	Resource *ResourceContents `json:"resource,omitempty"`
+}
+
+// ResourceContents is the content of a resource, either as text or as a base64-encoded blob.
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// ToolResult is the result of an MCP tools/call request.
+type ToolResult struct {
+	Content []ContentBlock `json:"content"`
+	// StructuredContent is the JSON result, set when the whole value fits in the response.
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	// IsError reports a failure of the tool itself, such as an HTTP error from the API.
+	IsError bool `json:"isError,omitempty"`
+	// Meta carries the response status and the continuation and page tokens.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// TextResult creates a tool result with a single text block.
+func TextResult(text string) *ToolResult {
+	return &ToolResult{Content: []ContentBlock{{Type: ContentTypeText, Text: text}}}
+}
+
+// JSONResult creates a tool result rendering value as JSON text, with value as the
+// structured content when it is an object.
+func JSONResult(value interface{}) *ToolResult {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return TextResult(err.Error())
+	}
+	result := TextResult(string(data))
+	if _, ok := value.(map[string]interface{}); ok {
+		result.StructuredContent = value
+	}
+	return result
+}
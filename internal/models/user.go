@@ -16,13 +16,22 @@ const (
 	// Add other roles as needed, e.g., RoleEditor
 )
 
+// IsValid reports whether the role is one of the platform roles.
+func (r UserRole) IsValid() bool {
+	_, ok := PlatformRoles[r]
+	return ok
+}
+
 // User represents a user in the system.
 type User struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Name      string             `bson:"name" json:"name"`
-	Email     string             `bson:"email" json:"email"`
-	Password  string             `bson:"password" json:"-"` // '-' to exclude from JSON responses
-	Role      UserRole           `bson:"role" json:"role"`  // Added Role field
-	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name     string             `bson:"name" json:"name"`
+	Email    string             `bson:"email" json:"email"`
+	Password string             `bson:"password" json:"-"` // '-' to exclude from JSON responses
+	Role     UserRole           `bson:"role" json:"role"`  // Added Role field
+	// SSOIssuer and SSOSubject identify the user at the OIDC provider they signed in with.
+	SSOIssuer  string    `bson:"ssoIssuer,omitempty" json:"ssoIssuer,omitempty"`
+	SSOSubject string    `bson:"ssoSubject,omitempty" json:"-"`
+	CreatedAt  time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time `bson:"updatedAt" json:"updatedAt"`
 }
@@ -0,0 +1,667 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+)
+
+// maxRefDepth bounds $ref expansion so recursive schemas cannot loop forever.
+const maxRefDepth = 8
+
+// httpMethods lists the path item keys that describe operations, in a stable order.
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+var nonToolChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// Document is a loosely-typed view of an OpenAPI 3 document.
+// It keeps the raw JSON tree so that $ref pointers can be resolved lazily.
+type Document struct {
+	Title           string
+	Description     string
+	Version         string
+	Servers         []string
+	SecuritySchemes map[string]models.SecurityScheme
+	Security        []models.OpenAPISecurityRequirement
+	// Tags are the declared tags in document order. Collections converted from Postman
+	// have one tag per folder, described by the folder description.
+	Tags       []Tag
+	Operations []*Operation
+
+	raw map[string]interface{}
+}
+
+// Operation is a single HTTP operation flattened from the paths object.
+type Operation struct {
+	ID          string                              `json:"id"`
+	ToolName    string                              `json:"toolName"`
+	Method      string                              `json:"method"`
+	Path        string                              `json:"path"`
+	Summary     string                              `json:"summary,omitempty"`
+	Description string                              `json:"description,omitempty"`
+	Tags        []string                            `json:"tags,omitempty"`
+	Deprecated  bool                                `json:"deprecated,omitempty"`
+	Parameters  []Parameter                         `json:"parameters,omitempty"`
+	RequestBody *RequestBody                        `json:"requestBody,omitempty"`
+	Responses   map[string]interface{}              `json:"responses,omitempty"`
+	Security    []models.OpenAPISecurityRequirement `json:"security,omitempty"`
+	Extensions  map[string]interface{}              `json:"extensions,omitempty"`
+	InputSchema map[string]interface{}              `json:"inputSchema"`
+	// OutputSchema is the JSON object schema of the first successful response, if any.
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+	// Position is the index of the operation in the order the spec lists them, which for
+	// converted Postman collections is the order of the requests in their folders.
+	Position int `json:"-"`
+}
+
+// Tag is a declared tag with its description.
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Parameter is a resolved operation parameter.
+type Parameter struct {
+	Name        string                 `json:"name"`
+	In          string                 `json:"in"`
+	Description string                 `json:"description,omitempty"`
+	Required    bool                   `json:"required,omitempty"`
+	Schema      map[string]interface{} `json:"schema,omitempty"`
+	Example     interface{}            `json:"example,omitempty"`
+}
+
+// RequestBody is a resolved operation request body.
+type RequestBody struct {
+	ContentType string                 `json:"contentType"`
+	Description string                 `json:"description,omitempty"`
+	Required    bool                   `json:"required,omitempty"`
+	Schema      map[string]interface{} `json:"schema,omitempty"`
+	Example     interface{}            `json:"example,omitempty"`
+}
+
+// Parse decodes an OpenAPI 3 JSON document and flattens its operations.
+func Parse(spec []byte) (*Document, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(spec, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	if _, ok := raw["paths"]; !ok {
+		if _, isSwagger := raw["swagger"]; isSwagger {
+			return nil, fmt.Errorf("swagger 2.0 documents are not supported, convert to OpenAPI 3 first")
+		}
+	}
+
+	doc := &Document{raw: raw, SecuritySchemes: map[string]models.SecurityScheme{}}
+
+	if info, ok := raw["info"].(map[string]interface{}); ok {
+		doc.Title, _ = info["title"].(string)
+		doc.Description, _ = info["description"].(string)
+		doc.Version, _ = info["version"].(string)
+	}
+
+	for _, s := range asSlice(raw["servers"]) {
+		if server, ok := s.(map[string]interface{}); ok {
+			if url, ok := server["url"].(string); ok && url != "" {
+				doc.Servers = append(doc.Servers, url)
+			}
+		}
+	}
+
+	if components, ok := raw["components"].(map[string]interface{}); ok {
+		if schemes, ok := components["securitySchemes"].(map[string]interface{}); ok {
+			for name, value := range schemes {
+				var scheme models.SecurityScheme
+				if err := remarshal(doc.resolve(value, 0), &scheme); err == nil {
+					doc.SecuritySchemes[name] = scheme
+				}
+			}
+		}
+	}
+	doc.Security = decodeSecurity(raw["security"])
+
+	for _, t := range asSlice(raw["tags"]) {
+		if tag, ok := t.(map[string]interface{}); ok {
+			name, _ := tag["name"].(string)
+			description, _ := tag["description"].(string)
+			if name != "" {
+				doc.Tags = append(doc.Tags, Tag{Name: name, Description: description})
+			}
+		}
+	}
+
+	if err := doc.collectOperations(); err != nil {
+		return nil, err
+	}
+	doc.setPositions(spec)
+
+	return doc, nil
+}
+
+// Raw returns the underlying JSON tree of the document.
+func (d *Document) Raw() map[string]interface{} {
+	return d.raw
+}
+
+// BaseURL returns the first declared server URL, or an empty string.
+func (d *Document) BaseURL() string {
+	if len(d.Servers) == 0 {
+		return ""
+	}
+	return d.Servers[0]
+}
+
+// Operation looks up an operation by its tool name or operation ID.
+func (d *Document) Operation(name string) *Operation {
+	for _, op := range d.Operations {
+		if op.ToolName == name || op.ID == name {
+			return op
+		}
+	}
+	return nil
+}
+
+// Schema returns the resolved component schema with the given name.
+func (d *Document) Schema(name string) (map[string]interface{}, bool) {
+	components, ok := d.raw["components"].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	schemas, ok := components["schemas"].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	schema, ok := schemas[name]
+	if !ok {
+		return nil, false
+	}
+	resolved, _ := d.resolve(schema, 0).(map[string]interface{})
+	return resolved, resolved != nil
+}
+
+// SchemaNames returns the names of all component schemas, sorted.
+func (d *Document) SchemaNames() []string {
+	var names []string
+	if components, ok := d.raw["components"].(map[string]interface{}); ok {
+		if schemas, ok := components["schemas"].(map[string]interface{}); ok {
+			for name := range schemas {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// collectOperations walks the paths object in a deterministic order.
+func (d *Document) collectOperations() error {
+	paths, _ := d.raw["paths"].(map[string]interface{})
+	pathKeys := make([]string, 0, len(paths))
+	for p := range paths {
+		pathKeys = append(pathKeys, p)
+	}
+	sort.Strings(pathKeys)
+
+	usedNames := make(map[string]int)
+	for _, path := range pathKeys {
+		item, ok := d.resolve(paths[path], 0).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		shared := d.parameters(item["parameters"])
+
+		for _, method := range httpMethods {
+			rawOp, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			op := d.buildOperation(path, method, rawOp, shared)
+
+			base := op.ToolName
+			if n := usedNames[base]; n > 0 {
+				op.ToolName = truncateName(fmt.Sprintf("%s_%d", base, n+1))
+			}
+			usedNames[base]++
+
+			d.Operations = append(d.Operations, op)
+		}
+	}
+	return nil
+}
+
+// setPositions numbers the operations in the order the spec lists paths and methods, which
+// the decoded JSON tree does not keep. Operations keep their alphabetical positions when the
+// spec cannot be walked.
+func (d *Document) setPositions(spec []byte) {
+	for i, op := range d.Operations {
+		op.Position = i
+	}
+	order, err := pathOrder(spec)
+	if err != nil {
+		return
+	}
+	positions := make(map[string]int, len(order))
+	for i, key := range order {
+		positions[key] = i
+	}
+	for _, op := range d.Operations {
+		if position, ok := positions[op.Path+" "+strings.ToLower(op.Method)]; ok {
+			op.Position = position
+		} else {
+			op.Position = len(order) + op.Position
+		}
+	}
+}
+
+// pathOrder returns "path method" keys for the members of each path item, in document order.
+func pathOrder(spec []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(spec))
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if key != "paths" {
+			if err := skipValue(dec); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := expectDelim(dec, '{'); err != nil {
+			return nil, err
+		}
+		var order []string
+		for dec.More() {
+			path, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			if err := expectDelim(dec, '{'); err != nil {
+				return nil, err
+			}
+			for dec.More() {
+				method, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				order = append(order, fmt.Sprintf("%v %v", path, method))
+				if err := skipValue(dec); err != nil {
+					return nil, err
+				}
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+		}
+		return order, nil
+	}
+	return nil, fmt.Errorf("no paths object")
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+// skipValue consumes the next value, however deeply nested.
+func skipValue(dec *json.Decoder) error {
+	var raw json.RawMessage
+	return dec.Decode(&raw)
+}
+
+func (d *Document) buildOperation(path, method string, rawOp map[string]interface{}, shared []Parameter) *Operation {
+	op := &Operation{
+		Method:     strings.ToUpper(method),
+		Path:       path,
+		Extensions: map[string]interface{}{},
+	}
+	op.ID, _ = rawOp["operationId"].(string)
+	op.Summary, _ = rawOp["summary"].(string)
+	op.Description, _ = rawOp["description"].(string)
+	op.Deprecated, _ = rawOp["deprecated"].(bool)
+	for _, t := range asSlice(rawOp["tags"]) {
+		if tag, ok := t.(string); ok {
+			op.Tags = append(op.Tags, tag)
+		}
+	}
+	for key, value := range rawOp {
+		if strings.HasPrefix(key, "x-") {
+			op.Extensions[key] = value
+		}
+	}
+	if responses, ok := rawOp["responses"].(map[string]interface{}); ok {
+		op.Responses = responses
+	}
+	if security, ok := rawOp["security"]; ok {
+		op.Security = decodeSecurity(security)
+	} else {
+		op.Security = d.Security
+	}
+
+	// Operation-level parameters override path-level ones with the same name and location.
+	params := d.parameters(rawOp["parameters"])
+	seen := make(map[string]bool)
+	for _, p := range params {
+		seen[p.In+":"+p.Name] = true
+	}
+	for _, p := range shared {
+		if !seen[p.In+":"+p.Name] {
+			params = append(params, p)
+		}
+	}
+	op.Parameters = params
+
+	if body, ok := d.resolve(rawOp["requestBody"], 0).(map[string]interface{}); ok {
+		op.RequestBody = d.requestBody(body)
+	}
+
+	if op.ID == "" {
+		op.ID = deriveOperationID(method, path)
+	}
+	op.ToolName = ToolName(op.ID)
+	op.InputSchema = op.buildInputSchema()
+	op.OutputSchema = d.outputSchema(op.Responses)
+
+	return op
+}
+
+func (d *Document) parameters(value interface{}) []Parameter {
+	var params []Parameter
+	for _, raw := range asSlice(value) {
+		p, ok := d.resolve(raw, 0).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		param := Parameter{}
+		param.Name, _ = p["name"].(string)
+		param.In, _ = p["in"].(string)
+		param.Description, _ = p["description"].(string)
+		param.Required, _ = p["required"].(bool)
+		if param.In == "path" {
+			param.Required = true
+		}
+		if schema, ok := p["schema"].(map[string]interface{}); ok {
+			param.Schema = schema
+		} else if content, ok := p["content"].(map[string]interface{}); ok {
+			for _, media := range content {
+				if m, ok := media.(map[string]interface{}); ok {
+					param.Schema, _ = m["schema"].(map[string]interface{})
+					param.Example = example(m, param.Schema)
+				}
+				break
+			}
+		}
+		if param.Example == nil {
+			param.Example = example(p, param.Schema)
+		}
+		if param.Name == "" || param.In == "" {
+			continue
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+func (d *Document) requestBody(body map[string]interface{}) *RequestBody {
+	content, ok := body["content"].(map[string]interface{})
+	if !ok || len(content) == 0 {
+		return nil
+	}
+
+	rb := &RequestBody{}
+	rb.Description, _ = body["description"].(string)
+	rb.Required, _ = body["required"].(bool)
+
+	// Prefer JSON, then form encodings, then whatever comes first alphabetically.
+	contentType := ""
+	for _, candidate := range []string{"application/json", "application/x-www-form-urlencoded", "multipart/form-data"} {
+		if _, ok := content[candidate]; ok {
+			contentType = candidate
+			break
+		}
+	}
+	if contentType == "" {
+		keys := make([]string, 0, len(content))
+		for k := range content {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		contentType = keys[0]
+	}
+	rb.ContentType = contentType
+
+	if media, ok := content[contentType].(map[string]interface{}); ok {
+		if schema, ok := d.resolve(media["schema"], 0).(map[string]interface{}); ok {
+			rb.Schema = schema
+		}
+		rb.Example = example(d.resolve(media, 0).(map[string]interface{}), rb.Schema)
+	}
+	return rb
+}
+
+// example returns the example of a parameter or media type object: its example, the value
+// of its first named example, or else the example of its schema.
+func example(object, schema map[string]interface{}) interface{} {
+	if value, ok := object["example"]; ok {
+		return value
+	}
+	if examples, ok := object["examples"].(map[string]interface{}); ok {
+		names := make([]string, 0, len(examples))
+		for name := range examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if e, ok := examples[name].(map[string]interface{}); ok {
+				if value, ok := e["value"]; ok {
+					return value
+				}
+			}
+		}
+	}
+	return schema["example"]
+}
+
+// buildInputSchema produces the JSON schema an MCP client sees for this operation.
+// Parameters become top-level properties and the request body is nested under "body".
+func (op *Operation) buildInputSchema() map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for _, p := range op.Parameters {
+		prop := map[string]interface{}{}
+		for k, v := range p.Schema {
+			prop[k] = v
+		}
+		if len(prop) == 0 {
+			prop["type"] = "string"
+		}
+		description := p.Description
+		if description == "" {
+			description = fmt.Sprintf("%s parameter %q", p.In, p.Name)
+		}
+		prop["description"] = description
+		properties[p.Name] = prop
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+
+	if op.RequestBody != nil {
+		body := map[string]interface{}{}
+		for k, v := range op.RequestBody.Schema {
+			body[k] = v
+		}
+		if len(body) == 0 {
+			body["type"] = "object"
+		}
+		if op.RequestBody.Description != "" {
+			body["description"] = op.RequestBody.Description
+		} else if _, ok := body["description"]; !ok {
+			body["description"] = fmt.Sprintf("Request body (%s)", op.RequestBody.ContentType)
+		}
+		properties["body"] = body
+		if op.RequestBody.Required {
+			required = append(required, "body")
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// ParametersIn returns the parameters declared in the given location (path, query, header, cookie).
+func (op *Operation) ParametersIn(in string) []Parameter {
+	var out []Parameter
+	for _, p := range op.Parameters {
+		if p.In == in {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// DisplayDescription returns the best human-readable description for the operation.
+func (op *Operation) DisplayDescription() string {
+	switch {
+	case op.Summary != "" && op.Description != "" && op.Summary != op.Description:
+		return op.Summary + "\n\n" + op.Description
+	case op.Summary != "":
+		return op.Summary
+	case op.Description != "":
+		return op.Description
+	default:
+		return fmt.Sprintf("%s %s", op.Method, op.Path)
+	}
+}
+
+// IsSafeMethod reports whether the operation uses an HTTP method without side effects.
+func (op *Operation) IsSafeMethod() bool {
+	switch op.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// resolve follows local $ref pointers ("#/components/...") and inlines them recursively.
+func (d *Document) resolve(value interface{}, depth int) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			if depth >= maxRefDepth {
+				return map[string]interface{}{"type": "object", "description": "recursive reference to " + ref}
+			}
+			target := d.lookupRef(ref)
+			if target == nil {
+				return map[string]interface{}{"type": "object", "description": "unresolved reference " + ref}
+			}
+			return d.resolve(target, depth+1)
+		}
+		out := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			out[k] = d.resolve(child, depth)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = d.resolve(child, depth)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+func (d *Document) lookupRef(ref string) interface{} {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+	var current interface{} = d.raw
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil
+		}
+	}
+	return current
+}
+
+// ToolName converts an arbitrary operation identifier into a name that is valid for MCP tools.
+func ToolName(id string) string {
+	name := nonToolChars.ReplaceAllString(id, "_")
+	name = strings.Trim(name, "_")
+	if name == "" {
+		name = "operation"
+	}
+	return truncateName(name)
+}
+
+func truncateName(name string) string {
+	if len(name) > 64 {
+		return name[:64]
+	}
+	return name
+}
+
+func deriveOperationID(method, path string) string {
+	var parts []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segment = "by_" + strings.Trim(segment, "{}")
+		}
+		parts = append(parts, segment)
+	}
+	return strings.ToLower(method) + "_" + strings.Join(parts, "_")
+}
+
+func decodeSecurity(value interface{}) []models.OpenAPISecurityRequirement {
+	var out []models.OpenAPISecurityRequirement
+	_ = remarshal(value, &out)
+	return out
+}
+
+func asSlice(value interface{}) []interface{} {
+	if s, ok := value.([]interface{}); ok {
+		return s
+	}
+	return nil
+}
+
+func remarshal(in interface{}, out interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
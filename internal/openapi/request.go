@@ -0,0 +1,135 @@
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// BodyArgument is the tool argument that carries the request body, see buildInputSchema.
+const BodyArgument = "body"
+
+// NewRequest builds the HTTP request for a tool call. Arguments are mapped back onto
+// path, query, header and cookie parameters, and "body" becomes the request body.
+func (op *Operation) NewRequest(ctx context.Context, baseURL string, args map[string]interface{}) (*http.Request, error) {
+	path := op.Path
+	for _, p := range op.ParametersIn("path") {
+		value, ok := args[p.Name]
+		if !ok || value == nil {
+			return nil, fmt.Errorf("missing required path parameter %q", p.Name)
+		}
+		formatted := FormatValue(value)
+		// PathEscape keeps dots, so "." and ".." would otherwise walk the path.
+		if formatted == "." || formatted == ".." {
+			return nil, fmt.Errorf("invalid value for path parameter %q", p.Name)
+		}
+		path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(formatted))
+	}
+
+	target, err := url.Parse(strings.TrimSuffix(baseURL, "/") + path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request URL: %w", err)
+	}
+
+	query := target.Query()
+	for _, p := range op.ParametersIn("query") {
+		value, ok := args[p.Name]
+		if !ok || value == nil {
+			continue
+		}
+		if list, ok := value.([]interface{}); ok {
+			for _, item := range list {
+				query.Add(p.Name, FormatValue(item))
+			}
+			continue
+		}
+		query.Set(p.Name, FormatValue(value))
+	}
+	target.RawQuery = query.Encode()
+
+	var body io.Reader
+	contentType := ""
+	if op.RequestBody != nil {
+		if value, ok := args[BodyArgument]; ok && value != nil {
+			encoded, err := encodeBody(op.RequestBody.ContentType, value)
+			if err != nil {
+				return nil, err
+			}
+			body = bytes.NewReader(encoded)
+			contentType = op.RequestBody.ContentType
+		} else if op.RequestBody.Required {
+			return nil, fmt.Errorf("missing required request body")
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, op.Method, target.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json, */*;q=0.5")
+
+	for _, p := range op.ParametersIn("header") {
+		if value, ok := args[p.Name]; ok && value != nil {
+			req.Header.Set(p.Name, FormatValue(value))
+		}
+	}
+	for _, p := range op.ParametersIn("cookie") {
+		if value, ok := args[p.Name]; ok && value != nil {
+			req.AddCookie(&http.Cookie{Name: p.Name, Value: FormatValue(value)})
+		}
+	}
+
+	return req, nil
+}
+
+// FormatValue renders a decoded JSON argument as a parameter string.
+// Whole numbers are printed without a decimal point and composite values as JSON.
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(b)
+	}
+}
+
+func encodeBody(contentType string, value interface{}) ([]byte, error) {
+	switch {
+	case strings.Contains(contentType, "json"):
+		return json.Marshal(value)
+	case contentType == "application/x-www-form-urlencoded":
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("form request body must be an object")
+		}
+		form := url.Values{}
+		for k, v := range fields {
+			form.Set(k, FormatValue(v))
+		}
+		return []byte(form.Encode()), nil
+	default:
+		if s, ok := value.(string); ok {
+			return []byte(s), nil
+		}
+		return json.Marshal(value)
+	}
+}
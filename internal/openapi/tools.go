@@ -0,0 +1,121 @@
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+)
+
+// AnnotationsExtension is the operation extension that overrides derived tool annotations, e.g.
+//
+//	x-mcp-annotations: {"destructiveHint": false, "title": "Archive issue"}
+const AnnotationsExtension = "x-mcp-annotations"
+
+// CategoryExtension is the operation extension that sets the tool category.
+// Without it the first tag of the operation is used.
+const CategoryExtension = "x-mcp-category"
+
+// Tool converts the operation into an MCP tool with derived annotations.
+func (op *Operation) Tool() models.Tool {
+	annotations := op.Annotations()
+	return models.Tool{
+		Name:         op.ToolName,
+		Title:        annotations.Title,
+		Description:  op.DisplayDescription(),
+		Category:     op.Category(),
+		Tags:         op.Tags,
+		InputSchema:  op.InputSchema,
+		OutputSchema: op.OutputSchema,
+		Annotations:  &annotations,
+	}
+}
+
+// Category returns the tool category declared by x-mcp-category, falling back to the first tag.
+func (op *Operation) Category() string {
+	if category, ok := op.Extensions[CategoryExtension].(string); ok && category != "" {
+		return category
+	}
+	if len(op.Tags) > 0 {
+		return op.Tags[0]
+	}
+	return ""
+}
+
+// Annotations derives MCP tool annotations from the HTTP method, then applies
+// the x-mcp-annotations extension when the spec author provided one.
+//
+// Safe methods are read-only and idempotent. PUT and DELETE are idempotent but
+// destructive, POST and PATCH are neither. Every operation reaches an external
+// API, so openWorldHint is always set.
+func (op *Operation) Annotations() models.ToolAnnotations {
+	readOnly, destructive, idempotent := false, false, false
+	switch op.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		readOnly, idempotent = true, true
+	case http.MethodPut, http.MethodDelete:
+		destructive, idempotent = true, true
+	}
+
+	annotations := models.ToolAnnotations{
+		Title:           op.Summary,
+		ReadOnlyHint:    &readOnly,
+		DestructiveHint: &destructive,
+		IdempotentHint:  &idempotent,
+		OpenWorldHint:   boolPtr(true),
+	}
+
+	if ext, ok := op.Extensions[AnnotationsExtension].(map[string]interface{}); ok {
+		var override models.ToolAnnotations
+		if err := remarshal(ext, &override); err == nil {
+			annotations.Apply(override)
+		}
+	}
+	return annotations
+}
+
+// outputSchema returns the JSON object schema of the lowest 2xx response.
+// MCP output schemas must describe objects, so array or scalar bodies are skipped.
+func (d *Document) outputSchema(responses map[string]interface{}) map[string]interface{} {
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		response, ok := d.resolve(responses[code], 0).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		content, ok := response["content"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		contentTypes := make([]string, 0, len(content))
+		for contentType := range content {
+			if strings.Contains(contentType, "json") {
+				contentTypes = append(contentTypes, contentType)
+			}
+		}
+		sort.Strings(contentTypes)
+		for _, contentType := range contentTypes {
+			m, ok := content[contentType].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			schema, ok := d.resolve(m["schema"], 0).(map[string]interface{})
+			if ok && schema["type"] == "object" {
+				return schema
+			}
+		}
+	}
+	return nil
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
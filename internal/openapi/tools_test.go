@@ -0,0 +1,61 @@
+package openapi
+
+import "testing"
+
+const annotatedSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Issues", "version": "1.0.0"},
+  "paths": {
+    "/issues": {
+      "get": {"operationId": "list_issues", "summary": "List issues",
+        "responses": {"200": {"content": {"application/json": {"schema": {"type": "array"}}}}}},
+      "post": {"operationId": "create_issue",
+        "responses": {"201": {"content": {"application/json": {"schema": {"type": "object", "properties": {"id": {"type": "string"}}}}}}}}
+    },
+    "/issues/{id}": {
+      "put": {"operationId": "replace_issue"},
+      "delete": {"operationId": "archive_issue", "x-mcp-annotations": {"destructiveHint": false, "title": "Archive issue"}}
+    }
+  }
+}`
+
+func TestToolAnnotations(t *testing.T) {
+	doc, err := Parse([]byte(annotatedSpec))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		tool                              string
+		title                             string
+		readOnly, destructive, idempotent bool
+	}{
+		{"list_issues", "List issues", true, false, true},
+		{"create_issue", "", false, false, false},
+		{"replace_issue", "", false, true, true},
+		{"archive_issue", "Archive issue", false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			op := doc.Operation(tt.tool)
+			if op == nil {
+				t.Fatalf("operation %s not found", tt.tool)
+			}
+			tool := op.Tool()
+			a := tool.Annotations
+			if tool.Title != tt.title || *a.ReadOnlyHint != tt.readOnly || *a.IdempotentHint != tt.idempotent || !*a.OpenWorldHint {
+				t.Errorf("annotations = %+v, title %q", *a, tool.Title)
+			}
+			if tool.IsDestructive() != tt.destructive {
+				t.Errorf("IsDestructive() = %v, want %v", tool.IsDestructive(), tt.destructive)
+			}
+		})
+	}
+
+	if schema := doc.Operation("list_issues").OutputSchema; schema != nil {
+		t.Errorf("array response became output schema %v", schema)
+	}
+	if schema := doc.Operation("create_issue").OutputSchema; schema["type"] != "object" {
+		t.Errorf("create_issue output schema = %v, want the object response", schema)
+	}
+}
@@ -0,0 +1,246 @@
+// Package outbound provides the HTTP client used for all traffic to integration APIs.
+//
+// Integration base URLs, tool URLs and OAuth2 endpoints are supplied by users, so the
+// client guards against server-side request forgery: every address a request would
+// connect to, including redirect targets, is resolved and checked before dialing, and
+// private, loopback, link-local and cloud metadata addresses are refused unless allowlisted.
+// The client also bounds redirects, time and response size, and records metrics per integration.
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/utils"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrBlockedDestination is returned when a request would connect to a disallowed address.
+	ErrBlockedDestination = errors.New("destination address is not allowed")
+	// ErrTooManyRedirects is returned when a response redirects more than Config.MaxRedirects times.
+	ErrTooManyRedirects = errors.New("too many redirects")
+	// ErrResponseTooLarge is returned while reading a body larger than Config.MaxResponseBytes.
+	ErrResponseTooLarge = errors.New("response body exceeds the size limit")
+	// ErrUnsupportedScheme is returned for URLs that are not http or https.
+	ErrUnsupportedScheme = errors.New("only http and https URLs are allowed")
+	// ErrCredentialedRedirect is returned when a request carrying credentials is redirected to
+	// another host or from https to http.
+	ErrCredentialedRedirect = errors.New("requests with credentials are not redirected to another host")
+)
+
+// Config controls the outbound client.
+type Config struct {
+	// AllowedHosts are hostnames, IP addresses or CIDR ranges that may be reached even
+	// though they resolve to private addresses, e.g. an internal API gateway.
+	AllowedHosts []string
+	// AllowPrivateNetworks disables address filtering altogether, for local development.
+	AllowPrivateNetworks bool
+	// Timeout bounds a whole request including redirects and reading the body.
+	Timeout time.Duration
+	// MaxRedirects is the number of redirects followed; 0 disables following redirects.
+	MaxRedirects int
+	// MaxResponseBytes caps how much of a response body can be read.
+	MaxResponseBytes int64
+}
+
+// DefaultConfig returns the limits used when nothing is configured.
+func DefaultConfig() Config {
+	return Config{
+		Timeout:          30 * time.Second,
+		MaxRedirects:     5,
+		MaxResponseBytes: 10 << 20,
+	}
+}
+
+type integrationKey struct{}
+
+// WithIntegration labels the requests made with ctx with an integration name for metrics and logs.
+func WithIntegration(ctx context.Context, integration string) context.Context {
+	return context.WithValue(ctx, integrationKey{}, integration)
+}
+
+type credentialsKey struct{}
+
+// WithCredentials marks the requests made with ctx as signed with credentials. Signers may put
+// secrets in any header or query parameter, so these requests only follow redirects that stay on
+// the same host and scheme.
+func WithCredentials(ctx context.Context) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, true)
+}
+
+func hasCredentials(ctx context.Context) bool {
+	signed, _ := ctx.Value(credentialsKey{}).(bool)
+	return signed
+}
+
+func integrationFrom(ctx context.Context) string {
+	if name, ok := ctx.Value(integrationKey{}).(string); ok && name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// NewClient creates the shared outbound client. metrics may be nil.
+func NewClient(config Config, metrics *utils.MetricsCollector, logger *zap.Logger) *http.Client {
+	defaults := DefaultConfig()
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.MaxResponseBytes <= 0 {
+		config.MaxResponseBytes = defaults.MaxResponseBytes
+	}
+
+	guard := newAddressGuard(config.AllowedHosts, config.AllowPrivateNetworks)
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+
+	transport := &http.Transport{
+		Proxy:                 nil, // an environment proxy would bypass the address checks
+		DialContext:           guard.dialContext(dialer),
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: config.Timeout,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	return &http.Client{
+		Timeout: config.Timeout,
+		Transport: &guardedTransport{
+			next:             transport,
+			maxResponseBytes: config.MaxResponseBytes,
+			metrics:          metrics,
+			logger:           logger,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if config.MaxRedirects == 0 {
+				return http.ErrUseLastResponse
+			}
+			if len(via) > config.MaxRedirects {
+				return ErrTooManyRedirects
+			}
+			if err := checkScheme(req); err != nil {
+				return err
+			}
+			// Never leak credentials to a different host.
+			original := via[0]
+			if req.URL.Host != original.URL.Host || (original.URL.Scheme == "https" && req.URL.Scheme != "https") {
+				if hasCredentials(original.Context()) {
+					return fmt.Errorf("%w: %s", ErrCredentialedRedirect, req.URL.Redacted())
+				}
+				req.Header.Del("Authorization")
+				req.Header.Del("Cookie")
+			}
+			return nil
+		},
+	}
+}
+
+// guardedTransport checks schemes, enforces the response size limit and records metrics.
+type guardedTransport struct {
+	next             http.RoundTripper
+	maxResponseBytes int64
+	metrics          *utils.MetricsCollector
+	logger           *zap.Logger
+}
+
+func (t *guardedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	integration := integrationFrom(req.Context())
+	if err := checkScheme(req); err != nil {
+		t.count("outbound_blocked_total", integration, "reason", "scheme")
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	t.observe(integration, start)
+	if err != nil {
+		if errors.Is(err, ErrBlockedDestination) {
+			t.count("outbound_blocked_total", integration, "reason", "address")
+			t.logger.Warn("Blocked outbound request",
+				zap.String("integration", integration),
+				zap.String("host", req.URL.Host),
+				zap.Error(err))
+		} else {
+			t.count("outbound_errors_total", integration, "", "")
+		}
+		return nil, err
+	}
+
+	t.count("outbound_requests_total", integration, "status", strconv.Itoa(resp.StatusCode/100)+"xx")
+	if resp.ContentLength > t.maxResponseBytes {
+		resp.Body.Close()
+		t.count("outbound_blocked_total", integration, "reason", "size")
+		return nil, fmt.Errorf("%w: %d bytes", ErrResponseTooLarge, resp.ContentLength)
+	}
+	resp.Body = &limitedBody{body: resp.Body, remaining: t.maxResponseBytes, onExceeded: func() {
+		t.count("outbound_blocked_total", integration, "reason", "size")
+	}}
+	return resp, nil
+}
+
+func (t *guardedTransport) count(name, integration, labelKey, labelValue string) {
+	if t.metrics == nil {
+		return
+	}
+	labels := map[string]string{"integration": integration}
+	if labelKey != "" {
+		labels[labelKey] = labelValue
+	}
+	t.metrics.Counter(name, labels).Inc()
+}
+
+func (t *guardedTransport) observe(integration string, start time.Time) {
+	if t.metrics == nil {
+		return
+	}
+	t.metrics.Histogram("outbound_request_duration_ms", map[string]string{"integration": integration}).
+		Observe(float64(time.Since(start).Milliseconds()))
+}
+
+// limitedBody fails reads once more than the limit has been read, rather than
+// silently truncating the response.
+type limitedBody struct {
+	body       io.ReadCloser
+	remaining  int64
+	onExceeded func()
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	// Read one byte past the limit to tell "exactly the limit" from "more".
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		b.onExceeded()
+		return n + int(b.remaining), ErrResponseTooLarge
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
+
+func checkScheme(req *http.Request) error {
+	switch strings.ToLower(req.URL.Scheme) {
+	case "http", "https":
+		return nil
+	default:
+		return ErrUnsupportedScheme
+	}
+}
@@ -0,0 +1,115 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/signing"
+	"go.uber.org/zap"
+)
+
+func TestSignedRequestsAreNotRedirectedToAnotherHost(t *testing.T) {
+	var mu sync.Mutex
+	var leaked []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if key := r.Header.Get("X-Custom-Key"); key != "" {
+			leaked = append(leaked, "header "+key)
+		}
+		if key := r.URL.Query().Get("api_key"); key != "" {
+			leaked = append(leaked, "query "+key)
+		}
+	}))
+	defer other.Close()
+	// The API redirects with the query string kept, as trailing-slash redirects do.
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, other.URL+"/moved?"+r.URL.RawQuery, http.StatusFound)
+		case "/local":
+			http.Redirect(w, r, "/final?"+r.URL.RawQuery, http.StatusFound)
+		}
+	}))
+	defer api.Close()
+
+	config := DefaultConfig()
+	config.AllowPrivateNetworks = true
+	client := NewClient(config, nil, zap.NewNop())
+
+	signers := map[string]map[string]string{
+		"custom header": {"in": "header", "name": "X-Custom-Key"},
+		"query string":  {"in": "query", "name": "api_key"},
+	}
+	for name, options := range signers {
+		t.Run(name, func(t *testing.T) {
+			signer, err := signing.New(signing.TypeAPIKey, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			send := func(path string) (*http.Response, error) {
+				req, err := http.NewRequestWithContext(WithCredentials(context.Background()), http.MethodGet, api.URL+path, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := signer.Sign(req, signing.Credentials{APIKey: "secret"}); err != nil {
+					t.Fatal(err)
+				}
+				return client.Do(req)
+			}
+
+			if _, err := send("/moved"); !errors.Is(err, ErrCredentialedRedirect) {
+				t.Fatalf("redirect to another host: error = %v, want %v", err, ErrCredentialedRedirect)
+			}
+
+			resp, err := send("/local")
+			if err != nil {
+				t.Fatalf("redirect on the same host: %v", err)
+			}
+			resp.Body.Close()
+			if resp.Request.URL.Path != "/final" {
+				t.Fatalf("redirect on the same host ended at %s, want /final", resp.Request.URL.Path)
+			}
+		})
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(leaked) > 0 {
+		t.Fatalf("credentials reached another host: %v", leaked)
+	}
+}
+
+func TestUnsignedRequestsFollowRedirectsToAnotherHost(t *testing.T) {
+	var authorization string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer other.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+"/spec.json", http.StatusFound)
+	}))
+	defer api.Close()
+
+	config := DefaultConfig()
+	config.AllowPrivateNetworks = true
+	client := NewClient(config, nil, zap.NewNop())
+
+	req, err := http.NewRequest(http.MethodGet, api.URL+"/spec.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+	if authorization != "" {
+		t.Fatalf("Authorization header reached another host: %q", authorization)
+	}
+}
@@ -0,0 +1,124 @@
+package outbound
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// blockedNetworks are never reached unless allowlisted, on top of the loopback,
+// private, link-local, multicast and unspecified ranges recognised by the net package.
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",          // "this" network
+	"100.64.0.0/10",      // carrier-grade NAT
+	"192.0.0.0/24",       // IETF protocol assignments
+	"198.18.0.0/15",      // benchmarking
+	"240.0.0.0/4",        // reserved
+	"255.255.255.255/32", // broadcast
+	"64:ff9b::/96",       // NAT64, can embed any IPv4 address
+	"2002::/16",          // 6to4, can embed any IPv4 address
+)
+
+// addressGuard decides which addresses may be dialed.
+type addressGuard struct {
+	allowAll      bool
+	allowedHosts  map[string]bool
+	allowedRanges []*net.IPNet
+}
+
+func newAddressGuard(allowed []string, allowAll bool) *addressGuard {
+	g := &addressGuard{allowAll: allowAll, allowedHosts: map[string]bool{}}
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			g.allowedRanges = append(g.allowedRanges, network)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			g.allowedRanges = append(g.allowedRanges, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		g.allowedHosts[entry] = true
+	}
+	return g
+}
+
+// allowedIP reports whether ip may be dialed.
+func (g *addressGuard) allowedIP(ip net.IP) bool {
+	for _, network := range g.allowedRanges {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return !IsBlockedIP(ip)
+}
+
+// IsBlockedIP reports whether ip is a loopback, private, link-local (which includes the
+// 169.254.169.254 cloud metadata endpoint), multicast, unspecified or otherwise reserved address.
+func IsBlockedIP(ip net.IP) bool {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// dialContext resolves the host itself and dials only addresses that pass the guard,
+// so a DNS answer cannot change between the check and the connection.
+func (g *addressGuard) dialContext(dialer *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		if g.allowAll || g.allowedHosts[strings.ToLower(host)] {
+			return dialer.DialContext(ctx, network, address)
+		}
+
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var lastErr error
+		for _, ip := range ips {
+			if !g.allowedIP(ip.IP) {
+				lastErr = fmt.Errorf("%w: %s resolves to %s", ErrBlockedDestination, host, ip.IP)
+				continue
+			}
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.IP.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		if lastErr == nil {
+			lastErr = fmt.Errorf("no addresses found for %s", host)
+		}
+		return nil, lastErr
+	}
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
@@ -0,0 +1,114 @@
+package outbound
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestIsBlockedIP(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1":        true,
+		"10.1.2.3":         true,
+		"172.16.0.1":       true,
+		"192.168.1.1":      true,
+		"169.254.169.254":  true, // cloud metadata
+		"100.64.0.1":       true,
+		"0.0.0.0":          true,
+		"::1":              true,
+		"fd00::1":          true,
+		"fe80::1":          true,
+		"::ffff:127.0.0.1": true,
+		"64:ff9b::a00:1":   true, // NAT64 of 10.0.0.1
+		"2002:a00:1::":     true, // 6to4 of 10.0.0.1
+		"8.8.8.8":          false,
+		"93.184.216.34":    false,
+		"2606:4700::1111":  false,
+	}
+	for address, want := range tests {
+		if got := IsBlockedIP(net.ParseIP(address)); got != want {
+			t.Errorf("IsBlockedIP(%s) = %v, want %v", address, got, want)
+		}
+	}
+}
+
+func TestAddressGuardAllowlist(t *testing.T) {
+	guard := newAddressGuard([]string{"10.0.0.0/8", "192.168.1.5", " Internal.Example.com "}, false)
+
+	if !guard.allowedIP(net.ParseIP("10.20.30.40")) || !guard.allowedIP(net.ParseIP("192.168.1.5")) {
+		t.Error("allowlisted private addresses are blocked")
+	}
+	if guard.allowedIP(net.ParseIP("192.168.1.6")) || guard.allowedIP(net.ParseIP("127.0.0.1")) {
+		t.Error("private addresses outside the allowlist are allowed")
+	}
+	if !guard.allowedHosts["internal.example.com"] {
+		t.Errorf("allowed hosts = %v, want internal.example.com", guard.allowedHosts)
+	}
+}
+
+func TestClientBlocksPrivateDestinations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stream" {
+			// Flushing first sends the body without a Content-Length.
+			w.(http.Flusher).Flush()
+		}
+		_, _ = io.WriteString(w, strings.Repeat("x", 64))
+	}))
+	defer server.Close()
+
+	blocked := NewClient(DefaultConfig(), nil, zap.NewNop())
+	if _, err := blocked.Get(server.URL); !errors.Is(err, ErrBlockedDestination) {
+		t.Fatalf("request to loopback: error = %v, want %v", err, ErrBlockedDestination)
+	}
+	if _, err := blocked.Get("file:///etc/passwd"); !errors.Is(err, ErrUnsupportedScheme) {
+		t.Fatalf("file URL: error = %v, want %v", err, ErrUnsupportedScheme)
+	}
+
+	config := DefaultConfig()
+	config.AllowedHosts = []string{"127.0.0.1"}
+	config.MaxResponseBytes = 16
+	allowed := NewClient(config, nil, zap.NewNop())
+	if _, err := allowed.Get(server.URL); !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("response with a large Content-Length: error = %v, want %v", err, ErrResponseTooLarge)
+	}
+	resp, err := allowed.Get(server.URL + "/stream")
+	if err != nil {
+		t.Fatalf("request to allowlisted address: %v", err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadAll(resp.Body); !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("reading a large streamed response: error = %v, want %v", err, ErrResponseTooLarge)
+	}
+}
+
+func TestJoinPath(t *testing.T) {
+	tests := []struct {
+		base, path, want string
+		wantErr          error
+	}{
+		{"https://api.example.com/v1/", "users/42", "https://api.example.com/v1/users/42", nil},
+		{"https://api.example.com/v1", "/./users//42", "https://api.example.com/v1/users/42", nil},
+		{"https://api.example.com/v1?key=x", "a%20b", "https://api.example.com/v1/a%20b", nil},
+		{"https://api.example.com/v1", "../admin", "", ErrPathTraversal},
+		{"https://api.example.com/v1", "%2e%2e/admin", "", ErrPathTraversal},
+		{"https://api.example.com/v1", "users%2f..%2fadmin", "", ErrPathTraversal},
+		{"https://api.example.com/v1", "users%5cadmin", "", ErrPathTraversal},
+		{"ftp://api.example.com/v1", "users", "", ErrUnsupportedScheme},
+	}
+	for _, tt := range tests {
+		got, err := JoinPath(tt.base, tt.path)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("JoinPath(%q, %q) error = %v, want %v", tt.base, tt.path, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("JoinPath(%q, %q) = %s, want %s", tt.base, tt.path, got, tt.want)
+		}
+	}
+}
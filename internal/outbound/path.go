@@ -0,0 +1,52 @@
+package outbound
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrPathTraversal is returned when a proxied path tries to leave the integration's base path.
+var ErrPathTraversal = errors.New("path traversal is not allowed")
+
+// JoinPath appends a client-supplied path to an integration base URL. Each segment is
+// decoded and re-escaped, "." segments are dropped, and "..", encoded slashes and
+// backslashes are rejected, so the result always stays below the base path.
+func JoinPath(baseURL, rawPath string) (*url.URL, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, ErrUnsupportedScheme
+	}
+	if base.Host == "" {
+		return nil, fmt.Errorf("invalid base URL: missing host")
+	}
+
+	segments := []string{}
+	for _, segment := range strings.Split(rawPath, "/") {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path segment %q: %w", segment, err)
+		}
+		switch {
+		case decoded == "" || decoded == ".":
+			continue
+		case decoded == ".." || strings.ContainsAny(decoded, "/\\"):
+			return nil, ErrPathTraversal
+		}
+		segments = append(segments, url.PathEscape(decoded))
+	}
+
+	target := *base
+	target.RawQuery = ""
+	target.Fragment = ""
+	joined := strings.TrimSuffix(base.EscapedPath(), "/") + "/" + strings.Join(segments, "/")
+	if target.Path, err = url.PathUnescape(joined); err != nil {
+		return nil, err
+	}
+	target.RawPath = joined
+	return &target, nil
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AccessTokenRepository stores personal access tokens.
+type AccessTokenRepository interface {
+	Create(ctx context.Context, token *models.AccessToken) error
+	// GetByHash returns the unrevoked token with the hash.
+	GetByHash(ctx context.Context, tokenHash string) (*models.AccessToken, error)
+	// ListByUser returns the unrevoked tokens of a user, newest first.
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]*models.AccessToken, error)
+	CountByUser(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	// Revoke revokes a token of a user and returns mongo.ErrNoDocuments when it has no such token.
+	Revoke(ctx context.Context, userID, id primitive.ObjectID) error
+	UpdateLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time, ip string) error
+}
+
+// accessTokenRepository is the concrete implementation of AccessTokenRepository.
+type accessTokenRepository struct {
+	collection *mongo.Collection
+}
+
+// NewAccessTokenRepository creates a new AccessTokenRepository.
+func NewAccessTokenRepository(db *mongo.Database) AccessTokenRepository {
+	collection := db.Collection("access_tokens")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// Index creation is best effort; hashes of random tokens do not collide.
+	_, _ = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+	})
+	return &accessTokenRepository{collection: collection}
+}
+
+// Create stores a new token.
+func (r *accessTokenRepository) Create(ctx context.Context, token *models.AccessToken) error {
+	token.ID = primitive.NewObjectID()
+	token.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, token)
+	return err
+}
+
+// GetByHash returns the unrevoked token with the hash.
+func (r *accessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.AccessToken, error) {
+	var token models.AccessToken
+	filter := bson.M{"tokenHash": tokenHash, "revokedAt": bson.M{"$exists": false}}
+	if err := r.collection.FindOne(ctx, filter).Decode(&token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListByUser returns the unrevoked tokens of a user, newest first.
+func (r *accessTokenRepository) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]*models.AccessToken, error) {
+	filter := bson.M{"userId": userID, "revokedAt": bson.M{"$exists": false}}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tokens := []*models.AccessToken{}
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// CountByUser returns the number of unrevoked tokens of a user.
+func (r *accessTokenRepository) CountByUser(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"userId": userID, "revokedAt": bson.M{"$exists": false}})
+}
+
+// Revoke revokes a token of a user.
+func (r *accessTokenRepository) Revoke(ctx context.Context, userID, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "userId": userID, "revokedAt": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revokedAt": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// UpdateLastUsed records when and from where a token was last used.
+func (r *accessTokenRepository) UpdateLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time, ip string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastUsedAt": at, "lastUsedIp": ip}})
+	return err
+}
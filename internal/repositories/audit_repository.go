@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditRepository stores the audit log. Events are only ever inserted; the one way to remove
+// them is retention, which deletes events older than a cutoff.
+type AuditRepository interface {
+	Insert(ctx context.Context, event *models.AuditEvent) error
+	// Find returns a page of the matching events, newest first, and how many match in total.
+	Find(ctx context.Context, query *models.AuditQuery) ([]*models.AuditEvent, int64, error)
+	// Each calls fn for every matching event, oldest first, ignoring the page of the query.
+	Each(ctx context.Context, query *models.AuditQuery, fn func(*models.AuditEvent) error) error
+	// DeleteBefore removes the events recorded before cutoff and returns how many it removed.
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// auditRepository is the concrete implementation of AuditRepository.
+type auditRepository struct {
+	collection *mongo.Collection
+}
+
+// NewAuditRepository creates a new AuditRepository.
+func NewAuditRepository(db *mongo.Database) AuditRepository {
+	collection := db.Collection("audit_events")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// Index creation is best effort; queries work without the indexes, only slower.
+	_, _ = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "actorId", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "organizationId", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "action", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "targetType", Value: 1}, {Key: "targetId", Value: 1}}},
+		{Keys: bson.D{{Key: "requestId", Value: 1}}},
+	})
+	return &auditRepository{collection: collection}
+}
+
+// Insert appends an event to the log.
+func (r *auditRepository) Insert(ctx context.Context, event *models.AuditEvent) error {
+	_, err := r.collection.InsertOne(ctx, event)
+	return err
+}
+
+// Find returns a page of the matching events, newest first.
+func (r *auditRepository) Find(ctx context.Context, query *models.AuditQuery) ([]*models.AuditEvent, int64, error) {
+	filter := auditFilter(query)
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((query.Page - 1) * query.Limit)).
+		SetLimit(int64(query.Limit))
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	events := []*models.AuditEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, 0, err
+	}
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+// Each streams the matching events, oldest first.
+func (r *auditRepository) Each(ctx context.Context, query *models.AuditQuery, fn func(*models.AuditEvent) error) error {
+	findOptions := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, auditFilter(query), findOptions)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var event models.AuditEvent
+		if err := cursor.Decode(&event); err != nil {
+			return err
+		}
+		if err := fn(&event); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// DeleteBefore removes the events recorded before cutoff.
+func (r *auditRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"timestamp": bson.M{"$lt": cutoff}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// auditFilter builds the filter of a query.
+func auditFilter(query *models.AuditQuery) bson.M {
+	filter := bson.M{}
+	if !query.ActorID.IsZero() {
+		filter["actorId"] = query.ActorID
+	}
+	if query.OrganizationID != "" {
+		filter["organizationId"] = query.OrganizationID
+	}
+	if group, ok := strings.CutSuffix(query.Action, ".*"); ok {
+		filter["action"] = bson.M{"$regex": "^" + regexp.QuoteMeta(group) + `\.`}
+	} else if query.Action != "" {
+		filter["action"] = query.Action
+	}
+	if query.Outcome != "" {
+		filter["outcome"] = query.Outcome
+	}
+	if query.TargetType != "" {
+		filter["targetType"] = query.TargetType
+	}
+	if query.TargetID != "" {
+		filter["targetId"] = query.TargetID
+	}
+	if query.RequestID != "" {
+		filter["requestId"] = query.RequestID
+	}
+	timestamp := bson.M{}
+	if !query.From.IsZero() {
+		timestamp["$gte"] = query.From
+	}
+	if !query.To.IsZero() {
+		timestamp["$lt"] = query.To
+	}
+	if len(timestamp) > 0 {
+		filter["timestamp"] = timestamp
+	}
+	return filter
+}
@@ -135,6 +135,52 @@ func (r *CollectionRepository) GetByUserID(ctx context.Context, userID string) (
 	return collections, nil
 }
 
+// GetAccessible retrieves the personal collections of a user together with those of the given organizations
+func (r *CollectionRepository) GetAccessible(ctx context.Context, userID string, organizationIDs []string) ([]*models.Collection, error) {
+	if organizationIDs == nil {
+		organizationIDs = []string{} // $in requires an array
+	}
+	filter := bson.M{"$or": bson.A{
+		bson.M{"user_id": userID, "organization_id": bson.M{"$exists": false}},
+		bson.M{"organization_id": bson.M{"$in": organizationIDs}},
+	}}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var collections []*models.Collection
+	if err = cursor.All(ctx, &collections); err != nil {
+		return nil, err
+	}
+
+	return collections, nil
+}
+
+// SetOrganization moves a collection into an organization, or makes it personal again when organizationID is empty
+func (r *CollectionRepository) SetOrganization(ctx context.Context, id string, organizationID string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{"organization_id": organizationID, "updated_at": time.Now()}}
+	if organizationID == "" {
+		update = bson.M{"$unset": bson.M{"organization_id": ""}, "$set": bson.M{"updated_at": time.Now()}}
+	}
+	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	return err
+}
+
+// ClearOrganization returns the collections of an organization to the users who created them
+func (r *CollectionRepository) ClearOrganization(ctx context.Context, organizationID string) error {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"organization_id": organizationID},
+		bson.M{"$unset": bson.M{"organization_id": ""}, "$set": bson.M{"updated_at": time.Now()}})
+	return err
+}
+
 // CountCreatedAfter returns the number of collections created after the given time
 func (r *CollectionRepository) CountCreatedAfter(ctx context.Context, after time.Time) (int64, error) {
 	filter := bson.M{"created_at": bson.M{"$gt": after}}
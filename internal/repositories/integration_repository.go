@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"github.com/AkashKesav/API2SDK/internal/models"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -67,7 +66,8 @@ func (r *integrationRepository) GetAll(ctx context.Context) ([]*models.Integrati
 
 // Update updates an existing Integration.
 func (r *integrationRepository) Update(ctx context.Context, id primitive.ObjectID, integration *models.Integration) (*models.Integration, error) {
-	_, err := r.collection.UpdateOne(ctx, primitive.M{"_id": id}, bson.M{"$set": integration})
+	// Replace rather than $set so that cleared optional settings are removed from the document.
+	_, err := r.collection.ReplaceOne(ctx, primitive.M{"_id": id}, integration)
 	if err != nil {
 		return nil, err
 	}
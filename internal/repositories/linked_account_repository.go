@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LinkedAccountRepository defines the interface for interacting with LinkedAccount data.
+type LinkedAccountRepository interface {
+	Create(ctx context.Context, account *models.LinkedAccount) (*models.LinkedAccount, error)
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.LinkedAccount, error)
+	GetByOwner(ctx context.Context, integrationID primitive.ObjectID, ownerID string) (*models.LinkedAccount, error)
+	GetByUserID(ctx context.Context, userID string) ([]*models.LinkedAccount, error)
+	// ListManagers returns the users that manage an owner's accounts.
+	ListManagers(ctx context.Context, ownerID string) ([]string, error)
+	Update(ctx context.Context, account *models.LinkedAccount) error
+	SetEnabled(ctx context.Context, id primitive.ObjectID, enabled bool) error
+	TouchLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+// linkedAccountRepository is the concrete implementation of LinkedAccountRepository.
+type linkedAccountRepository struct {
+	collection *mongo.Collection
+}
+
+// NewLinkedAccountRepository creates a new LinkedAccountRepository.
+func NewLinkedAccountRepository(db *mongo.Database) LinkedAccountRepository {
+	return &linkedAccountRepository{
+		collection: db.Collection("linked_accounts"),
+	}
+}
+
+// Create creates a new LinkedAccount.
+func (r *linkedAccountRepository) Create(ctx context.Context, account *models.LinkedAccount) (*models.LinkedAccount, error) {
+	account.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// GetByID retrieves a LinkedAccount by its ID.
+func (r *linkedAccountRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.LinkedAccount, error) {
+	var account models.LinkedAccount
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&account)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetByOwner retrieves the LinkedAccount an owner has for an integration.
+func (r *linkedAccountRepository) GetByOwner(ctx context.Context, integrationID primitive.ObjectID, ownerID string) (*models.LinkedAccount, error) {
+	var account models.LinkedAccount
+	err := r.collection.FindOne(ctx, bson.M{"integrationId": integrationID, "ownerId": ownerID}).Decode(&account)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetByUserID retrieves all LinkedAccounts managed by a user.
+func (r *linkedAccountRepository) GetByUserID(ctx context.Context, userID string) ([]*models.LinkedAccount, error) {
+	var accounts []*models.LinkedAccount
+	cursor, err := r.collection.Find(ctx, bson.M{"userId": userID}, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &accounts); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// ListManagers returns the distinct users that manage the LinkedAccounts of an owner.
+func (r *linkedAccountRepository) ListManagers(ctx context.Context, ownerID string) ([]string, error) {
+	values, err := r.collection.Distinct(ctx, "userId", bson.M{"ownerId": ownerID})
+	if err != nil {
+		return nil, err
+	}
+	managers := make([]string, 0, len(values))
+	for _, value := range values {
+		if userID, ok := value.(string); ok {
+			managers = append(managers, userID)
+		}
+	}
+	return managers, nil
+}
+
+// Update replaces a LinkedAccount.
+func (r *linkedAccountRepository) Update(ctx context.Context, account *models.LinkedAccount) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": account.ID}, account)
+	return err
+}
+
+// SetEnabled enables or disables a LinkedAccount.
+func (r *linkedAccountRepository) SetEnabled(ctx context.Context, id primitive.ObjectID, enabled bool) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"enabled": enabled, "updatedAt": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// TouchLastUsed records when a LinkedAccount was last used to execute a tool.
+func (r *linkedAccountRepository) TouchLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastUsedAt": at}})
+	return err
+}
+
+// Delete deletes a LinkedAccount by its ID.
+func (r *linkedAccountRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
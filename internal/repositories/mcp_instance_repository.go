@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/AkashKesav/API2SDK/internal/models"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -14,6 +15,10 @@ type MCPInstanceRepository interface {
 	GetByID(ctx context.Context, id primitive.ObjectID) (*models.MCPInstance, error)
 	GetByUserID(ctx context.Context, userID string) ([]*models.MCPInstance, error)
 	Delete(ctx context.Context, id primitive.ObjectID) error
+	// UpdateHosting replaces the hosting state of an instance; nil removes it.
+	UpdateHosting(ctx context.Context, id primitive.ObjectID, hosting *models.MCPHosting) error
+	// ListHosted retrieves all instances with a hosted server.
+	ListHosted(ctx context.Context) ([]*models.MCPInstance, error)
 }
 
 // mcpInstanceRepository is the concrete implementation of MCPInstanceRepository.
@@ -69,3 +74,29 @@ func (r *mcpInstanceRepository) Delete(ctx context.Context, id primitive.ObjectI
 	_, err := r.collection.DeleteOne(ctx, primitive.M{"_id": id})
 	return err
 }
+
+// UpdateHosting replaces the hosting state of an instance; nil removes it.
+func (r *mcpInstanceRepository) UpdateHosting(ctx context.Context, id primitive.ObjectID, hosting *models.MCPHosting) error {
+	update := primitive.M{"$set": primitive.M{"hosting": hosting, "updatedAt": time.Now()}}
+	if hosting == nil {
+		update = primitive.M{"$unset": primitive.M{"hosting": ""}, "$set": primitive.M{"updatedAt": time.Now()}}
+	}
+	_, err := r.collection.UpdateOne(ctx, primitive.M{"_id": id}, update)
+	return err
+}
+
+// ListHosted retrieves all instances with a hosted server.
+func (r *mcpInstanceRepository) ListHosted(ctx context.Context) ([]*models.MCPInstance, error) {
+	var mcpInstances []*models.MCPInstance
+	cursor, err := r.collection.Find(ctx, primitive.M{"hosting": primitive.M{"$exists": true}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &mcpInstances); err != nil {
+		return nil, err
+	}
+
+	return mcpInstances, nil
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MCPServerRepository stores the managed MCP servers.
+type MCPServerRepository interface {
+	Create(ctx context.Context, server *models.MCPServer) error
+	// Save replaces the stored state of a server.
+	Save(ctx context.Context, server *models.MCPServer) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]*models.MCPServer, error)
+}
+
+// mcpServerRepository is the concrete implementation of MCPServerRepository.
+type mcpServerRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMCPServerRepository creates a new MCPServerRepository.
+func NewMCPServerRepository(db *mongo.Database) MCPServerRepository {
+	return &mcpServerRepository{collection: db.Collection("mcp_servers")}
+}
+
+// Create stores a new server.
+func (r *mcpServerRepository) Create(ctx context.Context, server *models.MCPServer) error {
+	now := time.Now()
+	server.CreatedAt = now
+	server.UpdatedAt = now
+	_, err := r.collection.InsertOne(ctx, server)
+	return err
+}
+
+// Save replaces the stored state of a server.
+func (r *mcpServerRepository) Save(ctx context.Context, server *models.MCPServer) error {
+	server.UpdatedAt = time.Now()
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": server.ID}, server)
+	return err
+}
+
+// Delete removes a server.
+func (r *mcpServerRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// List returns all servers, oldest first.
+func (r *mcpServerRepository) List(ctx context.Context) ([]*models.MCPServer, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	servers := []*models.MCPServer{}
+	if err := cursor.All(ctx, &servers); err != nil {
+		return nil, err
+	}
+	return servers, nil
+}
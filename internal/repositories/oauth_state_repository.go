@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OAuthStateRepository stores pending OAuth2 authorization requests.
+type OAuthStateRepository interface {
+	Create(ctx context.Context, state *models.OAuthState) error
+	// Consume atomically loads and deletes an unexpired state so it can be used only once.
+	Consume(ctx context.Context, state string) (*models.OAuthState, error)
+}
+
+// oauthStateRepository is the concrete implementation of OAuthStateRepository.
+type oauthStateRepository struct {
+	collection *mongo.Collection
+}
+
+// NewOAuthStateRepository creates a new OAuthStateRepository.
+// Expired states are removed by a TTL index on expiresAt.
+func NewOAuthStateRepository(db *mongo.Database) OAuthStateRepository {
+	collection := db.Collection("oauth_states")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// Index creation is best effort; Consume checks expiry itself.
+	_, _ = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "state", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return &oauthStateRepository{collection: collection}
+}
+
+// Create stores a pending authorization request.
+func (r *oauthStateRepository) Create(ctx context.Context, state *models.OAuthState) error {
+	state.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, state)
+	return err
+}
+
+// Consume atomically loads and deletes an unexpired state.
+func (r *oauthStateRepository) Consume(ctx context.Context, state string) (*models.OAuthState, error) {
+	var result models.OAuthState
+	filter := bson.M{"state": state, "expiresAt": bson.M{"$gt": time.Now()}}
+	if err := r.collection.FindOneAndDelete(ctx, filter).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
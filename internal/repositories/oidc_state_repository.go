@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OIDCStateRepository stores pending single sign-on requests.
+type OIDCStateRepository interface {
+	Create(ctx context.Context, state *models.OIDCLoginState) error
+	// Consume atomically loads and deletes an unexpired state so it can be used only once.
+	Consume(ctx context.Context, state string) (*models.OIDCLoginState, error)
+}
+
+// oidcStateRepository is the concrete implementation of OIDCStateRepository.
+type oidcStateRepository struct {
+	collection *mongo.Collection
+}
+
+// NewOIDCStateRepository creates a new OIDCStateRepository.
+// Expired states are removed by a TTL index on expiresAt.
+func NewOIDCStateRepository(db *mongo.Database) OIDCStateRepository {
+	collection := db.Collection("oidc_states")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// Index creation is best effort; Consume checks expiry itself.
+	_, _ = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "state", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return &oidcStateRepository{collection: collection}
+}
+
+// Create stores a pending sign-on.
+func (r *oidcStateRepository) Create(ctx context.Context, state *models.OIDCLoginState) error {
+	state.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, state)
+	return err
+}
+
+// Consume atomically loads and deletes an unexpired state.
+func (r *oidcStateRepository) Consume(ctx context.Context, state string) (*models.OIDCLoginState, error) {
+	var result models.OIDCLoginState
+	filter := bson.M{"state": state, "expiresAt": bson.M{"$gt": time.Now()}}
+	if err := r.collection.FindOneAndDelete(ctx, filter).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
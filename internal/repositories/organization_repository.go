@@ -0,0 +1,272 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OrganizationRepository stores organizations, their members and their invitations. Custom
+// roles are kept by RoleRepository.
+type OrganizationRepository interface {
+	Create(ctx context.Context, organization *models.Organization) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Organization, error)
+	ListByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Organization, error)
+	Rename(ctx context.Context, id primitive.ObjectID, name string) error
+	// Delete removes an organization together with its members, invitations and custom roles.
+	Delete(ctx context.Context, id primitive.ObjectID) error
+
+	AddMember(ctx context.Context, member *models.OrganizationMember) error
+	// GetMember returns the membership of a user and mongo.ErrNoDocuments when there is none.
+	GetMember(ctx context.Context, organizationID, userID primitive.ObjectID) (*models.OrganizationMember, error)
+	ListMembers(ctx context.Context, organizationID primitive.ObjectID) ([]*models.OrganizationMember, error)
+	// ListMembershipsByUser returns the memberships of a user in every organization.
+	ListMembershipsByUser(ctx context.Context, userID primitive.ObjectID) ([]*models.OrganizationMember, error)
+	CountMembersWithRole(ctx context.Context, organizationID primitive.ObjectID, role models.OrganizationRole) (int64, error)
+	// UpdateMemberRole returns mongo.ErrNoDocuments when the user is not a member.
+	UpdateMemberRole(ctx context.Context, organizationID, userID primitive.ObjectID, role models.OrganizationRole) error
+	// RemoveMember returns mongo.ErrNoDocuments when the user is not a member.
+	RemoveMember(ctx context.Context, organizationID, userID primitive.ObjectID) error
+
+	CreateInvitation(ctx context.Context, invitation *models.OrganizationInvitation) error
+	// GetInvitationByHash returns the pending invitation with the token hash.
+	GetInvitationByHash(ctx context.Context, tokenHash string) (*models.OrganizationInvitation, error)
+	// ListPendingInvitations returns the unaccepted invitations of an organization, newest first.
+	ListPendingInvitations(ctx context.Context, organizationID primitive.ObjectID) ([]*models.OrganizationInvitation, error)
+	// AcceptInvitation marks a pending invitation accepted and returns mongo.ErrNoDocuments
+	// when it was accepted or deleted in the meantime.
+	AcceptInvitation(ctx context.Context, id, userID primitive.ObjectID, at time.Time) error
+	// DeleteInvitation returns mongo.ErrNoDocuments when the organization has no such pending invitation.
+	DeleteInvitation(ctx context.Context, organizationID, id primitive.ObjectID) error
+}
+
+// organizationRepository is the concrete implementation of OrganizationRepository.
+type organizationRepository struct {
+	organizations *mongo.Collection
+	members       *mongo.Collection
+	invitations   *mongo.Collection
+	roles         *mongo.Collection
+}
+
+// NewOrganizationRepository creates a new OrganizationRepository.
+func NewOrganizationRepository(db *mongo.Database) OrganizationRepository {
+	r := &organizationRepository{
+		organizations: db.Collection("organizations"),
+		members:       db.Collection("organization_members"),
+		invitations:   db.Collection("organization_invitations"),
+		roles:         db.Collection(organizationRolesCollection),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// Index creation is best effort; the unique membership index keeps users from joining twice.
+	_, _ = r.members.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "organizationId", Value: 1}, {Key: "userId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+	})
+	_, _ = r.invitations.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "organizationId", Value: 1}}},
+	})
+	return r
+}
+
+// Create stores a new organization.
+func (r *organizationRepository) Create(ctx context.Context, organization *models.Organization) error {
+	now := time.Now()
+	organization.ID = primitive.NewObjectID()
+	organization.CreatedAt = now
+	organization.UpdatedAt = now
+	_, err := r.organizations.InsertOne(ctx, organization)
+	return err
+}
+
+// GetByID returns an organization.
+func (r *organizationRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Organization, error) {
+	var organization models.Organization
+	if err := r.organizations.FindOne(ctx, bson.M{"_id": id}).Decode(&organization); err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// ListByIDs returns the organizations with the IDs, by name.
+func (r *organizationRepository) ListByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Organization, error) {
+	organizations := []*models.Organization{}
+	if len(ids) == 0 {
+		return organizations, nil
+	}
+	cursor, err := r.organizations.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &organizations); err != nil {
+		return nil, err
+	}
+	return organizations, nil
+}
+
+// Rename changes the name of an organization.
+func (r *organizationRepository) Rename(ctx context.Context, id primitive.ObjectID, name string) error {
+	result, err := r.organizations.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"name": name, "updatedAt": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Delete removes an organization together with its members, invitations and custom roles.
+func (r *organizationRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := r.roles.DeleteMany(ctx, bson.M{"organizationId": id}); err != nil {
+		return err
+	}
+	if _, err := r.invitations.DeleteMany(ctx, bson.M{"organizationId": id}); err != nil {
+		return err
+	}
+	if _, err := r.members.DeleteMany(ctx, bson.M{"organizationId": id}); err != nil {
+		return err
+	}
+	_, err := r.organizations.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// AddMember stores a new membership.
+func (r *organizationRepository) AddMember(ctx context.Context, member *models.OrganizationMember) error {
+	now := time.Now()
+	member.ID = primitive.NewObjectID()
+	member.CreatedAt = now
+	member.UpdatedAt = now
+	_, err := r.members.InsertOne(ctx, member)
+	return err
+}
+
+// GetMember returns the membership of a user in an organization.
+func (r *organizationRepository) GetMember(ctx context.Context, organizationID, userID primitive.ObjectID) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	if err := r.members.FindOne(ctx, bson.M{"organizationId": organizationID, "userId": userID}).Decode(&member); err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ListMembers returns the members of an organization, oldest first.
+func (r *organizationRepository) ListMembers(ctx context.Context, organizationID primitive.ObjectID) ([]*models.OrganizationMember, error) {
+	return r.findMembers(ctx, bson.M{"organizationId": organizationID})
+}
+
+// ListMembershipsByUser returns the memberships of a user.
+func (r *organizationRepository) ListMembershipsByUser(ctx context.Context, userID primitive.ObjectID) ([]*models.OrganizationMember, error) {
+	return r.findMembers(ctx, bson.M{"userId": userID})
+}
+
+func (r *organizationRepository) findMembers(ctx context.Context, filter bson.M) ([]*models.OrganizationMember, error) {
+	cursor, err := r.members.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	members := []*models.OrganizationMember{}
+	if err := cursor.All(ctx, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// CountMembersWithRole returns the number of members of an organization with the role.
+func (r *organizationRepository) CountMembersWithRole(ctx context.Context, organizationID primitive.ObjectID, role models.OrganizationRole) (int64, error) {
+	return r.members.CountDocuments(ctx, bson.M{"organizationId": organizationID, "role": role})
+}
+
+// UpdateMemberRole changes the role of a member.
+func (r *organizationRepository) UpdateMemberRole(ctx context.Context, organizationID, userID primitive.ObjectID, role models.OrganizationRole) error {
+	filter := bson.M{"organizationId": organizationID, "userId": userID}
+	result, err := r.members.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"role": role, "updatedAt": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// RemoveMember removes a user from an organization.
+func (r *organizationRepository) RemoveMember(ctx context.Context, organizationID, userID primitive.ObjectID) error {
+	result, err := r.members.DeleteOne(ctx, bson.M{"organizationId": organizationID, "userId": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// CreateInvitation stores a new invitation.
+func (r *organizationRepository) CreateInvitation(ctx context.Context, invitation *models.OrganizationInvitation) error {
+	invitation.ID = primitive.NewObjectID()
+	invitation.CreatedAt = time.Now()
+	_, err := r.invitations.InsertOne(ctx, invitation)
+	return err
+}
+
+// GetInvitationByHash returns the pending invitation with the token hash.
+func (r *organizationRepository) GetInvitationByHash(ctx context.Context, tokenHash string) (*models.OrganizationInvitation, error) {
+	var invitation models.OrganizationInvitation
+	filter := bson.M{"tokenHash": tokenHash, "acceptedAt": bson.M{"$exists": false}}
+	if err := r.invitations.FindOne(ctx, filter).Decode(&invitation); err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// ListPendingInvitations returns the unaccepted invitations of an organization, newest first.
+func (r *organizationRepository) ListPendingInvitations(ctx context.Context, organizationID primitive.ObjectID) ([]*models.OrganizationInvitation, error) {
+	filter := bson.M{"organizationId": organizationID, "acceptedAt": bson.M{"$exists": false}}
+	cursor, err := r.invitations.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	invitations := []*models.OrganizationInvitation{}
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// AcceptInvitation marks a pending invitation accepted by the user.
+func (r *organizationRepository) AcceptInvitation(ctx context.Context, id, userID primitive.ObjectID, at time.Time) error {
+	filter := bson.M{"_id": id, "acceptedAt": bson.M{"$exists": false}}
+	result, err := r.invitations.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"acceptedAt": at, "acceptedBy": userID}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// DeleteInvitation deletes a pending invitation of an organization.
+func (r *organizationRepository) DeleteInvitation(ctx context.Context, organizationID, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "organizationId": organizationID, "acceptedAt": bson.M{"$exists": false}}
+	result, err := r.invitations.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
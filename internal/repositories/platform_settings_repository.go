@@ -16,6 +16,8 @@ import (
 type PlatformSettingsRepository interface {
 	GetSettings(ctx context.Context) (*models.PlatformSettings, error)
 	UpdateSettings(ctx context.Context, settings map[string]interface{}) (*models.PlatformSettings, error)
+	// UpdateOIDCSettings replaces the single sign-on settings.
+	UpdateOIDCSettings(ctx context.Context, settings *models.OIDCSettings) (*models.PlatformSettings, error)
 }
 
 // MongoPlatformSettingsRepository implements PlatformSettingsRepository for MongoDB.
@@ -70,3 +72,20 @@ func (r *MongoPlatformSettingsRepository) UpdateSettings(ctx context.Context, ne
 	}
 	return &updatedSettings, nil
 }
+
+// UpdateOIDCSettings replaces the single sign-on settings, creating the settings document if needed.
+func (r *MongoPlatformSettingsRepository) UpdateOIDCSettings(ctx context.Context, oidc *models.OIDCSettings) (*models.PlatformSettings, error) {
+	update := bson.M{
+		"$set": bson.M{
+			"oidc":      oidc,
+			"updatedAt": time.Now(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var updatedSettings models.PlatformSettings
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{}, update, opts).Decode(&updatedSettings); err != nil {
+		return nil, err
+	}
+	return &updatedSettings, nil
+}
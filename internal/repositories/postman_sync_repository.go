@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collectionRevisionsCollection = "collection_revisions"
+
+// PostmanSyncRepository keeps the Postman links of collections and their revisions.
+type PostmanSyncRepository interface {
+	// LinkedCollections returns the collections linked to a Postman collection.
+	LinkedCollections(ctx context.Context) ([]*models.Collection, error)
+	// SetLink links a collection to a Postman collection, or unlinks it when link is nil.
+	SetLink(ctx context.Context, collectionID primitive.ObjectID, link *models.PostmanLink) error
+	// UpdateLink replaces the link of a collection whose latest revision is still revision. It
+	// returns false when another sync stored a revision or the collection was unlinked meanwhile.
+	UpdateLink(ctx context.Context, collectionID primitive.ObjectID, revision int, link *models.PostmanLink) (bool, error)
+	// ApplyRevision makes a new revision the content of a collection whose latest revision is
+	// still the one before it, and stores it. It returns false, storing nothing, otherwise.
+	ApplyRevision(ctx context.Context, link *models.PostmanLink, revision *models.CollectionRevision) (bool, error)
+	// ListRevisions returns the latest revisions of a collection, newest first, without their content.
+	ListRevisions(ctx context.Context, collectionID primitive.ObjectID, limit int) ([]*models.CollectionRevision, error)
+}
+
+// postmanSyncRepository is the concrete implementation of PostmanSyncRepository.
+type postmanSyncRepository struct {
+	collections *mongo.Collection
+	revisions   *mongo.Collection
+}
+
+// NewPostmanSyncRepository creates a new PostmanSyncRepository.
+func NewPostmanSyncRepository(db *mongo.Database) PostmanSyncRepository {
+	r := &postmanSyncRepository{
+		collections: db.Collection("collections"),
+		revisions:   db.Collection(collectionRevisionsCollection),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// Index creation is best effort; a collection has one revision of each number.
+	_, _ = r.revisions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "collection_id", Value: 1}, {Key: "revision", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return r
+}
+
+// LinkedCollections returns the collections linked to a Postman collection.
+func (r *postmanSyncRepository) LinkedCollections(ctx context.Context) ([]*models.Collection, error) {
+	cursor, err := r.collections.Find(ctx, bson.M{"postman_link": bson.M{"$exists": true}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var collections []*models.Collection
+	if err = cursor.All(ctx, &collections); err != nil {
+		return nil, err
+	}
+	return collections, nil
+}
+
+// SetLink links a collection to a Postman collection, or unlinks it when link is nil.
+func (r *postmanSyncRepository) SetLink(ctx context.Context, collectionID primitive.ObjectID, link *models.PostmanLink) error {
+	update := bson.M{"$set": bson.M{"postman_link": link, "updated_at": time.Now()}}
+	if link == nil {
+		update = bson.M{"$unset": bson.M{"postman_link": ""}, "$set": bson.M{"updated_at": time.Now()}}
+	}
+	result, err := r.collections.UpdateOne(ctx, bson.M{"_id": collectionID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// UpdateLink replaces the link of a collection whose latest revision is still revision.
+func (r *postmanSyncRepository) UpdateLink(ctx context.Context, collectionID primitive.ObjectID, revision int, link *models.PostmanLink) (bool, error) {
+	result, err := r.collections.UpdateOne(ctx,
+		bson.M{"_id": collectionID, "postman_link.revision": revision},
+		bson.M{"$set": bson.M{"postman_link": link}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// ApplyRevision makes a new revision the content of its collection and stores it. The stored
+// OpenAPI spec was converted from the previous content, so it is dropped.
+func (r *postmanSyncRepository) ApplyRevision(ctx context.Context, link *models.PostmanLink, revision *models.CollectionRevision) (bool, error) {
+	result, err := r.collections.UpdateOne(ctx,
+		bson.M{"_id": revision.CollectionID, "postman_link.revision": revision.Revision - 1},
+		bson.M{
+			"$set": bson.M{
+				"postman_link":     link,
+				"raw_postman_json": revision.RawPostmanJSON,
+				"updated_at":       time.Now(),
+			},
+			"$unset": bson.M{"openapi_spec": ""},
+		})
+	if err != nil {
+		return false, err
+	}
+	if result.MatchedCount == 0 {
+		return false, nil
+	}
+
+	revision.ID = primitive.NewObjectID()
+	if _, err := r.revisions.InsertOne(ctx, revision); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListRevisions returns the latest revisions of a collection, newest first, without their content.
+func (r *postmanSyncRepository) ListRevisions(ctx context.Context, collectionID primitive.ObjectID, limit int) ([]*models.CollectionRevision, error) {
+	opts := options.Find().
+		SetSort(bson.M{"revision": -1}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"raw_postman_json": 0})
+	cursor, err := r.revisions.Find(ctx, bson.M{"collection_id": collectionID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []*models.CollectionRevision{}
+	if err = cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
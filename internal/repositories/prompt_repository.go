@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PromptRepository stores the custom prompts of integrations. Names are unique per integration.
+type PromptRepository interface {
+	// Save creates the prompt or replaces the prompt with the same integration and name.
+	Save(ctx context.Context, prompt *models.Prompt) (*models.Prompt, error)
+	GetByName(ctx context.Context, integrationID primitive.ObjectID, name string) (*models.Prompt, error)
+	ListByIntegration(ctx context.Context, integrationID primitive.ObjectID) ([]*models.Prompt, error)
+	// Delete removes a prompt and returns mongo.ErrNoDocuments when there is none.
+	Delete(ctx context.Context, integrationID primitive.ObjectID, name string) error
+}
+
+// promptRepository is the concrete implementation of PromptRepository.
+type promptRepository struct {
+	collection *mongo.Collection
+}
+
+// NewPromptRepository creates a new PromptRepository.
+func NewPromptRepository(db *mongo.Database) PromptRepository {
+	return &promptRepository{collection: db.Collection("prompts")}
+}
+
+// Save creates the prompt or replaces the prompt with the same integration and name.
+func (r *promptRepository) Save(ctx context.Context, prompt *models.Prompt) (*models.Prompt, error) {
+	filter := bson.M{"integrationId": prompt.IntegrationID, "name": prompt.Name}
+	var existing models.Prompt
+	err := r.collection.FindOne(ctx, filter).Decode(&existing)
+	switch err {
+	case nil:
+		prompt.ID = existing.ID
+		prompt.CreatedAt = existing.CreatedAt
+	case mongo.ErrNoDocuments:
+		prompt.ID = primitive.NewObjectID()
+		prompt.CreatedAt = time.Now()
+	default:
+		return nil, err
+	}
+	prompt.UpdatedAt = time.Now()
+
+	_, err = r.collection.ReplaceOne(ctx, bson.M{"_id": prompt.ID}, prompt, options.Replace().SetUpsert(true))
+	if err != nil {
+		return nil, err
+	}
+	return prompt, nil
+}
+
+// GetByName retrieves a prompt of an integration by its name.
+func (r *promptRepository) GetByName(ctx context.Context, integrationID primitive.ObjectID, name string) (*models.Prompt, error) {
+	var prompt models.Prompt
+	err := r.collection.FindOne(ctx, bson.M{"integrationId": integrationID, "name": name}).Decode(&prompt)
+	if err != nil {
+		return nil, err
+	}
+	return &prompt, nil
+}
+
+// ListByIntegration returns the prompts of an integration, sorted by name.
+func (r *promptRepository) ListByIntegration(ctx context.Context, integrationID primitive.ObjectID) ([]*models.Prompt, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"integrationId": integrationID}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	prompts := []*models.Prompt{}
+	if err := cursor.All(ctx, &prompts); err != nil {
+		return nil, err
+	}
+	return prompts, nil
+}
+
+// Delete removes a prompt of an integration.
+func (r *promptRepository) Delete(ctx context.Context, integrationID primitive.ObjectID, name string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"integrationId": integrationID, "name": name})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
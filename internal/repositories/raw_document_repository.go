@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RawDocumentRepository reads and patches documents of any collection without decoding them
+// into models, for maintenance such as re-encrypting secrets.
+type RawDocumentRepository interface {
+	// Each calls fn for every document of the collection.
+	Each(ctx context.Context, collection string, fn func(doc bson.D) error) error
+	// SetIfUnchanged sets fields, given by dotted paths, of the document with the ID as long
+	// as the fields still hold their old values. It reports whether the document was updated.
+	SetIfUnchanged(ctx context.Context, collection string, id interface{}, old, updated map[string]interface{}) (bool, error)
+}
+
+// rawDocumentRepository is the concrete implementation of RawDocumentRepository.
+type rawDocumentRepository struct {
+	db *mongo.Database
+}
+
+// NewRawDocumentRepository creates a new RawDocumentRepository.
+func NewRawDocumentRepository(db *mongo.Database) RawDocumentRepository {
+	return &rawDocumentRepository{db: db}
+}
+
+// Each calls fn for every document of the collection.
+func (r *rawDocumentRepository) Each(ctx context.Context, collection string, fn func(doc bson.D) error) error {
+	cursor, err := r.db.Collection(collection).Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc bson.D
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// SetIfUnchanged sets fields of a document whose fields still hold their old values.
+func (r *rawDocumentRepository) SetIfUnchanged(ctx context.Context, collection string, id interface{}, old, updated map[string]interface{}) (bool, error) {
+	filter := bson.M{"_id": id}
+	for path, value := range old {
+		filter[path] = value
+	}
+	result, err := r.db.Collection(collection).UpdateOne(ctx, filter, bson.M{"$set": updated})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
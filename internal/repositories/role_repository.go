@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// organizationRolesCollection holds custom organization roles; organizations delete theirs with them.
+const organizationRolesCollection = "organization_roles"
+
+// RoleRepository stores the custom roles of organizations.
+type RoleRepository interface {
+	Create(ctx context.Context, role *models.Role) error
+	// GetByID returns a role of the organization and mongo.ErrNoDocuments when there is none.
+	GetByID(ctx context.Context, organizationID, id primitive.ObjectID) (*models.Role, error)
+	// GetByName returns a role of the organization and mongo.ErrNoDocuments when there is none.
+	GetByName(ctx context.Context, organizationID primitive.ObjectID, name string) (*models.Role, error)
+	ListByOrganization(ctx context.Context, organizationID primitive.ObjectID) ([]*models.Role, error)
+	// Update replaces the description and permissions of a role; names never change, as members refer to them.
+	Update(ctx context.Context, role *models.Role) error
+	// Delete returns mongo.ErrNoDocuments when the organization has no such role.
+	Delete(ctx context.Context, organizationID, id primitive.ObjectID) error
+}
+
+// roleRepository is the concrete implementation of RoleRepository.
+type roleRepository struct {
+	collection *mongo.Collection
+}
+
+// NewRoleRepository creates a new RoleRepository.
+func NewRoleRepository(db *mongo.Database) RoleRepository {
+	r := &roleRepository{collection: db.Collection(organizationRolesCollection)}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// Index creation is best effort; role names are unique within an organization.
+	_, _ = r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return r
+}
+
+// Create stores a new role.
+func (r *roleRepository) Create(ctx context.Context, role *models.Role) error {
+	now := time.Now()
+	role.ID = primitive.NewObjectID()
+	role.CreatedAt = now
+	role.UpdatedAt = now
+	_, err := r.collection.InsertOne(ctx, role)
+	return err
+}
+
+// GetByID returns a role of an organization.
+func (r *roleRepository) GetByID(ctx context.Context, organizationID, id primitive.ObjectID) (*models.Role, error) {
+	return r.findOne(ctx, bson.M{"_id": id, "organizationId": organizationID})
+}
+
+// GetByName returns the role of an organization with the name.
+func (r *roleRepository) GetByName(ctx context.Context, organizationID primitive.ObjectID, name string) (*models.Role, error) {
+	return r.findOne(ctx, bson.M{"organizationId": organizationID, "name": name})
+}
+
+func (r *roleRepository) findOne(ctx context.Context, filter bson.M) (*models.Role, error) {
+	var role models.Role
+	if err := r.collection.FindOne(ctx, filter).Decode(&role); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// ListByOrganization returns the roles of an organization, by name.
+func (r *roleRepository) ListByOrganization(ctx context.Context, organizationID primitive.ObjectID) ([]*models.Role, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"organizationId": organizationID}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	roles := []*models.Role{}
+	if err := cursor.All(ctx, &roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// Update replaces the description and permissions of a role.
+func (r *roleRepository) Update(ctx context.Context, role *models.Role) error {
+	role.UpdatedAt = time.Now()
+	filter := bson.M{"_id": role.ID, "organizationId": role.OrganizationID}
+	update := bson.M{"$set": bson.M{"description": role.Description, "permissions": role.Permissions, "updatedAt": role.UpdatedAt}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Delete removes a role of an organization.
+func (r *roleRepository) Delete(ctx context.Context, organizationID, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "organizationId": organizationID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
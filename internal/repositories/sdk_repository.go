@@ -94,6 +94,72 @@ func (r *SDKRepository) GetByUserID(ctx context.Context, userID string, page, li
 	return sdks, totalCount, nil
 }
 
+// GetAccessible retrieves a paginated list of the personal SDK records of a user together
+// with those of the given organizations. Only returns non-soft-deleted SDKs.
+func (r *SDKRepository) GetAccessible(ctx context.Context, userID string, organizationIDs []string, page, limit int) ([]*models.SDK, int64, error) {
+	if organizationIDs == nil {
+		organizationIDs = []string{} // $in requires an array
+	}
+	filter := bson.M{
+		"isDeleted": bson.M{"$ne": true},
+		"$or": bson.A{
+			bson.M{"userId": userID, "organizationId": bson.M{"$exists": false}},
+			bson.M{"organizationId": bson.M{"$in": organizationIDs}},
+		},
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSkip(int64((page - 1) * limit))
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		r.logger.Error("Failed to find accessible SDK records", zap.Error(err), zap.String("userID", userID))
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var sdks []*models.SDK
+	if err = cursor.All(ctx, &sdks); err != nil {
+		r.logger.Error("Failed to decode accessible SDK records", zap.Error(err), zap.String("userID", userID))
+		return nil, 0, err
+	}
+
+	totalCount, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		r.logger.Error("Failed to count accessible SDK records", zap.Error(err), zap.String("userID", userID))
+		return nil, 0, err
+	}
+
+	return sdks, totalCount, nil
+}
+
+// SetOrganizationForCollection moves the SDK records of a collection along with the collection
+// into an organization, or makes them personal again when organizationID is empty.
+func (r *SDKRepository) SetOrganizationForCollection(ctx context.Context, collectionID string, organizationID string) error {
+	update := bson.M{"$set": bson.M{"organizationId": organizationID, "updatedAt": time.Now()}}
+	if organizationID == "" {
+		update = bson.M{"$unset": bson.M{"organizationId": ""}, "$set": bson.M{"updatedAt": time.Now()}}
+	}
+	_, err := r.collection.UpdateMany(ctx, bson.M{"collectionId": collectionID}, update)
+	if err != nil {
+		r.logger.Error("Failed to move SDK records of collection", zap.Error(err), zap.String("collectionID", collectionID))
+	}
+	return err
+}
+
+// ClearOrganization returns the SDK records of an organization to the users who generated them.
+func (r *SDKRepository) ClearOrganization(ctx context.Context, organizationID string) error {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"organizationId": organizationID},
+		bson.M{"$unset": bson.M{"organizationId": ""}, "$set": bson.M{"updatedAt": time.Now()}})
+	if err != nil {
+		r.logger.Error("Failed to clear organization of SDK records", zap.Error(err), zap.String("organizationID", organizationID))
+	}
+	return err
+}
+
 // GetByCollectionID retrieves all SDK records for a specific collection.
 // Only returns non-soft-deleted SDKs.
 func (r *SDKRepository) GetByCollectionID(ctx context.Context, collectionID string) ([]*models.SDK, error) {
@@ -15,9 +15,13 @@ type SDKRepositoryInterface interface {
 	Update(ctx context.Context, sdk *models.SDK) error
 	GetByID(ctx context.Context, id primitive.ObjectID) (*models.SDK, error)
 	GetByUserID(ctx context.Context, userID string, page, limit int) ([]*models.SDK, int64, error)
+	GetAccessible(ctx context.Context, userID string, organizationIDs []string, page, limit int) ([]*models.SDK, int64, error)
 	GetByCollectionID(ctx context.Context, collectionID string) ([]*models.SDK, error)
+	SetOrganizationForCollection(ctx context.Context, collectionID string, organizationID string) error
+	ClearOrganization(ctx context.Context, organizationID string) error
 	UpdateFields(ctx context.Context, id primitive.ObjectID, fields bson.M) error
 	SoftDelete(ctx context.Context, id primitive.ObjectID, userID string) error
+	SoftDeleteSDK(ctx context.Context, id primitive.ObjectID) error
 	HardDelete(ctx context.Context, id primitive.ObjectID, userID string) error
 	Collection() *mongo.Collection
 }
@@ -28,12 +28,61 @@ func (r *SessionRepository) CountSessionsCreatedAfter(ctx context.Context, after
 }
 
 func (r *SessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
-	session.ID = primitive.NewObjectID()
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
 	session.CreatedAt = time.Now()
+	session.LastUsedAt = session.CreatedAt
 	_, err := r.collection.InsertOne(ctx, session)
 	return err
 }
 
+// GetSession retrieves a session by its ID.
+func (r *SessionRepository) GetSession(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	var session models.Session
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RotateRefreshToken replaces the refresh token of an unrevoked session whose current token
+// hash is oldHash. It returns mongo.ErrNoDocuments when the token was already rotated or the
+// session revoked, so that of two concurrent refreshes only one succeeds.
+func (r *SessionRepository) RotateRefreshToken(ctx context.Context, id primitive.ObjectID, oldHash, newHash string) error {
+	filter := bson.M{"_id": id, "refreshTokenHash": oldHash, "revokedAt": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{
+		"refreshTokenHash":         newHash,
+		"previousRefreshTokenHash": oldHash,
+		"lastUsedAt":               time.Now(),
+	}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// RevokeSession marks a session as revoked. Revoking a revoked session is not an error.
+func (r *SessionRepository) RevokeSession(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "revokedAt": bson.M{"$exists": false}}
+	_, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revokedAt": time.Now()}})
+	return err
+}
+
+// RevokeUserSessions revokes all sessions of a user and returns how many were active.
+func (r *SessionRepository) RevokeUserSessions(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	filter := bson.M{"userId": userID, "revokedAt": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revokedAt": time.Now()}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
 func (r *SessionRepository) DeleteSession(ctx context.Context, id primitive.ObjectID) error {
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	return err
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ToolExecutionRepository defines the interface for interacting with ToolExecution records.
+type ToolExecutionRepository interface {
+	Create(ctx context.Context, execution *models.ToolExecution) error
+	ListByLinkedAccount(ctx context.Context, linkedAccountID primitive.ObjectID, limit int64) ([]*models.ToolExecution, error)
+}
+
+// toolExecutionRepository is the concrete implementation of ToolExecutionRepository.
+type toolExecutionRepository struct {
+	collection *mongo.Collection
+}
+
+// NewToolExecutionRepository creates a new ToolExecutionRepository.
+func NewToolExecutionRepository(db *mongo.Database) ToolExecutionRepository {
+	return &toolExecutionRepository{
+		collection: db.Collection("tool_executions"),
+	}
+}
+
+// Create stores a new ToolExecution record.
+func (r *toolExecutionRepository) Create(ctx context.Context, execution *models.ToolExecution) error {
+	execution.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, execution)
+	return err
+}
+
+// ListByLinkedAccount returns the most recent executions made with a linked account.
+func (r *toolExecutionRepository) ListByLinkedAccount(ctx context.Context, linkedAccountID primitive.ObjectID, limit int64) ([]*models.ToolExecution, error) {
+	var executions []*models.ToolExecution
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, bson.M{"linkedAccountId": linkedAccountID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &executions); err != nil {
+		return nil, err
+	}
+
+	return executions, nil
+}
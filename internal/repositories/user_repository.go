@@ -18,6 +18,8 @@ type UserRepository interface {
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
 	FindByName(ctx context.Context, name string) (*models.User, error) // Changed from FindByUsername to FindByName
 	FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	// FindBySSOSubject returns the user signed in as the subject of the OIDC issuer, or nil if there is none.
+	FindBySSOSubject(ctx context.Context, issuer, subject string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
 	Delete(ctx context.Context, id primitive.ObjectID) error                     // Added Delete method
 	FindAll(ctx context.Context, page, limit int) ([]*models.User, int64, error) // Added FindAll for admin
@@ -87,6 +89,19 @@ func (r *mongoUserRepository) FindByID(ctx context.Context, id primitive.ObjectI
 	return &user, nil
 }
 
+// FindBySSOSubject retrieves the user of an OIDC identity.
+func (r *mongoUserRepository) FindBySSOSubject(ctx context.Context, issuer, subject string) (*models.User, error) {
+	var user models.User
+	err := r.collection.FindOne(ctx, bson.M{"ssoIssuer": issuer, "ssoSubject": subject}).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
 // Update modifies an existing user's details in the database.
 func (r *mongoUserRepository) Update(ctx context.Context, user *models.User) error {
 	user.UpdatedAt = time.Now()
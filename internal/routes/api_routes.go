@@ -1,8 +1,10 @@
 package routes
 
 import (
-	"github.com/AkashKesav/API2SDK/configs"
 	"github.com/AkashKesav/API2SDK/internal/controllers"
+	"github.com/AkashKesav/API2SDK/internal/middleware"
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/services"
 	"github.com/gofiber/fiber/v3"
 	"go.uber.org/zap"
 )
@@ -21,6 +23,11 @@ func setupCollectionRoutes(api fiber.Router, collectionController *controllers.C
 	api.Put("/:id", collectionController.UpdateCollection)
 	api.Delete("/:id", collectionController.DeleteCollection)
 	api.Post("/:id/generate-openapi-spec", collectionController.GenerateOpenAPISpec)
+	api.Put("/:id/organization", collectionController.TransferCollection)
+	api.Put("/:id/postman", collectionController.LinkPostmanCollection)
+	api.Delete("/:id/postman", collectionController.UnlinkPostmanCollection)
+	api.Post("/:id/postman/sync", collectionController.SyncPostmanCollection)
+	api.Get("/:id/revisions", collectionController.GetCollectionRevisions)
 }
 
 // setupGeneratorRoutes configures SDK generation endpoints
@@ -46,26 +53,26 @@ func setupPublicAPIRoutes(api fiber.Router, publicAPIController *controllers.Pub
 }
 
 // setupHTMXRoutes configures HTMX specific routes
-func setupHTMXRoutes(publicApi fiber.Router, protectedApi fiber.Router, htmxController *controllers.HTMXController) {
+func setupHTMXRoutes(api fiber.Router, htmxController *controllers.HTMXController, requireAuth, requireSession fiber.Handler) {
 	// Public HTMX routes
-	publicApi.Get("/framework-options", controllers.GetFrameworkOptionsHTML)
-	publicApi.Get("/popular-apis", htmxController.GetPopularAPIsHTML)
-	publicApi.Get("/theme-toggle", controllers.GetThemeToggleHTML)
-	publicApi.Post("/theme-toggle", controllers.HandleThemeToggle)
-
-	// Protected HTMX routes (now with no-auth middleware)
-	protectedApi.Post("/collections", htmxController.CreateCollectionHTML)
-	protectedApi.Post("/collections/from-url", htmxController.CreateCollectionFromURLHTML)
-	protectedApi.Post("/collections/from-public-api", htmxController.CreateCollectionFromPublicAPIHTML)
-	protectedApi.Get("/sdk-history", htmxController.GetSDKHistoryHTML)
-	protectedApi.Delete("/sdks/:id", htmxController.DeleteSDKHTML)
-	protectedApi.Get("/generation-status/:taskID", controllers.GetGenerationStatusHTML)
-	protectedApi.Post("/cancel-generation/:taskID", controllers.CancelGenerationTaskHTML)
-	protectedApi.Get("/user-profile-card", htmxController.GetUserProfileCardHTML)
+	api.Get("/framework-options", controllers.GetFrameworkOptionsHTML)
+	api.Get("/popular-apis", htmxController.GetPopularAPIsHTML)
+	api.Get("/theme-toggle", controllers.GetThemeToggleHTML)
+	api.Post("/theme-toggle", controllers.HandleThemeToggle)
+
+	// Protected HTMX routes
+	api.Post("/collections", htmxController.CreateCollectionHTML, requireAuth, requireSession)
+	api.Post("/collections/from-url", htmxController.CreateCollectionFromURLHTML, requireAuth, requireSession)
+	api.Post("/collections/from-public-api", htmxController.CreateCollectionFromPublicAPIHTML, requireAuth, requireSession)
+	api.Get("/sdk-history", htmxController.GetSDKHistoryHTML, requireAuth, requireSession)
+	api.Delete("/sdks/:id", htmxController.DeleteSDKHTML, requireAuth, requireSession)
+	api.Get("/generation-status/:taskID", controllers.GetGenerationStatusHTML, requireAuth, requireSession)
+	api.Post("/cancel-generation/:taskID", controllers.CancelGenerationTaskHTML, requireAuth, requireSession)
+	api.Get("/user-profile-card", htmxController.GetUserProfileCardHTML, requireAuth, requireSession)
 }
 
 // setupAuthRoutes configures authentication endpoints
-func setupAuthRoutes(api fiber.Router, authController *controllers.AuthController, config *configs.Config, logger *zap.Logger) {
+func setupAuthRoutes(api fiber.Router, authController *controllers.AuthController, requireAuth fiber.Handler, logger *zap.Logger) {
 	logger.Info("Setting up auth routes", zap.String("group", "auth"))
 
 	if authController == nil {
@@ -75,36 +82,143 @@ func setupAuthRoutes(api fiber.Router, authController *controllers.AuthControlle
 
 	api.Post("/register", authController.Register)
 	api.Post("/login", authController.Login)
-	api.Get("/profile", authController.GetUserProfile)
-	api.Post("/logout", authController.Logout)
+	api.Post("/refresh", authController.Refresh)
+	api.Get("/profile", authController.GetUserProfile, requireAuth)
+	api.Post("/logout", authController.Logout, requireAuth)
 
-	logger.Info("Auth routes setup completed", zap.Int("routes_added", 4))
+	logger.Info("Auth routes setup completed", zap.Int("routes_added", 5))
+}
+
+// setupSSORoutes configures single sign-on; the callback is authenticated by the state parameter
+func setupSSORoutes(api fiber.Router, ssoController *controllers.SSOController) {
+	api.Get("/oidc/login", ssoController.Login)
+	api.Get("/oidc/callback", ssoController.Callback)
 }
 
 // setupUserRoutes configures user management endpoints (self-service)
 func setupUserRoutes(api fiber.Router, userController *controllers.UserController) {
 	api.Get("/me", userController.GetMe)
-	api.Put("/me", userController.UpdateMe)
+	api.Put("/me", userController.UpdateMe, middleware.RequireSession())
+}
+
+// setupAccessTokenRoutes configures a user's personal access tokens; tokens cannot manage tokens
+func setupAccessTokenRoutes(api fiber.Router, accessTokenController *controllers.AccessTokenController) {
+	tokens := api.Group("/me/tokens", middleware.RequireSession())
+	tokens.Post("/", accessTokenController.CreateToken)
+	tokens.Get("/", accessTokenController.ListTokens)
+	tokens.Delete("/:tokenID", accessTokenController.RevokeToken)
+}
+
+// setupOrganizationRoutes configures organizations, their members, invitations and custom roles
+func setupOrganizationRoutes(api fiber.Router, organizationController *controllers.OrganizationController, policyController *controllers.PolicyController) {
+	api.Post("/", organizationController.CreateOrganization)
+	api.Get("/", organizationController.ListOrganizations)
+	api.Post("/invitations/accept", organizationController.AcceptInvitation)
+	api.Get("/:orgID", organizationController.GetOrganization)
+	api.Put("/:orgID", organizationController.RenameOrganization)
+	api.Delete("/:orgID", organizationController.DeleteOrganization)
+	api.Get("/:orgID/members", organizationController.ListMembers)
+	api.Patch("/:orgID/members/:userID", organizationController.UpdateMember)
+	api.Delete("/:orgID/members/:userID", organizationController.RemoveMember)
+	api.Post("/:orgID/invitations", organizationController.CreateInvitation)
+	api.Get("/:orgID/invitations", organizationController.ListInvitations)
+	api.Delete("/:orgID/invitations/:invitationID", organizationController.RevokeInvitation)
+	api.Get("/:orgID/roles", policyController.ListRoles)
+	api.Post("/:orgID/roles", policyController.CreateRole)
+	api.Put("/:orgID/roles/:roleID", policyController.UpdateRole)
+	api.Delete("/:orgID/roles/:roleID", policyController.DeleteRole)
+}
+
+// setupPermissionRoutes configures the explanation of permission decisions
+func setupPermissionRoutes(api fiber.Router, policyController *controllers.PolicyController) {
+	api.Get("/me/permissions/explain", policyController.ExplainPermission)
 }
 
 // setupUserMCPRoutes configures user MCP management endpoints
 func setupUserMCPRoutes(api fiber.Router, userMCPController *controllers.UserMCPController) {
-	mcps := api.Group("/mcps")
+	mcps := api.Group("/mcps", middleware.RequireScope(models.ScopeMCPManage))
 	mcps.Post("/", userMCPController.CreateMCPInstance)
 	mcps.Get("/", userMCPController.ListMCPInstances)
 	mcps.Delete("/:instanceID", userMCPController.DeleteMCPInstance)
 	mcps.Get("/:instanceID/resources", userMCPController.ListResources)
+	mcps.Post("/:instanceID/hosting", userMCPController.DeployHostedServer)
+	mcps.Get("/:instanceID/hosting", userMCPController.GetHostedServer)
+	mcps.Delete("/:instanceID/hosting", userMCPController.DeleteHostedServer)
+	mcps.Post("/:instanceID/hosting/start", userMCPController.StartHostedServer)
+	mcps.Post("/:instanceID/hosting/stop", userMCPController.StopHostedServer)
+	mcps.Post("/:instanceID/hosting/restart", userMCPController.RestartHostedServer)
+	mcps.Get("/:instanceID/hosting/logs", userMCPController.GetHostedServerLogs)
+}
+
+// setupLinkedAccountRoutes configures a user's linked integration accounts
+func setupLinkedAccountRoutes(api fiber.Router, linkedAccountController *controllers.LinkedAccountController, oauthController *controllers.OAuthController) {
+	accounts := api.Group("/linked-accounts", middleware.RequireScope(models.ScopeMCPManage))
+	accounts.Post("/", linkedAccountController.CreateLinkedAccount)
+	accounts.Get("/", linkedAccountController.ListLinkedAccounts)
+	accounts.Get("/oauth2/:integrationID/authorize", oauthController.Authorize)
+	accounts.Patch("/:accountID", linkedAccountController.UpdateLinkedAccount)
+	accounts.Delete("/:accountID", linkedAccountController.DeleteLinkedAccount)
+	accounts.Get("/:accountID/executions", linkedAccountController.ListExecutions)
+	accounts.Post("/:accountID/revoke", oauthController.Revoke)
+}
+
+// setupOAuthCallbackRoutes configures the redirect target of OAuth2 providers
+func setupOAuthCallbackRoutes(api fiber.Router, oauthController *controllers.OAuthController) {
+	api.Get("/oauth2/callback", oauthController.Callback)
 }
 
-// setupAdminRoutes configures admin-specific endpoints
-func setupAdminRoutes(api fiber.Router, adminController *controllers.AdminController, userController *controllers.UserController) {
+// setupAdminRoutes configures admin management of users, platform settings and logs; each
+// route requires its own platform permission
+func setupAdminRoutes(api fiber.Router, adminController *controllers.AdminController, userController *controllers.UserController, policy services.PolicyService) {
+	can := func(permission models.Permission) fiber.Handler {
+		return middleware.RequirePermission(policy, permission)
+	}
+
 	// User management by admin
-	api.Get("/users", userController.GetAllUsers)
-	api.Get("/users/:id", userController.GetUserByID)
-	api.Put("/users/:id", userController.UpdateUser)
-	api.Delete("/users/:id", userController.DeleteUser)
+	api.Get("/users", userController.GetAllUsers, can(models.PermissionUsersRead))
+	api.Get("/users/:id", userController.GetUserByID, can(models.PermissionUsersRead))
+	api.Put("/users/:id", userController.UpdateUser, can(models.PermissionUsersWrite))
+	api.Delete("/users/:id", userController.DeleteUser, can(models.PermissionUsersDelete))
+	api.Put("/users/:id/role", adminController.UpdateUserRole, can(models.PermissionUsersManageRoles))
 
 	// Platform settings by admin
-	api.Get("/settings", adminController.GetPlatformSettings)
-	api.Put("/settings", adminController.UpdatePlatformSettings)
+	api.Get("/settings", adminController.GetPlatformSettings, can(models.PermissionSettingsRead))
+	api.Put("/settings", adminController.UpdatePlatformSettings, can(models.PermissionSettingsWrite))
+	api.Get("/logs", adminController.GetSystemLogs, can(models.PermissionLogsRead))
+}
+
+// setupAdminSSORoutes configures admin management of the single sign-on settings
+func setupAdminSSORoutes(api fiber.Router, ssoController *controllers.SSOController, policy services.PolicyService) {
+	api.Get("/settings/oidc", ssoController.GetSettings, middleware.RequirePermission(policy, models.PermissionSettingsRead))
+	api.Put("/settings/oidc", ssoController.UpdateSettings, middleware.RequirePermission(policy, models.PermissionSettingsWrite))
+}
+
+// setupAdminAuditRoutes configures querying and exporting the audit log
+func setupAdminAuditRoutes(api fiber.Router, auditController *controllers.AuditController, policy services.PolicyService) {
+	api.Get("/audit", auditController.ListEvents, middleware.RequirePermission(policy, models.PermissionAuditLogRead))
+	api.Get("/audit/export", auditController.ExportEvents, middleware.RequirePermission(policy, models.PermissionAuditLogRead))
+}
+
+// setupAdminIntegrationRoutes configures admin management of integration auth, response shaping, tool
+// annotations, prompts and owning organizations. Credentials (API key, auth and OAuth2 client configuration)
+// have permissions of their own
+func setupAdminIntegrationRoutes(api fiber.Router, integrationController *controllers.IntegrationController, oauthController *controllers.OAuthController, policy services.PolicyService) {
+	can := func(permission models.Permission) fiber.Handler {
+		return middleware.RequirePermission(policy, permission)
+	}
+
+	api.Get("/integrations/:id/auth", integrationController.GetAuth, can(models.PermissionIntegrationCredentialsRead))
+	api.Put("/integrations/:id/auth", integrationController.UpdateAuth, can(models.PermissionIntegrationCredentialsWrite))
+	api.Get("/integrations/:id/api-key", integrationController.GetAPIKey, can(models.PermissionIntegrationCredentialsRead))
+	api.Put("/integrations/:id/api-key", integrationController.UpdateAPIKey, can(models.PermissionIntegrationCredentialsWrite))
+	api.Get("/integrations/:id/oauth2", oauthController.GetConfig, can(models.PermissionIntegrationCredentialsRead))
+	api.Put("/integrations/:id/oauth2", oauthController.UpdateConfig, can(models.PermissionIntegrationCredentialsWrite))
+	api.Get("/integrations/:id/response-shaping", integrationController.GetResponseShaping, can(models.PermissionIntegrationsRead))
+	api.Put("/integrations/:id/response-shaping", integrationController.UpdateResponseShaping, can(models.PermissionIntegrationsWrite))
+	api.Get("/integrations/:id/tool-overrides", integrationController.GetToolOverrides, can(models.PermissionIntegrationsRead))
+	api.Put("/integrations/:id/tool-overrides", integrationController.UpdateToolOverrides, can(models.PermissionIntegrationsWrite))
+	api.Put("/integrations/:id/organization", integrationController.UpdateOrganization, can(models.PermissionIntegrationsWrite))
+	api.Get("/integrations/:id/prompts", integrationController.ListPrompts, can(models.PermissionIntegrationsRead))
+	api.Put("/integrations/:id/prompts/:name", integrationController.SavePrompt, can(models.PermissionIntegrationsWrite))
+	api.Delete("/integrations/:id/prompts/:name", integrationController.DeletePrompt, can(models.PermissionIntegrationsWrite))
 }
@@ -2,6 +2,9 @@ package routes
 
 import (
 	"github.com/AkashKesav/API2SDK/internal/controllers"
+	"github.com/AkashKesav/API2SDK/internal/middleware"
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/services"
 	"github.com/gofiber/fiber/v3"
 )
 
@@ -9,41 +12,56 @@ import (
 type MCPRouter struct {
 	app           fiber.Router
 	mcpController *controllers.MCPController
+	// policy guards the management of the servers of every user
+	policy services.PolicyService
+	// access authenticates every MCP endpoint
+	access []fiber.Handler
 }
 
-// NewMCPRouter creates a new MCPRouter.
-func NewMCPRouter(app fiber.Router, mcpController *controllers.MCPController) *MCPRouter {
+// NewMCPRouter creates a new MCPRouter. The access handlers run before every endpoint; server
+// management then requires the mcp_servers permissions, and the MCP endpoints of instances
+// check that the caller owns the instance.
+func NewMCPRouter(app fiber.Router, mcpController *controllers.MCPController, policy services.PolicyService, access ...fiber.Handler) *MCPRouter {
 	return &MCPRouter{
 		app:           app,
 		mcpController: mcpController,
+		policy:        policy,
+		access:        access,
 	}
 }
 
 // SetupRoutes sets up the routes for the MCP system.
 func (r *MCPRouter) SetupRoutes() {
 	// New unified MCP server management endpoints
-	unified := r.app.Group("/unified")
+	read := middleware.RequirePermission(r.policy, models.PermissionMCPServersRead)
+	manage := middleware.RequirePermission(r.policy, models.PermissionMCPServersManage)
+	unified := r.app.Group("/unified", r.access...)
 	{
 		// Server management
-		unified.Post("/servers", r.mcpController.StartServer)            // Start a new MCP server
-		unified.Get("/servers", r.mcpController.ListServers)             // List all running servers
-		unified.Get("/servers/:serverId", r.mcpController.GetServer)     // Get server info
-		unified.Delete("/servers/:serverId", r.mcpController.StopServer) // Stop a specific server
-		unified.Delete("/servers", r.mcpController.StopAllServers)       // Stop all servers
+		unified.Post("/servers", r.mcpController.StartServer, manage)            // Start a new MCP server
+		unified.Get("/servers", r.mcpController.ListServers, read)               // List all running servers
+		unified.Get("/servers/:serverId", r.mcpController.GetServer, read)       // Get server info
+		unified.Delete("/servers/:serverId", r.mcpController.StopServer, manage) // Stop a specific server
+		unified.Delete("/servers", r.mcpController.StopAllServers, manage)       // Stop all servers
 
 		// Metrics and management
-		unified.Get("/metrics", r.mcpController.GetMCPMetrics)   // Get MCP system metrics
-		unified.Post("/cleanup", r.mcpController.CleanupServers) // Cleanup stopped servers
+		unified.Get("/metrics", r.mcpController.GetMCPMetrics, read)     // Get MCP system metrics
+		unified.Post("/cleanup", r.mcpController.CleanupServers, manage) // Cleanup stopped servers
 	}
 
-	// Legacy MCP instance endpoints for backward compatibility
+	// Legacy MCP instance endpoints for backward compatibility; they resolve the owner's
+	// credentials, so only the owner may use them
+	access := append(append([]fiber.Handler{}, r.access...), r.mcpController.AuthorizeInstance)
+	// Fiber appends each route's handler to the handlers it is given, so the capacity is capped
+	// to make every registration copy them instead of overwriting the previous route's handler.
+	access = access[:len(access):len(access)]
 	legacy := r.app.Group("/instances")
 	{
-		legacy.Get("/:instanceId/sse", r.mcpController.StreamTool)
-		legacy.All("/:instanceId/*", r.mcpController.HandleRequest)
+		legacy.Get("/:instanceId/sse", r.mcpController.StreamTool, access...)
+		legacy.All("/:instanceId/*", r.mcpController.HandleRequest, access...)
 	}
 
 	// For backward compatibility, also support the old format
-	r.app.Get("/:instanceId/sse", r.mcpController.StreamTool)
-	r.app.All("/:instanceId/*", r.mcpController.HandleRequest)
+	r.app.Get("/:instanceId/sse", r.mcpController.StreamTool, access...)
+	r.app.All("/:instanceId/*", r.mcpController.HandleRequest, access...)
 }
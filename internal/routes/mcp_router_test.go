@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/controllers"
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// ownedInstances returns an instance of the user "alice" for every ID; unused methods panic.
+type ownedInstances struct {
+	services.MCPInstanceService
+}
+
+func (ownedInstances) GetMCPInstance(ctx context.Context, id primitive.ObjectID) (*models.MCPInstance, error) {
+	return &models.MCPInstance{ID: id, UserID: "alice", IntegrationID: "invalid"}, nil
+}
+
+// unhosted has no deployed servers; unused methods panic.
+type unhosted struct {
+	services.MCPHostingService
+}
+
+func (unhosted) Endpoint(instanceID string) (string, error) {
+	return "", services.ErrNotHosted
+}
+
+func TestMCPRouterStreamsSSE(t *testing.T) {
+	controller := controllers.NewMCPController(ownedInstances{}, nil, nil, unhosted{}, nil, nil, zap.NewNop())
+	app := fiber.New()
+	next := func(c fiber.Ctx) error { return c.Next() }
+	authenticate := func(c fiber.Ctx) error {
+		c.Locals("user_id", "alice")
+		return c.Next()
+	}
+	// Several access handlers leave spare capacity in the handler chain shared by the routes.
+	NewMCPRouter(app.Group("/mcp"), controller, nil, authenticate, next, next).SetupRoutes()
+
+	const instanceID = "64b7f0c2e4b0a1a2b3c4d5e6"
+	for _, path := range []string{"/mcp/instances/" + instanceID + "/sse", "/mcp/" + instanceID + "/sse"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+			t.Errorf("GET %s = %d %q, want the StreamTool event stream", path, resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"github.com/AkashKesav/API2SDK/configs"
 	"github.com/AkashKesav/API2SDK/internal/controllers"
 	"github.com/AkashKesav/API2SDK/internal/middleware"
+	"github.com/AkashKesav/API2SDK/internal/models"
 	"github.com/AkashKesav/API2SDK/internal/services"
+	"github.com/go-redis/redis/v8"
 	"github.com/gofiber/fiber/v3"
 	"go.uber.org/zap"
 )
@@ -24,56 +26,93 @@ func SetupRoutes(
 	publicApiController *controllers.PublicAPIController,
 	mcpController *controllers.MCPController,
 	userMCPController *controllers.UserMCPController,
+	linkedAccountController *controllers.LinkedAccountController,
+	oauthController *controllers.OAuthController,
+	integrationController *controllers.IntegrationController,
+	accessTokenController *controllers.AccessTokenController,
+	organizationController *controllers.OrganizationController,
+	policyController *controllers.PolicyController,
+	ssoController *controllers.SSOController,
+	auditController *controllers.AuditController,
 	authService services.AuthService,
+	policyService services.PolicyService,
+	accessTokenService services.AccessTokenService,
+	redisClient *redis.Client,
 	logger *zap.Logger,
 	config *configs.Config,
 ) {
 	// API version 1 routes
 	api := app.Group("/api/v1")
 
-	// Auth routes (public)
+	// Every group that requires a signed-in user accepts the same access tokens, and personal
+	// access tokens on the routes of their scopes, limited per token
+	requireAuth := middleware.AuthMiddleware(authService, accessTokenService)
+	tokenRateLimit := middleware.AccessTokenRateLimiterMiddleware(middleware.RedisRateLimiterConfig{
+		RedisClient: redisClient,
+		Logger:      logger,
+	})
+
+	// Auth routes (login, registration and refresh are public)
 	authGroup := api.Group("/auth")
-	setupAuthRoutes(authGroup, authController, config, logger)
+	setupAuthRoutes(authGroup, authController, requireAuth, logger)
+	setupSSORoutes(authGroup, ssoController)
 
 	// Health check routes (public)
 	setupHealthRoutes(api, healthController)
 
 	// User self-service routes
-	usersGroup := api.Group("/users", middleware.NoAuthMiddleware())
+	usersGroup := api.Group("/users", requireAuth, tokenRateLimit)
 	setupUserRoutes(usersGroup, userController)
+	setupAccessTokenRoutes(usersGroup, accessTokenController)
+	setupPermissionRoutes(usersGroup, policyController)
 	setupUserMCPRoutes(usersGroup, userMCPController)
+	setupLinkedAccountRoutes(usersGroup, linkedAccountController, oauthController)
+
+	// OAuth2 provider callback (public - authenticated by the state parameter)
+	setupOAuthCallbackRoutes(api, oauthController)
 
 	// Collection routes
-	collectionsGroup := api.Group("/collections", middleware.NoAuthMiddleware())
+	collectionsGroup := api.Group("/collections", requireAuth, tokenRateLimit,
+		middleware.RequireMethodScope(models.ScopeCollectionsRead, models.ScopeCollectionsWrite))
 	setupCollectionRoutes(collectionsGroup, collectionController)
 
+	// Organization, membership and invitation routes
+	organizationsGroup := api.Group("/organizations", requireAuth, tokenRateLimit,
+		middleware.RequireMethodScope(models.ScopeCollectionsRead, models.ScopeCollectionsWrite))
+	setupOrganizationRoutes(organizationsGroup, organizationController, policyController)
+
 	// SDK generation routes with rate limiting
 	generateGroup := api.Group("/generate",
-		middleware.NoAuthMiddleware(),
+		requireAuth,
+		tokenRateLimit,
+		middleware.RequireScope(models.ScopeSDKGenerate),
 		middleware.EnhancedRateLimitMiddleware(middleware.NewRateLimiter(10, time.Minute), logger),
 		middleware.CircuitBreakerMiddleware("sdk_generation", logger))
 	setupGeneratorRoutes(generateGroup, sdkController)
 
 	// SDK management routes (history, deletion, download)
-	sdksGroup := api.Group("/sdks", middleware.NoAuthMiddleware())
+	sdksGroup := api.Group("/sdks", requireAuth, tokenRateLimit, middleware.RequireScope(models.ScopeSDKGenerate))
 	setupSDKRoutes(sdksGroup, sdkController)
 
 	// Public API browsing routes (public - no auth required)
 	publicApisGroup := api.Group("/public-apis")
 	setupPublicAPIRoutes(publicApisGroup, publicApiController)
 
-	// HTMX routes - mixed public and protected
-	publicHtmxGroup := api.Group("/htmx")
-	protectedHtmxGroup := api.Group("/htmx", middleware.NoAuthMiddleware())
-	setupHTMXRoutes(publicHtmxGroup, protectedHtmxGroup, htmxController)
+	// HTMX routes - mixed public and protected, so authentication is applied per route.
+	// They serve the web UI, so only sessions may use them.
+	htmxGroup := api.Group("/htmx")
+	setupHTMXRoutes(htmxGroup, htmxController, requireAuth, middleware.RequireSession())
 
-	// Admin routes
-	adminGroup := api.Group("/admin", middleware.NoAuthMiddleware(), middleware.AdminRequired())
-	setupAdminRoutes(adminGroup, adminController, userController)
+	// Admin routes; each requires a platform permission of its own
+	adminGroup := api.Group("/admin", requireAuth, tokenRateLimit, middleware.RequireScope(models.ScopeAdmin))
+	setupAdminRoutes(adminGroup, adminController, userController, policyService)
+	setupAdminSSORoutes(adminGroup, ssoController, policyService)
+	setupAdminAuditRoutes(adminGroup, auditController, policyService)
+	setupAdminIntegrationRoutes(adminGroup, integrationController, oauthController, policyService)
 
-	// MCP routes
+	// MCP routes; instances are used by their owners
 	mcpGroup := app.Group("/mcp")
-	mcpRouter := NewMCPRouter(mcpGroup, mcpController)
+	mcpRouter := NewMCPRouter(mcpGroup, mcpController, policyService, requireAuth, tokenRateLimit, middleware.RequireScope(models.ScopeMCPManage))
 	mcpRouter.SetupRoutes()
 
 	// Serve static files for frontend
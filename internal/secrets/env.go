@@ -0,0 +1,33 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvStore reads secrets from environment variables. Only variables whose names start with its
+// prefix can be read, so that references cannot reach the platform's own configuration.
+type EnvStore struct {
+	prefix string
+	lookup func(name string) (string, bool)
+}
+
+// NewEnvStore creates an EnvStore of the variables whose names start with prefix, which must
+// not be empty.
+func NewEnvStore(prefix string) *EnvStore {
+	return &EnvStore{prefix: prefix, lookup: os.LookupEnv}
+}
+
+// Get returns the value of the environment variable named by path.
+func (s *EnvStore) Get(ctx context.Context, path string) (string, error) {
+	if s.prefix == "" || !strings.HasPrefix(path, s.prefix) {
+		return "", fmt.Errorf("%w: environment variables must start with %s", ErrInvalidReference, s.prefix)
+	}
+	value, ok := s.lookup(path)
+	if !ok {
+		return "", fmt.Errorf("%w: %s is not set", ErrNotFound, path)
+	}
+	return value, nil
+}
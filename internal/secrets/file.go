@@ -0,0 +1,182 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/AkashKesav/API2SDK/internal/crypto"
+)
+
+// keystoreVersion is the format version of keystore files.
+const keystoreVersion = 1
+
+// keystore is the content of a keystore file: secret values encrypted with the keyring, by name.
+type keystore struct {
+	Version int               `json:"version"`
+	Secrets map[string]string `json:"secrets"`
+}
+
+// FileStore keeps secrets in a local JSON file, each value encrypted with the platform's master
+// keys, so that the file may be backed up or shipped without exposing them.
+type FileStore struct {
+	path    string
+	keyring *crypto.Keyring
+
+	mu sync.Mutex
+}
+
+// NewFileStore creates a FileStore of the keystore file at path. The file is created by the
+// first Set.
+func NewFileStore(path string, keyring *crypto.Keyring) *FileStore {
+	return &FileStore{path: path, keyring: keyring}
+}
+
+// Get returns the secret named path.
+func (s *FileStore) Get(ctx context.Context, path string) (string, error) {
+	s.mu.Lock()
+	store, err := s.load()
+	s.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	encrypted, ok := store.Secrets[path]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	value, err := s.keyring.Decrypt(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret %s: %w", path, err)
+	}
+	return value, nil
+}
+
+// Set stores a secret under name, replacing any previous value.
+func (s *FileStore) Set(name, value string) error {
+	if _, err := ParseReference(BackendFile + ":" + name); err != nil {
+		return err
+	}
+	encrypted, err := s.keyring.Encrypt(value)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	store, err := s.load()
+	if err != nil {
+		return err
+	}
+	store.Secrets[name] = encrypted
+	return s.save(store)
+}
+
+// Delete removes the secret named name.
+func (s *FileStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	store, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := store.Secrets[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	delete(store.Secrets, name)
+	return s.save(store)
+}
+
+// Names lists the names of the stored secrets.
+func (s *FileStore) Names() ([]string, error) {
+	s.mu.Lock()
+	store, err := s.load()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(store.Secrets))
+	for name := range store.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Rotate re-encrypts the secrets that are not encrypted under the active master key and returns
+// how many it re-encrypted.
+func (s *FileStore) Rotate() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	store, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+	rotated := 0
+	for name, encrypted := range store.Secrets {
+		value, changed, err := s.keyring.Rotate(encrypted)
+		if err != nil {
+			return 0, fmt.Errorf("failed to rotate secret %s: %w", name, err)
+		}
+		if changed {
+			store.Secrets[name] = value
+			rotated++
+		}
+	}
+	if rotated == 0 {
+		return 0, nil
+	}
+	return rotated, s.save(store)
+}
+
+// load reads the keystore file; a missing file is an empty keystore.
+func (s *FileStore) load() (*keystore, error) {
+	store := &keystore{Version: keystoreVersion, Secrets: map[string]string{}}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %w", err)
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("invalid keystore %s: %w", s.path, err)
+	}
+	if store.Version != keystoreVersion {
+		return nil, fmt.Errorf("unsupported keystore version %d", store.Version)
+	}
+	if store.Secrets == nil {
+		store.Secrets = map[string]string{}
+	}
+	return store, nil
+}
+
+// save replaces the keystore file, readable only by its owner, through a rename so that readers
+// never see a partly written file.
+func (s *FileStore) save(store *keystore) error {
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".keystore-*")
+	if err != nil {
+		return fmt.Errorf("failed to write keystore: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write keystore: %w", err)
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,175 @@
+// Package secrets reads credentials that are kept outside the database.
+//
+// Instead of a secret value, integrations and linked accounts may hold a Reference such as
+// "vault:integrations/github#token". The part before the colon names the Store the secret is
+// kept in: HashiCorp Vault KV, a local keystore file encrypted with the platform's master keys,
+// or environment variables. A Resolver looks references up in their stores and caches the
+// values for a while.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/configs"
+	"github.com/AkashKesav/API2SDK/internal/crypto"
+)
+
+// Built-in backends, the prefixes of references.
+const (
+	BackendVault = "vault"
+	BackendFile  = "file"
+	BackendEnv   = "env"
+)
+
+// maxReferenceLength bounds the length of a reference.
+const maxReferenceLength = 512
+
+var (
+	// ErrInvalidReference is returned for references that are malformed or name a secret the
+	// store refuses to read.
+	ErrInvalidReference = errors.New("invalid secret reference")
+	// ErrUnknownBackend is returned for references to a backend that is not configured.
+	ErrUnknownBackend = errors.New("secret backend is not configured")
+	// ErrNotFound is returned when the secret a reference names does not exist.
+	ErrNotFound = errors.New("secret not found")
+)
+
+// Reference names a secret in a store, written "backend:path".
+type Reference struct {
+	Backend string
+	Path    string
+}
+
+// ParseReference parses a reference. Paths are made of "/"-separated names, none of which may
+// be empty, "." or "..".
+func ParseReference(ref string) (Reference, error) {
+	backend, path, ok := strings.Cut(ref, ":")
+	if !ok || backend == "" || path == "" {
+		return Reference{}, fmt.Errorf("%w: %q is not backend:path", ErrInvalidReference, ref)
+	}
+	if len(ref) > maxReferenceLength {
+		return Reference{}, fmt.Errorf("%w: longer than %d characters", ErrInvalidReference, maxReferenceLength)
+	}
+	for _, r := range ref {
+		if r < 0x20 || r == 0x7f {
+			return Reference{}, fmt.Errorf("%w: contains control characters", ErrInvalidReference)
+		}
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return Reference{}, fmt.Errorf("%w: %q has an empty, '.' or '..' path segment", ErrInvalidReference, ref)
+		}
+	}
+	return Reference{Backend: strings.ToLower(backend), Path: path}, nil
+}
+
+// String returns the reference as "backend:path".
+func (r Reference) String() string {
+	return r.Backend + ":" + r.Path
+}
+
+// Within reports whether the path of the reference lies below namespace, a path prefix such as
+// "linked-accounts/customer-1".
+func (r Reference) Within(namespace string) bool {
+	return strings.HasPrefix(r.Path, namespace+"/")
+}
+
+// Store reads the secrets of one backend.
+type Store interface {
+	// Get returns the secret at path, or ErrNotFound.
+	Get(ctx context.Context, path string) (string, error)
+}
+
+// cachedSecret is a secret read by a Resolver.
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+// Resolver looks references up in the stores of their backends. Secrets are cached for the TTL
+// so that every tool call does not reach the store; failures are not cached.
+type Resolver struct {
+	stores map[string]Store
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[Reference]cachedSecret
+}
+
+// NewResolver creates a resolver of the stores, keyed by backend. A zero ttl disables caching.
+func NewResolver(stores map[string]Store, ttl time.Duration) *Resolver {
+	return &Resolver{stores: stores, ttl: ttl, now: time.Now, cache: map[Reference]cachedSecret{}}
+}
+
+// ResolverFromConfig creates the resolver of the configured stores: Vault when VAULT_ADDR is
+// set, the keystore file when SECRETS_FILE_PATH is set and environment variables when
+// SECRETS_ENV_PREFIX is set. The keystore is encrypted with keyring.
+func ResolverFromConfig(config *configs.Config, keyring *crypto.Keyring) *Resolver {
+	stores := map[string]Store{}
+	if config.VaultAddr != "" {
+		stores[BackendVault] = NewVaultStore(VaultConfig{
+			Address:   config.VaultAddr,
+			Token:     config.VaultToken,
+			Namespace: config.VaultNamespace,
+			Mount:     config.VaultKVMount,
+		})
+	}
+	if config.SecretsFilePath != "" {
+		stores[BackendFile] = NewFileStore(config.SecretsFilePath, keyring)
+	}
+	if config.SecretsEnvPrefix != "" {
+		stores[BackendEnv] = NewEnvStore(config.SecretsEnvPrefix)
+	}
+	return NewResolver(stores, time.Duration(config.SecretsCacheTTL)*time.Second)
+}
+
+// Backends lists the configured backends.
+func (r *Resolver) Backends() []string {
+	backends := make([]string, 0, len(r.stores))
+	for backend := range r.stores {
+		backends = append(backends, backend)
+	}
+	sort.Strings(backends)
+	return backends
+}
+
+// Supports reports whether the backend of the reference is configured.
+func (r *Resolver) Supports(ref Reference) bool {
+	_, ok := r.stores[ref.Backend]
+	return ok
+}
+
+// Resolve returns the secret the reference names and whether it came from the cache.
+func (r *Resolver) Resolve(ctx context.Context, ref Reference) (string, bool, error) {
+	store, ok := r.stores[ref.Backend]
+	if !ok {
+		return "", false, fmt.Errorf("%w: %s", ErrUnknownBackend, ref.Backend)
+	}
+
+	if r.ttl > 0 {
+		r.mu.Lock()
+		cached, ok := r.cache[ref]
+		r.mu.Unlock()
+		if ok && r.now().Before(cached.expires) {
+			return cached.value, true, nil
+		}
+	}
+
+	value, err := store.Get(ctx, ref.Path)
+	if err != nil {
+		return "", false, err
+	}
+	if r.ttl > 0 {
+		r.mu.Lock()
+		r.cache[ref] = cachedSecret{value: value, expires: r.now().Add(r.ttl)}
+		r.mu.Unlock()
+	}
+	return value, false, nil
+}
@@ -0,0 +1,149 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/crypto"
+)
+
+func TestParseReference(t *testing.T) {
+	ref, err := ParseReference("Vault:integrations/github#token")
+	if err != nil || ref.Backend != BackendVault || ref.Path != "integrations/github#token" {
+		t.Fatalf("ParseReference() = %+v, %v", ref, err)
+	}
+	if ref.String() != "vault:integrations/github#token" {
+		t.Errorf("String() = %q", ref.String())
+	}
+	if !ref.Within("integrations") || ref.Within("integration") || ref.Within("integrations/github") {
+		t.Errorf("Within() is wrong for %q", ref.Path)
+	}
+
+	for _, invalid := range []string{"", "vault", ":path", "vault:", "file:a//b", "file:/abs", "file:a/../b", "file:./a", "env:A\nB", "file:" + strings.Repeat("a", maxReferenceLength)} {
+		if _, err := ParseReference(invalid); !errors.Is(err, ErrInvalidReference) {
+			t.Errorf("ParseReference(%q) error = %v, want ErrInvalidReference", invalid, err)
+		}
+	}
+}
+
+// countingStore serves fixed secrets and counts the reads.
+type countingStore struct {
+	secrets map[string]string
+	reads   int
+}
+
+func (s *countingStore) Get(ctx context.Context, path string) (string, error) {
+	s.reads++
+	value, ok := s.secrets[path]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func TestResolverCachesSecrets(t *testing.T) {
+	store := &countingStore{secrets: map[string]string{"api-key": "k-1"}}
+	resolver := NewResolver(map[string]Store{BackendFile: store}, time.Minute)
+	now := time.Now()
+	resolver.now = func() time.Time { return now }
+	ref := Reference{Backend: BackendFile, Path: "api-key"}
+
+	if value, cached, err := resolver.Resolve(context.Background(), ref); err != nil || value != "k-1" || cached {
+		t.Fatalf("Resolve() = %q, %t, %v, want an uncached read", value, cached, err)
+	}
+	if value, cached, _ := resolver.Resolve(context.Background(), ref); value != "k-1" || !cached || store.reads != 1 {
+		t.Errorf("Resolve() again = %q, %t after %d reads, want a cached value", value, cached, store.reads)
+	}
+	now = now.Add(time.Minute)
+	if _, cached, _ := resolver.Resolve(context.Background(), ref); cached || store.reads != 2 {
+		t.Errorf("Resolve() after the TTL cached = %t after %d reads, want a new read", cached, store.reads)
+	}
+
+	missing := Reference{Backend: BackendFile, Path: "missing"}
+	for i := 0; i < 2; i++ {
+		if _, _, err := resolver.Resolve(context.Background(), missing); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Resolve(missing) error = %v", err)
+		}
+	}
+	if store.reads != 4 {
+		t.Errorf("failures were cached: %d reads, want 4", store.reads)
+	}
+	if _, _, err := resolver.Resolve(context.Background(), Reference{Backend: BackendVault, Path: "x"}); !errors.Is(err, ErrUnknownBackend) {
+		t.Errorf("Resolve() of an unconfigured backend error = %v, want ErrUnknownBackend", err)
+	}
+}
+
+func TestEnvStore(t *testing.T) {
+	t.Setenv("API2SDK_SECRET_GITHUB", "gh-token")
+	t.Setenv("JWT_SECRET", "platform secret")
+	store := NewEnvStore("API2SDK_SECRET_")
+
+	if value, err := store.Get(context.Background(), "API2SDK_SECRET_GITHUB"); err != nil || value != "gh-token" {
+		t.Errorf("Get() = %q, %v", value, err)
+	}
+	if _, err := store.Get(context.Background(), "JWT_SECRET"); !errors.Is(err, ErrInvalidReference) {
+		t.Errorf("Get() of a variable without the prefix error = %v, want ErrInvalidReference", err)
+	}
+	if _, err := store.Get(context.Background(), "API2SDK_SECRET_MISSING"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of an unset variable error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	oldKeyring, _ := crypto.NewKeyring("k1", map[string]string{"k1": "0123456789abcdef0123456789abcdef"}, false)
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	store := NewFileStore(path, oldKeyring)
+	ctx := context.Background()
+
+	if _, err := store.Get(ctx, "github"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() from a missing keystore error = %v, want ErrNotFound", err)
+	}
+	if err := store.Set("github", "gh-token"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := store.Set("linked-accounts/customer-1/token", "c1-token"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := store.Set("../escape", "x"); !errors.Is(err, ErrInvalidReference) {
+		t.Errorf("Set() with an invalid name error = %v, want ErrInvalidReference", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "gh-token") {
+		t.Error("the keystore holds a plaintext secret")
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("keystore mode = %v, %v, want 0600", info.Mode(), err)
+	}
+	if value, err := store.Get(ctx, "github"); err != nil || value != "gh-token" {
+		t.Errorf("Get() = %q, %v", value, err)
+	}
+	if names, _ := store.Names(); strings.Join(names, ",") != "github,linked-accounts/customer-1/token" {
+		t.Errorf("Names() = %v", names)
+	}
+
+	keyring, _ := crypto.NewKeyring("k2", map[string]string{
+		"k2": "fedcba9876543210fedcba9876543210",
+		"k1": "0123456789abcdef0123456789abcdef",
+	}, false)
+	rotated, err := NewFileStore(path, keyring).Rotate()
+	if err != nil || rotated != 2 {
+		t.Fatalf("Rotate() = %d, %v, want 2 secrets rotated", rotated, err)
+	}
+	current, _ := crypto.NewKeyring("k2", map[string]string{"k2": "fedcba9876543210fedcba9876543210"}, false)
+	if value, err := NewFileStore(path, current).Get(ctx, "github"); err != nil || value != "gh-token" {
+		t.Errorf("Get() after rotation without the old key = %q, %v", value, err)
+	}
+
+	if err := store.Delete("github"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := store.Delete("github"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() of a missing secret error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,103 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultVaultField is the field of a Vault secret read when a reference names none.
+const defaultVaultField = "value"
+
+// maxVaultResponseBytes bounds the size of a Vault response.
+const maxVaultResponseBytes = 1 << 20
+
+// VaultConfig configures a VaultStore.
+type VaultConfig struct {
+	Address   string // e.g. https://vault.example.com:8200
+	Token     string
+	Namespace string // Vault Enterprise namespace; empty for the root namespace
+	Mount     string // Mount of the KV version 2 secrets engine, "secret" when empty
+	// Client sends the requests to Vault. When nil a client with a 10 second timeout is used.
+	Client *http.Client
+}
+
+// VaultStore reads secrets from the KV version 2 secrets engine of HashiCorp Vault. Paths name
+// a secret and optionally one of its fields, as "integrations/github#token"; without a field
+// the "value" field is read.
+type VaultStore struct {
+	config VaultConfig
+}
+
+// NewVaultStore creates a VaultStore.
+func NewVaultStore(config VaultConfig) *VaultStore {
+	config.Address = strings.TrimRight(config.Address, "/")
+	if config.Mount == "" {
+		config.Mount = "secret"
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &VaultStore{config: config}
+}
+
+// vaultSecret is the body of Vault's response to a KV version 2 read.
+type vaultSecret struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// Get reads the latest version of the secret at path.
+func (s *VaultStore) Get(ctx context.Context, path string) (string, error) {
+	path, field, _ := strings.Cut(path, "#")
+	if field == "" {
+		field = defaultVaultField
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	endpoint := s.config.Address + "/v1/" + url.PathEscape(s.config.Mount) + "/data/" + strings.Join(segments, "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", s.config.Token)
+	if s.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.config.Namespace)
+	}
+	resp, err := s.config.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach Vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var secret vaultSecret
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxVaultResponseBytes)).Decode(&secret); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("invalid Vault response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("%w: %s", ErrNotFound, path)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("vault responded %d: %s", resp.StatusCode, strings.Join(secret.Errors, "; "))
+	}
+
+	value, ok := secret.Data.Data[field]
+	if !ok {
+		return "", fmt.Errorf("%w: %s has no field %q", ErrNotFound, path, field)
+	}
+	text, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("field %q of %s is not a string", field, path)
+	}
+	return text, nil
+}
@@ -0,0 +1,73 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newVaultStub serves the KV version 2 secrets of a Vault dev server with the token "root",
+// keyed by their path below the mount.
+func newVaultStub(t *testing.T, mount string, kv map[string]map[string]interface{}) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"permission denied"}})
+			return
+		}
+		prefix := "/v1/" + mount + "/data/"
+		data, ok := kv[strings.TrimPrefix(r.URL.Path, prefix)]
+		if r.Method != http.MethodGet || !strings.HasPrefix(r.URL.Path, prefix) || !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"data": data, "metadata": map[string]interface{}{"version": 3}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVaultStore(t *testing.T) {
+	server := newVaultStub(t, "kv", map[string]map[string]interface{}{
+		"integrations/github":     {"token": "gh-token", "value": "default-value", "port": 8080},
+		"linked-accounts/a b/key": {"value": "spaced"},
+	})
+	store := NewVaultStore(VaultConfig{Address: server.URL + "/", Token: "root", Mount: "kv"})
+	ctx := context.Background()
+
+	tests := []struct {
+		path, want string
+	}{
+		{"integrations/github#token", "gh-token"},
+		{"integrations/github", "default-value"},
+		{"linked-accounts/a b/key", "spaced"},
+	}
+	for _, tt := range tests {
+		if value, err := store.Get(ctx, tt.path); err != nil || value != tt.want {
+			t.Errorf("Get(%q) = %q, %v, want %q", tt.path, value, err, tt.want)
+		}
+	}
+
+	for _, path := range []string{"integrations/gitlab", "integrations/github#missing"} {
+		if _, err := store.Get(ctx, path); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) error = %v, want ErrNotFound", path, err)
+		}
+	}
+	if _, err := store.Get(ctx, "integrations/github#port"); err == nil {
+		t.Error("Get() of a number field succeeded")
+	}
+
+	denied := NewVaultStore(VaultConfig{Address: server.URL, Token: "wrong", Mount: "kv"})
+	if _, err := denied.Get(ctx, "integrations/github#token"); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Get() with a wrong token error = %v, want Vault's error", err)
+	}
+}
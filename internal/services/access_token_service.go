@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	// AccessTokenPrefix starts every personal access token, telling them apart from access JWTs.
+	AccessTokenPrefix = "a2s_"
+	// accessTokenDisplayLength is how much of a token is kept to identify it in listings.
+	accessTokenDisplayLength = len(AccessTokenPrefix) + 8
+	maxAccessTokensPerUser   = 50
+	maxAccessTokenNameLength = 100
+	maxAccessTokenRateLimit  = 10000
+	// lastUsedResolution bounds how often token use is written back.
+	lastUsedResolution = time.Minute
+)
+
+var (
+	// ErrInvalidAccessTokenRequest is returned for token requests with invalid fields.
+	ErrInvalidAccessTokenRequest = errors.New("invalid access token request")
+	// ErrAccessTokenNotFound is returned when the user has no such token.
+	ErrAccessTokenNotFound = errors.New("access token not found")
+)
+
+// AccessTokenService manages personal access tokens and authenticates requests made with them.
+type AccessTokenService interface {
+	// CreateToken creates a token for the user; only users with platform permissions may create tokens with the admin scope.
+	CreateToken(ctx context.Context, userID primitive.ObjectID, role models.UserRole, req *models.CreateAccessTokenRequest) (*models.CreatedAccessToken, error)
+	ListTokens(ctx context.Context, userID primitive.ObjectID) ([]*models.AccessToken, error)
+	RevokeToken(ctx context.Context, userID, tokenID primitive.ObjectID) error
+	// Authenticate returns the principal of an unrevoked, unexpired token used from an allowed address.
+	Authenticate(ctx context.Context, token, clientIP string) (*Principal, error)
+}
+
+type accessTokenService struct {
+	repo     repositories.AccessTokenRepository
+	userRepo repositories.UserRepository
+	audit    AuditService
+	logger   *zap.Logger
+}
+
+// NewAccessTokenService creates a new AccessTokenService.
+func NewAccessTokenService(repo repositories.AccessTokenRepository, userRepo repositories.UserRepository, audit AuditService, logger *zap.Logger) AccessTokenService {
+	return &accessTokenService{repo: repo, userRepo: userRepo, audit: audit, logger: logger}
+}
+
+// IsAccessToken reports whether a credential is a personal access token rather than a JWT.
+func IsAccessToken(credential string) bool {
+	return strings.HasPrefix(credential, AccessTokenPrefix)
+}
+
+// CreateToken creates a token for the user.
+func (s *accessTokenService) CreateToken(ctx context.Context, userID primitive.ObjectID, role models.UserRole, req *models.CreateAccessTokenRequest) (*models.CreatedAccessToken, error) {
+	scopes, err := validateAccessTokenRequest(req, role)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.repo.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count access tokens: %w", err)
+	}
+	if count >= maxAccessTokensPerUser {
+		return nil, fmt.Errorf("%w: at most %d tokens per user", ErrInvalidAccessTokenRequest, maxAccessTokensPerUser)
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+	token := AccessTokenPrefix + secret
+	accessToken := &models.AccessToken{
+		UserID:             userID,
+		Name:               strings.TrimSpace(req.Name),
+		Prefix:             token[:accessTokenDisplayLength],
+		TokenHash:          hashToken(token),
+		Scopes:             scopes,
+		AllowedIPs:         req.AllowedIPs,
+		RateLimitPerMinute: req.RateLimitPerMinute,
+		ExpiresAt:          req.ExpiresAt,
+	}
+	if err := s.repo.Create(ctx, accessToken); err != nil {
+		return nil, fmt.Errorf("failed to store access token: %w", err)
+	}
+
+	s.logger.Info("Access token created",
+		zap.String("userID", userID.Hex()),
+		zap.String("tokenID", accessToken.ID.Hex()),
+		zap.Strings("scopes", scopes))
+	s.audit.Record(ctx, &models.AuditEvent{
+		Action:     models.AuditActionAccessTokenCreate,
+		TargetType: models.AuditTargetAccessToken,
+		TargetID:   accessToken.ID.Hex(),
+		Metadata: map[string]string{
+			"name":   accessToken.Name,
+			"prefix": accessToken.Prefix,
+			"scopes": strings.Join(scopes, ","),
+		},
+	})
+	return &models.CreatedAccessToken{AccessToken: accessToken, Token: token}, nil
+}
+
+// ListTokens returns the unrevoked tokens of the user.
+func (s *accessTokenService) ListTokens(ctx context.Context, userID primitive.ObjectID) ([]*models.AccessToken, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// RevokeToken revokes a token of the user.
+func (s *accessTokenService) RevokeToken(ctx context.Context, userID, tokenID primitive.ObjectID) error {
+	err := s.repo.Revoke(ctx, userID, tokenID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrAccessTokenNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+	s.logger.Info("Access token revoked", zap.String("userID", userID.Hex()), zap.String("tokenID", tokenID.Hex()))
+	s.audit.Record(ctx, &models.AuditEvent{
+		Action:     models.AuditActionAccessTokenRevoke,
+		TargetType: models.AuditTargetAccessToken,
+		TargetID:   tokenID.Hex(),
+	})
+	return nil
+}
+
+// Authenticate returns the principal of a token. The role is that of the user now, so a
+// demoted admin's tokens lose admin access along with the user.
+func (s *accessTokenService) Authenticate(ctx context.Context, token, clientIP string) (*Principal, error) {
+	accessToken, err := s.repo.GetByHash(ctx, hashToken(token))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: unknown access token", ErrUnauthenticated)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load access token: %w", err)
+	}
+
+	now := time.Now()
+	if accessToken.ExpiresAt != nil && !now.Before(*accessToken.ExpiresAt) {
+		return nil, fmt.Errorf("%w: access token expired", ErrUnauthenticated)
+	}
+	if !ipAllowed(accessToken.AllowedIPs, clientIP) {
+		s.logger.Warn("Access token used from a disallowed address",
+			zap.String("tokenID", accessToken.ID.Hex()),
+			zap.String("ip", clientIP))
+		return nil, fmt.Errorf("%w: access token not allowed from this address", ErrUnauthenticated)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, accessToken.UserID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("%w: user no longer exists", ErrUnauthenticated)
+	}
+
+	if accessToken.LastUsedAt == nil || now.Sub(*accessToken.LastUsedAt) >= lastUsedResolution || accessToken.LastUsedIP != clientIP {
+		if err := s.repo.UpdateLastUsed(ctx, accessToken.ID, now, clientIP); err != nil {
+			s.logger.Warn("Failed to record access token use", zap.String("tokenID", accessToken.ID.Hex()), zap.Error(err))
+		}
+	}
+
+	return &Principal{
+		UserID:             user.ID,
+		Role:               user.Role,
+		AccessTokenID:      accessToken.ID,
+		Scopes:             accessToken.Scopes,
+		RateLimitPerMinute: accessToken.RateLimitPerMinute,
+	}, nil
+}
+
+// validateAccessTokenRequest checks a token request and returns its deduplicated scopes.
+func validateAccessTokenRequest(req *models.CreateAccessTokenRequest, role models.UserRole) ([]string, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxAccessTokenNameLength {
+		return nil, fmt.Errorf("%w: name is required and at most %d characters", ErrInvalidAccessTokenRequest, maxAccessTokenNameLength)
+	}
+	if len(req.Scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidAccessTokenRequest)
+	}
+
+	scopes := make([]string, 0, len(req.Scopes))
+	seen := make(map[string]bool)
+	for _, scope := range req.Scopes {
+		if !validScope(scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidAccessTokenRequest, scope)
+		}
+		if platformRole, ok := models.PlatformRoles[role]; scope == models.ScopeAdmin && (!ok || len(platformRole.Permissions) == 0) {
+			return nil, fmt.Errorf("%w: only users with platform permissions may create tokens with the admin scope", ErrInvalidAccessTokenRequest)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiresAt must be in the future", ErrInvalidAccessTokenRequest)
+	}
+	for _, entry := range req.AllowedIPs {
+		if net.ParseIP(entry) == nil {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return nil, fmt.Errorf("%w: %q is not an IP address or CIDR range", ErrInvalidAccessTokenRequest, entry)
+			}
+		}
+	}
+	if req.RateLimitPerMinute < 0 || req.RateLimitPerMinute > maxAccessTokenRateLimit {
+		return nil, fmt.Errorf("%w: rateLimitPerMinute must be between 0 and %d", ErrInvalidAccessTokenRequest, maxAccessTokenRateLimit)
+	}
+	return scopes, nil
+}
+
+func validScope(scope string) bool {
+	for _, valid := range models.AccessTokenScopes {
+		if scope == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// ipAllowed reports whether the client IP matches an entry of the allowlist; an empty allowlist allows all.
+func ipAllowed(allowlist []string, clientIP string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+	for _, entry := range allowlist {
+		if allowed := net.ParseIP(entry); allowed != nil {
+			if allowed.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
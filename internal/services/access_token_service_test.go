@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// memoryAccessTokens keeps access tokens in memory and counts the writes of their last use.
+type memoryAccessTokens struct {
+	tokens   []*models.AccessToken
+	lastUsed int
+}
+
+func (r *memoryAccessTokens) Create(ctx context.Context, token *models.AccessToken) error {
+	token.ID = primitive.NewObjectID()
+	token.CreatedAt = time.Now()
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *memoryAccessTokens) GetByHash(ctx context.Context, tokenHash string) (*models.AccessToken, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash && token.RevokedAt == nil {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *memoryAccessTokens) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]*models.AccessToken, error) {
+	tokens := []*models.AccessToken{}
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *memoryAccessTokens) CountByUser(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	tokens, _ := r.ListByUser(ctx, userID)
+	return int64(len(tokens)), nil
+}
+
+func (r *memoryAccessTokens) Revoke(ctx context.Context, userID, id primitive.ObjectID) error {
+	for _, token := range r.tokens {
+		if token.ID == id && token.UserID == userID && token.RevokedAt == nil {
+			now := time.Now()
+			token.RevokedAt = &now
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (r *memoryAccessTokens) UpdateLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time, ip string) error {
+	for _, token := range r.tokens {
+		if token.ID == id {
+			token.LastUsedAt, token.LastUsedIP = &at, ip
+		}
+	}
+	r.lastUsed++
+	return nil
+}
+
+// newTestAccessTokens returns an access token service with alice@example.com, a user without
+// platform permissions.
+func newTestAccessTokens() (AccessTokenService, *memoryAccessTokens, *models.User) {
+	alice := &models.User{ID: primitive.NewObjectID(), Email: "alice@example.com", Role: models.RoleUser}
+	tokens := &memoryAccessTokens{}
+	service := NewAccessTokenService(tokens, &memoryUsers{users: []*models.User{alice}}, NewAuditService(&memoryAuditEvents{}, 0, zap.NewNop()), zap.NewNop())
+	return service, tokens, alice
+}
+
+func TestAuthenticateAccessToken(t *testing.T) {
+	ctx := context.Background()
+	service, tokens, alice := newTestAccessTokens()
+	expiresAt := time.Now().Add(time.Hour)
+	created, err := service.CreateToken(ctx, alice.ID, alice.Role, &models.CreateAccessTokenRequest{
+		Name: " CI ", Scopes: []string{models.ScopeCollectionsRead, models.ScopeCollectionsRead}, RateLimitPerMinute: 30, ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	if !IsAccessToken(created.Token) || created.Name != "CI" || len(created.Scopes) != 1 || created.TokenHash == created.Token {
+		t.Fatalf("CreateToken() = %+v", created.AccessToken)
+	}
+
+	principal, err := service.Authenticate(ctx, created.Token, "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if principal.UserID != alice.ID || principal.AccessTokenID != created.ID || principal.RateLimitPerMinute != 30 ||
+		!principal.HasScope(models.ScopeCollectionsRead) || principal.HasScope(models.ScopeCollectionsWrite) {
+		t.Fatalf("Authenticate() = %+v", principal)
+	}
+	if _, err := service.Authenticate(ctx, AccessTokenPrefix+"unknown", "192.0.2.1"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("Authenticate() of an unknown token error = %v, want ErrUnauthenticated", err)
+	}
+
+	expired := time.Now().Add(-time.Second)
+	tokens.tokens[0].ExpiresAt = &expired
+	if _, err := service.Authenticate(ctx, created.Token, "192.0.2.1"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("Authenticate() of an expired token error = %v, want ErrUnauthenticated", err)
+	}
+	tokens.tokens[0].ExpiresAt = &expiresAt
+
+	if err := service.RevokeToken(ctx, primitive.NewObjectID(), created.ID); !errors.Is(err, ErrAccessTokenNotFound) {
+		t.Errorf("RevokeToken() by another user error = %v, want ErrAccessTokenNotFound", err)
+	}
+	if err := service.RevokeToken(ctx, alice.ID, created.ID); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	if _, err := service.Authenticate(ctx, created.Token, "192.0.2.1"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("Authenticate() of a revoked token error = %v, want ErrUnauthenticated", err)
+	}
+}
+
+func TestAccessTokenAllowedIPs(t *testing.T) {
+	ctx := context.Background()
+	service, _, alice := newTestAccessTokens()
+	if _, err := service.CreateToken(ctx, alice.ID, alice.Role, &models.CreateAccessTokenRequest{
+		Name: "office", Scopes: []string{models.ScopeCollectionsRead}, AllowedIPs: []string{"10.0.0.0/8", "not-an-ip"},
+	}); !errors.Is(err, ErrInvalidAccessTokenRequest) {
+		t.Fatalf("CreateToken() with an invalid allowlist entry error = %v, want ErrInvalidAccessTokenRequest", err)
+	}
+	created, err := service.CreateToken(ctx, alice.ID, alice.Role, &models.CreateAccessTokenRequest{
+		Name: "office", Scopes: []string{models.ScopeCollectionsRead}, AllowedIPs: []string{"192.0.2.7", "10.0.0.0/8", "2001:db8::/32"},
+	})
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+
+	for ip, allowed := range map[string]bool{
+		"192.0.2.7":   true,
+		"192.0.2.8":   false,
+		"10.20.30.40": true,
+		"11.0.0.1":    false,
+		"2001:db8::1": true,
+		"2001:db9::1": false,
+		"":            false,
+	} {
+		_, err := service.Authenticate(ctx, created.Token, ip)
+		if allowed && err != nil {
+			t.Errorf("Authenticate() from %q error = %v", ip, err)
+		}
+		if !allowed && !errors.Is(err, ErrUnauthenticated) {
+			t.Errorf("Authenticate() from %q error = %v, want ErrUnauthenticated", ip, err)
+		}
+	}
+}
+
+func TestCreateAdminScopedAccessToken(t *testing.T) {
+	ctx := context.Background()
+	service, _, alice := newTestAccessTokens()
+	for role, allowed := range map[models.UserRole]bool{
+		models.RoleUser:      false,
+		models.UserRole(""):  false,
+		models.RoleModerator: true,
+		models.RoleAdmin:     true,
+	} {
+		_, err := service.CreateToken(ctx, alice.ID, role, &models.CreateAccessTokenRequest{Name: "ops", Scopes: []string{models.ScopeAdmin}})
+		if allowed && err != nil {
+			t.Errorf("CreateToken() with the admin scope as %q error = %v", role, err)
+		}
+		if !allowed && !errors.Is(err, ErrInvalidAccessTokenRequest) {
+			t.Errorf("CreateToken() with the admin scope as %q error = %v, want ErrInvalidAccessTokenRequest", role, err)
+		}
+	}
+}
+
+func TestAccessTokenLastUsedThrottle(t *testing.T) {
+	ctx := context.Background()
+	service, tokens, alice := newTestAccessTokens()
+	created, err := service.CreateToken(ctx, alice.ID, alice.Role, &models.CreateAccessTokenRequest{Name: "CI", Scopes: []string{models.ScopeSDKGenerate}})
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+
+	use := func(ip string) {
+		t.Helper()
+		if _, err := service.Authenticate(ctx, created.Token, ip); err != nil {
+			t.Fatalf("Authenticate() error = %v", err)
+		}
+	}
+	use("192.0.2.1")
+	use("192.0.2.1")
+	if tokens.lastUsed != 1 {
+		t.Fatalf("last use written %d times within a minute, want 1", tokens.lastUsed)
+	}
+	use("192.0.2.2")
+	if tokens.lastUsed != 2 {
+		t.Fatalf("last use written %d times after an address change, want 2", tokens.lastUsed)
+	}
+	minuteAgo := time.Now().Add(-lastUsedResolution)
+	tokens.tokens[0].LastUsedAt = &minuteAgo
+	use("192.0.2.2")
+	if tokens.lastUsed != 3 {
+		t.Fatalf("last use written %d times after a minute, want 3", tokens.lastUsed)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -237,3 +238,125 @@ func (s *accountLockoutServiceImpl) GetLockoutInfo(ctx context.Context, identifi
 
 	return info, nil
 }
+
+// memoryAccountLockoutService keeps failed attempts in process memory. It is used when no Redis
+// is configured, so lockouts hold for a single server instance and are lost on restart.
+type memoryAccountLockoutService struct {
+	mu              sync.Mutex
+	accounts        map[string]*lockoutState
+	logger          *zap.Logger
+	maxAttempts     int
+	lockoutDuration time.Duration
+	attemptWindow   time.Duration
+}
+
+type lockoutState struct {
+	attempts     int
+	windowEndsAt time.Time
+	lockedUntil  time.Time
+}
+
+// NewMemoryAccountLockoutService creates an account lockout service that keeps its state in memory
+func NewMemoryAccountLockoutService(logger *zap.Logger, config AccountLockoutConfig) AccountLockoutService {
+	return &memoryAccountLockoutService{
+		accounts:        make(map[string]*lockoutState),
+		logger:          logger,
+		maxAttempts:     config.MaxAttempts,
+		lockoutDuration: config.LockoutDuration,
+		attemptWindow:   config.AttemptWindow,
+	}
+}
+
+// RecordFailedAttempt records a failed login attempt
+func (s *memoryAccountLockoutService) RecordFailedAttempt(ctx context.Context, identifier string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.prune(now)
+	state, ok := s.accounts[identifier]
+	if !ok || now.After(state.windowEndsAt) {
+		state = &lockoutState{lockedUntil: s.lockedUntil(identifier)}
+		s.accounts[identifier] = state
+	}
+	state.attempts++
+	state.windowEndsAt = now.Add(s.attemptWindow)
+
+	if state.attempts >= s.maxAttempts {
+		state.lockedUntil = now.Add(s.lockoutDuration)
+		s.logger.Warn("Account locked due to too many failed attempts",
+			zap.String("identifier", identifier),
+			zap.Int("attempts", state.attempts),
+			zap.Duration("lockout_duration", s.lockoutDuration),
+		)
+	}
+	return nil
+}
+
+// IsAccountLocked checks if an account is currently locked
+func (s *memoryAccountLockoutService) IsAccountLocked(ctx context.Context, identifier string) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	remaining := time.Until(s.lockedUntil(identifier))
+	if remaining <= 0 {
+		return false, 0, nil
+	}
+	return true, remaining, nil
+}
+
+// ResetFailedAttempts clears failed attempts for an account (called on successful login)
+func (s *memoryAccountLockoutService) ResetFailedAttempts(ctx context.Context, identifier string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.accounts, identifier)
+	return nil
+}
+
+// GetFailedAttempts returns the current number of failed attempts
+func (s *memoryAccountLockoutService) GetFailedAttempts(ctx context.Context, identifier string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.accounts[identifier]
+	if !ok || time.Now().After(state.windowEndsAt) {
+		return 0, nil
+	}
+	return state.attempts, nil
+}
+
+// GetLockoutInfo returns comprehensive lockout information for an account
+func (s *memoryAccountLockoutService) GetLockoutInfo(ctx context.Context, identifier string) (*LockoutInfo, error) {
+	isLocked, remainingTime, _ := s.IsAccountLocked(ctx, identifier)
+	failedAttempts, _ := s.GetFailedAttempts(ctx, identifier)
+
+	info := &LockoutInfo{
+		IsLocked:       isLocked,
+		FailedAttempts: failedAttempts,
+		MaxAttempts:    s.maxAttempts,
+		RemainingTime:  remainingTime,
+		AttemptsLeft:   s.maxAttempts - failedAttempts,
+	}
+	if info.AttemptsLeft < 0 {
+		info.AttemptsLeft = 0
+	}
+	return info, nil
+}
+
+// lockedUntil returns when the lockout of an account ends, or the zero time. s.mu must be held.
+func (s *memoryAccountLockoutService) lockedUntil(identifier string) time.Time {
+	if state, ok := s.accounts[identifier]; ok {
+		return state.lockedUntil
+	}
+	return time.Time{}
+}
+
+// prune drops accounts whose attempt window and lockout have both ended. s.mu must be held.
+func (s *memoryAccountLockoutService) prune(now time.Time) {
+	for identifier, state := range s.accounts {
+		if now.After(state.windowEndsAt) && now.After(state.lockedUntil) {
+			delete(s.accounts, identifier)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	// AuditExportJSONL exports one JSON event per line.
+	AuditExportJSONL = "jsonl"
+	// AuditExportCSV exports one event per row, with changes and metadata as JSON cells.
+	AuditExportCSV = "csv"
+
+	defaultAuditQueryLimit = 50
+	maxAuditQueryLimit     = 200
+	// auditRecordTimeout bounds writing an event, which happens after the audited action.
+	auditRecordTimeout = 5 * time.Second
+	// auditRedacted replaces secret values in recorded changes.
+	auditRedacted = "[redacted]"
+)
+
+var (
+	// ErrInvalidAuditQuery is returned for audit queries with invalid filters.
+	ErrInvalidAuditQuery = errors.New("invalid audit query")
+	// ErrUnsupportedAuditExportFormat is returned for export formats other than jsonl and csv.
+	ErrUnsupportedAuditExportFormat = errors.New("unsupported audit export format")
+)
+
+// auditCSVHeader are the columns of CSV exports.
+var auditCSVHeader = []string{
+	"id", "timestamp", "action", "outcome", "actorId", "accessTokenId", "organizationId",
+	"targetType", "targetId", "ipAddress", "userAgent", "requestId", "changes", "metadata",
+}
+
+// RequestMetadata describes the HTTP request an action is performed in. The request context
+// carries it so that audit events recorded by services name the actor and client.
+type RequestMetadata struct {
+	ActorID       primitive.ObjectID
+	AccessTokenID primitive.ObjectID
+	IPAddress     string
+	UserAgent     string
+	RequestID     string
+}
+
+type requestMetadataKey struct{}
+
+// WithRequestMetadata returns a context carrying the metadata of a request.
+func WithRequestMetadata(ctx context.Context, meta *RequestMetadata) context.Context {
+	return context.WithValue(ctx, requestMetadataKey{}, meta)
+}
+
+// RequestMetadataFrom returns the request metadata of a context, or nil outside requests.
+func RequestMetadataFrom(ctx context.Context) *RequestMetadata {
+	meta, _ := ctx.Value(requestMetadataKey{}).(*RequestMetadata)
+	return meta
+}
+
+// AuditService keeps the append-only audit log of security-relevant actions.
+type AuditService interface {
+	// Record appends an event, filling in the time and the request metadata of ctx. Recording is
+	// best effort: failures are logged and never fail the audited action.
+	Record(ctx context.Context, event *models.AuditEvent)
+	// Query returns a page of the matching events, newest first, and how many match in total.
+	Query(ctx context.Context, query *models.AuditQuery) ([]*models.AuditEvent, int64, error)
+	// Export writes every matching event to w, oldest first, as jsonl or csv.
+	Export(ctx context.Context, query *models.AuditQuery, format string, w io.Writer) error
+	// PruneExpired deletes the events older than the retention period.
+	PruneExpired(ctx context.Context) (int64, error)
+	// RunRetention prunes expired events every interval until ctx is done.
+	RunRetention(ctx context.Context, interval time.Duration)
+}
+
+// auditService is the concrete implementation of AuditService.
+type auditService struct {
+	repo      repositories.AuditRepository
+	retention time.Duration
+	logger    *zap.Logger
+}
+
+// NewAuditService creates a new AuditService. Events are kept for retention; zero keeps them forever.
+func NewAuditService(repo repositories.AuditRepository, retention time.Duration, logger *zap.Logger) AuditService {
+	return &auditService{repo: repo, retention: retention, logger: logger}
+}
+
+// Record appends an event.
+func (s *auditService) Record(ctx context.Context, event *models.AuditEvent) {
+	event.ID = primitive.NewObjectID()
+	event.Timestamp = time.Now().UTC()
+	if event.Outcome == "" {
+		event.Outcome = models.AuditOutcomeSuccess
+	}
+	if meta := RequestMetadataFrom(ctx); meta != nil {
+		if event.ActorID.IsZero() {
+			event.ActorID = meta.ActorID
+			event.AccessTokenID = meta.AccessTokenID
+		}
+		event.IPAddress = meta.IPAddress
+		event.UserAgent = meta.UserAgent
+		event.RequestID = meta.RequestID
+	}
+
+	// The event is written even when the client has gone away since the action completed.
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditRecordTimeout)
+	defer cancel()
+	if err := s.repo.Insert(writeCtx, event); err != nil {
+		s.logger.Error("Failed to record audit event",
+			zap.String("action", string(event.Action)),
+			zap.String("actorID", event.ActorID.Hex()),
+			zap.String("targetID", event.TargetID),
+			zap.Error(err))
+	}
+}
+
+// Query returns a page of the matching events.
+func (s *auditService) Query(ctx context.Context, query *models.AuditQuery) ([]*models.AuditEvent, int64, error) {
+	if err := normalizeAuditQuery(query); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.Find(ctx, query)
+}
+
+// Export writes every matching event to w.
+func (s *auditService) Export(ctx context.Context, query *models.AuditQuery, format string, w io.Writer) error {
+	if err := normalizeAuditQuery(query); err != nil {
+		return err
+	}
+
+	switch format {
+	case AuditExportJSONL:
+		encoder := json.NewEncoder(w)
+		return s.repo.Each(ctx, query, func(event *models.AuditEvent) error {
+			return encoder.Encode(event)
+		})
+	case AuditExportCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(auditCSVHeader); err != nil {
+			return err
+		}
+		err := s.repo.Each(ctx, query, func(event *models.AuditEvent) error {
+			return writer.Write(auditCSVRecord(event))
+		})
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedAuditExportFormat, format)
+	}
+}
+
+// PruneExpired deletes the events older than the retention period.
+func (s *auditService) PruneExpired(ctx context.Context) (int64, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	return s.repo.DeleteBefore(ctx, time.Now().Add(-s.retention))
+}
+
+// RunRetention prunes expired events now and then every interval until ctx is done.
+func (s *auditService) RunRetention(ctx context.Context, interval time.Duration) {
+	if s.retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		deleted, err := s.PruneExpired(ctx)
+		if err != nil {
+			s.logger.Error("Failed to prune expired audit events", zap.Error(err))
+		} else if deleted > 0 {
+			s.logger.Info("Pruned expired audit events", zap.Int64("deleted", deleted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// normalizeAuditQuery validates a query and applies the default page and limit.
+func normalizeAuditQuery(query *models.AuditQuery) error {
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidAuditQuery)
+	}
+	if query.Outcome != "" && query.Outcome != models.AuditOutcomeSuccess && query.Outcome != models.AuditOutcomeFailure {
+		return fmt.Errorf("%w: outcome must be success or failure", ErrInvalidAuditQuery)
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 {
+		query.Limit = defaultAuditQueryLimit
+	}
+	if query.Limit > maxAuditQueryLimit {
+		query.Limit = maxAuditQueryLimit
+	}
+	return nil
+}
+
+// auditCSVRecord returns the CSV row of an event.
+func auditCSVRecord(event *models.AuditEvent) []string {
+	changes, metadata := "", ""
+	if len(event.Changes) > 0 {
+		encoded, _ := json.Marshal(event.Changes)
+		changes = string(encoded)
+	}
+	if len(event.Metadata) > 0 {
+		encoded, _ := json.Marshal(event.Metadata)
+		metadata = string(encoded)
+	}
+	record := []string{
+		event.ID.Hex(),
+		event.Timestamp.UTC().Format(time.RFC3339Nano),
+		string(event.Action),
+		string(event.Outcome),
+		hexOrEmpty(event.ActorID),
+		hexOrEmpty(event.AccessTokenID),
+		event.OrganizationID,
+		event.TargetType,
+		event.TargetID,
+		event.IPAddress,
+		event.UserAgent,
+		event.RequestID,
+		changes,
+		metadata,
+	}
+	for i, value := range record {
+		record[i] = csvSafe(value)
+	}
+	return record
+}
+
+// csvSafe keeps spreadsheet applications from evaluating client-supplied values such as user
+// agents as formulas.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func hexOrEmpty(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	return id.Hex()
+}
+
+// AuditChanges returns the top-level fields that differ between the JSON encodings of before
+// and after, sorted by name. Values of fields named like secrets are redacted, at any depth.
+func AuditChanges(before, after interface{}) []models.AuditChange {
+	beforeFields, afterFields := auditFields(before), auditFields(after)
+
+	names := make(map[string]bool, len(beforeFields)+len(afterFields))
+	for name := range beforeFields {
+		names[name] = true
+	}
+	for name := range afterFields {
+		names[name] = true
+	}
+
+	var changes []models.AuditChange
+	for name := range names {
+		oldValue, newValue := beforeFields[name], afterFields[name]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		change := models.AuditChange{Field: name, Before: redactAuditValue(oldValue), After: redactAuditValue(newValue)}
+		if isSecretField(name) {
+			change.Before, change.After = redactedIfSet(oldValue), redactedIfSet(newValue)
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// auditFields returns the top-level fields of the JSON encoding of value.
+func auditFields(value interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if value == nil || (reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil()) {
+		return fields
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(encoded, &fields)
+	return fields
+}
+
+// redactAuditValue replaces the values of secret fields nested in value.
+func redactAuditValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for key, nested := range v {
+			if isSecretField(key) {
+				redacted[key] = redactedIfSet(nested)
+			} else {
+				redacted[key] = redactAuditValue(nested)
+			}
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, nested := range v {
+			redacted[i] = redactAuditValue(nested)
+		}
+		return redacted
+	default:
+		return value
+	}
+}
+
+// redactedIfSet returns the redaction marker for a set value and nil for an unset one, so a
+// change still shows whether a secret was added, replaced or removed.
+func redactedIfSet(value interface{}) interface{} {
+	if value == nil || value == "" {
+		return nil
+	}
+	return auditRedacted
+}
+
+// isSecretField reports whether a field name suggests the field holds a secret.
+func isSecretField(name string) bool {
+	name = strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+	// References to secret stores, such as apiKeyRef, name a secret without revealing it
+	if strings.HasSuffix(name, "ref") || strings.HasSuffix(name, "refs") {
+		return false
+	}
+	for _, marker := range []string{"secret", "password", "token", "apikey", "privatekey", "credential"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretAuditChange records that a secret changed without recording its value; it returns
+// nil when the secret did not change.
+func secretAuditChange(field, before, after string) *models.AuditChange {
+	if before == after {
+		return nil
+	}
+	return &models.AuditChange{Field: field, Before: redactedIfSet(before), After: redactedIfSet(after)}
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// memoryAuditEvents keeps audit events in memory, filtering only by action and time.
+type memoryAuditEvents struct {
+	mu     sync.Mutex
+	events []*models.AuditEvent
+}
+
+func (r *memoryAuditEvents) Insert(ctx context.Context, event *models.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *event
+	r.events = append(r.events, &copied)
+	return nil
+}
+
+func (r *memoryAuditEvents) Find(ctx context.Context, query *models.AuditQuery) ([]*models.AuditEvent, int64, error) {
+	var matching []*models.AuditEvent
+	_ = r.Each(ctx, query, func(event *models.AuditEvent) error {
+		matching = append([]*models.AuditEvent{event}, matching...)
+		return nil
+	})
+	total := int64(len(matching))
+	start := (query.Page - 1) * query.Limit
+	if start > len(matching) {
+		start = len(matching)
+	}
+	end := start + query.Limit
+	if end > len(matching) {
+		end = len(matching)
+	}
+	return matching[start:end], total, nil
+}
+
+func (r *memoryAuditEvents) Each(ctx context.Context, query *models.AuditQuery, fn func(*models.AuditEvent) error) error {
+	r.mu.Lock()
+	events := append([]*models.AuditEvent(nil), r.events...)
+	r.mu.Unlock()
+	for _, event := range events {
+		if query.Action != "" && string(event.Action) != query.Action {
+			continue
+		}
+		if (!query.From.IsZero() && event.Timestamp.Before(query.From)) || (!query.To.IsZero() && !event.Timestamp.Before(query.To)) {
+			continue
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryAuditEvents) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.events[:0]
+	for _, event := range r.events {
+		if !event.Timestamp.Before(cutoff) {
+			kept = append(kept, event)
+		}
+	}
+	deleted := int64(len(r.events) - len(kept))
+	r.events = kept
+	return deleted, nil
+}
+
+// recordedAuditEvents returns the events recorded by an audit service made by NewAuditService
+// with a memoryAuditEvents repository.
+func recordedAuditEvents(audit AuditService) []*models.AuditEvent {
+	repo := audit.(*auditService).repo.(*memoryAuditEvents)
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	return append([]*models.AuditEvent(nil), repo.events...)
+}
+
+func TestAuditRecordUsesRequestMetadata(t *testing.T) {
+	audit := NewAuditService(&memoryAuditEvents{}, 0, zap.NewNop())
+	actor, token := primitive.NewObjectID(), primitive.NewObjectID()
+	ctx, cancel := context.WithCancel(WithRequestMetadata(context.Background(), &RequestMetadata{
+		ActorID:       actor,
+		AccessTokenID: token,
+		IPAddress:     "192.0.2.7",
+		UserAgent:     "curl/8.0",
+		RequestID:     "req-1",
+	}))
+	// Events are written even when the request has been cancelled
+	cancel()
+
+	audit.Record(ctx, &models.AuditEvent{Action: models.AuditActionSettingsUpdate})
+	login := primitive.NewObjectID()
+	audit.Record(ctx, &models.AuditEvent{Action: models.AuditActionLogin, ActorID: login, Outcome: models.AuditOutcomeFailure})
+
+	events := recordedAuditEvents(audit)
+	if len(events) != 2 {
+		t.Fatalf("recorded %d events, want 2", len(events))
+	}
+	first := events[0]
+	if first.ActorID != actor || first.AccessTokenID != token || first.IPAddress != "192.0.2.7" || first.UserAgent != "curl/8.0" || first.RequestID != "req-1" {
+		t.Errorf("event = %+v, want the request metadata", first)
+	}
+	if first.Outcome != models.AuditOutcomeSuccess || first.Timestamp.IsZero() || first.ID.IsZero() {
+		t.Errorf("event = %+v, want an ID, a time and a success outcome", first)
+	}
+	if second := events[1]; second.ActorID != login || !second.AccessTokenID.IsZero() || second.RequestID != "req-1" {
+		t.Errorf("event = %+v, want its own actor and the request ID", second)
+	}
+}
+
+func TestAuditChangesRedactsSecrets(t *testing.T) {
+	before := map[string]interface{}{
+		"maxCollections": 10,
+		"smtp":           map[string]interface{}{"host": "mail.example.com", "password": "hunter2"},
+		"webhookSecret":  "old",
+		"unchanged":      "same",
+	}
+	after := map[string]interface{}{
+		"maxCollections": 20,
+		"smtp":           map[string]interface{}{"host": "smtp.example.com", "password": "hunter3"},
+		"webhookSecret":  "new",
+		"unchanged":      "same",
+		"apiKey":         "k-123",
+	}
+
+	changes := AuditChanges(before, after)
+	got := map[string]models.AuditChange{}
+	for _, change := range changes {
+		got[change.Field] = change
+	}
+	if len(changes) != 4 || changes[0].Field != "apiKey" {
+		t.Fatalf("AuditChanges() = %+v, want 4 changes sorted by field", changes)
+	}
+	if change := got["maxCollections"]; change.Before != float64(10) || change.After != float64(20) {
+		t.Errorf("maxCollections change = %+v", change)
+	}
+	if change := got["webhookSecret"]; change.Before != auditRedacted || change.After != auditRedacted {
+		t.Errorf("webhookSecret change = %+v, want redacted values", change)
+	}
+	if change := got["apiKey"]; change.Before != nil || change.After != auditRedacted {
+		t.Errorf("apiKey change = %+v, want an unset before and a redacted after", change)
+	}
+	smtp := got["smtp"].After.(map[string]interface{})
+	if smtp["host"] != "smtp.example.com" || smtp["password"] != auditRedacted {
+		t.Errorf("smtp change = %+v, want the host and a redacted password", smtp)
+	}
+	encoded, _ := json.Marshal(changes)
+	if strings.Contains(string(encoded), "hunter") || strings.Contains(string(encoded), "k-123") {
+		t.Errorf("changes leak a secret: %s", encoded)
+	}
+	var unset *models.OIDCSettings
+	if changes := AuditChanges(unset, &models.OIDCSettings{Enabled: true}); len(changes) == 0 || changes[0].Field != "clientId" {
+		t.Errorf("AuditChanges(nil, settings) = %+v, want every field as a change", changes)
+	}
+}
+
+func TestAuditExport(t *testing.T) {
+	audit := NewAuditService(&memoryAuditEvents{}, 0, zap.NewNop())
+	ctx := WithRequestMetadata(context.Background(), &RequestMetadata{UserAgent: "=HYPERLINK(\"http://evil\")", RequestID: "req-9"})
+	audit.Record(ctx, &models.AuditEvent{Action: models.AuditActionSDKDownload, TargetType: models.AuditTargetSDK, TargetID: "sdk-1", Metadata: map[string]string{"language": "go"}})
+	audit.Record(ctx, &models.AuditEvent{Action: models.AuditActionLogin, Outcome: models.AuditOutcomeFailure})
+
+	var jsonl bytes.Buffer
+	if err := audit.Export(ctx, &models.AuditQuery{}, AuditExportJSONL, &jsonl); err != nil {
+		t.Fatalf("Export(jsonl) error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(jsonl.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Export(jsonl) wrote %d lines, want 2", len(lines))
+	}
+	var first models.AuditEvent
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first.Action != models.AuditActionSDKDownload || first.Metadata["language"] != "go" {
+		t.Errorf("first line = %s (%v), want the SDK download", lines[0], err)
+	}
+
+	var csvOut bytes.Buffer
+	if err := audit.Export(ctx, &models.AuditQuery{Action: string(models.AuditActionLogin)}, AuditExportCSV, &csvOut); err != nil {
+		t.Fatalf("Export(csv) error = %v", err)
+	}
+	records, err := csv.NewReader(&csvOut).ReadAll()
+	if err != nil {
+		t.Fatalf("Export(csv) wrote invalid CSV: %v", err)
+	}
+	if len(records) != 2 || len(records[0]) != len(auditCSVHeader) {
+		t.Fatalf("Export(csv) = %v, want a header and one event", records)
+	}
+	row := map[string]string{}
+	for i, column := range records[0] {
+		row[column] = records[1][i]
+	}
+	if row["action"] != string(models.AuditActionLogin) || row["outcome"] != "failure" || row["requestId"] != "req-9" {
+		t.Errorf("CSV row = %v, want the failed login", row)
+	}
+	if !strings.HasPrefix(row["userAgent"], "'=") {
+		t.Errorf("CSV user agent = %q, want it escaped against formula injection", row["userAgent"])
+	}
+
+	if err := audit.Export(ctx, &models.AuditQuery{}, "xml", &bytes.Buffer{}); !errors.Is(err, ErrUnsupportedAuditExportFormat) {
+		t.Errorf("Export(xml) error = %v, want ErrUnsupportedAuditExportFormat", err)
+	}
+	now := time.Now()
+	if _, _, err := audit.Query(ctx, &models.AuditQuery{From: now, To: now.Add(-time.Hour)}); !errors.Is(err, ErrInvalidAuditQuery) {
+		t.Errorf("Query() with from after to error = %v, want ErrInvalidAuditQuery", err)
+	}
+}
+
+func TestAuditPruneExpired(t *testing.T) {
+	repo := &memoryAuditEvents{}
+	audit := NewAuditService(repo, 24*time.Hour, zap.NewNop())
+	audit.Record(context.Background(), &models.AuditEvent{Action: models.AuditActionLogin})
+	repo.events = append(repo.events, &models.AuditEvent{Action: models.AuditActionLogin, Timestamp: time.Now().Add(-48 * time.Hour)})
+
+	deleted, err := audit.PruneExpired(context.Background())
+	if err != nil || deleted != 1 || len(repo.events) != 1 {
+		t.Errorf("PruneExpired() = %d, %v with %d left, want 1 deleted and 1 left", deleted, err, len(repo.events))
+	}
+
+	forever := NewAuditService(repo, 0, zap.NewNop())
+	repo.events = append(repo.events, &models.AuditEvent{Timestamp: time.Now().Add(-10 * 365 * 24 * time.Hour)})
+	if deleted, err := forever.PruneExpired(context.Background()); err != nil || deleted != 0 {
+		t.Errorf("PruneExpired() without retention = %d, %v, want nothing deleted", deleted, err)
+	}
+}
+
+func TestLoginIsAudited(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestAuthService(t)
+
+	if _, _, err := service.Login(ctx, "alice@example.com", "wrong", ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Login() error = %v", err)
+	}
+	_, user, err := service.Login(ctx, "alice@example.com", "correct horse", ClientInfo{})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	events := recordedAuditEvents(service.audit)
+	if len(events) != 2 {
+		t.Fatalf("recorded %d events, want 2", len(events))
+	}
+	failed, succeeded := events[0], events[1]
+	if failed.Outcome != models.AuditOutcomeFailure || !failed.ActorID.IsZero() || failed.TargetID != user.ID.Hex() || failed.Metadata["reason"] != "invalid credentials" {
+		t.Errorf("failed login event = %+v", failed)
+	}
+	if succeeded.Outcome != models.AuditOutcomeSuccess || succeeded.ActorID != user.ID || succeeded.Action != models.AuditActionLogin {
+		t.Errorf("successful login event = %+v", succeeded)
+	}
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/openapi"
+	"github.com/AkashKesav/API2SDK/internal/signing"
+)
+
+// defaultAuthScheme keeps the historical behaviour of sending credentials as a bearer
+// token when neither the integration nor its spec says otherwise.
+var defaultAuthScheme = models.IntegrationAuth{Type: signing.TypeBearer}
+
+// SpecAuthSchemes maps the supported securitySchemes of a spec to signer configs.
+// Schemes that cannot be signed, such as HTTP digest, are left out.
+func SpecAuthSchemes(doc *openapi.Document) map[string]models.IntegrationAuth {
+	schemes := map[string]models.IntegrationAuth{}
+	if doc == nil {
+		return schemes
+	}
+	for name, scheme := range doc.SecuritySchemes {
+		if auth, ok := authFromSecurityScheme(name, scheme); ok {
+			schemes[name] = auth
+		}
+	}
+	return schemes
+}
+
+func authFromSecurityScheme(name string, scheme models.SecurityScheme) (models.IntegrationAuth, bool) {
+	auth := models.IntegrationAuth{SchemeName: name}
+	switch strings.ToLower(scheme.Type) {
+	case "apikey":
+		if scheme.Name == "" {
+			return auth, false
+		}
+		auth.Type = signing.TypeAPIKey
+		auth.Options = map[string]string{"in": strings.ToLower(scheme.In), "name": scheme.Name}
+	case "http":
+		switch strings.ToLower(scheme.Scheme) {
+		case "basic":
+			auth.Type = signing.TypeBasic
+		case "bearer":
+			auth.Type = signing.TypeBearer
+		default:
+			return auth, false
+		}
+	case "oauth2", "openidconnect":
+		auth.Type = signing.TypeOAuth2
+	default:
+		return auth, false
+	}
+	return auth, true
+}
+
+// ResolveAuthScheme decides how a request to the integration is signed. In order:
+// the integration's configured scheme, the operation's (or document's) security
+// requirements, the first supported scheme of the spec by name, and finally bearer.
+// doc and op may be nil.
+func ResolveAuthScheme(integration *models.Integration, doc *openapi.Document, op *openapi.Operation) (models.IntegrationAuth, error) {
+	specSchemes := SpecAuthSchemes(doc)
+
+	if configured := integration.Auth; configured != nil && (configured.SchemeName != "" || configured.Type != "") {
+		resolved := models.IntegrationAuth{SchemeName: configured.SchemeName, Options: map[string]string{}}
+		if configured.SchemeName != "" {
+			derived, ok := specSchemes[configured.SchemeName]
+			if !ok && configured.Type == "" {
+				return resolved, fmt.Errorf("security scheme %q is not declared or not supported by the spec", configured.SchemeName)
+			}
+			resolved.Type = derived.Type
+			for k, v := range derived.Options {
+				resolved.Options[k] = v
+			}
+		}
+		if configured.Type != "" {
+			resolved.Type = configured.Type
+		}
+		for k, v := range configured.Options {
+			resolved.Options[k] = v
+		}
+		return resolved, nil
+	}
+
+	var requirements []models.OpenAPISecurityRequirement
+	if op != nil {
+		requirements = op.Security
+	} else if doc != nil {
+		requirements = doc.Security
+	}
+	for _, requirement := range requirements {
+		names := make([]string, 0, len(requirement))
+		for name := range requirement {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if auth, ok := specSchemes[name]; ok {
+				return auth, nil
+			}
+		}
+	}
+
+	names := make([]string, 0, len(specSchemes))
+	for name := range specSchemes {
+		names = append(names, name)
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		return specSchemes[names[0]], nil
+	}
+	return defaultAuthScheme, nil
+}
+
+// NewRequestSigner returns the signer for a request to the integration, see ResolveAuthScheme.
+func NewRequestSigner(integration *models.Integration, doc *openapi.Document, op *openapi.Operation) (signing.Signer, error) {
+	auth, err := ResolveAuthScheme(integration, doc, op)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := signing.New(auth.Type, auth.Options)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth scheme for integration %s: %w", integration.Name, err)
+	}
+	return signer, nil
+}
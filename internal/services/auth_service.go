@@ -1,39 +1,157 @@
 package services
 
 import (
-	"context" // Added for repository calls
-	"errors"  // Added for error handling
-	"fmt"     // Added for error formatting
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
-	"github.com/AkashKesav/API2SDK/configs" // Added for config
+	"github.com/AkashKesav/API2SDK/configs"
+	"github.com/AkashKesav/API2SDK/internal/auth"
 	"github.com/AkashKesav/API2SDK/internal/models"
-	"github.com/AkashKesav/API2SDK/internal/repositories" // Added for ObjectID conversion
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt" // For password hashing
+	"golang.org/x/crypto/bcrypt"
 )
 
+// tokenIssuer is the issuer of the access tokens.
+const tokenIssuer = "api2sdk"
+
+var (
+	// ErrUserExists is returned when registering an email that is already taken.
+	ErrUserExists = errors.New("user with this email already exists")
+	// ErrInvalidCredentials is returned for an unknown email or a wrong password.
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrInvalidRefreshToken is returned for refresh tokens that are unknown, rotated, expired or revoked.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrUnauthenticated is returned for access tokens that do not authenticate a live session.
+	ErrUnauthenticated = errors.New("unauthenticated")
+)
+
+// AccountLockedError is returned by Login while an account is locked after too many failed attempts.
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("account is locked, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+// ClientInfo describes the client a session is created or refreshed from.
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// AuthTokens are the tokens of a session. The access token authenticates requests until it
+// expires; the refresh token is exchanged for new tokens once and then becomes invalid.
+type AuthTokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	// ExpiresIn is the lifetime of the access token in seconds.
+	ExpiresIn        int       `json:"expires_in"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// Principal is the authenticated user of a request. Requests authenticated with a personal
+// access token carry its ID and scopes; session principals are not limited by scopes.
+type Principal struct {
+	UserID    primitive.ObjectID
+	Role      models.UserRole
+	SessionID primitive.ObjectID
+
+	AccessTokenID      primitive.ObjectID
+	Scopes             []string
+	RateLimitPerMinute int
+}
+
+// IsAccessToken reports whether the request was authenticated with a personal access token.
+func (p *Principal) IsAccessToken() bool {
+	return !p.AccessTokenID.IsZero()
+}
+
+// HasScope reports whether the principal may use routes of the scope.
+func (p *Principal) HasScope(scope string) bool {
+	if !p.IsAccessToken() {
+		return true
+	}
+	for _, granted := range p.Scopes {
+		if granted == scope || granted == models.ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
 // AuthService defines the interface for authentication related services
 type AuthService interface {
-	Register(ctx context.Context, user models.User) (*models.User, error) // Return user
+	// Register creates a user. The first user of an installation becomes its admin.
+	Register(ctx context.Context, user models.User) (*models.User, error)
+	// Login verifies the credentials and starts a session.
+	Login(ctx context.Context, email, password string, client ClientInfo) (*AuthTokens, *models.User, error)
+	// StartSession starts a session for a user authenticated by other means, such as single sign-on.
+	StartSession(ctx context.Context, user *models.User, client ClientInfo) (*AuthTokens, error)
+	// Refresh exchanges a refresh token for new tokens of the same session.
+	Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*AuthTokens, error)
+	// Authenticate returns the principal of an access token whose session is still active.
+	Authenticate(ctx context.Context, accessToken string) (*Principal, error)
+	// Logout revokes a session.
+	Logout(ctx context.Context, sessionID primitive.ObjectID) error
+	// LogoutAll revokes every session of a user.
+	LogoutAll(ctx context.Context, userID primitive.ObjectID) error
+}
+
+// sessionStore is the part of the session repository the auth service uses.
+type sessionStore interface {
+	CreateSession(ctx context.Context, session *models.Session) error
+	GetSession(ctx context.Context, id primitive.ObjectID) (*models.Session, error)
+	RotateRefreshToken(ctx context.Context, id primitive.ObjectID, oldHash, newHash string) error
+	RevokeSession(ctx context.Context, id primitive.ObjectID) error
+	RevokeUserSessions(ctx context.Context, userID primitive.ObjectID) (int64, error)
 }
 
 type authService struct {
-	userRepository repositories.UserRepository
-	logger         *zap.Logger
-	config         *configs.Config // Added field for config
+	userRepository    repositories.UserRepository
+	sessionRepository sessionStore
+	lockout           AccountLockoutService
+	audit             AuditService
+	logger            *zap.Logger
+	secret            []byte
+	accessTokenTTL    time.Duration
+	refreshTokenTTL   time.Duration
+	// dummyHash is compared against for unknown emails, so that they take as long as wrong passwords.
+	dummyHash []byte
 }
 
 // NewAuthService creates a new instance of AuthService
-func NewAuthService(userRepo repositories.UserRepository, logger *zap.Logger, config *configs.Config) AuthService {
+func NewAuthService(userRepo repositories.UserRepository, sessionRepo *repositories.SessionRepository, lockout AccountLockoutService, audit AuditService, logger *zap.Logger, config *configs.Config) AuthService {
+	dummyHash, err := bcrypt.GenerateFromPassword([]byte("api2sdk-dummy-password"), bcrypt.DefaultCost)
+	if err != nil {
+		logger.Error("Failed to create dummy password hash", zap.Error(err))
+	}
 	return &authService{
-		userRepository: userRepo,
-		logger:         logger,
-		config:         config, // Store config
+		userRepository:    userRepo,
+		sessionRepository: sessionRepo,
+		lockout:           lockout,
+		audit:             audit,
+		logger:            logger,
+		secret:            []byte(config.JWTSecret),
+		accessTokenTTL:    time.Duration(config.AccessTokenTTL) * time.Minute,
+		refreshTokenTTL:   time.Duration(config.RefreshTokenTTL) * time.Hour,
+		dummyHash:         dummyHash,
 	}
 }
 
 // Register handles user registration, creates the user.
 func (s *authService) Register(ctx context.Context, user models.User) (*models.User, error) {
+	user.Email = normalizeEmail(user.Email)
 	s.logger.Info("Attempting registration", zap.String("email", user.Email))
 
 	existingUser, err := s.userRepository.FindByEmail(ctx, user.Email)
@@ -43,7 +161,7 @@ func (s *authService) Register(ctx context.Context, user models.User) (*models.U
 	}
 	if existingUser != nil {
 		s.logger.Warn("User already exists during registration attempt", zap.String("email", user.Email))
-		return nil, errors.New("user with this email already exists")
+		return nil, ErrUserExists
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
@@ -53,9 +171,14 @@ func (s *authService) Register(ctx context.Context, user models.User) (*models.U
 	}
 	user.Password = string(hashedPassword)
 
-	// Ensure default role if not provided
-	if user.Role == "" {
-		user.Role = models.RoleUser
+	// Roles are granted by admins; only the first user bootstraps the installation as admin
+	user.Role = models.RoleUser
+	count, err := s.userRepository.CountAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not count users: %w", err)
+	}
+	if count == 0 {
+		user.Role = models.RoleAdmin
 	}
 
 	createdUserID, err := s.userRepository.Create(ctx, &user)
@@ -65,7 +188,270 @@ func (s *authService) Register(ctx context.Context, user models.User) (*models.U
 	}
 	user.ID = createdUserID // Set the returned ID
 
-	s.logger.Info("User registered successfully", zap.String("email", user.Email))
+	s.logger.Info("User registered successfully", zap.String("email", user.Email), zap.String("role", string(user.Role)))
 	user.Password = "" // Clear password before returning
 	return &user, nil
 }
+
+// Login verifies the credentials and starts a session. Failed attempts count towards locking
+// the account; while it is locked, even the right password is refused.
+func (s *authService) Login(ctx context.Context, email, password string, client ClientInfo) (*AuthTokens, *models.User, error) {
+	email = normalizeEmail(email)
+	lockoutID := "login:" + email
+
+	locked, retryAfter, err := s.lockout.IsAccountLocked(ctx, lockoutID)
+	if err != nil {
+		// Like the rate limiters, a lockout store outage does not block logins
+		s.logger.Warn("Failed to check account lockout", zap.String("email", email), zap.Error(err))
+	}
+	if locked {
+		s.auditLogin(ctx, nil, email, models.AuditOutcomeFailure, "account locked")
+		return nil, nil, &AccountLockedError{RetryAfter: retryAfter}
+	}
+
+	user, err := s.userRepository.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	hash := s.dummyHash
+	if user != nil {
+		hash = []byte(user.Password)
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil || user == nil {
+		if err := s.lockout.RecordFailedAttempt(ctx, lockoutID); err != nil {
+			s.logger.Warn("Failed to record failed login attempt", zap.String("email", email), zap.Error(err))
+		}
+		s.logger.Info("Login failed", zap.String("email", email), zap.String("ip", client.IPAddress))
+		s.auditLogin(ctx, user, email, models.AuditOutcomeFailure, "invalid credentials")
+		return nil, nil, ErrInvalidCredentials
+	}
+	if err := s.lockout.ResetFailedAttempts(ctx, lockoutID); err != nil {
+		s.logger.Warn("Failed to reset failed login attempts", zap.String("email", email), zap.Error(err))
+	}
+
+	tokens, err := s.StartSession(ctx, user, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.auditLogin(ctx, user, email, models.AuditOutcomeSuccess, "")
+	user.Password = ""
+	return tokens, user, nil
+}
+
+// auditLogin records a password login. user is nil when no user has the email; only a
+// successful login names the user as the actor.
+func (s *authService) auditLogin(ctx context.Context, user *models.User, email string, outcome models.AuditOutcome, reason string) {
+	event := &models.AuditEvent{
+		Action:   models.AuditActionLogin,
+		Outcome:  outcome,
+		Metadata: map[string]string{"email": email},
+	}
+	if user != nil {
+		if outcome == models.AuditOutcomeSuccess {
+			event.ActorID = user.ID
+		}
+		event.TargetType = models.AuditTargetUser
+		event.TargetID = user.ID.Hex()
+	}
+	if reason != "" {
+		event.Metadata["reason"] = reason
+	}
+	s.audit.Record(ctx, event)
+}
+
+// StartSession starts a session for a user who has already been authenticated.
+func (s *authService) StartSession(ctx context.Context, user *models.User, client ClientInfo) (*AuthTokens, error) {
+	sessionID := primitive.NewObjectID()
+	refreshToken, refreshHash, err := newRefreshToken(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	session := &models.Session{
+		ID:               sessionID,
+		UserID:           user.ID,
+		RefreshTokenHash: refreshHash,
+		UserAgent:        client.UserAgent,
+		IPAddress:        client.IPAddress,
+		ExpiresAt:        time.Now().Add(s.refreshTokenTTL),
+	}
+	if err := s.sessionRepository.CreateSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	tokens, err := s.issueTokens(user, session, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("User logged in", zap.String("userID", user.ID.Hex()), zap.String("sessionID", session.ID.Hex()))
+	return tokens, nil
+}
+
+// Refresh exchanges a refresh token for new tokens. Presenting a token that was already
+// exchanged means it leaked or the client misbehaves, so the whole session is revoked.
+func (s *authService) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*AuthTokens, error) {
+	sessionID, ok := parseRefreshToken(refreshToken)
+	if !ok {
+		return nil, ErrInvalidRefreshToken
+	}
+	session, err := s.sessionRepository.GetSession(ctx, sessionID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	if !session.Active(time.Now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	presentedHash := hashToken(refreshToken)
+	if !equalHashes(presentedHash, session.RefreshTokenHash) {
+		if equalHashes(presentedHash, session.PreviousRefreshTokenHash) {
+			s.logger.Warn("Rotated refresh token reused, revoking session",
+				zap.String("sessionID", session.ID.Hex()),
+				zap.String("userID", session.UserID.Hex()),
+				zap.String("ip", client.IPAddress))
+			if err := s.sessionRepository.RevokeSession(ctx, session.ID); err != nil {
+				return nil, fmt.Errorf("failed to revoke session: %w", err)
+			}
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepository.FindByID(ctx, session.UserID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	if user == nil {
+		if err := s.sessionRepository.RevokeSession(ctx, session.ID); err != nil {
+			return nil, fmt.Errorf("failed to revoke session: %w", err)
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newToken, newHash, err := newRefreshToken(session.ID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.sessionRepository.RotateRefreshToken(ctx, session.ID, session.RefreshTokenHash, newHash)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// A concurrent refresh won
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	return s.issueTokens(user, session, newToken)
+}
+
+// Authenticate returns the principal of an access token. Besides the signature and expiry of
+// the token, the session it was issued for must still be active, so logging out takes effect
+// immediately rather than when the access token expires.
+func (s *authService) Authenticate(ctx context.Context, accessToken string) (*Principal, error) {
+	claims, err := auth.ParseToken(accessToken, s.secret, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+	userID, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid subject", ErrUnauthenticated)
+	}
+	sessionID, err := primitive.ObjectIDFromHex(claims.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid session", ErrUnauthenticated)
+	}
+
+	session, err := s.sessionRepository.GetSession(ctx, sessionID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: unknown session", ErrUnauthenticated)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	if session.UserID != userID || !session.Active(time.Now()) {
+		return nil, fmt.Errorf("%w: session ended", ErrUnauthenticated)
+	}
+
+	return &Principal{UserID: userID, Role: models.UserRole(claims.Role), SessionID: sessionID}, nil
+}
+
+// Logout revokes a session.
+func (s *authService) Logout(ctx context.Context, sessionID primitive.ObjectID) error {
+	if err := s.sessionRepository.RevokeSession(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	s.logger.Info("Session revoked", zap.String("sessionID", sessionID.Hex()))
+	return nil
+}
+
+// LogoutAll revokes every session of a user.
+func (s *authService) LogoutAll(ctx context.Context, userID primitive.ObjectID) error {
+	revoked, err := s.sessionRepository.RevokeUserSessions(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	s.logger.Info("All sessions revoked", zap.String("userID", userID.Hex()), zap.Int64("sessions", revoked))
+	return nil
+}
+
+// issueTokens signs an access token for the user's session and pairs it with the refresh token.
+func (s *authService) issueTokens(user *models.User, session *models.Session, refreshToken string) (*AuthTokens, error) {
+	jti, err := randomToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token ID: %w", err)
+	}
+	now := time.Now()
+	accessToken, err := auth.SignToken(auth.Claims{
+		Issuer:    tokenIssuer,
+		Subject:   user.ID.Hex(),
+		Role:      string(user.Role),
+		SessionID: session.ID.Hex(),
+		ID:        jti,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.accessTokenTTL).Unix(),
+	}, s.secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return &AuthTokens{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(s.accessTokenTTL.Seconds()),
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// newRefreshToken returns a refresh token of the session and its hash. The token names the
+// session so it can be looked up; only its hash is stored.
+func newRefreshToken(sessionID primitive.ObjectID) (string, string, error) {
+	secret, err := randomToken()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token := sessionID.Hex() + "." + secret
+	return token, hashToken(token), nil
+}
+
+// parseRefreshToken returns the session a refresh token names.
+func parseRefreshToken(token string) (primitive.ObjectID, bool) {
+	sessionHex, secret, ok := strings.Cut(token, ".")
+	if !ok || secret == "" {
+		return primitive.NilObjectID, false
+	}
+	sessionID, err := primitive.ObjectIDFromHex(sessionHex)
+	return sessionID, err == nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func equalHashes(a, b string) bool {
+	return b != "" && subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
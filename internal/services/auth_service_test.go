@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// memoryUsers keeps users in memory and hands out copies; unused methods panic.
+type memoryUsers struct {
+	repositories.UserRepository
+	users []*models.User
+}
+
+func (r *memoryUsers) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryUsers) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	for _, user := range r.users {
+		if user.ID == id {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// memorySessions keeps sessions in memory with the repository's rotation semantics.
+type memorySessions struct {
+	sessions map[primitive.ObjectID]*models.Session
+}
+
+func (r *memorySessions) CreateSession(ctx context.Context, session *models.Session) error {
+	session.CreatedAt = time.Now()
+	copied := *session
+	r.sessions[session.ID] = &copied
+	return nil
+}
+
+func (r *memorySessions) GetSession(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (r *memorySessions) RotateRefreshToken(ctx context.Context, id primitive.ObjectID, oldHash, newHash string) error {
+	session, ok := r.sessions[id]
+	if !ok || session.RefreshTokenHash != oldHash || session.RevokedAt != nil {
+		return mongo.ErrNoDocuments
+	}
+	session.PreviousRefreshTokenHash, session.RefreshTokenHash = oldHash, newHash
+	return nil
+}
+
+func (r *memorySessions) RevokeSession(ctx context.Context, id primitive.ObjectID) error {
+	if session, ok := r.sessions[id]; ok && session.RevokedAt == nil {
+		now := time.Now()
+		session.RevokedAt = &now
+	}
+	return nil
+}
+
+func (r *memorySessions) RevokeUserSessions(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	var revoked int64
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			now := time.Now()
+			session.RevokedAt = &now
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+// newTestAuthService returns an auth service with alice@example.com, password "correct horse",
+// and a lockout after three failed logins.
+func newTestAuthService(t *testing.T) (*authService, *memorySessions) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := &memorySessions{sessions: map[primitive.ObjectID]*models.Session{}}
+	return &authService{
+		userRepository:    &memoryUsers{users: []*models.User{{ID: primitive.NewObjectID(), Email: "alice@example.com", Password: string(hash), Role: models.RoleUser}}},
+		sessionRepository: sessions,
+		lockout:           NewMemoryAccountLockoutService(zap.NewNop(), AccountLockoutConfig{MaxAttempts: 3, LockoutDuration: time.Minute, AttemptWindow: time.Minute}),
+		audit:             NewAuditService(&memoryAuditEvents{}, 0, zap.NewNop()),
+		logger:            zap.NewNop(),
+		secret:            []byte("0123456789abcdef0123456789abcdef"),
+		accessTokenTTL:    15 * time.Minute,
+		refreshTokenTTL:   time.Hour,
+		dummyHash:         hash,
+	}, sessions
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestAuthService(t)
+
+	first, user, err := service.Login(ctx, " Alice@Example.com ", "correct horse", ClientInfo{IPAddress: "192.0.2.1"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if user.Password != "" {
+		t.Error("Login() returned the password hash")
+	}
+	principal, err := service.Authenticate(ctx, first.AccessToken)
+	if err != nil || principal.UserID != user.ID || principal.Role != models.RoleUser {
+		t.Fatalf("Authenticate() = %+v, %v", principal, err)
+	}
+
+	second, err := service.Refresh(ctx, first.RefreshToken, ClientInfo{})
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("Refresh() did not rotate the refresh token")
+	}
+	if _, err := service.Authenticate(ctx, second.AccessToken); err != nil {
+		t.Fatalf("Authenticate() with the refreshed token error = %v", err)
+	}
+
+	// Replaying the rotated token revokes the session, so the current tokens stop working too.
+	if _, err := service.Refresh(ctx, first.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("reused Refresh() error = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := service.Refresh(ctx, second.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Refresh() after reuse error = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := service.Authenticate(ctx, second.AccessToken); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("Authenticate() after reuse error = %v, want ErrUnauthenticated", err)
+	}
+}
+
+func TestRefreshRejectsInvalidTokens(t *testing.T) {
+	ctx := context.Background()
+	service, sessions := newTestAuthService(t)
+	tokens, _, err := service.Login(ctx, "alice@example.com", "correct horse", ClientInfo{})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	sessionID, _ := parseRefreshToken(tokens.RefreshToken)
+
+	for name, token := range map[string]string{
+		"malformed":       "not-a-token",
+		"unknown session": primitive.NewObjectID().Hex() + ".secret",
+		"wrong secret":    sessionID.Hex() + ".guessed",
+	} {
+		if _, err := service.Refresh(ctx, token, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("%s: Refresh() error = %v, want ErrInvalidRefreshToken", name, err)
+		}
+	}
+
+	sessions.sessions[sessionID].ExpiresAt = time.Now().Add(-time.Second)
+	if _, err := service.Refresh(ctx, tokens.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("expired Refresh() error = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestLogoutEndsSessions(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestAuthService(t)
+	first, user, err := service.Login(ctx, "alice@example.com", "correct horse", ClientInfo{})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	second, _, err := service.Login(ctx, "alice@example.com", "correct horse", ClientInfo{})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	principal, err := service.Authenticate(ctx, first.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Logout(ctx, principal.SessionID); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if _, err := service.Authenticate(ctx, first.AccessToken); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("Authenticate() after Logout() error = %v, want ErrUnauthenticated", err)
+	}
+	if _, err := service.Authenticate(ctx, second.AccessToken); err != nil {
+		t.Errorf("Logout() ended another session: %v", err)
+	}
+
+	if err := service.LogoutAll(ctx, user.ID); err != nil {
+		t.Fatalf("LogoutAll() error = %v", err)
+	}
+	if _, err := service.Refresh(ctx, second.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Refresh() after LogoutAll() error = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestLoginLocksOutAfterFailures(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestAuthService(t)
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := service.Login(ctx, "alice@example.com", "wrong", ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: Login() error = %v, want ErrInvalidCredentials", i+1, err)
+		}
+	}
+	var locked *AccountLockedError
+	if _, _, err := service.Login(ctx, "alice@example.com", "correct horse", ClientInfo{}); !errors.As(err, &locked) || locked.RetryAfter <= 0 {
+		t.Errorf("Login() while locked error = %v, want AccountLockedError", err)
+	}
+	if _, _, err := service.Login(ctx, "nobody@example.com", "correct horse", ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Login() of an unknown user error = %v, want ErrInvalidCredentials", err)
+	}
+}
@@ -18,25 +18,34 @@ type CollectionService struct {
 	repo       *repositories.CollectionRepository
 	logger     *zap.Logger
 	sdkService *SDKService // Added SDKService dependency
+	authorizer ResourceAuthorizer
 }
 
 // NewCollectionService creates a new collection service
-func NewCollectionService(repo *repositories.CollectionRepository, logger *zap.Logger, sdkService *SDKService) *CollectionService {
+func NewCollectionService(repo *repositories.CollectionRepository, logger *zap.Logger, sdkService *SDKService, authorizer ResourceAuthorizer) *CollectionService {
 	return &CollectionService{
 		repo:       repo,
 		logger:     logger,
 		sdkService: sdkService, // Store sdkService
+		authorizer: authorizer,
 	}
 }
 
-// CreateCollection creates a new collection
+// CreateCollection creates a new collection, in the requested organization if any
 func (s *CollectionService) CreateCollection(req *models.CreateCollectionRequest, userID string) (*models.Collection, error) {
+	if req.OrganizationID != "" {
+		if err := s.authorizer.Authorize(context.Background(), userID, userID, req.OrganizationID, models.ResourceCollections, models.ActionWrite); err != nil {
+			return nil, fmt.Errorf("cannot create collections in organization %s: %w", req.OrganizationID, err)
+		}
+	}
+
 	collection := &models.Collection{
-		Name:        req.Name,
-		Description: req.Description,
-		UserID:      userID,
-		PostmanData: req.PostmanData,
-		Endpoints:   []models.Endpoint{},
+		Name:           req.Name,
+		Description:    req.Description,
+		UserID:         userID,
+		OrganizationID: req.OrganizationID,
+		PostmanData:    req.PostmanData,
+		Endpoints:      []models.Endpoint{},
 	}
 
 	return s.repo.Create(context.Background(), collection)
@@ -52,22 +61,49 @@ func (s *CollectionService) GetCollection(id string) (*models.Collection, error)
 	return s.repo.GetByID(context.Background(), id)
 }
 
-// GetCollectionByIDAndUser retrieves a collection by its ID, ensuring it belongs to the specified user.
-func (s *CollectionService) GetCollectionByIDAndUser(ctx context.Context, collectionID string, userID string) (*models.Collection, error) {
-	collection, err := s.repo.GetByID(ctx, collectionID) // Assuming GetByID just takes ID
+// GetCollectionForUser retrieves a collection by its ID, ensuring the user may perform the action on it:
+// personal collections are only accessible to their owner, organization collections to members whose role permits the action.
+func (s *CollectionService) GetCollectionForUser(ctx context.Context, collectionID string, userID string, action models.Action) (*models.Collection, error) {
+	collection, err := s.repo.GetByID(ctx, collectionID)
 	if err != nil {
 		s.logger.Error("Failed to get collection by ID", zap.String("collectionID", collectionID), zap.Error(err))
 		return nil, fmt.Errorf("collection not found: %w", err)
 	}
 
-	if collection.UserID != userID {
-		s.logger.Warn("User attempted to access unauthorized collection", zap.String("collectionID", collectionID), zap.String("requestingUserID", userID), zap.String("actualUserID", collection.UserID))
-		return nil, fmt.Errorf("unauthorized to access this collection")
+	if err := s.authorizer.Authorize(ctx, userID, collection.UserID, collection.OrganizationID, models.ResourceCollections, action); err != nil {
+		s.logger.Warn("User attempted to access unauthorized collection", zap.String("collectionID", collectionID), zap.String("requestingUserID", userID), zap.String("action", string(action)), zap.Error(err))
+		return nil, fmt.Errorf("unauthorized to access this collection: %w", err)
 	}
 
 	return collection, nil
 }
 
+// TransferCollection moves a collection and its SDKs into an organization, or back to the user who created it
+// when organizationID is empty. The user must be allowed to delete the collection where it is and to write where it goes.
+func (s *CollectionService) TransferCollection(ctx context.Context, collectionID string, userID string, organizationID string) (*models.Collection, error) {
+	collection, err := s.GetCollectionForUser(ctx, collectionID, userID, models.ActionDelete)
+	if err != nil {
+		return nil, err
+	}
+	if organizationID == collection.OrganizationID {
+		return collection, nil
+	}
+	if organizationID != "" {
+		if err := s.authorizer.Authorize(ctx, userID, userID, organizationID, models.ResourceCollections, models.ActionWrite); err != nil {
+			return nil, fmt.Errorf("cannot move collections into organization %s: %w", organizationID, err)
+		}
+	}
+
+	if err := s.repo.SetOrganization(ctx, collectionID, organizationID); err != nil {
+		return nil, fmt.Errorf("failed to move collection: %w", err)
+	}
+	if err := s.sdkService.SetCollectionOrganization(ctx, collectionID, organizationID); err != nil {
+		return nil, err
+	}
+	s.logger.Info("Collection transferred", zap.String("collectionID", collectionID), zap.String("organizationID", organizationID), zap.String("userID", userID))
+	return s.repo.GetByID(ctx, collectionID)
+}
+
 // UpdateCollection updates a collection
 func (s *CollectionService) UpdateCollection(id string, req *models.UpdateCollectionRequest) (*models.Collection, error) {
 	return s.repo.Update(context.Background(), id, req)
@@ -83,6 +119,15 @@ func (s *CollectionService) GetCollectionsByUserID(userID string) ([]*models.Col
 	return s.repo.GetByUserID(context.Background(), userID)
 }
 
+// GetAccessibleCollections retrieves the personal collections of a user and those of the user's organizations
+func (s *CollectionService) GetAccessibleCollections(ctx context.Context, userID string) ([]*models.Collection, error) {
+	organizationIDs, err := s.authorizer.OrganizationIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetAccessible(ctx, userID, organizationIDs)
+}
+
 // GenerateOpenAPISpec generates an OpenAPI specification from a Postman collection
 // using the SDKService's ConvertPostmanToOpenAPI method.
 // It saves the spec to a temporary file and returns the path and the spec string.
@@ -128,6 +173,26 @@ func (s *CollectionService) GenerateOpenAPISpec(collectionID string) (string, st
 	return finalOpenAPISpecFilePath, openAPISpecString, nil
 }
 
+// OpenAPISpec returns the OpenAPI document of a collection: the imported spec, or else the
+// conversion of its Postman collection. Unlike GenerateOpenAPISpec nothing is written to disk.
+func (s *CollectionService) OpenAPISpec(ctx context.Context, collection *models.Collection) (string, error) {
+	if collection.OpenAPISpec != "" {
+		return collection.OpenAPISpec, nil
+	}
+	postmanJSON := collection.RawPostmanJSON
+	if postmanJSON == "" {
+		postmanJSON, _ = collection.PostmanData.(string)
+	}
+	if postmanJSON == "" {
+		return "", fmt.Errorf("collection %s has neither an OpenAPI spec nor Postman data", collection.ID.Hex())
+	}
+	spec, err := s.sdkService.ConvertPostmanToOpenAPI(ctx, postmanJSON)
+	if err != nil {
+		return "", fmt.Errorf("conversion from Postman to OpenAPI failed: %w", err)
+	}
+	return spec, nil
+}
+
 // GenerateSDKFromCollection generates an SDK for a given language from a Postman collection.
 // It first converts the Postman collection to OpenAPI, then generates the SDK.
 // Returns the path to the generated SDK, the SDK record ID, and an error if any.
@@ -152,13 +217,14 @@ func (s *CollectionService) GenerateSDKFromCollection(ctx context.Context, userI
 		return "", "", fmt.Errorf("failed to create SDK instance output directory %s: %w", sdkInstanceOutputDir, err) // Return empty sdkID
 	}
 
-	var targetPackageName string
+	var targetPackageName, organizationID string
 	collection, err := s.repo.GetByID(context.Background(), collectionID)
 	if err != nil {
 		s.logger.Warn("Failed to get collection by ID for package name derivation, using default.", zap.String("collectionID", collectionID), zap.Error(err))
 		targetPackageName = utils.DerivePackageName(nil, language) // Pass nil for collection to use default
 	} else {
 		targetPackageName = utils.DerivePackageName(collection, language)
+		organizationID = collection.OrganizationID // SDKs belong to the organization of their collection
 	}
 
 	// Prepare SDKGenerationRequest
@@ -172,6 +238,7 @@ func (s *CollectionService) GenerateSDKFromCollection(ctx context.Context, userI
 	sdkRecord := &models.SDK{
 		UserID:         userID,
 		CollectionID:   collectionID,
+		OrganizationID: organizationID,
 		GenerationType: models.GenerationTypeSDK,
 		Language:       language,
 		PackageName:    targetPackageName,
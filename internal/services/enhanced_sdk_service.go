@@ -4,10 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"time"
 
+	"github.com/AkashKesav/API2SDK/internal/mcpgen/codegen"
 	"github.com/AkashKesav/API2SDK/internal/models"
 	"github.com/AkashKesav/API2SDK/internal/repositories"
 	"github.com/AkashKesav/API2SDK/internal/utils"
@@ -309,11 +309,6 @@ func (s *EnhancedSDKService) executeSDKGeneration(ctx context.Context, req *mode
 
 // executeMCPGeneration performs the actual MCP generation
 func (s *EnhancedSDKService) executeMCPGeneration(ctx context.Context, req *models.MCPGenerationRequest, recordID primitive.ObjectID, tc *utils.TraceContext) error {
-	// Check if mcpgen is available
-	if _, err := exec.LookPath("mcpgen"); err != nil {
-		return utils.NewExternalServiceError("mcpgen", "mcpgen command not found in PATH", err)
-	}
-
 	// Get circuit breaker for Postman API
 	postmanCB := s.circuitBreakers.Get("postman_api")
 
@@ -403,33 +398,20 @@ func (s *EnhancedSDKService) generateMCPServer(ctx context.Context, req *models.
 		return utils.NewInternalError("Failed to create output directory", err)
 	}
 
-	// Create temporary spec file
-	tempSpecFile, err := os.CreateTemp("", "openapi_spec_*.json")
+	opts, err := mcpCodegenOptions(req)
 	if err != nil {
-		return utils.NewInternalError("Failed to create temporary file", err)
+		return utils.NewValidationError("Invalid MCP generation options", err.Error())
 	}
-	defer os.Remove(tempSpecFile.Name())
-
-	if _, err := tempSpecFile.Write([]byte(openAPIStr)); err != nil {
-		return utils.NewInternalError("Failed to write OpenAPI spec", err)
-	}
-	tempSpecFile.Close()
-
-	// Execute mcpgen command
-	cmd := exec.CommandContext(ctx, "mcpgen", "generate",
-		"--input", tempSpecFile.Name(),
-		"--output", outputDir,
-		"--transport", req.Transport,
-		"--port", fmt.Sprintf("%d", req.Port),
-		"--force")
 
-	output, err := cmd.CombinedOutput()
+	result, err := codegen.Generate([]byte(openAPIStr), outputDir, opts)
 	if err != nil {
-		childSpan.LogError("mcpgen command failed", err, zap.String("output", string(output)))
-		return utils.NewExternalServiceError("mcpgen", "MCP generation failed", err)
+		childSpan.LogError("MCP code generation failed", err)
+		return utils.NewInternalError("MCP generation failed", err)
 	}
 
-	childSpan.LogInfo("MCP server generated successfully", zap.String("output", string(output)))
+	childSpan.LogInfo("MCP server generated successfully",
+		zap.String("language", string(result.Language)),
+		zap.Int("toolCount", result.ToolCount))
 	return nil
 }
 
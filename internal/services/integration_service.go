@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/AkashKesav/API2SDK/internal/models"
 	"github.com/AkashKesav/API2SDK/internal/repositories"
@@ -14,23 +16,37 @@ type IntegrationService interface {
 	GetIntegration(ctx context.Context, id primitive.ObjectID) (*models.Integration, error)
 	ListIntegrations(ctx context.Context) ([]*models.Integration, error)
 	UpdateIntegration(ctx context.Context, id primitive.ObjectID, integration *models.Integration) (*models.Integration, error)
+	// OnChange registers a callback that runs after an integration is created or updated.
+	OnChange(fn func(id primitive.ObjectID))
 }
 
 // integrationService is the concrete implementation of IntegrationService.
 type integrationService struct {
-	repo repositories.IntegrationRepository
+	repo      repositories.IntegrationRepository
+	audit     AuditService
+	mu        sync.RWMutex
+	listeners []func(id primitive.ObjectID)
 }
 
-// NewIntegrationService creates a new IntegrationService.
-func NewIntegrationService(repo repositories.IntegrationRepository) IntegrationService {
-	return &integrationService{repo: repo}
+// NewIntegrationService creates a new IntegrationService. Changes to the credentials of an
+// integration are recorded in the audit log.
+func NewIntegrationService(repo repositories.IntegrationRepository, audit AuditService) IntegrationService {
+	return &integrationService{repo: repo, audit: audit}
 }
 
 // CreateIntegration creates a new integration.
 func (s *integrationService) CreateIntegration(ctx context.Context, integration *models.Integration) (*models.Integration, error) {
 	// The APIKey is already an EncryptedString, so no need to encrypt it here.
 	// The BSON marshaller will handle it automatically.
-	return s.repo.Create(ctx, integration)
+	now := time.Now()
+	integration.CreatedAt = now
+	integration.UpdatedAt = now
+	created, err := s.repo.Create(ctx, integration)
+	if err != nil {
+		return nil, err
+	}
+	s.notify(created.ID)
+	return created, nil
 }
 
 // GetIntegration retrieves an integration by its ID.
@@ -47,5 +63,63 @@ func (s *integrationService) ListIntegrations(ctx context.Context) ([]*models.In
 func (s *integrationService) UpdateIntegration(ctx context.Context, id primitive.ObjectID, integration *models.Integration) (*models.Integration, error) {
 	// The APIKey is already an EncryptedString, so no need to re-encrypt it here.
 	// The BSON marshaller will handle it automatically.
-	return s.repo.Update(ctx, id, integration)
+	previous, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	integration.UpdatedAt = time.Now()
+	updated, err := s.repo.Update(ctx, id, integration)
+	if err != nil {
+		return nil, err
+	}
+	if changes := integrationCredentialChanges(previous, integration); len(changes) > 0 {
+		s.audit.Record(ctx, &models.AuditEvent{
+			Action:         models.AuditActionIntegrationCredentialSet,
+			OrganizationID: integration.OrganizationID,
+			TargetType:     models.AuditTargetIntegration,
+			TargetID:       id.Hex(),
+			Changes:        changes,
+		})
+	}
+	s.notify(id)
+	return updated, nil
+}
+
+// integrationCredentialChanges returns the changes to the API key, auth scheme and OAuth2
+// client of an integration. Secrets are recorded only as set or unset; references to secret
+// stores are recorded as they are.
+func integrationCredentialChanges(before, after *models.Integration) []models.AuditChange {
+	changes := AuditChanges(
+		map[string]interface{}{"auth": before.Auth, "oauth2": before.OAuth2, "apiKeyRef": before.APIKeyRef},
+		map[string]interface{}{"auth": after.Auth, "oauth2": after.OAuth2, "apiKeyRef": after.APIKeyRef},
+	)
+	if change := secretAuditChange("apiKey", string(before.APIKey), string(after.APIKey)); change != nil {
+		changes = append(changes, *change)
+	}
+	var beforeSecret, afterSecret string
+	if before.OAuth2 != nil {
+		beforeSecret = string(before.OAuth2.ClientSecret)
+	}
+	if after.OAuth2 != nil {
+		afterSecret = string(after.OAuth2.ClientSecret)
+	}
+	if change := secretAuditChange("oauth2.clientSecret", beforeSecret, afterSecret); change != nil {
+		changes = append(changes, *change)
+	}
+	return changes
+}
+
+// OnChange registers a callback that runs after an integration is created or updated.
+func (s *integrationService) OnChange(fn func(id primitive.ObjectID)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+func (s *integrationService) notify(id primitive.ObjectID) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, fn := range s.listeners {
+		fn(id)
+	}
 }
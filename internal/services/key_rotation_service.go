@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/AkashKesav/API2SDK/internal/crypto"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+// EncryptedCollection is a collection and the paths of the EncryptedString fields of its
+// documents. A "*" in a path stands for every key of a map or element of an array.
+type EncryptedCollection struct {
+	Name   string
+	Fields []string
+}
+
+// EncryptedCollections are the collections whose documents hold EncryptedString fields. Only
+// the listed fields are rotated, so that ordinary strings are never mistaken for secrets.
+var EncryptedCollections = []EncryptedCollection{
+	{Name: "integrations", Fields: []string{"apiKey", "oauth2.clientSecret"}},
+	{Name: "linked_accounts", Fields: []string{
+		"credentials.apiKey", "credentials.password", "credentials.token",
+		"credentials.accessToken", "credentials.refreshToken", "credentials.secret",
+	}},
+	{Name: "mcp_servers", Fields: []string{
+		"config.env.*", "config.upstreams.*.headers.*", "config.upstreams.*.env.*", "config.users.*.token",
+	}},
+	{Name: "platform_settings", Fields: []string{"oidc.clientSecret"}},
+	{Name: "oauth_states", Fields: []string{"codeVerifier"}},
+	{Name: "oidc_states", Fields: []string{"codeVerifier"}},
+}
+
+// KeyRotationReport counts what a key rotation found in a collection.
+type KeyRotationReport struct {
+	Collection string `json:"collection"`
+	Documents  int    `json:"documents"`
+	// Rotated values were re-encrypted under the active key, or would be in a dry run.
+	Rotated int `json:"rotated"`
+	// Encrypted values were stored in plaintext and are now encrypted under the active key,
+	// or would be in a dry run.
+	Encrypted int `json:"encrypted"`
+	// Current values were already encrypted under the active key.
+	Current int `json:"current"`
+	// Failed values look encrypted but could not be decrypted, e.g. because their key is not
+	// configured, or could not be updated.
+	Failed int `json:"failed"`
+	// Conflicts are documents that changed while they were rotated; rotating again picks them up.
+	Conflicts int `json:"conflicts"`
+}
+
+// KeyRotationService re-encrypts stored secrets under the active master key.
+type KeyRotationService interface {
+	// Rotate re-encrypts every value of the encrypted fields that is not under the active master
+	// key: envelope-encrypted values get their data key rewrapped, values encrypted before key
+	// IDs were introduced are encrypted anew, and values stored in plaintext before encryption
+	// was introduced are encrypted. A dry run only counts them.
+	Rotate(ctx context.Context, dryRun bool) ([]KeyRotationReport, error)
+}
+
+// keyRotationService is the concrete implementation of KeyRotationService.
+type keyRotationService struct {
+	repo    repositories.RawDocumentRepository
+	keyring *crypto.Keyring
+	logger  *zap.Logger
+}
+
+// NewKeyRotationService creates a new KeyRotationService. keyring must hold every master key
+// stored values may be encrypted with.
+func NewKeyRotationService(repo repositories.RawDocumentRepository, keyring *crypto.Keyring, logger *zap.Logger) KeyRotationService {
+	return &keyRotationService{repo: repo, keyring: keyring, logger: logger}
+}
+
+// Rotate re-encrypts the values of every encrypted collection.
+func (s *keyRotationService) Rotate(ctx context.Context, dryRun bool) ([]KeyRotationReport, error) {
+	reports := make([]KeyRotationReport, 0, len(EncryptedCollections))
+	for _, collection := range EncryptedCollections {
+		report := KeyRotationReport{Collection: collection.Name}
+		err := s.repo.Each(ctx, collection.Name, func(doc bson.D) error {
+			return s.rotateDocument(ctx, collection, doc, dryRun, &report)
+		})
+		reports = append(reports, report)
+		if err != nil {
+			return reports, fmt.Errorf("failed to rotate %s: %w", collection.Name, err)
+		}
+		s.logger.Info("Rotated encryption keys",
+			zap.String("collection", collection.Name),
+			zap.String("activeKeyID", s.keyring.ActiveKeyID()),
+			zap.Int("rotated", report.Rotated),
+			zap.Int("encrypted", report.Encrypted),
+			zap.Int("failed", report.Failed),
+			zap.Bool("dryRun", dryRun))
+	}
+	return reports, nil
+}
+
+// documentRotation collects the new values of one document's encrypted fields by path.
+type documentRotation struct {
+	old, updated       map[string]interface{}
+	rotated, encrypted int
+}
+
+// rotateDocument re-encrypts the values of one document, unless it changed in the meantime.
+func (s *keyRotationService) rotateDocument(ctx context.Context, collection EncryptedCollection, doc bson.D, dryRun bool, report *KeyRotationReport) error {
+	report.Documents++
+	var id interface{}
+	for _, field := range doc {
+		if field.Key == "_id" {
+			id = field.Value
+		}
+	}
+	rotation := &documentRotation{old: map[string]interface{}{}, updated: map[string]interface{}{}}
+	for _, field := range collection.Fields {
+		s.rotateField("", strings.Split(field, "."), doc, rotation, report)
+	}
+	if len(rotation.updated) == 0 {
+		return nil
+	}
+	if !dryRun && id != nil {
+		ok, err := s.repo.SetIfUnchanged(ctx, collection.Name, id, rotation.old, rotation.updated)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			report.Conflicts++
+			return nil
+		}
+	}
+	report.Rotated += rotation.rotated
+	report.Encrypted += rotation.encrypted
+	return nil
+}
+
+// rotateField follows the remaining keys of a field path from value and rotates the strings at its end.
+func (s *keyRotationService) rotateField(path string, keys []string, value interface{}, rotation *documentRotation, report *KeyRotationReport) {
+	if len(keys) == 0 {
+		if v, ok := value.(string); ok {
+			s.rotateValue(path, v, rotation, report)
+		}
+		return
+	}
+	key, rest := keys[0], keys[1:]
+	follow := func(name string, nested interface{}) {
+		nestedPath := strings.TrimPrefix(path+"."+name, ".")
+		if !updatablePathKey(name) {
+			report.Failed++
+			s.logger.Warn("Cannot update a stored value whose path has a dot or dollar sign in a key",
+				zap.String("collection", report.Collection),
+				zap.String("path", nestedPath))
+			return
+		}
+		s.rotateField(nestedPath, rest, nested, rotation, report)
+	}
+	switch v := value.(type) {
+	case bson.D:
+		for _, field := range v {
+			if key == "*" || field.Key == key {
+				follow(field.Key, field.Value)
+			}
+		}
+	case bson.M:
+		for name, nested := range v {
+			if key == "*" || name == key {
+				follow(name, nested)
+			}
+		}
+	case bson.A:
+		for i, nested := range v {
+			if index := strconv.Itoa(i); key == "*" || index == key {
+				follow(index, nested)
+			}
+		}
+	}
+}
+
+// rotateValue collects the new value of an encrypted field: rewrapped or re-encrypted when it is
+// encrypted under another key, and encrypted when it was stored in plaintext.
+func (s *keyRotationService) rotateValue(path, value string, rotation *documentRotation, report *KeyRotationReport) {
+	if value == "" {
+		return
+	}
+	rotated, changed, err := s.keyring.Rotate(value)
+	if errors.Is(err, crypto.ErrNotCiphertext) {
+		if rotated, err = s.keyring.Encrypt(value); err == nil {
+			rotation.encrypted++
+			rotation.old[path] = value
+			rotation.updated[path] = rotated
+			return
+		}
+	}
+	switch {
+	case err != nil:
+		report.Failed++
+		s.logger.Warn("Failed to rotate a stored value",
+			zap.String("collection", report.Collection),
+			zap.String("path", path),
+			zap.Error(err))
+	case changed:
+		rotation.rotated++
+		rotation.old[path] = value
+		rotation.updated[path] = rotated
+	default:
+		report.Current++
+	}
+}
+
+// updatablePathKey reports whether a key can be part of the dotted path of an update; map keys
+// such as header names may contain dots.
+func updatablePathKey(key string) bool {
+	return key != "" && !strings.Contains(key, ".") && !strings.HasPrefix(key, "$")
+}
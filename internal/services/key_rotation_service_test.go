@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/crypto"
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// memoryRawDocuments keeps documents in memory by collection. Before each update it calls
+// beforeSet, if set, to simulate concurrent writes.
+type memoryRawDocuments struct {
+	collections map[string][]bson.D
+	beforeSet   func(collection string, doc bson.D)
+}
+
+func (r *memoryRawDocuments) Each(ctx context.Context, collection string, fn func(doc bson.D) error) error {
+	for _, doc := range r.collections[collection] {
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryRawDocuments) SetIfUnchanged(ctx context.Context, collection string, id interface{}, old, updated map[string]interface{}) (bool, error) {
+	for _, doc := range r.collections[collection] {
+		if doc[0].Value != id {
+			continue
+		}
+		if r.beforeSet != nil {
+			r.beforeSet(collection, doc)
+		}
+		for path, value := range old {
+			if *documentPath(doc, path) != value {
+				return false, nil
+			}
+		}
+		for path, value := range updated {
+			*documentPath(doc, path) = value
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// documentPath returns the value at a dotted path of a document.
+func documentPath(doc bson.D, path string) *interface{} {
+	var value interface{} = doc
+	var target *interface{}
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case bson.D:
+			for i := range v {
+				if v[i].Key == key {
+					target = &v[i].Value
+				}
+			}
+		case bson.A:
+			i, _ := strconv.Atoi(key)
+			target = &v[i]
+		}
+		value = *target
+	}
+	return target
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKeyring, _ := crypto.NewKeyring("k1", map[string]string{"k1": "0123456789abcdef0123456789abcdef"}, false)
+	keyring, err := crypto.NewKeyring("k2", map[string]string{
+		"k2": "570c77d71926aff4c71cf019179516c56912ff8fcdc9a373dd56b6ea5ef92168",
+		"k1": "0123456789abcdef0123456789abcdef",
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown, _ := crypto.NewKeyring("k0", map[string]string{"k0": "fedcba9876543210fedcba9876543210"}, false)
+	encrypt := func(keyring *crypto.Keyring, plaintext string) string {
+		ciphertext, err := keyring.Encrypt(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ciphertext
+	}
+
+	current := encrypt(keyring, "current")
+	lost := encrypt(unknown, "lost")
+	repo := &memoryRawDocuments{collections: map[string][]bson.D{
+		"integrations": {
+			{
+				{Key: "_id", Value: "i1"},
+				{Key: "name", Value: "github"},
+				{Key: "apiKey", Value: encrypt(oldKeyring, "api-key")},
+				{Key: "oauth2", Value: bson.D{
+					{Key: "clientId", Value: "client-id"},
+					{Key: "clientSecret", Value: encrypt(oldKeyring, "client-secret")},
+				}},
+			},
+			{{Key: "_id", Value: "i2"}, {Key: "apiKey", Value: current}},
+		},
+		"mcp_servers": {
+			{
+				{Key: "_id", Value: "m1"},
+				{Key: "config", Value: bson.D{
+					{Key: "command", Value: bson.A{"npx", "server"}},
+					{Key: "env", Value: bson.D{{Key: "TOKEN", Value: encrypt(oldKeyring, "token")}, {Key: "LOST", Value: lost}}},
+					{Key: "upstreams", Value: bson.A{bson.D{
+						{Key: "name", Value: "crm"},
+						{Key: "headers", Value: bson.D{{Key: "Authorization", Value: "Bearer plain"}, {Key: "X.Api", Value: "unreachable"}}},
+					}}},
+				}},
+			},
+		},
+	}}
+	service := NewKeyRotationService(repo, keyring, zap.NewNop())
+
+	dryRun, err := service.Rotate(context.Background(), true)
+	if err != nil {
+		t.Fatalf("Rotate(dry run) error = %v", err)
+	}
+	if len(dryRun) != len(EncryptedCollections) || dryRun[0].Rotated != 2 || dryRun[0].Current != 1 {
+		t.Fatalf("Rotate(dry run) = %+v, want 2 values to rotate in integrations", dryRun)
+	}
+	if value := repo.collections["integrations"][0][2].Value.(string); !strings.HasPrefix(value, "enc:v1:k1:") {
+		t.Fatalf("Rotate(dry run) changed a value to %q", value)
+	}
+
+	reports, err := service.Rotate(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	byCollection := map[string]KeyRotationReport{}
+	for _, report := range reports {
+		byCollection[report.Collection] = report
+	}
+	if report := byCollection["integrations"]; report.Documents != 2 || report.Rotated != 2 || report.Current != 1 || report.Failed != 0 {
+		t.Errorf("integrations report = %+v, want 2 rotated and 1 current", report)
+	}
+	if report := byCollection["mcp_servers"]; report.Rotated != 1 || report.Encrypted != 1 || report.Failed != 2 {
+		t.Errorf("mcp_servers report = %+v, want 1 rotated, 1 encrypted and 2 failed", report)
+	}
+
+	integration := repo.collections["integrations"][0]
+	server := repo.collections["mcp_servers"][0]
+	for _, tc := range []struct {
+		doc        bson.D
+		path, want string
+	}{
+		{integration, "apiKey", "api-key"},
+		{integration, "oauth2.clientSecret", "client-secret"},
+		{server, "config.env.TOKEN", "token"},
+		{server, "config.upstreams.0.headers.Authorization", "Bearer plain"},
+	} {
+		value := (*documentPath(tc.doc, tc.path)).(string)
+		if plaintext, err := keyring.Decrypt(value); !strings.HasPrefix(value, "enc:v1:k2:") || err != nil || plaintext != tc.want {
+			t.Errorf("%s = %q (%q, %v), want %q under k2", tc.path, value, plaintext, err, tc.want)
+		}
+	}
+	headers := (*documentPath(server, "config.upstreams.0.headers")).(bson.D)
+	if *documentPath(server, "config.env.LOST") != lost || headers[1].Value != "unreachable" || *documentPath(server, "config.command.0") != "npx" {
+		t.Error("Rotate() changed a value of mcp_servers it could not rotate or that is not encrypted")
+	}
+	if integration[1].Value != "github" || *documentPath(integration, "oauth2.clientId") != "client-id" || repo.collections["integrations"][1][1].Value != current {
+		t.Error("Rotate() changed a value of integrations it could not or need not rotate")
+	}
+}
+
+func TestKeyRotationEncryptsPlaintext(t *testing.T) {
+	key := map[string]string{"k1": "0123456789abcdef0123456789abcdef"}
+	keyring, _ := crypto.NewKeyring("k1", key, false)
+	strict, _ := crypto.NewKeyring("k1", key, true)
+	account := bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "ownerId", Value: "customer-1"},
+		{Key: "credentials", Value: bson.D{{Key: "apiKey", Value: "legacy-key"}, {Key: "username", Value: "alice"}}},
+	}
+	repo := &memoryRawDocuments{collections: map[string][]bson.D{"linked_accounts": {account}}}
+	crypto.SetDefaultKeyring(strict)
+	t.Cleanup(func() { crypto.SetDefaultKeyring(nil) })
+
+	read := func() (*models.LinkedAccount, error) {
+		data, err := bson.Marshal(account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded models.LinkedAccount
+		return &decoded, bson.Unmarshal(data, &decoded)
+	}
+	if _, err := read(); err == nil {
+		t.Fatal("strict read of a plaintext secret succeeded")
+	}
+
+	reports, err := NewKeyRotationService(repo, keyring, zap.NewNop()).Rotate(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if report := reports[1]; report.Collection != "linked_accounts" || report.Encrypted != 1 || report.Rotated != 0 || report.Failed != 0 {
+		t.Errorf("linked_accounts report = %+v, want 1 encrypted", report)
+	}
+	decoded, err := read()
+	if err != nil {
+		t.Fatalf("strict read after Rotate() error = %v", err)
+	}
+	if decoded.Credentials.APIKey != "legacy-key" || decoded.Credentials.Username != "alice" || decoded.OwnerID != "customer-1" {
+		t.Errorf("strict read after Rotate() = %+v", decoded.Credentials)
+	}
+}
+
+func TestKeyRotationSkipsConcurrentlyChangedDocuments(t *testing.T) {
+	oldKeyring, _ := crypto.NewKeyring("k1", map[string]string{"k1": "0123456789abcdef0123456789abcdef"}, false)
+	keyring, _ := crypto.NewKeyring("k2", map[string]string{
+		"k2": "570c77d71926aff4c71cf019179516c56912ff8fcdc9a373dd56b6ea5ef92168",
+		"k1": "0123456789abcdef0123456789abcdef",
+	}, false)
+	secret, _ := oldKeyring.Encrypt("old secret")
+	replacement, _ := keyring.Encrypt("new secret")
+	repo := &memoryRawDocuments{collections: map[string][]bson.D{
+		"linked_accounts": {{{Key: "_id", Value: "a1"}, {Key: "credentials", Value: bson.D{{Key: "accessToken", Value: secret}}}}},
+	}}
+	repo.beforeSet = func(collection string, doc bson.D) { *documentPath(doc, "credentials.accessToken") = replacement }
+
+	reports, err := NewKeyRotationService(repo, keyring, zap.NewNop()).Rotate(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if report := reports[1]; report.Collection != "linked_accounts" || report.Conflicts != 1 || report.Rotated != 0 {
+		t.Errorf("linked_accounts report = %+v, want a conflict", report)
+	}
+	if *documentPath(repo.collections["linked_accounts"][0], "credentials.accessToken") != replacement {
+		t.Error("Rotate() overwrote a concurrent update")
+	}
+}
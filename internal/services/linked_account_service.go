@@ -0,0 +1,511 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"github.com/AkashKesav/API2SDK/internal/signing"
+	"github.com/AkashKesav/API2SDK/internal/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrLinkedAccountNotFound is returned when an owner has no account for an integration
+	// and the integration has no shared API key to fall back to.
+	ErrLinkedAccountNotFound = errors.New("no linked account found for this owner")
+	// ErrLinkedAccountDisabled is returned when the owner's account has been disabled.
+	ErrLinkedAccountDisabled = errors.New("linked account is disabled")
+	// ErrLinkedAccountExists is returned when an owner links a second account to the same integration.
+	ErrLinkedAccountExists = errors.New("a linked account already exists for this owner and integration")
+	// ErrOwnerNotManaged is returned when a user acts for a linked account owner it does not manage.
+	ErrOwnerNotManaged = errors.New("linked account owner is not managed by this user")
+	// ErrInvalidOwnerID is returned for owner IDs that are empty, too long or contain other
+	// characters than letters, digits and ._:@-
+	ErrInvalidOwnerID = errors.New("invalid linked account owner ID")
+)
+
+// ownerIDPattern is the format of linked account owner IDs.
+var ownerIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:@-]{1,128}$`)
+
+// Credentials are the resolved secrets used for one tool execution.
+type Credentials struct {
+	LinkedAccountID primitive.ObjectID // Zero when the integration-level API key is used
+	OwnerID         string
+	AuthType        models.LinkedAccountAuthType
+	signing.Credentials
+}
+
+// Sign adds the credentials to an outgoing request using signer.
+func (c *Credentials) Sign(req *http.Request, signer signing.Signer) error {
+	if c == nil {
+		return nil
+	}
+	return signer.Sign(req, c.Credentials)
+}
+
+// OwnerAuthorizer decides whether a platform user may act for a linked account owner.
+type OwnerAuthorizer interface {
+	// AuthorizeOwner returns nil when userID may use the accounts of ownerID: its own and those
+	// of owners whose accounts it manages. Otherwise it returns ErrOwnerNotManaged.
+	AuthorizeOwner(ctx context.Context, userID, ownerID string) error
+}
+
+// LinkedAccountService manages per-owner credentials for integrations.
+type LinkedAccountService interface {
+	OwnerAuthorizer
+	// AuthorizeNewOwner returns nil when userID may link accounts for ownerID: the owners it may
+	// act for, and new owners that are not the ID of another platform user, which it then
+	// manages. Otherwise it returns ErrInvalidOwnerID or ErrOwnerNotManaged.
+	AuthorizeNewOwner(ctx context.Context, userID, ownerID string) error
+
+	CreateLinkedAccount(ctx context.Context, userID string, req *models.CreateLinkedAccountRequest) (*models.LinkedAccount, error)
+	GetLinkedAccount(ctx context.Context, id primitive.ObjectID) (*models.LinkedAccount, error)
+	ListLinkedAccounts(ctx context.Context, userID string) ([]*models.LinkedAccount, error)
+	SetEnabled(ctx context.Context, id primitive.ObjectID, enabled bool) error
+	DeleteLinkedAccount(ctx context.Context, id primitive.ObjectID) error
+
+	// ResolveCredentials returns the credentials to execute a tool with for ownerID.
+	// An enabled linked account wins; otherwise the integration-level API key is used.
+	// A nil result means the request is sent without credentials.
+	ResolveCredentials(ctx context.Context, integration *models.Integration, ownerID string) (*Credentials, error)
+	// RecordExecution stores an audit record of a tool execution.
+	RecordExecution(ctx context.Context, execution *models.ToolExecution)
+	ListExecutions(ctx context.Context, linkedAccountID primitive.ObjectID, limit int64) ([]*models.ToolExecution, error)
+}
+
+// linkedAccountService is the concrete implementation of LinkedAccountService.
+type linkedAccountService struct {
+	repo           repositories.LinkedAccountRepository
+	executionsRepo repositories.ToolExecutionRepository
+	tokenRefresher OAuth2TokenRefresher
+	secrets        SecretService
+	audit          AuditService
+	logger         *zap.Logger
+}
+
+// NewLinkedAccountService creates a new LinkedAccountService. tokenRefresher keeps
+// the access tokens of oauth2 accounts fresh when credentials are resolved, and secrets
+// resolves the credentials kept in secret stores. Accounts being linked, changed and
+// deleted are recorded in the audit log.
+func NewLinkedAccountService(repo repositories.LinkedAccountRepository, executionsRepo repositories.ToolExecutionRepository, tokenRefresher OAuth2TokenRefresher, secrets SecretService, audit AuditService, logger *zap.Logger) LinkedAccountService {
+	return &linkedAccountService{
+		repo:           repo,
+		executionsRepo: executionsRepo,
+		tokenRefresher: tokenRefresher,
+		secrets:        secrets,
+		audit:          audit,
+		logger:         logger,
+	}
+}
+
+// CreateLinkedAccount links an owner's credentials to an integration.
+func (s *linkedAccountService) CreateLinkedAccount(ctx context.Context, userID string, req *models.CreateLinkedAccountRequest) (*models.LinkedAccount, error) {
+	integrationID, err := primitive.ObjectIDFromHex(req.IntegrationID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid integration ID: %w", err)
+	}
+
+	ownerID := req.OwnerID
+	if ownerID == "" {
+		ownerID = userID
+	}
+	if err := s.AuthorizeNewOwner(ctx, userID, ownerID); err != nil {
+		return nil, err
+	}
+
+	credentials, err := credentialsFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range credentials.SecretRefs {
+		if s.secrets == nil {
+			return nil, fmt.Errorf("%w: no secret store is configured", ErrInvalidSecretReference)
+		}
+		if err := s.secrets.ValidateReference(ref, LinkedAccountSecretNamespace(ownerID)); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.repo.GetByOwner(ctx, integrationID, ownerID); err == nil {
+		return nil, ErrLinkedAccountExists
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to check existing linked accounts: %w", err)
+	}
+
+	now := time.Now()
+	account := &models.LinkedAccount{
+		IntegrationID: integrationID,
+		OwnerID:       ownerID,
+		UserID:        userID,
+		AuthType:      req.AuthType,
+		Enabled:       true,
+		Credentials:   credentials,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	created, err := s.repo.Create(ctx, account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create linked account: %w", err)
+	}
+
+	s.logger.Info("Linked account created",
+		zap.String("linkedAccountID", created.ID.Hex()),
+		zap.String("integrationID", integrationID.Hex()),
+		zap.String("ownerID", ownerID),
+		zap.String("authType", string(req.AuthType)))
+	s.recordAudit(ctx, models.AuditActionLinkedAccountCreate, created,
+		linkedAccountCredentialChanges(&models.LinkedAccount{}, created))
+	return created, nil
+}
+
+// linkedAccountCredentialChanges returns the changes to the auth type and credentials of a
+// linked account. Secrets are recorded only as set or unset; references to secret stores are
+// recorded as they are.
+func linkedAccountCredentialChanges(before, after *models.LinkedAccount) []models.AuditChange {
+	fields := func(account *models.LinkedAccount) map[string]interface{} {
+		return map[string]interface{}{
+			"authType":   account.AuthType,
+			"username":   account.Credentials.Username,
+			"keyId":      account.Credentials.KeyID,
+			"secretRefs": account.Credentials.SecretRefs,
+		}
+	}
+	changes := AuditChanges(fields(before), fields(after))
+	for _, secret := range []struct {
+		field         string
+		before, after types.EncryptedString
+	}{
+		{"apiKey", before.Credentials.APIKey, after.Credentials.APIKey},
+		{"password", before.Credentials.Password, after.Credentials.Password},
+		{"token", before.Credentials.Token, after.Credentials.Token},
+		{"accessToken", before.Credentials.AccessToken, after.Credentials.AccessToken},
+		{"refreshToken", before.Credentials.RefreshToken, after.Credentials.RefreshToken},
+		{"secret", before.Credentials.Secret, after.Credentials.Secret},
+	} {
+		if change := secretAuditChange(secret.field, string(secret.before), string(secret.after)); change != nil {
+			changes = append(changes, *change)
+		}
+	}
+	return changes
+}
+
+// recordAudit records an action on a linked account in the audit log.
+func (s *linkedAccountService) recordAudit(ctx context.Context, action models.AuditAction, account *models.LinkedAccount, changes []models.AuditChange) {
+	s.audit.Record(ctx, &models.AuditEvent{
+		Action:     action,
+		TargetType: models.AuditTargetLinkedAccount,
+		TargetID:   account.ID.Hex(),
+		Changes:    changes,
+		Metadata:   map[string]string{"integrationId": account.IntegrationID.Hex(), "ownerId": account.OwnerID},
+	})
+}
+
+// credentialsFromRequest checks that the request carries the secrets its auth type needs, as
+// values or as references to secret stores.
+func credentialsFromRequest(req *models.CreateLinkedAccountRequest) (models.LinkedAccountCredentials, error) {
+	credentials := models.LinkedAccountCredentials{}
+	if err := checkSecretRefs(req); err != nil {
+		return credentials, err
+	}
+	has := func(field, value string) bool { return value != "" || req.SecretRefs[field] != "" }
+	switch req.AuthType {
+	case models.LinkedAccountAuthAPIKey:
+		if !has(models.CredentialAPIKey, req.APIKey) {
+			return credentials, fmt.Errorf("apiKey is required for api_key accounts")
+		}
+		credentials.APIKey = types.EncryptedString(req.APIKey)
+	case models.LinkedAccountAuthBasic:
+		if req.Username == "" {
+			return credentials, fmt.Errorf("username is required for basic accounts")
+		}
+		credentials.Username = req.Username
+		credentials.Password = types.EncryptedString(req.Password)
+	case models.LinkedAccountAuthBearer:
+		if !has(models.CredentialToken, req.Token) {
+			return credentials, fmt.Errorf("token is required for bearer accounts")
+		}
+		credentials.Token = types.EncryptedString(req.Token)
+	case models.LinkedAccountAuthOAuth2:
+		if req.AccessToken == "" {
+			return credentials, fmt.Errorf("accessToken is required for oauth2 accounts")
+		}
+		credentials.AccessToken = types.EncryptedString(req.AccessToken)
+		credentials.RefreshToken = types.EncryptedString(req.RefreshToken)
+		credentials.TokenType = req.TokenType
+		credentials.ExpiresAt = req.ExpiresAt
+	case models.LinkedAccountAuthSignature:
+		if !has(models.CredentialSecret, req.Secret) {
+			return credentials, fmt.Errorf("secret is required for signature accounts")
+		}
+		credentials.KeyID = req.KeyID
+		credentials.Secret = types.EncryptedString(req.Secret)
+		// An optional token carries e.g. the session token of temporary AWS credentials.
+		credentials.Token = types.EncryptedString(req.Token)
+	default:
+		return credentials, fmt.Errorf("unsupported auth type: %s", req.AuthType)
+	}
+	if len(req.SecretRefs) > 0 {
+		credentials.SecretRefs = req.SecretRefs
+	}
+	return credentials, nil
+}
+
+// secretRefFields are the credential fields each auth type may reference in a secret store.
+// The tokens of oauth2 accounts are refreshed by the platform, so they cannot be referenced.
+var secretRefFields = map[models.LinkedAccountAuthType][]string{
+	models.LinkedAccountAuthAPIKey:    {models.CredentialAPIKey},
+	models.LinkedAccountAuthBasic:     {models.CredentialPassword},
+	models.LinkedAccountAuthBearer:    {models.CredentialToken},
+	models.LinkedAccountAuthSignature: {models.CredentialSecret, models.CredentialToken},
+}
+
+// checkSecretRefs checks that the secret references of a request are for fields of its auth
+// type that are not also given as values.
+func checkSecretRefs(req *models.CreateLinkedAccountRequest) error {
+	values := map[string]string{
+		models.CredentialAPIKey:   req.APIKey,
+		models.CredentialPassword: req.Password,
+		models.CredentialToken:    req.Token,
+		models.CredentialSecret:   req.Secret,
+	}
+	for field, ref := range req.SecretRefs {
+		if !containsString(secretRefFields[req.AuthType], field) {
+			return fmt.Errorf("%w: %s accounts cannot reference %s", ErrInvalidSecretReference, req.AuthType, field)
+		}
+		if ref == "" {
+			return fmt.Errorf("%w: the reference of %s is empty", ErrInvalidSecretReference, field)
+		}
+		if values[field] != "" {
+			return fmt.Errorf("%s is given both as a value and as a secret reference", field)
+		}
+	}
+	return nil
+}
+
+// AuthorizeOwner decides whether userID may act for ownerID.
+func (s *linkedAccountService) AuthorizeOwner(ctx context.Context, userID, ownerID string) error {
+	if ownerID == userID {
+		return nil
+	}
+	managers, err := s.repo.ListManagers(ctx, ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to look up linked account owner: %w", err)
+	}
+	if containsManager(managers, userID) {
+		return nil
+	}
+	return ErrOwnerNotManaged
+}
+
+// AuthorizeNewOwner decides whether userID may link accounts for ownerID.
+func (s *linkedAccountService) AuthorizeNewOwner(ctx context.Context, userID, ownerID string) error {
+	if ownerID == userID {
+		return nil
+	}
+	if !ownerIDPattern.MatchString(ownerID) {
+		return ErrInvalidOwnerID
+	}
+	managers, err := s.repo.ListManagers(ctx, ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to look up linked account owner: %w", err)
+	}
+	// Nobody manages an owner without accounts, so the first user to link one claims it. Owner
+	// IDs that look like user IDs are left to the users they may belong to.
+	unclaimed := len(managers) == 0 && !primitive.IsValidObjectID(ownerID)
+	if unclaimed || containsManager(managers, userID) {
+		return nil
+	}
+	return ErrOwnerNotManaged
+}
+
+// containsManager reports whether userID is one of managers.
+func containsManager(managers []string, userID string) bool {
+	for _, manager := range managers {
+		if manager == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetLinkedAccount retrieves a linked account by its ID.
+func (s *linkedAccountService) GetLinkedAccount(ctx context.Context, id primitive.ObjectID) (*models.LinkedAccount, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// ListLinkedAccounts lists the linked accounts managed by a user.
+func (s *linkedAccountService) ListLinkedAccounts(ctx context.Context, userID string) ([]*models.LinkedAccount, error) {
+	return s.repo.GetByUserID(ctx, userID)
+}
+
+// SetEnabled enables or disables a linked account.
+func (s *linkedAccountService) SetEnabled(ctx context.Context, id primitive.ObjectID, enabled bool) error {
+	account, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.SetEnabled(ctx, id, enabled); err != nil {
+		return err
+	}
+	s.logger.Info("Linked account updated", zap.String("linkedAccountID", id.Hex()), zap.Bool("enabled", enabled))
+	if account.Enabled != enabled {
+		s.recordAudit(ctx, models.AuditActionLinkedAccountUpdate, account,
+			[]models.AuditChange{{Field: "enabled", Before: account.Enabled, After: enabled}})
+	}
+	return nil
+}
+
+// DeleteLinkedAccount deletes a linked account.
+func (s *linkedAccountService) DeleteLinkedAccount(ctx context.Context, id primitive.ObjectID) error {
+	account, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.recordAudit(ctx, models.AuditActionLinkedAccountDelete, account,
+		linkedAccountCredentialChanges(account, &models.LinkedAccount{}))
+	return nil
+}
+
+// ResolveCredentials returns the credentials to execute a tool with for ownerID.
+func (s *linkedAccountService) ResolveCredentials(ctx context.Context, integration *models.Integration, ownerID string) (*Credentials, error) {
+	if ownerID != "" {
+		account, err := s.repo.GetByOwner(ctx, integration.ID, ownerID)
+		switch {
+		case err == nil:
+			if !account.Enabled {
+				return nil, ErrLinkedAccountDisabled
+			}
+			credentials := credentialsFromAccount(account)
+			if err := s.resolveSecretRefs(ctx, integration, account, credentials); err != nil {
+				return nil, err
+			}
+			if account.AuthType == models.LinkedAccountAuthOAuth2 && s.tokenRefresher != nil {
+				token, err := s.tokenRefresher.AccessToken(ctx, integration, account)
+				if err != nil {
+					return nil, err
+				}
+				credentials.Token = token
+			}
+			return credentials, nil
+		case !errors.Is(err, mongo.ErrNoDocuments):
+			return nil, fmt.Errorf("failed to look up linked account: %w", err)
+		case !integration.HasAPIKey():
+			return nil, ErrLinkedAccountNotFound
+		}
+	}
+
+	if !integration.HasAPIKey() {
+		return nil, nil
+	}
+	apiKey := string(integration.APIKey)
+	if integration.APIKeyRef != "" {
+		var err error
+		if apiKey, err = s.resolveSecret(ctx, integration.APIKeyRef, map[string]string{"integrationId": integration.ID.Hex()}); err != nil {
+			return nil, err
+		}
+	}
+	// The shared integration key is sent however the integration's scheme expects:
+	// as the API key or bearer token, "user:password" for basic, or the signing secret.
+	credentials := &Credentials{OwnerID: ownerID, AuthType: models.LinkedAccountAuthAPIKey}
+	credentials.APIKey = apiKey
+	credentials.Secret = apiKey
+	if integration.Auth != nil {
+		credentials.KeyID = integration.Auth.Options["key_id"]
+	}
+	return credentials, nil
+}
+
+func credentialsFromAccount(account *models.LinkedAccount) *Credentials {
+	credentials := &Credentials{
+		LinkedAccountID: account.ID,
+		OwnerID:         account.OwnerID,
+		AuthType:        account.AuthType,
+		Credentials: signing.Credentials{
+			APIKey:   string(account.Credentials.APIKey),
+			Username: account.Credentials.Username,
+			Password: string(account.Credentials.Password),
+			Token:    string(account.Credentials.Token),
+			KeyID:    account.Credentials.KeyID,
+			Secret:   string(account.Credentials.Secret),
+		},
+	}
+	if account.AuthType == models.LinkedAccountAuthOAuth2 {
+		credentials.Token = string(account.Credentials.AccessToken)
+	}
+	return credentials
+}
+
+// resolveSecretRefs replaces the credentials of an account that are kept in secret stores with
+// their values.
+func (s *linkedAccountService) resolveSecretRefs(ctx context.Context, integration *models.Integration, account *models.LinkedAccount, credentials *Credentials) error {
+	targets := map[string]*string{
+		models.CredentialAPIKey:   &credentials.APIKey,
+		models.CredentialPassword: &credentials.Password,
+		models.CredentialToken:    &credentials.Token,
+		models.CredentialSecret:   &credentials.Secret,
+	}
+	metadata := map[string]string{"integrationId": integration.ID.Hex(), "linkedAccountId": account.ID.Hex()}
+	for field, ref := range account.Credentials.SecretRefs {
+		target, ok := targets[field]
+		if !ok {
+			continue
+		}
+		value, err := s.resolveSecret(ctx, ref, metadata)
+		if err != nil {
+			return err
+		}
+		*target = value
+	}
+	return nil
+}
+
+// resolveSecret reads a secret referenced by a credential.
+func (s *linkedAccountService) resolveSecret(ctx context.Context, ref string, metadata map[string]string) (string, error) {
+	if s.secrets == nil {
+		return "", fmt.Errorf("%w: no secret store is configured", ErrInvalidSecretReference)
+	}
+	value, err := s.secrets.Resolve(ctx, ref, metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %w", ref, err)
+	}
+	return value, nil
+}
+
+// RecordExecution stores an audit record of a tool execution. Failures are logged, not returned,
+// so that auditing never breaks a tool call.
+func (s *linkedAccountService) RecordExecution(ctx context.Context, execution *models.ToolExecution) {
+	if execution.CreatedAt.IsZero() {
+		execution.CreatedAt = time.Now()
+	}
+	if err := s.executionsRepo.Create(ctx, execution); err != nil {
+		s.logger.Error("Failed to record tool execution",
+			zap.String("toolName", execution.ToolName),
+			zap.String("ownerID", execution.OwnerID),
+			zap.Error(err))
+	}
+	if !execution.LinkedAccountID.IsZero() {
+		if err := s.repo.TouchLastUsed(ctx, execution.LinkedAccountID, execution.CreatedAt); err != nil {
+			s.logger.Warn("Failed to update linked account usage", zap.String("linkedAccountID", execution.LinkedAccountID.Hex()), zap.Error(err))
+		}
+	}
+}
+
+// ListExecutions returns the most recent tool executions made with a linked account.
+func (s *linkedAccountService) ListExecutions(ctx context.Context, linkedAccountID primitive.ObjectID, limit int64) ([]*models.ToolExecution, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return s.executionsRepo.ListByLinkedAccount(ctx, linkedAccountID, limit)
+}
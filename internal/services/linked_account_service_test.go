@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// memoryLinkedAccounts keeps linked accounts in memory; unused methods panic.
+type memoryLinkedAccounts struct {
+	repositories.LinkedAccountRepository
+	accounts []*models.LinkedAccount
+}
+
+func (r *memoryLinkedAccounts) Create(ctx context.Context, account *models.LinkedAccount) (*models.LinkedAccount, error) {
+	account.ID = primitive.NewObjectID()
+	r.accounts = append(r.accounts, account)
+	return account, nil
+}
+
+func (r *memoryLinkedAccounts) GetByOwner(ctx context.Context, integrationID primitive.ObjectID, ownerID string) (*models.LinkedAccount, error) {
+	for _, account := range r.accounts {
+		if account.IntegrationID == integrationID && account.OwnerID == ownerID {
+			return account, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *memoryLinkedAccounts) GetByID(ctx context.Context, id primitive.ObjectID) (*models.LinkedAccount, error) {
+	for _, account := range r.accounts {
+		if account.ID == id {
+			copied := *account
+			return &copied, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *memoryLinkedAccounts) SetEnabled(ctx context.Context, id primitive.ObjectID, enabled bool) error {
+	for _, account := range r.accounts {
+		if account.ID == id {
+			account.Enabled = enabled
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (r *memoryLinkedAccounts) Delete(ctx context.Context, id primitive.ObjectID) error {
+	for i, account := range r.accounts {
+		if account.ID == id {
+			r.accounts = append(r.accounts[:i], r.accounts[i+1:]...)
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (r *memoryLinkedAccounts) ListManagers(ctx context.Context, ownerID string) ([]string, error) {
+	var managers []string
+	for _, account := range r.accounts {
+		if account.OwnerID == ownerID && !containsManager(managers, account.UserID) {
+			managers = append(managers, account.UserID)
+		}
+	}
+	return managers, nil
+}
+
+// newTestLinkedAccounts returns a service in which alice manages the accounts of customer-1.
+func newTestLinkedAccounts() (LinkedAccountService, *memoryLinkedAccounts) {
+	repo := &memoryLinkedAccounts{accounts: []*models.LinkedAccount{
+		{ID: primitive.NewObjectID(), IntegrationID: primitive.NewObjectID(), OwnerID: "customer-1", UserID: "alice", Enabled: true},
+	}}
+	return NewLinkedAccountService(repo, nil, nil, nil, NewAuditService(&memoryAuditEvents{}, 0, zap.NewNop()), zap.NewNop()), repo
+}
+
+func TestAuthorizeOwner(t *testing.T) {
+	service, _ := newTestLinkedAccounts()
+
+	tests := []struct {
+		name, userID, ownerID string
+		wantErr               error
+	}{
+		{"own accounts", "bob", "bob", nil},
+		{"managed owner", "alice", "customer-1", nil},
+		{"owner managed by another user", "bob", "customer-1", ErrOwnerNotManaged},
+		{"another platform user", "bob", "alice", ErrOwnerNotManaged},
+		{"owner without accounts", "bob", "customer-2", ErrOwnerNotManaged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := service.AuthorizeOwner(context.Background(), tt.userID, tt.ownerID); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AuthorizeOwner(%q, %q) = %v, want %v", tt.userID, tt.ownerID, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorizeNewOwner(t *testing.T) {
+	service, _ := newTestLinkedAccounts()
+
+	tests := []struct {
+		name, userID, ownerID string
+		wantErr               error
+	}{
+		{"own accounts", "bob", "bob", nil},
+		{"managed owner", "alice", "customer-1", nil},
+		{"unclaimed owner", "bob", "customer-2", nil},
+		{"owner managed by another user", "bob", "customer-1", ErrOwnerNotManaged},
+		{"owner ID of a platform user", "bob", primitive.NewObjectID().Hex(), ErrOwnerNotManaged},
+		{"invalid owner ID", "bob", "customer 2", ErrInvalidOwnerID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := service.AuthorizeNewOwner(context.Background(), tt.userID, tt.ownerID); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AuthorizeNewOwner(%q, %q) = %v, want %v", tt.userID, tt.ownerID, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateLinkedAccountRefusesForeignOwner(t *testing.T) {
+	service, repo := newTestLinkedAccounts()
+	req := &models.CreateLinkedAccountRequest{
+		IntegrationID: primitive.NewObjectID().Hex(),
+		OwnerID:       "customer-1",
+		AuthType:      models.LinkedAccountAuthAPIKey,
+		APIKey:        "secret",
+	}
+
+	if _, err := service.CreateLinkedAccount(context.Background(), "bob", req); !errors.Is(err, ErrOwnerNotManaged) {
+		t.Fatalf("CreateLinkedAccount() error = %v, want %v", err, ErrOwnerNotManaged)
+	}
+	if len(repo.accounts) != 1 {
+		t.Fatalf("accounts = %d, want the account of alice only", len(repo.accounts))
+	}
+	if _, err := service.CreateLinkedAccount(context.Background(), "alice", req); err != nil {
+		t.Fatalf("CreateLinkedAccount() by the manager: %v", err)
+	}
+}
+
+func TestResolveCredentials(t *testing.T) {
+	service, repo := newTestLinkedAccounts()
+	linked := repo.accounts[0]
+	linked.AuthType = models.LinkedAccountAuthBearer
+	linked.Credentials.Token = "owner-token"
+	disabled := &models.LinkedAccount{ID: primitive.NewObjectID(), IntegrationID: linked.IntegrationID, OwnerID: "customer-2", UserID: "alice"}
+	repo.accounts = append(repo.accounts, disabled)
+
+	withKey := &models.Integration{ID: linked.IntegrationID, APIKey: "shared-key"}
+	withoutKey := &models.Integration{ID: linked.IntegrationID}
+
+	tests := []struct {
+		name        string
+		integration *models.Integration
+		ownerID     string
+		wantSecret  string
+		wantErr     error
+	}{
+		{"linked account wins", withKey, "customer-1", "owner-token", nil},
+		{"disabled account", withKey, "customer-2", "", ErrLinkedAccountDisabled},
+		{"integration key fallback", withKey, "customer-3", "shared-key", nil},
+		{"no account and no key", withoutKey, "customer-3", "", ErrLinkedAccountNotFound},
+		{"no owner and no key", withoutKey, "", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credentials, err := service.ResolveCredentials(context.Background(), tt.integration, tt.ownerID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResolveCredentials() error = %v, want %v", err, tt.wantErr)
+			}
+			var secret string
+			if credentials != nil {
+				secret = credentials.Token + credentials.APIKey
+			}
+			if secret != tt.wantSecret {
+				t.Fatalf("ResolveCredentials() secret = %q, want %q", secret, tt.wantSecret)
+			}
+		})
+	}
+}
+
+func TestLinkedAccountChangesAreAudited(t *testing.T) {
+	ctx := context.Background()
+	audit := NewAuditService(&memoryAuditEvents{}, 0, zap.NewNop())
+	service := NewLinkedAccountService(&memoryLinkedAccounts{}, nil, nil, nil, audit, zap.NewNop())
+	account, err := service.CreateLinkedAccount(ctx, "alice", &models.CreateLinkedAccountRequest{
+		IntegrationID: primitive.NewObjectID().Hex(), AuthType: models.LinkedAccountAuthBasic, Username: "bot", Password: "hunter2",
+	})
+	if err != nil {
+		t.Fatalf("CreateLinkedAccount() error = %v", err)
+	}
+	if err := service.SetEnabled(ctx, account.ID, false); err != nil {
+		t.Fatalf("SetEnabled() error = %v", err)
+	}
+	if err := service.DeleteLinkedAccount(ctx, account.ID); err != nil {
+		t.Fatalf("DeleteLinkedAccount() error = %v", err)
+	}
+
+	events := recordedAuditEvents(audit)
+	wantActions := []models.AuditAction{models.AuditActionLinkedAccountCreate, models.AuditActionLinkedAccountUpdate, models.AuditActionLinkedAccountDelete}
+	if len(events) != len(wantActions) {
+		t.Fatalf("recorded %d events, want %d", len(events), len(wantActions))
+	}
+	for i, event := range events {
+		if event.Action != wantActions[i] || event.TargetType != models.AuditTargetLinkedAccount || event.TargetID != account.ID.Hex() {
+			t.Errorf("event %d = %+v, want %s of the account", i, event, wantActions[i])
+		}
+		encoded, _ := json.Marshal(event)
+		if strings.Contains(string(encoded), "hunter2") {
+			t.Errorf("event %d stores the password: %s", i, encoded)
+		}
+	}
+
+	changes := map[string]models.AuditChange{}
+	for _, change := range events[0].Changes {
+		changes[change.Field] = change
+	}
+	if changes["password"].After != auditRedacted || changes["password"].Before != nil || changes["username"].After != "bot" {
+		t.Errorf("create changes = %+v, want the redacted password and the username", events[0].Changes)
+	}
+	if deleted := events[2].Changes; len(deleted) == 0 {
+		t.Error("delete recorded no changes, want the removed credentials")
+	}
+}
@@ -0,0 +1,568 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/hosting"
+	"github.com/AkashKesav/API2SDK/internal/mcpgen/codegen"
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/openapi"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNotHosted is returned for instances without a deployed server.
+	ErrNotHosted = errors.New("no server is deployed for this instance")
+	// ErrHostedServerNotRunning is returned when a deployed server is not accepting requests.
+	ErrHostedServerNotRunning = errors.New("hosted server is not running")
+	// ErrHostingBusy is returned while the artifact of an instance is being built.
+	ErrHostingBusy = errors.New("a deployment is already in progress for this instance")
+	// ErrInvalidArtifact is returned when an SDK record is not a completed MCP server of the instance owner.
+	ErrInvalidArtifact = errors.New("the SDK is not a completed MCP server generation of the instance owner")
+)
+
+const (
+	// buildTimeout bounds unpacking and building one artifact.
+	buildTimeout = 10 * time.Minute
+	// hostingSaveTimeout bounds persisting one state change.
+	hostingSaveTimeout = 5 * time.Second
+)
+
+// MCPHostingConfig configures where and how hosted servers run.
+type MCPHostingConfig struct {
+	// Dir is where artifacts are unpacked and built, one directory per instance.
+	Dir     string
+	PortMin int
+	PortMax int
+	Limits  hosting.Limits
+}
+
+// MCPHostingService deploys generated MCP server artifacts for MCP instances and runs
+// them as supervised child processes on the platform host.
+type MCPHostingService interface {
+	// Deploy unpacks and builds the artifact of sdkID for the instance in the background, then starts it.
+	// A previous deployment of the instance is replaced.
+	Deploy(ctx context.Context, instanceID, sdkID primitive.ObjectID) (*models.MCPHosting, error)
+	// Undeploy stops the server of an instance and removes its files.
+	Undeploy(ctx context.Context, instanceID primitive.ObjectID) error
+	Start(ctx context.Context, instanceID primitive.ObjectID) (*models.MCPHosting, error)
+	Stop(ctx context.Context, instanceID primitive.ObjectID) (*models.MCPHosting, error)
+	// Restart restarts the server, which also re-resolves its credentials.
+	Restart(ctx context.Context, instanceID primitive.ObjectID) (*models.MCPHosting, error)
+	Status(ctx context.Context, instanceID primitive.ObjectID) (*models.MCPHosting, error)
+	// Logs returns up to tail of the most recent build and process output lines.
+	Logs(instanceID primitive.ObjectID, tail int) []string
+	// Endpoint returns the local origin of the running server of an instance.
+	// It returns ErrNotHosted when the instance has no deployment.
+	Endpoint(instanceID string) (string, error)
+	// Restore reloads the deployments at boot and starts those that should run.
+	Restore(ctx context.Context) error
+	// Shutdown stops all servers without changing their desired state.
+	Shutdown(ctx context.Context)
+}
+
+// mcpHostingService is the concrete implementation of MCPHostingService.
+type mcpHostingService struct {
+	repo                 repositories.MCPInstanceRepository
+	sdkRepo              repositories.SDKRepositoryInterface
+	integrationService   IntegrationService
+	linkedAccountService LinkedAccountService
+	authorizer           ResourceAuthorizer // Decides whose SDKs an instance may deploy
+	config               MCPHostingConfig
+	supervisor           *hosting.Supervisor
+	logger               *zap.Logger
+
+	mu sync.Mutex
+	// deployments are the hosting records of all deployed instances, keyed by instance ID hex.
+	deployments map[string]*models.MCPHosting
+	// builds cancels the running builds, keyed by instance ID hex.
+	builds map[string]context.CancelFunc
+}
+
+// NewMCPHostingService creates a new MCPHostingService.
+func NewMCPHostingService(
+	repo repositories.MCPInstanceRepository,
+	sdkRepo repositories.SDKRepositoryInterface,
+	integrationService IntegrationService,
+	linkedAccountService LinkedAccountService,
+	authorizer ResourceAuthorizer,
+	config MCPHostingConfig,
+	logger *zap.Logger,
+) (MCPHostingService, error) {
+	dir, err := filepath.Abs(config.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hosting directory: %w", err)
+	}
+	config.Dir = dir
+
+	s := &mcpHostingService{
+		repo:                 repo,
+		sdkRepo:              sdkRepo,
+		integrationService:   integrationService,
+		linkedAccountService: linkedAccountService,
+		authorizer:           authorizer,
+		config:               config,
+		logger:               logger,
+		deployments:          make(map[string]*models.MCPHosting),
+		builds:               make(map[string]context.CancelFunc),
+	}
+	s.supervisor = hosting.NewSupervisor(config.Limits, s.onProcessState, logger)
+	return s, nil
+}
+
+// Deploy unpacks and builds the artifact of sdkID for the instance in the background, then starts it.
+func (s *mcpHostingService) Deploy(ctx context.Context, instanceID, sdkID primitive.ObjectID) (*models.MCPHosting, error) {
+	instance, err := s.repo.GetByID(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MCP instance: %w", err)
+	}
+	sdk, err := s.sdkRepo.GetByID(ctx, sdkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SDK: %w", err)
+	}
+	if sdk.GenerationType != models.GenerationTypeMCP || sdk.Status != models.SDKStatusCompleted || sdk.IsDeleted ||
+		sdk.FilePath == "" {
+		return nil, ErrInvalidArtifact
+	}
+	// The instance's owner may deploy any SDK they may read, including those shared with their organizations
+	if err := s.authorizer.Authorize(ctx, instance.UserID, sdk.UserID, sdk.OrganizationID, models.ResourceSDKs, models.ActionRead); err != nil {
+		return nil, ErrInvalidArtifact
+	}
+
+	id := instanceID.Hex()
+	s.mu.Lock()
+	if _, ok := s.builds[id]; ok {
+		s.mu.Unlock()
+		return nil, ErrHostingBusy
+	}
+	buildCtx, cancel := context.WithTimeout(context.Background(), buildTimeout)
+	s.builds[id] = cancel
+	previous := s.deployments[id]
+	s.mu.Unlock()
+
+	// The previous server keeps its port, so clients are not disrupted more than necessary.
+	if err := s.supervisor.Stop(ctx, id); err != nil && !errors.Is(err, hosting.ErrNotRunning) {
+		s.finishBuild(id)
+		return nil, fmt.Errorf("failed to stop the previous server: %w", err)
+	}
+	port := 0
+	if previous != nil {
+		port = previous.Port
+	} else if port, err = s.allocatePort(); err != nil {
+		s.finishBuild(id)
+		return nil, err
+	}
+
+	record := &models.MCPHosting{
+		SDKID:        sdkID,
+		Port:         port,
+		DesiredState: models.MCPServerDesiredRunning,
+		Status:       models.MCPHostingStatusBuilding,
+		DeployedAt:   time.Now(),
+	}
+	if previous != nil {
+		record.Dir = previous.Dir
+	}
+	s.mu.Lock()
+	s.deployments[id] = record
+	s.mu.Unlock()
+	if err := s.save(instanceID); err != nil {
+		s.finishBuild(id)
+		return nil, err
+	}
+
+	s.supervisor.Logs(id).Note(fmt.Sprintf("deploying %s (%s)", sdkID.Hex(), sdk.MCPLanguage))
+	go s.build(buildCtx, instance, sdk.FilePath)
+	return s.snapshot(id), nil
+}
+
+// build unpacks and compiles the artifact into a fresh release directory, then starts the server.
+func (s *mcpHostingService) build(ctx context.Context, instance *models.MCPInstance, artifact string) {
+	id := instance.ID.Hex()
+	defer s.finishBuild(id)
+	logs := s.supervisor.Logs(id)
+
+	release := filepath.Join(s.config.Dir, id, strconv.FormatInt(time.Now().UnixNano(), 10))
+	language, command, err := s.unpackAndBuild(ctx, release, artifact, logs)
+	if err != nil {
+		logs.Note("deployment failed: " + err.Error())
+		s.logger.Warn("Failed to build hosted MCP server", zap.String("instanceId", id), zap.Error(err))
+		_ = os.RemoveAll(release)
+		s.update(instance.ID, func(record *models.MCPHosting) {
+			record.Status = models.MCPServerStatusFailed
+			record.LastError = err.Error()
+		})
+		return
+	}
+
+	var oldDir string
+	s.update(instance.ID, func(record *models.MCPHosting) {
+		oldDir = record.Dir
+		record.Language = language
+		record.Command = command
+		record.Dir = release
+		record.Status = models.MCPServerStatusStopped
+		record.LastError = ""
+	})
+	if oldDir != "" && oldDir != release {
+		_ = os.RemoveAll(oldDir)
+	}
+	logs.Note("build succeeded")
+
+	if record := s.snapshot(id); record != nil && record.DesiredState == models.MCPServerDesiredRunning {
+		if err := s.startProcess(context.Background(), instance); err != nil {
+			logs.Note("failed to start: " + err.Error())
+			s.update(instance.ID, func(record *models.MCPHosting) {
+				record.Status = models.MCPServerStatusFailed
+				record.LastError = err.Error()
+			})
+		}
+	}
+}
+
+func (s *mcpHostingService) unpackAndBuild(ctx context.Context, dir, artifact string, logs *hosting.LogBuffer) (string, []string, error) {
+	if err := hosting.Unpack(artifact, dir); err != nil {
+		return "", nil, err
+	}
+	language, err := hosting.DetectLanguage(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	command, err := hosting.Build(ctx, dir, language, hosting.Environment(), logs)
+	if err != nil {
+		return "", nil, err
+	}
+	return language, command, nil
+}
+
+func (s *mcpHostingService) finishBuild(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.builds[id]; ok {
+		cancel()
+		delete(s.builds, id)
+	}
+}
+
+// Undeploy stops the server of an instance and removes its files.
+func (s *mcpHostingService) Undeploy(ctx context.Context, instanceID primitive.ObjectID) error {
+	id := instanceID.Hex()
+	s.mu.Lock()
+	if _, ok := s.builds[id]; ok {
+		s.mu.Unlock()
+		return ErrHostingBusy
+	}
+	_, ok := s.deployments[id]
+	delete(s.deployments, id)
+	s.mu.Unlock()
+	if !ok {
+		return ErrNotHosted
+	}
+
+	if err := s.supervisor.Stop(ctx, id); err != nil && !errors.Is(err, hosting.ErrNotRunning) {
+		return fmt.Errorf("failed to stop server: %w", err)
+	}
+	if err := os.RemoveAll(filepath.Join(s.config.Dir, id)); err != nil {
+		s.logger.Warn("Failed to remove hosted server files", zap.String("instanceId", id), zap.Error(err))
+	}
+	return s.repo.UpdateHosting(ctx, instanceID, nil)
+}
+
+// Start marks the server as desired running and starts it.
+func (s *mcpHostingService) Start(ctx context.Context, instanceID primitive.ObjectID) (*models.MCPHosting, error) {
+	id := instanceID.Hex()
+	record := s.snapshot(id)
+	if record == nil {
+		return nil, ErrNotHosted
+	}
+	s.update(instanceID, func(record *models.MCPHosting) {
+		record.DesiredState = models.MCPServerDesiredRunning
+	})
+	// A server that is still building starts when its build finishes.
+	if record.Status == models.MCPHostingStatusBuilding {
+		return s.snapshot(id), nil
+	}
+	if _, running := s.supervisor.State(id); running {
+		return s.snapshot(id), nil
+	}
+
+	instance, err := s.repo.GetByID(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MCP instance: %w", err)
+	}
+	if err := s.startProcess(ctx, instance); err != nil {
+		return nil, err
+	}
+	return s.snapshot(id), nil
+}
+
+// Stop marks the server as desired stopped and stops it.
+func (s *mcpHostingService) Stop(ctx context.Context, instanceID primitive.ObjectID) (*models.MCPHosting, error) {
+	id := instanceID.Hex()
+	if s.snapshot(id) == nil {
+		return nil, ErrNotHosted
+	}
+	s.update(instanceID, func(record *models.MCPHosting) {
+		record.DesiredState = models.MCPServerDesiredStopped
+	})
+	if err := s.supervisor.Stop(ctx, id); err != nil && !errors.Is(err, hosting.ErrNotRunning) {
+		return nil, fmt.Errorf("failed to stop server: %w", err)
+	}
+	return s.snapshot(id), nil
+}
+
+// Restart restarts the server, which also re-resolves its credentials.
+func (s *mcpHostingService) Restart(ctx context.Context, instanceID primitive.ObjectID) (*models.MCPHosting, error) {
+	id := instanceID.Hex()
+	if s.snapshot(id) == nil {
+		return nil, ErrNotHosted
+	}
+	if err := s.supervisor.Stop(ctx, id); err != nil && !errors.Is(err, hosting.ErrNotRunning) {
+		return nil, fmt.Errorf("failed to stop server: %w", err)
+	}
+	return s.Start(ctx, instanceID)
+}
+
+// Status returns the hosting state of an instance.
+func (s *mcpHostingService) Status(ctx context.Context, instanceID primitive.ObjectID) (*models.MCPHosting, error) {
+	if record := s.snapshot(instanceID.Hex()); record != nil {
+		return record, nil
+	}
+	return nil, ErrNotHosted
+}
+
+// Logs returns up to tail of the most recent build and process output lines.
+func (s *mcpHostingService) Logs(instanceID primitive.ObjectID, tail int) []string {
+	return s.supervisor.Logs(instanceID.Hex()).Tail(tail)
+}
+
+// Endpoint returns the local origin of the running server of an instance.
+func (s *mcpHostingService) Endpoint(instanceID string) (string, error) {
+	record := s.snapshot(instanceID)
+	if record == nil {
+		return "", ErrNotHosted
+	}
+	if state, ok := s.supervisor.State(instanceID); !ok || state.Status != hosting.StatusRunning {
+		return "", ErrHostedServerNotRunning
+	}
+	return fmt.Sprintf("http://127.0.0.1:%d", record.Port), nil
+}
+
+// Restore reloads the deployments at boot and starts those that should run.
+// Builds interrupted by the previous shutdown are started again.
+func (s *mcpHostingService) Restore(ctx context.Context) error {
+	instances, err := s.repo.ListHosted(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list hosted instances: %w", err)
+	}
+
+	for _, instance := range instances {
+		id := instance.ID.Hex()
+		record := *instance.Hosting
+		record.PID = 0
+		s.mu.Lock()
+		s.deployments[id] = &record
+		s.mu.Unlock()
+
+		if record.DesiredState != models.MCPServerDesiredRunning {
+			continue
+		}
+		if record.Status == models.MCPHostingStatusBuilding || len(record.Command) == 0 {
+			if _, err := s.Deploy(ctx, instance.ID, record.SDKID); err != nil {
+				s.logger.Warn("Failed to redeploy hosted MCP server", zap.String("instanceId", id), zap.Error(err))
+			}
+			continue
+		}
+		if err := s.startProcess(ctx, instance); err != nil {
+			s.logger.Warn("Failed to restore hosted MCP server", zap.String("instanceId", id), zap.Error(err))
+			s.update(instance.ID, func(record *models.MCPHosting) {
+				record.Status = models.MCPServerStatusFailed
+				record.LastError = err.Error()
+			})
+		}
+	}
+	return nil
+}
+
+// Shutdown stops all servers and builds without changing their desired state.
+func (s *mcpHostingService) Shutdown(ctx context.Context) {
+	s.mu.Lock()
+	for _, cancel := range s.builds {
+		cancel()
+	}
+	s.mu.Unlock()
+	s.supervisor.StopAll(ctx)
+}
+
+// startProcess resolves the environment of the instance's server and hands it to the supervisor.
+func (s *mcpHostingService) startProcess(ctx context.Context, instance *models.MCPInstance) error {
+	id := instance.ID.Hex()
+	record := s.snapshot(id)
+	if record == nil {
+		return ErrNotHosted
+	}
+	if len(record.Command) == 0 {
+		return fmt.Errorf("the server has not been built")
+	}
+	env, err := s.serverEnv(ctx, instance, record.Port)
+	if err != nil {
+		return err
+	}
+	return s.supervisor.Start(id, hosting.Spec{
+		Command: record.Command,
+		Dir:     record.Dir,
+		Env:     hosting.Environment(env...),
+	})
+}
+
+// serverEnv returns the variables a generated server is configured with: the transport and port
+// it listens on, the API base URL, and the instance owner's credentials under the names the
+// generator derived from the integration's security schemes.
+func (s *mcpHostingService) serverEnv(ctx context.Context, instance *models.MCPInstance, port int) ([]string, error) {
+	env := []string{
+		"MCP_TRANSPORT=streamable-http",
+		"MCP_PORT=" + strconv.Itoa(port),
+	}
+
+	integrationID, err := primitive.ObjectIDFromHex(instance.IntegrationID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid integration ID: %w", err)
+	}
+	integration, err := s.integrationService.GetIntegration(ctx, integrationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get integration: %w", err)
+	}
+	if integration.BaseURL != "" {
+		env = append(env, "API_BASE_URL="+integration.BaseURL)
+	}
+
+	credentials, err := s.linkedAccountService.ResolveCredentials(ctx, integration, instance.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve credentials: %w", err)
+	}
+	if credentials == nil {
+		return env, nil
+	}
+
+	var doc *openapi.Document
+	if integration.OpenAPISpec != "" {
+		if doc, err = openapi.Parse([]byte(integration.OpenAPISpec)); err != nil {
+			s.logger.Warn("Failed to parse integration spec", zap.String("integration", integration.Name), zap.Error(err))
+		}
+	}
+	for _, variable := range codegen.CredentialEnvVars(doc) {
+		switch variable.Kind {
+		case "basic":
+			if credentials.Username != "" {
+				env = append(env, variable.Username+"="+credentials.Username, variable.Password+"="+credentials.Password)
+			}
+		case "apiKey":
+			if value := firstNonEmpty(credentials.APIKey, credentials.Token); value != "" {
+				env = append(env, variable.Value+"="+value)
+			}
+		default:
+			if value := firstNonEmpty(credentials.Token, credentials.APIKey); value != "" {
+				env = append(env, variable.Value+"="+value)
+			}
+		}
+	}
+	return env, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// allocatePort picks a free port that no other deployment uses.
+func (s *mcpHostingService) allocatePort() (int, error) {
+	s.mu.Lock()
+	taken := make(map[int]bool, len(s.deployments))
+	for _, record := range s.deployments {
+		taken[record.Port] = true
+	}
+	s.mu.Unlock()
+	return hosting.FreePort(s.config.PortMin, s.config.PortMax, taken)
+}
+
+// onProcessState mirrors the supervisor's view of a process into the hosting record.
+func (s *mcpHostingService) onProcessState(id string, state hosting.State) {
+	instanceID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return
+	}
+	s.update(instanceID, func(record *models.MCPHosting) {
+		switch state.Status {
+		case hosting.StatusStarting:
+			record.Status = models.MCPServerStatusStarting
+		case hosting.StatusRunning:
+			record.Status = models.MCPServerStatusRunning
+		case hosting.StatusFailed:
+			record.Status = models.MCPServerStatusFailed
+		default:
+			record.Status = models.MCPServerStatusStopped
+		}
+		record.PID = state.PID
+		record.Restarts = state.Restarts
+		if state.LastError != "" {
+			record.LastError = state.LastError
+		}
+		record.StartedAt = state.StartedAt
+		record.StoppedAt = state.StoppedAt
+	})
+}
+
+// update changes the in-memory record of an instance and persists it.
+func (s *mcpHostingService) update(instanceID primitive.ObjectID, change func(record *models.MCPHosting)) {
+	s.mu.Lock()
+	record, ok := s.deployments[instanceID.Hex()]
+	if ok {
+		change(record)
+	}
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	if err := s.save(instanceID); err != nil {
+		s.logger.Warn("Failed to save hosting state", zap.String("instanceId", instanceID.Hex()), zap.Error(err))
+	}
+}
+
+func (s *mcpHostingService) save(instanceID primitive.ObjectID) error {
+	record := s.snapshot(instanceID.Hex())
+	if record == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hostingSaveTimeout)
+	defer cancel()
+	if err := s.repo.UpdateHosting(ctx, instanceID, record); err != nil {
+		return fmt.Errorf("failed to save hosting state: %w", err)
+	}
+	return nil
+}
+
+// snapshot returns a copy of the record of an instance, or nil when it is not deployed.
+func (s *mcpHostingService) snapshot(id string) *models.MCPHosting {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.deployments[id]
+	if !ok {
+		return nil
+	}
+	copied := *record
+	copied.Command = append([]string(nil), record.Command...)
+	return &copied
+}
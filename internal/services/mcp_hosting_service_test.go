@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// hostedMCPInstances keeps MCP instances and their hosting state in memory.
+type hostedMCPInstances struct {
+	memoryMCPInstances
+	mu      sync.Mutex
+	hosting map[primitive.ObjectID]models.MCPHosting
+}
+
+func (r *hostedMCPInstances) UpdateHosting(ctx context.Context, id primitive.ObjectID, hosting *models.MCPHosting) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if hosting == nil {
+		delete(r.hosting, id)
+	} else {
+		r.hosting[id] = *hosting
+	}
+	return nil
+}
+
+func TestDeployAuthorizesOrganizationSDKs(t *testing.T) {
+	ctx := context.Background()
+	organizations, _, alice, bob := newTestOrganizationService()
+	organization, err := organizations.CreateOrganization(ctx, alice.ID, &models.CreateOrganizationRequest{Name: "Acme"})
+	if err != nil {
+		t.Fatalf("CreateOrganization() error = %v", err)
+	}
+	invitation, err := organizations.CreateInvitation(ctx, alice.ID, organization.ID, &models.CreateInvitationRequest{Email: bob.Email})
+	if err != nil {
+		t.Fatalf("CreateInvitation() error = %v", err)
+	}
+	if _, err := organizations.AcceptInvitation(ctx, bob.ID, invitation.Token); err != nil {
+		t.Fatalf("AcceptInvitation() error = %v", err)
+	}
+
+	// The artifacts do not exist, so accepted deployments fail in the background.
+	artifact := func(owner *models.User, organizationID string) *models.SDK {
+		return &models.SDK{
+			ID: primitive.NewObjectID(), UserID: owner.ID.Hex(), OrganizationID: organizationID,
+			GenerationType: models.GenerationTypeMCP, Status: models.SDKStatusCompleted,
+			FilePath: filepath.Join(t.TempDir(), "server.zip"),
+		}
+	}
+	shared := artifact(alice, organization.ID.Hex())
+	personal := artifact(alice, "")
+	instance := &models.MCPInstance{ID: primitive.NewObjectID(), UserID: bob.ID.Hex()}
+	repo := &hostedMCPInstances{
+		memoryMCPInstances: memoryMCPInstances{instances: map[primitive.ObjectID]*models.MCPInstance{instance.ID: instance}},
+		hosting:            make(map[primitive.ObjectID]models.MCPHosting),
+	}
+	service, err := NewMCPHostingService(repo, &memorySDKs{sdks: []*models.SDK{shared, personal}}, nil, nil, organizations,
+		MCPHostingConfig{Dir: t.TempDir(), PortMin: 20000, PortMax: 20999}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewMCPHostingService() error = %v", err)
+	}
+
+	if _, err := service.Deploy(ctx, instance.ID, personal.ID); !errors.Is(err, ErrInvalidArtifact) {
+		t.Fatalf("Deploy() of another member's personal SDK error = %v, want ErrInvalidArtifact", err)
+	}
+	record, err := service.Deploy(ctx, instance.ID, shared.ID)
+	if err != nil {
+		t.Fatalf("Deploy() of an organization SDK error = %v", err)
+	}
+	// The build may already have failed by the time Deploy returns.
+	if record.SDKID != shared.ID || (record.Status != models.MCPHostingStatusBuilding && record.Status != models.MCPServerStatusFailed) {
+		t.Fatalf("Deploy() = %+v", record)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for record.Status == models.MCPHostingStatusBuilding {
+		if time.Now().After(deadline) {
+			t.Fatal("build did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+		if record, err = service.Status(ctx, instance.ID); err != nil {
+			t.Fatalf("Status() error = %v", err)
+		}
+	}
+	if record.Status != models.MCPServerStatusFailed {
+		t.Fatalf("Status() = %+v, want a failed build", record)
+	}
+}
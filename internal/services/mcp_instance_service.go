@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/outbound"
 	"github.com/AkashKesav/API2SDK/internal/repositories"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -18,7 +19,7 @@ type MCPInstanceService interface {
 	ListMCPInstances(ctx context.Context, userID string) ([]*models.MCPInstance, error)
 	DeleteMCPInstance(ctx context.Context, id primitive.ObjectID) error
 	GetTools(ctx context.Context, instanceID primitive.ObjectID) ([]models.Tool, error)
-	ExecuteToolCall(ctx context.Context, instanceID primitive.ObjectID, toolName string, args map[string]interface{}) (interface{}, error)
+	ExecuteToolCall(ctx context.Context, instanceID primitive.ObjectID, toolName string, args map[string]interface{}) (*models.ToolResult, error)
 	GetResources(ctx context.Context, instanceID primitive.ObjectID) ([]models.Resource, error)
 }
 
@@ -27,15 +28,21 @@ type mcpInstanceService struct {
 	repo               repositories.MCPInstanceRepository
 	integrationService IntegrationService
 	toolProvider       ToolProvider
+	owners             OwnerAuthorizer
+	httpClient         *http.Client
 	toolCache          map[primitive.ObjectID][]models.Tool
 }
 
-// NewMCPInstanceService creates a new MCPInstanceService.
-func NewMCPInstanceService(repo repositories.MCPInstanceRepository, integrationService IntegrationService, toolProvider ToolProvider) MCPInstanceService {
+// NewMCPInstanceService creates a new MCPInstanceService. owners decides which linked account
+// owners a caller may execute tools for; httpClient is the shared outbound client used to fetch
+// tool lists from an instance's ToolsURL.
+func NewMCPInstanceService(repo repositories.MCPInstanceRepository, integrationService IntegrationService, toolProvider ToolProvider, owners OwnerAuthorizer, httpClient *http.Client) MCPInstanceService {
 	return &mcpInstanceService{
 		repo:               repo,
 		integrationService: integrationService,
 		toolProvider:       toolProvider,
+		owners:             owners,
+		httpClient:         httpClient,
 		toolCache:          make(map[primitive.ObjectID][]models.Tool),
 	}
 }
@@ -107,7 +114,11 @@ func (s *mcpInstanceService) GetTools(ctx context.Context, instanceID primitive.
 	}
 
 	if mcpInstance.ToolsURL != "" {
-		resp, err := http.Get(mcpInstance.ToolsURL)
+		req, err := http.NewRequestWithContext(outbound.WithIntegration(ctx, mcpInstance.IntegrationID), http.MethodGet, mcpInstance.ToolsURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tools URL: %w", err)
+		}
+		resp, err := s.httpClient.Do(req)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch tools from URL: %w", err)
 		}
@@ -127,7 +138,7 @@ func (s *mcpInstanceService) GetTools(ctx context.Context, instanceID primitive.
 	}
 
 	if mcpInstance.ServerType == "unified" {
-		tools := []models.Tool{aciSearchFunctionsTool, aciExecuteFunctionTool}
+		tools := []models.Tool{aciSearchFunctionsTool, aciExecuteFunctionTool, ContinueResultTool, NextPageTool}
 		s.toolCache[instanceID] = tools
 		return tools, nil
 	}
@@ -141,18 +152,29 @@ func (s *mcpInstanceService) GetTools(ctx context.Context, instanceID primitive.
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tools: %w", err)
 	}
+	tools = append(tools, ContinueResultTool, NextPageTool)
 
 	s.toolCache[instanceID] = tools
 	return tools, nil
 }
 
 // ExecuteToolCall runs a specific tool with the given arguments.
-func (s *mcpInstanceService) ExecuteToolCall(ctx context.Context, instanceID primitive.ObjectID, toolName string, args map[string]interface{}) (interface{}, error) {
+// The follow-up tools for truncated and paginated results are available on every instance.
+func (s *mcpInstanceService) ExecuteToolCall(ctx context.Context, instanceID primitive.ObjectID, toolName string, args map[string]interface{}) (*models.ToolResult, error) {
 	mcpInstance, err := s.GetMCPInstance(ctx, instanceID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get MCP instance: %w", err)
 	}
 
+	switch toolName {
+	case ContinueResultToolName:
+		token, _ := args["continuation_token"].(string)
+		return s.toolProvider.ContinueResult(ctx, token)
+	case NextPageToolName:
+		token, _ := args["page_token"].(string)
+		return s.toolProvider.NextPage(ctx, token)
+	}
+
 	integrationId, err := primitive.ObjectIDFromHex(mcpInstance.IntegrationID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid integration ID: %w", err)
@@ -165,7 +187,7 @@ func (s *mcpInstanceService) ExecuteToolCall(ctx context.Context, instanceID pri
 			if err != nil {
 				return nil, fmt.Errorf("failed to search for tools: %w", err)
 			}
-			return tools, nil
+			return models.JSONResult(map[string]interface{}{"functions": tools}), nil
 		}
 
 		if toolName == aciExecuteFunctionTool.Name {
@@ -179,7 +201,17 @@ func (s *mcpInstanceService) ExecuteToolCall(ctx context.Context, instanceID pri
 				return nil, fmt.Errorf("missing or invalid function_arguments")
 			}
 
-			result, err := s.toolProvider.ExecuteTool(ctx, integrationId, funcName, funcArgs)
+			// Instances execute as their owner unless the caller names another linked account
+			// owner, which the instance owner must manage.
+			ownerID := mcpInstance.UserID
+			if overrideID, ok := args["override_linked_account_owner_id"].(string); ok && overrideID != "" {
+				if err := s.owners.AuthorizeOwner(ctx, mcpInstance.UserID, overrideID); err != nil {
+					return nil, err
+				}
+				ownerID = overrideID
+			}
+
+			result, err := s.toolProvider.ExecuteTool(ctx, integrationId, ownerID, funcName, funcArgs)
 			if err != nil {
 				return nil, fmt.Errorf("failed to execute tool: %w", err)
 			}
@@ -189,7 +221,7 @@ func (s *mcpInstanceService) ExecuteToolCall(ctx context.Context, instanceID pri
 		return nil, fmt.Errorf("unknown tool for unified server")
 	}
 
-	result, err := s.toolProvider.ExecuteTool(ctx, integrationId, toolName, args)
+	result, err := s.toolProvider.ExecuteTool(ctx, integrationId, mcpInstance.UserID, toolName, args)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute tool: %w", err)
 	}
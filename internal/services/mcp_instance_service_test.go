@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryMCPInstances keeps MCP instances in memory; unused methods panic.
+type memoryMCPInstances struct {
+	repositories.MCPInstanceRepository
+	instances map[primitive.ObjectID]*models.MCPInstance
+}
+
+func (r *memoryMCPInstances) GetByID(ctx context.Context, id primitive.ObjectID) (*models.MCPInstance, error) {
+	instance, ok := r.instances[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return instance, nil
+}
+
+// ownerRecordingToolProvider records the linked account owner of each executed tool; unused
+// methods panic.
+type ownerRecordingToolProvider struct {
+	ToolProvider
+	owners []string
+}
+
+func (p *ownerRecordingToolProvider) ExecuteTool(ctx context.Context, integrationID primitive.ObjectID, linkedAccountOwnerID string, toolName string, arguments map[string]interface{}) (*models.ToolResult, error) {
+	p.owners = append(p.owners, linkedAccountOwnerID)
+	return models.TextResult("ok"), nil
+}
+
+func TestExecuteToolCallOwnerOverride(t *testing.T) {
+	instance := &models.MCPInstance{ID: primitive.NewObjectID(), UserID: "alice", IntegrationID: primitive.NewObjectID().Hex(), ServerType: "unified"}
+	repo := &memoryMCPInstances{instances: map[primitive.ObjectID]*models.MCPInstance{instance.ID: instance}}
+	owners, _ := newTestLinkedAccounts()
+	provider := &ownerRecordingToolProvider{}
+	service := NewMCPInstanceService(repo, nil, provider, owners, nil)
+
+	tests := []struct {
+		name, override string
+		wantErr        error
+	}{
+		{"instance owner", "", nil},
+		{"managed owner", "customer-1", nil},
+		{"unmanaged owner", "customer-2", ErrOwnerNotManaged},
+		{"another platform user", "bob", ErrOwnerNotManaged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]interface{}{"function_name": "get_user", "function_arguments": map[string]interface{}{}}
+			if tt.override != "" {
+				args["override_linked_account_owner_id"] = tt.override
+			}
+			if _, err := service.ExecuteToolCall(context.Background(), instance.ID, aciExecuteFunctionTool.Name, args); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ExecuteToolCall() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if fmt.Sprint(provider.owners) != "[alice customer-1]" {
+		t.Fatalf("tools executed for %v, want [alice customer-1]", provider.owners)
+	}
+}
@@ -0,0 +1,446 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/openapi"
+	"github.com/AkashKesav/API2SDK/internal/outbound"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"github.com/AkashKesav/API2SDK/internal/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// OAuth2CallbackPath is the route the provider redirects back to after consent.
+	OAuth2CallbackPath = "/api/v1/oauth2/callback"
+
+	// oauthStateTTL bounds how long a user may take to complete the consent screen.
+	oauthStateTTL = 10 * time.Minute
+	// tokenRefreshLeeway refreshes access tokens this long before they expire.
+	tokenRefreshLeeway = time.Minute
+)
+
+var (
+	// ErrOAuth2NotConfigured is returned when an integration has no usable OAuth2 client config.
+	ErrOAuth2NotConfigured = errors.New("OAuth2 is not configured for this integration")
+	// ErrOAuth2InvalidState is returned when a callback's state is unknown, expired or already used.
+	ErrOAuth2InvalidState = errors.New("invalid or expired OAuth2 state")
+	// ErrOAuth2TokenExpired is returned when an access token expired and cannot be refreshed.
+	ErrOAuth2TokenExpired = errors.New("OAuth2 access token expired and no refresh token is available")
+)
+
+// OAuth2TokenRefresher returns a valid access token for an oauth2 linked account,
+// refreshing and persisting it first when it is about to expire.
+type OAuth2TokenRefresher interface {
+	AccessToken(ctx context.Context, integration *models.Integration, account *models.LinkedAccount) (string, error)
+}
+
+// OAuth2Service runs the OAuth2 authorization-code flow that links accounts to integrations.
+type OAuth2Service interface {
+	OAuth2TokenRefresher
+
+	// DeriveConfig reads the endpoints and scopes of an authorizationCode flow from the spec.
+	// schemeName selects the security scheme; when empty the first suitable one is used.
+	DeriveConfig(integration *models.Integration, schemeName string) (*models.IntegrationOAuth2Config, error)
+	// UpdateConfig stores the client credentials of an integration, filling endpoints from the spec.
+	UpdateConfig(ctx context.Context, integrationID primitive.ObjectID, req *models.UpdateOAuth2ConfigRequest) (*models.Integration, error)
+	// AuthorizationURL starts a flow for ownerID and returns the provider's consent URL.
+	AuthorizationURL(ctx context.Context, integrationID primitive.ObjectID, userID, ownerID, returnTo string) (string, error)
+	// HandleCallback exchanges the code for tokens and links them to the owner of the flow.
+	// It returns the linked account and the returnTo of the flow.
+	HandleCallback(ctx context.Context, state, code string) (*models.LinkedAccount, string, error)
+	// Revoke revokes the account's tokens with the provider and unlinks the account.
+	Revoke(ctx context.Context, account *models.LinkedAccount) error
+}
+
+// oauth2Service is the concrete implementation of OAuth2Service.
+type oauth2Service struct {
+	integrationService IntegrationService
+	accountRepo        repositories.LinkedAccountRepository
+	stateRepo          repositories.OAuthStateRepository
+	redirectURL        string
+	httpClient         *http.Client
+	logger             *zap.Logger
+
+	// refreshLocks serialises refreshes per linked account so a refresh token is used only once.
+	refreshLocks sync.Map // primitive.ObjectID -> *sync.Mutex
+}
+
+// NewOAuth2Service creates a new OAuth2Service. publicBaseURL is the externally
+// reachable origin of the server and is used to build the redirect URL; httpClient
+// is the shared outbound client used for token and revocation requests.
+func NewOAuth2Service(integrationService IntegrationService, accountRepo repositories.LinkedAccountRepository, stateRepo repositories.OAuthStateRepository, publicBaseURL string, httpClient *http.Client, logger *zap.Logger) OAuth2Service {
+	return &oauth2Service{
+		integrationService: integrationService,
+		accountRepo:        accountRepo,
+		stateRepo:          stateRepo,
+		redirectURL:        strings.TrimSuffix(publicBaseURL, "/") + OAuth2CallbackPath,
+		httpClient:         httpClient,
+		logger:             logger,
+	}
+}
+
+// DeriveConfig reads the endpoints and scopes of an authorizationCode flow from the spec.
+func (s *oauth2Service) DeriveConfig(integration *models.Integration, schemeName string) (*models.IntegrationOAuth2Config, error) {
+	if integration.OpenAPISpec == "" {
+		return nil, fmt.Errorf("integration %s has no OpenAPI spec", integration.Name)
+	}
+	doc, err := openapi.Parse([]byte(integration.OpenAPISpec))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec for integration %s: %w", integration.Name, err)
+	}
+
+	names := make([]string, 0, len(doc.SecuritySchemes))
+	for name, scheme := range doc.SecuritySchemes {
+		if !strings.EqualFold(scheme.Type, "oauth2") || scheme.Flows == nil || scheme.Flows.AuthorizationCode == nil {
+			continue
+		}
+		if schemeName == "" || name == schemeName {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		if schemeName != "" {
+			return nil, fmt.Errorf("security scheme %q is not an oauth2 authorizationCode scheme", schemeName)
+		}
+		return nil, fmt.Errorf("integration %s declares no oauth2 authorizationCode scheme", integration.Name)
+	}
+	sort.Strings(names)
+
+	flow := doc.SecuritySchemes[names[0]].Flows.AuthorizationCode
+	scopes := make([]string, 0, len(flow.Scopes))
+	for scope := range flow.Scopes {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+
+	return &models.IntegrationOAuth2Config{
+		SchemeName:       names[0],
+		AuthorizationURL: flow.AuthorizationURL,
+		TokenURL:         flow.TokenURL,
+		Scopes:           scopes,
+	}, nil
+}
+
+// UpdateConfig stores the client credentials of an integration, filling endpoints from the spec.
+func (s *oauth2Service) UpdateConfig(ctx context.Context, integrationID primitive.ObjectID, req *models.UpdateOAuth2ConfigRequest) (*models.Integration, error) {
+	integration, err := s.integrationService.GetIntegration(ctx, integrationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get integration: %w", err)
+	}
+
+	config, err := s.DeriveConfig(integration, req.SchemeName)
+	if err != nil {
+		// Endpoints given explicitly make the spec optional.
+		if req.AuthorizationURL == "" || req.TokenURL == "" {
+			return nil, err
+		}
+		config = &models.IntegrationOAuth2Config{SchemeName: req.SchemeName}
+	}
+
+	config.ClientID = req.ClientID
+	config.ClientSecret = types.EncryptedString(req.ClientSecret)
+	if req.ClientSecret == "" && integration.OAuth2 != nil {
+		config.ClientSecret = integration.OAuth2.ClientSecret
+	}
+	if req.AuthorizationURL != "" {
+		config.AuthorizationURL = req.AuthorizationURL
+	}
+	if req.TokenURL != "" {
+		config.TokenURL = req.TokenURL
+	}
+	config.RevocationURL = req.RevocationURL
+	if req.Scopes != nil {
+		config.Scopes = req.Scopes
+	}
+
+	for _, endpoint := range []string{config.AuthorizationURL, config.TokenURL, config.RevocationURL} {
+		if endpoint == "" {
+			continue
+		}
+		if u, err := url.Parse(endpoint); err != nil || !u.IsAbs() {
+			return nil, fmt.Errorf("invalid OAuth2 endpoint %q", endpoint)
+		}
+	}
+
+	integration.OAuth2 = config
+	updated, err := s.integrationService.UpdateIntegration(ctx, integrationID, integration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update integration: %w", err)
+	}
+
+	s.logger.Info("OAuth2 config updated",
+		zap.String("integrationID", integrationID.Hex()),
+		zap.String("scheme", config.SchemeName))
+	return updated, nil
+}
+
+// AuthorizationURL starts a flow for ownerID and returns the provider's consent URL.
+func (s *oauth2Service) AuthorizationURL(ctx context.Context, integrationID primitive.ObjectID, userID, ownerID, returnTo string) (string, error) {
+	integration, err := s.integrationService.GetIntegration(ctx, integrationID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get integration: %w", err)
+	}
+	config, err := s.clientConfig(integration)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	if ownerID == "" {
+		ownerID = userID
+	}
+	err = s.stateRepo.Create(ctx, &models.OAuthState{
+		State:         state,
+		IntegrationID: integrationID,
+		OwnerID:       ownerID,
+		UserID:        userID,
+		CodeVerifier:  types.EncryptedString(verifier),
+		ReturnTo:      returnTo,
+		ExpiresAt:     time.Now().Add(oauthStateTTL),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to store OAuth2 state: %w", err)
+	}
+
+	return config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// HandleCallback exchanges the code for tokens and links them to the owner of the flow.
+func (s *oauth2Service) HandleCallback(ctx context.Context, state, code string) (*models.LinkedAccount, string, error) {
+	pending, err := s.stateRepo.Consume(ctx, state)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, "", ErrOAuth2InvalidState
+		}
+		return nil, "", fmt.Errorf("failed to load OAuth2 state: %w", err)
+	}
+
+	integration, err := s.integrationService.GetIntegration(ctx, pending.IntegrationID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get integration: %w", err)
+	}
+	config, err := s.clientConfig(integration)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := config.Exchange(s.clientContext(ctx, integration), code, oauth2.VerifierOption(string(pending.CodeVerifier)))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	credentials := models.LinkedAccountCredentials{}
+	applyToken(&credentials, token)
+
+	now := time.Now()
+	account, err := s.accountRepo.GetByOwner(ctx, pending.IntegrationID, pending.OwnerID)
+	switch {
+	case err == nil:
+		// Re-linking replaces whatever credentials the owner had before.
+		account.AuthType = models.LinkedAccountAuthOAuth2
+		account.Enabled = true
+		account.Credentials = credentials
+		account.UpdatedAt = now
+		if err := s.accountRepo.Update(ctx, account); err != nil {
+			return nil, "", fmt.Errorf("failed to update linked account: %w", err)
+		}
+	case errors.Is(err, mongo.ErrNoDocuments):
+		account, err = s.accountRepo.Create(ctx, &models.LinkedAccount{
+			IntegrationID: pending.IntegrationID,
+			OwnerID:       pending.OwnerID,
+			UserID:        pending.UserID,
+			AuthType:      models.LinkedAccountAuthOAuth2,
+			Enabled:       true,
+			Credentials:   credentials,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to create linked account: %w", err)
+		}
+	default:
+		return nil, "", fmt.Errorf("failed to look up linked account: %w", err)
+	}
+
+	s.logger.Info("OAuth2 account linked",
+		zap.String("linkedAccountID", account.ID.Hex()),
+		zap.String("integrationID", pending.IntegrationID.Hex()),
+		zap.String("ownerID", pending.OwnerID))
+	return account, pending.ReturnTo, nil
+}
+
+// AccessToken returns a valid access token for an oauth2 linked account.
+func (s *oauth2Service) AccessToken(ctx context.Context, integration *models.Integration, account *models.LinkedAccount) (string, error) {
+	if !needsRefresh(account.Credentials.ExpiresAt) {
+		return string(account.Credentials.AccessToken), nil
+	}
+
+	lock, _ := s.refreshLocks.LoadOrStore(account.ID, &sync.Mutex{})
+	mu := lock.(*sync.Mutex)
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Another caller may have refreshed while we waited for the lock.
+	current, err := s.accountRepo.GetByID(ctx, account.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to reload linked account: %w", err)
+	}
+	if !needsRefresh(current.Credentials.ExpiresAt) {
+		return string(current.Credentials.AccessToken), nil
+	}
+
+	if current.Credentials.RefreshToken == "" {
+		if current.Credentials.ExpiresAt.After(time.Now()) {
+			return string(current.Credentials.AccessToken), nil
+		}
+		return "", ErrOAuth2TokenExpired
+	}
+
+	config, err := s.clientConfig(integration)
+	if err != nil {
+		return "", err
+	}
+	expired := &oauth2.Token{RefreshToken: string(current.Credentials.RefreshToken), Expiry: time.Now().Add(-time.Second)}
+	token, err := config.TokenSource(s.clientContext(ctx, integration), expired).Token()
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh OAuth2 token: %w", err)
+	}
+
+	applyToken(&current.Credentials, token)
+	current.UpdatedAt = time.Now()
+	if err := s.accountRepo.Update(ctx, current); err != nil {
+		// The new token is still good for this call; the next one refreshes again.
+		s.logger.Error("Failed to persist refreshed OAuth2 token", zap.String("linkedAccountID", current.ID.Hex()), zap.Error(err))
+	}
+
+	s.logger.Debug("OAuth2 token refreshed", zap.String("linkedAccountID", current.ID.Hex()))
+	return token.AccessToken, nil
+}
+
+// Revoke revokes the account's tokens with the provider (RFC 7009) and unlinks the account.
+// Revocation failures are logged; the account is unlinked regardless.
+func (s *oauth2Service) Revoke(ctx context.Context, account *models.LinkedAccount) error {
+	integration, err := s.integrationService.GetIntegration(ctx, account.IntegrationID)
+	if err == nil && integration.OAuth2 != nil && integration.OAuth2.RevocationURL != "" {
+		// Revoking the refresh token first also invalidates its access tokens at most providers.
+		tokens := []struct {
+			hint  string
+			value types.EncryptedString
+		}{
+			{"refresh_token", account.Credentials.RefreshToken},
+			{"access_token", account.Credentials.AccessToken},
+		}
+		for _, token := range tokens {
+			if token.value == "" {
+				continue
+			}
+			if err := s.revokeToken(outbound.WithIntegration(ctx, integration.Name), integration.OAuth2, string(token.value), token.hint); err != nil {
+				s.logger.Warn("Failed to revoke OAuth2 token",
+					zap.String("linkedAccountID", account.ID.Hex()),
+					zap.String("tokenType", token.hint),
+					zap.Error(err))
+			}
+		}
+	}
+
+	if err := s.accountRepo.Delete(ctx, account.ID); err != nil {
+		return fmt.Errorf("failed to delete linked account: %w", err)
+	}
+	s.refreshLocks.Delete(account.ID)
+
+	s.logger.Info("OAuth2 account revoked", zap.String("linkedAccountID", account.ID.Hex()))
+	return nil
+}
+
+func (s *oauth2Service) revokeToken(ctx context.Context, config *models.IntegrationOAuth2Config, token, hint string) error {
+	form := url.Values{"token": {token}, "token_type_hint": {hint}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.RevocationURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(config.ClientID), url.QueryEscape(string(config.ClientSecret)))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("revocation endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+// clientConfig builds the oauth2 client config of an integration.
+func (s *oauth2Service) clientConfig(integration *models.Integration) (*oauth2.Config, error) {
+	config := integration.OAuth2
+	if config == nil || config.ClientID == "" || config.AuthorizationURL == "" || config.TokenURL == "" {
+		return nil, ErrOAuth2NotConfigured
+	}
+	return &oauth2.Config{
+		ClientID:     config.ClientID,
+		ClientSecret: string(config.ClientSecret),
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  config.AuthorizationURL,
+			TokenURL: config.TokenURL,
+		},
+		RedirectURL: s.redirectURL,
+		Scopes:      config.Scopes,
+	}, nil
+}
+
+// clientContext makes the oauth2 package use the outbound client, labelled with the integration.
+func (s *oauth2Service) clientContext(ctx context.Context, integration *models.Integration) context.Context {
+	return context.WithValue(outbound.WithIntegration(ctx, integration.Name), oauth2.HTTPClient, s.httpClient)
+}
+
+// applyToken copies a token response onto stored credentials. Providers may omit the
+// refresh token on refresh, in which case the previous one stays valid.
+func applyToken(credentials *models.LinkedAccountCredentials, token *oauth2.Token) {
+	credentials.AccessToken = types.EncryptedString(token.AccessToken)
+	if token.RefreshToken != "" {
+		credentials.RefreshToken = types.EncryptedString(token.RefreshToken)
+	}
+	credentials.TokenType = token.Type()
+	credentials.ExpiresAt = nil
+	if !token.Expiry.IsZero() {
+		expiry := token.Expiry
+		credentials.ExpiresAt = &expiry
+	}
+}
+
+func needsRefresh(expiresAt *time.Time) bool {
+	return expiresAt != nil && time.Until(*expiresAt) < tokenRefreshLeeway
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// memoryOAuthStates keeps pending flows in memory.
+type memoryOAuthStates struct {
+	repositories.OAuthStateRepository
+	states map[string]*models.OAuthState
+}
+
+func (r *memoryOAuthStates) Create(ctx context.Context, state *models.OAuthState) error {
+	r.states[state.State] = state
+	return nil
+}
+
+func (r *memoryOAuthStates) Consume(ctx context.Context, state string) (*models.OAuthState, error) {
+	pending, ok := r.states[state]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	delete(r.states, state)
+	return pending, nil
+}
+
+// fakeOAuthProvider is a token endpoint that only grants code "granted" with the verifier of
+// the challenge it was sent on the consent screen.
+type fakeOAuthProvider struct {
+	challenge string
+}
+
+func (p *fakeOAuthProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.URL.Path != "/token" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if r.PostForm.Get("code") != "granted" || base64.RawURLEncoding.EncodeToString(sum[:]) != p.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token":  "access-1",
+		"refresh_token": "refresh-1",
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+	})
+}
+
+func TestOAuth2AuthorizationCodeFlow(t *testing.T) {
+	provider := &fakeOAuthProvider{}
+	server := httptest.NewServer(provider)
+	defer server.Close()
+
+	integration := &models.Integration{ID: primitive.NewObjectID(), Name: "crm", OAuth2: &models.IntegrationOAuth2Config{
+		ClientID:         "client-1",
+		AuthorizationURL: server.URL + "/authorize",
+		TokenURL:         server.URL + "/token",
+	}}
+	accounts := &memoryLinkedAccounts{}
+	states := &memoryOAuthStates{states: map[string]*models.OAuthState{}}
+	service := NewOAuth2Service(&memoryIntegrationService{integrations: []*models.Integration{integration}}, accounts, states, "https://app.example.com/", server.Client(), zap.NewNop())
+
+	start := func() url.Values {
+		t.Helper()
+		authURL, err := service.AuthorizationURL(context.Background(), integration.ID, "alice", "customer-1", "/done")
+		if err != nil {
+			t.Fatalf("AuthorizationURL() error = %v", err)
+		}
+		u, err := url.Parse(authURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u.Query()
+	}
+
+	query := start()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("consent URL query = %v, want an S256 code challenge", query)
+	}
+	if got := query.Get("redirect_uri"); got != "https://app.example.com"+OAuth2CallbackPath {
+		t.Fatalf("redirect_uri = %q", got)
+	}
+	if other := start(); other.Get("state") == query.Get("state") || other.Get("code_challenge") == query.Get("code_challenge") {
+		t.Fatal("two flows share a state or code challenge")
+	}
+	provider.challenge = query.Get("code_challenge")
+
+	if _, _, err := service.HandleCallback(context.Background(), "forged", "granted"); !errors.Is(err, ErrOAuth2InvalidState) {
+		t.Fatalf("callback with unknown state: error = %v, want %v", err, ErrOAuth2InvalidState)
+	}
+
+	account, returnTo, err := service.HandleCallback(context.Background(), query.Get("state"), "granted")
+	if err != nil {
+		t.Fatalf("HandleCallback() error = %v", err)
+	}
+	if returnTo != "/done" || account.OwnerID != "customer-1" || account.UserID != "alice" {
+		t.Fatalf("HandleCallback() = %+v, %q", account, returnTo)
+	}
+	if account.Credentials.AccessToken != "access-1" || account.Credentials.RefreshToken != "refresh-1" || account.Credentials.ExpiresAt == nil {
+		t.Fatalf("linked credentials = %+v", account.Credentials)
+	}
+
+	if _, _, err := service.HandleCallback(context.Background(), query.Get("state"), "granted"); !errors.Is(err, ErrOAuth2InvalidState) {
+		t.Fatalf("replayed callback: error = %v, want %v", err, ErrOAuth2InvalidState)
+	}
+}
+
+func TestOAuth2CallbackRejectsWrongVerifier(t *testing.T) {
+	provider := &fakeOAuthProvider{challenge: "challenge-of-another-flow"}
+	server := httptest.NewServer(provider)
+	defer server.Close()
+
+	integration := &models.Integration{ID: primitive.NewObjectID(), OAuth2: &models.IntegrationOAuth2Config{
+		ClientID:         "client-1",
+		AuthorizationURL: server.URL + "/authorize",
+		TokenURL:         server.URL + "/token",
+	}}
+	accounts := &memoryLinkedAccounts{}
+	states := &memoryOAuthStates{states: map[string]*models.OAuthState{}}
+	service := NewOAuth2Service(&memoryIntegrationService{integrations: []*models.Integration{integration}}, accounts, states, "https://app.example.com", server.Client(), zap.NewNop())
+
+	authURL, err := service.AuthorizationURL(context.Background(), integration.ID, "alice", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	if _, _, err := service.HandleCallback(context.Background(), u.Query().Get("state"), "granted"); err == nil {
+		t.Fatal("HandleCallback() succeeded with a verifier that does not match the challenge")
+	}
+	if len(accounts.accounts) != 0 {
+		t.Fatalf("linked accounts = %d, want none", len(accounts.accounts))
+	}
+}
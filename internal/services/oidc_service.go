@@ -0,0 +1,718 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/auth"
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"github.com/AkashKesav/API2SDK/internal/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// OIDCCallbackPath is the route the provider redirects back to after sign-in.
+	OIDCCallbackPath = "/api/v1/auth/oidc/callback"
+
+	// oidcStateTTL bounds how long a user may take to sign in with the provider.
+	oidcStateTTL = 10 * time.Minute
+	// oidcDiscoveryTTL is how long discovery documents and key sets are cached.
+	oidcDiscoveryTTL = time.Hour
+	// oidcKeyRefreshInterval bounds how often a token signed with an unknown key makes the key
+	// set be fetched again, so forged key IDs cannot hammer the provider.
+	oidcKeyRefreshInterval = time.Minute
+	// oidcClockSkew is the leeway for the expiry and issue time of ID tokens.
+	oidcClockSkew = 2 * time.Minute
+	// maxOIDCDocumentSize bounds the discovery documents and key sets read from providers.
+	maxOIDCDocumentSize = 1 << 20
+
+	defaultOIDCGroupsClaim = "groups"
+)
+
+var (
+	// ErrOIDCNotConfigured is returned when single sign-on is disabled or not set up.
+	ErrOIDCNotConfigured = errors.New("single sign-on is not configured")
+	// ErrOIDCInvalidState is returned when a callback's state is unknown, expired or already used.
+	ErrOIDCInvalidState = errors.New("invalid or expired sign-on state")
+	// ErrOIDCInvalidIDToken is returned for ID tokens that are missing, badly signed, expired or meant for another client.
+	ErrOIDCInvalidIDToken = errors.New("invalid ID token")
+	// ErrOIDCAccessDenied is returned when the identity may not sign in to this installation.
+	ErrOIDCAccessDenied = errors.New("single sign-on is not allowed for this account")
+	// ErrInvalidOIDCSettings is returned for OIDC settings with invalid fields.
+	ErrInvalidOIDCSettings = errors.New("invalid OIDC settings")
+)
+
+// OIDCService signs users in with an OpenID Connect provider and manages its settings.
+type OIDCService interface {
+	// GetSettings returns the OIDC settings; they are empty until an administrator sets them.
+	GetSettings(ctx context.Context) (*models.OIDCSettings, error)
+	// UpdateSettings validates and stores the OIDC settings. Enabled settings must point at an
+	// issuer whose discovery document can be fetched.
+	UpdateSettings(ctx context.Context, req *models.UpdateOIDCSettingsRequest) (*models.OIDCSettings, error)
+	// AuthorizationURL starts a sign-on and returns the provider's sign-in URL.
+	AuthorizationURL(ctx context.Context, returnTo string) (string, error)
+	// HandleCallback exchanges the code for an ID token, provisions or updates the user it
+	// identifies and starts a session. It returns the returnTo of the sign-on.
+	HandleCallback(ctx context.Context, state, code string, client ClientInfo) (*AuthTokens, *models.User, string, error)
+}
+
+// oidcDiscovery is the part of a provider's discovery document that sign-on uses.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcProvider is the cached discovery document and key set of an issuer.
+type oidcProvider struct {
+	discovery     oidcDiscovery
+	keys          *auth.JSONWebKeySet
+	fetchedAt     time.Time
+	keysFetchedAt time.Time
+}
+
+// oidcService is the concrete implementation of OIDCService.
+type oidcService struct {
+	settings      PlatformSettingsService
+	stateRepo     repositories.OIDCStateRepository
+	userRepo      repositories.UserRepository
+	authService   AuthService
+	organizations OrganizationService
+	policy        PolicyService
+	audit         AuditService
+	redirectURL   string
+	httpClient    *http.Client
+	logger        *zap.Logger
+
+	mu        sync.Mutex
+	providers map[string]*oidcProvider
+}
+
+// NewOIDCService creates a new OIDCService. The provider redirects back to OIDCCallbackPath
+// under publicBaseURL, which must be registered with it.
+func NewOIDCService(settings PlatformSettingsService, stateRepo repositories.OIDCStateRepository, userRepo repositories.UserRepository, authService AuthService, organizations OrganizationService, policy PolicyService, audit AuditService, publicBaseURL string, httpClient *http.Client, logger *zap.Logger) OIDCService {
+	return &oidcService{
+		settings:      settings,
+		stateRepo:     stateRepo,
+		userRepo:      userRepo,
+		authService:   authService,
+		organizations: organizations,
+		policy:        policy,
+		audit:         audit,
+		redirectURL:   strings.TrimSuffix(publicBaseURL, "/") + OIDCCallbackPath,
+		httpClient:    httpClient,
+		logger:        logger,
+		providers:     map[string]*oidcProvider{},
+	}
+}
+
+// GetSettings returns the OIDC settings.
+func (s *oidcService) GetSettings(ctx context.Context) (*models.OIDCSettings, error) {
+	platformSettings, err := s.settings.GetPlatformSettingsStruct(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get platform settings: %w", err)
+	}
+	if platformSettings.OIDC == nil {
+		return &models.OIDCSettings{}, nil
+	}
+	return platformSettings.OIDC, nil
+}
+
+// UpdateSettings validates and stores the OIDC settings.
+func (s *oidcService) UpdateSettings(ctx context.Context, req *models.UpdateOIDCSettingsRequest) (*models.OIDCSettings, error) {
+	current, err := s.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &models.OIDCSettings{
+		Enabled:        req.Enabled,
+		IssuerURL:      strings.TrimSpace(req.IssuerURL),
+		ClientID:       strings.TrimSpace(req.ClientID),
+		ClientSecret:   current.ClientSecret,
+		Scopes:         req.Scopes,
+		GroupsClaim:    strings.TrimSpace(req.GroupsClaim),
+		AllowedDomains: req.AllowedDomains,
+		DefaultRole:    req.DefaultRole,
+		GroupMappings:  req.GroupMappings,
+	}
+	if req.ClientSecret != "" {
+		settings.ClientSecret = types.EncryptedString(req.ClientSecret)
+	}
+	for i, domain := range settings.AllowedDomains {
+		settings.AllowedDomains[i] = strings.ToLower(strings.TrimSpace(domain))
+	}
+	if err := s.validateSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	delete(s.providers, current.IssuerURL)
+	delete(s.providers, settings.IssuerURL)
+	s.mu.Unlock()
+	if settings.Enabled {
+		if _, err := s.provider(ctx, settings.IssuerURL); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidOIDCSettings, err)
+		}
+	}
+
+	updated, err := s.settings.UpdateOIDCSettings(ctx, settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store OIDC settings: %w", err)
+	}
+	s.logger.Info("OIDC settings updated", zap.Bool("enabled", settings.Enabled), zap.String("issuer", settings.IssuerURL))
+	return updated, nil
+}
+
+// validateSettings checks the fields of OIDC settings and that their mappings grant existing roles.
+func (s *oidcService) validateSettings(ctx context.Context, settings *models.OIDCSettings) error {
+	if settings.Enabled {
+		issuer, err := url.Parse(settings.IssuerURL)
+		if err != nil || issuer.Host == "" || (issuer.Scheme != "https" && !(issuer.Scheme == "http" && isLoopbackHost(issuer.Hostname()))) {
+			return fmt.Errorf("%w: issuerUrl must be an https URL", ErrInvalidOIDCSettings)
+		}
+		if settings.ClientID == "" {
+			return fmt.Errorf("%w: clientId is required", ErrInvalidOIDCSettings)
+		}
+	}
+	if settings.DefaultRole != "" && !settings.DefaultRole.IsValid() {
+		return fmt.Errorf("%w: unknown default role %q", ErrInvalidOIDCSettings, settings.DefaultRole)
+	}
+
+	for _, mapping := range settings.GroupMappings {
+		if mapping.Group == "" {
+			return fmt.Errorf("%w: every group mapping needs a group", ErrInvalidOIDCSettings)
+		}
+		if mapping.Role == "" && mapping.OrganizationID.IsZero() {
+			return fmt.Errorf("%w: group mapping %q grants nothing", ErrInvalidOIDCSettings, mapping.Group)
+		}
+		if mapping.Role != "" && !mapping.Role.IsValid() {
+			return fmt.Errorf("%w: unknown role %q", ErrInvalidOIDCSettings, mapping.Role)
+		}
+		if mapping.OrganizationID.IsZero() != (mapping.OrganizationRole == "") {
+			return fmt.Errorf("%w: group mapping %q needs both an organization and an organization role", ErrInvalidOIDCSettings, mapping.Group)
+		}
+		if mapping.OrganizationID.IsZero() {
+			continue
+		}
+		// Owners cannot be taken away again without risking an organization without owners
+		if mapping.OrganizationRole == models.OrganizationRoleOwner {
+			return fmt.Errorf("%w: groups cannot make owners", ErrInvalidOIDCSettings)
+		}
+		if _, err := s.policy.OrganizationRole(ctx, mapping.OrganizationID, mapping.OrganizationRole); errors.Is(err, ErrRoleNotFound) {
+			return fmt.Errorf("%w: unknown organization role %q", ErrInvalidOIDCSettings, mapping.OrganizationRole)
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AuthorizationURL starts a sign-on and returns the provider's sign-in URL.
+func (s *oidcService) AuthorizationURL(ctx context.Context, returnTo string) (string, error) {
+	settings, err := s.enabledSettings(ctx)
+	if err != nil {
+		return "", err
+	}
+	provider, err := s.provider(ctx, settings.IssuerURL)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	err = s.stateRepo.Create(ctx, &models.OIDCLoginState{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: types.EncryptedString(verifier),
+		ReturnTo:     returnTo,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to store sign-on state: %w", err)
+	}
+
+	config := s.clientConfig(settings, provider)
+	return config.AuthCodeURL(state, oauth2.SetAuthURLParam("nonce", nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// HandleCallback completes a sign-on.
+func (s *oidcService) HandleCallback(ctx context.Context, state, code string, client ClientInfo) (*AuthTokens, *models.User, string, error) {
+	pending, err := s.stateRepo.Consume(ctx, state)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil, "", ErrOIDCInvalidState
+		}
+		return nil, nil, "", fmt.Errorf("failed to load sign-on state: %w", err)
+	}
+	settings, err := s.enabledSettings(ctx)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	provider, err := s.provider(ctx, settings.IssuerURL)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	config := s.clientConfig(settings, provider)
+	token, err := config.Exchange(context.WithValue(ctx, oauth2.HTTPClient, s.httpClient), code, oauth2.VerifierOption(string(pending.CodeVerifier)))
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" {
+		return nil, nil, "", fmt.Errorf("%w: the token response has no ID token", ErrOIDCInvalidIDToken)
+	}
+
+	claims, err := s.verifyIDToken(ctx, settings, provider, rawIDToken, pending.Nonce)
+	if err != nil {
+		s.auditSSOLogin(ctx, nil, nil, err)
+		return nil, nil, "", err
+	}
+	user, err := s.provision(ctx, settings, provider.discovery.Issuer, claims)
+	if err != nil {
+		s.auditSSOLogin(ctx, claims, nil, err)
+		return nil, nil, "", err
+	}
+	organizationIDs, roles := organizationGroupRoles(settings, claims.groups)
+	if err := s.organizations.SyncSSOMemberships(ctx, user.ID, organizationIDs, roles); err != nil {
+		return nil, nil, "", fmt.Errorf("failed to apply group memberships: %w", err)
+	}
+
+	tokens, err := s.authService.StartSession(ctx, user, client)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	s.auditSSOLogin(ctx, claims, user, nil)
+	user.Password = ""
+	return tokens, user, pending.ReturnTo, nil
+}
+
+// auditSSOLogin records a sign-on; err is why it was refused. Only rejected ID tokens and denied
+// users are recorded as failures, not provider or database outages.
+func (s *oidcService) auditSSOLogin(ctx context.Context, claims *idTokenClaims, user *models.User, err error) {
+	event := &models.AuditEvent{Action: models.AuditActionSSOLogin, Metadata: map[string]string{}}
+	switch {
+	case err == nil:
+		event.ActorID = user.ID
+		event.TargetType = models.AuditTargetUser
+		event.TargetID = user.ID.Hex()
+	case errors.Is(err, ErrOIDCInvalidIDToken), errors.Is(err, ErrOIDCAccessDenied):
+		event.Outcome = models.AuditOutcomeFailure
+		event.Metadata["reason"] = err.Error()
+	default:
+		return
+	}
+	if claims != nil {
+		event.Metadata["issuer"] = claims.Issuer
+		event.Metadata["subject"] = claims.Subject
+		if claims.Email != "" {
+			event.Metadata["email"] = claims.Email
+		}
+	}
+	s.audit.Record(ctx, event)
+}
+
+// enabledSettings returns the OIDC settings, or ErrOIDCNotConfigured unless sign-on is enabled.
+func (s *oidcService) enabledSettings(ctx context.Context) (*models.OIDCSettings, error) {
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled || settings.IssuerURL == "" || settings.ClientID == "" {
+		return nil, ErrOIDCNotConfigured
+	}
+	return settings, nil
+}
+
+// clientConfig builds the oauth2 client config of the provider.
+func (s *oidcService) clientConfig(settings *models.OIDCSettings, provider *oidcProvider) *oauth2.Config {
+	scopes := []string{"openid", "email", "profile"}
+	if len(settings.Scopes) > 0 {
+		scopes = []string{"openid"}
+		for _, scope := range settings.Scopes {
+			if scope != "openid" {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return &oauth2.Config{
+		ClientID:     settings.ClientID,
+		ClientSecret: string(settings.ClientSecret),
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  provider.discovery.AuthorizationEndpoint,
+			TokenURL: provider.discovery.TokenEndpoint,
+		},
+		RedirectURL: s.redirectURL,
+		Scopes:      scopes,
+	}
+}
+
+// provider returns the discovery document and key set of the issuer, fetching them when they
+// are not cached or the cache is stale.
+func (s *oidcService) provider(ctx context.Context, issuer string) (*oidcProvider, error) {
+	s.mu.Lock()
+	cached, ok := s.providers[issuer]
+	s.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < oidcDiscoveryTTL {
+		return cached, nil
+	}
+
+	var discovery oidcDiscovery
+	if err := s.getJSON(ctx, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider: %w", err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, fmt.Errorf("discovery document is for issuer %q, not %q", discovery.Issuer, issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("discovery document lacks the authorization, token or JWKS endpoint")
+	}
+	keys, err := s.fetchKeys(ctx, discovery.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	provider := &oidcProvider{discovery: discovery, keys: keys, fetchedAt: now, keysFetchedAt: now}
+	s.mu.Lock()
+	s.providers[issuer] = provider
+	s.mu.Unlock()
+	return provider, nil
+}
+
+func (s *oidcService) fetchKeys(ctx context.Context, jwksURI string) (*auth.JSONWebKeySet, error) {
+	var keys auth.JSONWebKeySet
+	if err := s.getJSON(ctx, jwksURI, &keys); err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC signing keys: %w", err)
+	}
+	return &keys, nil
+}
+
+// getJSON fetches a JSON document of the provider.
+func (s *oidcService) getJSON(ctx context.Context, target string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", target, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxOIDCDocumentSize)).Decode(v)
+}
+
+// idTokenClaims are the claims of an ID token that sign-on uses.
+type idTokenClaims struct {
+	Issuer            string    `json:"iss"`
+	Subject           string    `json:"sub"`
+	Audience          audience  `json:"aud"`
+	AuthorizedParty   string    `json:"azp"`
+	ExpiresAt         float64   `json:"exp"`
+	IssuedAt          float64   `json:"iat"`
+	Nonce             string    `json:"nonce"`
+	Email             string    `json:"email"`
+	EmailVerified     claimBool `json:"email_verified"`
+	Name              string    `json:"name"`
+	PreferredUsername string    `json:"preferred_username"`
+	groups            []string
+}
+
+// verifyIDToken checks the signature and claims of an ID token (OpenID Connect Core 3.1.3.7).
+func (s *oidcService) verifyIDToken(ctx context.Context, settings *models.OIDCSettings, provider *oidcProvider, rawIDToken, nonce string) (*idTokenClaims, error) {
+	payload, err := auth.VerifySignature(rawIDToken, provider.keys)
+	if errors.Is(err, auth.ErrUnknownKey) && time.Since(provider.keysFetchedAt) > oidcKeyRefreshInterval {
+		// The provider may have rotated its keys since they were cached
+		keys, fetchErr := s.fetchKeys(ctx, provider.discovery.JWKSURI)
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		s.mu.Lock()
+		provider.keys, provider.keysFetchedAt = keys, time.Now()
+		s.mu.Unlock()
+		payload, err = auth.VerifySignature(rawIDToken, keys)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCInvalidIDToken, err)
+	}
+
+	var claims idTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCInvalidIDToken, err)
+	}
+	now := time.Now()
+	switch {
+	case claims.Issuer != provider.discovery.Issuer:
+		return nil, fmt.Errorf("%w: issued by %q", ErrOIDCInvalidIDToken, claims.Issuer)
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: no subject", ErrOIDCInvalidIDToken)
+	case !claims.Audience.contains(settings.ClientID):
+		return nil, fmt.Errorf("%w: not issued to this client", ErrOIDCInvalidIDToken)
+	case claims.AuthorizedParty != "" && claims.AuthorizedParty != settings.ClientID:
+		return nil, fmt.Errorf("%w: authorized party is %q", ErrOIDCInvalidIDToken, claims.AuthorizedParty)
+	case now.After(time.Unix(int64(claims.ExpiresAt), 0).Add(oidcClockSkew)):
+		return nil, fmt.Errorf("%w: expired", ErrOIDCInvalidIDToken)
+	case claims.IssuedAt != 0 && time.Unix(int64(claims.IssuedAt), 0).After(now.Add(oidcClockSkew)):
+		return nil, fmt.Errorf("%w: issued in the future", ErrOIDCInvalidIDToken)
+	case subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrOIDCInvalidIDToken)
+	}
+
+	groupsClaim := settings.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = defaultOIDCGroupsClaim
+	}
+	claims.groups = groupClaim(payload, groupsClaim)
+	return &claims, nil
+}
+
+// provision returns the user of the identity, creating or linking one on first sign-in, and
+// applies the platform role of their groups.
+func (s *oidcService) provision(ctx context.Context, settings *models.OIDCSettings, issuer string, claims *idTokenClaims) (*models.User, error) {
+	email := normalizeEmail(claims.Email)
+	if email == "" {
+		return nil, fmt.Errorf("%w: the provider did not share an email address", ErrOIDCAccessDenied)
+	}
+	if !emailDomainAllowed(email, settings.AllowedDomains) {
+		return nil, fmt.Errorf("%w: %s is not an allowed domain", ErrOIDCAccessDenied, email[strings.LastIndex(email, "@")+1:])
+	}
+	role, managed := platformGroupRole(settings, claims.groups)
+
+	user, err := s.userRepo.FindBySSOSubject(ctx, issuer, claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	if user == nil {
+		user, err = s.userRepo.FindByEmail(ctx, email)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up user: %w", err)
+		}
+		if user == nil {
+			return s.createUser(ctx, issuer, email, role, claims)
+		}
+		// Linking takes over the account, so the provider must vouch for the address
+		if !bool(claims.EmailVerified) || user.SSOSubject != "" {
+			return nil, fmt.Errorf("%w: %s is already registered", ErrOIDCAccessDenied, email)
+		}
+		user.SSOIssuer, user.SSOSubject = issuer, claims.Subject
+		if managed {
+			user.Role = role
+		}
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to link user: %w", err)
+		}
+		s.logger.Info("User linked to OIDC identity", zap.String("userID", user.ID.Hex()), zap.String("issuer", issuer))
+		return user, nil
+	}
+
+	if managed && user.Role != role {
+		s.logger.Info("Platform role changed by OIDC groups",
+			zap.String("userID", user.ID.Hex()),
+			zap.String("from", string(user.Role)),
+			zap.String("to", string(role)))
+		user.Role = role
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to update user role: %w", err)
+		}
+	}
+	return user, nil
+}
+
+// createUser provisions a user for an identity signing in for the first time.
+func (s *oidcService) createUser(ctx context.Context, issuer, email string, role models.UserRole, claims *idTokenClaims) (*models.User, error) {
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	// Without a password the user can only sign in through the provider
+	user := &models.User{
+		Name:       name,
+		Email:      email,
+		Role:       role,
+		SSOIssuer:  issuer,
+		SSOSubject: claims.Subject,
+	}
+	id, err := s.userRepo.Create(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	user.ID = id
+	s.logger.Info("User provisioned by OIDC", zap.String("userID", id.Hex()), zap.String("issuer", issuer), zap.String("role", string(role)))
+	return user, nil
+}
+
+// platformRoleRank orders platform roles from least to most privileged.
+var platformRoleRank = map[models.UserRole]int{
+	models.RoleUser:      0,
+	models.RoleModerator: 1,
+	models.RoleAdmin:     2,
+}
+
+// platformGroupRole returns the most privileged platform role the groups are mapped to, or the
+// default role. managed is false when no mapping grants platform roles, in which case roles of
+// existing users are left to administrators.
+func platformGroupRole(settings *models.OIDCSettings, groups []string) (role models.UserRole, managed bool) {
+	role = settings.DefaultRole
+	if role == "" {
+		role = models.RoleUser
+	}
+	matched := false
+	for _, mapping := range settings.GroupMappings {
+		if mapping.Role == "" {
+			continue
+		}
+		managed = true
+		if !containsString(groups, mapping.Group) {
+			continue
+		}
+		if !matched || platformRoleRank[mapping.Role] > platformRoleRank[role] {
+			role, matched = mapping.Role, true
+		}
+	}
+	return role, managed
+}
+
+// organizationGroupRoles returns the organizations with group mappings and, of those, the role
+// the groups give the user; the first matching mapping of an organization wins.
+func organizationGroupRoles(settings *models.OIDCSettings, groups []string) ([]primitive.ObjectID, map[primitive.ObjectID]models.OrganizationRole) {
+	var organizationIDs []primitive.ObjectID
+	roles := map[primitive.ObjectID]models.OrganizationRole{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, mapping := range settings.GroupMappings {
+		if mapping.OrganizationID.IsZero() {
+			continue
+		}
+		if !seen[mapping.OrganizationID] {
+			seen[mapping.OrganizationID] = true
+			organizationIDs = append(organizationIDs, mapping.OrganizationID)
+		}
+		if _, ok := roles[mapping.OrganizationID]; !ok && containsString(groups, mapping.Group) {
+			roles[mapping.OrganizationID] = mapping.OrganizationRole
+		}
+	}
+	return organizationIDs, roles
+}
+
+// groupClaim reads the groups of an ID token payload. The claim may be a dotted path into nested
+// objects, such as "realm_access.roles", and hold a list of groups or a single one.
+func groupClaim(payload []byte, claim string) []string {
+	var value interface{}
+	if err := json.Unmarshal(payload, &value); err != nil {
+		return nil
+	}
+	for _, key := range strings.Split(claim, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		groups := make([]string, 0, len(v))
+		for _, item := range v {
+			if group, ok := item.(string); ok {
+				groups = append(groups, group)
+			}
+		}
+		return groups
+	}
+	return nil
+}
+
+func emailDomainAllowed(email string, domains []string) bool {
+	if len(domains) == 0 {
+		return true
+	}
+	domain := email[strings.LastIndex(email, "@")+1:]
+	return containsString(domains, domain)
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// audience is the aud claim, which is a single string or a list of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) contains(clientID string) bool {
+	return containsString(a, clientID)
+}
+
+// claimBool is a boolean claim; some providers send email_verified as a string.
+type claimBool bool
+
+func (b *claimBool) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case bool:
+		*b = claimBool(v)
+	case string:
+		*b = claimBool(v == "true")
+	}
+	return nil
+}
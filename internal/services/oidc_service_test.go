@@ -0,0 +1,385 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/auth"
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+func (r *memoryUsers) FindBySSOSubject(ctx context.Context, issuer, subject string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.SSOIssuer == issuer && user.SSOSubject == subject {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryUsers) Create(ctx context.Context, user *models.User) (primitive.ObjectID, error) {
+	user.ID = primitive.NewObjectID()
+	copied := *user
+	r.users = append(r.users, &copied)
+	return user.ID, nil
+}
+
+func (r *memoryUsers) Update(ctx context.Context, user *models.User) error {
+	for i, existing := range r.users {
+		if existing.ID == user.ID {
+			copied := *user
+			r.users[i] = &copied
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+// memoryPlatformSettings keeps the platform settings in memory; unused methods panic.
+type memoryPlatformSettings struct {
+	PlatformSettingsService
+	settings models.PlatformSettings
+}
+
+func (s *memoryPlatformSettings) GetPlatformSettingsStruct(ctx context.Context) (*models.PlatformSettings, error) {
+	copied := s.settings
+	return &copied, nil
+}
+
+func (s *memoryPlatformSettings) UpdateOIDCSettings(ctx context.Context, settings *models.OIDCSettings) (*models.OIDCSettings, error) {
+	s.settings.OIDC = settings
+	return settings, nil
+}
+
+// memoryOIDCStates keeps pending sign-ons in memory.
+type memoryOIDCStates struct {
+	repositories.OIDCStateRepository
+	states map[string]*models.OIDCLoginState
+}
+
+func (r *memoryOIDCStates) Create(ctx context.Context, state *models.OIDCLoginState) error {
+	r.states[state.State] = state
+	return nil
+}
+
+func (r *memoryOIDCStates) Consume(ctx context.Context, state string) (*models.OIDCLoginState, error) {
+	pending, ok := r.states[state]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	delete(r.states, state)
+	return pending, nil
+}
+
+// fakeOIDCIssuer serves discovery, an RSA and an EC signing key, and a token endpoint that
+// returns idToken for code "granted" with the verifier of the challenge it was sent.
+type fakeOIDCIssuer struct {
+	server    *httptest.Server
+	rsaKey    *rsa.PrivateKey
+	ecKey     *ecdsa.PrivateKey
+	challenge string
+	idToken   string
+}
+
+func newFakeOIDCIssuer(t *testing.T) *fakeOIDCIssuer {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &fakeOIDCIssuer{rsaKey: rsaKey, ecKey: ecKey}
+	issuer.server = httptest.NewServer(issuer)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (p *fakeOIDCIssuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	case "/jwks":
+		encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+		_ = json.NewEncoder(w).Encode(auth.JSONWebKeySet{Keys: []auth.JSONWebKey{
+			{KeyType: "RSA", KeyID: "rsa-1", Use: "sig", Algorithm: "RS256", N: encode(p.rsaKey.N.Bytes()), E: encode(big.NewInt(int64(p.rsaKey.E)).Bytes())},
+			{KeyType: "EC", KeyID: "ec-1", Use: "sig", Curve: "P-256", X: encode(p.ecKey.X.FillBytes(make([]byte, 32))), Y: encode(p.ecKey.Y.FillBytes(make([]byte, 32)))},
+		}})
+	case "/token":
+		_ = r.ParseForm()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != "granted" || base64.RawURLEncoding.EncodeToString(sum[:]) != p.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-1",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     p.idToken,
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// sign returns the claims as a compact JWT signed with the algorithm and key ID.
+func (p *fakeOIDCIssuer) sign(t *testing.T, algorithm, keyID string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": algorithm, "kid": keyID, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	var err error
+	switch algorithm {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, p.rsaKey, crypto.SHA256, digest[:])
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, p.ecKey, digest[:])
+		if err == nil {
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	case "HS256":
+		// Signed with the RSA modulus as the shared secret, as in key confusion attacks
+		signature = digest[:]
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+type oidcTestEnv struct {
+	service       OIDCService
+	issuer        *fakeOIDCIssuer
+	users         *memoryUsers
+	organizations *memoryOrganizations
+	organization  primitive.ObjectID
+}
+
+func newTestOIDCService(t *testing.T) *oidcTestEnv {
+	t.Helper()
+	issuer := newFakeOIDCIssuer(t)
+	authService, _ := newTestAuthService(t)
+	users := authService.userRepository.(*memoryUsers)
+
+	organizations := newMemoryOrganizations()
+	policy := NewPolicyService(organizations, &memoryRoles{}, zap.NewNop())
+	organizationService := NewOrganizationService(organizations, policy, users, nil, nil, zap.NewNop())
+	owner := users.users[0]
+	organization, err := organizationService.CreateOrganization(context.Background(), owner.ID, &models.CreateOrganizationRequest{Name: "Engineering"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewOIDCService(&memoryPlatformSettings{}, &memoryOIDCStates{states: map[string]*models.OIDCLoginState{}}, users,
+		authService, organizationService, policy, authService.audit, "https://app.example.com", issuer.server.Client(), zap.NewNop())
+	_, err = service.UpdateSettings(context.Background(), &models.UpdateOIDCSettingsRequest{
+		Enabled:      true,
+		IssuerURL:    issuer.server.URL,
+		ClientID:     "client-1",
+		ClientSecret: "secret-1",
+		GroupMappings: []models.OIDCGroupMapping{
+			{Group: "platform-admins", Role: models.RoleAdmin},
+			{Group: "engineering", OrganizationID: organization.ID, OrganizationRole: models.OrganizationRoleMember},
+		},
+	})
+	if err != nil {
+		t.Fatalf("UpdateSettings() error = %v", err)
+	}
+	return &oidcTestEnv{service: service, issuer: issuer, users: users, organizations: organizations, organization: organization.ID}
+}
+
+// signIn runs a sign-on in which the provider issues an ID token with the default claims of
+// subject "carol-1" changed by edit, signed with algorithm.
+func (e *oidcTestEnv) signIn(t *testing.T, algorithm string, edit func(claims map[string]interface{})) (*AuthTokens, *models.User, error) {
+	t.Helper()
+	authURL, err := e.service.AuthorizationURL(context.Background(), "/app")
+	if err != nil {
+		t.Fatalf("AuthorizationURL() error = %v", err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("nonce") == "" || query.Get("scope") != "openid email profile" {
+		t.Fatalf("authorization URL query = %v", query)
+	}
+	e.issuer.challenge = query.Get("code_challenge")
+
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":            e.issuer.server.URL,
+		"sub":            "carol-1",
+		"aud":            []string{"client-1"},
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          query.Get("nonce"),
+		"email":          "Carol@Example.com",
+		"email_verified": true,
+		"name":           "Carol",
+		"groups":         []string{"engineering"},
+	}
+	if edit != nil {
+		edit(claims)
+	}
+	keyID := map[string]string{"RS256": "rsa-1", "ES256": "ec-1"}[algorithm]
+	e.issuer.idToken = e.issuer.sign(t, algorithm, keyID, claims)
+
+	tokens, user, returnTo, err := e.service.HandleCallback(context.Background(), query.Get("state"), "granted", ClientInfo{})
+	if err == nil && returnTo != "/app" {
+		t.Fatalf("returnTo = %q, want /app", returnTo)
+	}
+	return tokens, user, err
+}
+
+func TestOIDCProvisionsUsersAndMapsGroups(t *testing.T) {
+	env := newTestOIDCService(t)
+
+	tokens, user, err := env.signIn(t, "RS256", nil)
+	if err != nil {
+		t.Fatalf("first sign-in error = %v", err)
+	}
+	if tokens.AccessToken == "" || user.Email != "carol@example.com" || user.Name != "Carol" || user.Role != models.RoleUser {
+		t.Fatalf("provisioned user = %+v", user)
+	}
+	member, err := env.organizations.GetMember(context.Background(), env.organization, user.ID)
+	if err != nil || member.Role != models.OrganizationRoleMember || member.Source != models.MembershipSourceSSO {
+		t.Fatalf("membership = %+v, %v", member, err)
+	}
+
+	// Signing in again finds the same user; the groups now make them an admin outside engineering
+	_, again, err := env.signIn(t, "ES256", func(claims map[string]interface{}) {
+		claims["groups"] = []string{"platform-admins"}
+	})
+	if err != nil {
+		t.Fatalf("second sign-in error = %v", err)
+	}
+	if again.ID != user.ID || again.Role != models.RoleAdmin {
+		t.Fatalf("second sign-in user = %+v, want %s as admin", again, user.ID.Hex())
+	}
+	if _, err := env.organizations.GetMember(context.Background(), env.organization, user.ID); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("membership after leaving the group: error = %v, want it removed", err)
+	}
+}
+
+func TestOIDCRejectsInvalidIDTokens(t *testing.T) {
+	env := newTestOIDCService(t)
+
+	for _, tc := range []struct {
+		name      string
+		algorithm string
+		edit      func(claims map[string]interface{})
+	}{
+		{"other audience", "RS256", func(c map[string]interface{}) { c["aud"] = "client-2" }},
+		{"other issuer", "RS256", func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }},
+		{"replayed nonce", "RS256", func(c map[string]interface{}) { c["nonce"] = "replayed" }},
+		{"expired", "RS256", func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{"other authorized party", "RS256", func(c map[string]interface{}) { c["azp"] = "client-2" }},
+		{"symmetric algorithm", "HS256", nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := env.signIn(t, tc.algorithm, tc.edit); !errors.Is(err, ErrOIDCInvalidIDToken) {
+				t.Fatalf("error = %v, want %v", err, ErrOIDCInvalidIDToken)
+			}
+		})
+	}
+	if len(env.users.users) != 1 {
+		t.Fatalf("rejected sign-ins provisioned %d users", len(env.users.users)-1)
+	}
+
+	if _, _, _, err := env.service.HandleCallback(context.Background(), "forged", "granted", ClientInfo{}); !errors.Is(err, ErrOIDCInvalidState) {
+		t.Fatalf("callback with unknown state: error = %v, want %v", err, ErrOIDCInvalidState)
+	}
+}
+
+func TestOIDCLinksExistingUsersByVerifiedEmail(t *testing.T) {
+	env := newTestOIDCService(t)
+	alice := env.users.users[0]
+	asAlice := func(verified bool) func(claims map[string]interface{}) {
+		return func(claims map[string]interface{}) {
+			claims["sub"], claims["email"], claims["email_verified"] = "alice-1", "alice@example.com", verified
+		}
+	}
+
+	if _, _, err := env.signIn(t, "RS256", asAlice(false)); !errors.Is(err, ErrOIDCAccessDenied) {
+		t.Fatalf("unverified email: error = %v, want %v", err, ErrOIDCAccessDenied)
+	}
+	_, user, err := env.signIn(t, "RS256", asAlice(true))
+	if err != nil {
+		t.Fatalf("verified email: error = %v", err)
+	}
+	if user.ID != alice.ID || user.SSOSubject != "alice-1" {
+		t.Fatalf("linked user = %+v, want %s", user, alice.ID.Hex())
+	}
+	// Alice created the organization, so her ownership is not the groups' to take away
+	member, err := env.organizations.GetMember(context.Background(), env.organization, alice.ID)
+	if err != nil || member.Role != models.OrganizationRoleOwner {
+		t.Fatalf("owner membership = %+v, %v", member, err)
+	}
+}
+
+func TestOIDCSettingsValidation(t *testing.T) {
+	env := newTestOIDCService(t)
+	for _, req := range []*models.UpdateOIDCSettingsRequest{
+		{Enabled: true, IssuerURL: "http://idp.example.com", ClientID: "client-1"},
+		{Enabled: true, IssuerURL: env.issuer.server.URL},
+		{GroupMappings: []models.OIDCGroupMapping{{Group: "g", Role: "root"}}},
+		{GroupMappings: []models.OIDCGroupMapping{{Group: "g", OrganizationID: env.organization, OrganizationRole: models.OrganizationRoleOwner}}},
+		{GroupMappings: []models.OIDCGroupMapping{{Group: "g", OrganizationID: env.organization, OrganizationRole: "auditor"}}},
+	} {
+		if _, err := env.service.UpdateSettings(context.Background(), req); !errors.Is(err, ErrInvalidOIDCSettings) {
+			t.Errorf("UpdateSettings(%+v) error = %v, want %v", req, err, ErrInvalidOIDCSettings)
+		}
+	}
+
+	settings, err := env.service.GetSettings(context.Background())
+	if err != nil || settings.ClientSecret != "secret-1" {
+		t.Fatalf("GetSettings() = %+v, %v; rejected updates must keep the settings", settings, err)
+	}
+}
+
+func TestGroupClaim(t *testing.T) {
+	payload := []byte(`{"groups":"admins","realm_access":{"roles":["a","b"]}}`)
+	if got := groupClaim(payload, "groups"); len(got) != 1 || got[0] != "admins" {
+		t.Errorf("groupClaim(groups) = %v", got)
+	}
+	if got := groupClaim(payload, "realm_access.roles"); len(got) != 2 || got[1] != "b" {
+		t.Errorf("groupClaim(realm_access.roles) = %v", got)
+	}
+	if got := groupClaim(payload, "missing.path"); got != nil {
+		t.Errorf("groupClaim(missing.path) = %v, want none", got)
+	}
+}
@@ -0,0 +1,612 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	maxOrganizationNameLength = 100
+	// invitationTTL is how long an invitation can be accepted.
+	invitationTTL = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidOrganizationRequest is returned for organization requests with invalid fields.
+	ErrInvalidOrganizationRequest = errors.New("invalid organization request")
+	// ErrOrganizationNotFound is returned for organizations the user is not a member of.
+	ErrOrganizationNotFound = errors.New("organization not found")
+	// ErrPermissionDenied is returned when the role of the user does not permit an action.
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrMemberNotFound is returned when the organization has no such member.
+	ErrMemberNotFound = errors.New("member not found")
+	// ErrLastOwner is returned when a change would leave an organization without an owner.
+	ErrLastOwner = errors.New("an organization must keep at least one owner")
+	// ErrInvitationNotFound is returned for unknown, accepted, revoked or expired invitations.
+	ErrInvitationNotFound = errors.New("invitation not found")
+)
+
+// ResourceAuthorizer decides what users may do with collections, SDKs and integrations,
+// which are owned either by the user who created them or by an organization.
+type ResourceAuthorizer interface {
+	// Authorize returns ErrPermissionDenied unless the user may perform the action on a resource
+	// created by ownerID and, when organizationID is set, owned by that organization. Personal
+	// resources are only accessible to their creator; in an organization the member's role
+	// decides, and members who may write may also delete what they created.
+	Authorize(ctx context.Context, userID, ownerID, organizationID string, resource models.ResourceKind, action models.Action) error
+	// OrganizationIDs returns the organizations the user is a member of.
+	OrganizationIDs(ctx context.Context, userID string) ([]string, error)
+}
+
+// OrganizationService manages organizations, their members and invitations, and authorizes
+// access to the resources they own.
+type OrganizationService interface {
+	ResourceAuthorizer
+	// CreateOrganization creates an organization with the user as its owner.
+	CreateOrganization(ctx context.Context, userID primitive.ObjectID, req *models.CreateOrganizationRequest) (*models.OrganizationMembership, error)
+	ListOrganizations(ctx context.Context, userID primitive.ObjectID) ([]*models.OrganizationMembership, error)
+	GetOrganization(ctx context.Context, userID, organizationID primitive.ObjectID) (*models.OrganizationMembership, error)
+	RenameOrganization(ctx context.Context, userID, organizationID primitive.ObjectID, req *models.CreateOrganizationRequest) (*models.Organization, error)
+	// DeleteOrganization deletes an organization; its collections and SDKs return to the
+	// members who created them.
+	DeleteOrganization(ctx context.Context, userID, organizationID primitive.ObjectID) error
+
+	ListMembers(ctx context.Context, userID, organizationID primitive.ObjectID) ([]*models.OrganizationMember, error)
+	// UpdateMemberRole changes the role of a member to a built-in or custom role; only owners
+	// may appoint or demote owners.
+	UpdateMemberRole(ctx context.Context, userID, organizationID, memberID primitive.ObjectID, role models.OrganizationRole) error
+	// RemoveMember removes a member, or lets the user leave when memberID is the user.
+	RemoveMember(ctx context.Context, userID, organizationID, memberID primitive.ObjectID) error
+
+	// CreateInvitation invites an email address. The returned token is not stored; the
+	// invitee accepts the invitation with it.
+	CreateInvitation(ctx context.Context, userID, organizationID primitive.ObjectID, req *models.CreateInvitationRequest) (*models.CreatedInvitation, error)
+	ListInvitations(ctx context.Context, userID, organizationID primitive.ObjectID) ([]*models.OrganizationInvitation, error)
+	RevokeInvitation(ctx context.Context, userID, organizationID, invitationID primitive.ObjectID) error
+	// AcceptInvitation adds the user to the organization of an invitation sent to their email address.
+	AcceptInvitation(ctx context.Context, userID primitive.ObjectID, token string) (*models.OrganizationMembership, error)
+
+	// SyncSSOMemberships makes the user's memberships in the organizations follow their OIDC
+	// groups: the user joins with, or changes to, the role in roles, and leaves organizations
+	// without one. Memberships that were not granted through SSO, and owners, are left alone.
+	SyncSSOMemberships(ctx context.Context, userID primitive.ObjectID, organizationIDs []primitive.ObjectID, roles map[primitive.ObjectID]models.OrganizationRole) error
+}
+
+type organizationService struct {
+	repo           repositories.OrganizationRepository
+	policy         PolicyService
+	userRepo       repositories.UserRepository
+	collectionRepo *repositories.CollectionRepository
+	sdkRepo        repositories.SDKRepositoryInterface
+	logger         *zap.Logger
+}
+
+// NewOrganizationService creates a new OrganizationService.
+func NewOrganizationService(repo repositories.OrganizationRepository, policy PolicyService, userRepo repositories.UserRepository, collectionRepo *repositories.CollectionRepository, sdkRepo repositories.SDKRepositoryInterface, logger *zap.Logger) OrganizationService {
+	return &organizationService{
+		repo:           repo,
+		policy:         policy,
+		userRepo:       userRepo,
+		collectionRepo: collectionRepo,
+		sdkRepo:        sdkRepo,
+		logger:         logger,
+	}
+}
+
+// Authorize checks that the user may perform the action on a resource.
+func (s *organizationService) Authorize(ctx context.Context, userID, ownerID, organizationID string, resource models.ResourceKind, action models.Action) error {
+	if organizationID == "" {
+		if userID != "" && userID == ownerID {
+			return nil
+		}
+		return ErrPermissionDenied
+	}
+
+	orgID, err := primitive.ObjectIDFromHex(organizationID)
+	if err != nil {
+		return ErrPermissionDenied
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrPermissionDenied
+	}
+	decision, err := s.policy.CheckOrganization(ctx, uid, orgID, models.NewPermission(resource, action))
+	if err != nil {
+		return err
+	}
+	if decision.Allowed {
+		return nil
+	}
+	if userID == ownerID && action == models.ActionDelete {
+		decision, err = s.policy.CheckOrganization(ctx, uid, orgID, models.NewPermission(resource, models.ActionWrite))
+		if err != nil {
+			return err
+		}
+		if decision.Allowed {
+			return nil
+		}
+	}
+	return ErrPermissionDenied
+}
+
+// OrganizationIDs returns the organizations the user is a member of.
+func (s *organizationService) OrganizationIDs(ctx context.Context, userID string) ([]string, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return []string{}, nil
+	}
+	memberships, err := s.repo.ListMembershipsByUser(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization memberships: %w", err)
+	}
+	ids := make([]string, 0, len(memberships))
+	for _, membership := range memberships {
+		ids = append(ids, membership.OrganizationID.Hex())
+	}
+	return ids, nil
+}
+
+// CreateOrganization creates an organization owned by the user.
+func (s *organizationService) CreateOrganization(ctx context.Context, userID primitive.ObjectID, req *models.CreateOrganizationRequest) (*models.OrganizationMembership, error) {
+	name, err := validateOrganizationName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	organization := &models.Organization{Name: name, CreatedBy: userID}
+	if err := s.repo.Create(ctx, organization); err != nil {
+		return nil, fmt.Errorf("failed to store organization: %w", err)
+	}
+	owner := &models.OrganizationMember{OrganizationID: organization.ID, UserID: userID, Role: models.OrganizationRoleOwner}
+	if err := s.repo.AddMember(ctx, owner); err != nil {
+		// Without its owner nobody could reach the organization again.
+		_ = s.repo.Delete(ctx, organization.ID)
+		return nil, fmt.Errorf("failed to add organization owner: %w", err)
+	}
+
+	s.logger.Info("Organization created", zap.String("organizationID", organization.ID.Hex()), zap.String("userID", userID.Hex()))
+	return &models.OrganizationMembership{Organization: organization, Role: owner.Role}, nil
+}
+
+// ListOrganizations returns the organizations of the user with the user's role in each.
+func (s *organizationService) ListOrganizations(ctx context.Context, userID primitive.ObjectID) ([]*models.OrganizationMembership, error) {
+	memberships, err := s.repo.ListMembershipsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization memberships: %w", err)
+	}
+	roles := make(map[primitive.ObjectID]models.OrganizationRole, len(memberships))
+	ids := make([]primitive.ObjectID, 0, len(memberships))
+	for _, membership := range memberships {
+		roles[membership.OrganizationID] = membership.Role
+		ids = append(ids, membership.OrganizationID)
+	}
+
+	organizations, err := s.repo.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	result := make([]*models.OrganizationMembership, 0, len(organizations))
+	for _, organization := range organizations {
+		result = append(result, &models.OrganizationMembership{Organization: organization, Role: roles[organization.ID]})
+	}
+	return result, nil
+}
+
+// GetOrganization returns an organization of the user.
+func (s *organizationService) GetOrganization(ctx context.Context, userID, organizationID primitive.ObjectID) (*models.OrganizationMembership, error) {
+	member, err := s.member(ctx, organizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	organization, err := s.repo.GetByID(ctx, organizationID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return &models.OrganizationMembership{Organization: organization, Role: member.Role}, nil
+}
+
+// RenameOrganization changes the name of an organization.
+func (s *organizationService) RenameOrganization(ctx context.Context, userID, organizationID primitive.ObjectID, req *models.CreateOrganizationRequest) (*models.Organization, error) {
+	name, err := validateOrganizationName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.require(ctx, userID, organizationID, models.PermissionOrganizationManage); err != nil {
+		return nil, err
+	}
+	err = s.repo.Rename(ctx, organizationID, name)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename organization: %w", err)
+	}
+	return s.repo.GetByID(ctx, organizationID)
+}
+
+// DeleteOrganization deletes an organization after returning its resources to their creators.
+func (s *organizationService) DeleteOrganization(ctx context.Context, userID, organizationID primitive.ObjectID) error {
+	if _, err := s.require(ctx, userID, organizationID, models.PermissionOrganizationManage); err != nil {
+		return err
+	}
+	if err := s.collectionRepo.ClearOrganization(ctx, organizationID.Hex()); err != nil {
+		return fmt.Errorf("failed to release organization collections: %w", err)
+	}
+	if err := s.sdkRepo.ClearOrganization(ctx, organizationID.Hex()); err != nil {
+		return fmt.Errorf("failed to release organization SDKs: %w", err)
+	}
+	if err := s.repo.Delete(ctx, organizationID); err != nil {
+		return fmt.Errorf("failed to delete organization: %w", err)
+	}
+	s.logger.Info("Organization deleted", zap.String("organizationID", organizationID.Hex()), zap.String("userID", userID.Hex()))
+	return nil
+}
+
+// ListMembers returns the members of an organization with their names and email addresses.
+func (s *organizationService) ListMembers(ctx context.Context, userID, organizationID primitive.ObjectID) ([]*models.OrganizationMember, error) {
+	if _, err := s.member(ctx, organizationID, userID); err != nil {
+		return nil, err
+	}
+	members, err := s.repo.ListMembers(ctx, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	for _, member := range members {
+		if user, err := s.userRepo.FindByID(ctx, member.UserID); err == nil && user != nil {
+			member.Name = user.Name
+			member.Email = user.Email
+		}
+	}
+	return members, nil
+}
+
+// UpdateMemberRole changes the role of a member.
+func (s *organizationService) UpdateMemberRole(ctx context.Context, userID, organizationID, memberID primitive.ObjectID, role models.OrganizationRole) error {
+	actor, err := s.require(ctx, userID, organizationID, models.PermissionOrganizationManageMembers)
+	if err != nil {
+		return err
+	}
+	if err := s.validRole(ctx, organizationID, role); err != nil {
+		return err
+	}
+	target, err := s.repo.GetMember(ctx, organizationID, memberID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrMemberNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get member: %w", err)
+	}
+	if target.Role == role {
+		return nil
+	}
+	if role == models.OrganizationRoleOwner || target.Role == models.OrganizationRoleOwner {
+		if err := s.permits(ctx, actor, models.PermissionOrganizationManage); err != nil {
+			return err
+		}
+	}
+	if target.Role == models.OrganizationRoleOwner {
+		if err := s.keepOwner(ctx, organizationID); err != nil {
+			return err
+		}
+	}
+
+	err = s.repo.UpdateMemberRole(ctx, organizationID, memberID, role)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrMemberNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update member role: %w", err)
+	}
+	s.logger.Info("Organization member role changed",
+		zap.String("organizationID", organizationID.Hex()),
+		zap.String("memberID", memberID.Hex()),
+		zap.String("role", string(role)),
+		zap.String("userID", userID.Hex()))
+	return nil
+}
+
+// RemoveMember removes a member from an organization.
+func (s *organizationService) RemoveMember(ctx context.Context, userID, organizationID, memberID primitive.ObjectID) error {
+	actor, err := s.member(ctx, organizationID, userID)
+	if err != nil {
+		return err
+	}
+	target := actor
+	if memberID != userID {
+		if err := s.permits(ctx, actor, models.PermissionOrganizationManageMembers); err != nil {
+			return err
+		}
+		target, err = s.repo.GetMember(ctx, organizationID, memberID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrMemberNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get member: %w", err)
+		}
+		if target.Role == models.OrganizationRoleOwner {
+			if err := s.permits(ctx, actor, models.PermissionOrganizationManage); err != nil {
+				return err
+			}
+		}
+	}
+	if target.Role == models.OrganizationRoleOwner {
+		if err := s.keepOwner(ctx, organizationID); err != nil {
+			return err
+		}
+	}
+
+	err = s.repo.RemoveMember(ctx, organizationID, memberID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrMemberNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+	s.logger.Info("Organization member removed",
+		zap.String("organizationID", organizationID.Hex()),
+		zap.String("memberID", memberID.Hex()),
+		zap.String("userID", userID.Hex()))
+	return nil
+}
+
+// CreateInvitation invites an email address to the organization.
+func (s *organizationService) CreateInvitation(ctx context.Context, userID, organizationID primitive.ObjectID, req *models.CreateInvitationRequest) (*models.CreatedInvitation, error) {
+	email := normalizeEmail(req.Email)
+	if !strings.Contains(email, "@") {
+		return nil, fmt.Errorf("%w: a valid email address is required", ErrInvalidOrganizationRequest)
+	}
+	role := req.Role
+	if role == "" {
+		role = models.OrganizationRoleMember
+	}
+	actor, err := s.require(ctx, userID, organizationID, models.PermissionOrganizationManageMembers)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validRole(ctx, organizationID, role); err != nil {
+		return nil, err
+	}
+	if role == models.OrganizationRoleOwner {
+		if err := s.permits(ctx, actor, models.PermissionOrganizationManage); err != nil {
+			return nil, err
+		}
+	}
+	if user, err := s.userRepo.FindByEmail(ctx, email); err == nil && user != nil {
+		if _, err := s.repo.GetMember(ctx, organizationID, user.ID); err == nil {
+			return nil, fmt.Errorf("%w: %s is already a member", ErrInvalidOrganizationRequest, email)
+		}
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+	invitation := &models.OrganizationInvitation{
+		OrganizationID: organizationID,
+		Email:          email,
+		Role:           role,
+		TokenHash:      hashToken(token),
+		InvitedBy:      userID,
+		ExpiresAt:      time.Now().Add(invitationTTL),
+	}
+	if err := s.repo.CreateInvitation(ctx, invitation); err != nil {
+		return nil, fmt.Errorf("failed to store invitation: %w", err)
+	}
+
+	s.logger.Info("Organization invitation created",
+		zap.String("organizationID", organizationID.Hex()),
+		zap.String("invitationID", invitation.ID.Hex()),
+		zap.String("role", string(role)),
+		zap.String("userID", userID.Hex()))
+	return &models.CreatedInvitation{OrganizationInvitation: invitation, Token: token}, nil
+}
+
+// ListInvitations returns the pending invitations of an organization.
+func (s *organizationService) ListInvitations(ctx context.Context, userID, organizationID primitive.ObjectID) ([]*models.OrganizationInvitation, error) {
+	if _, err := s.require(ctx, userID, organizationID, models.PermissionOrganizationManageMembers); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPendingInvitations(ctx, organizationID)
+}
+
+// RevokeInvitation deletes a pending invitation.
+func (s *organizationService) RevokeInvitation(ctx context.Context, userID, organizationID, invitationID primitive.ObjectID) error {
+	if _, err := s.require(ctx, userID, organizationID, models.PermissionOrganizationManageMembers); err != nil {
+		return err
+	}
+	err := s.repo.DeleteInvitation(ctx, organizationID, invitationID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrInvitationNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+	return nil
+}
+
+// AcceptInvitation adds the user to the organization of the invitation.
+func (s *organizationService) AcceptInvitation(ctx context.Context, userID primitive.ObjectID, token string) (*models.OrganizationMembership, error) {
+	if token == "" {
+		return nil, ErrInvitationNotFound
+	}
+	invitation, err := s.repo.GetInvitationByHash(ctx, hashToken(token))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvitationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	if time.Now().After(invitation.ExpiresAt) {
+		return nil, ErrInvitationNotFound
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrInvitationNotFound
+	}
+	// The token alone is not enough: it may have been forwarded or leaked.
+	if normalizeEmail(user.Email) != invitation.Email {
+		return nil, ErrInvitationNotFound
+	}
+
+	organization, err := s.repo.GetByID(ctx, invitation.OrganizationID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvitationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	if err := s.repo.AcceptInvitation(ctx, invitation.ID, userID, time.Now()); errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvitationNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	if existing, err := s.repo.GetMember(ctx, organization.ID, userID); err == nil {
+		return &models.OrganizationMembership{Organization: organization, Role: existing.Role}, nil
+	}
+	member := &models.OrganizationMember{OrganizationID: organization.ID, UserID: userID, Role: invitation.Role}
+	if err := s.repo.AddMember(ctx, member); err != nil {
+		return nil, fmt.Errorf("failed to add member: %w", err)
+	}
+
+	s.logger.Info("Organization invitation accepted",
+		zap.String("organizationID", organization.ID.Hex()),
+		zap.String("invitationID", invitation.ID.Hex()),
+		zap.String("userID", userID.Hex()))
+	return &models.OrganizationMembership{Organization: organization, Role: member.Role}, nil
+}
+
+// SyncSSOMemberships applies the organization roles of the user's OIDC groups.
+func (s *organizationService) SyncSSOMemberships(ctx context.Context, userID primitive.ObjectID, organizationIDs []primitive.ObjectID, roles map[primitive.ObjectID]models.OrganizationRole) error {
+	for _, organizationID := range organizationIDs {
+		role, granted := roles[organizationID]
+		member, err := s.repo.GetMember(ctx, organizationID, userID)
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			if !granted {
+				continue
+			}
+			if _, err := s.repo.GetByID(ctx, organizationID); errors.Is(err, mongo.ErrNoDocuments) {
+				// The organization was deleted after it was mapped
+				continue
+			} else if err != nil {
+				return fmt.Errorf("failed to get organization: %w", err)
+			}
+			err = s.repo.AddMember(ctx, &models.OrganizationMember{
+				OrganizationID: organizationID,
+				UserID:         userID,
+				Role:           role,
+				Source:         models.MembershipSourceSSO,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to add member: %w", err)
+			}
+			s.logger.Info("SSO group membership granted",
+				zap.String("organizationID", organizationID.Hex()),
+				zap.String("userID", userID.Hex()),
+				zap.String("role", string(role)))
+		case err != nil:
+			return fmt.Errorf("failed to get member: %w", err)
+		case member.Source != models.MembershipSourceSSO || member.Role == models.OrganizationRoleOwner:
+			continue
+		case !granted:
+			if err := s.repo.RemoveMember(ctx, organizationID, userID); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				return fmt.Errorf("failed to remove member: %w", err)
+			}
+			s.logger.Info("SSO group membership revoked",
+				zap.String("organizationID", organizationID.Hex()),
+				zap.String("userID", userID.Hex()))
+		case member.Role != role:
+			if err := s.repo.UpdateMemberRole(ctx, organizationID, userID, role); err != nil {
+				return fmt.Errorf("failed to update member role: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// member returns the membership of the user, or ErrOrganizationNotFound when there is none.
+func (s *organizationService) member(ctx context.Context, organizationID, userID primitive.ObjectID) (*models.OrganizationMember, error) {
+	member, err := s.repo.GetMember(ctx, organizationID, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization membership: %w", err)
+	}
+	return member, nil
+}
+
+// require returns the membership of the user when their role grants the permission.
+func (s *organizationService) require(ctx context.Context, userID, organizationID primitive.ObjectID, permission models.Permission) (*models.OrganizationMember, error) {
+	member, err := s.member(ctx, organizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.permits(ctx, member, permission); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// permits returns ErrPermissionDenied unless the role of the member grants the permission.
+func (s *organizationService) permits(ctx context.Context, member *models.OrganizationMember, permission models.Permission) error {
+	role, err := s.policy.OrganizationRole(ctx, member.OrganizationID, member.Role)
+	if errors.Is(err, ErrRoleNotFound) {
+		return ErrPermissionDenied
+	}
+	if err != nil {
+		return err
+	}
+	if _, ok := role.Grants(permission); !ok {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// validRole returns ErrInvalidOrganizationRequest unless the role is a built-in role or a custom role of the organization.
+func (s *organizationService) validRole(ctx context.Context, organizationID primitive.ObjectID, role models.OrganizationRole) error {
+	_, err := s.policy.OrganizationRole(ctx, organizationID, role)
+	if errors.Is(err, ErrRoleNotFound) {
+		return fmt.Errorf("%w: unknown role %q", ErrInvalidOrganizationRequest, role)
+	}
+	return err
+}
+
+// keepOwner returns ErrLastOwner when the organization has a single owner.
+func (s *organizationService) keepOwner(ctx context.Context, organizationID primitive.ObjectID) error {
+	owners, err := s.repo.CountMembersWithRole(ctx, organizationID, models.OrganizationRoleOwner)
+	if err != nil {
+		return fmt.Errorf("failed to count owners: %w", err)
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+func validateOrganizationName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("%w: name is required", ErrInvalidOrganizationRequest)
+	}
+	if len(name) > maxOrganizationNameLength {
+		return "", fmt.Errorf("%w: name must be at most %d characters", ErrInvalidOrganizationRequest, maxOrganizationNameLength)
+	}
+	return name, nil
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// memoryOrganizations keeps organizations, members and invitations in memory; unused methods panic.
+type memoryOrganizations struct {
+	repositories.OrganizationRepository
+	organizations map[primitive.ObjectID]*models.Organization
+	members       []*models.OrganizationMember
+	invitations   []*models.OrganizationInvitation
+}
+
+func newMemoryOrganizations() *memoryOrganizations {
+	return &memoryOrganizations{organizations: map[primitive.ObjectID]*models.Organization{}}
+}
+
+func (r *memoryOrganizations) Create(ctx context.Context, organization *models.Organization) error {
+	organization.ID = primitive.NewObjectID()
+	r.organizations[organization.ID] = organization
+	return nil
+}
+
+func (r *memoryOrganizations) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Organization, error) {
+	organization, ok := r.organizations[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return organization, nil
+}
+
+func (r *memoryOrganizations) AddMember(ctx context.Context, member *models.OrganizationMember) error {
+	member.ID = primitive.NewObjectID()
+	copied := *member
+	r.members = append(r.members, &copied)
+	return nil
+}
+
+func (r *memoryOrganizations) GetMember(ctx context.Context, organizationID, userID primitive.ObjectID) (*models.OrganizationMember, error) {
+	for _, member := range r.members {
+		if member.OrganizationID == organizationID && member.UserID == userID {
+			copied := *member
+			return &copied, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *memoryOrganizations) ListMembershipsByUser(ctx context.Context, userID primitive.ObjectID) ([]*models.OrganizationMember, error) {
+	var memberships []*models.OrganizationMember
+	for _, member := range r.members {
+		if member.UserID == userID {
+			memberships = append(memberships, member)
+		}
+	}
+	return memberships, nil
+}
+
+func (r *memoryOrganizations) CountMembersWithRole(ctx context.Context, organizationID primitive.ObjectID, role models.OrganizationRole) (int64, error) {
+	var count int64
+	for _, member := range r.members {
+		if member.OrganizationID == organizationID && member.Role == role {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryOrganizations) UpdateMemberRole(ctx context.Context, organizationID, userID primitive.ObjectID, role models.OrganizationRole) error {
+	for _, member := range r.members {
+		if member.OrganizationID == organizationID && member.UserID == userID {
+			member.Role = role
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (r *memoryOrganizations) RemoveMember(ctx context.Context, organizationID, userID primitive.ObjectID) error {
+	for i, member := range r.members {
+		if member.OrganizationID == organizationID && member.UserID == userID {
+			r.members = append(r.members[:i], r.members[i+1:]...)
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (r *memoryOrganizations) CreateInvitation(ctx context.Context, invitation *models.OrganizationInvitation) error {
+	invitation.ID = primitive.NewObjectID()
+	r.invitations = append(r.invitations, invitation)
+	return nil
+}
+
+func (r *memoryOrganizations) GetInvitationByHash(ctx context.Context, tokenHash string) (*models.OrganizationInvitation, error) {
+	for _, invitation := range r.invitations {
+		if invitation.TokenHash == tokenHash && invitation.AcceptedAt == nil {
+			return invitation, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *memoryOrganizations) AcceptInvitation(ctx context.Context, id, userID primitive.ObjectID, at time.Time) error {
+	for _, invitation := range r.invitations {
+		if invitation.ID == id && invitation.AcceptedAt == nil {
+			invitation.AcceptedAt, invitation.AcceptedBy = &at, userID
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+// memoryRoles keeps custom roles in memory.
+type memoryRoles struct {
+	roles []*models.Role
+}
+
+func (r *memoryRoles) Create(ctx context.Context, role *models.Role) error {
+	role.ID = primitive.NewObjectID()
+	r.roles = append(r.roles, role)
+	return nil
+}
+
+func (r *memoryRoles) GetByID(ctx context.Context, organizationID, id primitive.ObjectID) (*models.Role, error) {
+	for _, role := range r.roles {
+		if role.OrganizationID == organizationID && role.ID == id {
+			return role, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *memoryRoles) GetByName(ctx context.Context, organizationID primitive.ObjectID, name string) (*models.Role, error) {
+	for _, role := range r.roles {
+		if role.OrganizationID == organizationID && role.Name == name {
+			return role, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *memoryRoles) ListByOrganization(ctx context.Context, organizationID primitive.ObjectID) ([]*models.Role, error) {
+	roles := []*models.Role{}
+	for _, role := range r.roles {
+		if role.OrganizationID == organizationID {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+func (r *memoryRoles) Update(ctx context.Context, role *models.Role) error {
+	for i, stored := range r.roles {
+		if stored.OrganizationID == role.OrganizationID && stored.ID == role.ID {
+			r.roles[i] = role
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (r *memoryRoles) Delete(ctx context.Context, organizationID, id primitive.ObjectID) error {
+	for i, role := range r.roles {
+		if role.OrganizationID == organizationID && role.ID == id {
+			r.roles = append(r.roles[:i], r.roles[i+1:]...)
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+// newTestOrganizationService returns organization and policy services with alice@example.com and bob@example.com.
+func newTestOrganizationService() (OrganizationService, PolicyService, *models.User, *models.User) {
+	alice := &models.User{ID: primitive.NewObjectID(), Email: "alice@example.com"}
+	bob := &models.User{ID: primitive.NewObjectID(), Email: "bob@example.com"}
+	organizations := newMemoryOrganizations()
+	policy := NewPolicyService(organizations, &memoryRoles{}, zap.NewNop())
+	service := NewOrganizationService(organizations, policy, &memoryUsers{users: []*models.User{alice, bob}}, nil, nil, zap.NewNop())
+	return service, policy, alice, bob
+}
+
+func TestOrganizationRolePermissions(t *testing.T) {
+	for _, tc := range []struct {
+		role       models.OrganizationRole
+		permission models.Permission
+		allowed    bool
+	}{
+		{models.OrganizationRoleViewer, models.PermissionCollectionsRead, true},
+		{models.OrganizationRoleViewer, models.PermissionCollectionsWrite, false},
+		{models.OrganizationRoleMember, models.PermissionSDKsWrite, true},
+		{models.OrganizationRoleMember, models.PermissionSDKsDelete, false},
+		{models.OrganizationRoleAdmin, models.PermissionCollectionsDelete, true},
+		{models.OrganizationRoleAdmin, models.PermissionOrganizationManageMembers, true},
+		{models.OrganizationRoleAdmin, models.PermissionOrganizationManage, false},
+		{models.OrganizationRoleOwner, models.PermissionOrganizationManage, true},
+	} {
+		if _, got := models.OrganizationRoles[tc.role].Grants(tc.permission); got != tc.allowed {
+			t.Errorf("%s grants %s = %v, want %v", tc.role, tc.permission, got, tc.allowed)
+		}
+	}
+}
+
+func TestAuthorizeResources(t *testing.T) {
+	ctx := context.Background()
+	service, _, alice, bob := newTestOrganizationService()
+	organization, err := service.CreateOrganization(ctx, alice.ID, &models.CreateOrganizationRequest{Name: " Acme "})
+	if err != nil {
+		t.Fatalf("CreateOrganization() error = %v", err)
+	}
+	if organization.Name != "Acme" || organization.Role != models.OrganizationRoleOwner {
+		t.Fatalf("CreateOrganization() = %+v", organization)
+	}
+	orgID := organization.ID.Hex()
+
+	// Personal resources belong to their owner alone.
+	if err := service.Authorize(ctx, alice.ID.Hex(), alice.ID.Hex(), "", models.ResourceCollections, models.ActionDelete); err != nil {
+		t.Errorf("owner Authorize() error = %v", err)
+	}
+	if err := service.Authorize(ctx, bob.ID.Hex(), alice.ID.Hex(), "", models.ResourceCollections, models.ActionRead); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("other user Authorize() error = %v, want ErrPermissionDenied", err)
+	}
+
+	// Outsiders cannot reach organization resources, members can as far as their role allows.
+	if err := service.Authorize(ctx, bob.ID.Hex(), alice.ID.Hex(), orgID, models.ResourceCollections, models.ActionRead); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("outsider Authorize() error = %v, want ErrPermissionDenied", err)
+	}
+	invitation, err := service.CreateInvitation(ctx, alice.ID, organization.ID, &models.CreateInvitationRequest{Email: "Bob@Example.com"})
+	if err != nil {
+		t.Fatalf("CreateInvitation() error = %v", err)
+	}
+	if _, err := service.AcceptInvitation(ctx, bob.ID, invitation.Token); err != nil {
+		t.Fatalf("AcceptInvitation() error = %v", err)
+	}
+	if err := service.Authorize(ctx, bob.ID.Hex(), alice.ID.Hex(), orgID, models.ResourceCollections, models.ActionWrite); err != nil {
+		t.Errorf("member write Authorize() error = %v", err)
+	}
+	if err := service.Authorize(ctx, bob.ID.Hex(), alice.ID.Hex(), orgID, models.ResourceCollections, models.ActionDelete); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("member delete Authorize() error = %v, want ErrPermissionDenied", err)
+	}
+	// Members may delete what they created themselves.
+	if err := service.Authorize(ctx, bob.ID.Hex(), bob.ID.Hex(), orgID, models.ResourceCollections, models.ActionDelete); err != nil {
+		t.Errorf("creator delete Authorize() error = %v", err)
+	}
+
+	ids, err := service.OrganizationIDs(ctx, bob.ID.Hex())
+	if err != nil || len(ids) != 1 || ids[0] != orgID {
+		t.Errorf("OrganizationIDs() = %v, %v", ids, err)
+	}
+}
+
+func TestAcceptInvitationRequiresInvitedEmail(t *testing.T) {
+	ctx := context.Background()
+	service, _, alice, bob := newTestOrganizationService()
+	organization, err := service.CreateOrganization(ctx, alice.ID, &models.CreateOrganizationRequest{Name: "Acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invitation, err := service.CreateInvitation(ctx, alice.ID, organization.ID, &models.CreateInvitationRequest{Email: "carol@example.com", Role: models.OrganizationRoleViewer})
+	if err != nil {
+		t.Fatalf("CreateInvitation() error = %v", err)
+	}
+
+	if _, err := service.AcceptInvitation(ctx, bob.ID, invitation.Token); !errors.Is(err, ErrInvitationNotFound) {
+		t.Errorf("AcceptInvitation() by another address error = %v, want ErrInvitationNotFound", err)
+	}
+	if _, err := service.AcceptInvitation(ctx, bob.ID, "guessed"); !errors.Is(err, ErrInvitationNotFound) {
+		t.Errorf("AcceptInvitation() with an unknown token error = %v, want ErrInvitationNotFound", err)
+	}
+	if _, err := service.CreateInvitation(ctx, alice.ID, organization.ID, &models.CreateInvitationRequest{Email: "alice@example.com"}); !errors.Is(err, ErrInvalidOrganizationRequest) {
+		t.Errorf("CreateInvitation() for a member error = %v, want ErrInvalidOrganizationRequest", err)
+	}
+}
+
+func TestOrganizationKeepsAnOwner(t *testing.T) {
+	ctx := context.Background()
+	service, _, alice, bob := newTestOrganizationService()
+	organization, err := service.CreateOrganization(ctx, alice.ID, &models.CreateOrganizationRequest{Name: "Acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invitation, err := service.CreateInvitation(ctx, alice.ID, organization.ID, &models.CreateInvitationRequest{Email: "bob@example.com", Role: models.OrganizationRoleAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.AcceptInvitation(ctx, bob.ID, invitation.Token); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.RemoveMember(ctx, alice.ID, organization.ID, alice.ID); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("last owner leaving error = %v, want ErrLastOwner", err)
+	}
+	if err := service.UpdateMemberRole(ctx, alice.ID, organization.ID, alice.ID, models.OrganizationRoleMember); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("demoting the last owner error = %v, want ErrLastOwner", err)
+	}
+	// Admins manage members but cannot touch owners.
+	if err := service.RemoveMember(ctx, bob.ID, organization.ID, alice.ID); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("admin removing an owner error = %v, want ErrPermissionDenied", err)
+	}
+	if err := service.UpdateMemberRole(ctx, bob.ID, organization.ID, bob.ID, models.OrganizationRoleOwner); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("admin promoting to owner error = %v, want ErrPermissionDenied", err)
+	}
+
+	if err := service.UpdateMemberRole(ctx, alice.ID, organization.ID, bob.ID, models.OrganizationRoleOwner); err != nil {
+		t.Fatalf("UpdateMemberRole() error = %v", err)
+	}
+	if err := service.RemoveMember(ctx, alice.ID, organization.ID, alice.ID); err != nil {
+		t.Errorf("owner leaving with another owner error = %v", err)
+	}
+}
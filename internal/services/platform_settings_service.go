@@ -13,16 +13,20 @@ type PlatformSettingsService interface {
 	UpdatePlatformSettings(ctx context.Context, settings map[string]interface{}) (map[string]interface{}, error)
 	GetPlatformSettingsStruct(ctx context.Context) (*models.PlatformSettings, error) // New method
 	GetSupportedSDKLanguages(ctx context.Context) ([]string, error)                  // New method for supported languages
+	// UpdateOIDCSettings replaces the single sign-on settings.
+	UpdateOIDCSettings(ctx context.Context, settings *models.OIDCSettings) (*models.OIDCSettings, error)
 }
 
 // PlatformSettingsServiceImpl implements PlatformSettingsService.
 type PlatformSettingsServiceImpl struct {
 	repository repositories.PlatformSettingsRepository
+	audit      AuditService
 }
 
-// NewPlatformSettingsService creates a new PlatformSettingsServiceImpl.
-func NewPlatformSettingsService(repo repositories.PlatformSettingsRepository) PlatformSettingsService {
-	return &PlatformSettingsServiceImpl{repository: repo}
+// NewPlatformSettingsService creates a new PlatformSettingsServiceImpl. Every update is
+// recorded in the audit log with the settings before and after it.
+func NewPlatformSettingsService(repo repositories.PlatformSettingsRepository, audit AuditService) PlatformSettingsService {
+	return &PlatformSettingsServiceImpl{repository: repo, audit: audit}
 }
 
 // GetPlatformSettingsMap retrieves the current platform settings as a map.
@@ -52,14 +56,25 @@ func (s *PlatformSettingsServiceImpl) GetPlatformSettingsStruct(ctx context.Cont
 
 // UpdatePlatformSettings updates the platform settings.
 func (s *PlatformSettingsServiceImpl) UpdatePlatformSettings(ctx context.Context, newSettings map[string]interface{}) (map[string]interface{}, error) {
+	previous, err := s.GetPlatformSettingsMap(ctx)
+	if err != nil {
+		return nil, err
+	}
 	updatedSettingsDoc, err := s.repository.UpdateSettings(ctx, newSettings) // This line will now use models.PlatformSettings
 	if err != nil {
 		return nil, err
 	}
-	if updatedSettingsDoc == nil { // Handle case where UpdateSettings might return nil, nil
-		return newSettings, nil // Or some other appropriate response
+	updated := newSettings
+	if updatedSettingsDoc != nil { // Handle case where UpdateSettings might return nil, nil
+		updated = updatedSettingsDoc.Settings
 	}
-	return updatedSettingsDoc.Settings, nil
+	s.audit.Record(ctx, &models.AuditEvent{
+		Action:     models.AuditActionSettingsUpdate,
+		TargetType: models.AuditTargetSettings,
+		TargetID:   "platform",
+		Changes:    AuditChanges(previous, updated),
+	})
+	return updated, nil
 }
 
 // GetSupportedSDKLanguages retrieves the list of supported SDK languages.
@@ -78,3 +93,31 @@ func (s *PlatformSettingsServiceImpl) GetSupportedSDKLanguages(ctx context.Conte
 	}
 	return settingsDoc.SupportedSDKLanguages, nil
 }
+
+// UpdateOIDCSettings replaces the single sign-on settings.
+func (s *PlatformSettingsServiceImpl) UpdateOIDCSettings(ctx context.Context, settings *models.OIDCSettings) (*models.OIDCSettings, error) {
+	previous, err := s.GetPlatformSettingsStruct(ctx)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.repository.UpdateOIDCSettings(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	before := previous.OIDC
+	if before == nil {
+		before = &models.OIDCSettings{}
+	}
+	changes := AuditChanges(before, updated.OIDC)
+	if change := secretAuditChange("clientSecret", string(before.ClientSecret), string(updated.OIDC.ClientSecret)); change != nil {
+		changes = append(changes, *change)
+	}
+	s.audit.Record(ctx, &models.AuditEvent{
+		Action:     models.AuditActionOIDCSettingsUpdate,
+		TargetType: models.AuditTargetSettings,
+		TargetID:   "oidc",
+		Changes:    changes,
+	})
+	return updated.OIDC, nil
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	maxRoleNameLength        = 50
+	maxRoleDescriptionLength = 200
+)
+
+var (
+	// ErrInvalidPermission is returned for permissions that are not an action on a known resource.
+	ErrInvalidPermission = errors.New("invalid permission")
+	// ErrInvalidRoleRequest is returned for role requests with invalid fields.
+	ErrInvalidRoleRequest = errors.New("invalid role request")
+	// ErrRoleNotFound is returned when an organization has no such role.
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrRoleInUse is returned when deleting a role members still have.
+	ErrRoleInUse = errors.New("role is assigned to members")
+)
+
+// PolicyService decides which permissions users have and explains why. Platform permissions
+// come from the platform role of the user; permissions on the resources of an organization
+// come from the user's built-in or custom role in that organization.
+type PolicyService interface {
+	// CheckPlatform decides whether the platform role grants the permission.
+	CheckPlatform(role models.UserRole, permission models.Permission) *models.PolicyDecision
+	// CheckOrganization decides whether the role of the user in the organization grants the permission.
+	CheckOrganization(ctx context.Context, userID, organizationID primitive.ObjectID, permission models.Permission) (*models.PolicyDecision, error)
+	// Explain decides whether the user has the permission, in the organization when organizationID
+	// is set and platform-wide otherwise; it returns ErrInvalidPermission for unknown permissions.
+	Explain(ctx context.Context, userID primitive.ObjectID, role models.UserRole, permission models.Permission, organizationID string) (*models.PolicyDecision, error)
+	// OrganizationRole resolves a built-in or custom role of an organization; it returns ErrRoleNotFound for unknown roles.
+	OrganizationRole(ctx context.Context, organizationID primitive.ObjectID, name models.OrganizationRole) (*models.Role, error)
+
+	// ListRoles returns the built-in roles followed by the custom roles of the organization.
+	ListRoles(ctx context.Context, userID, organizationID primitive.ObjectID) ([]*models.Role, error)
+	// CreateRole defines a custom role; only members who may manage the organization may.
+	CreateRole(ctx context.Context, userID, organizationID primitive.ObjectID, req *models.RoleRequest) (*models.Role, error)
+	// UpdateRole changes the description and permissions of a custom role; its name stays.
+	UpdateRole(ctx context.Context, userID, organizationID, roleID primitive.ObjectID, req *models.RoleRequest) (*models.Role, error)
+	// DeleteRole deletes a custom role no member has.
+	DeleteRole(ctx context.Context, userID, organizationID, roleID primitive.ObjectID) error
+}
+
+type policyService struct {
+	organizationRepo repositories.OrganizationRepository
+	roleRepo         repositories.RoleRepository
+	logger           *zap.Logger
+}
+
+// NewPolicyService creates a new PolicyService.
+func NewPolicyService(organizationRepo repositories.OrganizationRepository, roleRepo repositories.RoleRepository, logger *zap.Logger) PolicyService {
+	return &policyService{
+		organizationRepo: organizationRepo,
+		roleRepo:         roleRepo,
+		logger:           logger,
+	}
+}
+
+// CheckPlatform decides whether the platform role grants the permission.
+func (s *policyService) CheckPlatform(role models.UserRole, permission models.Permission) *models.PolicyDecision {
+	decision := &models.PolicyDecision{Permission: permission, Role: string(role)}
+	platformRole, ok := models.PlatformRoles[role]
+	if !ok {
+		decision.Reason = fmt.Sprintf("%q is not a platform role", role)
+		return decision
+	}
+	return decide(decision, platformRole, "platform role "+string(role))
+}
+
+// CheckOrganization decides whether the role of the user in the organization grants the permission.
+func (s *policyService) CheckOrganization(ctx context.Context, userID, organizationID primitive.ObjectID, permission models.Permission) (*models.PolicyDecision, error) {
+	decision := &models.PolicyDecision{Permission: permission, OrganizationID: organizationID.Hex()}
+	if !permission.IsOrganizationPermission() {
+		decision.Reason = fmt.Sprintf("%s is a platform permission, which organization roles do not grant", permission)
+		return decision, nil
+	}
+
+	member, err := s.organizationRepo.GetMember(ctx, organizationID, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		decision.Reason = "not a member of the organization"
+		return decision, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization membership: %w", err)
+	}
+	decision.Role = string(member.Role)
+
+	role, err := s.OrganizationRole(ctx, organizationID, member.Role)
+	if errors.Is(err, ErrRoleNotFound) {
+		decision.Reason = fmt.Sprintf("organization role %s no longer exists", member.Role)
+		return decision, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decide(decision, role, "organization role "+string(member.Role)), nil
+}
+
+// Explain decides whether the user has the permission, in the organization or platform-wide.
+func (s *policyService) Explain(ctx context.Context, userID primitive.ObjectID, role models.UserRole, permission models.Permission, organizationID string) (*models.PolicyDecision, error) {
+	if !permission.IsValid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPermission, permission)
+	}
+	if organizationID == "" {
+		return s.CheckPlatform(role, permission), nil
+	}
+	orgID, err := primitive.ObjectIDFromHex(organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid organization ID", ErrInvalidPermission)
+	}
+	return s.CheckOrganization(ctx, userID, orgID, permission)
+}
+
+// OrganizationRole resolves a built-in or custom role of an organization.
+func (s *policyService) OrganizationRole(ctx context.Context, organizationID primitive.ObjectID, name models.OrganizationRole) (*models.Role, error) {
+	if role, ok := models.OrganizationRoles[name]; ok {
+		return role, nil
+	}
+	role, err := s.roleRepo.GetByName(ctx, organizationID, string(name))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	return role, nil
+}
+
+// ListRoles returns the built-in roles followed by the custom roles of the organization.
+func (s *policyService) ListRoles(ctx context.Context, userID, organizationID primitive.ObjectID) ([]*models.Role, error) {
+	if _, err := s.organizationRepo.GetMember(ctx, organizationID, userID); errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrOrganizationNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get organization membership: %w", err)
+	}
+	custom, err := s.roleRepo.ListByOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	roles := make([]*models.Role, 0, len(models.OrganizationRoles)+len(custom))
+	for _, name := range []models.OrganizationRole{models.OrganizationRoleOwner, models.OrganizationRoleAdmin, models.OrganizationRoleMember, models.OrganizationRoleViewer} {
+		roles = append(roles, models.OrganizationRoles[name])
+	}
+	return append(roles, custom...), nil
+}
+
+// CreateRole defines a custom role of an organization.
+func (s *policyService) CreateRole(ctx context.Context, userID, organizationID primitive.ObjectID, req *models.RoleRequest) (*models.Role, error) {
+	if err := s.requireManage(ctx, userID, organizationID); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxRoleNameLength {
+		return nil, fmt.Errorf("%w: name is required and at most %d characters", ErrInvalidRoleRequest, maxRoleNameLength)
+	}
+	if models.OrganizationRole(name).IsValid() {
+		return nil, fmt.Errorf("%w: %s is a built-in role", ErrInvalidRoleRequest, name)
+	}
+	role := &models.Role{OrganizationID: organizationID, Name: name}
+	if err := applyRoleRequest(role, req); err != nil {
+		return nil, err
+	}
+	if _, err := s.roleRepo.GetByName(ctx, organizationID, name); err == nil {
+		return nil, fmt.Errorf("%w: the organization already has a role named %s", ErrInvalidRoleRequest, name)
+	}
+
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, fmt.Errorf("failed to store role: %w", err)
+	}
+	s.logger.Info("Organization role created",
+		zap.String("organizationID", organizationID.Hex()),
+		zap.String("role", name),
+		zap.String("userID", userID.Hex()))
+	return role, nil
+}
+
+// UpdateRole changes the description and permissions of a custom role.
+func (s *policyService) UpdateRole(ctx context.Context, userID, organizationID, roleID primitive.ObjectID, req *models.RoleRequest) (*models.Role, error) {
+	if err := s.requireManage(ctx, userID, organizationID); err != nil {
+		return nil, err
+	}
+	role, err := s.roleRepo.GetByID(ctx, organizationID, roleID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	if name := strings.TrimSpace(req.Name); name != "" && name != role.Name {
+		return nil, fmt.Errorf("%w: roles cannot be renamed", ErrInvalidRoleRequest)
+	}
+	if err := applyRoleRequest(role, req); err != nil {
+		return nil, err
+	}
+
+	err = s.roleRepo.Update(ctx, role)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+	s.logger.Info("Organization role updated",
+		zap.String("organizationID", organizationID.Hex()),
+		zap.String("role", role.Name),
+		zap.String("userID", userID.Hex()))
+	return role, nil
+}
+
+// DeleteRole deletes a custom role no member has.
+func (s *policyService) DeleteRole(ctx context.Context, userID, organizationID, roleID primitive.ObjectID) error {
+	if err := s.requireManage(ctx, userID, organizationID); err != nil {
+		return err
+	}
+	role, err := s.roleRepo.GetByID(ctx, organizationID, roleID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrRoleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get role: %w", err)
+	}
+	members, err := s.organizationRepo.CountMembersWithRole(ctx, organizationID, models.OrganizationRole(role.Name))
+	if err != nil {
+		return fmt.Errorf("failed to count role members: %w", err)
+	}
+	if members > 0 {
+		return ErrRoleInUse
+	}
+
+	err = s.roleRepo.Delete(ctx, organizationID, roleID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrRoleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	s.logger.Info("Organization role deleted",
+		zap.String("organizationID", organizationID.Hex()),
+		zap.String("role", role.Name),
+		zap.String("userID", userID.Hex()))
+	return nil
+}
+
+// requireManage returns ErrOrganizationNotFound for non-members and ErrPermissionDenied unless
+// the user may manage the organization.
+func (s *policyService) requireManage(ctx context.Context, userID, organizationID primitive.ObjectID) error {
+	decision, err := s.CheckOrganization(ctx, userID, organizationID, models.PermissionOrganizationManage)
+	if err != nil {
+		return err
+	}
+	if decision.Allowed {
+		return nil
+	}
+	if decision.Role == "" {
+		return ErrOrganizationNotFound
+	}
+	return ErrPermissionDenied
+}
+
+// applyRoleRequest sets the description and the deduplicated permissions of a custom role.
+// Custom roles may grant any organization permission except managing the organization, which
+// would let their members appoint owners.
+func applyRoleRequest(role *models.Role, req *models.RoleRequest) error {
+	description := strings.TrimSpace(req.Description)
+	if len(description) > maxRoleDescriptionLength {
+		return fmt.Errorf("%w: description must be at most %d characters", ErrInvalidRoleRequest, maxRoleDescriptionLength)
+	}
+
+	permissions := make([]models.Permission, 0, len(req.Permissions))
+	seen := make(map[models.Permission]bool)
+	for _, permission := range req.Permissions {
+		if !permission.IsOrganizationPermission() {
+			return fmt.Errorf("%w: %q is not an organization permission", ErrInvalidRoleRequest, permission)
+		}
+		if permission.Matches(models.PermissionOrganizationManage) {
+			return fmt.Errorf("%w: only owners may manage the organization", ErrInvalidRoleRequest)
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+
+	role.Description = description
+	role.Permissions = permissions
+	return nil
+}
+
+// decide completes the decision with whether the role grants its permission.
+func decide(decision *models.PolicyDecision, role *models.Role, subject string) *models.PolicyDecision {
+	if granted, ok := role.Grants(decision.Permission); ok {
+		decision.Allowed = true
+		decision.GrantedBy = granted
+		decision.Reason = fmt.Sprintf("%s grants %s", subject, granted)
+		return decision
+	}
+	decision.Reason = fmt.Sprintf("%s does not grant %s", subject, decision.Permission)
+	return decision
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCheckPlatform(t *testing.T) {
+	_, policy, _, _ := newTestOrganizationService()
+	for _, tc := range []struct {
+		role       models.UserRole
+		permission models.Permission
+		allowed    bool
+	}{
+		{models.RoleAdmin, models.PermissionIntegrationCredentialsWrite, true},
+		{models.RoleModerator, models.PermissionUsersRead, true},
+		{models.RoleModerator, models.PermissionMCPServersManage, true},
+		{models.RoleModerator, models.PermissionIntegrationCredentialsRead, false},
+		{models.RoleModerator, models.PermissionSettingsWrite, false},
+		{models.RoleUser, models.PermissionMCPServersRead, false},
+		{"", models.PermissionUsersRead, false},
+	} {
+		decision := policy.CheckPlatform(tc.role, tc.permission)
+		if decision.Allowed != tc.allowed || decision.Reason == "" {
+			t.Errorf("CheckPlatform(%q, %s) = %+v, want allowed %v", tc.role, tc.permission, decision, tc.allowed)
+		}
+	}
+
+	if decision := policy.CheckPlatform(models.RoleAdmin, models.PermissionUsersDelete); decision.GrantedBy != "*" {
+		t.Errorf("admin decision granted by %q, want *", decision.GrantedBy)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+	service, policy, alice, bob := newTestOrganizationService()
+	organization, err := service.CreateOrganization(ctx, alice.ID, &models.CreateOrganizationRequest{Name: "Acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := policy.Explain(ctx, alice.ID, models.RoleUser, "collections:fly", ""); !errors.Is(err, ErrInvalidPermission) {
+		t.Errorf("Explain() of an unknown permission error = %v, want ErrInvalidPermission", err)
+	}
+
+	decision, err := policy.Explain(ctx, alice.ID, models.RoleUser, models.PermissionCollectionsDelete, organization.ID.Hex())
+	if err != nil || !decision.Allowed || decision.Role != string(models.OrganizationRoleOwner) {
+		t.Errorf("owner Explain() = %+v, %v", decision, err)
+	}
+	decision, err = policy.Explain(ctx, bob.ID, models.RoleUser, models.PermissionCollectionsRead, organization.ID.Hex())
+	if err != nil || decision.Allowed || decision.Reason != "not a member of the organization" {
+		t.Errorf("outsider Explain() = %+v, %v", decision, err)
+	}
+	// Organization roles never grant platform permissions, not even to owners.
+	decision, err = policy.Explain(ctx, alice.ID, models.RoleUser, models.PermissionUsersRead, organization.ID.Hex())
+	if err != nil || decision.Allowed {
+		t.Errorf("platform permission in an organization Explain() = %+v, %v", decision, err)
+	}
+}
+
+func TestCustomOrganizationRoles(t *testing.T) {
+	ctx := context.Background()
+	service, policy, alice, bob := newTestOrganizationService()
+	organization, err := service.CreateOrganization(ctx, alice.ID, &models.CreateOrganizationRequest{Name: "Acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, req := range map[string]*models.RoleRequest{
+		"built-in name":       {Name: "admin", Permissions: []models.Permission{models.PermissionCollectionsRead}},
+		"platform permission": {Name: "support", Permissions: []models.Permission{models.PermissionUsersRead}},
+		"manage organization": {Name: "deputy", Permissions: []models.Permission{"organization:*"}},
+		"everything":          {Name: "root", Permissions: []models.Permission{"*"}},
+	} {
+		if _, err := policy.CreateRole(ctx, alice.ID, organization.ID, req); !errors.Is(err, ErrInvalidRoleRequest) {
+			t.Errorf("%s: CreateRole() error = %v, want ErrInvalidRoleRequest", name, err)
+		}
+	}
+
+	role, err := policy.CreateRole(ctx, alice.ID, organization.ID, &models.RoleRequest{
+		Name:        "publisher",
+		Permissions: []models.Permission{models.PermissionCollectionsRead, "sdks:*"},
+	})
+	if err != nil {
+		t.Fatalf("CreateRole() error = %v", err)
+	}
+	invitation, err := service.CreateInvitation(ctx, alice.ID, organization.ID, &models.CreateInvitationRequest{Email: "bob@example.com", Role: "publisher"})
+	if err != nil {
+		t.Fatalf("CreateInvitation() with a custom role error = %v", err)
+	}
+	if _, err := service.AcceptInvitation(ctx, bob.ID, invitation.Token); err != nil {
+		t.Fatal(err)
+	}
+
+	orgID := organization.ID.Hex()
+	if err := service.Authorize(ctx, bob.ID.Hex(), alice.ID.Hex(), orgID, models.ResourceSDKs, models.ActionDelete); err != nil {
+		t.Errorf("custom role SDK delete Authorize() error = %v", err)
+	}
+	if err := service.Authorize(ctx, bob.ID.Hex(), alice.ID.Hex(), orgID, models.ResourceCollections, models.ActionWrite); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("custom role collection write Authorize() error = %v, want ErrPermissionDenied", err)
+	}
+	if _, err := policy.CreateRole(ctx, bob.ID, organization.ID, &models.RoleRequest{Name: "other"}); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("CreateRole() by a member error = %v, want ErrPermissionDenied", err)
+	}
+	if err := service.UpdateMemberRole(ctx, alice.ID, organization.ID, bob.ID, "unknown"); !errors.Is(err, ErrInvalidOrganizationRequest) {
+		t.Errorf("UpdateMemberRole() to an unknown role error = %v, want ErrInvalidOrganizationRequest", err)
+	}
+
+	if err := policy.DeleteRole(ctx, alice.ID, organization.ID, role.ID); !errors.Is(err, ErrRoleInUse) {
+		t.Errorf("DeleteRole() of an assigned role error = %v, want ErrRoleInUse", err)
+	}
+	if err := service.UpdateMemberRole(ctx, alice.ID, organization.ID, bob.ID, models.OrganizationRoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if err := policy.DeleteRole(ctx, alice.ID, organization.ID, role.ID); err != nil {
+		t.Errorf("DeleteRole() error = %v", err)
+	}
+	if err := policy.DeleteRole(ctx, alice.ID, organization.ID, primitive.NewObjectID()); !errors.Is(err, ErrRoleNotFound) {
+		t.Errorf("DeleteRole() of an unknown role error = %v, want ErrRoleNotFound", err)
+	}
+}
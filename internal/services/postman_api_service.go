@@ -22,6 +22,7 @@ type PostmanAPIService struct {
 	logger     *zap.Logger
 	httpClient *http.Client
 	apiKey     string
+	baseURL    string
 }
 
 // NewPostmanAPIService creates a new PostmanAPIService.
@@ -32,10 +33,17 @@ func NewPostmanAPIService(logger *zap.Logger, apiKey string) *PostmanAPIService
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		apiKey: apiKey,
+		apiKey:  apiKey,
+		baseURL: postmanAPIBaseURL,
 	}
 }
 
+// WithBaseURL points the service at another Postman API, such as a local stub, and returns it.
+func (s *PostmanAPIService) WithBaseURL(baseURL string) *PostmanAPIService {
+	s.baseURL = strings.TrimRight(baseURL, "/")
+	return s
+}
+
 // getPostmanAPIKey is now a direct field access, but kept for consistency if logic were added.
 func (s *PostmanAPIService) getPostmanAPIKey() string {
 	if s.apiKey == "" {
@@ -68,7 +76,7 @@ func (s *PostmanAPIService) ListPublicWorkspaces(ctx context.Context) ([]Postman
 		return nil, fmt.Errorf("postman API key not configured") // Keep as is, more of a status
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/workspaces", postmanAPIBaseURL), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/workspaces", s.baseURL), nil)
 	if err != nil {
 		s.logger.Error("Failed to create request for public workspaces", zap.Error(err))
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -104,7 +112,7 @@ func (s *PostmanAPIService) ListCollectionsInWorkspace(ctx context.Context, work
 		return nil, fmt.Errorf("postman API key not configured") // Keep as is
 	}
 
-	url := fmt.Sprintf("%s/collections?workspace=%s", postmanAPIBaseURL, workspaceID)
+	url := fmt.Sprintf("%s/collections?workspace=%s", s.baseURL, workspaceID)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		s.logger.Error("Failed to create request for collections in workspace", zap.String("workspaceID", workspaceID), zap.Error(err))
@@ -142,7 +150,7 @@ func (s *PostmanAPIService) GetCollection(ctx context.Context, collectionUID str
 		return "", fmt.Errorf("postman API key not configured") // Keep as is
 	}
 
-	collectionURL := fmt.Sprintf("%s/collections/%s", postmanAPIBaseURL, collectionUID)
+	collectionURL := fmt.Sprintf("%s/collections/%s", s.baseURL, collectionUID)
 	s.logger.Info("Fetching Postman collection", zap.String("url", collectionURL))
 
 	req, err := http.NewRequestWithContext(ctx, "GET", collectionURL, nil)
@@ -218,7 +226,7 @@ func (s *PostmanAPIService) SearchCollections(ctx context.Context, query string,
 	}
 
 	// Construct the search URL with query parameters
-	searchURL := fmt.Sprintf("%s/search?type=collection&q=%s", postmanAPIBaseURL, url.QueryEscape(query))
+	searchURL := fmt.Sprintf("%s/search?type=collection&q=%s", s.baseURL, url.QueryEscape(query))
 	if limit > 0 {
 		searchURL += fmt.Sprintf("&perPage=%d", limit)
 	}
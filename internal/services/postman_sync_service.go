@@ -0,0 +1,392 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.uber.org/zap"
+)
+
+const maxCollectionRevisions = 100
+
+var (
+	ErrNotPostmanCollection   = errors.New("only collections imported from Postman can be linked")
+	ErrInvalidPostmanUID      = errors.New("invalid Postman collection UID")
+	ErrCollectionNotLinked    = errors.New("collection is not linked to a Postman collection")
+	ErrPostmanFetchFailed     = errors.New("failed to fetch the Postman collection")
+	ErrPostmanSyncConflict    = errors.New("collection was synced or unlinked concurrently")
+	postmanCollectionUIDRegex = regexp.MustCompile(`^[A-Za-z0-9-]{1,100}$`)
+)
+
+// PostmanCollectionFetcher fetches the content of Postman collections; PostmanAPIService is one.
+type PostmanCollectionFetcher interface {
+	GetCollection(ctx context.Context, collectionUID string) (string, error)
+}
+
+// PostmanSyncService keeps collections linked to Postman collections up to date. A sync stores a
+// revision whenever the content of the Postman collection changed and, for links that ask for
+// it, regenerates the collection's SDKs from it.
+type PostmanSyncService interface {
+	// Link links a collection to a Postman collection and syncs it.
+	Link(ctx context.Context, collection *models.Collection, req *models.LinkPostmanCollectionRequest) (*models.PostmanSyncResult, error)
+	// Unlink stops syncing a collection; its revisions are kept.
+	Unlink(ctx context.Context, collection *models.Collection) error
+	// Sync checks a linked collection for changes now.
+	Sync(ctx context.Context, collection *models.Collection, trigger models.SyncTrigger) (*models.PostmanSyncResult, error)
+	// SyncAll checks every linked collection and returns how many changed.
+	SyncAll(ctx context.Context) (int, error)
+	// Revisions returns the latest revisions of a collection, newest first, without their content.
+	Revisions(ctx context.Context, collection *models.Collection) ([]*models.CollectionRevision, error)
+	// RunScheduler syncs every linked collection now and then every interval until ctx is done.
+	RunScheduler(ctx context.Context, interval time.Duration)
+}
+
+// postmanSyncService is the concrete implementation of PostmanSyncService.
+type postmanSyncService struct {
+	repo       repositories.PostmanSyncRepository
+	postman    PostmanCollectionFetcher
+	sdkRepo    repositories.SDKRepositoryInterface
+	sdkService SDKServiceInterface
+	audit      AuditService
+	logger     *zap.Logger
+}
+
+// NewPostmanSyncService creates a new PostmanSyncService.
+func NewPostmanSyncService(repo repositories.PostmanSyncRepository, postman PostmanCollectionFetcher, sdkRepo repositories.SDKRepositoryInterface, sdkService SDKServiceInterface, audit AuditService, logger *zap.Logger) PostmanSyncService {
+	return &postmanSyncService{
+		repo:       repo,
+		postman:    postman,
+		sdkRepo:    sdkRepo,
+		sdkService: sdkService,
+		audit:      audit,
+		logger:     logger,
+	}
+}
+
+// Link links a collection to a Postman collection. Nothing is linked unless the Postman
+// collection can be fetched.
+func (s *postmanSyncService) Link(ctx context.Context, collection *models.Collection, req *models.LinkPostmanCollectionRequest) (*models.PostmanSyncResult, error) {
+	if collection.Source != models.CollectionSourcePostman && collection.Source != models.CollectionSourcePostmanPublic {
+		return nil, ErrNotPostmanCollection
+	}
+	uid := strings.TrimSpace(req.PostmanUID)
+	if !postmanCollectionUIDRegex.MatchString(uid) {
+		return nil, ErrInvalidPostmanUID
+	}
+	content, err := s.fetch(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	link := &models.PostmanLink{UID: uid, AutoRegenerate: req.AutoRegenerate, LinkedAt: time.Now()}
+	if previous := collection.PostmanLink; previous != nil {
+		// Revisions are numbered per collection, whichever Postman collection they came from
+		link.Revision = previous.Revision
+		link.ContentHash = previous.ContentHash
+	}
+	if err := s.repo.SetLink(ctx, collection.ID, link); err != nil {
+		return nil, err
+	}
+	collection.PostmanLink = link
+	return s.apply(ctx, collection, content, models.SyncTriggerLink)
+}
+
+// Unlink stops syncing a collection.
+func (s *postmanSyncService) Unlink(ctx context.Context, collection *models.Collection) error {
+	if collection.PostmanLink == nil {
+		return ErrCollectionNotLinked
+	}
+	if err := s.repo.SetLink(ctx, collection.ID, nil); err != nil {
+		return err
+	}
+	collection.PostmanLink = nil
+	return nil
+}
+
+// Sync fetches a linked collection from Postman and stores a revision when it changed. A
+// failed fetch is recorded on the link.
+func (s *postmanSyncService) Sync(ctx context.Context, collection *models.Collection, trigger models.SyncTrigger) (*models.PostmanSyncResult, error) {
+	link := collection.PostmanLink
+	if link == nil {
+		return nil, ErrCollectionNotLinked
+	}
+	content, err := s.fetch(ctx, link.UID)
+	if err != nil {
+		failed := *link
+		failed.LastCheckedAt = time.Now()
+		failed.LastError = err.Error()
+		if _, updateErr := s.repo.UpdateLink(ctx, collection.ID, link.Revision, &failed); updateErr != nil {
+			s.logger.Error("Failed to record a failed Postman sync", zap.String("collectionID", collection.ID.Hex()), zap.Error(updateErr))
+		}
+		collection.PostmanLink = &failed
+		return nil, err
+	}
+	return s.apply(ctx, collection, content, trigger)
+}
+
+// SyncAll syncs every linked collection; collections that fail are logged and retried on the
+// next run.
+func (s *postmanSyncService) SyncAll(ctx context.Context) (int, error) {
+	collections, err := s.repo.LinkedCollections(ctx)
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	for _, collection := range collections {
+		if ctx.Err() != nil {
+			return changed, ctx.Err()
+		}
+		result, err := s.Sync(ctx, collection, models.SyncTriggerSchedule)
+		if err != nil {
+			if !errors.Is(err, ErrPostmanSyncConflict) {
+				s.logger.Warn("Failed to sync Postman collection",
+					zap.String("collectionID", collection.ID.Hex()),
+					zap.String("postmanUID", collection.PostmanLink.UID),
+					zap.Error(err))
+			}
+			continue
+		}
+		if result.Changed {
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// Revisions returns the latest revisions of a collection.
+func (s *postmanSyncService) Revisions(ctx context.Context, collection *models.Collection) ([]*models.CollectionRevision, error) {
+	return s.repo.ListRevisions(ctx, collection.ID, maxCollectionRevisions)
+}
+
+// RunScheduler syncs every linked collection now and then every interval until ctx is done.
+func (s *postmanSyncService) RunScheduler(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		changed, err := s.SyncAll(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Error("Failed to sync linked Postman collections", zap.Error(err))
+		} else if changed > 0 {
+			s.logger.Info("Synced changed Postman collections", zap.Int("changed", changed))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// postmanContent is a Postman collection as a sync fetched it.
+type postmanContent struct {
+	raw       string
+	hash      string
+	updatedAt time.Time
+}
+
+// fetch returns the content of a Postman collection.
+func (s *postmanSyncService) fetch(ctx context.Context, uid string) (*postmanContent, error) {
+	raw, err := s.postman.GetCollection(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrPostmanFetchFailed, uid, err)
+	}
+	hash, updatedAt, err := postmanContentHash(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrPostmanFetchFailed, uid, err)
+	}
+	return &postmanContent{raw: raw, hash: hash, updatedAt: updatedAt}, nil
+}
+
+// apply compares the fetched content of a linked collection with its latest revision, storing
+// a new revision when it changed.
+func (s *postmanSyncService) apply(ctx context.Context, collection *models.Collection, content *postmanContent, trigger models.SyncTrigger) (*models.PostmanSyncResult, error) {
+	previous := collection.PostmanLink
+	link := *previous
+	link.LastCheckedAt = time.Now()
+	link.LastError = ""
+	link.UpdatedAt = content.updatedAt
+	result := &models.PostmanSyncResult{Collection: collection}
+
+	if content.hash == previous.ContentHash {
+		updated, err := s.repo.UpdateLink(ctx, collection.ID, previous.Revision, &link)
+		if err != nil {
+			return nil, err
+		}
+		if !updated {
+			return nil, ErrPostmanSyncConflict
+		}
+		collection.PostmanLink = &link
+		return result, nil
+	}
+
+	link.Revision++
+	link.ContentHash = content.hash
+	revision := &models.CollectionRevision{
+		CollectionID:     collection.ID,
+		Revision:         link.Revision,
+		PostmanUID:       link.UID,
+		PostmanUpdatedAt: content.updatedAt,
+		ContentHash:      content.hash,
+		RawPostmanJSON:   content.raw,
+		Trigger:          trigger,
+		CreatedAt:        link.LastCheckedAt,
+	}
+	applied, err := s.repo.ApplyRevision(ctx, &link, revision)
+	if err != nil {
+		return nil, err
+	}
+	if !applied {
+		return nil, ErrPostmanSyncConflict
+	}
+	s.logger.Info("Stored a new revision of a linked Postman collection",
+		zap.String("collectionID", collection.ID.Hex()),
+		zap.String("postmanUID", link.UID),
+		zap.Int("revision", link.Revision))
+
+	// The first revision of a link regenerates only when it differs from the imported content
+	previousContentHash, _, _ := postmanContentHash(collection.RawPostmanJSON)
+	collection.PostmanLink = &link
+	collection.RawPostmanJSON = content.raw
+	collection.OpenAPISpec = ""
+	revision.RawPostmanJSON = ""
+	result.Changed = true
+	result.Revision = revision
+
+	if link.AutoRegenerate && content.hash != previousContentHash {
+		regenerated, err := s.regenerateSDKs(ctx, collection, revision.Revision)
+		if err != nil {
+			// The revision is stored; the SDKs can be generated again by hand
+			s.logger.Error("Failed to regenerate the SDKs of a synced collection", zap.String("collectionID", collection.ID.Hex()), zap.Error(err))
+		}
+		result.Regenerated = regenerated
+	}
+	return result, nil
+}
+
+// regenerateSDKs starts generating, from the collection's new content, an SDK for every
+// language and package name an SDK of the collection was generated in.
+func (s *postmanSyncService) regenerateSDKs(ctx context.Context, collection *models.Collection, revision int) ([]*models.SDK, error) {
+	collectionID := collection.ID.Hex()
+	existing, err := s.sdkRepo.GetByCollectionID(ctx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var regenerated []*models.SDK
+	for _, sdk := range existing {
+		if sdk.GenerationType == models.GenerationTypeMCP || sdk.Status != models.SDKStatusCompleted || sdk.Language == "" {
+			continue
+		}
+		key := sdk.Language + "\x00" + sdk.PackageName
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		record, err := s.sdkService.CreateSDKRecord(ctx, &models.SDK{
+			UserID:         sdk.UserID,
+			OrganizationID: collection.OrganizationID,
+			CollectionID:   collectionID,
+			GenerationType: models.GenerationTypeSDK,
+			Language:       sdk.Language,
+			PackageName:    sdk.PackageName,
+			Status:         models.SDKStatusPending,
+		})
+		if err != nil {
+			return regenerated, err
+		}
+		req := &models.SDKGenerationRequest{
+			CollectionID: collectionID,
+			Language:     sdk.Language,
+			PackageName:  sdk.PackageName,
+			PostmanJSON:  collection.RawPostmanJSON,
+		}
+		// Generation outlives the sync; its outcome is audited with the sync's metadata
+		go s.generate(context.WithoutCancel(ctx), record, req, revision)
+		regenerated = append(regenerated, record)
+	}
+	return regenerated, nil
+}
+
+// generate runs a regeneration and records its outcome.
+func (s *postmanSyncService) generate(ctx context.Context, record *models.SDK, req *models.SDKGenerationRequest, revision int) {
+	generated, genErr := s.sdkService.GenerateSDK(ctx, req, record.ID)
+
+	event := &models.AuditEvent{
+		Action:         models.AuditActionSDKGenerate,
+		OrganizationID: record.OrganizationID,
+		TargetType:     models.AuditTargetSDK,
+		TargetID:       record.ID.Hex(),
+		Metadata: map[string]string{
+			"collectionId": record.CollectionID,
+			"language":     record.Language,
+			"trigger":      "postman_sync",
+			"revision":     strconv.Itoa(revision),
+		},
+	}
+	if genErr != nil {
+		event.Outcome = models.AuditOutcomeFailure
+		event.Metadata["error"] = genErr.Error()
+	}
+	s.audit.Record(ctx, event)
+
+	if genErr != nil {
+		s.logger.Error("SDK regeneration failed", zap.String("recordID", record.ID.Hex()), zap.Error(genErr))
+		if err := s.sdkService.UpdateSDKStatus(ctx, record.ID, models.SDKStatusFailed, genErr.Error()); err != nil {
+			s.logger.Error("Failed to update SDK status to failed after regeneration error", zap.String("recordID", record.ID.Hex()), zap.Error(err))
+		}
+		return
+	}
+	if err := s.sdkService.UpdateSDKRecord(ctx, generated); err != nil {
+		s.logger.Error("Failed to update SDK record after regeneration", zap.String("recordID", record.ID.Hex()), zap.Error(err))
+	}
+}
+
+// postmanContentHash returns the SHA-256 hash of the content of a Postman collection and the
+// updatedAt time Postman reports in its info. The hash leaves updatedAt out and does not
+// depend on formatting, so only changes to the collection itself change it.
+func postmanContentHash(raw string) (string, time.Time, error) {
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	var content map[string]interface{}
+	if err := decoder.Decode(&content); err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid collection JSON: %w", err)
+	}
+	if content == nil {
+		return "", time.Time{}, fmt.Errorf("invalid collection JSON: not an object")
+	}
+
+	var updatedAt time.Time
+	if info, ok := content["info"].(map[string]interface{}); ok {
+		if value, ok := info["updatedAt"].(string); ok {
+			updatedAt, _ = time.Parse(time.RFC3339, value)
+		}
+		delete(info, "updatedAt")
+	}
+
+	// Maps are encoded with sorted keys, which makes the encoding canonical
+	var canonical bytes.Buffer
+	encoder := json.NewEncoder(&canonical)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(content); err != nil {
+		return "", time.Time{}, err
+	}
+	sum := sha256.Sum256(canonical.Bytes())
+	return hex.EncodeToString(sum[:]), updatedAt, nil
+}
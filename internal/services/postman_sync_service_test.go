@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// memoryPostmanSync keeps linked collections and their revisions in memory.
+type memoryPostmanSync struct {
+	collections map[primitive.ObjectID]*models.Collection
+	revisions   []*models.CollectionRevision
+}
+
+func (r *memoryPostmanSync) LinkedCollections(ctx context.Context) ([]*models.Collection, error) {
+	var linked []*models.Collection
+	for _, collection := range r.collections {
+		if collection.PostmanLink != nil {
+			copied := *collection
+			linked = append(linked, &copied)
+		}
+	}
+	return linked, nil
+}
+
+func (r *memoryPostmanSync) SetLink(ctx context.Context, collectionID primitive.ObjectID, link *models.PostmanLink) error {
+	collection, ok := r.collections[collectionID]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+	collection.PostmanLink = link
+	return nil
+}
+
+func (r *memoryPostmanSync) UpdateLink(ctx context.Context, collectionID primitive.ObjectID, revision int, link *models.PostmanLink) (bool, error) {
+	collection, ok := r.collections[collectionID]
+	if !ok || collection.PostmanLink == nil || collection.PostmanLink.Revision != revision {
+		return false, nil
+	}
+	copied := *link
+	collection.PostmanLink = &copied
+	return true, nil
+}
+
+func (r *memoryPostmanSync) ApplyRevision(ctx context.Context, link *models.PostmanLink, revision *models.CollectionRevision) (bool, error) {
+	collection, ok := r.collections[revision.CollectionID]
+	if !ok || collection.PostmanLink == nil || collection.PostmanLink.Revision != revision.Revision-1 {
+		return false, nil
+	}
+	copied := *link
+	collection.PostmanLink = &copied
+	collection.RawPostmanJSON = revision.RawPostmanJSON
+	collection.OpenAPISpec = ""
+	stored := *revision
+	stored.ID = primitive.NewObjectID()
+	r.revisions = append(r.revisions, &stored)
+	return true, nil
+}
+
+func (r *memoryPostmanSync) ListRevisions(ctx context.Context, collectionID primitive.ObjectID, limit int) ([]*models.CollectionRevision, error) {
+	revisions := []*models.CollectionRevision{}
+	for i := len(r.revisions) - 1; i >= 0 && len(revisions) < limit; i-- {
+		if r.revisions[i].CollectionID == collectionID {
+			copied := *r.revisions[i]
+			copied.RawPostmanJSON = ""
+			revisions = append(revisions, &copied)
+		}
+	}
+	return revisions, nil
+}
+
+func (r *memorySDKs) GetByCollectionID(ctx context.Context, collectionID string) ([]*models.SDK, error) {
+	var sdks []*models.SDK
+	for _, sdk := range r.sdks {
+		if sdk.CollectionID == collectionID {
+			sdks = append(sdks, sdk)
+		}
+	}
+	return sdks, nil
+}
+
+// recordingSDKService records the generations it is asked for; unused methods panic.
+type recordingSDKService struct {
+	SDKServiceInterface
+	mu        sync.Mutex
+	requests  []*models.SDKGenerationRequest
+	completed chan *models.SDK
+}
+
+func (s *recordingSDKService) CreateSDKRecord(ctx context.Context, sdk *models.SDK) (*models.SDK, error) {
+	sdk.ID = primitive.NewObjectID()
+	return sdk, nil
+}
+
+func (s *recordingSDKService) GenerateSDK(ctx context.Context, req *models.SDKGenerationRequest, recordID primitive.ObjectID) (*models.SDK, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	return &models.SDK{ID: recordID, Language: req.Language, Status: models.SDKStatusCompleted}, nil
+}
+
+func (s *recordingSDKService) UpdateSDKRecord(ctx context.Context, sdk *models.SDK) error {
+	s.completed <- sdk
+	return nil
+}
+
+// postmanStub serves collections the way the Postman API does, to the API key "test-key".
+type postmanStub struct {
+	mu          sync.Mutex
+	collections map[string]string
+	failing     bool
+}
+
+func (p *postmanStub) set(uid, content string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.collections[uid] = content
+}
+
+func newPostmanStub(t *testing.T) (*postmanStub, *PostmanAPIService) {
+	t.Helper()
+	stub := &postmanStub{collections: map[string]string{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		if r.Header.Get("X-Api-Key") != "test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if stub.failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		content, ok := stub.collections[strings.TrimPrefix(r.URL.Path, "/collections/")]
+		if r.Method != http.MethodGet || !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]string{"name": "instanceNotFoundError"}})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"collection": ` + content + `}`))
+	}))
+	t.Cleanup(server.Close)
+	return stub, NewPostmanAPIService(zap.NewNop(), "test-key").WithBaseURL(server.URL + "/")
+}
+
+const (
+	postmanCollectionV1 = `{"info":{"name":"Petstore","updatedAt":"2026-10-01T10:00:00.000Z"},"item":[{"name":"List pets","request":{"method":"GET","url":"https://petstore.example.com/pets"}}]}`
+	postmanCollectionV2 = `{"info":{"name":"Petstore","updatedAt":"2026-10-02T10:00:00.000Z"},"item":[{"name":"List pets","request":{"method":"GET","url":"https://petstore.example.com/pets"}},{"name":"Get pet","request":{"method":"GET","url":"https://petstore.example.com/pets/:id"}}]}`
+)
+
+func TestPostmanContentHash(t *testing.T) {
+	hash, updatedAt, err := postmanContentHash(postmanCollectionV1)
+	if err != nil || updatedAt.IsZero() {
+		t.Fatalf("postmanContentHash() = %q, %v, %v", hash, updatedAt, err)
+	}
+	touched := strings.Replace(postmanCollectionV1, "2026-10-01", "2026-10-05", 1)
+	reformatted := `{ "item": [{"request": {"url": "https://petstore.example.com/pets", "method": "GET"}, "name": "List pets"}], "info": {"name": "Petstore"} }`
+	for _, same := range []string{touched, reformatted} {
+		if other, _, _ := postmanContentHash(same); other != hash {
+			t.Errorf("hash of %s differs from the original", same)
+		}
+	}
+	if other, _, _ := postmanContentHash(postmanCollectionV2); other == hash {
+		t.Error("a changed collection has the same hash")
+	}
+	for _, invalid := range []string{"", "null", "[1]", "{"} {
+		if _, _, err := postmanContentHash(invalid); err == nil {
+			t.Errorf("postmanContentHash(%q) succeeded", invalid)
+		}
+	}
+}
+
+func TestPostmanSync(t *testing.T) {
+	ctx := context.Background()
+	stub, postman := newPostmanStub(t)
+	stub.set("12345-petstore", postmanCollectionV1)
+
+	collection := &models.Collection{
+		ID:             primitive.NewObjectID(),
+		UserID:         "user-1",
+		Source:         models.CollectionSourcePostman,
+		RawPostmanJSON: strings.Replace(postmanCollectionV1, "2026-10-01", "2026-09-01", 1),
+		OpenAPISpec:    `{"openapi":"3.0.0"}`,
+	}
+	repo := &memoryPostmanSync{collections: map[primitive.ObjectID]*models.Collection{collection.ID: collection}}
+	collectionID := collection.ID.Hex()
+	sdks := &memorySDKs{sdks: []*models.SDK{
+		{CollectionID: collectionID, UserID: "user-1", Language: "go", PackageName: "petstore", Status: models.SDKStatusCompleted},
+		{CollectionID: collectionID, UserID: "user-2", Language: "go", PackageName: "petstore", Status: models.SDKStatusCompleted},
+		{CollectionID: collectionID, UserID: "user-1", Language: "python", Status: models.SDKStatusFailed},
+		{CollectionID: collectionID, UserID: "user-1", GenerationType: models.GenerationTypeMCP, MCPLanguage: "typescript", Status: models.SDKStatusCompleted},
+	}}
+	sdkService := &recordingSDKService{completed: make(chan *models.SDK, 10)}
+	audit := NewAuditService(&memoryAuditEvents{}, 0, zap.NewNop())
+	service := NewPostmanSyncService(repo, postman, sdks, sdkService, audit, zap.NewNop())
+
+	// Links are checked before anything is stored
+	openAPI := &models.Collection{ID: primitive.NewObjectID(), Source: models.CollectionSourceOpenAPI}
+	if _, err := service.Link(ctx, openAPI, &models.LinkPostmanCollectionRequest{PostmanUID: "12345-petstore"}); !errors.Is(err, ErrNotPostmanCollection) {
+		t.Errorf("Link() of an OpenAPI collection error = %v, want ErrNotPostmanCollection", err)
+	}
+	if _, err := service.Link(ctx, collection, &models.LinkPostmanCollectionRequest{PostmanUID: "../workspaces"}); !errors.Is(err, ErrInvalidPostmanUID) {
+		t.Errorf("Link() with a path error = %v, want ErrInvalidPostmanUID", err)
+	}
+	if _, err := service.Link(ctx, collection, &models.LinkPostmanCollectionRequest{PostmanUID: "12345-missing"}); !errors.Is(err, ErrPostmanFetchFailed) || collection.PostmanLink != nil {
+		t.Fatalf("Link() of a missing collection error = %v, link = %+v", err, collection.PostmanLink)
+	}
+
+	// Linking stores the first revision; its content is the imported one, so nothing is regenerated
+	linked, err := service.Link(ctx, collection, &models.LinkPostmanCollectionRequest{PostmanUID: "12345-petstore", AutoRegenerate: true})
+	if err != nil {
+		t.Fatalf("Link() error = %v", err)
+	}
+	if !linked.Changed || linked.Revision.Revision != 1 || linked.Revision.Trigger != models.SyncTriggerLink || len(linked.Regenerated) != 0 {
+		t.Errorf("Link() = %+v, want revision 1 without regenerations", linked)
+	}
+	if link := repo.collections[collection.ID].PostmanLink; link == nil || link.Revision != 1 || link.UpdatedAt.Format("2006-01-02") != "2026-10-01" {
+		t.Errorf("stored link = %+v", link)
+	}
+
+	// A collection that was only touched in Postman has no new revision
+	stub.set("12345-petstore", strings.Replace(postmanCollectionV1, "2026-10-01", "2026-10-03", 1))
+	if changed, err := service.SyncAll(ctx); err != nil || changed != 0 {
+		t.Errorf("SyncAll() = %d, %v, want no changes", changed, err)
+	}
+	if link := repo.collections[collection.ID].PostmanLink; link.Revision != 1 || link.UpdatedAt.Format("2006-01-02") != "2026-10-03" || link.LastCheckedAt.IsZero() {
+		t.Errorf("link after an unchanged sync = %+v", link)
+	}
+
+	// A changed collection is stored as a new revision and regenerates every SDK once
+	stub.set("12345-petstore", postmanCollectionV2)
+	if changed, err := service.SyncAll(ctx); err != nil || changed != 1 {
+		t.Fatalf("SyncAll() = %d, %v, want 1 changed collection", changed, err)
+	}
+	select {
+	case <-sdkService.completed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the SDK was not regenerated")
+	}
+	if len(sdkService.requests) != 1 || sdkService.requests[0].Language != "go" || sdkService.requests[0].PackageName != "petstore" || sdkService.requests[0].PostmanJSON != postmanCollectionV2 {
+		t.Errorf("regenerations = %+v, want the go SDK from the new content", sdkService.requests)
+	}
+	stored := repo.collections[collection.ID]
+	if stored.RawPostmanJSON != postmanCollectionV2 || stored.OpenAPISpec != "" || stored.PostmanLink.Revision != 2 {
+		t.Errorf("collection after the change = %+v", stored)
+	}
+	events := recordedAuditEvents(audit)
+	if len(events) != 1 || events[0].Action != models.AuditActionSDKGenerate || events[0].Metadata["trigger"] != "postman_sync" || events[0].Metadata["revision"] != "2" {
+		t.Errorf("audit events = %+v, want the regeneration", events)
+	}
+
+	revisions, err := service.Revisions(ctx, collection)
+	if err != nil || len(revisions) != 2 || revisions[0].Revision != 2 || revisions[0].Trigger != models.SyncTriggerSchedule || revisions[0].RawPostmanJSON != "" {
+		t.Errorf("Revisions() = %+v, %v", revisions, err)
+	}
+
+	// A sync from a stale copy of the collection must not store a revision twice
+	stale := *collection
+	stale.PostmanLink = &models.PostmanLink{UID: "12345-petstore", Revision: 1, ContentHash: linked.Revision.ContentHash}
+	if _, err := service.Sync(ctx, &stale, models.SyncTriggerManual); !errors.Is(err, ErrPostmanSyncConflict) {
+		t.Errorf("Sync() of a stale collection error = %v, want ErrPostmanSyncConflict", err)
+	}
+
+	// A failed check is recorded on the link
+	stub.mu.Lock()
+	stub.failing = true
+	stub.mu.Unlock()
+	current := *repo.collections[collection.ID]
+	if _, err := service.Sync(ctx, &current, models.SyncTriggerManual); !errors.Is(err, ErrPostmanFetchFailed) {
+		t.Errorf("Sync() while Postman fails error = %v, want ErrPostmanFetchFailed", err)
+	}
+	if link := repo.collections[collection.ID].PostmanLink; link.LastError == "" || link.Revision != 2 {
+		t.Errorf("link after a failed sync = %+v", link)
+	}
+
+	if err := service.Unlink(ctx, collection); err != nil || repo.collections[collection.ID].PostmanLink != nil {
+		t.Errorf("Unlink() error = %v", err)
+	}
+	if _, err := service.Sync(ctx, collection, models.SyncTriggerManual); !errors.Is(err, ErrCollectionNotLinked) {
+		t.Errorf("Sync() of an unlinked collection error = %v, want ErrCollectionNotLinked", err)
+	}
+}
@@ -0,0 +1,512 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/openapi"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"github.com/AkashKesav/API2SDK/internal/signing"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Names of generated prompts, relative to the integration: the authentication guide,
+// a workflow per tag (Postman folder) and a usage prompt per operation.
+const (
+	authenticatePromptName   = "authenticate"
+	workflowPromptPrefix     = "workflow_"
+	usagePromptPrefix        = "use_"
+	maxPromptExampleBytes    = 2000
+	maxCustomPromptMessages  = 50
+	maxCustomPromptTextBytes = 64 << 10
+)
+
+// ErrPromptNotFound is returned for prompts an integration does not have.
+var ErrPromptNotFound = errors.New("prompt not found")
+
+// ErrInvalidPromptArguments is returned when a prompt is rendered without a required argument.
+var ErrInvalidPromptArguments = errors.New("invalid prompt arguments")
+
+// ErrInvalidPrompt is returned when a custom prompt cannot be saved as it is.
+var ErrInvalidPrompt = errors.New("invalid prompt")
+
+var (
+	promptPlaceholder = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+	promptNameChars   = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+)
+
+// PromptService provides the MCP prompts of integrations. Prompts are generated from
+// the spec and complemented or replaced, by name, by prompts authored by admins.
+//
+// MCP servers expose the prompts of several integrations, so the names they list are
+// qualified with the integration as <integration>_<name>, see PromptPrefix.
+type PromptService interface {
+	// ListPrompts returns the prompts of an integration under their qualified names.
+	ListPrompts(ctx context.Context, integration *models.Integration) ([]models.PromptDescriptor, error)
+	// GetPrompt renders the prompt with the qualified name. It returns ErrPromptNotFound
+	// when the integration has no such prompt.
+	GetPrompt(ctx context.Context, integration *models.Integration, name string, arguments map[string]string) (*models.PromptResult, error)
+	// Prompts returns the generated and custom prompts of an integration by relative name,
+	// custom prompts replacing generated ones of the same name.
+	Prompts(ctx context.Context, integration *models.Integration) ([]*models.Prompt, error)
+	ListCustomPrompts(ctx context.Context, integrationID primitive.ObjectID) ([]*models.Prompt, error)
+	// SaveCustomPrompt creates or replaces the custom prompt with the prompt's name.
+	SaveCustomPrompt(ctx context.Context, prompt *models.Prompt) (*models.Prompt, error)
+	DeleteCustomPrompt(ctx context.Context, integrationID primitive.ObjectID, name string) error
+}
+
+// generatedPrompts are the prompts generated for one version of an integration.
+type generatedPrompts struct {
+	updatedAt time.Time
+	prompts   []*models.Prompt
+}
+
+// promptService is the concrete implementation of PromptService.
+type promptService struct {
+	repo repositories.PromptRepository
+	mu   sync.Mutex
+	// generated caches generated prompts by integration, as long as the integration is unchanged.
+	generated map[primitive.ObjectID]generatedPrompts
+}
+
+// NewPromptService creates a new PromptService.
+func NewPromptService(repo repositories.PromptRepository) PromptService {
+	return &promptService{repo: repo, generated: make(map[primitive.ObjectID]generatedPrompts)}
+}
+
+// PromptPrefix returns the prefix that qualifies the prompt names of an integration.
+func PromptPrefix(integration *models.Integration) string {
+	return strings.ToLower(openapi.ToolName(integration.Name)) + "_"
+}
+
+// ListPrompts returns the prompts of an integration under their qualified names.
+func (s *promptService) ListPrompts(ctx context.Context, integration *models.Integration) ([]models.PromptDescriptor, error) {
+	prompts, err := s.Prompts(ctx, integration)
+	if err != nil {
+		return nil, err
+	}
+	prefix := PromptPrefix(integration)
+	descriptors := make([]models.PromptDescriptor, 0, len(prompts))
+	for _, prompt := range prompts {
+		descriptors = append(descriptors, prompt.Descriptor(prefix+prompt.Name))
+	}
+	return descriptors, nil
+}
+
+// GetPrompt renders the prompt with the qualified name.
+func (s *promptService) GetPrompt(ctx context.Context, integration *models.Integration, name string, arguments map[string]string) (*models.PromptResult, error) {
+	relative, ok := strings.CutPrefix(name, PromptPrefix(integration))
+	if !ok {
+		return nil, ErrPromptNotFound
+	}
+	prompts, err := s.Prompts(ctx, integration)
+	if err != nil {
+		return nil, err
+	}
+	for _, prompt := range prompts {
+		if prompt.Name == relative {
+			return RenderPrompt(prompt, arguments)
+		}
+	}
+	return nil, ErrPromptNotFound
+}
+
+// Prompts returns the generated and custom prompts of an integration.
+func (s *promptService) Prompts(ctx context.Context, integration *models.Integration) ([]*models.Prompt, error) {
+	generated, err := s.generatedPrompts(integration)
+	if err != nil {
+		return nil, err
+	}
+	custom, err := s.repo.ListByIntegration(ctx, integration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custom prompts: %w", err)
+	}
+
+	customNames := make(map[string]bool, len(custom))
+	prompts := make([]*models.Prompt, 0, len(generated)+len(custom))
+	for _, prompt := range custom {
+		prompt.Source = models.PromptSourceCustom
+		customNames[prompt.Name] = true
+	}
+	for _, prompt := range generated {
+		if !customNames[prompt.Name] {
+			prompts = append(prompts, prompt)
+		}
+	}
+	return append(prompts, custom...), nil
+}
+
+// ListCustomPrompts returns the custom prompts of an integration.
+func (s *promptService) ListCustomPrompts(ctx context.Context, integrationID primitive.ObjectID) ([]*models.Prompt, error) {
+	prompts, err := s.repo.ListByIntegration(ctx, integrationID)
+	if err != nil {
+		return nil, err
+	}
+	for _, prompt := range prompts {
+		prompt.Source = models.PromptSourceCustom
+	}
+	return prompts, nil
+}
+
+// SaveCustomPrompt validates and stores a custom prompt.
+func (s *promptService) SaveCustomPrompt(ctx context.Context, prompt *models.Prompt) (*models.Prompt, error) {
+	if err := validatePrompt(prompt); err != nil {
+		return nil, err
+	}
+	saved, err := s.repo.Save(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	saved.Source = models.PromptSourceCustom
+	return saved, nil
+}
+
+// DeleteCustomPrompt removes a custom prompt.
+func (s *promptService) DeleteCustomPrompt(ctx context.Context, integrationID primitive.ObjectID, name string) error {
+	err := s.repo.Delete(ctx, integrationID, name)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrPromptNotFound
+	}
+	return err
+}
+
+// validatePrompt checks that a custom prompt can be listed and rendered.
+func validatePrompt(prompt *models.Prompt) error {
+	if !promptNameChars.MatchString(prompt.Name) {
+		return fmt.Errorf("%w: name must be non-empty and consist of letters, digits, '_', '-' and '.'", ErrInvalidPrompt)
+	}
+	if len(prompt.Messages) == 0 || len(prompt.Messages) > maxCustomPromptMessages {
+		return fmt.Errorf("%w: a prompt needs between 1 and %d messages", ErrInvalidPrompt, maxCustomPromptMessages)
+	}
+	declared := make(map[string]bool, len(prompt.Arguments))
+	for _, argument := range prompt.Arguments {
+		if !promptNameChars.MatchString(argument.Name) {
+			return fmt.Errorf("%w: invalid argument name %q", ErrInvalidPrompt, argument.Name)
+		}
+		if declared[argument.Name] {
+			return fmt.Errorf("%w: duplicate argument %q", ErrInvalidPrompt, argument.Name)
+		}
+		declared[argument.Name] = true
+	}
+	for i, message := range prompt.Messages {
+		if message.Role != models.PromptRoleUser && message.Role != models.PromptRoleAssistant {
+			return fmt.Errorf("%w: message %d: role must be %q or %q", ErrInvalidPrompt, i, models.PromptRoleUser, models.PromptRoleAssistant)
+		}
+		if message.Text == "" || len(message.Text) > maxCustomPromptTextBytes {
+			return fmt.Errorf("%w: message %d: text must be non-empty and at most %d bytes", ErrInvalidPrompt, i, maxCustomPromptTextBytes)
+		}
+		for _, match := range promptPlaceholder.FindAllStringSubmatch(message.Text, -1) {
+			if !declared[match[1]] {
+				return fmt.Errorf("%w: message %d references undeclared argument %q", ErrInvalidPrompt, i, match[1])
+			}
+		}
+	}
+	return nil
+}
+
+// RenderPrompt substitutes the arguments into the {{name}} placeholders of a prompt.
+// Lines that only reference arguments that were not given are left out, so templates can
+// mention optional arguments on lines of their own.
+func RenderPrompt(prompt *models.Prompt, arguments map[string]string) (*models.PromptResult, error) {
+	var missing []string
+	for _, argument := range prompt.Arguments {
+		if argument.Required && arguments[argument.Name] == "" {
+			missing = append(missing, argument.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: missing required arguments: %s", ErrInvalidPromptArguments, strings.Join(missing, ", "))
+	}
+
+	result := &models.PromptResult{Description: prompt.Description, Messages: []models.PromptResultMessage{}}
+	for _, message := range prompt.Messages {
+		lines := strings.Split(message.Text, "\n")
+		kept := lines[:0]
+		for _, line := range lines {
+			matches := promptPlaceholder.FindAllStringSubmatch(line, -1)
+			given := len(matches) == 0
+			for _, match := range matches {
+				if arguments[match[1]] != "" {
+					given = true
+				}
+			}
+			if given {
+				kept = append(kept, promptPlaceholder.ReplaceAllStringFunc(line, func(placeholder string) string {
+					return arguments[promptPlaceholder.FindStringSubmatch(placeholder)[1]]
+				}))
+			}
+		}
+		result.Messages = append(result.Messages, models.PromptResultMessage{
+			Role:    message.Role,
+			Content: models.ContentBlock{Type: models.ContentTypeText, Text: strings.Join(kept, "\n")},
+		})
+	}
+	return result, nil
+}
+
+// generatedPrompts returns the prompts derived from the integration's spec, cached until
+// the integration changes.
+func (s *promptService) generatedPrompts(integration *models.Integration) ([]*models.Prompt, error) {
+	s.mu.Lock()
+	cached, ok := s.generated[integration.ID]
+	s.mu.Unlock()
+	if ok && cached.updatedAt.Equal(integration.UpdatedAt) {
+		return cached.prompts, nil
+	}
+
+	prompts, err := GeneratePrompts(integration)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.generated[integration.ID] = generatedPrompts{updatedAt: integration.UpdatedAt, prompts: prompts}
+	s.mu.Unlock()
+	return prompts, nil
+}
+
+// GeneratePrompts derives prompts from the integration's spec: how to authenticate, one
+// workflow per tag with its operations in document order, and a usage prompt per operation
+// with its parameters as arguments. Collections converted from Postman have a tag per folder,
+// so their workflows follow the folders' request sequences and descriptions.
+func GeneratePrompts(integration *models.Integration) ([]*models.Prompt, error) {
+	var doc *openapi.Document
+	if integration.OpenAPISpec != "" {
+		var err error
+		if doc, err = openapi.Parse([]byte(integration.OpenAPISpec)); err != nil {
+			return nil, fmt.Errorf("failed to parse OpenAPI spec for integration %s: %w", integration.Name, err)
+		}
+	}
+
+	prompts := []*models.Prompt{authenticatePrompt(integration, doc)}
+	if doc != nil {
+		operations := append([]*openapi.Operation(nil), doc.Operations...)
+		sort.SliceStable(operations, func(i, j int) bool { return operations[i].Position < operations[j].Position })
+
+		prompts = append(prompts, workflowPrompts(integration, doc, operations)...)
+		for _, op := range operations {
+			prompts = append(prompts, usagePrompt(integration, op))
+		}
+	}
+	for _, prompt := range prompts {
+		prompt.IntegrationID = integration.ID
+		prompt.Source = models.PromptSourceGenerated
+	}
+	return prompts, nil
+}
+
+// authenticatePrompt explains how requests to the integration are authenticated.
+func authenticatePrompt(integration *models.Integration, doc *openapi.Document) *models.Prompt {
+	var text strings.Builder
+	fmt.Fprintf(&text, "Explain how to authenticate with the %s API through this server.\n\n", integration.Name)
+
+	auth, err := ResolveAuthScheme(integration, doc, nil)
+	if err != nil {
+		fmt.Fprintf(&text, "The configured authentication is invalid: %s.\n", err)
+	} else {
+		switch auth.Type {
+		case signing.TypeOAuth2:
+			text.WriteString("Requests are authorized with OAuth2 access tokens. Each user links their account once through the OAuth2 authorization flow of the linked accounts API; tokens are refreshed automatically.\n")
+		case signing.TypeAPIKey:
+			fmt.Fprintf(&text, "Requests carry an API key in the %s %q. Each user stores their key as a linked account.\n", auth.Options["in"], auth.Options["name"])
+		case signing.TypeBasic:
+			text.WriteString("Requests use HTTP basic authentication with the username and password stored in the user's linked account.\n")
+		case signing.TypeBearer:
+			text.WriteString("Requests carry a bearer token, taken from the user's linked account or else the integration's API key.\n")
+		default:
+			fmt.Fprintf(&text, "Requests are signed with the %q scheme using the credentials of the user's linked account.\n", auth.Type)
+		}
+	}
+
+	if doc != nil && len(doc.SecuritySchemes) > 0 {
+		names := make([]string, 0, len(doc.SecuritySchemes))
+		for name := range doc.SecuritySchemes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		text.WriteString("\nThe API documents these security schemes:\n")
+		for _, name := range names {
+			scheme := doc.SecuritySchemes[name]
+			fmt.Fprintf(&text, "- %s (%s", name, scheme.Type)
+			if scheme.Scheme != "" {
+				fmt.Fprintf(&text, ", %s", scheme.Scheme)
+			}
+			text.WriteString(")")
+			if scheme.Description != "" {
+				fmt.Fprintf(&text, ": %s", scheme.Description)
+			}
+			text.WriteString("\n")
+			if scheme.Flows != nil && scheme.Flows.AuthorizationCode != nil && len(scheme.Flows.AuthorizationCode.Scopes) > 0 {
+				scopes := make([]string, 0, len(scheme.Flows.AuthorizationCode.Scopes))
+				for scope := range scheme.Flows.AuthorizationCode.Scopes {
+					scopes = append(scopes, scope)
+				}
+				sort.Strings(scopes)
+				fmt.Fprintf(&text, "  Scopes: %s\n", strings.Join(scopes, ", "))
+			}
+		}
+	}
+
+	return &models.Prompt{
+		Name:        authenticatePromptName,
+		Title:       "Authenticate with " + integration.Name,
+		Description: fmt.Sprintf("How to authenticate with the %s API", integration.Name),
+		Messages:    []models.PromptMessage{{Role: models.PromptRoleUser, Text: strings.TrimRight(text.String(), "\n")}},
+	}
+}
+
+// workflowPrompts returns a prompt per tag that walks through the tag's operations in order.
+// Tags used by operations but not declared follow the declared ones, in order of first use.
+func workflowPrompts(integration *models.Integration, doc *openapi.Document, operations []*openapi.Operation) []*models.Prompt {
+	tags := append([]openapi.Tag(nil), doc.Tags...)
+	declared := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		declared[tag.Name] = true
+	}
+	steps := make(map[string][]*openapi.Operation)
+	for _, op := range operations {
+		for _, tag := range op.Tags {
+			if !declared[tag] {
+				declared[tag] = true
+				tags = append(tags, openapi.Tag{Name: tag})
+			}
+			steps[tag] = append(steps[tag], op)
+		}
+	}
+
+	var prompts []*models.Prompt
+	used := make(map[string]bool)
+	for _, tag := range tags {
+		if len(steps[tag.Name]) == 0 {
+			continue
+		}
+		name := workflowPromptPrefix + strings.ToLower(openapi.ToolName(tag.Name))
+		if used[name] {
+			continue
+		}
+		used[name] = true
+
+		var text strings.Builder
+		fmt.Fprintf(&text, "Carry out the %q workflow of the %s API.\n", tag.Name, integration.Name)
+		if tag.Description != "" {
+			fmt.Fprintf(&text, "\n%s\n", tag.Description)
+		}
+		text.WriteString("\nThe workflow consists of these steps, in order:\n")
+		for i, op := range steps[tag.Name] {
+			fmt.Fprintf(&text, "%d. %s (%s %s): %s\n", i+1, op.ToolName, op.Method, op.Path, firstLine(op.DisplayDescription()))
+			if example := exampleArguments(op); example != "" {
+				fmt.Fprintf(&text, "   Example arguments: %s\n", example)
+			}
+		}
+		text.WriteString("\nUse the results of earlier steps as arguments of later ones.\nGoal: {{goal}}")
+
+		description := tag.Description
+		if description == "" {
+			description = fmt.Sprintf("The %s requests of the %s API, in order", tag.Name, integration.Name)
+		}
+		prompts = append(prompts, &models.Prompt{
+			Name:        name,
+			Title:       fmt.Sprintf("%s: %s workflow", integration.Name, tag.Name),
+			Description: firstLine(description),
+			Arguments:   []models.PromptArgument{{Name: "goal", Description: "What to achieve with the workflow"}},
+			Messages:    []models.PromptMessage{{Role: models.PromptRoleUser, Text: text.String()}},
+		})
+	}
+	return prompts
+}
+
+// usagePrompt asks for a call of the operation, with its parameters as arguments.
+func usagePrompt(integration *models.Integration, op *openapi.Operation) *models.Prompt {
+	var arguments []models.PromptArgument
+	var text strings.Builder
+	fmt.Fprintf(&text, "Use the %s tool of the %s API (%s %s).\n\n%s\n", op.ToolName, integration.Name, op.Method, op.Path, op.DisplayDescription())
+	if example := exampleArguments(op); example != "" {
+		fmt.Fprintf(&text, "\nExample arguments: %s\n", example)
+	}
+	text.WriteString("\nCall it with these arguments:")
+
+	for _, p := range op.Parameters {
+		if p.Name == "body" && op.RequestBody != nil {
+			// The tool passes the request body under "body", hiding the parameter.
+			continue
+		}
+		description := p.Description
+		if description == "" {
+			description = fmt.Sprintf("%s parameter %q", p.In, p.Name)
+		}
+		if p.Example != nil {
+			description += " (e.g. " + exampleText(p.Example) + ")"
+		}
+		arguments = append(arguments, models.PromptArgument{Name: p.Name, Description: description, Required: p.Required})
+		fmt.Fprintf(&text, "\n%s: {{%s}}", p.Name, p.Name)
+	}
+	if op.RequestBody != nil {
+		description := op.RequestBody.Description
+		if description == "" {
+			description = fmt.Sprintf("Request body (%s)", op.RequestBody.ContentType)
+		}
+		arguments = append(arguments, models.PromptArgument{Name: "body", Description: description + " as JSON", Required: op.RequestBody.Required})
+		text.WriteString("\nbody: {{body}}")
+	}
+	if len(arguments) == 0 {
+		text.WriteString(" none.")
+	}
+
+	title := op.Summary
+	if title == "" {
+		title = op.ToolName
+	}
+	return &models.Prompt{
+		Name:        usagePromptPrefix + op.ToolName,
+		Title:       fmt.Sprintf("%s: %s", integration.Name, title),
+		Description: firstLine(op.DisplayDescription()),
+		Arguments:   arguments,
+		Messages:    []models.PromptMessage{{Role: models.PromptRoleUser, Text: text.String()}},
+	}
+}
+
+// exampleArguments returns the examples of an operation's parameters and body as JSON
+// tool arguments, or an empty string when it has none.
+func exampleArguments(op *openapi.Operation) string {
+	example := map[string]interface{}{}
+	for _, p := range op.Parameters {
+		if p.Example != nil {
+			example[p.Name] = p.Example
+		}
+	}
+	if op.RequestBody != nil && op.RequestBody.Example != nil && op.RequestBody.Example != "" {
+		example["body"] = op.RequestBody.Example
+	}
+	if len(example) == 0 {
+		return ""
+	}
+	return exampleText(example)
+}
+
+// exampleText renders an example compactly, truncating large ones.
+func exampleText(value interface{}) string {
+	if s, ok := value.(string); ok && len(s) <= maxPromptExampleBytes {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	if len(data) > maxPromptExampleBytes {
+		return string(data[:maxPromptExampleBytes]) + "..."
+	}
+	return string(data)
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return line
+}
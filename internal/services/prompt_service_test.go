@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const promptSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "CRM", "version": "1.0.0"},
+  "tags": [{"name": "Contacts", "description": "Manage contacts"}],
+  "paths": {
+    "/contacts": {"post": {"operationId": "create_contact", "tags": ["Contacts"], "summary": "Create a contact",
+      "requestBody": {"required": true, "content": {"application/json": {"schema": {"type": "object"}}}}}},
+    "/contacts/{id}": {"get": {"operationId": "get_contact", "tags": ["Contacts"], "summary": "Get a contact",
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}, "example": "c-1"}]}}
+  }
+}`
+
+// memoryPrompts keeps custom prompts in memory; unused methods panic.
+type memoryPrompts struct {
+	repositories.PromptRepository
+	prompts []*models.Prompt
+}
+
+func (r *memoryPrompts) Save(ctx context.Context, prompt *models.Prompt) (*models.Prompt, error) {
+	r.Delete(ctx, prompt.IntegrationID, prompt.Name)
+	r.prompts = append(r.prompts, prompt)
+	return prompt, nil
+}
+
+func (r *memoryPrompts) ListByIntegration(ctx context.Context, integrationID primitive.ObjectID) ([]*models.Prompt, error) {
+	var prompts []*models.Prompt
+	for _, prompt := range r.prompts {
+		if prompt.IntegrationID == integrationID {
+			copied := *prompt
+			prompts = append(prompts, &copied)
+		}
+	}
+	return prompts, nil
+}
+
+func (r *memoryPrompts) Delete(ctx context.Context, integrationID primitive.ObjectID, name string) error {
+	for i, prompt := range r.prompts {
+		if prompt.IntegrationID == integrationID && prompt.Name == name {
+			r.prompts = append(r.prompts[:i], r.prompts[i+1:]...)
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func promptNames(descriptors []models.PromptDescriptor) []string {
+	names := make([]string, 0, len(descriptors))
+	for _, descriptor := range descriptors {
+		names = append(names, descriptor.Name)
+	}
+	return names
+}
+
+func TestGeneratePrompts(t *testing.T) {
+	integration := &models.Integration{ID: primitive.NewObjectID(), Name: "CRM", OpenAPISpec: promptSpec}
+	prompts, err := GeneratePrompts(integration)
+	if err != nil {
+		t.Fatalf("GeneratePrompts() error = %v", err)
+	}
+	byName := map[string]*models.Prompt{}
+	var names []string
+	for _, prompt := range prompts {
+		byName[prompt.Name] = prompt
+		names = append(names, prompt.Name)
+		if prompt.Source != models.PromptSourceGenerated || prompt.IntegrationID != integration.ID {
+			t.Errorf("prompt %s: source = %q, integration = %s", prompt.Name, prompt.Source, prompt.IntegrationID.Hex())
+		}
+	}
+	want := "authenticate,workflow_contacts,use_create_contact,use_get_contact"
+	if got := strings.Join(names, ","); got != want {
+		t.Fatalf("prompts = %s, want %s", got, want)
+	}
+
+	workflow := byName["workflow_contacts"].Messages[0].Text
+	if !strings.Contains(workflow, "1. create_contact") || !strings.Contains(workflow, "2. get_contact") {
+		t.Errorf("workflow does not list the operations in order:\n%s", workflow)
+	}
+	get := byName["use_get_contact"]
+	if len(get.Arguments) != 1 || get.Arguments[0].Name != "id" || !get.Arguments[0].Required || !strings.Contains(get.Arguments[0].Description, "c-1") {
+		t.Errorf("use_get_contact arguments = %+v", get.Arguments)
+	}
+	create := byName["use_create_contact"]
+	if len(create.Arguments) != 1 || create.Arguments[0].Name != "body" || !create.Arguments[0].Required {
+		t.Errorf("use_create_contact arguments = %+v", create.Arguments)
+	}
+
+	if _, err := GeneratePrompts(&models.Integration{Name: "Broken", OpenAPISpec: "{"}); err == nil {
+		t.Error("GeneratePrompts() accepted an invalid spec")
+	}
+}
+
+func TestRenderPrompt(t *testing.T) {
+	prompt := &models.Prompt{
+		Arguments: []models.PromptArgument{{Name: "id", Required: true}, {Name: "note"}},
+		Messages:  []models.PromptMessage{{Role: models.PromptRoleUser, Text: "Get {{ id }}.\nNote: {{note}}\nThanks"}},
+	}
+
+	result, err := RenderPrompt(prompt, map[string]string{"id": "c-1"})
+	if err != nil {
+		t.Fatalf("RenderPrompt() error = %v", err)
+	}
+	if got := result.Messages[0].Content.Text; got != "Get c-1.\nThanks" {
+		t.Errorf("text = %q, want the optional line left out", got)
+	}
+	result, err = RenderPrompt(prompt, map[string]string{"id": "c-1", "note": "urgent"})
+	if err != nil {
+		t.Fatalf("RenderPrompt() error = %v", err)
+	}
+	if got := result.Messages[0].Content.Text; got != "Get c-1.\nNote: urgent\nThanks" {
+		t.Errorf("text = %q", got)
+	}
+
+	if _, err := RenderPrompt(prompt, map[string]string{"note": "urgent"}); !errors.Is(err, ErrInvalidPromptArguments) {
+		t.Errorf("RenderPrompt() without a required argument error = %v, want ErrInvalidPromptArguments", err)
+	}
+}
+
+func TestCustomPromptsReplaceGenerated(t *testing.T) {
+	ctx := context.Background()
+	integration := &models.Integration{ID: primitive.NewObjectID(), Name: "CRM", OpenAPISpec: promptSpec}
+	service := NewPromptService(&memoryPrompts{})
+
+	custom := &models.Prompt{
+		IntegrationID: integration.ID,
+		Name:          "authenticate",
+		Arguments:     []models.PromptArgument{{Name: "user", Required: true}},
+		Messages:      []models.PromptMessage{{Role: models.PromptRoleUser, Text: "Ask {{user}} for the team key."}},
+	}
+	if _, err := service.SaveCustomPrompt(ctx, custom); err != nil {
+		t.Fatalf("SaveCustomPrompt() error = %v", err)
+	}
+
+	descriptors, err := service.ListPrompts(ctx, integration)
+	if err != nil {
+		t.Fatalf("ListPrompts() error = %v", err)
+	}
+	want := "crm_workflow_contacts,crm_use_create_contact,crm_use_get_contact,crm_authenticate"
+	if got := strings.Join(promptNames(descriptors), ","); got != want {
+		t.Fatalf("prompts = %s, want %s", got, want)
+	}
+
+	result, err := service.GetPrompt(ctx, integration, "crm_authenticate", map[string]string{"user": "alice"})
+	if err != nil {
+		t.Fatalf("GetPrompt() error = %v", err)
+	}
+	if got := result.Messages[0].Content.Text; got != "Ask alice for the team key." {
+		t.Errorf("custom prompt text = %q", got)
+	}
+	for _, name := range []string{"authenticate", "crm_missing", "docs_authenticate"} {
+		if _, err := service.GetPrompt(ctx, integration, name, nil); !errors.Is(err, ErrPromptNotFound) {
+			t.Errorf("GetPrompt(%q) error = %v, want ErrPromptNotFound", name, err)
+		}
+	}
+
+	if err := service.DeleteCustomPrompt(ctx, integration.ID, "authenticate"); err != nil {
+		t.Fatalf("DeleteCustomPrompt() error = %v", err)
+	}
+	if err := service.DeleteCustomPrompt(ctx, integration.ID, "authenticate"); !errors.Is(err, ErrPromptNotFound) {
+		t.Errorf("second DeleteCustomPrompt() error = %v, want ErrPromptNotFound", err)
+	}
+	result, err = service.GetPrompt(ctx, integration, "crm_authenticate", nil)
+	if err != nil || strings.Contains(result.Messages[0].Content.Text, "team key") {
+		t.Errorf("generated prompt is not restored: %+v, %v", result, err)
+	}
+}
+
+func TestSaveCustomPromptValidates(t *testing.T) {
+	message := []models.PromptMessage{{Role: models.PromptRoleUser, Text: "Hello"}}
+	tests := map[string]*models.Prompt{
+		"empty name":          {Messages: message},
+		"invalid name":        {Name: "a b", Messages: message},
+		"no messages":         {Name: "greet"},
+		"unknown role":        {Name: "greet", Messages: []models.PromptMessage{{Role: "system", Text: "Hello"}}},
+		"empty text":          {Name: "greet", Messages: []models.PromptMessage{{Role: models.PromptRoleUser}}},
+		"undeclared argument": {Name: "greet", Messages: []models.PromptMessage{{Role: models.PromptRoleUser, Text: "Hello {{name}}"}}},
+		"duplicate argument": {Name: "greet", Messages: message,
+			Arguments: []models.PromptArgument{{Name: "name"}, {Name: "name"}}},
+	}
+	service := NewPromptService(&memoryPrompts{})
+	for name, prompt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := service.SaveCustomPrompt(context.Background(), prompt); !errors.Is(err, ErrInvalidPrompt) {
+				t.Errorf("SaveCustomPrompt() error = %v, want ErrInvalidPrompt", err)
+			}
+		})
+	}
+}
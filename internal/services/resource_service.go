@@ -0,0 +1,519 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/openapi"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// URI schemes of the resources served by MCP servers.
+const (
+	IntegrationResourceScheme = "integration://"
+	CollectionResourceScheme  = "collection://"
+	OpenAPIResourceScheme     = "openapi://"
+	SDKResourceScheme         = "sdk://"
+)
+
+const (
+	// maxListedSDKs bounds the SDK manifests listed as resources; older ones stay readable.
+	maxListedSDKs = 100
+	// maxManifestFiles bounds the archive entries listed in an SDK manifest.
+	maxManifestFiles = 1000
+	// maxCachedSpecs bounds the converted collection specs kept in memory.
+	maxCachedSpecs = 32
+)
+
+// ErrResourceNotFound is returned for resource URIs that do not name a resource visible in the scope.
+var ErrResourceNotFound = errors.New("resource not found")
+
+// ResourceScope limits the resources an MCP server exposes.
+type ResourceScope struct {
+	// OwnerID is the user whose collections and SDKs are visible, along with those of the
+	// user's organizations. Without it, none are.
+	OwnerID string
+	// Integration, when set, reports whether an integration is visible.
+	Integration func(*models.Integration) bool
+}
+
+func (scope ResourceScope) allows(integration *models.Integration) bool {
+	return scope.Integration == nil || scope.Integration(integration)
+}
+
+// ResourceService resolves the MCP resources of the platform against its repositories:
+//
+//	integration://<id>                        an integration and its tools
+//	collection://<id>                         a collection, with its Postman collection
+//	openapi://<collection>                    the OpenAPI document of a collection, JSON or YAML
+//	openapi://<collection>/paths              the operations of the document
+//	openapi://<collection>/paths/<operation>  an operation, by tool name or operation ID
+//	openapi://<collection>/schemas            the component schemas of the document
+//	openapi://<collection>/schemas/<schema>   a component schema, with references resolved
+//	sdk://<id>/manifest                       a generated SDK or MCP server and its files
+type ResourceService interface {
+	ListResources(ctx context.Context, scope ResourceScope) ([]models.Resource, error)
+	ListResourceTemplates() []models.ResourceTemplate
+	// ReadResource returns the contents of a resource, or ErrResourceNotFound.
+	ReadResource(ctx context.Context, scope ResourceScope, uri string) (*models.ResourceContents, error)
+}
+
+// cachedSpec is the OpenAPI document of one version of a collection.
+type cachedSpec struct {
+	updatedAt time.Time
+	spec      string
+	doc       *openapi.Document
+}
+
+// resourceService is the concrete implementation of ResourceService.
+type resourceService struct {
+	integrationService IntegrationService
+	toolProvider       ToolProvider
+	collectionService  *CollectionService
+	sdkRepo            repositories.SDKRepositoryInterface
+	mu                 sync.Mutex
+	// specs caches collection documents, as converting a Postman collection is slow.
+	specs map[primitive.ObjectID]*cachedSpec
+}
+
+// NewResourceService creates a new ResourceService.
+func NewResourceService(integrationService IntegrationService, toolProvider ToolProvider, collectionService *CollectionService, sdkRepo repositories.SDKRepositoryInterface) ResourceService {
+	return &resourceService{
+		integrationService: integrationService,
+		toolProvider:       toolProvider,
+		collectionService:  collectionService,
+		sdkRepo:            sdkRepo,
+		specs:              make(map[primitive.ObjectID]*cachedSpec),
+	}
+}
+
+// ListResources returns the integrations, collections, OpenAPI documents and SDKs in scope.
+// Operations and schemas are reached through the resource templates.
+func (s *resourceService) ListResources(ctx context.Context, scope ResourceScope) ([]models.Resource, error) {
+	integrations, err := s.integrationService.ListIntegrations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list integrations: %w", err)
+	}
+	resources := []models.Resource{}
+	for _, integration := range integrations {
+		if !scope.allows(integration) {
+			continue
+		}
+		resources = append(resources, models.Resource{
+			URI:         IntegrationResourceScheme + integration.ID.Hex(),
+			Name:        integration.Name,
+			Description: integration.Description,
+			MimeType:    models.MimeTypeJSON,
+		})
+	}
+	if scope.OwnerID == "" {
+		return resources, nil
+	}
+
+	collections, err := s.collectionService.GetAccessibleCollections(ctx, scope.OwnerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	for _, collection := range collections {
+		specMimeType := models.MimeTypeJSON
+		if collection.OpenAPISpec != "" {
+			specMimeType = specType(collection.OpenAPISpec)
+		}
+		resources = append(resources,
+			models.Resource{
+				URI:         CollectionResourceScheme + collection.ID.Hex(),
+				Name:        collection.Name,
+				Description: collection.Description,
+				MimeType:    models.MimeTypeJSON,
+			},
+			models.Resource{
+				URI:         OpenAPIResourceScheme + collection.ID.Hex(),
+				Name:        collection.Name + " OpenAPI",
+				Description: "The OpenAPI document of " + collection.Name,
+				MimeType:    specMimeType,
+			})
+	}
+
+	organizationIDs, err := s.collectionService.authorizer.OrganizationIDs(ctx, scope.OwnerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	sdks, _, err := s.sdkRepo.GetAccessible(ctx, scope.OwnerID, organizationIDs, 1, maxListedSDKs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SDKs: %w", err)
+	}
+	for _, sdk := range sdks {
+		resources = append(resources, models.Resource{
+			URI:         SDKResourceScheme + sdk.ID.Hex() + "/manifest",
+			Name:        sdkName(sdk),
+			Description: fmt.Sprintf("Manifest of a generated %s (%s)", sdk.GenerationType, sdk.Status),
+			MimeType:    models.MimeTypeJSON,
+		})
+	}
+	return resources, nil
+}
+
+// ListResourceTemplates describes the resources by their URI templates.
+func (s *resourceService) ListResourceTemplates() []models.ResourceTemplate {
+	return []models.ResourceTemplate{
+		{URITemplate: IntegrationResourceScheme + "{integrationId}", Name: "integration", Description: "An integration with its authentication and tools", MimeType: models.MimeTypeJSON},
+		{URITemplate: CollectionResourceScheme + "{collectionId}", Name: "collection", Description: "A collection with its endpoints and Postman collection", MimeType: models.MimeTypeJSON},
+		{URITemplate: OpenAPIResourceScheme + "{collectionId}", Name: "openapi", Description: "The OpenAPI document of a collection, as JSON or YAML"},
+		{URITemplate: OpenAPIResourceScheme + "{collectionId}/paths", Name: "openapi-operations", Description: "The operations of a collection's OpenAPI document, with their URIs", MimeType: models.MimeTypeJSON},
+		{URITemplate: OpenAPIResourceScheme + "{collectionId}/paths/{operation}", Name: "openapi-operation", Description: "An operation of a collection's OpenAPI document, by tool name or operation ID", MimeType: models.MimeTypeJSON},
+		{URITemplate: OpenAPIResourceScheme + "{collectionId}/schemas", Name: "openapi-schemas", Description: "The component schemas of a collection's OpenAPI document, with their URIs", MimeType: models.MimeTypeJSON},
+		{URITemplate: OpenAPIResourceScheme + "{collectionId}/schemas/{schema}", Name: "openapi-schema", Description: "A component schema of a collection's OpenAPI document, with references resolved", MimeType: models.MimeTypeJSON},
+		{URITemplate: SDKResourceScheme + "{sdkId}/manifest", Name: "sdk-manifest", Description: "A generated SDK or MCP server with the files of its archive", MimeType: models.MimeTypeJSON},
+	}
+}
+
+// ReadResource returns the contents of a resource.
+func (s *resourceService) ReadResource(ctx context.Context, scope ResourceScope, uri string) (*models.ResourceContents, error) {
+	switch {
+	case strings.HasPrefix(uri, IntegrationResourceScheme):
+		return s.readIntegration(ctx, scope, uri)
+	case strings.HasPrefix(uri, CollectionResourceScheme):
+		return s.readCollection(ctx, scope, uri)
+	case strings.HasPrefix(uri, OpenAPIResourceScheme):
+		return s.readOpenAPI(ctx, scope, uri)
+	case strings.HasPrefix(uri, SDKResourceScheme):
+		return s.readSDKManifest(ctx, scope, uri)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+	}
+}
+
+func (s *resourceService) readIntegration(ctx context.Context, scope ResourceScope, uri string) (*models.ResourceContents, error) {
+	id, err := primitive.ObjectIDFromHex(strings.TrimPrefix(uri, IntegrationResourceScheme))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+	}
+	integration, err := s.integrationService.GetIntegration(ctx, id)
+	if err != nil || !scope.allows(integration) {
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+	}
+
+	content := map[string]interface{}{
+		"id":          integration.ID.Hex(),
+		"name":        integration.Name,
+		"description": integration.Description,
+		"baseURL":     integration.BaseURL,
+		"createdAt":   integration.CreatedAt,
+		"updatedAt":   integration.UpdatedAt,
+	}
+	var doc *openapi.Document
+	if integration.OpenAPISpec != "" {
+		if doc, err = openapi.Parse([]byte(integration.OpenAPISpec)); err != nil {
+			content["specError"] = err.Error()
+		}
+	}
+	if auth, err := ResolveAuthScheme(integration, doc, nil); err == nil {
+		content["auth"] = auth
+	}
+	tools, err := s.toolProvider.GetTools(ctx, integration.ID)
+	if err != nil {
+		content["toolsError"] = err.Error()
+	} else {
+		content["tools"] = tools
+		content["toolCount"] = len(tools)
+	}
+	return jsonContents(uri, content)
+}
+
+func (s *resourceService) readCollection(ctx context.Context, scope ResourceScope, uri string) (*models.ResourceContents, error) {
+	collection, err := s.collection(ctx, scope, strings.TrimPrefix(uri, CollectionResourceScheme))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, uri)
+	}
+
+	content := map[string]interface{}{
+		"id":           collection.ID.Hex(),
+		"name":         collection.Name,
+		"description":  collection.Description,
+		"source":       collection.Source,
+		"sourceDetail": collection.SourceDetail,
+		"endpoints":    collection.Endpoints,
+		"createdAt":    collection.CreatedAt,
+		"updatedAt":    collection.UpdatedAt,
+		"openapi":      OpenAPIResourceScheme + collection.ID.Hex(),
+	}
+	// Postman data is stored as a JSON string, or as a document by older imports.
+	postmanJSON := collection.RawPostmanJSON
+	if postmanJSON == "" {
+		postmanJSON, _ = collection.PostmanData.(string)
+	}
+	if json.Valid([]byte(postmanJSON)) {
+		content["postman"] = json.RawMessage(postmanJSON)
+	} else if _, isString := collection.PostmanData.(string); collection.PostmanData != nil && !isString {
+		content["postman"] = collection.PostmanData
+	}
+	return jsonContents(uri, content)
+}
+
+func (s *resourceService) readOpenAPI(ctx context.Context, scope ResourceScope, uri string) (*models.ResourceContents, error) {
+	parts := strings.SplitN(strings.TrimPrefix(uri, OpenAPIResourceScheme), "/", 3)
+	collection, err := s.collection(ctx, scope, parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, uri)
+	}
+	cached, err := s.spec(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 1 {
+		return &models.ResourceContents{URI: uri, MimeType: specType(cached.spec), Text: cached.spec}, nil
+	}
+	if cached.doc == nil {
+		return nil, fmt.Errorf("the OpenAPI document of collection %s is not JSON, so only the whole document can be read", parts[0])
+	}
+
+	base := OpenAPIResourceScheme + parts[0]
+	name := ""
+	if len(parts) == 3 {
+		if name, err = url.PathUnescape(parts[2]); err != nil || name == "" {
+			return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+		}
+	}
+	switch {
+	case parts[1] == "paths" && len(parts) == 2:
+		operations := make([]map[string]interface{}, 0, len(cached.doc.Operations))
+		for _, op := range cached.doc.Operations {
+			operations = append(operations, map[string]interface{}{
+				"name":    op.ToolName,
+				"method":  op.Method,
+				"path":    op.Path,
+				"summary": op.Summary,
+				"tags":    op.Tags,
+				"uri":     base + "/paths/" + url.PathEscape(op.ToolName),
+			})
+		}
+		return jsonContents(uri, map[string]interface{}{"operations": operations})
+
+	case parts[1] == "paths":
+		op := cached.doc.Operation(name)
+		if op == nil {
+			return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+		}
+		return jsonContents(uri, op)
+
+	case parts[1] == "schemas" && len(parts) == 2:
+		names := cached.doc.SchemaNames()
+		schemas := make([]map[string]interface{}, 0, len(names))
+		for _, schemaName := range names {
+			schemas = append(schemas, map[string]interface{}{
+				"name": schemaName,
+				"uri":  base + "/schemas/" + url.PathEscape(schemaName),
+			})
+		}
+		return jsonContents(uri, map[string]interface{}{"schemas": schemas})
+
+	case parts[1] == "schemas":
+		schema, ok := cached.doc.Schema(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+		}
+		return jsonContents(uri, schema)
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+	}
+}
+
+func (s *resourceService) readSDKManifest(ctx context.Context, scope ResourceScope, uri string) (*models.ResourceContents, error) {
+	hexID, ok := strings.CutSuffix(strings.TrimPrefix(uri, SDKResourceScheme), "/manifest")
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+	}
+	id, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+	}
+	sdk, err := s.sdkRepo.GetByID(ctx, id)
+	if err != nil || sdk == nil || sdk.IsDeleted || !s.readable(ctx, scope, models.ResourceSDKs, sdk.UserID, sdk.OrganizationID) {
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+	}
+
+	manifest := sdkManifest{
+		ID:               sdk.ID.Hex(),
+		Name:             sdkName(sdk),
+		GenerationType:   sdk.GenerationType,
+		Status:           sdk.Status,
+		PackageName:      sdk.PackageName,
+		Language:         sdk.Language,
+		MCPTransport:     sdk.MCPTransport,
+		MCPPort:          sdk.MCPPort,
+		MCPLanguage:      sdk.MCPLanguage,
+		ErrorMessage:     sdk.ErrorMessage,
+		DownloadURL:      sdk.DownloadURL,
+		GenerationTimeMs: sdk.GenerationTime,
+		CreatedAt:        sdk.CreatedAt,
+	}
+	if sdk.CollectionID != "" {
+		manifest.CollectionID = sdk.CollectionID
+		manifest.Collection = CollectionResourceScheme + sdk.CollectionID
+	}
+	if !sdk.GeneratedAt.IsZero() {
+		manifest.GeneratedAt = &sdk.GeneratedAt
+	}
+	if sdk.FilePath != "" {
+		if manifest.Files, err = archiveFiles(sdk.FilePath); err != nil {
+			manifest.FilesError = err.Error()
+		}
+	}
+	return jsonContents(uri, manifest)
+}
+
+// sdkManifest is the content of an sdk://<id>/manifest resource.
+type sdkManifest struct {
+	ID               string                     `json:"id"`
+	Name             string                     `json:"name"`
+	GenerationType   models.GenerationType      `json:"generationType"`
+	Status           models.SDKGenerationStatus `json:"status"`
+	CollectionID     string                     `json:"collectionId,omitempty"`
+	Collection       string                     `json:"collection,omitempty"`
+	PackageName      string                     `json:"packageName,omitempty"`
+	Language         string                     `json:"language,omitempty"`
+	MCPTransport     string                     `json:"mcpTransport,omitempty"`
+	MCPPort          int                        `json:"mcpPort,omitempty"`
+	MCPLanguage      string                     `json:"mcpLanguage,omitempty"`
+	ErrorMessage     string                     `json:"errorMessage,omitempty"`
+	DownloadURL      string                     `json:"downloadUrl,omitempty"`
+	GenerationTimeMs int64                      `json:"generationTimeMs,omitempty"`
+	CreatedAt        time.Time                  `json:"createdAt"`
+	GeneratedAt      *time.Time                 `json:"generatedAt,omitempty"`
+	Files            *archiveListing            `json:"files,omitempty"`
+	FilesError       string                     `json:"filesError,omitempty"`
+}
+
+// archiveListing lists the files of a generated archive.
+type archiveListing struct {
+	Entries    []archiveEntry `json:"entries"`
+	Count      int            `json:"count"`
+	TotalBytes uint64         `json:"totalBytes"`
+	// Truncated is set when only the first maxManifestFiles entries are listed.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type archiveEntry struct {
+	Path string `json:"path"`
+	Size uint64 `json:"size"`
+}
+
+// readable reports whether the scope's owner may read a resource created by ownerID and owned
+// by organizationID, if set.
+func (s *resourceService) readable(ctx context.Context, scope ResourceScope, resource models.ResourceKind, ownerID, organizationID string) bool {
+	if scope.OwnerID == "" {
+		return false
+	}
+	if organizationID == "" || s.collectionService == nil {
+		return ownerID == scope.OwnerID
+	}
+	return s.collectionService.authorizer.Authorize(ctx, scope.OwnerID, ownerID, organizationID, resource, models.ActionRead) == nil
+}
+
+// collection loads a collection the scope's owner may read.
+func (s *resourceService) collection(ctx context.Context, scope ResourceScope, id string) (*models.Collection, error) {
+	if scope.OwnerID == "" {
+		return nil, ErrResourceNotFound
+	}
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return nil, ErrResourceNotFound
+	}
+	collection, err := s.collectionService.GetCollectionForUser(ctx, id, scope.OwnerID, models.ActionRead)
+	if err != nil || collection == nil {
+		return nil, ErrResourceNotFound
+	}
+	return collection, nil
+}
+
+// spec returns the OpenAPI document of a collection, converting and parsing it once per
+// version of the collection. The document is nil for specs that are not JSON.
+func (s *resourceService) spec(ctx context.Context, collection *models.Collection) (*cachedSpec, error) {
+	s.mu.Lock()
+	cached, ok := s.specs[collection.ID]
+	s.mu.Unlock()
+	if ok && cached.updatedAt.Equal(collection.UpdatedAt) {
+		return cached, nil
+	}
+
+	spec, err := s.collectionService.OpenAPISpec(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	cached = &cachedSpec{updatedAt: collection.UpdatedAt, spec: spec}
+	if specType(spec) == models.MimeTypeJSON {
+		if cached.doc, err = openapi.Parse([]byte(spec)); err != nil {
+			return nil, fmt.Errorf("failed to parse the OpenAPI document of collection %s: %w", collection.ID.Hex(), err)
+		}
+	}
+
+	s.mu.Lock()
+	if len(s.specs) >= maxCachedSpecs {
+		for id := range s.specs {
+			delete(s.specs, id)
+			break
+		}
+	}
+	s.specs[collection.ID] = cached
+	s.mu.Unlock()
+	return cached, nil
+}
+
+// archiveFiles lists the files of a zip archive, up to maxManifestFiles.
+func archiveFiles(path string) (*archiveListing, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer archive.Close()
+
+	listing := &archiveListing{Entries: []archiveEntry{}}
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		listing.Count++
+		listing.TotalBytes += file.UncompressedSize64
+		if len(listing.Entries) < maxManifestFiles {
+			listing.Entries = append(listing.Entries, archiveEntry{Path: file.Name, Size: file.UncompressedSize64})
+		}
+	}
+	listing.Truncated = listing.Count > len(listing.Entries)
+	return listing, nil
+}
+
+// specType returns the MIME type of a spec, which is YAML unless it is a JSON object.
+func specType(spec string) string {
+	if strings.HasPrefix(strings.TrimSpace(spec), "{") {
+		return models.MimeTypeJSON
+	}
+	return models.MimeTypeYAML
+}
+
+func sdkName(sdk *models.SDK) string {
+	switch {
+	case sdk.PackageName != "":
+		return sdk.PackageName
+	case sdk.GenerationType == models.GenerationTypeMCP:
+		return "MCP server " + sdk.ID.Hex()
+	default:
+		return "SDK " + sdk.ID.Hex()
+	}
+}
+
+func jsonContents(uri string, value interface{}) (*models.ResourceContents, error) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", uri, err)
+	}
+	return &models.ResourceContents{URI: uri, MimeType: models.MimeTypeJSON, Text: string(data)}, nil
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memorySDKs keeps SDK records in memory; unused methods panic.
+type memorySDKs struct {
+	repositories.SDKRepositoryInterface
+	sdks []*models.SDK
+}
+
+func (r *memorySDKs) GetByID(ctx context.Context, id primitive.ObjectID) (*models.SDK, error) {
+	for _, sdk := range r.sdks {
+		if sdk.ID == id {
+			return sdk, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func resourceURIs(resources []models.Resource) []string {
+	uris := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		uris = append(uris, resource.URI)
+	}
+	return uris
+}
+
+func TestIntegrationResources(t *testing.T) {
+	crm := &models.Integration{ID: primitive.NewObjectID(), Name: "crm", OpenAPISpec: promptSpec}
+	docs := &models.Integration{ID: primitive.NewObjectID(), Name: "docs"}
+	integrations := &memoryIntegrationService{integrations: []*models.Integration{crm, docs}}
+	tools := &staticToolProvider{tools: map[primitive.ObjectID][]models.Tool{crm.ID: {{Name: "crm__get_contact"}}}}
+	service := NewResourceService(integrations, tools, nil, nil)
+	ctx := context.Background()
+	onlyCRM := ResourceScope{Integration: func(integration *models.Integration) bool { return integration.Name == "crm" }}
+
+	all, err := service.ListResources(ctx, ResourceScope{})
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	want := IntegrationResourceScheme + crm.ID.Hex() + "," + IntegrationResourceScheme + docs.ID.Hex()
+	if got := strings.Join(resourceURIs(all), ","); got != want {
+		t.Errorf("resources = %s, want %s", got, want)
+	}
+	scoped, err := service.ListResources(ctx, onlyCRM)
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	if got := strings.Join(resourceURIs(scoped), ","); got != IntegrationResourceScheme+crm.ID.Hex() {
+		t.Errorf("scoped resources = %s", got)
+	}
+
+	contents, err := service.ReadResource(ctx, onlyCRM, IntegrationResourceScheme+crm.ID.Hex())
+	if err != nil {
+		t.Fatalf("ReadResource() error = %v", err)
+	}
+	var content struct {
+		Name      string        `json:"name"`
+		ToolCount int           `json:"toolCount"`
+		Tools     []models.Tool `json:"tools"`
+	}
+	if err := json.Unmarshal([]byte(contents.Text), &content); err != nil {
+		t.Fatal(err)
+	}
+	if contents.URI != IntegrationResourceScheme+crm.ID.Hex() || contents.MimeType != models.MimeTypeJSON || content.Name != "crm" || content.ToolCount != 1 {
+		t.Errorf("contents = %+v", contents)
+	}
+
+	for _, uri := range []string{
+		IntegrationResourceScheme + docs.ID.Hex(),
+		IntegrationResourceScheme + primitive.NewObjectID().Hex(),
+		IntegrationResourceScheme + "not-an-id",
+		"file:///etc/passwd",
+		// Collections and SDKs are only visible to an owner.
+		CollectionResourceScheme + primitive.NewObjectID().Hex(),
+		OpenAPIResourceScheme + primitive.NewObjectID().Hex() + "/paths",
+	} {
+		if _, err := service.ReadResource(ctx, onlyCRM, uri); !errors.Is(err, ErrResourceNotFound) {
+			t.Errorf("ReadResource(%s) error = %v, want ErrResourceNotFound", uri, err)
+		}
+	}
+}
+
+func TestResourceTemplatesCoverSchemes(t *testing.T) {
+	templates := NewResourceService(nil, nil, nil, nil).ListResourceTemplates()
+	for _, scheme := range []string{IntegrationResourceScheme, CollectionResourceScheme, OpenAPIResourceScheme, SDKResourceScheme} {
+		found := false
+		for _, template := range templates {
+			if template.Name == "" {
+				t.Errorf("template %s has no name", template.URITemplate)
+			}
+			found = found || strings.HasPrefix(template.URITemplate, scheme)
+		}
+		if !found {
+			t.Errorf("no template for %s", scheme)
+		}
+	}
+}
+
+func TestSDKManifestResource(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "sdk.zip")
+	file, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := zip.NewWriter(file)
+	for name, body := range map[string]string{"package.json": "{}", "src/index.ts": "export {}"} {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry.Write([]byte(body))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	sdk := &models.SDK{ID: primitive.NewObjectID(), UserID: "alice", PackageName: "crm-client", CollectionID: "c1", FilePath: archive}
+	deleted := &models.SDK{ID: primitive.NewObjectID(), UserID: "alice", IsDeleted: true}
+	service := NewResourceService(nil, nil, nil, &memorySDKs{sdks: []*models.SDK{sdk, deleted}})
+	ctx := context.Background()
+	uri := SDKResourceScheme + sdk.ID.Hex() + "/manifest"
+
+	contents, err := service.ReadResource(ctx, ResourceScope{OwnerID: "alice"}, uri)
+	if err != nil {
+		t.Fatalf("ReadResource() error = %v", err)
+	}
+	var manifest sdkManifest
+	if err := json.Unmarshal([]byte(contents.Text), &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Name != "crm-client" || manifest.Collection != CollectionResourceScheme+"c1" {
+		t.Errorf("manifest = %+v", manifest)
+	}
+	if manifest.Files == nil || manifest.Files.Count != 2 || manifest.Files.TotalBytes != uint64(len("{}")+len("export {}")) {
+		t.Errorf("files = %+v, error %q", manifest.Files, manifest.FilesError)
+	}
+
+	for name, tt := range map[string]struct {
+		owner string
+		uri   string
+	}{
+		"other owner": {"bob", uri},
+		"no owner":    {"", uri},
+		"deleted":     {"alice", SDKResourceScheme + deleted.ID.Hex() + "/manifest"},
+		"no manifest": {"alice", SDKResourceScheme + sdk.ID.Hex()},
+	} {
+		if _, err := service.ReadResource(ctx, ResourceScope{OwnerID: tt.owner}, tt.uri); !errors.Is(err, ErrResourceNotFound) {
+			t.Errorf("%s: ReadResource() error = %v, want ErrResourceNotFound", name, err)
+		}
+	}
+}
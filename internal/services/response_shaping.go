@@ -0,0 +1,503 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/openapi"
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/jmespath/go-jmespath"
+)
+
+const (
+	// ContinueResultToolName is the follow-up tool that returns the rest of a truncated result.
+	ContinueResultToolName = "ACI_CONTINUE_RESULT"
+	// NextPageToolName is the follow-up tool that fetches the next page of a paginated result.
+	NextPageToolName = "ACI_NEXT_PAGE"
+	// ResultResourceScheme prefixes the URIs of tool responses exposed as resources.
+	ResultResourceScheme = "tool-result://"
+
+	// DefaultMaxResultBytes is the size above which tool results are truncated.
+	DefaultMaxResultBytes = 20000
+	// maxInlineMediaBytes is the largest image or audio response inlined as base64.
+	maxInlineMediaBytes = 512 << 10
+	// defaultShapingKey is the ResponseShaping entry used for tools without their own.
+	defaultShapingKey = "*"
+)
+
+// ErrInvalidResponseShaping is returned for response shaping configurations that cannot be applied.
+var ErrInvalidResponseShaping = errors.New("invalid response shaping")
+
+// ContinueResultTool is the definition of the ACI_CONTINUE_RESULT follow-up tool.
+var ContinueResultTool = models.Tool{
+	Name:        ContinueResultToolName,
+	Description: "Return the next part of a tool result that was truncated. Pass the continuation_token given at the end of the truncated result.",
+	InputSchema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"continuation_token": map[string]interface{}{
+				"type":        "string",
+				"description": "The continuation token from the truncated result",
+			},
+		},
+		"required": []string{"continuation_token"},
+	},
+	Annotations: &models.ToolAnnotations{Title: "Continue result", ReadOnlyHint: boolPtr(true)},
+}
+
+// NextPageTool is the definition of the ACI_NEXT_PAGE follow-up tool.
+var NextPageTool = models.Tool{
+	Name:        NextPageToolName,
+	Description: "Fetch the next page of a paginated tool result. Pass the page_token given with the previous page.",
+	InputSchema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"page_token": map[string]interface{}{
+				"type":        "string",
+				"description": "The page token from the previous page",
+			},
+		},
+		"required": []string{"page_token"},
+	},
+	Annotations: &models.ToolAnnotations{Title: "Next page", ReadOnlyHint: boolPtr(true)},
+}
+
+// ValidateResponseShaping checks that the expressions of a shaping configuration compile.
+func ValidateResponseShaping(shaping models.ResponseShaping) error {
+	if shaping.JSONPath != "" && shaping.JMESPath != "" {
+		return fmt.Errorf("%w: set only one of jsonPath and jmesPath", ErrInvalidResponseShaping)
+	}
+	if shaping.JSONPath != "" {
+		if _, err := jsonpath.New(shaping.JSONPath); err != nil {
+			return fmt.Errorf("%w: jsonPath: %v", ErrInvalidResponseShaping, err)
+		}
+	}
+	if shaping.JMESPath != "" {
+		if _, err := jmespath.Compile(shaping.JMESPath); err != nil {
+			return fmt.Errorf("%w: jmesPath: %v", ErrInvalidResponseShaping, err)
+		}
+	}
+	if shaping.MaxBytes < 0 {
+		return fmt.Errorf("%w: maxBytes must not be negative", ErrInvalidResponseShaping)
+	}
+	if p := shaping.Pagination; p != nil {
+		switch {
+		case p.CursorPath != "" && p.CursorParam == "":
+			return fmt.Errorf("%w: pagination cursorPath requires cursorParam", ErrInvalidResponseShaping)
+		case p.CursorPath == "" && p.CursorParam != "":
+			return fmt.Errorf("%w: pagination cursorParam requires cursorPath", ErrInvalidResponseShaping)
+		case p.CursorPath != "" && p.PageParam != "":
+			return fmt.Errorf("%w: set only one of pagination cursorPath and pageParam", ErrInvalidResponseShaping)
+		}
+		if p.CursorPath != "" {
+			if _, err := jmespath.Compile(p.CursorPath); err != nil {
+				return fmt.Errorf("%w: pagination cursorPath: %v", ErrInvalidResponseShaping, err)
+			}
+		}
+	}
+	return nil
+}
+
+// responseShapingFor returns the shaping configuration of a tool, falling back to the "*" entry.
+func responseShapingFor(integration *models.Integration, toolName string) models.ResponseShaping {
+	if shaping, ok := integration.ResponseShaping[toolName]; ok {
+		return shaping
+	}
+	return integration.ResponseShaping[defaultShapingKey]
+}
+
+// projectResponse applies the JSONPath or JMESPath projection of shaping to a decoded JSON value.
+func projectResponse(shaping models.ResponseShaping, value interface{}) (interface{}, error) {
+	switch {
+	case shaping.JMESPath != "":
+		return jmespath.Search(shaping.JMESPath, value)
+	case shaping.JSONPath != "":
+		return jsonpath.Get(shaping.JSONPath, value)
+	default:
+		return value, nil
+	}
+}
+
+// shapeResponse turns an API response into a tool result. JSON is projected and returned
+// as text and structured content, text as text or an embedded resource, images and audio
+// inline, and other binary content as a link to a result resource. Results larger than
+// the limit are truncated with a continuation token, and paginated responses get a page token.
+func (s *toolProviderService) shapeResponse(integration *models.Integration, op *openapi.Operation, ownerID string, arguments map[string]interface{}, resp *http.Response, body []byte) (*models.ToolResult, error) {
+	shaping := responseShapingFor(integration, op.ToolName)
+	maxBytes := shaping.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxResultBytes
+	}
+
+	mediaType := responseMediaType(resp.Header.Get("Content-Type"), body)
+	result := &models.ToolResult{
+		IsError: resp.StatusCode >= 400,
+		Meta: map[string]interface{}{
+			"status":      resp.StatusCode,
+			"contentType": mediaType,
+		},
+	}
+	if result.IsError {
+		result.Content = append(result.Content, textBlock(fmt.Sprintf("HTTP %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))))
+	}
+	if len(body) == 0 {
+		if !result.IsError {
+			result.Content = append(result.Content, textBlock(fmt.Sprintf("HTTP %d %s (empty response)", resp.StatusCode, http.StatusText(resp.StatusCode))))
+		}
+		return result, nil
+	}
+
+	var decoded interface{}
+	switch {
+	case isJSONMediaType(mediaType) && json.Unmarshal(body, &decoded) == nil:
+		// Error bodies are returned as sent; projections and pagination describe successful responses.
+		value := decoded
+		if !result.IsError {
+			projected, err := projectResponse(shaping, decoded)
+			if err != nil {
+				return nil, fmt.Errorf("failed to shape response of %s: %w", op.ToolName, err)
+			}
+			value = projected
+		}
+		text, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode response of %s: %w", op.ToolName, err)
+		}
+		if len(text) <= maxBytes {
+			result.Content = append(result.Content, textBlock(string(text)))
+			if _, ok := value.(map[string]interface{}); ok {
+				result.StructuredContent = value
+			}
+		} else if err := s.addTruncatedText(result, string(text), mediaType, maxBytes, false); err != nil {
+			return nil, err
+		}
+		if !result.IsError {
+			next := nextPageArguments(shaping.Pagination, op, arguments, decoded, value, resp.Header)
+			if err := s.addPageToken(result, integration, ownerID, op.ToolName, next); err != nil {
+				return nil, err
+			}
+		}
+
+	case isTextMediaType(mediaType) || result.IsError:
+		plain := mediaType == "text/plain" || mediaType == "text/markdown" || result.IsError
+		if plain && len(body) <= maxBytes {
+			result.Content = append(result.Content, textBlock(string(body)))
+		} else if err := s.addTruncatedText(result, string(body), mediaType, maxBytes, !plain); err != nil {
+			return nil, err
+		}
+		if !result.IsError {
+			next := nextPageArguments(shaping.Pagination, op, arguments, nil, nil, resp.Header)
+			if err := s.addPageToken(result, integration, ownerID, op.ToolName, next); err != nil {
+				return nil, err
+			}
+		}
+
+	case (strings.HasPrefix(mediaType, "image/") || strings.HasPrefix(mediaType, "audio/")) && len(body) <= maxInlineMediaBytes:
+		blockType := models.ContentTypeImage
+		if strings.HasPrefix(mediaType, "audio/") {
+			blockType = models.ContentTypeAudio
+		}
+		result.Content = append(result.Content, models.ContentBlock{
+			Type:     blockType,
+			Data:     base64.StdEncoding.EncodeToString(body),
+			MimeType: mediaType,
+		})
+
+	default:
+		key, err := s.results.put(&storedResult{data: body, mimeType: mediaType})
+		if err != nil {
+			return nil, fmt.Errorf("failed to store response of %s: %w", op.ToolName, err)
+		}
+		uri := ResultResourceScheme + key
+		result.Content = append(result.Content,
+			textBlock(fmt.Sprintf("The response is %d bytes of %s and is available as resource %s.", len(body), mediaType, uri)),
+			models.ContentBlock{
+				Type:     models.ContentTypeResourceLink,
+				URI:      uri,
+				Name:     op.ToolName + " response",
+				MimeType: mediaType,
+				Size:     int64(len(body)),
+			})
+		result.Meta["resourceUri"] = uri
+	}
+	return result, nil
+}
+
+// addTruncatedText stores text and adds its first chunk to result, with a continuation
+// token when it does not fit. With asResource the chunk is embedded as a resource whose
+// URI reads the whole text.
+func (s *toolProviderService) addTruncatedText(result *models.ToolResult, text, mediaType string, maxBytes int, asResource bool) error {
+	key, err := s.results.put(&storedResult{text: text, mimeType: mediaType, chunkSize: maxBytes})
+	if err != nil {
+		return fmt.Errorf("failed to store result: %w", err)
+	}
+	chunk, end := textChunk(text, 0, maxBytes)
+	if asResource {
+		result.Content = append(result.Content, models.ContentBlock{
+			Type:     models.ContentTypeResource,
+			Resource: &models.ResourceContents{URI: ResultResourceScheme + key, MimeType: mediaType, Text: chunk},
+		})
+	} else {
+		result.Content = append(result.Content, textBlock(chunk))
+	}
+	addContinuation(result, key, end, len(text))
+	return nil
+}
+
+// addPageToken stores the request for the next page and adds its token to result.
+func (s *toolProviderService) addPageToken(result *models.ToolResult, integration *models.Integration, ownerID, toolName string, next map[string]interface{}) error {
+	if next == nil {
+		return nil
+	}
+	token, err := s.results.put(&storedResult{page: &pageRequest{
+		integrationID: integration.ID,
+		ownerID:       ownerID,
+		toolName:      toolName,
+		arguments:     next,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to store page token: %w", err)
+	}
+	result.Meta["nextPageToken"] = token
+	result.Content = append(result.Content, textBlock(fmt.Sprintf("More results are available. Call %s with page_token %q to fetch the next page.", NextPageToolName, token)))
+	return nil
+}
+
+// ContinueResult returns the part of a truncated result that follows the continuation token.
+func (s *toolProviderService) ContinueResult(ctx context.Context, token string) (*models.ToolResult, error) {
+	key, offsetText, ok := strings.Cut(token, ".")
+	offset, err := strconv.Atoi(offsetText)
+	if !ok || err != nil || offset < 0 {
+		return nil, ErrResultExpired
+	}
+	entry, err := s.results.get(key)
+	if err != nil || entry.page != nil || entry.data != nil || offset > len(entry.text) {
+		return nil, ErrResultExpired
+	}
+
+	chunk, end := textChunk(entry.text, offset, entry.chunkSize)
+	result := &models.ToolResult{
+		Content: []models.ContentBlock{textBlock(chunk)},
+		Meta:    map[string]interface{}{"contentType": entry.mimeType},
+	}
+	addContinuation(result, key, end, len(entry.text))
+	return result, nil
+}
+
+// NextPage runs the tool call stored under a page token.
+func (s *toolProviderService) NextPage(ctx context.Context, token string) (*models.ToolResult, error) {
+	entry, err := s.results.get(token)
+	if err != nil || entry.page == nil {
+		return nil, ErrResultExpired
+	}
+	page := entry.page
+	return s.ExecuteTool(ctx, page.integrationID, page.ownerID, page.toolName, cloneArguments(page.arguments))
+}
+
+// ReadResultResource returns the full content of a response stored as a tool-result:// resource.
+func (s *toolProviderService) ReadResultResource(ctx context.Context, uri string) (*models.ResourceContents, error) {
+	key, ok := strings.CutPrefix(uri, ResultResourceScheme)
+	if !ok {
+		return nil, fmt.Errorf("not a tool result URI: %s", uri)
+	}
+	entry, err := s.results.get(key)
+	if err != nil || entry.page != nil {
+		return nil, ErrResultExpired
+	}
+	contents := &models.ResourceContents{URI: uri, MimeType: entry.mimeType}
+	if entry.data != nil {
+		contents.Blob = base64.StdEncoding.EncodeToString(entry.data)
+	} else {
+		contents.Text = entry.text
+	}
+	return contents, nil
+}
+
+// addContinuation adds the continuation token and a hint to result when text continues after end.
+func addContinuation(result *models.ToolResult, key string, end, total int) {
+	if end >= total {
+		return
+	}
+	token := key + "." + strconv.Itoa(end)
+	if result.Meta == nil {
+		result.Meta = map[string]interface{}{}
+	}
+	result.Meta["truncated"] = true
+	result.Meta["continuationToken"] = token
+	result.Content = append(result.Content, textBlock(fmt.Sprintf("[Truncated after %d of %d bytes. Call %s with continuation_token %q for the rest.]", end, total, ContinueResultToolName, token)))
+}
+
+// textChunk returns at most size bytes of text starting at offset, cut at a UTF-8
+// boundary, and the offset following the chunk.
+func textChunk(text string, offset, size int) (string, int) {
+	end := offset + size
+	if end >= len(text) {
+		return text[offset:], len(text)
+	}
+	for end > offset && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	if end == offset {
+		end = offset + size
+	}
+	return text[offset:end], end
+}
+
+// nextPageArguments returns the arguments for the next page of a response, or nil when
+// there is none. body is the decoded response and projected its projection, both nil for
+// non-JSON responses. Without a pagination config a Link rel="next" header is used.
+func nextPageArguments(pagination *models.PaginationConfig, op *openapi.Operation, arguments map[string]interface{}, body, projected interface{}, header http.Header) map[string]interface{} {
+	next := cloneArguments(arguments)
+	switch {
+	case pagination != nil && pagination.CursorPath != "":
+		if body == nil {
+			return nil
+		}
+		cursor, err := jmespath.Search(pagination.CursorPath, body)
+		if err != nil || cursor == nil || cursor == "" {
+			return nil
+		}
+		if openapi.FormatValue(cursor) == openapi.FormatValue(arguments[pagination.CursorParam]) {
+			return nil
+		}
+		next[pagination.CursorParam] = cursor
+
+	case pagination != nil && pagination.PageParam != "":
+		if isEmptyPage(projected) {
+			return nil
+		}
+		page := 1.0
+		if current, err := strconv.ParseFloat(openapi.FormatValue(arguments[pagination.PageParam]), 64); err == nil {
+			page = current
+		}
+		next[pagination.PageParam] = page + 1
+
+	default:
+		link, err := url.Parse(linkNext(header.Values("Link")))
+		if err != nil || link.RawQuery == "" {
+			return nil
+		}
+		query := link.Query()
+		changed := false
+		for _, p := range op.ParametersIn("query") {
+			values, ok := query[p.Name]
+			if !ok {
+				continue
+			}
+			var value interface{} = values[0]
+			if len(values) > 1 {
+				list := make([]interface{}, len(values))
+				for i, v := range values {
+					list[i] = v
+				}
+				value = list
+			}
+			if openapi.FormatValue(value) != openapi.FormatValue(arguments[p.Name]) {
+				changed = true
+			}
+			next[p.Name] = value
+		}
+		if !changed {
+			return nil
+		}
+	}
+	return next
+}
+
+// linkNext returns the target of the rel="next" entry of Link headers.
+func linkNext(headers []string) string {
+	for _, header := range headers {
+		for _, link := range strings.Split(header, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(value), `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// isEmptyPage reports whether a projected page has no items.
+func isEmptyPage(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+// responseMediaType returns the media type of a response, sniffing it when the header is missing.
+func responseMediaType(contentType string, body []byte) string {
+	if contentType == "" {
+		if json.Valid(body) {
+			return "application/json"
+		}
+		contentType = http.DetectContentType(body)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(contentType, ";")
+	}
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func isTextMediaType(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/xml" || strings.HasSuffix(mediaType, "+xml"),
+		mediaType == "application/yaml" || mediaType == "application/x-yaml",
+		mediaType == "application/javascript",
+		mediaType == "application/x-www-form-urlencoded",
+		mediaType == "application/graphql",
+		isJSONMediaType(mediaType):
+		return true
+	}
+	return false
+}
+
+func textBlock(text string) models.ContentBlock {
+	return models.ContentBlock{Type: models.ContentTypeText, Text: text}
+}
+
+func cloneArguments(arguments map[string]interface{}) map[string]interface{} {
+	clone := make(map[string]interface{}, len(arguments))
+	for key, value := range arguments {
+		clone[key] = value
+	}
+	return clone
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/openapi"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const shapingSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Items", "version": "1.0.0"},
+  "paths": {
+    "/items": {"get": {"operationId": "list_items", "parameters": [
+      {"name": "cursor", "in": "query", "schema": {"type": "string"}},
+      {"name": "page", "in": "query", "schema": {"type": "integer"}}
+    ]}}
+  }
+}`
+
+// shape runs shapeResponse for list_items with the given shaping configuration.
+func shape(t *testing.T, shaping models.ResponseShaping, arguments map[string]interface{}, contentType, body string, header http.Header) (*toolProviderService, *models.ToolResult) {
+	t.Helper()
+	doc, err := openapi.Parse([]byte(shapingSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := &toolProviderService{results: newResultStore(), logger: zap.NewNop()}
+	integration := &models.Integration{ID: primitive.NewObjectID(), ResponseShaping: map[string]models.ResponseShaping{"*": shaping}}
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	for name, values := range header {
+		resp.Header[name] = values
+	}
+	resp.Header.Set("Content-Type", contentType)
+
+	result, err := service.shapeResponse(integration, doc.Operation("list_items"), "alice", arguments, resp, []byte(body))
+	if err != nil {
+		t.Fatalf("shapeResponse() error = %v", err)
+	}
+	return service, result
+}
+
+func TestShapeResponseProjectsJSON(t *testing.T) {
+	_, result := shape(t, models.ResponseShaping{JMESPath: "{names: items[].name}"}, nil, "application/json",
+		`{"items": [{"name": "a", "secret": 1}, {"name": "b", "secret": 2}]}`, nil)
+
+	if len(result.Content) != 1 || result.Content[0].Text != `{"names":["a","b"]}` {
+		t.Fatalf("content = %+v", result.Content)
+	}
+	if result.StructuredContent == nil {
+		t.Fatal("structured content is missing")
+	}
+}
+
+func TestShapeResponseTruncatesWithContinuation(t *testing.T) {
+	text := strings.Repeat("é", 30) // 60 bytes, cut only at rune boundaries
+	service, result := shape(t, models.ResponseShaping{MaxBytes: 25}, nil, "text/plain", text, nil)
+
+	token, _ := result.Meta["continuationToken"].(string)
+	if token == "" || result.Meta["truncated"] != true {
+		t.Fatalf("meta = %v, want a continuation token", result.Meta)
+	}
+	got := result.Content[0].Text
+	for token != "" {
+		next, err := service.ContinueResult(context.Background(), token)
+		if err != nil {
+			t.Fatalf("ContinueResult() error = %v", err)
+		}
+		got += next.Content[0].Text
+		token, _ = next.Meta["continuationToken"].(string)
+	}
+	if got != text {
+		t.Fatalf("reassembled text = %q, want %q", got, text)
+	}
+
+	if _, err := service.ContinueResult(context.Background(), "unknown.0"); !errors.Is(err, ErrResultExpired) {
+		t.Fatalf("ContinueResult() with an unknown token: error = %v, want %v", err, ErrResultExpired)
+	}
+}
+
+func TestShapeResponseMedia(t *testing.T) {
+	_, image := shape(t, models.ResponseShaping{}, nil, "image/png", "\x89PNG", nil)
+	if image.Content[0].Type != models.ContentTypeImage || image.Content[0].Data != "iVBORw==" {
+		t.Fatalf("image content = %+v", image.Content)
+	}
+
+	service, binary := shape(t, models.ResponseShaping{}, nil, "application/pdf", "%PDF-1.7", nil)
+	uri, _ := binary.Meta["resourceUri"].(string)
+	if !strings.HasPrefix(uri, ResultResourceScheme) {
+		t.Fatalf("meta = %v, want a result resource", binary.Meta)
+	}
+	contents, err := service.ReadResultResource(context.Background(), uri)
+	if err != nil {
+		t.Fatalf("ReadResultResource() error = %v", err)
+	}
+	if contents.Blob != "JVBERi0xLjc=" || contents.MimeType != "application/pdf" {
+		t.Fatalf("resource contents = %+v", contents)
+	}
+}
+
+func TestShapeResponsePagination(t *testing.T) {
+	tests := []struct {
+		name      string
+		shaping   models.ResponseShaping
+		arguments map[string]interface{}
+		body      string
+		header    http.Header
+		want      map[string]string // next page arguments, nil for the last page
+	}{
+		{
+			name:    "cursor",
+			shaping: models.ResponseShaping{Pagination: &models.PaginationConfig{CursorPath: "next", CursorParam: "cursor"}},
+			body:    `{"items": [1], "next": "c2"}`,
+			want:    map[string]string{"cursor": "c2"},
+		},
+		{
+			name:    "last cursor page",
+			shaping: models.ResponseShaping{Pagination: &models.PaginationConfig{CursorPath: "next", CursorParam: "cursor"}},
+			body:    `{"items": [1], "next": null}`,
+		},
+		{
+			name:      "page number",
+			shaping:   models.ResponseShaping{JMESPath: "items", Pagination: &models.PaginationConfig{PageParam: "page"}},
+			arguments: map[string]interface{}{"page": 2},
+			body:      `{"items": [1]}`,
+			want:      map[string]string{"page": "3"},
+		},
+		{
+			name:    "empty page",
+			shaping: models.ResponseShaping{JMESPath: "items", Pagination: &models.PaginationConfig{PageParam: "page"}},
+			body:    `{"items": []}`,
+		},
+		{
+			name:   "link header",
+			body:   `[1]`,
+			header: http.Header{"Link": {`<https://api.example.com/items?cursor=c9>; rel="next", <https://api.example.com/items>; rel="first"`}},
+			want:   map[string]string{"cursor": "c9"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, result := shape(t, tt.shaping, tt.arguments, "application/json", tt.body, tt.header)
+			token, _ := result.Meta["nextPageToken"].(string)
+			if tt.want == nil {
+				if token != "" {
+					t.Fatalf("got a page token on the last page")
+				}
+				return
+			}
+			entry, err := service.results.get(token)
+			if err != nil || entry.page == nil {
+				t.Fatalf("page token %q is not stored: %v", token, err)
+			}
+			for name, want := range tt.want {
+				if got := openapi.FormatValue(entry.page.arguments[name]); got != want {
+					t.Errorf("next page %s = %q, want %q", name, got, want)
+				}
+			}
+			if entry.page.ownerID != "alice" {
+				t.Errorf("next page runs for %q, want alice", entry.page.ownerID)
+			}
+		})
+	}
+}
+
+func TestValidateResponseShaping(t *testing.T) {
+	invalid := []models.ResponseShaping{
+		{JSONPath: "$.items", JMESPath: "items"},
+		{JMESPath: "items[?"},
+		{MaxBytes: -1},
+		{Pagination: &models.PaginationConfig{CursorPath: "next"}},
+		{Pagination: &models.PaginationConfig{CursorPath: "next", CursorParam: "cursor", PageParam: "page"}},
+	}
+	for _, shaping := range invalid {
+		if err := ValidateResponseShaping(shaping); !errors.Is(err, ErrInvalidResponseShaping) {
+			t.Errorf("ValidateResponseShaping(%+v) = %v, want %v", shaping, err, ErrInvalidResponseShaping)
+		}
+	}
+	if err := ValidateResponseShaping(models.ResponseShaping{JSONPath: "$.items[*].name", MaxBytes: 100}); err != nil {
+		t.Errorf("ValidateResponseShaping() of a valid config = %v", err)
+	}
+}
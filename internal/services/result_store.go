@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrResultExpired is returned for continuation tokens, page tokens and result
+// resources that are unknown or have expired.
+var ErrResultExpired = errors.New("result not found or expired")
+
+const (
+	resultStoreTTL        = 30 * time.Minute
+	resultStoreMaxEntries = 1000
+	resultStoreMaxBytes   = 256 << 20
+)
+
+// storedResult is one entry of the result store. Exactly one of the groups is set:
+// the text of a truncated result, the body of a binary response, or a page request.
+type storedResult struct {
+	text      string
+	chunkSize int
+
+	data     []byte
+	mimeType string
+
+	page *pageRequest
+
+	size      int
+	expiresAt time.Time
+}
+
+// pageRequest is the tool call that fetches the next page of a result.
+type pageRequest struct {
+	integrationID primitive.ObjectID
+	ownerID       string
+	toolName      string
+	arguments     map[string]interface{}
+}
+
+// resultStore keeps tool results that do not fit in a response for a limited time.
+// It is bounded by entry count and total size, evicting the entries closest to expiry.
+type resultStore struct {
+	mu      sync.Mutex
+	entries map[string]*storedResult
+	bytes   int
+}
+
+func newResultStore() *resultStore {
+	return &resultStore{entries: make(map[string]*storedResult)}
+}
+
+// put stores entry and returns its random key.
+func (s *resultStore) put(entry *storedResult) (string, error) {
+	key, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	entry.size = len(entry.text) + len(entry.data)
+	entry.expiresAt = time.Now().Add(resultStoreTTL)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purge(entry.size)
+	s.entries[key] = entry
+	s.bytes += entry.size
+	return key, nil
+}
+
+func (s *resultStore) get(key string) (*storedResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, ErrResultExpired
+	}
+	return entry, nil
+}
+
+// purge drops expired entries, then the entries closest to expiry until an entry
+// of size more bytes fits. Callers hold s.mu.
+func (s *resultStore) purge(size int) {
+	now := time.Now()
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			s.remove(key)
+		}
+	}
+	for len(s.entries) > 0 && (len(s.entries) >= resultStoreMaxEntries || s.bytes+size > resultStoreMaxBytes) {
+		oldestKey := ""
+		for key, entry := range s.entries {
+			if oldestKey == "" || entry.expiresAt.Before(s.entries[oldestKey].expiresAt) {
+				oldestKey = key
+			}
+		}
+		s.remove(oldestKey)
+	}
+}
+
+func (s *resultStore) remove(key string) {
+	s.bytes -= s.entries[key].size
+	delete(s.entries, key)
+}
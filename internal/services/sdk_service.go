@@ -14,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/AkashKesav/API2SDK/internal/mcpgen/codegen"
 	"github.com/AkashKesav/API2SDK/internal/models"
 	"github.com/AkashKesav/API2SDK/internal/repositories"
 	"github.com/AkashKesav/API2SDK/internal/utils" // Assuming utils.ErrNotFound, utils.ErrUnauthorized exist or handle errors appropriately
@@ -38,10 +39,10 @@ type SDKServiceInterface interface {
 	// Similar to GenerateSDK, it manages the generation process and updates the record.
 	GenerateMCP(ctx context.Context, req *models.MCPGenerationRequest, recordID primitive.ObjectID) (*models.SDK, error)
 
-	// GetSDKByID retrieves an SDK by its ID, ensuring user ownership.
+	// GetSDKByID retrieves an SDK by its ID, ensuring the user may read it.
 	GetSDKByID(ctx context.Context, sdkID primitive.ObjectID, userID string) (*models.SDK, error)
 
-	// GetSDKsByUserID retrieves a paginated list of SDKs for a specific user.
+	// GetSDKsByUserID retrieves a paginated list of the SDKs of a user and of the user's organizations.
 	GetSDKsByUserID(ctx context.Context, userID string, page, limit int) ([]*models.SDK, int64, error)
 
 	// UpdateSDKStatus updates the status and an optional error message of an SDK record.
@@ -66,7 +67,7 @@ type SDKServiceInterface interface {
 	// GetPhpVendorZip returns the embedded PHP vendor zip filesystem.
 	GetPhpVendorZip() embed.FS
 
-	// DeleteSDK soft deletes an SDK record, verifying ownership.
+	// DeleteSDK soft deletes an SDK record, verifying the user may delete it.
 	DeleteSDK(ctx context.Context, sdkID primitive.ObjectID, userID string) error
 
 	// GetTotalGeneratedSDKsCount returns the total number of generated SDKs (not soft-deleted)
@@ -193,6 +194,7 @@ type SDKService struct {
 	phpVendorZip    embed.FS // Embedded PHP vendor zip
 	tempDirRootBase string   // Base for creating temporary directories for SDK generation
 	jsRuntime       *goja.Runtime
+	authorizer      ResourceAuthorizer // Decides who may read and delete SDKs
 	// jsErrorMutex              sync.Mutex // Remains removed
 	// lastJSConversionHadErrors bool      // Remains removed
 }
@@ -208,6 +210,7 @@ func NewSDKService(
 	pyFS embed.FS, // Changed from pyGenScriptPath to pyFS to match struct
 	phpFS embed.FS, // Changed from phpGenScriptPath to phpFS to match struct
 	phpVendorFS embed.FS, // Changed from phpVendorZipPath to phpVendorFS
+	authorizer ResourceAuthorizer,
 ) (*SDKService, error) {
 	if openAPIGenPath == "" {
 		logger.Warn("OpenAPI Generator path not explicitly set. Relying on it being in PATH or pre-configured.")
@@ -233,6 +236,7 @@ func NewSDKService(
 		phpVendorZip:    phpVendorFS, // Correctly assign embed.FS
 		tempDirRootBase: tempDirRoot,
 		jsRuntime:       jsRuntime,
+		authorizer:      authorizer,
 	}, nil
 }
 
@@ -291,10 +295,15 @@ func (s *SDKService) UpdateSDKRecord(ctx context.Context, sdk *models.SDK) error
 	return nil
 }
 
-// GetSDKsByUserID retrieves a paginated list of SDKs for a specific user.
+// GetSDKsByUserID retrieves a paginated list of the SDKs of a user and of the user's organizations.
 func (s *SDKService) GetSDKsByUserID(ctx context.Context, userID string, page, limit int) ([]*models.SDK, int64, error) {
 	s.logger.Info("Fetching SDKs for user", zap.String("userID", userID), zap.Int("page", page), zap.Int("limit", limit))
-	sdks, total, err := s.sdkRepo.GetByUserID(ctx, userID, page, limit)
+	organizationIDs, err := s.authorizer.OrganizationIDs(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to retrieve organizations of user", zap.Error(err), zap.String("userID", userID))
+		return nil, 0, fmt.Errorf("failed to get SDKs for user %s: %w", userID, err)
+	}
+	sdks, total, err := s.sdkRepo.GetAccessible(ctx, userID, organizationIDs, page, limit)
 	if err != nil {
 		s.logger.Error("Failed to retrieve SDKs for user from repository", zap.Error(err), zap.String("userID", userID))
 		return nil, 0, fmt.Errorf("failed to get SDKs for user %s: %w", userID, err)
@@ -385,9 +394,13 @@ func (s *SDKService) GenerateSDK(ctx context.Context, genReq *models.SDKGenerati
 		}
 	}()
 
-	// Step 1: Fetch the collection data (e.g., Postman JSON) using genReq.CollectionID
-	s.logger.Info("Fetching Postman collection data", zap.String("collectionID", genReq.CollectionID))
-	postmanJSON, err := s.postmanClient.GetRawCollectionJSONByID(genReq.CollectionID)
+	// Step 1: Fetch the collection data (e.g., Postman JSON) using genReq.CollectionID, unless
+	// the request carries the content already
+	postmanJSON := genReq.PostmanJSON
+	if postmanJSON == "" {
+		s.logger.Info("Fetching Postman collection data", zap.String("collectionID", genReq.CollectionID))
+		postmanJSON, err = s.postmanClient.GetRawCollectionJSONByID(genReq.CollectionID)
+	}
 	if err != nil {
 		s.logger.Error("Failed to fetch Postman collection data", zap.String("collectionID", genReq.CollectionID), zap.Error(err))
 		sdkRecord.Status = models.SDKStatusFailed
@@ -521,7 +534,8 @@ func (s *SDKService) GenerateMCP(ctx context.Context, genReq *models.MCPGenerati
 	s.logger.Info("Starting MCP generation process in service",
 		zap.String("recordID", recordID.Hex()),
 		zap.String("collectionID", genReq.CollectionID),
-		zap.String("transport", string(genReq.Transport)),
+		zap.String("transport", genReq.Transport),
+		zap.String("language", genReq.Language),
 	)
 
 	sdkRecord, err := s.sdkRepo.GetByID(ctx, recordID)
@@ -585,7 +599,7 @@ func (s *SDKService) GenerateMCP(ctx context.Context, genReq *models.MCPGenerati
 	}
 	s.logger.Info("OpenAPI spec written to file for MCP", zap.String("filePath", openAPIFilePath))
 
-	// Step 4: Invoke the MCP generation tool based on genReq.Transport and other parameters
+	// Step 4: Render the MCP server project based on genReq.Transport and genReq.Language
 	finalMCPDir := filepath.Join("generated_mcps", recordID.Hex())
 	if err := os.MkdirAll(finalMCPDir, 0755); err != nil {
 		s.logger.Error("Failed to create final MCP directory", zap.String("dirPath", finalMCPDir), zap.Error(err))
@@ -595,9 +609,20 @@ func (s *SDKService) GenerateMCP(ctx context.Context, genReq *models.MCPGenerati
 		return sdkRecord, fmt.Errorf("failed to create MCP directory: %w", err)
 	}
 
-	generatedMCPPath, mcpID, err := s.GenerateMCPServer(ctx, sdkRecord.UserID, genReq.CollectionID, openAPIStr, finalMCPDir, string(genReq.Transport), genReq.Port)
+	opts, err := mcpCodegenOptions(genReq)
+	if err != nil {
+		s.logger.Error("Invalid MCP generation options", zap.String("transport", genReq.Transport), zap.String("language", genReq.Language), zap.Error(err))
+		sdkRecord.Status = models.SDKStatusFailed
+		sdkRecord.ErrorMessage = err.Error()
+		s.sdkRepo.Update(ctx, sdkRecord)
+		return sdkRecord, err
+	}
+
+	// Generate into a subdirectory so the archive is not written inside the tree it zips.
+	generatedMCPPath := filepath.Join(finalMCPDir, "server")
+	result, err := s.GenerateMCPServer(ctx, openAPIStr, generatedMCPPath, opts)
 	if err != nil {
-		s.logger.Error("Failed to generate MCP server", zap.String("transport", string(genReq.Transport)), zap.Error(err))
+		s.logger.Error("Failed to generate MCP server", zap.String("transport", genReq.Transport), zap.Error(err))
 		sdkRecord.Status = models.SDKStatusFailed
 		sdkRecord.ErrorMessage = fmt.Sprintf("Failed to generate MCP server: %s", err.Error())
 		s.sdkRepo.Update(ctx, sdkRecord)
@@ -624,12 +649,12 @@ func (s *SDKService) GenerateMCP(ctx context.Context, genReq *models.MCPGenerati
 	s.logger.Info("MCP generation completed successfully",
 		zap.String("recordID", recordID.Hex()),
 		zap.String("filePath", sdkRecord.FilePath),
-		zap.String("mcpID", mcpID),
+		zap.Int("toolCount", result.ToolCount),
 	)
 	return sdkRecord, nil
 }
 
-// GetSDKByID retrieves an SDK by its ID and verifies user ownership.
+// GetSDKByID retrieves an SDK by its ID and verifies the user may read it.
 func (s *SDKService) GetSDKByID(ctx context.Context, sdkID primitive.ObjectID, userID string) (*models.SDK, error) {
 	s.logger.Info("Fetching SDK by ID for user", zap.String("sdkID", sdkID.Hex()), zap.String("userID", userID))
 	sdk, err := s.sdkRepo.GetByID(ctx, sdkID)
@@ -642,16 +667,21 @@ func (s *SDKService) GetSDKByID(ctx context.Context, sdkID primitive.ObjectID, u
 		return nil, fmt.Errorf("failed to retrieve SDK %s: %w", sdkID.Hex(), err)
 	}
 
-	if sdk.UserID != userID {
-		s.logger.Warn("User not authorized to access SDK", zap.String("sdkID", sdkID.Hex()), zap.String("sdkUserID", sdk.UserID), zap.String("requestUserID", userID))
-		return nil, fmt.Errorf("user not authorized to access SDK %s", sdkID.Hex()) // Consider a utils.ErrUnauthorized
+	if sdk == nil {
+		s.logger.Warn("SDK not found", zap.String("sdkID", sdkID.Hex()))
+		return nil, fmt.Errorf("SDK with ID %s not found", sdkID.Hex())
+	}
+
+	if err := s.authorizer.Authorize(ctx, userID, sdk.UserID, sdk.OrganizationID, models.ResourceSDKs, models.ActionRead); err != nil {
+		s.logger.Warn("User not authorized to access SDK", zap.String("sdkID", sdkID.Hex()), zap.String("sdkUserID", sdk.UserID), zap.String("requestUserID", userID), zap.Error(err))
+		return nil, fmt.Errorf("user not authorized to access SDK %s: %w", sdkID.Hex(), err)
 	}
 
 	s.logger.Info("SDK retrieved successfully by ID for user", zap.String("sdkID", sdkID.Hex()))
 	return sdk, nil
 }
 
-// DeleteSDK soft deletes an SDK record, verifying ownership.
+// DeleteSDK soft deletes an SDK record, verifying the user may delete it.
 // It also attempts to remove the generated SDK file/directory.
 func (s *SDKService) DeleteSDK(ctx context.Context, sdkID primitive.ObjectID, userID string) error {
 	s.logger.Info("Attempting to delete SDK", zap.String("sdkID", sdkID.Hex()), zap.String("userID", userID))
@@ -667,17 +697,23 @@ func (s *SDKService) DeleteSDK(ctx context.Context, sdkID primitive.ObjectID, us
 		return fmt.Errorf("failed to retrieve SDK %s for deletion: %w", sdkID.Hex(), err)
 	}
 
-	if sdk.UserID != userID {
+	if sdk == nil {
+		s.logger.Warn("SDK not found for deletion", zap.String("sdkID", sdkID.Hex()))
+		return fmt.Errorf("SDK with ID %s not found", sdkID.Hex())
+	}
+
+	if err := s.authorizer.Authorize(ctx, userID, sdk.UserID, sdk.OrganizationID, models.ResourceSDKs, models.ActionDelete); err != nil {
 		s.logger.Warn("User not authorized to delete SDK",
 			zap.String("sdkID", sdkID.Hex()),
 			zap.String("recordUserID", sdk.UserID),
-			zap.String("requestUserID", userID))
-		return fmt.Errorf("user not authorized to delete SDK %s", sdkID.Hex()) // Or utils.ErrUnauthorized
+			zap.String("requestUserID", userID),
+			zap.Error(err))
+		return fmt.Errorf("user not authorized to delete SDK %s: %w", sdkID.Hex(), err)
 	}
 
-	// Soft delete the record in the database
-	// The repository's SoftDelete might also take userID for an additional check, which is fine.
-	err = s.sdkRepo.SoftDelete(ctx, sdkID, userID)
+	// Soft delete the record in the database. Organization members may delete SDKs generated
+	// by others, so the record is not filtered by user here.
+	err = s.sdkRepo.SoftDeleteSDK(ctx, sdkID)
 	if err != nil {
 		s.logger.Error("Failed to soft delete SDK record in repository", zap.Error(err), zap.String("sdkID", sdkID.Hex()))
 		return fmt.Errorf("failed to delete SDK record %s: %w", sdkID.Hex(), err)
@@ -718,6 +754,14 @@ func (s *SDKService) DeleteSDK(ctx context.Context, sdkID primitive.ObjectID, us
 	return nil
 }
 
+// SetCollectionOrganization moves the SDK records of a collection into the organization the collection moved to.
+func (s *SDKService) SetCollectionOrganization(ctx context.Context, collectionID string, organizationID string) error {
+	if err := s.sdkRepo.SetOrganizationForCollection(ctx, collectionID, organizationID); err != nil {
+		return fmt.Errorf("failed to move SDKs of collection %s: %w", collectionID, err)
+	}
+	return nil
+}
+
 // ConvertPostmanToOpenAPI converts a Postman collection JSON to OpenAPI v3 JSON.
 // It uses an embedded JavaScript bundle (Postman SDK) to perform the conversion.
 func (s *SDKService) ConvertPostmanToOpenAPI(ctx context.Context, postmanCollectionJSON string) (string, error) {
@@ -965,86 +1009,55 @@ func (s *SDKService) waitForPromise(vm *goja.Runtime, promiseVal goja.Value) <-c
 	return ch
 }
 
-// GenerateMCPServer generates an MCP server project from an OpenAPI specification string using the mcpgen Go module.
-// This is a separate method that was previously part of the monolithic GenerateSDK.
-// It's kept for potential direct use but the main flow uses GenerateMCP via the interface.
-func (s *SDKService) GenerateMCPServer(ctx context.Context, userID, collectionID, openAPISpecContent, outputDir, transport string, port int) (string, string, error) {
-	startTime := time.Now()
-	s.logger.Info("Starting MCP server generation using mcpgen",
-		zap.String("userID", userID),
-		zap.String("collectionID", collectionID),
+// GenerateMCPServer renders a standalone MCP server project for an OpenAPI specification string.
+// The project is written to outputDir by the embedded codegen package; no external tooling is required.
+// It does not touch the SDK record, the caller (GenerateMCP) owns status updates.
+func (s *SDKService) GenerateMCPServer(ctx context.Context, openAPISpecContent, outputDir string, opts codegen.Options) (*codegen.Result, error) {
+	s.logger.Info("Starting MCP server generation",
 		zap.String("outputDir", outputDir),
-		zap.String("transport", transport),
-		zap.Int("port", port),
+		zap.String("language", string(opts.Language)),
+		zap.String("transport", string(opts.Transport)),
+		zap.Int("port", opts.Port),
 	)
 
-	mcpRecord := &models.SDK{
-		UserID:         userID,
-		CollectionID:   collectionID,
-		GenerationType: models.GenerationTypeMCP,
-		MCPTransport:   transport,
-		MCPPort:        port,
-		Status:         models.SDKStatusPending,
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
-	}
-
-	createdRecord, err := s.sdkRepo.Create(ctx, mcpRecord)
-	if err != nil {
-		s.logger.Error("Failed to create MCP record", zap.Error(err))
-		return "", "", fmt.Errorf("failed to create MCP record: %w", err)
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	mcpRecord = createdRecord
-	s.logger.Info("MCP record created", zap.String("sdkID", mcpRecord.ID.Hex()))
 
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		s.logger.Error("Failed to create output directory", zap.String("outputDir", outputDir), zap.Error(err))
-		s.UpdateSDKRecordStatus(ctx, mcpRecord.ID.Hex(), models.SDKStatusFailed, time.Since(startTime).Milliseconds(), fmt.Sprintf("failed to create output directory %s: %s", outputDir, err.Error()))
-		return "", mcpRecord.ID.Hex(), fmt.Errorf("failed to create output directory %s: %w", outputDir, err)
+		return nil, fmt.Errorf("failed to create output directory %s: %w", outputDir, err)
 	}
 
-	tempSpecFile, err := os.CreateTemp("", "openapi_spec_*.json")
+	result, err := codegen.Generate([]byte(openAPISpecContent), outputDir, opts)
 	if err != nil {
-		s.logger.Error("Failed to create temporary file for OpenAPI spec", zap.Error(err))
-		s.UpdateSDKRecordStatus(ctx, mcpRecord.ID.Hex(), models.SDKStatusFailed, time.Since(startTime).Milliseconds(), fmt.Sprintf("failed to create temp spec file: %s", err.Error()))
-		return "", mcpRecord.ID.Hex(), fmt.Errorf("failed to create temp spec file: %w", err)
+		s.logger.Error("Failed to generate MCP server", zap.String("outputDir", outputDir), zap.Error(err))
+		return nil, err
 	}
-	defer os.Remove(tempSpecFile.Name())
-	if _, err := tempSpecFile.Write([]byte(openAPISpecContent)); err != nil {
-		s.logger.Error("Failed to write OpenAPI spec to temporary file", zap.Error(err))
-		s.UpdateSDKRecordStatus(ctx, mcpRecord.ID.Hex(), models.SDKStatusFailed, time.Since(startTime).Milliseconds(), fmt.Sprintf("failed to write spec to temp file: %s", err.Error()))
-		return "", mcpRecord.ID.Hex(), fmt.Errorf("failed to write spec to temp file: %w", err)
-	}
-	tempSpecFile.Close()
 
-	// Check if mcpgen is available in PATH
-	_, err = exec.LookPath("mcpgen")
+	s.logger.Info("MCP server generation completed",
+		zap.String("outputDir", outputDir),
+		zap.Int("toolCount", result.ToolCount),
+		zap.Strings("files", result.Files))
+	return result, nil
+}
+
+// mcpCodegenOptions translates an MCP generation request into generator options.
+func mcpCodegenOptions(genReq *models.MCPGenerationRequest) (codegen.Options, error) {
+	language, err := codegen.NormalizeLanguage(genReq.Language)
 	if err != nil {
-		errorMsg := "mcpgen command not found in PATH. Please install mcpgen CLI tool."
-		s.logger.Error(errorMsg, zap.Error(err))
-		s.UpdateSDKRecordStatus(ctx, mcpRecord.ID.Hex(), models.SDKStatusFailed, time.Since(startTime).Milliseconds(), errorMsg)
-		return "", mcpRecord.ID.Hex(), fmt.Errorf("%s", errorMsg)
+		return codegen.Options{}, err
 	}
-
-	cmd := exec.Command("mcpgen", "generate",
-		"--input", tempSpecFile.Name(),
-		"--output", outputDir,
-		"--transport", transport,
-		"--port", fmt.Sprintf("%d", port),
-		"--force")
-
-	output, err := cmd.CombinedOutput()
+	transport, err := codegen.NormalizeTransport(genReq.Transport)
 	if err != nil {
-		s.logger.Error("Failed to generate MCP server", zap.Error(err), zap.String("output", string(output)))
-		errorMsg := fmt.Sprintf("mcpgen command failed: %s, output: %s. Ensure 'mcpgen' is installed and in PATH.", err.Error(), string(output))
-		s.UpdateSDKRecordStatus(ctx, mcpRecord.ID.Hex(), models.SDKStatusFailed, time.Since(startTime).Milliseconds(), errorMsg)
-		return "", mcpRecord.ID.Hex(), fmt.Errorf("failed to generate MCP server: %w, output: %s", err, string(output))
+		return codegen.Options{}, err
 	}
-
-	s.logger.Info("MCP server generation completed", zap.String("outputDir", outputDir), zap.String("commandOutput", string(output)))
-	s.UpdateSDKRecordStatus(ctx, mcpRecord.ID.Hex(), models.SDKStatusCompleted, time.Since(startTime).Milliseconds(), "")
-
-	return outputDir, mcpRecord.ID.Hex(), nil
+	return codegen.Options{
+		Name:      genReq.ServerName,
+		Language:  language,
+		Transport: transport,
+		Port:      genReq.Port,
+	}, nil
 }
 
 // generateWithOpenAPIGenerator uses the OpenAPI Generator CLI to generate SDKs.
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/secrets"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidSecretReference is returned for secret references that cannot be used: malformed,
+// to a backend that is not configured, or outside the namespace they must lie in.
+var ErrInvalidSecretReference = errors.New("invalid secret reference")
+
+// LinkedAccountSecretNamespace is the namespace of the secrets the linked accounts of an owner
+// may reference, so that users cannot reach the secrets of other owners.
+func LinkedAccountSecretNamespace(ownerID string) string {
+	return "linked-accounts/" + ownerID
+}
+
+// SecretService resolves the references to secret stores held by integrations and linked
+// accounts.
+type SecretService interface {
+	// ValidateReference checks that ref can be resolved: it is well-formed, its backend is
+	// configured and, unless namespace is empty, its path lies in namespace. Environment
+	// variables can only be referenced without a namespace.
+	ValidateReference(ref, namespace string) error
+	// Resolve returns the secret ref names. Every read is recorded in the audit log along with
+	// metadata naming what the secret is used for.
+	Resolve(ctx context.Context, ref string, metadata map[string]string) (string, error)
+}
+
+// secretService is the concrete implementation of SecretService.
+type secretService struct {
+	resolver *secrets.Resolver
+	audit    AuditService
+	logger   *zap.Logger
+}
+
+// NewSecretService creates a new SecretService reading from the stores of resolver.
+func NewSecretService(resolver *secrets.Resolver, audit AuditService, logger *zap.Logger) SecretService {
+	return &secretService{resolver: resolver, audit: audit, logger: logger}
+}
+
+// ValidateReference checks that a reference can be resolved.
+func (s *secretService) ValidateReference(ref, namespace string) error {
+	parsed, err := secrets.ParseReference(ref)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSecretReference, err)
+	}
+	if !s.resolver.Supports(parsed) {
+		return fmt.Errorf("%w: backend %q is not configured", ErrInvalidSecretReference, parsed.Backend)
+	}
+	if namespace == "" {
+		return nil
+	}
+	if parsed.Backend == secrets.BackendEnv || !parsed.Within(namespace) {
+		return fmt.Errorf("%w: the path must start with %s/", ErrInvalidSecretReference, namespace)
+	}
+	return nil
+}
+
+// Resolve returns the secret a reference names and audits the read.
+func (s *secretService) Resolve(ctx context.Context, ref string, metadata map[string]string) (string, error) {
+	event := &models.AuditEvent{
+		Action:     models.AuditActionSecretRead,
+		TargetType: models.AuditTargetSecret,
+		TargetID:   ref,
+		Metadata:   map[string]string{},
+	}
+	for key, value := range metadata {
+		event.Metadata[key] = value
+	}
+
+	value, cached, err := s.resolve(ctx, ref)
+	event.Metadata["cached"] = strconv.FormatBool(cached)
+	if err != nil {
+		event.Outcome = models.AuditOutcomeFailure
+		event.Metadata["reason"] = err.Error()
+		s.logger.Warn("Failed to read secret", zap.String("ref", ref), zap.Error(err))
+	}
+	s.audit.Record(ctx, event)
+	return value, err
+}
+
+func (s *secretService) resolve(ctx context.Context, ref string) (string, bool, error) {
+	parsed, err := secrets.ParseReference(ref)
+	if err != nil {
+		return "", false, err
+	}
+	return s.resolver.Resolve(ctx, parsed)
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/secrets"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// mapSecretStore serves fixed secrets.
+type mapSecretStore map[string]string
+
+func (s mapSecretStore) Get(ctx context.Context, path string) (string, error) {
+	value, ok := s[path]
+	if !ok {
+		return "", secrets.ErrNotFound
+	}
+	return value, nil
+}
+
+// newTestSecretService returns a secret service of a vault store and an env store.
+func newTestSecretService(vault mapSecretStore) SecretService {
+	resolver := secrets.NewResolver(map[string]secrets.Store{
+		secrets.BackendVault: vault,
+		secrets.BackendEnv:   secrets.NewEnvStore("API2SDK_SECRET_"),
+	}, time.Minute)
+	return NewSecretService(resolver, NewAuditService(&memoryAuditEvents{}, 0, zap.NewNop()), zap.NewNop())
+}
+
+func TestValidateSecretReference(t *testing.T) {
+	service := newTestSecretService(mapSecretStore{})
+	namespace := LinkedAccountSecretNamespace("customer-1")
+
+	tests := []struct {
+		ref, namespace string
+		valid          bool
+	}{
+		{"vault:integrations/github#token", "", true},
+		{"env:API2SDK_SECRET_GITHUB", "", true},
+		{"vault:linked-accounts/customer-1/github", namespace, true},
+		{"vault:linked-accounts/customer-2/github", namespace, false},
+		{"vault:linked-accounts/customer-1", namespace, false},
+		{"vault:linked-accounts/customer-1/../customer-2/github", namespace, false},
+		{"env:API2SDK_SECRET_GITHUB", namespace, false},
+		{"file:linked-accounts/customer-1/github", namespace, false},
+		{"not a reference", "", false},
+	}
+	for _, tt := range tests {
+		err := service.ValidateReference(tt.ref, tt.namespace)
+		if tt.valid && err != nil {
+			t.Errorf("ValidateReference(%q, %q) = %v, want valid", tt.ref, tt.namespace, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidSecretReference) {
+			t.Errorf("ValidateReference(%q, %q) = %v, want ErrInvalidSecretReference", tt.ref, tt.namespace, err)
+		}
+	}
+}
+
+func TestResolveSecretIsAudited(t *testing.T) {
+	service := newTestSecretService(mapSecretStore{"integrations/github#token": "gh-token"})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if value, err := service.Resolve(ctx, "vault:integrations/github#token", map[string]string{"integrationId": "i-1"}); err != nil || value != "gh-token" {
+			t.Fatalf("Resolve() = %q, %v", value, err)
+		}
+	}
+	if _, err := service.Resolve(ctx, "vault:integrations/gitlab", nil); !errors.Is(err, secrets.ErrNotFound) {
+		t.Fatalf("Resolve() of a missing secret error = %v, want ErrNotFound", err)
+	}
+
+	events := recordedAuditEvents(service.(*secretService).audit)
+	if len(events) != 3 {
+		t.Fatalf("recorded %d events, want 3", len(events))
+	}
+	first, second, failed := events[0], events[1], events[2]
+	if first.Action != models.AuditActionSecretRead || first.TargetID != "vault:integrations/github#token" || first.Metadata["integrationId"] != "i-1" || first.Metadata["cached"] != "false" {
+		t.Errorf("first read = %+v", first)
+	}
+	if second.Metadata["cached"] != "true" {
+		t.Errorf("second read = %+v, want it served from the cache", second)
+	}
+	if failed.Outcome != models.AuditOutcomeFailure || failed.Metadata["reason"] == "" {
+		t.Errorf("failed read = %+v, want a failure with its reason", failed)
+	}
+}
+
+func TestResolveCredentialsFromSecretStore(t *testing.T) {
+	ctx := context.Background()
+	secretService := newTestSecretService(mapSecretStore{
+		"integrations/github#key":                 "shared-key",
+		"linked-accounts/customer-1/github#token": "customer-token",
+	})
+	repo := &memoryLinkedAccounts{}
+	service := NewLinkedAccountService(repo, nil, nil, secretService, NewAuditService(&memoryAuditEvents{}, 0, zap.NewNop()), zap.NewNop())
+	integration := &models.Integration{ID: primitive.NewObjectID(), APIKeyRef: "vault:integrations/github#key"}
+
+	shared, err := service.ResolveCredentials(ctx, integration, "")
+	if err != nil || shared.APIKey != "shared-key" || shared.Secret != "shared-key" {
+		t.Fatalf("ResolveCredentials() = %+v, %v, want the referenced shared key", shared, err)
+	}
+
+	_, err = service.CreateLinkedAccount(ctx, "customer-1", &models.CreateLinkedAccountRequest{
+		IntegrationID: integration.ID.Hex(),
+		AuthType:      models.LinkedAccountAuthBearer,
+		SecretRefs:    map[string]string{models.CredentialToken: "vault:linked-accounts/customer-1/github#token"},
+	})
+	if err != nil {
+		t.Fatalf("CreateLinkedAccount() error = %v", err)
+	}
+	if stored := repo.accounts[0].Credentials; stored.Token != "" || stored.SecretRefs[models.CredentialToken] == "" {
+		t.Errorf("stored credentials = %+v, want only the reference", stored)
+	}
+	own, err := service.ResolveCredentials(ctx, integration, "customer-1")
+	if err != nil || own.Token != "customer-token" || own.LinkedAccountID.IsZero() {
+		t.Errorf("ResolveCredentials() = %+v, %v, want the referenced token", own, err)
+	}
+
+	invalid := []*models.CreateLinkedAccountRequest{
+		// Another owner's secret
+		{AuthType: models.LinkedAccountAuthBearer, SecretRefs: map[string]string{models.CredentialToken: "vault:linked-accounts/customer-1/github#token"}},
+		// The platform's configuration
+		{AuthType: models.LinkedAccountAuthAPIKey, SecretRefs: map[string]string{models.CredentialAPIKey: "env:API2SDK_SECRET_GITHUB"}},
+		// A field the auth type does not use
+		{AuthType: models.LinkedAccountAuthBearer, SecretRefs: map[string]string{models.CredentialAPIKey: "vault:linked-accounts/customer-2/key"}},
+		// Tokens refreshed by the platform
+		{AuthType: models.LinkedAccountAuthOAuth2, AccessToken: "at", SecretRefs: map[string]string{models.CredentialToken: "vault:linked-accounts/customer-2/token"}},
+	}
+	for i, req := range invalid {
+		req.IntegrationID = integration.ID.Hex()
+		if _, err := service.CreateLinkedAccount(ctx, "customer-2", req); !errors.Is(err, ErrInvalidSecretReference) {
+			t.Errorf("CreateLinkedAccount(invalid %d) error = %v, want ErrInvalidSecretReference", i, err)
+		}
+	}
+	if _, err := service.CreateLinkedAccount(ctx, "customer-2", &models.CreateLinkedAccountRequest{
+		IntegrationID: integration.ID.Hex(),
+		AuthType:      models.LinkedAccountAuthBearer,
+		Token:         "value",
+		SecretRefs:    map[string]string{models.CredentialToken: "vault:linked-accounts/customer-2/token"},
+	}); err == nil {
+		t.Error("CreateLinkedAccount() with a token given twice succeeded")
+	}
+}
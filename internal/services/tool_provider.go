@@ -13,6 +13,17 @@ type ToolProvider interface {
 	// GetTools returns a list of all tools provided by the integration.
 	GetTools(ctx context.Context, integrationID primitive.ObjectID) ([]models.Tool, error)
 
-	// ExecuteTool runs a specific tool with the given arguments.
-	ExecuteTool(ctx context.Context, integrationID primitive.ObjectID, toolName string, arguments map[string]interface{}) (interface{}, error)
+	// ExecuteTool runs a specific tool with the given arguments, using the credentials
+	// linked by linkedAccountOwnerID. An empty owner ID uses the integration's own key.
+	// The response is shaped into MCP content blocks, see models.ResponseShaping.
+	ExecuteTool(ctx context.Context, integrationID primitive.ObjectID, linkedAccountOwnerID string, toolName string, arguments map[string]interface{}) (*models.ToolResult, error)
+
+	// ContinueResult returns the next part of a truncated result.
+	ContinueResult(ctx context.Context, continuationToken string) (*models.ToolResult, error)
+
+	// NextPage fetches the next page of a paginated result.
+	NextPage(ctx context.Context, pageToken string) (*models.ToolResult, error)
+
+	// ReadResultResource returns a tool response that was exposed as a tool-result:// resource.
+	ReadResultResource(ctx context.Context, uri string) (*models.ResourceContents, error)
 }
@@ -3,50 +3,186 @@ package services
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/AkashKesav/API2SDK/internal/models"
+	"github.com/AkashKesav/API2SDK/internal/openapi"
+	"github.com/AkashKesav/API2SDK/internal/outbound"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
 )
 
 // toolProviderService is a concrete implementation of the ToolProvider interface.
 type toolProviderService struct {
-	integrationService IntegrationService
+	integrationService   IntegrationService
+	linkedAccountService LinkedAccountService
+	audit                AuditService
+	httpClient           *http.Client
+	results              *resultStore
+	logger               *zap.Logger
 }
 
-// NewToolProviderService creates a new ToolProviderService.
-func NewToolProviderService(integrationService IntegrationService) ToolProvider {
+// NewToolProviderService creates a new ToolProviderService. httpClient is the shared
+// outbound client, which enforces the timeout, size and address limits of tool calls.
+func NewToolProviderService(integrationService IntegrationService, linkedAccountService LinkedAccountService, audit AuditService, httpClient *http.Client, logger *zap.Logger) ToolProvider {
 	return &toolProviderService{
-		integrationService: integrationService,
+		integrationService:   integrationService,
+		linkedAccountService: linkedAccountService,
+		audit:                audit,
+		httpClient:           httpClient,
+		results:              newResultStore(),
+		logger:               logger,
 	}
 }
 
 // GetTools returns a list of all tools provided by the integration.
+// Tools are derived from the integration's OpenAPI spec, one per operation, with
+// annotations inferred from the operation and then replaced by any per-integration overrides.
 func (s *toolProviderService) GetTools(ctx context.Context, integrationID primitive.ObjectID) ([]models.Tool, error) {
-	// In a real implementation, this would fetch the tools from the integration's API.
-	// For now, we'll just return a dummy list of tools.
-	return []models.Tool{
-		{
-			Name:        "get_weather",
-			Description: "Get the current weather for a location.",
-			InputSchema: map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"location": map[string]interface{}{
-						"type":        "string",
-						"description": "The location to get the weather for.",
-					},
-				},
-				"required": []string{"location"},
-			},
-		},
-	}, nil
+	integration, err := s.integrationService.GetIntegration(ctx, integrationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get integration: %w", err)
+	}
+	if integration.OpenAPISpec == "" {
+		return []models.Tool{}, nil
+	}
+
+	doc, err := openapi.Parse([]byte(integration.OpenAPISpec))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec for integration %s: %w", integration.Name, err)
+	}
+
+	tools := make([]models.Tool, 0, len(doc.Operations))
+	for _, op := range doc.Operations {
+		tool := op.Tool()
+		if override, ok := integration.ToolOverrides[tool.Name]; ok {
+			tool.Annotations.Apply(override)
+			tool.Title = tool.Annotations.Title
+		}
+		tools = append(tools, tool)
+	}
+	return tools, nil
 }
 
 // ExecuteTool runs a specific tool with the given arguments.
-func (s *toolProviderService) ExecuteTool(ctx context.Context, integrationID primitive.ObjectID, toolName string, arguments map[string]interface{}) (interface{}, error) {
-	// In a real implementation, this would execute the tool by making a request to the integration's API.
-	// For now, we'll just return a dummy result.
-	return map[string]interface{}{
-		"result": fmt.Sprintf("Successfully executed tool %s with arguments %v", toolName, arguments),
-	}, nil
+// The tool's operation is called on the integration's API with the credentials of
+// linkedAccountOwnerID, and every execution is recorded for auditing.
+func (s *toolProviderService) ExecuteTool(ctx context.Context, integrationID primitive.ObjectID, linkedAccountOwnerID string, toolName string, arguments map[string]interface{}) (*models.ToolResult, error) {
+	integration, err := s.integrationService.GetIntegration(ctx, integrationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get integration: %w", err)
+	}
+
+	execution := &models.ToolExecution{
+		IntegrationID: integrationID,
+		OwnerID:       linkedAccountOwnerID,
+		ToolName:      toolName,
+	}
+	start := time.Now()
+	result, err := s.executeOperation(ctx, integration, linkedAccountOwnerID, toolName, arguments, execution)
+	execution.DurationMs = time.Since(start).Milliseconds()
+	execution.Success = err == nil && execution.StatusCode < 400
+	if err != nil {
+		execution.Error = err.Error()
+	}
+	s.linkedAccountService.RecordExecution(ctx, execution)
+	s.auditExecution(ctx, integration, execution)
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// auditExecution records a tool execution in the audit log.
+func (s *toolProviderService) auditExecution(ctx context.Context, integration *models.Integration, execution *models.ToolExecution) {
+	event := &models.AuditEvent{
+		Action:         models.AuditActionToolExecute,
+		OrganizationID: integration.OrganizationID,
+		TargetType:     models.AuditTargetIntegration,
+		TargetID:       integration.ID.Hex(),
+		Metadata: map[string]string{
+			"tool":       execution.ToolName,
+			"owner":      execution.OwnerID,
+			"statusCode": strconv.Itoa(execution.StatusCode),
+			"durationMs": strconv.FormatInt(execution.DurationMs, 10),
+		},
+	}
+	if !execution.LinkedAccountID.IsZero() {
+		event.Metadata["linkedAccountId"] = execution.LinkedAccountID.Hex()
+	}
+	if !execution.Success {
+		event.Outcome = models.AuditOutcomeFailure
+		if execution.Error != "" {
+			event.Metadata["error"] = execution.Error
+		}
+	}
+	s.audit.Record(ctx, event)
+}
+
+func (s *toolProviderService) executeOperation(ctx context.Context, integration *models.Integration, ownerID, toolName string, arguments map[string]interface{}, execution *models.ToolExecution) (*models.ToolResult, error) {
+	doc, err := openapi.Parse([]byte(integration.OpenAPISpec))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec for integration %s: %w", integration.Name, err)
+	}
+	op := doc.Operation(toolName)
+	if op == nil {
+		return nil, fmt.Errorf("tool '%s' not found in integration %s", toolName, integration.Name)
+	}
+
+	credentials, err := s.linkedAccountService.ResolveCredentials(ctx, integration, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if credentials != nil {
+		execution.LinkedAccountID = credentials.LinkedAccountID
+	}
+
+	baseURL := integration.BaseURL
+	if baseURL == "" {
+		baseURL = doc.BaseURL()
+	}
+	if baseURL == "" {
+		return nil, fmt.Errorf("integration %s has no base URL", integration.Name)
+	}
+
+	reqCtx := outbound.WithIntegration(ctx, integration.Name)
+	if credentials != nil {
+		reqCtx = outbound.WithCredentials(reqCtx)
+	}
+	req, err := op.NewRequest(reqCtx, baseURL, arguments)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := NewRequestSigner(integration, doc, op)
+	if err != nil {
+		return nil, err
+	}
+	if err := credentials.Sign(req, signer); err != nil {
+		return nil, fmt.Errorf("failed to sign request to %s: %w", integration.Name, err)
+	}
+
+	s.logger.Debug("Executing tool",
+		zap.String("toolName", toolName),
+		zap.String("integration", integration.Name),
+		zap.String("method", req.Method),
+		zap.String("url", req.URL.Redacted()),
+		zap.String("linkedAccountID", execution.LinkedAccountID.Hex()))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", integration.Name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %w", integration.Name, err)
+	}
+	execution.StatusCode = resp.StatusCode
+
+	return s.shapeResponse(integration, op, ownerID, arguments, resp, body)
 }
@@ -0,0 +1,515 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	// toolIndexTTL bounds how long the index trusts its view of the integration list.
+	// Changes made through IntegrationService are picked up immediately; the TTL
+	// catches changes made by other instances.
+	toolIndexTTL = 5 * time.Minute
+
+	// DefaultToolSearchLimit is used when a query does not set a limit.
+	DefaultToolSearchLimit = 20
+	// MaxToolSearchLimit caps the page size of a single query.
+	MaxToolSearchLimit = 100
+
+	// BM25 parameters.
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// Field weights: a term in the tool name counts three times, in tags or the category twice.
+	nameWeight = 3
+	tagWeight  = 2
+)
+
+var (
+	// ErrToolNotFound is returned by FindTool when no indexed tool has the name.
+	ErrToolNotFound = errors.New("tool not found")
+	// ErrAmbiguousTool is returned by FindTool when several apps have a tool with the name
+	// and the caller did not say which app it means.
+	ErrAmbiguousTool = errors.New("tool name is ambiguous")
+)
+
+// ToolSearchQuery describes a search over all integrations' tools.
+type ToolSearchQuery struct {
+	Query    string   `json:"query,omitempty"`
+	AppNames []string `json:"app_names,omitempty"`
+	Category string   `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Limit    int      `json:"limit,omitempty"`
+	Offset   int      `json:"offset,omitempty"`
+	// Filter, when set, drops tools for which it returns false before ranking and pagination.
+	Filter func(models.Tool) bool `json:"-"`
+}
+
+// ToolSearchHit is a single ranked tool.
+type ToolSearchHit struct {
+	models.Tool
+	IntegrationID primitive.ObjectID `json:"integration_id"`
+	App           string             `json:"app"`
+	Score         float64            `json:"score,omitempty"`
+}
+
+// ToolSearchResults is one page of search hits.
+type ToolSearchResults struct {
+	Hits   []ToolSearchHit `json:"functions"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+// ToolSearchService maintains an in-memory BM25 index over the tools of all integrations.
+type ToolSearchService interface {
+	// Search ranks tools against the query and returns one page of results.
+	Search(ctx context.Context, query ToolSearchQuery) (*ToolSearchResults, error)
+	// FindTool looks up a tool by its exact name. app, when set, is the name or ID of the
+	// integration the tool must belong to.
+	FindTool(ctx context.Context, name, app string) (*ToolSearchHit, error)
+	// InvalidateIntegration schedules an integration to be re-indexed before the next query.
+	InvalidateIntegration(id primitive.ObjectID)
+	// Refresh synchronises the index with the integration store.
+	Refresh(ctx context.Context) error
+}
+
+// indexedTool is a tool together with its term frequencies.
+type indexedTool struct {
+	hit    ToolSearchHit
+	terms  map[string]int
+	length int
+}
+
+// indexedIntegration holds the indexed tools of one integration.
+type indexedIntegration struct {
+	name      string
+	updatedAt time.Time
+	tools     []*indexedTool
+}
+
+// toolSearchService is the concrete implementation of ToolSearchService.
+type toolSearchService struct {
+	integrationService IntegrationService
+	toolProvider       ToolProvider
+	logger             *zap.Logger
+
+	// syncMu serialises synchronisation so that concurrent queries do not re-index twice.
+	syncMu sync.Mutex
+
+	mu           sync.RWMutex
+	integrations map[primitive.ObjectID]*indexedIntegration
+	docFreq      map[string]int
+	docCount     int
+	totalLength  int
+	dirty        map[primitive.ObjectID]bool
+	syncedAt     time.Time
+}
+
+// NewToolSearchService creates a new ToolSearchService. The index is built lazily on the first query.
+func NewToolSearchService(integrationService IntegrationService, toolProvider ToolProvider, logger *zap.Logger) ToolSearchService {
+	return &toolSearchService{
+		integrationService: integrationService,
+		toolProvider:       toolProvider,
+		logger:             logger,
+		integrations:       make(map[primitive.ObjectID]*indexedIntegration),
+		docFreq:            make(map[string]int),
+		dirty:              make(map[primitive.ObjectID]bool),
+	}
+}
+
+// InvalidateIntegration schedules an integration to be re-indexed before the next query.
+func (s *toolSearchService) InvalidateIntegration(id primitive.ObjectID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dirty[id] = true
+}
+
+// Refresh synchronises the index with the integration store. Only integrations that
+// are new, were updated or were invalidated are re-indexed; deleted ones are dropped.
+func (s *toolSearchService) Refresh(ctx context.Context) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	return s.syncAll(ctx)
+}
+
+// ensureFresh brings the index up to date before a query.
+func (s *toolSearchService) ensureFresh(ctx context.Context) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	s.mu.RLock()
+	expired := time.Since(s.syncedAt) > toolIndexTTL
+	dirty := make([]primitive.ObjectID, 0, len(s.dirty))
+	for id := range s.dirty {
+		dirty = append(dirty, id)
+	}
+	s.mu.RUnlock()
+
+	if expired {
+		return s.syncAll(ctx)
+	}
+	for _, id := range dirty {
+		integration, err := s.integrationService.GetIntegration(ctx, id)
+		if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && integration == nil) {
+			// The integration is gone; stop serving its tools.
+			s.replace(id, nil)
+			continue
+		}
+		if err != nil {
+			// Keep serving the indexed tools; the integration stays dirty and is retried on the next query.
+			s.logger.Warn("Failed to re-index integration",
+				zap.String("integrationID", id.Hex()),
+				zap.Error(err))
+			continue
+		}
+		s.replace(id, s.indexIntegration(ctx, integration))
+	}
+	return nil
+}
+
+// syncAll diffs the integration list against the index. Callers must hold syncMu.
+func (s *toolSearchService) syncAll(ctx context.Context) error {
+	integrations, err := s.integrationService.ListIntegrations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list integrations: %w", err)
+	}
+
+	s.mu.RLock()
+	stale := make([]*models.Integration, 0)
+	seen := make(map[primitive.ObjectID]bool, len(integrations))
+	for _, integration := range integrations {
+		seen[integration.ID] = true
+		existing, ok := s.integrations[integration.ID]
+		if !ok || s.dirty[integration.ID] || !existing.updatedAt.Equal(integration.UpdatedAt) {
+			stale = append(stale, integration)
+		}
+	}
+	removed := make([]primitive.ObjectID, 0)
+	for id := range s.integrations {
+		if !seen[id] {
+			removed = append(removed, id)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, integration := range stale {
+		s.replace(integration.ID, s.indexIntegration(ctx, integration))
+	}
+	for _, id := range removed {
+		s.replace(id, nil)
+	}
+
+	s.mu.Lock()
+	s.syncedAt = time.Now()
+	s.mu.Unlock()
+
+	if len(stale) > 0 || len(removed) > 0 {
+		s.logger.Debug("Tool search index synchronised",
+			zap.Int("reindexed", len(stale)),
+			zap.Int("removed", len(removed)),
+			zap.Int("tools", s.docCount))
+	}
+	return nil
+}
+
+// indexIntegration fetches and tokenizes the tools of one integration.
+// A provider failure leaves the integration indexed without tools so that it is retried on the next sync.
+func (s *toolSearchService) indexIntegration(ctx context.Context, integration *models.Integration) *indexedIntegration {
+	entry := &indexedIntegration{name: integration.Name, updatedAt: integration.UpdatedAt}
+
+	tools, err := s.toolProvider.GetTools(ctx, integration.ID)
+	if err != nil {
+		s.logger.Warn("Failed to index tools for integration",
+			zap.String("integrationID", integration.ID.Hex()),
+			zap.String("integrationName", integration.Name),
+			zap.Error(err))
+		entry.updatedAt = time.Time{}
+		return entry
+	}
+
+	for _, tool := range tools {
+		terms := make(map[string]int)
+		addTerms(terms, tool.Name, nameWeight)
+		addTerms(terms, tool.Title, nameWeight)
+		addTerms(terms, tool.Description, 1)
+		addTerms(terms, tool.Category, tagWeight)
+		for _, tag := range tool.Tags {
+			addTerms(terms, tag, tagWeight)
+		}
+		for _, param := range schemaPropertyNames(tool.InputSchema) {
+			addTerms(terms, param, 1)
+		}
+
+		length := 0
+		for _, n := range terms {
+			length += n
+		}
+		entry.tools = append(entry.tools, &indexedTool{
+			hit: ToolSearchHit{
+				Tool:          tool,
+				IntegrationID: integration.ID,
+				App:           integration.Name,
+			},
+			terms:  terms,
+			length: length,
+		})
+	}
+	return entry
+}
+
+// replace swaps the indexed tools of one integration and keeps the corpus statistics in step.
+// A nil entry removes the integration.
+func (s *toolSearchService) replace(id primitive.ObjectID, entry *indexedIntegration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if old, ok := s.integrations[id]; ok {
+		for _, doc := range old.tools {
+			for term := range doc.terms {
+				if s.docFreq[term]--; s.docFreq[term] <= 0 {
+					delete(s.docFreq, term)
+				}
+			}
+			s.docCount--
+			s.totalLength -= doc.length
+		}
+		delete(s.integrations, id)
+	}
+	delete(s.dirty, id)
+
+	if entry == nil {
+		return
+	}
+	for _, doc := range entry.tools {
+		for term := range doc.terms {
+			s.docFreq[term]++
+		}
+		s.docCount++
+		s.totalLength += doc.length
+	}
+	s.integrations[id] = entry
+}
+
+// Search ranks tools against the query and returns one page of results.
+// Without query text, matching tools are returned ordered by app and name.
+func (s *toolSearchService) Search(ctx context.Context, query ToolSearchQuery) (*ToolSearchResults, error) {
+	if err := s.ensureFresh(ctx); err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultToolSearchLimit
+	}
+	if limit > MaxToolSearchLimit {
+		limit = MaxToolSearchLimit
+	}
+	offset := query.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	queryTerms := make(map[string]int)
+	addTerms(queryTerms, query.Query, 1)
+
+	s.mu.RLock()
+	avgLength := 1.0
+	if s.docCount > 0 {
+		avgLength = float64(s.totalLength) / float64(s.docCount)
+	}
+
+	var hits []ToolSearchHit
+	for _, entry := range s.integrations {
+		if !matchesAny(entry.name, query.AppNames) {
+			continue
+		}
+		for _, doc := range entry.tools {
+			if query.Category != "" && !strings.EqualFold(doc.hit.Category, query.Category) {
+				continue
+			}
+			if len(query.Tags) > 0 && !hasAnyTag(doc.hit.Tags, query.Tags) {
+				continue
+			}
+			if query.Filter != nil && !query.Filter(doc.hit.Tool) {
+				continue
+			}
+
+			hit := doc.hit
+			if len(queryTerms) > 0 {
+				hit.Score = s.score(doc, queryTerms, avgLength)
+				if hit.Score <= 0 {
+					continue
+				}
+			}
+			hits = append(hits, hit)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].App != hits[j].App {
+			return hits[i].App < hits[j].App
+		}
+		return hits[i].Name < hits[j].Name
+	})
+
+	results := &ToolSearchResults{Total: len(hits), Limit: limit, Offset: offset, Hits: []ToolSearchHit{}}
+	if offset < len(hits) {
+		end := offset + limit
+		if end > len(hits) {
+			end = len(hits)
+		}
+		results.Hits = hits[offset:end]
+	}
+	return results, nil
+}
+
+// score computes the BM25 score of one document. Callers must hold mu.
+func (s *toolSearchService) score(doc *indexedTool, queryTerms map[string]int, avgLength float64) float64 {
+	var score float64
+	for term := range queryTerms {
+		tf := float64(doc.terms[term])
+		if tf == 0 {
+			continue
+		}
+		df := float64(s.docFreq[term])
+		idf := math.Log(1 + (float64(s.docCount)-df+0.5)/(df+0.5))
+		norm := tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(doc.length)/avgLength))
+		score += idf * norm
+	}
+	return score
+}
+
+// FindTool looks up a tool by its exact name. app, when set, is the name or ID of the
+// integration the tool must belong to. Tool names are only unique within an integration,
+// so a name that several integrations share is refused unless app picks one of them.
+func (s *toolSearchService) FindTool(ctx context.Context, name, app string) (*ToolSearchHit, error) {
+	if err := s.ensureFresh(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	var matches []ToolSearchHit
+	for id, entry := range s.integrations {
+		if app != "" && app != id.Hex() && !strings.EqualFold(app, entry.name) {
+			continue
+		}
+		for _, doc := range entry.tools {
+			if doc.hit.Name == name {
+				matches = append(matches, doc.hit)
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s", ErrToolNotFound, name)
+	case 1:
+		return &matches[0], nil
+	}
+	apps := make([]string, len(matches))
+	for i, match := range matches {
+		apps[i] = fmt.Sprintf("%s (%s)", match.App, match.IntegrationID.Hex())
+	}
+	sort.Strings(apps)
+	return nil, fmt.Errorf("%w: %s is offered by %s", ErrAmbiguousTool, name, strings.Join(apps, ", "))
+}
+
+// addTerms tokenizes text and adds each term to terms with the given weight.
+func addTerms(terms map[string]int, text string, weight int) {
+	for _, token := range tokenize(text) {
+		terms[token] += weight
+	}
+}
+
+// tokenize splits text into lower-case terms. camelCase, snake_case and kebab-case
+// identifiers are split into their words, and a plural "s" is stripped.
+func tokenize(text string) []string {
+	var tokens []string
+	var current []rune
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		token := strings.ToLower(string(current))
+		current = current[:0]
+		if len(token) > 3 && strings.HasSuffix(token, "s") && !strings.HasSuffix(token, "ss") {
+			token = token[:len(token)-1]
+		}
+		if len(token) > 1 || unicode.IsDigit(rune(token[0])) {
+			tokens = append(tokens, token)
+		}
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			// Split "listPets" before "P" and "HTTPServer" before "S".
+			if unicode.IsUpper(r) && len(current) > 0 {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					flush()
+				}
+			}
+			current = append(current, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// schemaPropertyNames returns the top-level property names of a JSON schema.
+func schemaPropertyNames(schema map[string]interface{}) []string {
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	return names
+}
+
+func matchesAny(value string, candidates []string) bool {
+	if len(candidates) == 0 {
+		return true
+	}
+	for _, candidate := range candidates {
+		if strings.EqualFold(value, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyTag(tags, wanted []string) bool {
+	for _, tag := range tags {
+		if matchesAny(tag, wanted) {
+			return true
+		}
+	}
+	return false
+}
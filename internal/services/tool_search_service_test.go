@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// memoryIntegrationService keeps integrations in memory; unused methods panic.
+type memoryIntegrationService struct {
+	IntegrationService
+	integrations []*models.Integration
+	// getErr, when set, fails GetIntegration.
+	getErr error
+}
+
+func (s *memoryIntegrationService) ListIntegrations(ctx context.Context) ([]*models.Integration, error) {
+	return s.integrations, nil
+}
+
+func (s *memoryIntegrationService) GetIntegration(ctx context.Context, id primitive.ObjectID) (*models.Integration, error) {
+	if s.getErr != nil {
+		return nil, s.getErr
+	}
+	for _, integration := range s.integrations {
+		if integration.ID == id {
+			return integration, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// staticToolProvider serves fixed tools per integration; unused methods panic.
+type staticToolProvider struct {
+	ToolProvider
+	tools map[primitive.ObjectID][]models.Tool
+}
+
+func (p *staticToolProvider) GetTools(ctx context.Context, integrationID primitive.ObjectID) ([]models.Tool, error) {
+	return p.tools[integrationID], nil
+}
+
+// newTestToolSearch indexes two apps, github and jira, which both have a get_user tool.
+func newTestToolSearch() (ToolSearchService, *models.Integration, *models.Integration) {
+	github := &models.Integration{ID: primitive.NewObjectID(), Name: "github"}
+	jira := &models.Integration{ID: primitive.NewObjectID(), Name: "jira"}
+	provider := &staticToolProvider{tools: map[primitive.ObjectID][]models.Tool{
+		github.ID: {
+			{Name: "list_pull_requests", Description: "List pull requests of a repository", Tags: []string{"code"}},
+			{Name: "get_user", Description: "Get a GitHub user"},
+		},
+		jira.ID: {
+			{Name: "search_issues", Description: "Search issues with JQL", Category: "tracking"},
+			{Name: "get_user", Description: "Get a Jira user"},
+		},
+	}}
+	integrations := &memoryIntegrationService{integrations: []*models.Integration{github, jira}}
+	return NewToolSearchService(integrations, provider, zap.NewNop()), github, jira
+}
+
+func TestToolSearch(t *testing.T) {
+	search, _, _ := newTestToolSearch()
+
+	tests := []struct {
+		name  string
+		query ToolSearchQuery
+		want  []string
+	}{
+		{"ranked by relevance", ToolSearchQuery{Query: "pull requests"}, []string{"github/list_pull_requests"}},
+		{"plural and case folded", ToolSearchQuery{Query: "Issue"}, []string{"jira/search_issues"}},
+		{"app filter", ToolSearchQuery{Query: "user", AppNames: []string{"JIRA"}}, []string{"jira/get_user"}},
+		{"no query lists by app and name", ToolSearchQuery{Limit: 2, Offset: 1}, []string{"github/list_pull_requests", "jira/get_user"}},
+		{"category", ToolSearchQuery{Category: "tracking"}, []string{"jira/search_issues"}},
+		{"tags", ToolSearchQuery{Tags: []string{"code"}}, []string{"github/list_pull_requests"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := search.Search(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			var got []string
+			for _, hit := range results.Hits {
+				got = append(got, hit.App+"/"+hit.Name)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("Search() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindTool(t *testing.T) {
+	search, github, jira := newTestToolSearch()
+
+	tests := []struct {
+		name, tool, app string
+		want            primitive.ObjectID
+		wantErr         error
+	}{
+		{"unique name", "search_issues", "", jira.ID, nil},
+		{"shared name without app", "get_user", "", primitive.NilObjectID, ErrAmbiguousTool},
+		{"shared name with integration ID", "get_user", github.ID.Hex(), github.ID, nil},
+		{"shared name with app name", "get_user", "Jira", jira.ID, nil},
+		{"tool of another app", "search_issues", "github", primitive.NilObjectID, ErrToolNotFound},
+		{"unknown tool", "delete_user", "", primitive.NilObjectID, ErrToolNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, err := search.FindTool(context.Background(), tt.tool, tt.app)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FindTool(%q, %q) error = %v, want %v", tt.tool, tt.app, err, tt.wantErr)
+			}
+			if err == nil && hit.IntegrationID != tt.want {
+				t.Fatalf("FindTool(%q, %q) = %s, want %s", tt.tool, tt.app, hit.IntegrationID.Hex(), tt.want.Hex())
+			}
+		})
+	}
+}
+
+func TestInvalidatedIntegrationIsKeptWhileUnreadable(t *testing.T) {
+	github := &models.Integration{ID: primitive.NewObjectID(), Name: "github"}
+	provider := &staticToolProvider{tools: map[primitive.ObjectID][]models.Tool{github.ID: {{Name: "get_user"}}}}
+	integrations := &memoryIntegrationService{integrations: []*models.Integration{github}}
+	search := NewToolSearchService(integrations, provider, zap.NewNop())
+	hits := func() int {
+		t.Helper()
+		results, err := search.Search(context.Background(), ToolSearchQuery{Query: "user"})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		return len(results.Hits)
+	}
+	if n := hits(); n != 1 {
+		t.Fatalf("Search() = %d hits, want 1", n)
+	}
+
+	integrations.getErr = errors.New("connection reset")
+	search.InvalidateIntegration(github.ID)
+	if n := hits(); n != 1 {
+		t.Fatalf("Search() while the integration is unreadable = %d hits, want the indexed tool", n)
+	}
+	if !search.(*toolSearchService).dirty[github.ID] {
+		t.Fatal("an integration that could not be read is no longer marked dirty")
+	}
+
+	integrations.getErr = nil
+	integrations.integrations = nil
+	if n := hits(); n != 0 {
+		t.Fatalf("Search() after the integration was deleted = %d hits, want 0", n)
+	}
+}
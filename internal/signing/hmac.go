@@ -0,0 +1,104 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// hmacSigner signs requests with a shared secret. The signed string is
+//
+//	METHOD \n PATH?QUERY \n TIMESTAMP \n hex(sha256(body))
+//
+// and the signature, timestamp and key ID are sent in configurable headers.
+type hmacSigner struct {
+	hash            func() hash.Hash
+	header          string
+	prefix          string
+	timestampHeader string
+	keyIDHeader     string
+	base64          bool
+	now             func() time.Time
+}
+
+// newHMACSigner reads the options:
+//
+//	hash              sha256 (default) or sha512
+//	header            signature header, default X-Signature
+//	prefix            prepended to the signature value, e.g. "sha256="
+//	timestamp_header  default X-Timestamp
+//	key_id_header     default X-Key-Id, sent only when the credentials have a key ID
+//	encoding          hex (default) or base64
+func newHMACSigner(options map[string]string) (Signer, error) {
+	s := &hmacSigner{
+		hash:            sha256.New,
+		header:          optionOr(options, "header", "X-Signature"),
+		prefix:          options["prefix"],
+		timestampHeader: optionOr(options, "timestamp_header", "X-Timestamp"),
+		keyIDHeader:     optionOr(options, "key_id_header", "X-Key-Id"),
+		now:             time.Now,
+	}
+	switch optionOr(options, "hash", "sha256") {
+	case "sha256":
+	case "sha512":
+		s.hash = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported hmac hash %q", options["hash"])
+	}
+	switch optionOr(options, "encoding", "hex") {
+	case "hex":
+	case "base64":
+		s.base64 = true
+	default:
+		return nil, fmt.Errorf("unsupported hmac encoding %q", options["encoding"])
+	}
+	return s, nil
+}
+
+func (s *hmacSigner) Sign(req *http.Request, credentials Credentials) error {
+	secret := credentials.Secret
+	if secret == "" {
+		secret = credentials.APIKey
+	}
+	if secret == "" {
+		return errors.New("hmac scheme requires a secret")
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		return fmt.Errorf("failed to read request body for signing: %w", err)
+	}
+	bodyHash := sha256.Sum256(body)
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+
+	mac := hmac.New(s.hash, []byte(secret))
+	mac.Write([]byte(req.Method + "\n" + req.URL.RequestURI() + "\n" + timestamp + "\n" + hex.EncodeToString(bodyHash[:])))
+	sum := mac.Sum(nil)
+
+	signature := hex.EncodeToString(sum)
+	if s.base64 {
+		signature = base64.StdEncoding.EncodeToString(sum)
+	}
+
+	req.Header.Set(s.header, s.prefix+signature)
+	req.Header.Set(s.timestampHeader, timestamp)
+	if credentials.KeyID != "" {
+		req.Header.Set(s.keyIDHeader, credentials.KeyID)
+	}
+	return nil
+}
+
+func optionOr(options map[string]string, key, fallback string) string {
+	if value := options[key]; value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,79 @@
+package signing
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// apiKeySigner sends the API key in a header, query parameter or cookie.
+type apiKeySigner struct {
+	in   string
+	name string
+}
+
+// newAPIKeySigner reads the "in" (header, query or cookie) and "name" options.
+// They default to the X-API-Key header.
+func newAPIKeySigner(options map[string]string) (Signer, error) {
+	s := &apiKeySigner{in: strings.ToLower(options["in"]), name: options["name"]}
+	if s.in == "" {
+		s.in = "header"
+	}
+	if s.name == "" {
+		s.name = "X-API-Key"
+	}
+	switch s.in {
+	case "header", "query", "cookie":
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unsupported api key location %q", s.in)
+	}
+}
+
+func (s *apiKeySigner) Sign(req *http.Request, credentials Credentials) error {
+	key := credentials.APIKey
+	if key == "" {
+		key = credentials.Token
+	}
+	if key == "" {
+		return errors.New("api key scheme requires an API key")
+	}
+	switch s.in {
+	case "query":
+		query := req.URL.Query()
+		query.Set(s.name, key)
+		req.URL.RawQuery = query.Encode()
+	case "cookie":
+		req.AddCookie(&http.Cookie{Name: s.name, Value: key})
+	default:
+		req.Header.Set(s.name, key)
+	}
+	return nil
+}
+
+// signBasic uses HTTP basic auth. A shared API key of the form "user:password" is accepted too.
+func signBasic(req *http.Request, credentials Credentials) error {
+	username, password := credentials.Username, credentials.Password
+	if username == "" && credentials.APIKey != "" {
+		username, password, _ = strings.Cut(credentials.APIKey, ":")
+	}
+	if username == "" {
+		return errors.New("basic scheme requires a username")
+	}
+	req.SetBasicAuth(username, password)
+	return nil
+}
+
+// signBearer sends the token, or the API key when there is none, as a bearer token.
+func signBearer(req *http.Request, credentials Credentials) error {
+	token := credentials.Token
+	if token == "" {
+		token = credentials.APIKey
+	}
+	if token == "" {
+		return errors.New("bearer scheme requires a token")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
@@ -0,0 +1,120 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHMACSigner(t *testing.T) {
+	signer, err := New(TypeHMAC, map[string]string{"encoding": "base64", "prefix": "v1=", "header": "X-Sig"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer.(*hmacSigner).now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	// A body without GetBody must still be readable after signing.
+	req := httptest.NewRequest(http.MethodPost, "https://api.example.com/v1/items?b=2&a=1", io.NopCloser(strings.NewReader(`{"name":"x"}`)))
+	req.GetBody = nil
+	if err := signer.Sign(req, Credentials{Secret: "s3cret", KeyID: "key-1"}); err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	bodyHash := sha256.Sum256([]byte(`{"name":"x"}`))
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte("POST\n/v1/items?b=2&a=1\n1700000000\n" + hex.EncodeToString(bodyHash[:])))
+	want := "v1=" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	if got := req.Header.Get("X-Sig"); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+	if req.Header.Get("X-Timestamp") != "1700000000" || req.Header.Get("X-Key-Id") != "key-1" {
+		t.Errorf("headers = %v, want the timestamp and key ID", req.Header)
+	}
+	if body, _ := io.ReadAll(req.Body); string(body) != `{"name":"x"}` {
+		t.Errorf("body after signing = %q", body)
+	}
+
+	if err := signer.Sign(httptest.NewRequest(http.MethodGet, "https://api.example.com/", nil), Credentials{}); err == nil {
+		t.Error("Sign() without a secret succeeded")
+	}
+	if _, err := New(TypeHMAC, map[string]string{"hash": "md5"}); err == nil {
+		t.Error("New() accepted an unsupported hash")
+	}
+}
+
+// TestSigV4Signer uses the get-vanilla case of the AWS Signature Version 4 test suite.
+func TestSigV4Signer(t *testing.T) {
+	signer, err := New(TypeAWSSigV4, map[string]string{"region": "us-east-1", "service": "service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer.(*sigV4Signer).now = func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	credentials := Credentials{KeyID: "AKIDEXAMPLE", Secret: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	if err := signer.Sign(req, credentials); err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+
+	if _, err := New(TypeAWSSigV4, map[string]string{"region": "us-east-1"}); err == nil {
+		t.Error("New() without a service succeeded")
+	}
+}
+
+func TestAPIKeySigner(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]string
+		check   func(*http.Request) string
+	}{
+		{"default header", nil, func(r *http.Request) string { return r.Header.Get("X-API-Key") }},
+		{"query", map[string]string{"in": "query", "name": "key"}, func(r *http.Request) string { return r.URL.Query().Get("key") }},
+		{"cookie", map[string]string{"in": "cookie", "name": "session"}, func(r *http.Request) string {
+			cookie, err := r.Cookie("session")
+			if err != nil {
+				return ""
+			}
+			return cookie.Value
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := New(TypeAPIKey, tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "https://api.example.com/items", nil)
+			if err := signer.Sign(req, Credentials{APIKey: "k-1"}); err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.check(req); got != "k-1" {
+				t.Errorf("API key = %q, want k-1", got)
+			}
+		})
+	}
+}
+
+func TestBasicSignerAcceptsSharedKey(t *testing.T) {
+	signer, _ := New(TypeBasic, nil)
+	req := httptest.NewRequest(http.MethodGet, "https://api.example.com/", nil)
+	if err := signer.Sign(req, Credentials{APIKey: "user:pass"}); err != nil {
+		t.Fatal(err)
+	}
+	if username, password, ok := req.BasicAuth(); !ok || username != "user" || password != "pass" {
+		t.Errorf("basic auth = %q, %q, %v", username, password, ok)
+	}
+}
@@ -1,19 +1,23 @@
 package types
 
 import (
+	"errors"
+	"fmt"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/bsontype"
 
 	"github.com/AkashKesav/API2SDK/internal/crypto"
 )
 
-// EncryptedString is a string that is automatically encrypted when stored in MongoDB.
+// EncryptedString is a string that is automatically encrypted when stored in MongoDB, with
+// envelope encryption under the active key of the default keyring.
 type EncryptedString string
 
 // MarshalBSONValue implements the bson.ValueMarshaler interface.
 func (es EncryptedString) MarshalBSONValue() (bsontype.Type, []byte, error) {
 	if es == "" {
-		return bsontype.String, []byte(""), nil
+		return bson.MarshalValue("")
 	}
 	encrypted, err := crypto.Encrypt(string(es))
 	if err != nil {
@@ -22,28 +26,34 @@ func (es EncryptedString) MarshalBSONValue() (bsontype.Type, []byte, error) {
 	return bson.MarshalValue(encrypted)
 }
 
-// UnmarshalBSONValue implements the bson.ValueUnmarshaler interface.
+// UnmarshalBSONValue implements the bson.ValueUnmarshaler interface. Values that are not
+// encrypted, which were stored before encryption was introduced, are read as plaintext unless
+// the keyring is strict; values that fail to decrypt are always an error.
 func (es *EncryptedString) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
 	if t == bsontype.Null {
 		*es = ""
 		return nil
 	}
-	var encrypted string
-	if err := bson.Unmarshal(data, &encrypted); err != nil {
-		// Attempt to handle raw string values gracefully.
-		if t == bsontype.String {
-			*es = EncryptedString(string(data))
-			return nil
-		}
-		return err
+	encrypted, ok := bson.RawValue{Type: t, Value: data}.StringValueOK()
+	if !ok {
+		return fmt.Errorf("cannot decode BSON %s into an EncryptedString", t)
+	}
+	if encrypted == "" {
+		*es = ""
+		return nil
 	}
-	decrypted, err := crypto.Decrypt(encrypted)
+	keyring, err := crypto.DefaultKeyring()
 	if err != nil {
-		// If decryption fails, it might be a raw (unencrypted) value.
-		// For backward compatibility, we can choose to return the raw value.
+		return err
+	}
+	decrypted, err := keyring.Decrypt(encrypted)
+	if errors.Is(err, crypto.ErrNotCiphertext) && !keyring.Strict() {
 		*es = EncryptedString(encrypted)
 		return nil
 	}
+	if err != nil {
+		return fmt.Errorf("cannot decrypt EncryptedString: %w", err)
+	}
 	*es = EncryptedString(decrypted)
 	return nil
 }
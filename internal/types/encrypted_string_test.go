@@ -0,0 +1,73 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/AkashKesav/API2SDK/internal/crypto"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type secretDocument struct {
+	Secret EncryptedString `bson:"secret"`
+}
+
+func useKeyring(t *testing.T, strict bool) *crypto.Keyring {
+	t.Helper()
+	keyring, err := crypto.NewKeyring("k1", map[string]string{"k1": "0123456789abcdef0123456789abcdef"}, strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crypto.SetDefaultKeyring(keyring)
+	t.Cleanup(func() { crypto.SetDefaultKeyring(nil) })
+	return keyring
+}
+
+func TestEncryptedStringRoundTrip(t *testing.T) {
+	useKeyring(t, true)
+
+	data, err := bson.Marshal(secretDocument{Secret: "s3cret"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var stored bson.M
+	_ = bson.Unmarshal(data, &stored)
+	if stored["secret"] == "s3cret" {
+		t.Fatal("Marshal() stored the plaintext")
+	}
+	var decoded secretDocument
+	if err := bson.Unmarshal(data, &decoded); err != nil || decoded.Secret != "s3cret" {
+		t.Errorf("Unmarshal() = %q, %v, want the plaintext", decoded.Secret, err)
+	}
+}
+
+func TestEncryptedStringPlaintextFallback(t *testing.T) {
+	plaintext, _ := bson.Marshal(bson.M{"secret": "stored before encryption"})
+
+	useKeyring(t, false)
+	var decoded secretDocument
+	if err := bson.Unmarshal(plaintext, &decoded); err != nil || decoded.Secret != "stored before encryption" {
+		t.Errorf("Unmarshal() of plaintext = %q, %v, want the raw value", decoded.Secret, err)
+	}
+
+	useKeyring(t, true)
+	if err := bson.Unmarshal(plaintext, &decoded); !errors.Is(err, crypto.ErrNotCiphertext) {
+		t.Errorf("Unmarshal() of plaintext in strict mode error = %v, want ErrNotCiphertext", err)
+	}
+}
+
+func TestEncryptedStringUndecryptableValue(t *testing.T) {
+	other, err := crypto.NewKeyring("k2", map[string]string{"k2": "fedcba9876543210fedcba9876543210"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, _ := other.Encrypt("s3cret")
+	data, _ := bson.Marshal(bson.M{"secret": ciphertext})
+
+	// Values that are encrypted are never read as plaintext, strict or not
+	useKeyring(t, false)
+	var decoded secretDocument
+	if err := bson.Unmarshal(data, &decoded); !errors.Is(err, crypto.ErrUnknownKeyID) {
+		t.Errorf("Unmarshal() error = %v, want ErrUnknownKeyID", err)
+	}
+}
@@ -146,9 +146,6 @@ func (cv *ConfigValidator) ValidateEnvironment() error {
 	cv.errors = make([]string, 0)
 	cv.warnings = make([]string, 0)
 
-	// Check required external tools
-	cv.checkExternalTool("mcpgen", "MCP generation will not work")
-
 	// Check optional external tools
 	cv.checkOptionalTool("openapi-generator-cli", "OpenAPI generator features may be limited")
 
@@ -131,7 +131,7 @@ func ZipDirectory(sourceDir string, targetZipPath string) error {
 		return fmt.Errorf("ZipDirectory: failed to get absolute path for sourceDir %s: %w", sourceDir, err)
 	}
 
-	err = filepath.Walk(sourceDir, func(filePath string, info os.FileInfo, err error) error {
+	err = filepath.Walk(cleanSourceDir, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return fmt.Errorf("ZipDirectory: error accessing path %s: %w", filePath, err)
 		}
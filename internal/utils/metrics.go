@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -95,9 +96,16 @@ func (mc *MetricsCollector) getOrCreateMetric(name string, metricType MetricType
 
 // getMetricKey generates a unique key for a metric based on name and labels
 func (mc *MetricsCollector) getMetricKey(name string, labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	// Sort so the same labels always map to the same metric
+	sort.Strings(names)
+
 	key := name
-	for k, v := range labels {
-		key += ":" + k + "=" + v
+	for _, k := range names {
+		key += ":" + k + "=" + labels[k]
 	}
 	return key
 }
@@ -287,8 +287,9 @@ type SDKGenerationRequest struct {
 // MCPGenerationRequest validates MCP generation input
 type MCPGenerationRequest struct {
 	CollectionID string `json:"collectionId" validate:"required,mongodb_id"`
-	Transport    string `json:"transport" validate:"required,oneof=stdio web streamable-http"`
+	Transport    string `json:"transport" validate:"required,oneof=stdio sse web streamable-http"`
 	Port         int    `json:"port,omitempty" validate:"omitempty,min=1024,max=65535"`
+	Language     string `json:"language,omitempty" validate:"omitempty,oneof=go typescript"`
 }
 
 // LoginRequest validates login input